@@ -17,6 +17,7 @@ import (
 	"github.com/tranmh/gassigeher/internal/handlers"
 	"github.com/tranmh/gassigeher/internal/logging"
 	"github.com/tranmh/gassigeher/internal/middleware"
+	"github.com/tranmh/gassigeher/internal/models"
 	"github.com/tranmh/gassigeher/internal/repository"
 	"github.com/tranmh/gassigeher/internal/services"
 	"github.com/tranmh/gassigeher/internal/static"
@@ -65,6 +66,16 @@ func main() {
 	// Load configuration
 	cfg := config.Load()
 
+	// Forwarding headers are only trusted from these proxies when resolving client IPs
+	if err := logging.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
+	// Bundled breached passwords plus the optional list of the operator
+	if err := services.LoadBreachedPasswords(cfg.PasswordBreachListFile); err != nil {
+		log.Fatalf("Invalid PASSWORD_BREACH_LIST_FILE: %v", err)
+	}
+
 	// Initialize database with multi-database support
 	dbConfig := cfg.GetDBConfig()
 	db, dialect, err := database.InitializeWithConfig(dbConfig)
@@ -110,7 +121,22 @@ func main() {
 	settingsHandler := handlers.NewSettingsHandler(db, cfg)
 	experienceHandler := handlers.NewExperienceRequestHandler(db, cfg)
 	reactivationHandler := handlers.NewReactivationRequestHandler(db, cfg)
+	invitationHandler := handlers.NewInvitationHandler(db, cfg)
+	registrationHandler := handlers.NewRegistrationRequestHandler(db, cfg)
 	dashboardHandler := handlers.NewDashboardHandler(db, cfg)
+	emailTemplateHandler := handlers.NewEmailTemplateHandler(db, cfg)
+	notificationPreferenceHandler := handlers.NewNotificationPreferenceHandler(db, cfg)
+	telegramHandler := handlers.NewTelegramHandler(db, cfg)
+	pushSubscriptionHandler := handlers.NewPushSubscriptionHandler(db, cfg)
+	notificationHandler := handlers.NewNotificationHandler(db, cfg)
+	announcementHandler := handlers.NewAnnouncementHandler(db, cfg)
+	runSheetHandler := handlers.NewRunSheetHandler(db, cfg)
+	webhookHandler := handlers.NewWebhookHandler(db, cfg)
+	auditLogHandler := handlers.NewAuditLogHandler(db, cfg)
+	roleHandler := handlers.NewRoleHandler(db, cfg)
+	twoFactorHandler := handlers.NewTwoFactorHandler(db, cfg)
+	passkeyHandler := handlers.NewPasskeyHandler(db, cfg)
+	sessionHandler := handlers.NewSessionHandler(db, cfg)
 	healthHandler := handlers.NewHealthHandler()
 	router.HandleFunc("/api/health", healthHandler.Health).Methods("GET")
 
@@ -130,25 +156,71 @@ func main() {
 	cronService.Start()
 	defer cronService.Stop()
 
+	// Start event dispatcher for notifications, activity tracking and webhooks
+	eventDispatcher := services.NewEventDispatcherFromConfig(db, cfg)
+	eventDispatcher.Start()
+	defer eventDispatcher.Stop()
+
 	// Version endpoint (public)
 	router.HandleFunc("/api/version", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(version.Get())
 	}).Methods("GET")
 
-	// Public routes
-	router.HandleFunc("/api/auth/register", authHandler.Register).Methods("POST")
-	router.HandleFunc("/api/auth/verify-email", authHandler.VerifyEmail).Methods("POST")
-	// BUG FIX #6: Add rate limiting to login endpoint
-	loginRoute := router.PathPrefix("/api/auth/login").Subrouter()
-	loginRoute.Use(middleware.RateLimitLogin)
-	loginRoute.HandleFunc("", authHandler.Login).Methods("POST")
-	// DONE: BUG #6 - Rate limiting applied to login
-	router.HandleFunc("/api/auth/forgot-password", authHandler.ForgotPassword).Methods("POST")
-	router.HandleFunc("/api/auth/reset-password", authHandler.ResetPassword).Methods("POST")
+	// Public routes, rate limited per client IP with counters stored in the database
+	rateLimiter := services.NewRateLimitService(db)
+	rateLimit := func(policy models.RateLimitPolicy, handler http.HandlerFunc) http.Handler {
+		return middleware.RateLimit(rateLimiter, policy)(handler)
+	}
+	router.Handle("/api/auth/register", rateLimit(models.RateLimitRegister, authHandler.Register)).Methods("POST")
+	// Registration mode (open, invite_only, approval_required) for the registration page
+	router.HandleFunc("/api/auth/registration", authHandler.RegistrationStatus).Methods("GET")
+	router.Handle("/api/auth/verify-email", rateLimit(models.RateLimitVerifyEmail, authHandler.VerifyEmail)).Methods("POST")
+	// Failed password logins also lock the account, see AuthHandler.Login
+	router.Handle("/api/auth/login", rateLimit(models.RateLimitLogin, authHandler.Login)).Methods("POST")
+	// Second login step with the challenge token from login
+	twoFactorRoute := router.PathPrefix("/api/auth/2fa").Subrouter()
+	twoFactorRoute.Use(middleware.RateLimit(rateLimiter, models.RateLimitTwoFactor))
+	twoFactorRoute.HandleFunc("/verify", authHandler.VerifyTwoFactor).Methods("POST")
+	twoFactorRoute.HandleFunc("/setup", authHandler.SetupTwoFactorLogin).Methods("POST")
+	twoFactorRoute.HandleFunc("/setup/confirm", authHandler.ConfirmTwoFactorLogin).Methods("POST")
+	// Login with a passkey instead of email and password
+	passkeyRoute := router.PathPrefix("/api/auth/passkey").Subrouter()
+	passkeyRoute.Use(middleware.RateLimit(rateLimiter, models.RateLimitPasskey))
+	passkeyRoute.HandleFunc("/begin", authHandler.PasskeyLoginBegin).Methods("POST")
+	passkeyRoute.HandleFunc("/finish", authHandler.PasskeyLoginFinish).Methods("POST")
+	// Login with a link sent by email, if enabled in the settings
+	router.HandleFunc("/api/auth/magic-link", authHandler.MagicLinkStatus).Methods("GET")
+	magicLinkRoute := router.PathPrefix("/api/auth/magic-link").Subrouter()
+	magicLinkRoute.Use(middleware.RateLimit(rateLimiter, models.RateLimitMagicLink))
+	magicLinkRoute.HandleFunc("", authHandler.RequestMagicLink).Methods("POST")
+	magicLinkRoute.HandleFunc("/verify", authHandler.LoginWithMagicLink).Methods("POST")
+	// Single sign-on with the OpenID Connect provider, login and callback are browser redirects
+	router.HandleFunc("/api/auth/oidc", authHandler.OIDCStatus).Methods("GET")
+	oidcRoute := router.PathPrefix("/api/auth/oidc").Subrouter()
+	oidcRoute.Use(middleware.RateLimit(rateLimiter, models.RateLimitOIDC))
+	oidcRoute.HandleFunc("/login", authHandler.OIDCLogin).Methods("GET")
+	oidcRoute.HandleFunc("/callback", authHandler.OIDCCallback).Methods("GET")
+	oidcRoute.HandleFunc("/finish", authHandler.LoginWithOIDC).Methods("POST")
+	// Requesting and using reset links share one limit
+	router.Handle("/api/auth/forgot-password", rateLimit(models.RateLimitPassword, authHandler.ForgotPassword)).Methods("POST")
+	router.Handle("/api/auth/reset-password", rateLimit(models.RateLimitPassword, authHandler.ResetPassword)).Methods("POST")
+	// Refresh and logout are authenticated by the refresh token in the body, the access token may have expired
+	router.HandleFunc("/api/auth/refresh", authHandler.RefreshToken).Methods("POST")
+	router.HandleFunc("/api/auth/logout", authHandler.Logout).Methods("POST")
 
 	// Reactivation request (public - for deactivated users)
-	router.HandleFunc("/api/reactivation-requests", reactivationHandler.CreateRequest).Methods("POST")
+	router.Handle("/api/reactivation-requests", rateLimit(models.RateLimitReactivation, reactivationHandler.CreateRequest)).Methods("POST")
+
+	// Public unsubscribe links (signed token, RFC 8058 one-click via POST)
+	router.HandleFunc("/api/unsubscribe", notificationPreferenceHandler.GetUnsubscribe).Methods("GET")
+	router.HandleFunc("/api/unsubscribe", notificationPreferenceHandler.Unsubscribe).Methods("POST")
+
+	// Telegram bot webhook (verified via secret token header)
+	router.HandleFunc("/api/telegram/webhook", telegramHandler.Webhook).Methods("POST")
+
+	// Web Push application server key (public)
+	router.HandleFunc("/api/push/vapid-public-key", pushSubscriptionHandler.GetPublicKey).Methods("GET")
 
 	// Booking time routes (public - for time slot availability)
 	router.HandleFunc("/api/booking-times/available", bookingTimeHandler.GetAvailableSlots).Methods("GET")
@@ -160,16 +232,48 @@ func main() {
 
 	// Protected routes (authenticated users)
 	protected := router.PathPrefix("/api").Subrouter()
-	protected.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	protected.Use(middleware.AuthMiddleware(cfg.JWTSecret, repository.NewUserRepository(db)))
+	// Changes made by admins are recorded in the audit log, including admin actions on user routes
+	protected.Use(middleware.AuditMiddleware(services.NewAuditService(db)))
 
 	// Auth
 	protected.HandleFunc("/auth/change-password", authHandler.ChangePassword).Methods("PUT")
+	protected.HandleFunc("/auth/logout-all", authHandler.LogoutAll).Methods("POST")
 
 	// Users
 	protected.HandleFunc("/users/me", userHandler.GetMe).Methods("GET")
 	protected.HandleFunc("/users/me", userHandler.UpdateMe).Methods("PUT")
 	protected.HandleFunc("/users/me/photo", userHandler.UploadPhoto).Methods("POST")
 	protected.HandleFunc("/users/me", userHandler.DeleteAccount).Methods("DELETE")
+	protected.HandleFunc("/users/me/notification-preferences", notificationPreferenceHandler.GetPreferences).Methods("GET")
+	protected.HandleFunc("/users/me/notification-preferences", notificationPreferenceHandler.UpdatePreferences).Methods("PUT")
+	protected.HandleFunc("/users/me/reminder-schedule", notificationPreferenceHandler.GetReminderSchedule).Methods("GET")
+	protected.HandleFunc("/users/me/reminder-schedule", notificationPreferenceHandler.UpdateReminderSchedule).Methods("PUT")
+	protected.HandleFunc("/users/me/reminder-schedule", notificationPreferenceHandler.ResetReminderSchedule).Methods("DELETE")
+	protected.HandleFunc("/users/me/notification-channels", notificationPreferenceHandler.GetChannels).Methods("GET")
+	protected.HandleFunc("/users/me/telegram-link", telegramHandler.CreateLink).Methods("POST")
+	protected.HandleFunc("/users/me/telegram-link", telegramHandler.DeleteLink).Methods("DELETE")
+	protected.HandleFunc("/users/me/push-subscriptions", pushSubscriptionHandler.ListSubscriptions).Methods("GET")
+	protected.HandleFunc("/users/me/push-subscriptions", pushSubscriptionHandler.Subscribe).Methods("POST")
+	protected.HandleFunc("/users/me/push-subscriptions/{id}", pushSubscriptionHandler.DeleteSubscription).Methods("DELETE")
+	protected.HandleFunc("/users/me/2fa", twoFactorHandler.GetStatus).Methods("GET")
+	protected.HandleFunc("/users/me/2fa/setup", twoFactorHandler.Setup).Methods("POST")
+	protected.HandleFunc("/users/me/2fa/enable", twoFactorHandler.Enable).Methods("POST")
+	protected.HandleFunc("/users/me/2fa/disable", twoFactorHandler.Disable).Methods("POST")
+	protected.HandleFunc("/users/me/2fa/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes).Methods("POST")
+	protected.HandleFunc("/users/me/passkeys", passkeyHandler.ListPasskeys).Methods("GET")
+	protected.HandleFunc("/users/me/passkeys/register/begin", passkeyHandler.BeginRegistration).Methods("POST")
+	protected.HandleFunc("/users/me/passkeys/register/finish", passkeyHandler.FinishRegistration).Methods("POST")
+	protected.HandleFunc("/users/me/passkeys/{id}", passkeyHandler.RenamePasskey).Methods("PUT")
+	protected.HandleFunc("/users/me/passkeys/{id}", passkeyHandler.DeletePasskey).Methods("DELETE")
+	protected.HandleFunc("/users/me/sessions", sessionHandler.ListSessions).Methods("GET")
+	protected.HandleFunc("/users/me/sessions/{id}", sessionHandler.RevokeSession).Methods("DELETE")
+
+	// Notification center routes
+	protected.HandleFunc("/notifications", notificationHandler.ListNotifications).Methods("GET")
+	protected.HandleFunc("/notifications/unread-count", notificationHandler.GetUnreadCount).Methods("GET")
+	protected.HandleFunc("/notifications/read-all", notificationHandler.MarkAllRead).Methods("PUT")
+	protected.HandleFunc("/notifications/{id}/read", notificationHandler.MarkRead).Methods("PUT")
 
 	// Dogs (read-only for authenticated users)
 	protected.HandleFunc("/dogs", dogHandler.ListDogs).Methods("GET")
@@ -191,69 +295,110 @@ func main() {
 	protected.HandleFunc("/experience-requests", experienceHandler.CreateRequest).Methods("POST")
 	protected.HandleFunc("/experience-requests", experienceHandler.ListRequests).Methods("GET")
 
-	// Admin-only routes
-	admin := protected.PathPrefix("").Subrouter()
-	admin.Use(middleware.RequireAdmin)
-
-	// Dog management (admin only)
-	admin.HandleFunc("/dogs", dogHandler.CreateDog).Methods("POST")
-	admin.HandleFunc("/dogs/{id}", dogHandler.UpdateDog).Methods("PUT")
-	admin.HandleFunc("/dogs/{id}", dogHandler.DeleteDog).Methods("DELETE")
-	admin.HandleFunc("/dogs/{id}/photo", dogHandler.UploadDogPhoto).Methods("POST")
-	admin.HandleFunc("/dogs/{id}/availability", dogHandler.ToggleAvailability).Methods("PUT")
-	admin.HandleFunc("/dogs/{id}/featured", dogHandler.SetFeatured).Methods("PUT")
-
-	// Blocked dates management (admin only)
-	admin.HandleFunc("/blocked-dates", blockedDateHandler.CreateBlockedDate).Methods("POST")
-	admin.HandleFunc("/blocked-dates/{id}", blockedDateHandler.DeleteBlockedDate).Methods("DELETE")
-
-	// Booking management (admin only)
-	admin.HandleFunc("/bookings/{id}/move", bookingHandler.MoveBooking).Methods("PUT")
-
-	// System settings (admin only)
-	admin.HandleFunc("/settings", settingsHandler.GetAllSettings).Methods("GET")
-	admin.HandleFunc("/settings/{key}", settingsHandler.UpdateSetting).Methods("PUT")
-
-	// Experience requests management (admin only)
-	admin.HandleFunc("/experience-requests/{id}/approve", experienceHandler.ApproveRequest).Methods("PUT")
-	admin.HandleFunc("/experience-requests/{id}/deny", experienceHandler.DenyRequest).Methods("PUT")
-
-	// User management (admin only)
-	admin.HandleFunc("/users", userHandler.ListUsers).Methods("GET")
-	admin.HandleFunc("/users/{id}", userHandler.GetUser).Methods("GET")
-	admin.HandleFunc("/users/{id}/activate", userHandler.ActivateUser).Methods("PUT")
-	admin.HandleFunc("/users/{id}/deactivate", userHandler.DeactivateUser).Methods("PUT")
-
-	// Reactivation requests management (admin only)
-	admin.HandleFunc("/reactivation-requests", reactivationHandler.ListRequests).Methods("GET")
-	admin.HandleFunc("/reactivation-requests/{id}/approve", reactivationHandler.ApproveRequest).Methods("PUT")
-	admin.HandleFunc("/reactivation-requests/{id}/deny", reactivationHandler.DenyRequest).Methods("PUT")
-
-	// Admin dashboard (admin only)
-	admin.HandleFunc("/admin/stats", dashboardHandler.GetStats).Methods("GET")
-	admin.HandleFunc("/admin/activity", dashboardHandler.GetRecentActivity).Methods("GET")
-
-	// Booking time management (admin only)
-	admin.HandleFunc("/admin/booking-times/rules", bookingTimeHandler.GetRules).Methods("GET")
-	admin.HandleFunc("/admin/booking-times/rules", bookingTimeHandler.UpdateRules).Methods("PUT")
-	admin.HandleFunc("/admin/booking-times/rules", bookingTimeHandler.CreateRule).Methods("POST")
-	admin.HandleFunc("/admin/booking-times/rules/{id}", bookingTimeHandler.DeleteRule).Methods("DELETE")
-
-	// Holiday management (admin only)
-	admin.HandleFunc("/admin/holidays", holidayHandler.CreateHoliday).Methods("POST")
-	admin.HandleFunc("/admin/holidays/{id}", holidayHandler.UpdateHoliday).Methods("PUT")
-	admin.HandleFunc("/admin/holidays/{id}", holidayHandler.DeleteHoliday).Methods("DELETE")
-
-	// Booking approval management (admin only)
-	admin.HandleFunc("/bookings/pending-approvals", bookingHandler.GetPendingApprovals).Methods("GET")
-	admin.HandleFunc("/bookings/{id}/approve", bookingHandler.ApprovePendingBooking).Methods("PUT")
-	admin.HandleFunc("/bookings/{id}/reject", bookingHandler.RejectPendingBooking).Methods("PUT")
-
-	// DONE: Phase 4 - Super Admin routes (authenticated + admin + super admin)
-	superAdmin := admin.PathPrefix("").Subrouter()
-	superAdmin.Use(middleware.RequireSuperAdmin)
-	superAdmin.HandleFunc("/admin/users/{id}/promote", userHandler.PromoteToAdmin).Methods("POST")
-	superAdmin.HandleFunc("/admin/users/{id}/demote", userHandler.DemoteAdmin).Methods("POST")
+	// Staff routes, grouped by the permission they require (see models.BuiltinRolePermissions)
+	withPermission := func(permission string) *mux.Router {
+		group := protected.PathPrefix("").Subrouter()
+		group.Use(middleware.RequirePermission(permission))
+		return group
+	}
+
+	// Dog management
+	dogStaff := withPermission(models.PermissionManageDogs)
+	dogStaff.HandleFunc("/dogs", dogHandler.CreateDog).Methods("POST")
+	dogStaff.HandleFunc("/dogs/{id}", dogHandler.UpdateDog).Methods("PUT")
+	dogStaff.HandleFunc("/dogs/{id}", dogHandler.DeleteDog).Methods("DELETE")
+	dogStaff.HandleFunc("/dogs/{id}/photo", dogHandler.UploadDogPhoto).Methods("POST")
+	dogStaff.HandleFunc("/dogs/{id}/availability", dogHandler.ToggleAvailability).Methods("PUT")
+	dogStaff.HandleFunc("/dogs/{id}/featured", dogHandler.SetFeatured).Methods("PUT")
+
+	// Booking management and approvals
+	bookingStaff := withPermission(models.PermissionManageBookings)
+	bookingStaff.HandleFunc("/bookings/{id}/move", bookingHandler.MoveBooking).Methods("PUT")
+	bookingStaff.HandleFunc("/bookings/pending-approvals", bookingHandler.GetPendingApprovals).Methods("GET")
+	bookingStaff.HandleFunc("/bookings/{id}/approve", bookingHandler.ApprovePendingBooking).Methods("PUT")
+	bookingStaff.HandleFunc("/bookings/{id}/reject", bookingHandler.RejectPendingBooking).Methods("PUT")
+
+	// Calendar: blocked dates, booking times and holidays
+	calendarStaff := withPermission(models.PermissionManageCalendar)
+	calendarStaff.HandleFunc("/blocked-dates", blockedDateHandler.CreateBlockedDate).Methods("POST")
+	calendarStaff.HandleFunc("/blocked-dates/{id}", blockedDateHandler.DeleteBlockedDate).Methods("DELETE")
+	calendarStaff.HandleFunc("/admin/booking-times/rules", bookingTimeHandler.GetRules).Methods("GET")
+	calendarStaff.HandleFunc("/admin/booking-times/rules", bookingTimeHandler.UpdateRules).Methods("PUT")
+	calendarStaff.HandleFunc("/admin/booking-times/rules", bookingTimeHandler.CreateRule).Methods("POST")
+	calendarStaff.HandleFunc("/admin/booking-times/rules/{id}", bookingTimeHandler.DeleteRule).Methods("DELETE")
+	calendarStaff.HandleFunc("/admin/holidays", holidayHandler.CreateHoliday).Methods("POST")
+	calendarStaff.HandleFunc("/admin/holidays/{id}", holidayHandler.UpdateHoliday).Methods("PUT")
+	calendarStaff.HandleFunc("/admin/holidays/{id}", holidayHandler.DeleteHoliday).Methods("DELETE")
+
+	// User management, experience, reactivation and registration requests, invitations
+	userStaff := withPermission(models.PermissionManageUsers)
+	userStaff.HandleFunc("/experience-requests/{id}/approve", experienceHandler.ApproveRequest).Methods("PUT")
+	userStaff.HandleFunc("/experience-requests/{id}/deny", experienceHandler.DenyRequest).Methods("PUT")
+	userStaff.HandleFunc("/users", userHandler.ListUsers).Methods("GET")
+	userStaff.HandleFunc("/users/tags", userHandler.ListTags).Methods("GET")
+	userStaff.HandleFunc("/users/{id}", userHandler.GetUser).Methods("GET")
+	userStaff.HandleFunc("/users/{id}/tags", userHandler.UpdateUserTags).Methods("PUT")
+	userStaff.HandleFunc("/users/{id}/activate", userHandler.ActivateUser).Methods("PUT")
+	userStaff.HandleFunc("/users/{id}/deactivate", userHandler.DeactivateUser).Methods("PUT")
+	userStaff.HandleFunc("/users/{id}/sessions", sessionHandler.ListUserSessions).Methods("GET")
+	userStaff.HandleFunc("/users/{id}/sessions/{sessionId}", sessionHandler.RevokeUserSession).Methods("DELETE")
+	userStaff.HandleFunc("/reactivation-requests", reactivationHandler.ListRequests).Methods("GET")
+	userStaff.HandleFunc("/reactivation-requests/{id}/approve", reactivationHandler.ApproveRequest).Methods("PUT")
+	userStaff.HandleFunc("/reactivation-requests/{id}/deny", reactivationHandler.DenyRequest).Methods("PUT")
+	userStaff.HandleFunc("/registration-requests", registrationHandler.ListRequests).Methods("GET")
+	userStaff.HandleFunc("/registration-requests/{id}/approve", registrationHandler.ApproveRequest).Methods("PUT")
+	userStaff.HandleFunc("/registration-requests/{id}/deny", registrationHandler.DenyRequest).Methods("PUT")
+	userStaff.HandleFunc("/invitations", invitationHandler.ListInvitations).Methods("GET")
+	userStaff.HandleFunc("/invitations", invitationHandler.CreateInvitation).Methods("POST")
+	userStaff.HandleFunc("/invitations/{id}", invitationHandler.RevokeInvitation).Methods("DELETE")
+
+	// Announcements
+	announcementStaff := withPermission(models.PermissionManageAnnouncements)
+	announcementStaff.HandleFunc("/admin/announcements", announcementHandler.ListAnnouncements).Methods("GET")
+	announcementStaff.HandleFunc("/admin/announcements", announcementHandler.CreateAnnouncement).Methods("POST")
+	announcementStaff.HandleFunc("/admin/announcements/preview", announcementHandler.PreviewAnnouncement).Methods("POST")
+	announcementStaff.HandleFunc("/admin/announcements/{id}", announcementHandler.GetAnnouncement).Methods("GET")
+	announcementStaff.HandleFunc("/admin/announcements/{id}", announcementHandler.CancelAnnouncement).Methods("DELETE")
+
+	// System settings, email templates and webhooks
+	settingsStaff := withPermission(models.PermissionManageSettings)
+	settingsStaff.HandleFunc("/settings", settingsHandler.GetAllSettings).Methods("GET")
+	settingsStaff.HandleFunc("/settings/{key}", settingsHandler.UpdateSetting).Methods("PUT")
+	settingsStaff.HandleFunc("/admin/email-templates", emailTemplateHandler.ListTemplates).Methods("GET")
+	settingsStaff.HandleFunc("/admin/email-templates/{key}", emailTemplateHandler.GetTemplate).Methods("GET")
+	settingsStaff.HandleFunc("/admin/email-templates/{key}", emailTemplateHandler.UpdateTemplate).Methods("PUT")
+	settingsStaff.HandleFunc("/admin/email-templates/{key}", emailTemplateHandler.ResetTemplate).Methods("DELETE")
+	settingsStaff.HandleFunc("/admin/email-templates/{key}/preview", emailTemplateHandler.PreviewTemplate).Methods("POST")
+	settingsStaff.HandleFunc("/admin/webhooks", webhookHandler.ListWebhooks).Methods("GET")
+	settingsStaff.HandleFunc("/admin/webhooks", webhookHandler.CreateWebhook).Methods("POST")
+	settingsStaff.HandleFunc("/admin/webhooks/{id}", webhookHandler.UpdateWebhook).Methods("PUT")
+	settingsStaff.HandleFunc("/admin/webhooks/{id}", webhookHandler.DeleteWebhook).Methods("DELETE")
+	settingsStaff.HandleFunc("/admin/webhooks/{id}/rotate-secret", webhookHandler.RotateWebhookSecret).Methods("POST")
+	settingsStaff.HandleFunc("/admin/webhooks/{id}/deliveries", webhookHandler.ListWebhookDeliveries).Methods("GET")
+
+	// Admin dashboard, activity feed and run sheet
+	dashboardStaff := withPermission(models.PermissionViewDashboard)
+	dashboardStaff.HandleFunc("/admin/stats", dashboardHandler.GetStats).Methods("GET")
+	dashboardStaff.HandleFunc("/admin/activity", dashboardHandler.GetRecentActivity).Methods("GET")
+	dashboardStaff.HandleFunc("/admin/run-sheet", runSheetHandler.GetRunSheet).Methods("GET")
+
+	// Roles and admin promotion (super admin)
+	roleStaff := withPermission(models.PermissionManageRoles)
+	roleStaff.HandleFunc("/admin/roles", roleHandler.ListRoles).Methods("GET")
+	roleStaff.HandleFunc("/admin/roles", roleHandler.CreateRole).Methods("POST")
+	roleStaff.HandleFunc("/admin/roles/{id}", roleHandler.UpdateRole).Methods("PUT")
+	roleStaff.HandleFunc("/admin/roles/{id}", roleHandler.DeleteRole).Methods("DELETE")
+	roleStaff.HandleFunc("/admin/users/{id}/roles", roleHandler.GetUserRoles).Methods("GET")
+	roleStaff.HandleFunc("/admin/users/{id}/roles", roleHandler.UpdateUserRoles).Methods("PUT")
+	roleStaff.HandleFunc("/admin/users/{id}/promote", userHandler.PromoteToAdmin).Methods("POST")
+	roleStaff.HandleFunc("/admin/users/{id}/demote", userHandler.DemoteAdmin).Methods("POST")
+	roleStaff.HandleFunc("/admin/users/{id}/2fa", twoFactorHandler.ResetUser).Methods("DELETE")
+
+	// Audit log (super admin)
+	auditStaff := withPermission(models.PermissionViewAuditLog)
+	auditStaff.HandleFunc("/admin/audit-log", auditLogHandler.ListAuditLog).Methods("GET")
+	auditStaff.HandleFunc("/admin/audit-log/export", auditLogHandler.ExportAuditLog).Methods("GET")
+	auditStaff.HandleFunc("/admin/audit-log/verify", auditLogHandler.VerifyAuditLog).Methods("GET")
 
 	// Uploads directory (user photos, dog photos) - must remain on filesystem
 	router.PathPrefix("/uploads/").Handler(http.StripPrefix("/uploads/", http.FileServer(http.Dir("./uploads"))))
@@ -274,6 +419,9 @@ func main() {
 	router.HandleFunc("/forgot-password", func(w http.ResponseWriter, r *http.Request) {
 		serveEmbeddedFile(w, r, frontendFS, "forgot-password.html")
 	}).Methods("GET")
+	router.HandleFunc("/unsubscribe", func(w http.ResponseWriter, r *http.Request) {
+		serveEmbeddedFile(w, r, frontendFS, "unsubscribe.html")
+	}).Methods("GET")
 
 	// Static files from embedded frontend
 	router.PathPrefix("/").Handler(http.FileServer(http.FS(frontendFS)))
@@ -1,9 +1,11 @@
 package config
 
 import (
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/tranmh/gassigeher/internal/database"
 )
@@ -34,7 +36,14 @@ type Config struct {
 
 	// JWT
 	JWTSecret          string
-	JWTExpirationHours int
+	JWTExpirationHours int // Lifetime of a login (refresh token), extended on every refresh
+	AccessTokenMinutes int // Lifetime of access tokens
+
+	// Password policy (see services.PasswordPolicy)
+	PasswordMinLength      int    // Minimum length, at least 8
+	PasswordMinScore       int    // Minimum strength score from 0 (any) to 4 (very strong)
+	PasswordBreachCheck    bool   // Reject passwords found in the list of breached passwords
+	PasswordBreachListFile string // Additional SHA-1 hashes of breached passwords, one per line
 
 	// Super Admin (DONE: replaces ADMIN_EMAILS)
 	SuperAdminEmail string
@@ -60,6 +69,36 @@ type Config struct {
 	// BCC Admin Copy (works with all providers)
 	EmailBCCAdmin string
 
+	// Announcements are sent at most at this rate to stay within provider limits (0 = unthrottled)
+	AnnouncementEmailsPerMinute int
+
+	// SMS Gateway (generic HTTP gateway, disabled when URL is empty)
+	SMSGatewayURL   string
+	SMSGatewayToken string
+	SMSSender       string
+
+	// Telegram Bot (disabled when token is empty)
+	TelegramBotToken      string
+	TelegramBotUsername   string
+	TelegramAPIURL        string
+	TelegramWebhookSecret string
+
+	// Web Push (VAPID keys are generated and stored in the database if not set)
+	WebPushEnabled  bool
+	VAPIDPublicKey  string
+	VAPIDPrivateKey string
+	VAPIDSubject    string // mailto: or https: contact for push services
+
+	// OpenID Connect single sign-on (disabled when the issuer is empty)
+	OIDCIssuerURL    string
+	OIDCClientID     string
+	OIDCClientSecret string
+	OIDCRedirectURL  string // Defaults to BaseURL + /api/auth/oidc/callback
+	OIDCScopes       string // Space separated, openid is always requested
+	OIDCProviderName string // Label of the login button
+	OIDCGroupsClaim  string // ID token claim with the groups of the user
+	OIDCGroupRoles   string // Comma separated group=role pairs, e.g. "shelter-admins=admin,kennel=kennel_staff"
+
 	// Uploads
 	UploadDir       string
 	MaxUploadSizeMB int
@@ -70,8 +109,9 @@ type Config struct {
 	AutoDeactivationDays    int
 
 	// Server
-	Port    string
-	BaseURL string // Base URL for email links (e.g., "https://gassigeher.com")
+	Port           string
+	BaseURL        string // Base URL for email links (e.g., "https://gassigeher.com")
+	TrustedProxies string // Reverse proxies whose X-Forwarded-For is believed, comma separated IPs or CIDRs
 }
 
 // Load loads configuration from environment variables
@@ -100,6 +140,13 @@ func Load() *Config {
 		// JWT
 		JWTSecret:          getEnv("JWT_SECRET", "change-this-in-production"),
 		JWTExpirationHours: getEnvAsInt("JWT_EXPIRATION_HOURS", 24),
+		AccessTokenMinutes: getEnvAsInt("ACCESS_TOKEN_MINUTES", 15),
+
+		// Password policy
+		PasswordMinLength:      getEnvAsInt("PASSWORD_MIN_LENGTH", 8),
+		PasswordMinScore:       getEnvAsInt("PASSWORD_MIN_SCORE", 3),
+		PasswordBreachCheck:    getEnvAsBool("PASSWORD_BREACH_CHECK", true),
+		PasswordBreachListFile: getEnv("PASSWORD_BREACH_LIST_FILE", ""),
 
 		// Super Admin (DONE: replaces ADMIN_EMAILS)
 		SuperAdminEmail: getEnv("SUPER_ADMIN_EMAIL", ""),
@@ -125,6 +172,36 @@ func Load() *Config {
 		// BCC Admin Copy
 		EmailBCCAdmin: getEnv("EMAIL_BCC_ADMIN", ""),
 
+		// Announcement throttling
+		AnnouncementEmailsPerMinute: getEnvAsInt("ANNOUNCEMENT_EMAILS_PER_MINUTE", 30),
+
+		// SMS Gateway
+		SMSGatewayURL:   getEnv("SMS_GATEWAY_URL", ""),
+		SMSGatewayToken: getEnv("SMS_GATEWAY_TOKEN", ""),
+		SMSSender:       getEnv("SMS_SENDER", "Gassigeher"),
+
+		// Telegram Bot
+		TelegramBotToken:      getEnv("TELEGRAM_BOT_TOKEN", ""),
+		TelegramBotUsername:   getEnv("TELEGRAM_BOT_USERNAME", ""),
+		TelegramAPIURL:        getEnv("TELEGRAM_API_URL", "https://api.telegram.org"),
+		TelegramWebhookSecret: getEnv("TELEGRAM_WEBHOOK_SECRET", ""),
+
+		// Web Push
+		WebPushEnabled:  getEnvAsBool("WEB_PUSH_ENABLED", true),
+		VAPIDPublicKey:  getEnv("VAPID_PUBLIC_KEY", ""),
+		VAPIDPrivateKey: getEnv("VAPID_PRIVATE_KEY", ""),
+		VAPIDSubject:    getEnv("VAPID_SUBJECT", ""),
+
+		// OpenID Connect
+		OIDCIssuerURL:    getEnv("OIDC_ISSUER_URL", ""),
+		OIDCClientID:     getEnv("OIDC_CLIENT_ID", ""),
+		OIDCClientSecret: getEnv("OIDC_CLIENT_SECRET", ""),
+		OIDCRedirectURL:  getEnv("OIDC_REDIRECT_URL", ""),
+		OIDCScopes:       getEnv("OIDC_SCOPES", "openid email profile"),
+		OIDCProviderName: getEnv("OIDC_PROVIDER_NAME", "Single Sign-On"),
+		OIDCGroupsClaim:  getEnv("OIDC_GROUPS_CLAIM", "groups"),
+		OIDCGroupRoles:   getEnv("OIDC_GROUP_ROLES", ""),
+
 		// Uploads
 		UploadDir:       getEnv("UPLOAD_DIR", "./uploads"),
 		MaxUploadSizeMB: getEnvAsInt("MAX_UPLOAD_SIZE_MB", 5),
@@ -135,9 +212,90 @@ func Load() *Config {
 		AutoDeactivationDays:    getEnvAsInt("AUTO_DEACTIVATION_DAYS", 365),
 
 		// Server
-		Port:    getEnv("PORT", "8080"),
-		BaseURL: getEnv("BASE_URL", "http://localhost:8080"),
+		Port:           getEnv("PORT", "8080"),
+		BaseURL:        getEnv("BASE_URL", "http://localhost:8080"),
+		TrustedProxies: getEnv("TRUSTED_PROXIES", "127.0.0.0/8,::1"),
+	}
+}
+
+// GetAccessTokenMinutes returns the lifetime of access tokens, 15 minutes when not configured
+func (c *Config) GetAccessTokenMinutes() int {
+	if c.AccessTokenMinutes <= 0 {
+		return 15
+	}
+	return c.AccessTokenMinutes
+}
+
+// GetPasswordMinLength returns the minimum password length, never less than 8
+func (c *Config) GetPasswordMinLength() int {
+	if c.PasswordMinLength < 8 {
+		return 8
+	}
+	return c.PasswordMinLength
+}
+
+// GetRefreshTokenLifetime returns how long a login lasts without a refresh, 24 hours when not configured
+func (c *Config) GetRefreshTokenLifetime() time.Duration {
+	if c.JWTExpirationHours <= 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(c.JWTExpirationHours) * time.Hour
+}
+
+// GetWebAuthnOrigin returns the origin passkey ceremonies must come from, scheme and host of BaseURL
+func (c *Config) GetWebAuthnOrigin() string {
+	u, err := url.Parse(c.BaseURL)
+	if err != nil || u.Host == "" {
+		return "http://localhost:8080"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// GetWebAuthnRPID returns the relying party ID passkeys are bound to, the host name of BaseURL
+func (c *Config) GetWebAuthnRPID() string {
+	u, err := url.Parse(c.BaseURL)
+	if err != nil || u.Hostname() == "" {
+		return "localhost"
+	}
+	return u.Hostname()
+}
+
+// OIDCEnabled reports whether the single sign-on with an OpenID Connect provider is configured
+func (c *Config) OIDCEnabled() bool {
+	return c.OIDCIssuerURL != "" && c.OIDCClientID != ""
+}
+
+// GetOIDCRedirectURL returns the callback the provider redirects to after the login
+func (c *Config) GetOIDCRedirectURL() string {
+	if c.OIDCRedirectURL != "" {
+		return c.OIDCRedirectURL
+	}
+	return strings.TrimRight(c.BaseURL, "/") + "/api/auth/oidc/callback"
+}
+
+// GetOIDCScopes returns the requested scopes, always including openid
+func (c *Config) GetOIDCScopes() []string {
+	scopes := []string{"openid"}
+	for _, scope := range strings.Fields(c.OIDCScopes) {
+		if scope != "openid" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// GetOIDCGroupRoles parses OIDCGroupRoles into the roles granted by each group
+func (c *Config) GetOIDCGroupRoles() map[string][]string {
+	groupRoles := map[string][]string{}
+	for _, pair := range strings.Split(c.OIDCGroupRoles, ",") {
+		group, role, ok := strings.Cut(pair, "=")
+		group, role = strings.TrimSpace(group), strings.TrimSpace(role)
+		if !ok || group == "" || role == "" {
+			continue
+		}
+		groupRoles[group] = append(groupRoles[group], role)
 	}
+	return groupRoles
 }
 
 // GetDBConfig builds a database configuration from the application config
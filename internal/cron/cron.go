@@ -2,45 +2,82 @@ package cron
 
 import (
 	"database/sql"
-	"fmt"
 	"log"
 	"strconv"
 	"time"
 
 	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
 	"github.com/tranmh/gassigeher/internal/repository"
 	"github.com/tranmh/gassigeher/internal/services"
 )
 
+// sessionHistoryDays is how long ended sessions are kept to recognize known devices
+const sessionHistoryDays = 90
+
 // CronService handles scheduled tasks
 type CronService struct {
-	db           *sql.DB
-	bookingRepo  *repository.BookingRepository
-	userRepo     *repository.UserRepository
-	settingsRepo *repository.SettingsRepository
-	emailService *services.EmailService
-	stopChan     chan bool
+	db              *sql.DB
+	bookingRepo     *repository.BookingRepository
+	userRepo        *repository.UserRepository
+	tokenRepo       *repository.RefreshTokenRepository
+	twoFactorRepo   *repository.TwoFactorRepository
+	passkeyRepo     *repository.PasskeyRepository
+	magicLinkRepo   *repository.MagicLinkRepository
+	oidcRepo        *repository.OIDCRepository
+	rateLimits      *services.RateLimitService
+	sessionRepo     *repository.SessionRepository
+	settingsRepo    *repository.SettingsRepository
+	reminderService *services.ReminderService
+	notifier        *services.NotificationService // Reminders and account notices on all channels
+	announcements   *services.AnnouncementService // nil = no config
+	runSheets       *services.RunSheetService     // nil = no config
+	webhooks        *services.WebhookService
+	outbox          *services.OutboxService
+	stopChan        chan bool
 }
 
 // NewCronService creates a new cron service
 func NewCronService(db *sql.DB, cfg *config.Config) *CronService {
 	// Initialize email service for reminders (fail gracefully if not configured)
 	var emailService *services.EmailService
+	var notifier *services.NotificationService
+	var announcements *services.AnnouncementService
+	var runSheets *services.RunSheetService
 	if cfg != nil {
 		var err error
-		emailService, err = services.NewEmailService(services.ConfigToEmailConfig(cfg))
+		emailService, err = services.NewEmailServiceFromConfig(db, cfg)
 		if err != nil {
 			log.Printf("Warning: Email service not available for cron jobs: %v", err)
 		}
+		notifier = services.NewNotificationServiceFromConfig(db, cfg, emailService)
+		announcements = services.NewAnnouncementServiceFromConfig(db, cfg, emailService)
+		runSheets = services.NewRunSheetService(db, emailService)
 	}
 
+	bookingRepo := repository.NewBookingRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	settingsRepo := repository.NewSettingsRepository(db)
+
 	return &CronService{
-		db:           db,
-		bookingRepo:  repository.NewBookingRepository(db),
-		userRepo:     repository.NewUserRepository(db),
-		settingsRepo: repository.NewSettingsRepository(db),
-		emailService: emailService,
-		stopChan:     make(chan bool),
+		db:              db,
+		bookingRepo:     bookingRepo,
+		userRepo:        userRepo,
+		tokenRepo:       repository.NewRefreshTokenRepository(db),
+		twoFactorRepo:   repository.NewTwoFactorRepository(db),
+		passkeyRepo:     repository.NewPasskeyRepository(db),
+		magicLinkRepo:   repository.NewMagicLinkRepository(db),
+		oidcRepo:        repository.NewOIDCRepository(db),
+		rateLimits:      services.NewRateLimitService(db),
+		sessionRepo:     repository.NewSessionRepository(db),
+		settingsRepo:    settingsRepo,
+		reminderService: services.NewReminderService(bookingRepo, repository.NewBookingReminderRepository(db), userRepo, settingsRepo),
+		notifier:        notifier,
+		announcements:   announcements,
+		runSheets:       runSheets,
+		webhooks:        services.NewWebhookService(db),
+		outbox:          services.NewOutboxService(db),
+		stopChan:        make(chan bool),
 	}
 }
 
@@ -56,6 +93,24 @@ func (s *CronService) Start() {
 
 	// Run booking reminder job every 15 minutes
 	go s.runPeriodically("Send booking reminders", 15*time.Minute, s.sendBookingReminders)
+
+	// Send scheduled announcements every minute (also resumes announcements interrupted by a restart)
+	go s.runPeriodically("Send announcements", time.Minute, s.sendAnnouncements)
+
+	// Check every 5 minutes whether the daily run sheet digest is due (sent once per day)
+	go s.runPeriodically("Send run sheet digest", 5*time.Minute, s.sendRunSheetDigest)
+
+	// Deliver queued webhook events and retries every 30 seconds
+	go s.runPeriodically("Deliver webhooks", 30*time.Second, s.deliverWebhooks)
+
+	// Remove expired refresh tokens daily at 4am
+	go s.runDaily("Delete expired refresh tokens", 4, 0, s.deleteExpiredRefreshTokens)
+	go s.runDaily("Delete expired two-factor challenges", 4, 0, s.deleteExpiredTwoFactorChallenges)
+	go s.runDaily("Delete expired passkey challenges", 4, 0, s.deleteExpiredPasskeyChallenges)
+	go s.runDaily("Delete expired magic links", 4, 0, s.deleteExpiredMagicLinks)
+	go s.runDaily("Delete expired OIDC logins", 4, 0, s.deleteExpiredOIDCLogins)
+	go s.runDaily("Delete expired rate limits", 4, 0, s.deleteExpiredRateLimits)
+	go s.runDaily("Delete stale sessions", 4, 0, s.deleteStaleSessions)
 }
 
 // Stop stops all cron jobs
@@ -84,9 +139,27 @@ func (s *CronService) runPeriodically(name string, interval time.Duration, fn fu
 	}
 }
 
-// autoCompleteBookings marks past scheduled bookings as completed
+// autoCompleteBookings marks past scheduled bookings as completed and records a booking.completed event for each
 func (s *CronService) autoCompleteBookings() {
-	count, err := s.bookingRepo.AutoComplete()
+	count := 0
+	err := s.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		bookings, err := s.bookingRepo.WithTx(tx).CompleteDue(time.Now())
+		if err != nil {
+			return nil, err
+		}
+
+		events := make([]*models.DomainEvent, 0, len(bookings))
+		for _, booking := range bookings {
+			event, err := models.NewBookingEvent(models.EventBookingCompleted, nil,
+				models.NewBookingEventData(booking, booking.Dog.Name, booking.User.Name))
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, event)
+		}
+		count = len(bookings)
+		return events, nil
+	})
 	if err != nil {
 		log.Printf("Error auto-completing bookings: %v", err)
 		return
@@ -99,36 +172,97 @@ func (s *CronService) autoCompleteBookings() {
 	}
 }
 
-// sendBookingReminders sends reminders for upcoming bookings (1-2 hours before)
+// sendAnnouncements sends admin announcements whose scheduled time has come
+func (s *CronService) sendAnnouncements() {
+	if s.announcements == nil {
+		return
+	}
+
+	count, err := s.announcements.ProcessDue(time.Now())
+	if err != nil {
+		log.Printf("Error sending announcements: %v", err)
+		return
+	}
+
+	if count > 0 {
+		log.Printf("Sent %d announcement(s)", count)
+	}
+}
+
+// sendRunSheetDigest emails today's run sheet to staff once the configured send time has passed
+func (s *CronService) sendRunSheetDigest() {
+	if s.runSheets == nil {
+		return
+	}
+
+	sent, err := s.runSheets.SendDigest(time.Now())
+	if err != nil {
+		log.Printf("Error sending run sheet digest: %v", err)
+		return
+	}
+
+	if sent {
+		log.Println("Sent daily run sheet digest")
+	}
+}
+
+// deliverWebhooks delivers queued webhook events whose next attempt is due
+func (s *CronService) deliverWebhooks() {
+	count, err := s.webhooks.ProcessDue(time.Now())
+	if err != nil {
+		log.Printf("Error delivering webhooks: %v", err)
+		return
+	}
+
+	if count > 0 {
+		log.Printf("Delivered %d webhook event(s)", count)
+	}
+}
+
+// sendBookingReminders sends due reminders according to the configured schedules
+// Reminders are claimed before sending, so restarts and overlapping runs never send duplicates
 func (s *CronService) sendBookingReminders() {
-	// Check if email service is available
-	if s.emailService == nil {
-		log.Println("Reminder check: email service not configured, skipping")
+	// Check if notifications are available
+	if s.notifier == nil {
+		log.Println("Reminder check: notifications not configured, skipping")
 		return
 	}
 
-	// Get bookings that need reminders
-	bookings, err := s.bookingRepo.GetForReminders()
+	// Get bookings with due reminders
+	reminders, err := s.reminderService.DueReminders(time.Now())
 	if err != nil {
 		log.Printf("Error getting bookings for reminders: %v", err)
 		return
 	}
 
-	if len(bookings) == 0 {
+	if len(reminders) == 0 {
 		log.Println("Reminder check: no reminders to send")
 		return
 	}
 
-	log.Printf("Found %d booking(s) that need reminders", len(bookings))
+	log.Printf("Found %d booking(s) that need reminders", len(reminders))
 
 	// Send reminder for each booking
-	for _, booking := range bookings {
+	for _, reminder := range reminders {
+		booking := reminder.Booking
+
 		// Skip if user has no email
 		if booking.User == nil || booking.User.Email == nil {
 			log.Printf("Skipping reminder for booking %d: no user email", booking.ID)
 			continue
 		}
 
+		// Claim before sending
+		claimed, err := s.reminderService.Claim(reminder)
+		if err != nil {
+			log.Printf("Error claiming reminder for booking %d: %v", booking.ID, err)
+			continue
+		}
+		if !claimed {
+			log.Printf("Skipping reminder for booking %d: already sent", booking.ID)
+			continue
+		}
+
 		// Skip if dog name is missing
 		dogName := "Unbekannter Hund"
 		if booking.Dog != nil && booking.Dog.Name != "" {
@@ -141,8 +275,8 @@ func (s *CronService) sendBookingReminders() {
 			formattedDate = t.Format("02.01.2006")
 		}
 
-		// Send reminder email
-		err := s.emailService.SendBookingReminder(
+		// Send reminder on all enabled channels
+		err = s.notifier.SendBookingReminder(
 			*booking.User.Email,
 			booking.User.Name,
 			dogName,
@@ -152,17 +286,15 @@ func (s *CronService) sendBookingReminders() {
 
 		if err != nil {
 			log.Printf("Error sending reminder for booking %d: %v", booking.ID, err)
+			// Release the claim so the next run retries
+			if err := s.reminderService.Release(reminder); err != nil {
+				log.Printf("Error releasing reminder for booking %d: %v", booking.ID, err)
+			}
 			continue
 		}
 
-		// Mark reminder as sent
-		if err := s.bookingRepo.MarkReminderSent(booking.ID); err != nil {
-			log.Printf("Error marking reminder sent for booking %d: %v", booking.ID, err)
-			continue
-		}
-
-		log.Printf("Sent reminder for booking %d (user: %s, dog: %s, time: %s %s)",
-			booking.ID, booking.User.Name, dogName, formattedDate, booking.ScheduledTime)
+		log.Printf("Sent reminder %v for booking %d (user: %s, dog: %s, time: %s %s)",
+			reminder.Keys, booking.ID, booking.User.Name, dogName, formattedDate, booking.ScheduledTime)
 	}
 }
 
@@ -195,6 +327,92 @@ func (s *CronService) runDaily(name string, hour, minute int, fn func()) {
 	}
 }
 
+// deleteExpiredRefreshTokens removes refresh tokens that can no longer be used
+func (s *CronService) deleteExpiredRefreshTokens() {
+	deleted, err := s.tokenRepo.DeleteExpired(time.Now())
+	if err != nil {
+		log.Printf("Error deleting expired refresh tokens: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("Deleted %d expired refresh token(s)", deleted)
+	}
+}
+
+// deleteExpiredTwoFactorChallenges removes second login steps that were not completed
+func (s *CronService) deleteExpiredTwoFactorChallenges() {
+	deleted, err := s.twoFactorRepo.DeleteExpiredChallenges(time.Now())
+	if err != nil {
+		log.Printf("Error deleting expired two-factor challenges: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("Deleted %d expired two-factor challenge(s)", deleted)
+	}
+}
+
+// deleteExpiredPasskeyChallenges removes challenges of passkey registrations and logins that were not completed
+func (s *CronService) deleteExpiredPasskeyChallenges() {
+	deleted, err := s.passkeyRepo.DeleteExpiredChallenges(time.Now())
+	if err != nil {
+		log.Printf("Error deleting expired passkey challenges: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("Deleted %d expired passkey challenge(s)", deleted)
+	}
+}
+
+// deleteExpiredMagicLinks removes login links that can no longer be used
+func (s *CronService) deleteExpiredMagicLinks() {
+	deleted, err := s.magicLinkRepo.DeleteExpired(time.Now())
+	if err != nil {
+		log.Printf("Error deleting expired magic links: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("Deleted %d expired magic link(s)", deleted)
+	}
+}
+
+// deleteExpiredOIDCLogins removes single sign-on logins that were not completed
+func (s *CronService) deleteExpiredOIDCLogins() {
+	deleted, err := s.oidcRepo.DeleteExpiredLogins(time.Now())
+	if err != nil {
+		log.Printf("Error deleting expired OIDC logins: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("Deleted %d expired OIDC login(s)", deleted)
+	}
+}
+
+// deleteExpiredRateLimits removes expired request counters and old failed logins of unlocked accounts
+func (s *CronService) deleteExpiredRateLimits() {
+	deleted, err := s.rateLimits.DeleteExpired()
+	if err != nil {
+		log.Printf("Error deleting expired rate limits: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("Deleted %d expired rate limit(s)", deleted)
+	}
+}
+
+// deleteStaleSessions removes sessions that ended more than sessionHistoryDays ago
+// Until then they tell which devices a user logged in with, see TokenService.Issue.
+func (s *CronService) deleteStaleSessions() {
+	now := time.Now()
+	deleted, err := s.sessionRepo.DeleteStale(now.AddDate(0, 0, -sessionHistoryDays), now)
+	if err != nil {
+		log.Printf("Error deleting stale sessions: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("Deleted %d stale session(s)", deleted)
+	}
+}
+
 // autoDeactivateInactiveUsers deactivates users who haven't been active for the configured period
 func (s *CronService) autoDeactivateInactiveUsers() {
 	// Get deactivation period from settings
@@ -225,19 +443,24 @@ func (s *CronService) autoDeactivateInactiveUsers() {
 
 	log.Printf("Found %d inactive user(s) to deactivate", len(users))
 
-	// Deactivate each user
+	// Deactivate each user, users are notified through the user.deactivated events
 	for _, user := range users {
-		if err := s.userRepo.Deactivate(user.ID, "auto_inactivity"); err != nil {
+		err := s.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+			if err := s.userRepo.WithTx(tx).Deactivate(user.ID, models.DeactivationReasonInactivity); err != nil {
+				return nil, err
+			}
+			if err := s.tokenRepo.WithTx(tx).RevokeAllForUser(user.ID); err != nil {
+				return nil, err
+			}
+			event, err := models.NewDomainEvent(models.EventUserDeactivated, models.AggregateUser, user.ID, nil,
+				&models.UserDeactivatedEventData{UserID: user.ID, Name: user.Name, Reason: models.DeactivationReasonInactivity, InactiveDays: days})
+			return []*models.DomainEvent{event}, err
+		})
+		if err != nil {
 			log.Printf("Error deactivating user %d: %v", user.ID, err)
 			continue
 		}
 
 		log.Printf("Auto-deactivated user %d (inactive for %d days)", user.ID, days)
-
-		// Send email notification about deactivation
-		if s.emailService != nil && user.Email != nil {
-			reason := fmt.Sprintf("Keine Aktivität seit %d Tagen", days)
-			go s.emailService.SendAccountDeactivated(*user.Email, user.Name, reason)
-		}
 	}
 }
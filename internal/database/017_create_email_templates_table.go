@@ -0,0 +1,40 @@
+package database
+
+func init() {
+	RegisterMigration(&Migration{
+		ID:          "017_create_email_templates_table",
+		Description: "Create email_templates table for admin overrides of the embedded email templates",
+		Up: map[string]string{
+			"sqlite": `
+CREATE TABLE IF NOT EXISTS email_templates (
+  template_key TEXT PRIMARY KEY,
+  subject TEXT NOT NULL,
+  body TEXT NOT NULL,
+  updated_by INTEGER,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY (updated_by) REFERENCES users(id) ON DELETE SET NULL
+);
+`,
+			"mysql": `
+CREATE TABLE IF NOT EXISTS email_templates (
+  template_key VARCHAR(100) PRIMARY KEY,
+  subject VARCHAR(500) NOT NULL,
+  body MEDIUMTEXT NOT NULL,
+  updated_by INT,
+  updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  FOREIGN KEY (updated_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`,
+			"postgres": `
+CREATE TABLE IF NOT EXISTS email_templates (
+  template_key VARCHAR(100) PRIMARY KEY,
+  subject VARCHAR(500) NOT NULL,
+  body TEXT NOT NULL,
+  updated_by INTEGER,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY (updated_by) REFERENCES users(id) ON DELETE SET NULL
+);
+`,
+		},
+	})
+}
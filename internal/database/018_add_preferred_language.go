@@ -0,0 +1,22 @@
+package database
+
+func init() {
+	RegisterMigration(&Migration{
+		ID:          "018_add_preferred_language",
+		Description: "Add preferred_language column to users for localized emails",
+		Up: map[string]string{
+			"sqlite": `
+-- Add preferred_language column to users table (ISO 639-1 code)
+ALTER TABLE users ADD COLUMN preferred_language TEXT DEFAULT 'de';
+`,
+			"mysql": `
+-- Add preferred_language column to users table (ISO 639-1 code)
+ALTER TABLE users ADD COLUMN preferred_language VARCHAR(5) DEFAULT 'de';
+`,
+			"postgres": `
+-- Add preferred_language column to users table (ISO 639-1 code)
+ALTER TABLE users ADD COLUMN preferred_language VARCHAR(5) DEFAULT 'de';
+`,
+		},
+	})
+}
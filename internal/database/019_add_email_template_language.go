@@ -0,0 +1,39 @@
+package database
+
+func init() {
+	RegisterMigration(&Migration{
+		ID:          "019_add_email_template_language",
+		Description: "Store email template overrides per language",
+		Up: map[string]string{
+			"sqlite": `
+-- SQLite requires table recreation to change the primary key
+CREATE TABLE IF NOT EXISTS email_templates_new (
+  template_key TEXT NOT NULL,
+  language TEXT NOT NULL DEFAULT 'de',
+  subject TEXT NOT NULL,
+  body TEXT NOT NULL,
+  updated_by INTEGER,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (template_key, language),
+  FOREIGN KEY (updated_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Existing overrides were written for the German templates
+INSERT INTO email_templates_new (template_key, language, subject, body, updated_by, updated_at)
+SELECT template_key, 'de', subject, body, updated_by, updated_at FROM email_templates;
+
+DROP TABLE email_templates;
+ALTER TABLE email_templates_new RENAME TO email_templates;
+`,
+			"mysql": `
+ALTER TABLE email_templates ADD COLUMN language VARCHAR(5) NOT NULL DEFAULT 'de' AFTER template_key;
+ALTER TABLE email_templates DROP PRIMARY KEY, ADD PRIMARY KEY (template_key, language);
+`,
+			"postgres": `
+ALTER TABLE email_templates ADD COLUMN language VARCHAR(5) NOT NULL DEFAULT 'de';
+ALTER TABLE email_templates DROP CONSTRAINT IF EXISTS email_templates_pkey;
+ALTER TABLE email_templates ADD PRIMARY KEY (template_key, language);
+`,
+		},
+	})
+}
@@ -0,0 +1,46 @@
+package database
+
+func init() {
+	RegisterMigration(&Migration{
+		ID:          "020_create_notification_preferences_table",
+		Description: "Create notification_preferences table for per-user opt-outs per category and channel",
+		Up: map[string]string{
+			"sqlite": `
+-- Missing rows mean the category is enabled (opt-out model)
+CREATE TABLE IF NOT EXISTS notification_preferences (
+  user_id INTEGER NOT NULL,
+  category TEXT NOT NULL,
+  channel TEXT NOT NULL DEFAULT 'email',
+  enabled INTEGER NOT NULL DEFAULT 1,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (user_id, category, channel),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+`,
+			"mysql": `
+-- Missing rows mean the category is enabled (opt-out model)
+CREATE TABLE IF NOT EXISTS notification_preferences (
+  user_id INT NOT NULL,
+  category VARCHAR(50) NOT NULL,
+  channel VARCHAR(20) NOT NULL DEFAULT 'email',
+  enabled TINYINT(1) NOT NULL DEFAULT 1,
+  updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (user_id, category, channel),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`,
+			"postgres": `
+-- Missing rows mean the category is enabled (opt-out model)
+CREATE TABLE IF NOT EXISTS notification_preferences (
+  user_id INTEGER NOT NULL,
+  category VARCHAR(50) NOT NULL,
+  channel VARCHAR(20) NOT NULL DEFAULT 'email',
+  enabled BOOLEAN NOT NULL DEFAULT TRUE,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (user_id, category, channel),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+`,
+		},
+	})
+}
@@ -0,0 +1,82 @@
+package database
+
+func init() {
+	RegisterMigration(&Migration{
+		ID:          "021_create_booking_reminders_table",
+		Description: "Track each sent booking reminder, add reminder schedule settings, replace reminder_sent_at",
+		Up: map[string]string{
+			"sqlite": `
+-- One row per sent reminder, the primary key prevents duplicate sends
+CREATE TABLE IF NOT EXISTS booking_reminders (
+  booking_id INTEGER NOT NULL,
+  reminder_key TEXT NOT NULL,
+  sent_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (booking_id, reminder_key),
+  FOREIGN KEY (booking_id) REFERENCES bookings(id) ON DELETE CASCADE
+);
+
+-- Keep reminders sent with the old single-reminder tracking
+INSERT INTO booking_reminders (booking_id, reminder_key, sent_at)
+SELECT id, 'legacy', reminder_sent_at FROM bookings WHERE reminder_sent_at IS NOT NULL;
+
+ALTER TABLE bookings DROP COLUMN reminder_sent_at;
+
+-- Personal reminder schedule (NULL = use the default schedule)
+ALTER TABLE users ADD COLUMN reminder_schedule TEXT;
+
+INSERT OR IGNORE INTO system_settings (key, value) VALUES
+  ('booking_reminders', '1d@18:00,1h'),
+  ('reminder_max_count', '3'),
+  ('reminder_max_days_before', '3');
+`,
+			"mysql": `
+-- One row per sent reminder, the primary key prevents duplicate sends
+CREATE TABLE IF NOT EXISTS booking_reminders (
+  booking_id INT NOT NULL,
+  reminder_key VARCHAR(20) NOT NULL,
+  sent_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (booking_id, reminder_key),
+  FOREIGN KEY (booking_id) REFERENCES bookings(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Keep reminders sent with the old single-reminder tracking
+INSERT INTO booking_reminders (booking_id, reminder_key, sent_at)
+SELECT id, 'legacy', reminder_sent_at FROM bookings WHERE reminder_sent_at IS NOT NULL;
+
+ALTER TABLE bookings DROP COLUMN reminder_sent_at;
+
+-- Personal reminder schedule (NULL = use the default schedule)
+ALTER TABLE users ADD COLUMN reminder_schedule VARCHAR(255);
+
+` + "INSERT IGNORE INTO system_settings (`key`, value) VALUES\n" +
+				"  ('booking_reminders', '1d@18:00,1h'),\n" +
+				"  ('reminder_max_count', '3'),\n" +
+				"  ('reminder_max_days_before', '3');",
+			"postgres": `
+-- One row per sent reminder, the primary key prevents duplicate sends
+CREATE TABLE IF NOT EXISTS booking_reminders (
+  booking_id INTEGER NOT NULL,
+  reminder_key VARCHAR(20) NOT NULL,
+  sent_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (booking_id, reminder_key),
+  FOREIGN KEY (booking_id) REFERENCES bookings(id) ON DELETE CASCADE
+);
+
+-- Keep reminders sent with the old single-reminder tracking
+INSERT INTO booking_reminders (booking_id, reminder_key, sent_at)
+SELECT id, 'legacy', reminder_sent_at FROM bookings WHERE reminder_sent_at IS NOT NULL;
+
+ALTER TABLE bookings DROP COLUMN IF EXISTS reminder_sent_at;
+
+-- Personal reminder schedule (NULL = use the default schedule)
+ALTER TABLE users ADD COLUMN reminder_schedule VARCHAR(255);
+
+INSERT INTO system_settings (key, value) VALUES
+  ('booking_reminders', '1d@18:00,1h'),
+  ('reminder_max_count', '3'),
+  ('reminder_max_days_before', '3')
+ON CONFLICT (key) DO NOTHING;
+`,
+		},
+	})
+}
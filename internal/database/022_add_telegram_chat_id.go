@@ -0,0 +1,22 @@
+package database
+
+func init() {
+	RegisterMigration(&Migration{
+		ID:          "022_add_telegram_chat_id",
+		Description: "Add telegram_chat_id column to users for Telegram notifications",
+		Up: map[string]string{
+			"sqlite": `
+-- Chat the user linked via the Telegram bot (NULL = not linked)
+ALTER TABLE users ADD COLUMN telegram_chat_id TEXT;
+`,
+			"mysql": `
+-- Chat the user linked via the Telegram bot (NULL = not linked)
+ALTER TABLE users ADD COLUMN telegram_chat_id VARCHAR(64);
+`,
+			"postgres": `
+-- Chat the user linked via the Telegram bot (NULL = not linked)
+ALTER TABLE users ADD COLUMN telegram_chat_id VARCHAR(64);
+`,
+		},
+	})
+}
@@ -0,0 +1,52 @@
+package database
+
+func init() {
+	RegisterMigration(&Migration{
+		ID:          "024_create_notifications_table",
+		Description: "Create notifications table for the in-app notification center",
+		Up: map[string]string{
+			"sqlite": `
+-- Params are a JSON array of strings, title and message are rendered on read
+CREATE TABLE IF NOT EXISTS notifications (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL,
+  type TEXT NOT NULL,
+  params TEXT NOT NULL DEFAULT '[]',
+  link TEXT,
+  read_at TIMESTAMP,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_notifications_user_read ON notifications(user_id, read_at);
+`,
+			"mysql": `
+-- Params are a JSON array of strings, title and message are rendered on read
+CREATE TABLE IF NOT EXISTS notifications (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  user_id INT NOT NULL,
+  type VARCHAR(100) NOT NULL,
+  params TEXT NOT NULL,
+  link VARCHAR(255),
+  read_at DATETIME,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_notifications_user_read (user_id, read_at),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`,
+			"postgres": `
+-- Params are a JSON array of strings, title and message are rendered on read
+CREATE TABLE IF NOT EXISTS notifications (
+  id SERIAL PRIMARY KEY,
+  user_id INTEGER NOT NULL,
+  type VARCHAR(100) NOT NULL,
+  params TEXT NOT NULL DEFAULT '[]',
+  link VARCHAR(255),
+  read_at TIMESTAMP WITH TIME ZONE,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_notifications_user_read ON notifications(user_id, read_at);
+`,
+		},
+	})
+}
@@ -0,0 +1,127 @@
+package database
+
+func init() {
+	RegisterMigration(&Migration{
+		ID:          "025_create_announcements_tables",
+		Description: "Create user_tags for segmenting users, announcements and announcement_deliveries for admin broadcasts",
+		Up: map[string]string{
+			"sqlite": `
+-- Free-form tags admins assign to users (e.g. "volunteer-team")
+CREATE TABLE IF NOT EXISTS user_tags (
+  user_id INTEGER NOT NULL,
+  tag TEXT NOT NULL,
+  PRIMARY KEY (user_id, tag),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_user_tags_tag ON user_tags(tag);
+
+-- Segment is the JSON target filter, recipients are resolved when sending starts
+CREATE TABLE IF NOT EXISTS announcements (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  subject TEXT NOT NULL,
+  message TEXT NOT NULL,
+  segment TEXT NOT NULL,
+  status TEXT NOT NULL DEFAULT 'scheduled' CHECK(status IN ('scheduled', 'sending', 'sent', 'cancelled')),
+  scheduled_at TIMESTAMP NOT NULL,
+  created_by INTEGER,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  started_at TIMESTAMP,
+  completed_at TIMESTAMP,
+  FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS idx_announcements_status ON announcements(status, scheduled_at);
+
+-- One row per recipient, status refers to the email
+CREATE TABLE IF NOT EXISTS announcement_deliveries (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  announcement_id INTEGER NOT NULL,
+  user_id INTEGER NOT NULL,
+  status TEXT NOT NULL DEFAULT 'pending' CHECK(status IN ('pending', 'sent', 'skipped', 'failed')),
+  error TEXT,
+  processed_at TIMESTAMP,
+  UNIQUE (announcement_id, user_id),
+  FOREIGN KEY (announcement_id) REFERENCES announcements(id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+`,
+			"mysql": `
+-- Free-form tags admins assign to users (e.g. "volunteer-team")
+CREATE TABLE IF NOT EXISTS user_tags (
+  user_id INT NOT NULL,
+  tag VARCHAR(50) NOT NULL,
+  PRIMARY KEY (user_id, tag),
+  INDEX idx_user_tags_tag (tag),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Segment is the JSON target filter, recipients are resolved when sending starts
+CREATE TABLE IF NOT EXISTS announcements (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  subject VARCHAR(200) NOT NULL,
+  message TEXT NOT NULL,
+  segment TEXT NOT NULL,
+  status ENUM('scheduled', 'sending', 'sent', 'cancelled') NOT NULL DEFAULT 'scheduled',
+  scheduled_at DATETIME NOT NULL,
+  created_by INT,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+  started_at DATETIME,
+  completed_at DATETIME,
+  INDEX idx_announcements_status (status, scheduled_at),
+  FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- One row per recipient, status refers to the email
+CREATE TABLE IF NOT EXISTS announcement_deliveries (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  announcement_id INT NOT NULL,
+  user_id INT NOT NULL,
+  status ENUM('pending', 'sent', 'skipped', 'failed') NOT NULL DEFAULT 'pending',
+  error TEXT,
+  processed_at DATETIME,
+  UNIQUE KEY uniq_announcement_user (announcement_id, user_id),
+  FOREIGN KEY (announcement_id) REFERENCES announcements(id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`,
+			"postgres": `
+-- Free-form tags admins assign to users (e.g. "volunteer-team")
+CREATE TABLE IF NOT EXISTS user_tags (
+  user_id INTEGER NOT NULL,
+  tag VARCHAR(50) NOT NULL,
+  PRIMARY KEY (user_id, tag),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_user_tags_tag ON user_tags(tag);
+
+-- Segment is the JSON target filter, recipients are resolved when sending starts
+CREATE TABLE IF NOT EXISTS announcements (
+  id SERIAL PRIMARY KEY,
+  subject VARCHAR(200) NOT NULL,
+  message TEXT NOT NULL,
+  segment TEXT NOT NULL,
+  status VARCHAR(20) NOT NULL DEFAULT 'scheduled' CHECK(status IN ('scheduled', 'sending', 'sent', 'cancelled')),
+  scheduled_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  created_by INTEGER,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  started_at TIMESTAMP WITH TIME ZONE,
+  completed_at TIMESTAMP WITH TIME ZONE,
+  FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS idx_announcements_status ON announcements(status, scheduled_at);
+
+-- One row per recipient, status refers to the email
+CREATE TABLE IF NOT EXISTS announcement_deliveries (
+  id SERIAL PRIMARY KEY,
+  announcement_id INTEGER NOT NULL,
+  user_id INTEGER NOT NULL,
+  status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK(status IN ('pending', 'sent', 'skipped', 'failed')),
+  error TEXT,
+  processed_at TIMESTAMP WITH TIME ZONE,
+  UNIQUE (announcement_id, user_id),
+  FOREIGN KEY (announcement_id) REFERENCES announcements(id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+`,
+		},
+	})
+}
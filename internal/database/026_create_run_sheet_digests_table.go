@@ -0,0 +1,46 @@
+package database
+
+func init() {
+	RegisterMigration(&Migration{
+		ID:          "026_create_run_sheet_digests_table",
+		Description: "Track sent run sheet digests per day and add digest recipient and send time settings",
+		Up: map[string]string{
+			"sqlite": `
+-- One row per day, the primary key prevents sending the digest twice
+CREATE TABLE IF NOT EXISTS run_sheet_digests (
+  digest_date TEXT PRIMARY KEY,
+  sent_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- No recipients = digest disabled
+INSERT OR IGNORE INTO system_settings (key, value) VALUES
+  ('run_sheet_recipients', ''),
+  ('run_sheet_send_time', '06:30');
+`,
+			"mysql": `
+-- One row per day, the primary key prevents sending the digest twice
+CREATE TABLE IF NOT EXISTS run_sheet_digests (
+  digest_date VARCHAR(10) PRIMARY KEY,
+  sent_at DATETIME DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- No recipients = digest disabled
+` + "INSERT IGNORE INTO system_settings (`key`, value) VALUES\n" +
+				"  ('run_sheet_recipients', ''),\n" +
+				"  ('run_sheet_send_time', '06:30');",
+			"postgres": `
+-- One row per day, the primary key prevents sending the digest twice
+CREATE TABLE IF NOT EXISTS run_sheet_digests (
+  digest_date VARCHAR(10) PRIMARY KEY,
+  sent_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- No recipients = digest disabled
+INSERT INTO system_settings (key, value) VALUES
+  ('run_sheet_recipients', ''),
+  ('run_sheet_send_time', '06:30')
+ON CONFLICT (key) DO NOTHING;
+`,
+		},
+	})
+}
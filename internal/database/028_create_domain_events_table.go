@@ -0,0 +1,55 @@
+package database
+
+func init() {
+	RegisterMigration(&Migration{
+		ID:          "028_create_domain_events_table",
+		Description: "Create domain_events as transactional outbox for booking, user and dog events",
+		Up: map[string]string{
+			"sqlite": `
+-- Written in the same transaction as the state change, dispatched_at is set once all subscribers ran
+CREATE TABLE IF NOT EXISTS domain_events (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  event_type TEXT NOT NULL,
+  aggregate_type TEXT NOT NULL,
+  aggregate_id INTEGER NOT NULL,
+  actor_id INTEGER,
+  payload TEXT NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  dispatched_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_domain_events_dispatched ON domain_events(dispatched_at, id);
+CREATE INDEX IF NOT EXISTS idx_domain_events_aggregate ON domain_events(aggregate_type, aggregate_id);
+`,
+			"mysql": `
+-- Written in the same transaction as the state change, dispatched_at is set once all subscribers ran
+CREATE TABLE IF NOT EXISTS domain_events (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  event_type VARCHAR(50) NOT NULL,
+  aggregate_type VARCHAR(20) NOT NULL,
+  aggregate_id INT NOT NULL,
+  actor_id INT,
+  payload TEXT NOT NULL,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+  dispatched_at DATETIME,
+  INDEX idx_domain_events_dispatched (dispatched_at, id),
+  INDEX idx_domain_events_aggregate (aggregate_type, aggregate_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`,
+			"postgres": `
+-- Written in the same transaction as the state change, dispatched_at is set once all subscribers ran
+CREATE TABLE IF NOT EXISTS domain_events (
+  id SERIAL PRIMARY KEY,
+  event_type VARCHAR(50) NOT NULL,
+  aggregate_type VARCHAR(20) NOT NULL,
+  aggregate_id INTEGER NOT NULL,
+  actor_id INTEGER,
+  payload TEXT NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  dispatched_at TIMESTAMP WITH TIME ZONE
+);
+CREATE INDEX IF NOT EXISTS idx_domain_events_dispatched ON domain_events(dispatched_at, id);
+CREATE INDEX IF NOT EXISTS idx_domain_events_aggregate ON domain_events(aggregate_type, aggregate_id);
+`,
+		},
+	})
+}
@@ -0,0 +1,73 @@
+package database
+
+func init() {
+	RegisterMigration(&Migration{
+		ID:          "029_create_activity_log_table",
+		Description: "Create activity_log for the admin activity feed, filled from domain events",
+		Up: map[string]string{
+			"sqlite": `
+-- One row per domain event, the message is rendered from type and params when read
+-- dog_name is a snapshot because dogs can be deleted, user names are joined from users
+CREATE TABLE IF NOT EXISTS activity_log (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  event_id INTEGER NOT NULL UNIQUE,
+  type TEXT NOT NULL,
+  actor_id INTEGER,
+  user_id INTEGER,
+  dog_id INTEGER,
+  dog_name TEXT,
+  booking_id INTEGER,
+  params TEXT NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_activity_log_created ON activity_log(created_at, id);
+CREATE INDEX IF NOT EXISTS idx_activity_log_type ON activity_log(type, created_at);
+CREATE INDEX IF NOT EXISTS idx_activity_log_user ON activity_log(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_activity_log_actor ON activity_log(actor_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_activity_log_dog ON activity_log(dog_id, created_at);
+`,
+			"mysql": `
+-- One row per domain event, the message is rendered from type and params when read
+-- dog_name is a snapshot because dogs can be deleted, user names are joined from users
+CREATE TABLE IF NOT EXISTS activity_log (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  event_id INT NOT NULL UNIQUE,
+  type VARCHAR(50) NOT NULL,
+  actor_id INT,
+  user_id INT,
+  dog_id INT,
+  dog_name VARCHAR(100),
+  booking_id INT,
+  params TEXT NOT NULL,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_activity_log_created (created_at, id),
+  INDEX idx_activity_log_type (type, created_at),
+  INDEX idx_activity_log_user (user_id, created_at),
+  INDEX idx_activity_log_actor (actor_id, created_at),
+  INDEX idx_activity_log_dog (dog_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`,
+			"postgres": `
+-- One row per domain event, the message is rendered from type and params when read
+-- dog_name is a snapshot because dogs can be deleted, user names are joined from users
+CREATE TABLE IF NOT EXISTS activity_log (
+  id SERIAL PRIMARY KEY,
+  event_id INTEGER NOT NULL UNIQUE,
+  type VARCHAR(50) NOT NULL,
+  actor_id INTEGER,
+  user_id INTEGER,
+  dog_id INTEGER,
+  dog_name VARCHAR(100),
+  booking_id INTEGER,
+  params TEXT NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_activity_log_created ON activity_log(created_at, id);
+CREATE INDEX IF NOT EXISTS idx_activity_log_type ON activity_log(type, created_at);
+CREATE INDEX IF NOT EXISTS idx_activity_log_user ON activity_log(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_activity_log_actor ON activity_log(actor_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_activity_log_dog ON activity_log(dog_id, created_at);
+`,
+		},
+	})
+}
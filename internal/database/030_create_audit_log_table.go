@@ -0,0 +1,79 @@
+package database
+
+func init() {
+	RegisterMigration(&Migration{
+		ID:          "030_create_audit_log_table",
+		Description: "Create append-only, hash-chained audit_log for admin actions",
+		Up: map[string]string{
+			"sqlite": `
+-- Rows are only ever inserted, each hash covers the row and the hash of the previous row
+-- prev_hash is unique so that two writers can never append to the same chain head
+CREATE TABLE IF NOT EXISTS audit_log (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  actor_id INTEGER NOT NULL,
+  actor_email TEXT NOT NULL,
+  action TEXT NOT NULL,
+  target_type TEXT NOT NULL,
+  target_id TEXT NOT NULL,
+  before_state TEXT,
+  after_state TEXT,
+  ip_address TEXT NOT NULL,
+  request_id TEXT NOT NULL,
+  status_code INTEGER NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  prev_hash TEXT NOT NULL UNIQUE,
+  hash TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_audit_log_created ON audit_log(created_at, id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_target ON audit_log(target_type, target_id);
+`,
+			"mysql": `
+-- Rows are only ever inserted, each hash covers the row and the hash of the previous row
+-- prev_hash is unique so that two writers can never append to the same chain head
+CREATE TABLE IF NOT EXISTS audit_log (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  actor_id INT NOT NULL,
+  actor_email VARCHAR(255) NOT NULL,
+  action VARCHAR(255) NOT NULL,
+  target_type VARCHAR(50) NOT NULL,
+  target_id VARCHAR(100) NOT NULL,
+  before_state TEXT,
+  after_state TEXT,
+  ip_address VARCHAR(45) NOT NULL,
+  request_id VARCHAR(64) NOT NULL,
+  status_code INT NOT NULL,
+  created_at DATETIME NOT NULL,
+  prev_hash CHAR(64) NOT NULL UNIQUE,
+  hash CHAR(64) NOT NULL,
+  INDEX idx_audit_log_created (created_at, id),
+  INDEX idx_audit_log_actor (actor_id, created_at),
+  INDEX idx_audit_log_target (target_type, target_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`,
+			"postgres": `
+-- Rows are only ever inserted, each hash covers the row and the hash of the previous row
+-- prev_hash is unique so that two writers can never append to the same chain head
+CREATE TABLE IF NOT EXISTS audit_log (
+  id SERIAL PRIMARY KEY,
+  actor_id INTEGER NOT NULL,
+  actor_email VARCHAR(255) NOT NULL,
+  action VARCHAR(255) NOT NULL,
+  target_type VARCHAR(50) NOT NULL,
+  target_id VARCHAR(100) NOT NULL,
+  before_state TEXT,
+  after_state TEXT,
+  ip_address VARCHAR(45) NOT NULL,
+  request_id VARCHAR(64) NOT NULL,
+  status_code INTEGER NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  prev_hash CHAR(64) NOT NULL UNIQUE,
+  hash CHAR(64) NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_audit_log_created ON audit_log(created_at, id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_target ON audit_log(target_type, target_id);
+`,
+		},
+	})
+}
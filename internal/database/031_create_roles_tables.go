@@ -0,0 +1,104 @@
+package database
+
+func init() {
+	RegisterMigration(&Migration{
+		ID:          "031_create_roles_tables",
+		Description: "Create roles with named permissions and role assignments, seed the built-in roles",
+		Up: map[string]string{
+			"sqlite": `
+-- Permissions of built-in roles are defined in code (models.BuiltinRolePermissions),
+-- role_permissions only holds those of custom roles
+-- admin and super_admin follow users.is_admin and users.is_super_admin, so existing admins keep their access
+CREATE TABLE IF NOT EXISTS roles (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name TEXT NOT NULL UNIQUE,
+  description TEXT NOT NULL DEFAULT '',
+  is_builtin INTEGER NOT NULL DEFAULT 0,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS role_permissions (
+  role_id INTEGER NOT NULL,
+  permission TEXT NOT NULL,
+  PRIMARY KEY (role_id, permission),
+  FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS user_roles (
+  user_id INTEGER NOT NULL,
+  role_id INTEGER NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (user_id, role_id),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_user_roles_role ON user_roles(role_id);
+INSERT OR IGNORE INTO roles (name, description, is_builtin) VALUES
+  ('super_admin', 'Voller Zugriff inklusive Rollen und Audit-Log', 1),
+  ('admin', 'Verwaltung von Hunden, Buchungen, Benutzern und Einstellungen', 1),
+  ('kennel_staff', 'Hunde verwalten', 1),
+  ('scheduler', 'Buchungen genehmigen, verschieben und stornieren', 1);
+`,
+			"mysql": `
+-- Permissions of built-in roles are defined in code (models.BuiltinRolePermissions),
+-- role_permissions only holds those of custom roles
+-- admin and super_admin follow users.is_admin and users.is_super_admin, so existing admins keep their access
+CREATE TABLE IF NOT EXISTS roles (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  name VARCHAR(50) NOT NULL UNIQUE,
+  description VARCHAR(255) NOT NULL DEFAULT '',
+  is_builtin TINYINT(1) NOT NULL DEFAULT 0,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+CREATE TABLE IF NOT EXISTS role_permissions (
+  role_id INT NOT NULL,
+  permission VARCHAR(50) NOT NULL,
+  PRIMARY KEY (role_id, permission),
+  FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+CREATE TABLE IF NOT EXISTS user_roles (
+  user_id INT NOT NULL,
+  role_id INT NOT NULL,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (user_id, role_id),
+  INDEX idx_user_roles_role (role_id),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+INSERT IGNORE INTO roles (name, description, is_builtin) VALUES
+  ('super_admin', 'Voller Zugriff inklusive Rollen und Audit-Log', 1),
+  ('admin', 'Verwaltung von Hunden, Buchungen, Benutzern und Einstellungen', 1),
+  ('kennel_staff', 'Hunde verwalten', 1),
+  ('scheduler', 'Buchungen genehmigen, verschieben und stornieren', 1);
+`,
+			"postgres": `
+-- Permissions of built-in roles are defined in code (models.BuiltinRolePermissions),
+-- role_permissions only holds those of custom roles
+-- admin and super_admin follow users.is_admin and users.is_super_admin, so existing admins keep their access
+CREATE TABLE IF NOT EXISTS roles (
+  id SERIAL PRIMARY KEY,
+  name VARCHAR(50) NOT NULL UNIQUE,
+  description VARCHAR(255) NOT NULL DEFAULT '',
+  is_builtin BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS role_permissions (
+  role_id INTEGER NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+  permission VARCHAR(50) NOT NULL,
+  PRIMARY KEY (role_id, permission)
+);
+CREATE TABLE IF NOT EXISTS user_roles (
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  role_id INTEGER NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (user_id, role_id)
+);
+CREATE INDEX IF NOT EXISTS idx_user_roles_role ON user_roles(role_id);
+INSERT INTO roles (name, description, is_builtin) VALUES
+  ('super_admin', 'Voller Zugriff inklusive Rollen und Audit-Log', TRUE),
+  ('admin', 'Verwaltung von Hunden, Buchungen, Benutzern und Einstellungen', TRUE),
+  ('kennel_staff', 'Hunde verwalten', TRUE),
+  ('scheduler', 'Buchungen genehmigen, verschieben und stornieren', TRUE)
+ON CONFLICT (name) DO NOTHING;
+`,
+		},
+	})
+}
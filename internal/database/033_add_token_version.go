@@ -0,0 +1,22 @@
+package database
+
+func init() {
+	RegisterMigration(&Migration{
+		ID:          "033_add_token_version",
+		Description: "Add token_version column to users to invalidate issued access tokens",
+		Up: map[string]string{
+			"sqlite": `
+-- Access tokens carry the version they were issued with, increasing it invalidates them
+ALTER TABLE users ADD COLUMN token_version INTEGER NOT NULL DEFAULT 0;
+`,
+			"mysql": `
+-- Access tokens carry the version they were issued with, increasing it invalidates them
+ALTER TABLE users ADD COLUMN token_version INT NOT NULL DEFAULT 0;
+`,
+			"postgres": `
+-- Access tokens carry the version they were issued with, increasing it invalidates them
+ALTER TABLE users ADD COLUMN token_version INTEGER NOT NULL DEFAULT 0;
+`,
+		},
+	})
+}
@@ -0,0 +1,112 @@
+package database
+
+func init() {
+	RegisterMigration(&Migration{
+		ID:          "034_create_two_factor_tables",
+		Description: "Create tables for TOTP two-factor authentication, recovery codes and login challenges",
+		Up: map[string]string{
+			"sqlite": `
+-- TOTP secret of a user, enabled_at stays NULL until the first code was confirmed
+-- last_used_step is the 30 second time step of the last accepted code, codes cannot be replayed
+CREATE TABLE IF NOT EXISTS user_totp (
+  user_id INTEGER PRIMARY KEY,
+  secret TEXT NOT NULL,
+  enabled_at TIMESTAMP,
+  last_used_step INTEGER NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NOT NULL,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Single-use recovery codes, only the SHA-256 hash is stored
+CREATE TABLE IF NOT EXISTS recovery_codes (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL,
+  code_hash TEXT NOT NULL,
+  used_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user ON recovery_codes(user_id);
+
+-- Second login step after the password was checked, identified by the hash of a short-lived token
+CREATE TABLE IF NOT EXISTS two_factor_challenges (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL,
+  token_hash TEXT NOT NULL UNIQUE,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  expires_at TIMESTAMP NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_two_factor_challenges_expires ON two_factor_challenges(expires_at);
+`,
+			"mysql": `
+-- TOTP secret of a user, enabled_at stays NULL until the first code was confirmed
+-- last_used_step is the 30 second time step of the last accepted code, codes cannot be replayed
+CREATE TABLE IF NOT EXISTS user_totp (
+  user_id INT PRIMARY KEY,
+  secret VARCHAR(64) NOT NULL,
+  enabled_at DATETIME NULL,
+  last_used_step BIGINT NOT NULL DEFAULT 0,
+  created_at DATETIME NOT NULL,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Single-use recovery codes, only the SHA-256 hash is stored
+CREATE TABLE IF NOT EXISTS recovery_codes (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  user_id INT NOT NULL,
+  code_hash CHAR(64) NOT NULL,
+  used_at DATETIME NULL,
+  created_at DATETIME NOT NULL,
+  INDEX idx_recovery_codes_user (user_id),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Second login step after the password was checked, identified by the hash of a short-lived token
+CREATE TABLE IF NOT EXISTS two_factor_challenges (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  user_id INT NOT NULL,
+  token_hash CHAR(64) NOT NULL UNIQUE,
+  attempts INT NOT NULL DEFAULT 0,
+  expires_at DATETIME NOT NULL,
+  created_at DATETIME NOT NULL,
+  INDEX idx_two_factor_challenges_expires (expires_at),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`,
+			"postgres": `
+-- TOTP secret of a user, enabled_at stays NULL until the first code was confirmed
+-- last_used_step is the 30 second time step of the last accepted code, codes cannot be replayed
+CREATE TABLE IF NOT EXISTS user_totp (
+  user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  secret VARCHAR(64) NOT NULL,
+  enabled_at TIMESTAMP WITH TIME ZONE,
+  last_used_step BIGINT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Single-use recovery codes, only the SHA-256 hash is stored
+CREATE TABLE IF NOT EXISTS recovery_codes (
+  id SERIAL PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  code_hash CHAR(64) NOT NULL,
+  used_at TIMESTAMP WITH TIME ZONE,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user ON recovery_codes(user_id);
+
+-- Second login step after the password was checked, identified by the hash of a short-lived token
+CREATE TABLE IF NOT EXISTS two_factor_challenges (
+  id SERIAL PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  token_hash CHAR(64) NOT NULL UNIQUE,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_two_factor_challenges_expires ON two_factor_challenges(expires_at);
+`,
+		},
+	})
+}
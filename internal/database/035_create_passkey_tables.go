@@ -0,0 +1,98 @@
+package database
+
+func init() {
+	RegisterMigration(&Migration{
+		ID:          "035_create_passkey_tables",
+		Description: "Create tables for passkeys (WebAuthn credentials) and their ceremony challenges",
+		Up: map[string]string{
+			"sqlite": `
+-- Passkey of a user, credential_id and public_key (COSE) are base64url encoded
+-- sign_count detects cloned authenticators, it must increase with every login unless it stays 0
+CREATE TABLE IF NOT EXISTS passkeys (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL,
+  credential_id TEXT NOT NULL UNIQUE,
+  public_key TEXT NOT NULL,
+  sign_count INTEGER NOT NULL DEFAULT 0,
+  name TEXT NOT NULL,
+  transports TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL,
+  last_used_at TIMESTAMP,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_passkeys_user ON passkeys(user_id);
+
+-- Challenge of a registration or login ceremony, used once
+-- user_id is NULL for logins, the user is known from the passkey
+CREATE TABLE IF NOT EXISTS webauthn_challenges (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER,
+  challenge TEXT NOT NULL UNIQUE,
+  ceremony TEXT NOT NULL CHECK(ceremony IN ('registration', 'login')),
+  expires_at TIMESTAMP NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_webauthn_challenges_expires ON webauthn_challenges(expires_at);
+`,
+			"mysql": `
+-- Passkey of a user, credential_id and public_key (COSE) are base64url encoded
+-- sign_count detects cloned authenticators, it must increase with every login unless it stays 0
+CREATE TABLE IF NOT EXISTS passkeys (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  user_id INT NOT NULL,
+  credential_id VARCHAR(255) NOT NULL UNIQUE,
+  public_key TEXT NOT NULL,
+  sign_count BIGINT NOT NULL DEFAULT 0,
+  name VARCHAR(100) NOT NULL,
+  transports VARCHAR(255) NOT NULL DEFAULT '',
+  created_at DATETIME NOT NULL,
+  last_used_at DATETIME NULL,
+  INDEX idx_passkeys_user (user_id),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Challenge of a registration or login ceremony, used once
+-- user_id is NULL for logins, the user is known from the passkey
+CREATE TABLE IF NOT EXISTS webauthn_challenges (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  user_id INT NULL,
+  challenge VARCHAR(64) NOT NULL UNIQUE,
+  ceremony ENUM('registration', 'login') NOT NULL,
+  expires_at DATETIME NOT NULL,
+  created_at DATETIME NOT NULL,
+  INDEX idx_webauthn_challenges_expires (expires_at),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`,
+			"postgres": `
+-- Passkey of a user, credential_id and public_key (COSE) are base64url encoded
+-- sign_count detects cloned authenticators, it must increase with every login unless it stays 0
+CREATE TABLE IF NOT EXISTS passkeys (
+  id SERIAL PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  credential_id VARCHAR(255) NOT NULL UNIQUE,
+  public_key TEXT NOT NULL,
+  sign_count BIGINT NOT NULL DEFAULT 0,
+  name VARCHAR(100) NOT NULL,
+  transports VARCHAR(255) NOT NULL DEFAULT '',
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  last_used_at TIMESTAMP WITH TIME ZONE
+);
+CREATE INDEX IF NOT EXISTS idx_passkeys_user ON passkeys(user_id);
+
+-- Challenge of a registration or login ceremony, used once
+-- user_id is NULL for logins, the user is known from the passkey
+CREATE TABLE IF NOT EXISTS webauthn_challenges (
+  id SERIAL PRIMARY KEY,
+  user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+  challenge VARCHAR(64) NOT NULL UNIQUE,
+  ceremony VARCHAR(20) NOT NULL CHECK(ceremony IN ('registration', 'login')),
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_webauthn_challenges_expires ON webauthn_challenges(expires_at);
+`,
+		},
+	})
+}
@@ -0,0 +1,69 @@
+package database
+
+func init() {
+	RegisterMigration(&Migration{
+		ID:          "036_create_magic_links_table",
+		Description: "Create magic_links for passwordless email login, add the magic link settings",
+		Up: map[string]string{
+			"sqlite": `
+-- Login link sent by email, only SHA-256 hashes of the link token and the device token are stored
+-- device_hash binds the link to the browser that requested it, used_at makes it single-use
+CREATE TABLE IF NOT EXISTS magic_links (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL,
+  token_hash TEXT NOT NULL UNIQUE,
+  device_hash TEXT NOT NULL,
+  expires_at TIMESTAMP NOT NULL,
+  used_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_magic_links_user ON magic_links(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_magic_links_expires ON magic_links(expires_at);
+
+INSERT OR IGNORE INTO system_settings (key, value) VALUES
+  ('magic_link_login', 'false'),
+  ('magic_link_same_device', 'true');
+`,
+			"mysql": `
+-- Login link sent by email, only SHA-256 hashes of the link token and the device token are stored
+-- device_hash binds the link to the browser that requested it, used_at makes it single-use
+CREATE TABLE IF NOT EXISTS magic_links (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  user_id INT NOT NULL,
+  token_hash CHAR(64) NOT NULL UNIQUE,
+  device_hash CHAR(64) NOT NULL,
+  expires_at DATETIME NOT NULL,
+  used_at DATETIME NULL,
+  created_at DATETIME NOT NULL,
+  INDEX idx_magic_links_user (user_id, created_at),
+  INDEX idx_magic_links_expires (expires_at),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+` + "INSERT IGNORE INTO system_settings (`key`, value) VALUES\n" +
+				"  ('magic_link_login', 'false'),\n" +
+				"  ('magic_link_same_device', 'true');",
+			"postgres": `
+-- Login link sent by email, only SHA-256 hashes of the link token and the device token are stored
+-- device_hash binds the link to the browser that requested it, used_at makes it single-use
+CREATE TABLE IF NOT EXISTS magic_links (
+  id SERIAL PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  token_hash CHAR(64) NOT NULL UNIQUE,
+  device_hash CHAR(64) NOT NULL,
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  used_at TIMESTAMP WITH TIME ZONE,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_magic_links_user ON magic_links(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_magic_links_expires ON magic_links(expires_at);
+
+INSERT INTO system_settings (key, value) VALUES
+  ('magic_link_login', 'false'),
+  ('magic_link_same_device', 'true')
+ON CONFLICT (key) DO NOTHING;
+`,
+		},
+	})
+}
@@ -0,0 +1,98 @@
+package database
+
+func init() {
+	RegisterMigration(&Migration{
+		ID:          "037_create_oidc_tables",
+		Description: "Create user_identities and oidc_logins for the single sign-on with OpenID Connect",
+		Up: map[string]string{
+			"sqlite": `
+-- Account at the identity provider linked to a user, found again by issuer and subject
+CREATE TABLE IF NOT EXISTS user_identities (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL,
+  issuer TEXT NOT NULL,
+  subject TEXT NOT NULL,
+  email TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  last_login_at TIMESTAMP,
+  UNIQUE(issuer, subject),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_user_identities_user ON user_identities(user_id);
+
+-- Login at the identity provider in progress, state_hash identifies the redirect back,
+-- login_code_hash the single-use code the login page exchanges for the tokens afterwards
+CREATE TABLE IF NOT EXISTS oidc_logins (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  state_hash TEXT NOT NULL UNIQUE,
+  nonce TEXT NOT NULL,
+  code_verifier TEXT NOT NULL,
+  user_id INTEGER,
+  login_code_hash TEXT UNIQUE,
+  expires_at TIMESTAMP NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_oidc_logins_expires ON oidc_logins(expires_at);
+`,
+			"mysql": `
+-- Account at the identity provider linked to a user, found again by issuer and subject
+CREATE TABLE IF NOT EXISTS user_identities (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  user_id INT NOT NULL,
+  issuer VARCHAR(255) NOT NULL,
+  subject VARCHAR(255) NOT NULL,
+  email VARCHAR(255) NOT NULL,
+  created_at DATETIME NOT NULL,
+  last_login_at DATETIME NULL,
+  UNIQUE KEY uq_user_identities_subject (issuer, subject),
+  INDEX idx_user_identities_user (user_id),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Login at the identity provider in progress, state_hash identifies the redirect back,
+-- login_code_hash the single-use code the login page exchanges for the tokens afterwards
+CREATE TABLE IF NOT EXISTS oidc_logins (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  state_hash CHAR(64) NOT NULL UNIQUE,
+  nonce VARCHAR(64) NOT NULL,
+  code_verifier VARCHAR(128) NOT NULL,
+  user_id INT NULL,
+  login_code_hash CHAR(64) NULL UNIQUE,
+  expires_at DATETIME NOT NULL,
+  created_at DATETIME NOT NULL,
+  INDEX idx_oidc_logins_expires (expires_at),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`,
+			"postgres": `
+-- Account at the identity provider linked to a user, found again by issuer and subject
+CREATE TABLE IF NOT EXISTS user_identities (
+  id SERIAL PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  issuer VARCHAR(255) NOT NULL,
+  subject VARCHAR(255) NOT NULL,
+  email VARCHAR(255) NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  last_login_at TIMESTAMP WITH TIME ZONE,
+  UNIQUE(issuer, subject)
+);
+CREATE INDEX IF NOT EXISTS idx_user_identities_user ON user_identities(user_id);
+
+-- Login at the identity provider in progress, state_hash identifies the redirect back,
+-- login_code_hash the single-use code the login page exchanges for the tokens afterwards
+CREATE TABLE IF NOT EXISTS oidc_logins (
+  id SERIAL PRIMARY KEY,
+  state_hash CHAR(64) NOT NULL UNIQUE,
+  nonce VARCHAR(64) NOT NULL,
+  code_verifier VARCHAR(128) NOT NULL,
+  user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+  login_code_hash CHAR(64) UNIQUE,
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_oidc_logins_expires ON oidc_logins(expires_at);
+`,
+		},
+	})
+}
@@ -0,0 +1,64 @@
+package database
+
+func init() {
+	RegisterMigration(&Migration{
+		ID:          "038_create_rate_limit_tables",
+		Description: "Create rate_limit_buckets and login_lockouts, so limits survive restarts",
+		Up: map[string]string{
+			"sqlite": `
+-- Requests of a client in the current window of a rate limit policy, bucket_key is policy and client IP
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+  bucket_key TEXT PRIMARY KEY,
+  hits INTEGER NOT NULL,
+  expires_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_expires ON rate_limit_buckets(expires_at);
+
+-- Failed password logins of an account, account_key is the SHA-256 hash of the normalized email address
+CREATE TABLE IF NOT EXISTS login_lockouts (
+  account_key TEXT PRIMARY KEY,
+  failures INTEGER NOT NULL,
+  locked_until TIMESTAMP,
+  last_failure_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_login_lockouts_last_failure ON login_lockouts(last_failure_at);
+`,
+			"mysql": `
+-- Requests of a client in the current window of a rate limit policy, bucket_key is policy and client IP
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+  bucket_key VARCHAR(191) PRIMARY KEY,
+  hits INT NOT NULL,
+  expires_at DATETIME NOT NULL,
+  INDEX idx_rate_limit_buckets_expires (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Failed password logins of an account, account_key is the SHA-256 hash of the normalized email address
+CREATE TABLE IF NOT EXISTS login_lockouts (
+  account_key CHAR(64) PRIMARY KEY,
+  failures INT NOT NULL,
+  locked_until DATETIME NULL,
+  last_failure_at DATETIME NOT NULL,
+  INDEX idx_login_lockouts_last_failure (last_failure_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`,
+			"postgres": `
+-- Requests of a client in the current window of a rate limit policy, bucket_key is policy and client IP
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+  bucket_key VARCHAR(191) PRIMARY KEY,
+  hits INTEGER NOT NULL,
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_expires ON rate_limit_buckets(expires_at);
+
+-- Failed password logins of an account, account_key is the SHA-256 hash of the normalized email address
+CREATE TABLE IF NOT EXISTS login_lockouts (
+  account_key CHAR(64) PRIMARY KEY,
+  failures INTEGER NOT NULL,
+  locked_until TIMESTAMP WITH TIME ZONE,
+  last_failure_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_login_lockouts_last_failure ON login_lockouts(last_failure_at);
+`,
+		},
+	})
+}
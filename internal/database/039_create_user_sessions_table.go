@@ -0,0 +1,60 @@
+package database
+
+func init() {
+	RegisterMigration(&Migration{
+		ID:          "039_create_user_sessions_table",
+		Description: "Create user_sessions with the device and IP of every login",
+		Up: map[string]string{
+			"sqlite": `
+-- One session per login, family_id links it to the refresh tokens of the login
+-- A session is active while its family has an unused, unrevoked and unexpired refresh token
+CREATE TABLE IF NOT EXISTS user_sessions (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL,
+  family_id TEXT NOT NULL UNIQUE,
+  device TEXT NOT NULL,
+  user_agent TEXT,
+  ip_address TEXT,
+  created_at TIMESTAMP NOT NULL,
+  last_used_at TIMESTAMP NOT NULL,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user ON user_sessions(user_id, device);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_last_used ON user_sessions(last_used_at);
+`,
+			"mysql": `
+-- One session per login, family_id links it to the refresh tokens of the login
+-- A session is active while its family has an unused, unrevoked and unexpired refresh token
+CREATE TABLE IF NOT EXISTS user_sessions (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  user_id INT NOT NULL,
+  family_id CHAR(64) NOT NULL UNIQUE,
+  device VARCHAR(100) NOT NULL,
+  user_agent VARCHAR(512),
+  ip_address VARCHAR(45),
+  created_at DATETIME NOT NULL,
+  last_used_at DATETIME NOT NULL,
+  INDEX idx_user_sessions_user (user_id, device),
+  INDEX idx_user_sessions_last_used (last_used_at),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`,
+			"postgres": `
+-- One session per login, family_id links it to the refresh tokens of the login
+-- A session is active while its family has an unused, unrevoked and unexpired refresh token
+CREATE TABLE IF NOT EXISTS user_sessions (
+  id SERIAL PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  family_id CHAR(64) NOT NULL UNIQUE,
+  device VARCHAR(100) NOT NULL,
+  user_agent VARCHAR(512),
+  ip_address VARCHAR(45),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  last_used_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user ON user_sessions(user_id, device);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_last_used ON user_sessions(last_used_at);
+`,
+		},
+	})
+}
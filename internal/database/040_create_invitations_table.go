@@ -0,0 +1,112 @@
+package database
+
+func init() {
+	RegisterMigration(&Migration{
+		ID:          "040_create_invitations_table",
+		Description: "Create invitations and registration_requests, add the registration mode setting",
+		Up: map[string]string{
+			"sqlite": `
+-- Invitation codes created by admins, email restricts the code to one address
+-- Registrations with a code get the experience level and tag of the invitation
+CREATE TABLE IF NOT EXISTS invitations (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  code TEXT NOT NULL UNIQUE,
+  email TEXT,
+  max_uses INTEGER NOT NULL DEFAULT 1,
+  use_count INTEGER NOT NULL DEFAULT 0,
+  experience_level TEXT NOT NULL DEFAULT 'green' CHECK(experience_level IN ('green', 'blue', 'orange')),
+  tag TEXT,
+  expires_at TIMESTAMP NOT NULL,
+  revoked_at TIMESTAMP,
+  created_by INTEGER,
+  created_at TIMESTAMP NOT NULL,
+  FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS idx_invitations_created ON invitations(created_at);
+
+-- Registrations waiting for an admin in the approval_required mode, the user is inactive until approved
+CREATE TABLE IF NOT EXISTS registration_requests (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL,
+  status TEXT DEFAULT 'pending' CHECK(status IN ('pending', 'approved', 'denied')),
+  admin_message TEXT,
+  reviewed_by INTEGER,
+  reviewed_at TIMESTAMP,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  FOREIGN KEY (reviewed_by) REFERENCES users(id)
+);
+CREATE INDEX IF NOT EXISTS idx_registration_pending ON registration_requests(status, created_at);
+
+INSERT OR IGNORE INTO system_settings (key, value) VALUES ('registration_mode', 'open');
+`,
+			"mysql": `
+-- Invitation codes created by admins, email restricts the code to one address
+-- Registrations with a code get the experience level and tag of the invitation
+CREATE TABLE IF NOT EXISTS invitations (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  code VARCHAR(20) NOT NULL UNIQUE,
+  email VARCHAR(255),
+  max_uses INT NOT NULL DEFAULT 1,
+  use_count INT NOT NULL DEFAULT 0,
+  experience_level VARCHAR(20) NOT NULL DEFAULT 'green' CHECK(experience_level IN ('green', 'blue', 'orange')),
+  tag VARCHAR(50),
+  expires_at DATETIME NOT NULL,
+  revoked_at DATETIME NULL,
+  created_by INT NULL,
+  created_at DATETIME NOT NULL,
+  INDEX idx_invitations_created (created_at),
+  FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Registrations waiting for an admin in the approval_required mode, the user is inactive until approved
+CREATE TABLE IF NOT EXISTS registration_requests (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  user_id INT NOT NULL,
+  status VARCHAR(20) DEFAULT 'pending' CHECK(status IN ('pending', 'approved', 'denied')),
+  admin_message TEXT,
+  reviewed_by INT,
+  reviewed_at DATETIME,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_registration_pending (status, created_at),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  FOREIGN KEY (reviewed_by) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+` + "INSERT IGNORE INTO system_settings (`key`, value) VALUES ('registration_mode', 'open');",
+			"postgres": `
+-- Invitation codes created by admins, email restricts the code to one address
+-- Registrations with a code get the experience level and tag of the invitation
+CREATE TABLE IF NOT EXISTS invitations (
+  id SERIAL PRIMARY KEY,
+  code VARCHAR(20) NOT NULL UNIQUE,
+  email VARCHAR(255),
+  max_uses INTEGER NOT NULL DEFAULT 1,
+  use_count INTEGER NOT NULL DEFAULT 0,
+  experience_level VARCHAR(20) NOT NULL DEFAULT 'green' CHECK(experience_level IN ('green', 'blue', 'orange')),
+  tag VARCHAR(50),
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  revoked_at TIMESTAMP WITH TIME ZONE,
+  created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_invitations_created ON invitations(created_at);
+
+-- Registrations waiting for an admin in the approval_required mode, the user is inactive until approved
+CREATE TABLE IF NOT EXISTS registration_requests (
+  id SERIAL PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  status VARCHAR(20) DEFAULT 'pending' CHECK(status IN ('pending', 'approved', 'denied')),
+  admin_message TEXT,
+  reviewed_by INTEGER REFERENCES users(id),
+  reviewed_at TIMESTAMP WITH TIME ZONE,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_registration_pending ON registration_requests(status, created_at);
+
+INSERT INTO system_settings (key, value) VALUES ('registration_mode', 'open')
+ON CONFLICT (key) DO NOTHING;
+`,
+		},
+	})
+}
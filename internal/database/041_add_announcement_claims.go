@@ -0,0 +1,28 @@
+package database
+
+func init() {
+	RegisterMigration(&Migration{
+		ID:          "041_add_announcement_claims",
+		Description: "Add claimed_at to announcements and announcement_deliveries so only one instance sends an announcement",
+		Up: map[string]string{
+			"sqlite": `
+-- Renewed while an instance sends the announcement, other instances only resume it once the claim is stale
+ALTER TABLE announcements ADD COLUMN claimed_at TIMESTAMP;
+-- Set right before the email is sent, a delivery is claimed by one instance only
+ALTER TABLE announcement_deliveries ADD COLUMN claimed_at TIMESTAMP;
+`,
+			"mysql": `
+-- Renewed while an instance sends the announcement, other instances only resume it once the claim is stale
+ALTER TABLE announcements ADD COLUMN claimed_at DATETIME;
+-- Set right before the email is sent, a delivery is claimed by one instance only
+ALTER TABLE announcement_deliveries ADD COLUMN claimed_at DATETIME;
+`,
+			"postgres": `
+-- Renewed while an instance sends the announcement, other instances only resume it once the claim is stale
+ALTER TABLE announcements ADD COLUMN claimed_at TIMESTAMP WITH TIME ZONE;
+-- Set right before the email is sent, a delivery is claimed by one instance only
+ALTER TABLE announcement_deliveries ADD COLUMN claimed_at TIMESTAMP WITH TIME ZONE;
+`,
+		},
+	})
+}
@@ -0,0 +1,61 @@
+package database
+
+func init() {
+	RegisterMigration(&Migration{
+		ID:          "042_add_domain_event_delivery_tracking",
+		Description: "Add claims and retries to domain_events and domain_event_deliveries for the subscribers that handled an event",
+		Up: map[string]string{
+			"sqlite": `
+-- The instance dispatching the event, other instances only take it over once the claim is stale
+ALTER TABLE domain_events ADD COLUMN claimed_by TEXT;
+ALTER TABLE domain_events ADD COLUMN claimed_at TIMESTAMP;
+-- Events with a failed subscriber stay undispatched and are retried with backoff
+ALTER TABLE domain_events ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE domain_events ADD COLUMN next_attempt_at TIMESTAMP;
+
+-- Subscribers that handled an event, a retry only runs the others
+CREATE TABLE IF NOT EXISTS domain_event_deliveries (
+  event_id INTEGER NOT NULL,
+  subscriber TEXT NOT NULL,
+  delivered_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (event_id, subscriber),
+  FOREIGN KEY (event_id) REFERENCES domain_events(id) ON DELETE CASCADE
+);
+`,
+			"mysql": `
+-- The instance dispatching the event, other instances only take it over once the claim is stale
+ALTER TABLE domain_events ADD COLUMN claimed_by VARCHAR(100);
+ALTER TABLE domain_events ADD COLUMN claimed_at DATETIME;
+-- Events with a failed subscriber stay undispatched and are retried with backoff
+ALTER TABLE domain_events ADD COLUMN attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE domain_events ADD COLUMN next_attempt_at DATETIME;
+
+-- Subscribers that handled an event, a retry only runs the others
+CREATE TABLE IF NOT EXISTS domain_event_deliveries (
+  event_id INT NOT NULL,
+  subscriber VARCHAR(50) NOT NULL,
+  delivered_at DATETIME NOT NULL,
+  PRIMARY KEY (event_id, subscriber),
+  FOREIGN KEY (event_id) REFERENCES domain_events(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+`,
+			"postgres": `
+-- The instance dispatching the event, other instances only take it over once the claim is stale
+ALTER TABLE domain_events ADD COLUMN claimed_by VARCHAR(100);
+ALTER TABLE domain_events ADD COLUMN claimed_at TIMESTAMP WITH TIME ZONE;
+-- Events with a failed subscriber stay undispatched and are retried with backoff
+ALTER TABLE domain_events ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE domain_events ADD COLUMN next_attempt_at TIMESTAMP WITH TIME ZONE;
+
+-- Subscribers that handled an event, a retry only runs the others
+CREATE TABLE IF NOT EXISTS domain_event_deliveries (
+  event_id INTEGER NOT NULL,
+  subscriber VARCHAR(50) NOT NULL,
+  delivered_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  PRIMARY KEY (event_id, subscriber),
+  FOREIGN KEY (event_id) REFERENCES domain_events(id) ON DELETE CASCADE
+);
+`,
+		},
+	})
+}
@@ -0,0 +1,25 @@
+package database
+
+func init() {
+	RegisterMigration(&Migration{
+		ID:          "043_add_webhook_delivery_claims",
+		Description: "Add claimed_by and claimed_at to webhook_deliveries so only one instance sends a delivery",
+		Up: map[string]string{
+			"sqlite": `
+-- The instance sending the delivery, other instances only take it over once the claim is stale
+ALTER TABLE webhook_deliveries ADD COLUMN claimed_by TEXT;
+ALTER TABLE webhook_deliveries ADD COLUMN claimed_at TIMESTAMP;
+`,
+			"mysql": `
+-- The instance sending the delivery, other instances only take it over once the claim is stale
+ALTER TABLE webhook_deliveries ADD COLUMN claimed_by VARCHAR(100);
+ALTER TABLE webhook_deliveries ADD COLUMN claimed_at DATETIME;
+`,
+			"postgres": `
+-- The instance sending the delivery, other instances only take it over once the claim is stale
+ALTER TABLE webhook_deliveries ADD COLUMN claimed_by VARCHAR(100);
+ALTER TABLE webhook_deliveries ADD COLUMN claimed_at TIMESTAMP WITH TIME ZONE;
+`,
+		},
+	})
+}
@@ -0,0 +1,25 @@
+package database
+
+func init() {
+	RegisterMigration(&Migration{
+		ID:          "044_add_notification_event_id",
+		Description: "Add event_id to notifications so a retried domain event records each notification once",
+		Up: map[string]string{
+			"sqlite": `
+-- The domain event that caused the notification, NULL for notifications without an event
+ALTER TABLE notifications ADD COLUMN event_id INTEGER;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_event_user_type ON notifications(event_id, user_id, type);
+`,
+			"mysql": `
+-- The domain event that caused the notification, NULL for notifications without an event
+ALTER TABLE notifications ADD COLUMN event_id INT;
+CREATE UNIQUE INDEX idx_notifications_event_user_type ON notifications(event_id, user_id, type);
+`,
+			"postgres": `
+-- The domain event that caused the notification, NULL for notifications without an event
+ALTER TABLE notifications ADD COLUMN event_id INTEGER;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_event_user_type ON notifications(event_id, user_id, type);
+`,
+		},
+	})
+}
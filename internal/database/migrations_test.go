@@ -14,8 +14,8 @@ import (
 func TestMigrationRegistry(t *testing.T) {
 	migrations := GetAllMigrations()
 
-	t.Run("All_15_migrations_registered", func(t *testing.T) {
-		assert.Len(t, migrations, 15, "Should have 15 migrations")
+	t.Run("All_43_migrations_registered", func(t *testing.T) {
+		assert.Len(t, migrations, 43, "Should have 43 migrations")
 	})
 
 	t.Run("Migrations_have_unique_IDs", func(t *testing.T) {
@@ -74,7 +74,7 @@ func TestRunMigrations_SQLite(t *testing.T) {
 	var count int
 	err = db.QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&count)
 	assert.NoError(t, err)
-	assert.Equal(t, 15, count, "Should have 15 applied migrations")
+	assert.Equal(t, 43, count, "Should have 43 applied migrations")
 
 	// Verify all tables created
 	tables := []string{
@@ -87,10 +87,10 @@ func TestRunMigrations_SQLite(t *testing.T) {
 		assert.NoError(t, err, "Table %s should exist", table)
 	}
 
-	// Verify default settings inserted (3 from migration 008 + 5 from migration 012)
+	// Verify default settings inserted (3 from migration 008 + 5 from migration 012 + 3 from migration 021 + 2 from migration 026)
 	err = db.QueryRow("SELECT COUNT(*) FROM system_settings").Scan(&count)
 	assert.NoError(t, err)
-	assert.Equal(t, 8, count, "Should have 8 default settings")
+	assert.Equal(t, 16, count, "Should have 16 default settings")
 
 	// Verify photo_thumbnail column exists in dogs table
 	err = db.QueryRow(`
@@ -119,16 +119,16 @@ func TestRunMigrations_Idempotent(t *testing.T) {
 	var count int
 	err = db.QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&count)
 	assert.NoError(t, err)
-	assert.Equal(t, 15, count)
+	assert.Equal(t, 43, count)
 
 	// Run migrations second time (should be idempotent)
 	err = RunMigrationsWithDialect(db, dialect)
 	assert.NoError(t, err, "Second migration run should succeed (idempotent)")
 
-	// Count should still be 15 (no duplicates)
+	// Count should still be 43 (no duplicates)
 	err = db.QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&count)
 	assert.NoError(t, err)
-	assert.Equal(t, 15, count, "Should still have 15 migrations (no duplicates)")
+	assert.Equal(t, 43, count, "Should still have 43 migrations (no duplicates)")
 }
 
 // TestGetMigrationStatus tests migration status reporting
@@ -146,7 +146,7 @@ func TestGetMigrationStatus(t *testing.T) {
 	applied, pending, err := GetMigrationStatus(db, dialect)
 	assert.NoError(t, err)
 	assert.Equal(t, 0, applied)
-	assert.Equal(t, 15, pending)
+	assert.Equal(t, 43, pending)
 
 	// After migrations
 	err = RunMigrationsWithDialect(db, dialect)
@@ -154,7 +154,7 @@ func TestGetMigrationStatus(t *testing.T) {
 
 	applied, pending, err = GetMigrationStatus(db, dialect)
 	assert.NoError(t, err)
-	assert.Equal(t, 15, applied)
+	assert.Equal(t, 43, applied)
 	assert.Equal(t, 0, pending)
 }
 
@@ -365,6 +365,34 @@ func TestMigrationOrder(t *testing.T) {
 		"014_add_featured_dogs",
 		"015_add_external_link",
 		"016_add_reminder_sent",
+		"017_create_email_templates_table",
+		"018_add_preferred_language",
+		"019_add_email_template_language",
+		"020_create_notification_preferences_table",
+		"021_create_booking_reminders_table",
+		"022_add_telegram_chat_id",
+		"023_create_push_subscriptions_table",
+		"024_create_notifications_table",
+		"025_create_announcements_tables",
+		"026_create_run_sheet_digests_table",
+		"027_create_webhooks_tables",
+		"028_create_domain_events_table",
+		"029_create_activity_log_table",
+		"030_create_audit_log_table",
+		"031_create_roles_tables",
+		"032_create_refresh_tokens_table",
+		"033_add_token_version",
+		"034_create_two_factor_tables",
+		"035_create_passkey_tables",
+		"036_create_magic_links_table",
+		"037_create_oidc_tables",
+		"038_create_rate_limit_tables",
+		"039_create_user_sessions_table",
+		"040_create_invitations_table",
+		"041_add_announcement_claims",
+		"042_add_domain_event_delivery_tracking",
+		"043_add_webhook_delivery_claims",
+		"044_add_notification_event_id",
 	}
 
 	assert.Len(t, migrations, len(expectedOrder))
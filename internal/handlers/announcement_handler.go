@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/i18n"
+	"github.com/tranmh/gassigeher/internal/middleware"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/services"
+)
+
+// AnnouncementHandler handles admin announcements to user segments
+// Announcements are sent by the cron job once their scheduled time has come
+type AnnouncementHandler struct {
+	announcementRepo *repository.AnnouncementRepository
+	userRepo         *repository.UserRepository
+	service          *services.AnnouncementService
+	config           *config.Config
+}
+
+// NewAnnouncementHandler creates a new announcement handler
+func NewAnnouncementHandler(db *sql.DB, cfg *config.Config) *AnnouncementHandler {
+	return &AnnouncementHandler{
+		announcementRepo: repository.NewAnnouncementRepository(db),
+		userRepo:         repository.NewUserRepository(db),
+		service:          services.NewAnnouncementServiceFromConfig(db, cfg, nil),
+		config:           cfg,
+	}
+}
+
+// ListAnnouncements lists all announcements with their delivery counts (admin only)
+func (h *AnnouncementHandler) ListAnnouncements(w http.ResponseWriter, r *http.Request) {
+	announcements, err := h.announcementRepo.FindAll()
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_announcements")
+		return
+	}
+
+	reports, err := h.announcementRepo.Reports()
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_announcements")
+		return
+	}
+
+	for _, announcement := range announcements {
+		announcement.Report = reports[announcement.ID]
+	}
+
+	respondJSON(w, http.StatusOK, announcements)
+}
+
+// GetAnnouncement gets an announcement with its delivery report, including failed deliveries (admin only)
+func (h *AnnouncementHandler) GetAnnouncement(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_announcement_id")
+		return
+	}
+
+	announcement, err := h.announcementRepo.FindByID(id)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_announcements")
+		return
+	}
+	if announcement == nil {
+		respondError(w, r, http.StatusNotFound, "announcement_not_found")
+		return
+	}
+
+	announcement.Report, err = h.announcementRepo.Report(id)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_announcements")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, announcement)
+}
+
+// CreateAnnouncement schedules an announcement (admin only)
+// Without scheduled_at it is sent with the next cron run (within a minute)
+func (h *AnnouncementHandler) CreateAnnouncement(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req models.CreateAnnouncementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respondValidationError(w, r, err)
+		return
+	}
+
+	announcement := &models.Announcement{
+		Subject:     req.Subject,
+		Message:     req.Message,
+		Segment:     req.Segment,
+		ScheduledAt: time.Now(),
+		CreatedBy:   &userID,
+	}
+	if req.ScheduledAt != nil {
+		announcement.ScheduledAt = *req.ScheduledAt
+	}
+
+	if err := h.announcementRepo.Create(announcement); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_create_announcement")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, announcement)
+}
+
+// PreviewAnnouncement renders the announcement email and counts the recipients without saving (admin only)
+func (h *AnnouncementHandler) PreviewAnnouncement(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req models.CreateAnnouncementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respondValidationError(w, r, err)
+		return
+	}
+
+	name := ""
+	if user, err := h.userRepo.FindByID(userID); err == nil && user != nil {
+		name = user.Name
+	}
+
+	preview, err := h.service.Preview(&req, i18n.FromRequest(r), name)
+	if err != nil {
+		log.Printf("Failed to preview announcement: %v", err)
+		respondError(w, r, http.StatusInternalServerError, "failed_to_preview_announcement")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, preview)
+}
+
+// CancelAnnouncement cancels an announcement that has not started sending (admin only)
+func (h *AnnouncementHandler) CancelAnnouncement(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_announcement_id")
+		return
+	}
+
+	announcement, err := h.announcementRepo.FindByID(id)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_cancel_announcement")
+		return
+	}
+	if announcement == nil {
+		respondError(w, r, http.StatusNotFound, "announcement_not_found")
+		return
+	}
+
+	cancelled, err := h.announcementRepo.Cancel(id)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_cancel_announcement")
+		return
+	}
+	if !cancelled {
+		respondError(w, r, http.StatusConflict, "announcement_not_cancellable")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Announcement cancelled"})
+}
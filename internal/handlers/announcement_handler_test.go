@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestAnnouncementHandler tests scheduling, previewing and cancelling announcements
+func TestAnnouncementHandler(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	handler := NewAnnouncementHandler(db, &config.Config{JWTSecret: "test-secret"})
+
+	adminID := testutil.SeedTestUser(t, db, "admin@example.com", "Admin", "orange")
+	db.Exec("UPDATE users SET is_admin = 1 WHERE id = ?", adminID)
+	testutil.SeedTestUser(t, db, "green@example.com", "Green", "green")
+
+	newRequest := func(method, path string, body interface{}) *http.Request {
+		var buf bytes.Buffer
+		if body != nil {
+			json.NewEncoder(&buf).Encode(body)
+		}
+		req := httptest.NewRequest(method, path, &buf)
+		req.Header.Set("Accept-Language", "en")
+		return req.WithContext(contextWithUser(req.Context(), adminID, "admin@example.com", true))
+	}
+
+	t.Run("validation", func(t *testing.T) {
+		tests := []struct {
+			name string
+			body map[string]interface{}
+			code string
+		}{
+			{"missing subject", map[string]interface{}{"message": "Hello"}, "invalid_announcement_subject"},
+			{"missing message", map[string]interface{}{"subject": "News"}, "invalid_announcement_message"},
+			{"invalid level", map[string]interface{}{"subject": "News", "message": "Hello", "segment": map[string]interface{}{"experience_levels": []string{"red"}}}, "invalid_segment_experience_level"},
+			{"invalid status", map[string]interface{}{"subject": "News", "message": "Hello", "segment": map[string]interface{}{"status": "deleted"}}, "invalid_segment_status"},
+			{"invalid days", map[string]interface{}{"subject": "News", "message": "Hello", "segment": map[string]interface{}{"booked_within_days": 0}}, "invalid_segment_days"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rec := httptest.NewRecorder()
+				handler.CreateAnnouncement(rec, newRequest("POST", "/api/admin/announcements", tt.body))
+
+				var resp map[string]interface{}
+				json.Unmarshal(rec.Body.Bytes(), &resp)
+				if rec.Code != http.StatusBadRequest || resp["code"] != tt.code {
+					t.Errorf("Expected 400 %s, got %d: %s", tt.code, rec.Code, rec.Body.String())
+				}
+			})
+		}
+	})
+
+	t.Run("preview", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.PreviewAnnouncement(rec, newRequest("POST", "/api/admin/announcements/preview", map[string]interface{}{
+			"subject": "Summer party",
+			"message": "Closed on Saturday",
+			"segment": map[string]interface{}{"experience_levels": []string{"green"}},
+		}))
+
+		var preview models.AnnouncementPreview
+		json.Unmarshal(rec.Body.Bytes(), &preview)
+		if rec.Code != http.StatusOK || preview.RecipientCount != 1 || preview.Subject != "Summer party" {
+			t.Errorf("Unexpected preview %d: %s", rec.Code, rec.Body.String())
+		}
+		if testutil.CountRows(t, db, "announcements") != 0 {
+			t.Error("Expected preview not to store an announcement")
+		}
+	})
+
+	var scheduled models.Announcement
+	t.Run("create", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.CreateAnnouncement(rec, newRequest("POST", "/api/admin/announcements", map[string]interface{}{
+			"subject":      "Summer party",
+			"message":      "Closed on Saturday",
+			"segment":      map[string]interface{}{"tags": []string{" Team "}},
+			"scheduled_at": time.Now().Add(24 * time.Hour),
+		}))
+
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+		}
+		json.Unmarshal(rec.Body.Bytes(), &scheduled)
+		if scheduled.Status != models.AnnouncementStatusScheduled || scheduled.Segment.Status != models.SegmentStatusActive {
+			t.Errorf("Unexpected announcement: %+v", scheduled)
+		}
+		if len(scheduled.Segment.Tags) != 1 || scheduled.Segment.Tags[0] != "team" {
+			t.Errorf("Expected normalized tags, got %v", scheduled.Segment.Tags)
+		}
+	})
+
+	t.Run("list and get", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ListAnnouncements(rec, newRequest("GET", "/api/admin/announcements", nil))
+
+		var announcements []models.Announcement
+		json.Unmarshal(rec.Body.Bytes(), &announcements)
+		if rec.Code != http.StatusOK || len(announcements) != 1 {
+			t.Fatalf("Expected 1 announcement, got %d: %s", rec.Code, rec.Body.String())
+		}
+
+		rec = httptest.NewRecorder()
+		req := mux.SetURLVars(newRequest("GET", "/api/admin/announcements/999", nil), map[string]string{"id": "999"})
+		handler.GetAnnouncement(rec, req)
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", rec.Code)
+		}
+
+		rec = httptest.NewRecorder()
+		id := strconv.Itoa(scheduled.ID)
+		req = mux.SetURLVars(newRequest("GET", "/api/admin/announcements/"+id, nil), map[string]string{"id": id})
+		handler.GetAnnouncement(rec, req)
+
+		var announcement models.Announcement
+		json.Unmarshal(rec.Body.Bytes(), &announcement)
+		if rec.Code != http.StatusOK || announcement.Report == nil || announcement.Report.Total != 0 {
+			t.Errorf("Expected announcement with empty report, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		id := strconv.Itoa(scheduled.ID)
+		rec := httptest.NewRecorder()
+		req := mux.SetURLVars(newRequest("DELETE", "/api/admin/announcements/"+id, nil), map[string]string{"id": id})
+		handler.CancelAnnouncement(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+
+		rec = httptest.NewRecorder()
+		req = mux.SetURLVars(newRequest("DELETE", "/api/admin/announcements/"+id, nil), map[string]string{"id": id})
+		handler.CancelAnnouncement(rec, req)
+		if rec.Code != http.StatusConflict {
+			t.Errorf("Expected status 409 for a cancelled announcement, got %d", rec.Code)
+		}
+
+		cancelled, _ := repository.NewAnnouncementRepository(db).FindByID(scheduled.ID)
+		if cancelled.Status != models.AnnouncementStatusCancelled {
+			t.Errorf("Expected status cancelled, got %s", cancelled.Status)
+		}
+	})
+}
+
+// TestUserHandler_Tags tests assigning tags to users
+func TestUserHandler_Tags(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	handler := NewUserHandler(db, &config.Config{JWTSecret: "test-secret", JWTExpirationHours: 24})
+
+	adminID := testutil.SeedTestUser(t, db, "admin@example.com", "Admin", "orange")
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "User", "green")
+
+	update := func(id int, tags []string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]interface{}{"tags": tags})
+		req := httptest.NewRequest("PUT", "/api/users/"+strconv.Itoa(id)+"/tags", bytes.NewReader(body))
+		req = req.WithContext(contextWithUser(req.Context(), adminID, "admin@example.com", true))
+		req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(id)})
+		rec := httptest.NewRecorder()
+		handler.UpdateUserTags(rec, req)
+		return rec
+	}
+
+	rec := update(userID, []string{"Team", "weekend", "team"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	tags, _ := repository.NewUserRepository(db).GetTags(userID)
+	if len(tags) != 2 || tags[0] != "team" || tags[1] != "weekend" {
+		t.Errorf("Expected normalized tags, got %v", tags)
+	}
+
+	if rec := update(userID, []string{""}); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an empty tag, got %d", rec.Code)
+	}
+	if rec := update(999, []string{"team"}); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown user, got %d", rec.Code)
+	}
+
+	t.Run("list users includes tags", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/users", nil)
+		req = req.WithContext(contextWithUser(req.Context(), adminID, "admin@example.com", true))
+		rec := httptest.NewRecorder()
+		handler.ListUsers(rec, req)
+
+		var users []models.User
+		json.Unmarshal(rec.Body.Bytes(), &users)
+		for _, user := range users {
+			if user.ID == userID && len(user.Tags) != 2 {
+				t.Errorf("Expected 2 tags, got %v", user.Tags)
+			}
+		}
+	})
+
+	t.Run("list all tags", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/users/tags", nil)
+		req = req.WithContext(contextWithUser(req.Context(), adminID, "admin@example.com", true))
+		rec := httptest.NewRecorder()
+		handler.ListTags(rec, req)
+
+		var all []string
+		json.Unmarshal(rec.Body.Bytes(), &all)
+		if rec.Code != http.StatusOK || len(all) != 2 {
+			t.Errorf("Expected 2 tags, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/services"
+)
+
+// Page size of the audit log
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 200
+)
+
+// AuditLogHandler serves the audit log of admin actions (super admin only)
+type AuditLogHandler struct {
+	db        *sql.DB
+	cfg       *config.Config
+	auditRepo *repository.AuditLogRepository
+	audit     *services.AuditService
+}
+
+// NewAuditLogHandler creates a new audit log handler
+func NewAuditLogHandler(db *sql.DB, cfg *config.Config) *AuditLogHandler {
+	return &AuditLogHandler{
+		db:        db,
+		cfg:       cfg,
+		auditRepo: repository.NewAuditLogRepository(db),
+		audit:     services.NewAuditService(db),
+	}
+}
+
+// ListAuditLog returns a page of the audit log, newest first (super admin only)
+// Query parameters: actor_id, action (part of "METHOD /route"), target_type, target_id,
+// date_from, date_to (YYYY-MM-DD), limit (default 50, max 200), offset
+func (h *AuditLogHandler) ListAuditLog(w http.ResponseWriter, r *http.Request) {
+	filter, code := parseAuditFilter(r)
+	if code != "" {
+		respondError(w, r, http.StatusBadRequest, code)
+		return
+	}
+
+	query := r.URL.Query()
+	filter.Limit = defaultAuditLimit
+	if value, err := strconv.Atoi(query.Get("limit")); err == nil && value > 0 {
+		filter.Limit = value
+	}
+	if filter.Limit > maxAuditLimit {
+		filter.Limit = maxAuditLimit
+	}
+	if value, err := strconv.Atoi(query.Get("offset")); err == nil && value > 0 {
+		filter.Offset = value
+	}
+
+	entries, total, err := h.auditRepo.Find(filter)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_audit_log")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, &models.AuditLogResponse{
+		Entries: entries,
+		Total:   total,
+		Limit:   filter.Limit,
+		Offset:  filter.Offset,
+	})
+}
+
+// ExportAuditLog downloads all matching entries oldest first, with their hashes (super admin only)
+// Query parameters: the filters of ListAuditLog and format=json (default) or csv
+func (h *AuditLogHandler) ExportAuditLog(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" {
+		respondError(w, r, http.StatusBadRequest, "invalid_export_format")
+		return
+	}
+
+	filter, code := parseAuditFilter(r)
+	if code != "" {
+		respondError(w, r, http.StatusBadRequest, code)
+		return
+	}
+
+	entries := []*models.AuditEntry{}
+	err := h.auditRepo.Each(filter, func(entry *models.AuditEntry) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_audit_log")
+		return
+	}
+
+	filename := fmt.Sprintf("audit-log-%s.%s", time.Now().Format("20060102-150405"), format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	if format == "json" {
+		respondJSON(w, http.StatusOK, entries)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"id", "created_at", "actor_id", "actor_email", "action", "target_type", "target_id",
+		"before", "after", "ip_address", "request_id", "status_code", "prev_hash", "hash"})
+	for _, entry := range entries {
+		writer.Write([]string{
+			strconv.Itoa(entry.ID),
+			entry.CreatedAt.UTC().Format(time.RFC3339),
+			strconv.Itoa(entry.ActorID),
+			entry.ActorEmail,
+			entry.Action,
+			entry.TargetType,
+			entry.TargetID,
+			string(entry.Before),
+			string(entry.After),
+			entry.IPAddress,
+			entry.RequestID,
+			strconv.Itoa(entry.StatusCode),
+			entry.PrevHash,
+			entry.Hash,
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		log.Printf("Failed to write audit log export: %v", err)
+	}
+}
+
+// VerifyAuditLog checks the hash chain of the whole audit log (super admin only)
+func (h *AuditLogHandler) VerifyAuditLog(w http.ResponseWriter, r *http.Request) {
+	result, err := h.audit.Verify()
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_verify_audit_log")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+// parseAuditFilter reads the filter query parameters, the code is set for invalid values
+func parseAuditFilter(r *http.Request) (*models.AuditFilter, string) {
+	query := r.URL.Query()
+	filter := &models.AuditFilter{
+		Action:     strings.TrimSpace(query.Get("action")),
+		TargetType: strings.TrimSpace(query.Get("target_type")),
+		TargetID:   strings.TrimSpace(query.Get("target_id")),
+		DateFrom:   query.Get("date_from"),
+		DateTo:     query.Get("date_to"),
+	}
+
+	if value := query.Get("actor_id"); value != "" {
+		actorID, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, "invalid_user_id"
+		}
+		filter.ActorID = actorID
+	}
+	for _, date := range []string{filter.DateFrom, filter.DateTo} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return nil, "invalid_date_format"
+		}
+	}
+
+	return filter, ""
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/services"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestAuditLogHandler tests listing, exporting and verifying the audit log
+func TestAuditLogHandler(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	handler := NewAuditLogHandler(db, &config.Config{})
+	superAdminID := testutil.SeedTestUser(t, db, "super@example.com", "Super", "orange")
+
+	audit := services.NewAuditService(db)
+	for _, entry := range []*models.AuditEntry{
+		{ActorID: 2, ActorEmail: "admin@example.com", Action: "PUT /api/settings/{key}", TargetType: "setting",
+			TargetID: "booking_advance_days", Before: []byte(`{"value":"14"}`), After: []byte(`{"value":"21"}`), StatusCode: 200},
+		{ActorID: 3, ActorEmail: "other@example.com", Action: "DELETE /api/dogs/{id}", TargetType: "dog",
+			TargetID: "5", Before: []byte(`{"name":"Bella"}`), StatusCode: 200},
+	} {
+		entry.IPAddress = "192.0.2.1"
+		entry.RequestID = "req"
+		if err := audit.Record(entry); err != nil {
+			t.Fatalf("Record() failed: %v", err)
+		}
+	}
+
+	get := func(path string, handle http.HandlerFunc) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req = req.WithContext(contextWithUser(req.Context(), superAdminID, "super@example.com", true))
+		rec := httptest.NewRecorder()
+		handle(rec, req)
+		return rec
+	}
+
+	t.Run("lists entries with filters", func(t *testing.T) {
+		rec := get("/api/admin/audit-log?actor_id=2", handler.ListAuditLog)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", rec.Code, rec.Body.String())
+		}
+		var response models.AuditLogResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		if response.Total != 1 || response.Limit != defaultAuditLimit || response.Entries[0].TargetID != "booking_advance_days" {
+			t.Errorf("Expected the settings entry, got %+v", response)
+		}
+		if string(response.Entries[0].After) != `{"value":"21"}` || response.Entries[0].Hash == "" {
+			t.Errorf("Expected after state and hash, got %+v", response.Entries[0])
+		}
+	})
+
+	t.Run("rejects invalid filters", func(t *testing.T) {
+		for _, path := range []string{"/api/admin/audit-log?actor_id=abc", "/api/admin/audit-log?date_from=2030-13-01"} {
+			if rec := get(path, handler.ListAuditLog); rec.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400 for %s, got %d", path, rec.Code)
+			}
+		}
+	})
+
+	t.Run("exports csv with hashes", func(t *testing.T) {
+		rec := get("/api/admin/audit-log/export?format=csv", handler.ExportAuditLog)
+		if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/csv") {
+			t.Fatalf("Expected csv, got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+		}
+		if !strings.Contains(rec.Header().Get("Content-Disposition"), "attachment") {
+			t.Errorf("Expected a download, got %q", rec.Header().Get("Content-Disposition"))
+		}
+		rows, err := csv.NewReader(rec.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("Failed to parse csv: %v", err)
+		}
+		if len(rows) != 3 || rows[0][len(rows[0])-1] != "hash" || rows[1][4] != "PUT /api/settings/{key}" {
+			t.Errorf("Expected header and 2 entries oldest first, got %v", rows)
+		}
+		if rows[2][12] != rows[1][13] {
+			t.Errorf("Expected exported prev_hash to match the previous hash")
+		}
+	})
+
+	t.Run("exports json", func(t *testing.T) {
+		rec := get("/api/admin/audit-log/export?target_type=dog", handler.ExportAuditLog)
+		var entries []*models.AuditEntry
+		json.Unmarshal(rec.Body.Bytes(), &entries)
+		if rec.Code != http.StatusOK || len(entries) != 1 || entries[0].TargetID != "5" {
+			t.Errorf("Expected the dog entry, got %d %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("rejects unknown export format", func(t *testing.T) {
+		if rec := get("/api/admin/audit-log/export?format=xml", handler.ExportAuditLog); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", rec.Code)
+		}
+	})
+
+	t.Run("verifies the chain", func(t *testing.T) {
+		rec := get("/api/admin/audit-log/verify", handler.VerifyAuditLog)
+		var result models.AuditVerification
+		json.Unmarshal(rec.Body.Bytes(), &result)
+		if rec.Code != http.StatusOK || !result.Valid || result.EntriesChecked != 2 {
+			t.Errorf("Expected a valid chain, got %d %s", rec.Code, rec.Body.String())
+		}
+
+		db.Exec(`UPDATE audit_log SET actor_id = 9 WHERE target_type = 'dog'`)
+		rec = get("/api/admin/audit-log/verify", handler.VerifyAuditLog)
+		json.Unmarshal(rec.Body.Bytes(), &result)
+		if result.Valid || result.Reason != "hash_mismatch" {
+			t.Errorf("Expected tampering to be detected, got %s", rec.Body.String())
+		}
+	})
+}
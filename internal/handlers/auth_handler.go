@@ -3,12 +3,18 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/i18n"
+	"github.com/tranmh/gassigeher/internal/logging"
 	"github.com/tranmh/gassigeher/internal/middleware"
 	"github.com/tranmh/gassigeher/internal/models"
 	"github.com/tranmh/gassigeher/internal/repository"
@@ -18,14 +24,26 @@ import (
 // AuthHandler handles authentication endpoints
 type AuthHandler struct {
 	userRepo     *repository.UserRepository
+	roleRepo     *repository.RoleRepository
 	authService  *services.AuthService
+	passwords    *services.PasswordPolicy
+	tokens       *services.TokenService
+	twoFactor    *services.TwoFactorService
+	passkeys     *services.PasskeyService
+	magicLinks   *services.MagicLinkService
+	oidc         *services.OIDCService
+	lockout      *services.LoginLockoutService
+	invitations  *services.InvitationService
+	approvals    *repository.RegistrationRequestRepository // Registrations waiting for an admin
 	emailService *services.EmailService
+	notifier     *services.NotificationService
+	outbox       *services.OutboxService
 	config       *config.Config
 }
 
 // NewAuthHandler creates a new auth handler
 func NewAuthHandler(db *sql.DB, cfg *config.Config) *AuthHandler {
-	emailService, err := services.NewEmailService(services.ConfigToEmailConfig(cfg))
+	emailService, err := services.NewEmailServiceFromConfig(db, cfg)
 	if err != nil {
 		// Log error but don't fail - emails will fail gracefully
 		fmt.Printf("Warning: Failed to initialize email service: %v\n", err)
@@ -33,8 +51,20 @@ func NewAuthHandler(db *sql.DB, cfg *config.Config) *AuthHandler {
 
 	return &AuthHandler{
 		userRepo:     repository.NewUserRepository(db),
-		authService:  services.NewAuthService(cfg.JWTSecret, cfg.JWTExpirationHours),
+		roleRepo:     repository.NewRoleRepository(db),
+		authService:  services.NewAuthService(cfg.JWTSecret, cfg.GetAccessTokenMinutes()),
+		passwords:    services.NewPasswordPolicy(cfg),
+		tokens:       services.NewTokenService(db, cfg),
+		twoFactor:    services.NewTwoFactorService(db),
+		passkeys:     services.NewPasskeyService(db, cfg),
+		magicLinks:   services.NewMagicLinkService(db, cfg),
+		oidc:         services.NewOIDCService(db, cfg),
+		lockout:      services.NewLoginLockoutService(db),
+		invitations:  services.NewInvitationService(db),
+		approvals:    repository.NewRegistrationRequestRepository(db),
 		emailService: emailService,
+		notifier:     services.NewNotificationServiceFromConfig(db, cfg, emailService),
+		outbox:       services.NewOutboxService(db),
 		config:       cfg,
 	}
 }
@@ -43,58 +73,92 @@ func NewAuthHandler(db *sql.DB, cfg *config.Config) *AuthHandler {
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	var req models.RegisterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
 		return
 	}
 
 	// Validate input (includes phone number validation)
 	if err := req.Validate(); err != nil {
-		respondError(w, http.StatusBadRequest, err.Error())
+		respondValidationError(w, r, err)
 		return
 	}
 
 	// Validate password strength
-	if err := h.authService.ValidatePassword(req.Password); err != nil {
-		respondError(w, http.StatusBadRequest, err.Error())
+	if err := h.passwords.Validate(req.Password, req.Name, req.Email); err != nil {
+		respondValidationError(w, r, err)
 		return
 	}
 
+	// The registration mode decides whether an invitation code is required
+	mode := h.invitations.RegistrationMode()
+	var invitation *models.Invitation
+	if strings.TrimSpace(req.InvitationCode) != "" {
+		found, err := h.invitations.Check(req.InvitationCode, req.Email)
+		if errors.Is(err, services.ErrInvalidInvitation) {
+			respondError(w, r, http.StatusBadRequest, "invalid_invitation")
+			return
+		}
+		if err != nil {
+			respondError(w, r, http.StatusInternalServerError, "database_error")
+			return
+		}
+		invitation = found
+	} else if mode == models.RegistrationModeInviteOnly {
+		respondError(w, r, http.StatusForbidden, "invitation_required")
+		return
+	}
+
+	// Without an invitation an admin approves the account in the approval_required mode
+	needsApproval := mode == models.RegistrationModeApproval && invitation == nil
+
 	// Check if user already exists
 	existing, err := h.userRepo.FindByEmail(req.Email)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Database error")
+		respondError(w, r, http.StatusInternalServerError, "database_error")
 		return
 	}
 	if existing != nil {
-		respondError(w, http.StatusConflict, "Email already registered")
+		respondError(w, r, http.StatusConflict, "email_already_registered")
 		return
 	}
 
 	// Hash password
 	passwordHash, err := h.authService.HashPassword(req.Password)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to hash password")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_hash_password")
 		return
 	}
 
 	// Generate verification token
 	verificationToken, err := h.authService.GenerateToken()
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to generate verification token")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_generate_verification_token")
 		return
 	}
 
 	expires := time.Now().Add(24 * time.Hour)
 
 	// Create user
+	// Default to the language the user registered in
+	preferredLanguage := req.PreferredLanguage
+	if preferredLanguage == "" {
+		preferredLanguage = i18n.FromRequest(r)
+	}
+
+	experienceLevel := "green"
+	if invitation != nil {
+		experienceLevel = invitation.ExperienceLevel
+	}
+
 	user := &models.User{
 		Name:                     req.Name,
 		Email:                    &req.Email,
 		Phone:                    &req.Phone,
 		PasswordHash:             &passwordHash,
-		ExperienceLevel:          "green",
+		ExperienceLevel:          experienceLevel,
+		PreferredLanguage:        preferredLanguage,
 		IsVerified:               false,
-		IsActive:                 true,
+		IsActive:                 !needsApproval,
 		IsDeleted:                false,
 		VerificationToken:        &verificationToken,
 		VerificationTokenExpires: &expires,
@@ -102,8 +166,49 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		LastActivityAt:           time.Now(),
 	}
 
-	if err := h.userRepo.Create(user); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to create user")
+	err = h.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		userRepo := h.userRepo.WithTx(tx)
+		if err := userRepo.Create(user); err != nil {
+			return nil, err
+		}
+		event, err := models.NewDomainEvent(models.EventUserRegistered, models.AggregateUser, user.ID, &user.ID,
+			&models.UserEventData{UserID: user.ID, Name: user.Name})
+		if err != nil {
+			return nil, err
+		}
+		events := []*models.DomainEvent{event}
+
+		if invitation != nil {
+			if err := h.invitations.Redeem(tx, invitation); err != nil {
+				return nil, err
+			}
+			if invitation.Tag != nil {
+				if err := userRepo.SetTags(user.ID, []string{*invitation.Tag}); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		if needsApproval {
+			request := &models.RegistrationRequest{UserID: user.ID}
+			if err := h.approvals.WithTx(tx).Create(request); err != nil {
+				return nil, err
+			}
+			event, err := models.NewDomainEvent(models.EventRegistrationRequested, models.AggregateRegistrationRequest, request.ID, &user.ID,
+				&models.RegistrationRequestEventData{RequestID: request.ID, UserID: user.ID, UserName: user.Name})
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, event)
+		}
+		return events, nil
+	})
+	if errors.Is(err, services.ErrInvalidInvitation) {
+		respondError(w, r, http.StatusBadRequest, "invalid_invitation")
+		return
+	}
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_create_user")
 		return
 	}
 
@@ -115,45 +220,62 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if needsApproval {
+		if h.notifier != nil {
+			go h.notifier.NotifyAdmins(models.NotificationTypeAdminRegistrationRequest, user.Name, req.Email)
+		}
+		respondJSON(w, http.StatusCreated, map[string]interface{}{
+			"message":           "Registration successful. Please check your email to verify your account. An administrator will approve your account.",
+			"user_id":           user.ID,
+			"approval_required": true,
+		})
+		return
+	}
+
 	respondJSON(w, http.StatusCreated, map[string]interface{}{
 		"message": "Registration successful. Please check your email to verify your account.",
 		"user_id": user.ID,
 	})
 }
 
+// RegistrationStatus tells the registration page whether an invitation code is required
+func (h *AuthHandler) RegistrationStatus(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, models.RegistrationStatus{Mode: h.invitations.RegistrationMode()})
+}
+
 // VerifyEmail handles email verification
 func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
 	var req models.VerifyEmailRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
 		return
 	}
 
 	if strings.TrimSpace(req.Token) == "" {
-		respondError(w, http.StatusBadRequest, "Token is required")
+		respondError(w, r, http.StatusBadRequest, "token_required")
 		return
 	}
 
 	// Find user by token
 	user, err := h.userRepo.FindByVerificationToken(req.Token)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Database error")
+		respondError(w, r, http.StatusInternalServerError, "database_error")
 		return
 	}
 	if user == nil {
-		respondError(w, http.StatusNotFound, "Invalid or expired verification token")
+		respondError(w, r, http.StatusNotFound, "invalid_verification_token")
 		return
 	}
 
 	// Check if already verified
 	if user.IsVerified {
-		respondError(w, http.StatusBadRequest, "Email already verified")
+		respondError(w, r, http.StatusBadRequest, "email_already_verified")
 		return
 	}
 
 	// Check if token expired
 	if user.VerificationTokenExpires != nil && time.Now().After(*user.VerificationTokenExpires) {
-		respondError(w, http.StatusBadRequest, "Verification token expired")
+		respondError(w, r, http.StatusBadRequest, "verification_token_expired")
 		return
 	}
 
@@ -163,13 +285,29 @@ func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
 	user.VerificationTokenExpires = nil
 
 	if err := h.userRepo.Update(user); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to verify user")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_verify_user")
 		return
 	}
 
-	// Send welcome email
-	if h.emailService != nil && user.Email != nil {
-		if err := h.emailService.SendWelcomeEmail(*user.Email, user.Name); err != nil {
+	// Registrations waiting for an admin are welcomed when they are approved
+	if !user.IsActive {
+		pending, err := h.approvals.HasPendingRequest(user.ID)
+		if err != nil {
+			respondError(w, r, http.StatusInternalServerError, "database_error")
+			return
+		}
+		if pending {
+			respondJSON(w, http.StatusOK, map[string]interface{}{
+				"message":           "Email verified successfully. An administrator will approve your account before you can login.",
+				"approval_required": true,
+			})
+			return
+		}
+	}
+
+	// Send welcome notification
+	if h.notifier != nil && user.Email != nil {
+		if err := h.notifier.SendWelcome(*user.Email, user.Name); err != nil {
 			fmt.Printf("Failed to send welcome email: %v\n", err)
 		}
 	}
@@ -183,31 +321,49 @@ func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var req models.LoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
 		return
 	}
 
 	if strings.TrimSpace(req.Email) == "" || strings.TrimSpace(req.Password) == "" {
-		respondError(w, http.StatusBadRequest, "Email and password are required")
+		respondError(w, r, http.StatusBadRequest, "email_and_password_required")
 		return
 	}
 
-	// Find user
-	user, err := h.userRepo.FindByEmail(req.Email)
+	// Accounts are locked after repeated failures, also for unknown addresses so locks do not reveal accounts
+	locked, err := h.lockout.LockedFor(req.Email)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Database error")
+		respondError(w, r, http.StatusInternalServerError, "database_error")
 		return
 	}
-	if user == nil || user.PasswordHash == nil {
-		respondError(w, http.StatusUnauthorized, "Ungültige Anmeldedaten")
+	if locked > 0 {
+		respondAccountLocked(w, r, locked)
+		return
+	}
+
+	// Find user
+	user, err := h.userRepo.FindByEmail(req.Email)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "database_error")
 		return
 	}
 
 	// Check password
-	if !h.authService.CheckPassword(req.Password, *user.PasswordHash) {
-		respondError(w, http.StatusUnauthorized, "Ungültige Anmeldedaten")
+	if user == nil || user.PasswordHash == nil || !h.authService.CheckPassword(req.Password, *user.PasswordHash) {
+		lock, err := h.lockout.RecordFailure(req.Email)
+		if err != nil {
+			fmt.Printf("Warning: Failed to record failed login: %v\n", err)
+		}
+		if lock > 0 {
+			respondAccountLocked(w, r, lock)
+			return
+		}
+		respondError(w, r, http.StatusUnauthorized, "invalid_credentials")
 		return
 	}
+	if err := h.lockout.Reset(req.Email); err != nil {
+		fmt.Printf("Warning: Failed to reset failed logins: %v\n", err)
+	}
 
 	// SECURITY FIX: Return uniform error messages to prevent account enumeration
 	// Don't reveal if account is unverified or deactivated
@@ -218,58 +374,568 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		if user.Email != nil && user.VerificationToken != nil && h.emailService != nil {
 			go h.emailService.SendVerificationEmail(*user.Email, user.Name, *user.VerificationToken)
 		}
-		respondError(w, http.StatusUnauthorized, "Ungültige Anmeldedaten")
+		respondError(w, r, http.StatusUnauthorized, "invalid_credentials")
 		return
 	}
 
 	// Check if active
 	if !user.IsActive {
 		// Could send reactivation instructions via email (don't reveal in response)
-		respondError(w, http.StatusUnauthorized, "Ungültige Anmeldedaten")
+		respondError(w, r, http.StatusUnauthorized, "invalid_credentials")
+		return
+	}
+
+	h.finishLogin(w, r, user, false)
+}
+
+// sessionClient returns the device and IP of the request, recorded in the login session
+func sessionClient(r *http.Request) models.SessionClient {
+	return models.NewSessionClient(r.UserAgent(), logging.GetClientIP(r))
+}
+
+// respondAccountLocked writes a 429 response with the remaining lock in minutes
+func respondAccountLocked(w http.ResponseWriter, r *http.Request, lock time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(lock.Seconds()))))
+	respondError(w, r, http.StatusTooManyRequests, "account_locked", int(math.Ceil(lock.Minutes())))
+}
+
+// PasskeyLoginBegin returns the options for a login with a passkey
+func (h *AuthHandler) PasskeyLoginBegin(w http.ResponseWriter, r *http.Request) {
+	options, err := h.passkeys.BeginLogin()
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_generate_token")
+		return
+	}
+	respondJSON(w, http.StatusOK, options)
+}
+
+// PasskeyLoginFinish logs in with the signed challenge of a passkey
+func (h *AuthHandler) PasskeyLoginFinish(w http.ResponseWriter, r *http.Request) {
+	var req models.PasskeyLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+
+	userID, err := h.passkeys.FinishLogin(&req.Credential)
+	if errors.Is(err, services.ErrInvalidPasskey) {
+		respondError(w, r, http.StatusUnauthorized, "invalid_passkey")
+		return
+	}
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "database_error")
+		return
+	}
+
+	// Same uniform error as the password login for unverified, deactivated and deleted accounts
+	user, err := h.userRepo.FindByID(userID)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "database_error")
+		return
+	}
+	if user == nil || !user.IsVerified || !user.IsActive || user.IsDeleted {
+		respondError(w, r, http.StatusUnauthorized, "invalid_passkey")
+		return
+	}
+
+	// The passkey verified the user (biometrics or PIN), it counts as second factor
+	h.finishLogin(w, r, user, true)
+}
+
+// MagicLinkStatus tells the login page whether the login with an email link is enabled
+func (h *AuthHandler) MagicLinkStatus(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, models.MagicLinkStatus{Enabled: h.magicLinks.Enabled()})
+}
+
+// RequestMagicLink sends a login link to the email address
+// The response is the same for unknown, unverified and deactivated accounts and when too many links were requested.
+func (h *AuthHandler) RequestMagicLink(w http.ResponseWriter, r *http.Request) {
+	if !h.magicLinks.Enabled() {
+		respondError(w, r, http.StatusForbidden, "magic_link_disabled")
+		return
+	}
+
+	var req models.MagicLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondValidationError(w, r, err)
+		return
+	}
+
+	deviceToken, err := h.magicLinks.NewDeviceToken()
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_send_magic_link")
+		return
+	}
+
+	user, err := h.userRepo.FindByEmail(req.Email)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "database_error")
+		return
+	}
+	if user != nil && user.IsVerified && user.IsActive && !user.IsDeleted && user.Email != nil {
+		token, err := h.magicLinks.Create(user.ID, deviceToken)
+		if err != nil {
+			respondError(w, r, http.StatusInternalServerError, "failed_to_send_magic_link")
+			return
+		}
+		if token != "" && h.emailService != nil {
+			if err := h.emailService.SendMagicLinkEmail(*user.Email, user.Name, token); err != nil {
+				fmt.Printf("Failed to send magic link email: %v\n", err)
+			}
+		}
+	}
+
+	respondJSON(w, http.StatusOK, models.MagicLinkResponse{
+		Message:     "If an account exists with this email, you will receive a login link.",
+		DeviceToken: deviceToken,
+		ExpiresIn:   int(services.MagicLinkTTL.Seconds()),
+	})
+}
+
+// LoginWithMagicLink logs in with the token of the link, users with 2FA enter their code next
+func (h *AuthHandler) LoginWithMagicLink(w http.ResponseWriter, r *http.Request) {
+	if !h.magicLinks.Enabled() {
+		respondError(w, r, http.StatusForbidden, "magic_link_disabled")
+		return
+	}
+
+	var req models.MagicLinkLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+
+	userID, err := h.magicLinks.Use(req.Token, req.DeviceToken)
+	switch {
+	case errors.Is(err, services.ErrInvalidMagicLink):
+		respondError(w, r, http.StatusUnauthorized, "invalid_magic_link")
+		return
+	case errors.Is(err, services.ErrMagicLinkOtherDevice):
+		respondError(w, r, http.StatusUnauthorized, "magic_link_other_device")
+		return
+	case err != nil:
+		respondError(w, r, http.StatusInternalServerError, "database_error")
+		return
+	}
+
+	// The account may have been deactivated since the link was sent
+	user, err := h.userRepo.FindByID(userID)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "database_error")
+		return
+	}
+	if user == nil || !user.IsVerified || !user.IsActive || user.IsDeleted {
+		respondError(w, r, http.StatusUnauthorized, "invalid_magic_link")
+		return
+	}
+
+	h.finishLogin(w, r, user, false)
+}
+
+// oidcStateCookie binds the state of a single sign-on to the browser that started it
+const oidcStateCookie = "oidc_state"
+
+// OIDCStatus tells the login page whether the single sign-on is configured
+func (h *AuthHandler) OIDCStatus(w http.ResponseWriter, r *http.Request) {
+	if !h.config.OIDCEnabled() {
+		respondJSON(w, http.StatusOK, models.OIDCStatus{})
+		return
+	}
+	respondJSON(w, http.StatusOK, models.OIDCStatus{Enabled: true, ProviderName: h.config.OIDCProviderName})
+}
+
+// OIDCLogin redirects the browser to the identity provider
+func (h *AuthHandler) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	if !h.config.OIDCEnabled() {
+		redirectOIDCError(w, r, "oidc_disabled")
+		return
+	}
+
+	authURL, state, err := h.oidc.Begin(r.Context())
+	if err != nil {
+		fmt.Printf("Failed to start OIDC login: %v\n", err)
+		redirectOIDCError(w, r, "oidc_login_failed")
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     "/api/auth/oidc",
+		MaxAge:   600,
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(h.config.BaseURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCCallback handles the redirect back from the identity provider
+// The browser continues on the login page with a single-use login code, errors are passed as oidc_error.
+func (h *AuthHandler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	cookie, cookieErr := r.Cookie(oidcStateCookie)
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Path: "/api/auth/oidc", MaxAge: -1, HttpOnly: true})
+
+	switch {
+	case !h.config.OIDCEnabled():
+		redirectOIDCError(w, r, "oidc_disabled")
+		return
+	case query.Get("error") != "":
+		// Cancelled or denied at the provider
+		redirectOIDCError(w, r, "oidc_login_failed")
+		return
+	case cookieErr != nil || cookie.Value != query.Get("state"):
+		redirectOIDCError(w, r, "invalid_oidc_login")
+		return
+	}
+
+	loginCode, err := h.oidc.Callback(r.Context(), query.Get("state"), query.Get("code"))
+	switch {
+	case errors.Is(err, services.ErrOIDCEmailNotVerified):
+		redirectOIDCError(w, r, "oidc_email_not_verified")
+		return
+	case errors.Is(err, services.ErrOIDCAccountNotFound):
+		redirectOIDCError(w, r, "oidc_account_not_found")
+		return
+	case errors.Is(err, services.ErrInvalidOIDCLogin):
+		fmt.Printf("Rejected OIDC login: %v\n", err)
+		redirectOIDCError(w, r, "invalid_oidc_login")
+		return
+	case err != nil:
+		fmt.Printf("Failed to complete OIDC login: %v\n", err)
+		redirectOIDCError(w, r, "oidc_login_failed")
+		return
+	}
+
+	http.Redirect(w, r, "/login.html?oidc_code="+url.QueryEscape(loginCode), http.StatusFound)
+}
+
+// LoginWithOIDC exchanges the login code for the tokens, users with 2FA enter their code next
+func (h *AuthHandler) LoginWithOIDC(w http.ResponseWriter, r *http.Request) {
+	if !h.config.OIDCEnabled() {
+		respondError(w, r, http.StatusForbidden, "oidc_disabled")
+		return
+	}
+
+	var req models.OIDCLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+
+	userID, err := h.oidc.Finish(req.Code)
+	if errors.Is(err, services.ErrInvalidOIDCLogin) {
+		respondError(w, r, http.StatusUnauthorized, "invalid_oidc_login")
+		return
+	}
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "database_error")
+		return
+	}
+
+	user, err := h.userRepo.FindByID(userID)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "database_error")
+		return
+	}
+	if user == nil || !user.IsVerified || !user.IsActive || user.IsDeleted {
+		respondError(w, r, http.StatusUnauthorized, "invalid_oidc_login")
+		return
+	}
+
+	h.finishLogin(w, r, user, false)
+}
+
+// redirectOIDCError sends the browser back to the login page, which shows the error
+func redirectOIDCError(w http.ResponseWriter, r *http.Request, code string) {
+	http.Redirect(w, r, "/login.html?oidc_error="+code, http.StatusFound)
+}
+
+// finishLogin asks for the second step with a code of the authenticator app or issues the tokens
+// Staff without 2FA set it up first, also when they log in with a passkey or single sign-on.
+func (h *AuthHandler) finishLogin(w http.ResponseWriter, r *http.Request, user *models.User, multiFactor bool) {
+	enabled, err := h.twoFactor.IsEnabled(user.ID)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "database_error")
+		return
+	}
+	required, err := h.twoFactor.RequiredFor(user)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "database_error")
+		return
+	}
+	if (enabled && !multiFactor) || (!enabled && required) {
+		challengeToken, expiresIn, err := h.twoFactor.StartChallenge(user.ID)
+		if err != nil {
+			respondError(w, r, http.StatusInternalServerError, "failed_to_generate_token")
+			return
+		}
+		respondJSON(w, http.StatusOK, models.TwoFactorChallengeResponse{
+			TwoFactorRequired:      enabled,
+			TwoFactorSetupRequired: !enabled,
+			ChallengeToken:         challengeToken,
+			ExpiresIn:              expiresIn,
+		})
+		return
+	}
+
+	h.completeLogin(w, r, user, nil)
+}
+
+// VerifyTwoFactor completes a login with a code of the authenticator app or a recovery code
+func (h *AuthHandler) VerifyTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req models.TwoFactorChallengeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
 		return
 	}
 
+	challenge, user, ok := h.findChallenge(w, r, req.ChallengeToken)
+	if !ok {
+		return
+	}
+
+	if err := h.twoFactor.Verify(user.ID, req.Code, req.RecoveryCode); err != nil {
+		h.respondTwoFactorError(w, r, challenge, err)
+		return
+	}
+	if err := h.twoFactor.EndChallenge(challenge); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "database_error")
+		return
+	}
+
+	h.completeLogin(w, r, user, nil)
+}
+
+// SetupTwoFactorLogin creates the 2FA secret of a staff member who logs in without 2FA
+func (h *AuthHandler) SetupTwoFactorLogin(w http.ResponseWriter, r *http.Request) {
+	var req models.TwoFactorChallengeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+
+	_, user, ok := h.findChallenge(w, r, req.ChallengeToken)
+	if !ok {
+		return
+	}
+
+	setup, err := h.twoFactor.Setup(user)
+	if errors.Is(err, services.ErrTwoFactorAlreadyEnabled) {
+		respondError(w, r, http.StatusBadRequest, "two_factor_already_enabled")
+		return
+	}
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_set_up_two_factor")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, setup)
+}
+
+// ConfirmTwoFactorLogin enables 2FA with the first code and completes the login
+// The response contains the recovery codes, they are shown only once.
+func (h *AuthHandler) ConfirmTwoFactorLogin(w http.ResponseWriter, r *http.Request) {
+	var req models.TwoFactorChallengeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+
+	challenge, user, ok := h.findChallenge(w, r, req.ChallengeToken)
+	if !ok {
+		return
+	}
+
+	recoveryCodes, err := h.twoFactor.Enable(user.ID, req.Code)
+	if err != nil {
+		h.respondTwoFactorError(w, r, challenge, err)
+		return
+	}
+	if err := h.twoFactor.EndChallenge(challenge); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "database_error")
+		return
+	}
+
+	h.completeLogin(w, r, user, recoveryCodes)
+}
+
+// completeLogin issues the tokens of a user who passed all login steps
+func (h *AuthHandler) completeLogin(w http.ResponseWriter, r *http.Request, user *models.User, recoveryCodes []string) {
 	// Update last activity
 	if err := h.userRepo.UpdateLastActivity(user.ID); err != nil {
 		fmt.Printf("Failed to update last activity: %v\n", err)
 	}
 
-	// DONE: Get admin status from database (not config)
-	isAdmin := user.IsAdmin
-	isSuperAdmin := user.IsSuperAdmin // DONE: Phase 3
+	// Roles and permissions, checked by RequirePermission
+	if err := loadUserRoles(h.roleRepo, user); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "database_error")
+		return
+	}
 
-	// Generate JWT
-	// DONE: Phase 3 - Include isSuperAdmin in JWT
-	token, err := h.authService.GenerateJWT(user.ID, req.Email, isAdmin, isSuperAdmin)
+	// Short-lived access token (JWT with admin flags and permissions) and refresh token to renew it
+	client := sessionClient(r)
+	tokens, err := h.tokens.Issue(user, client)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to generate token")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_generate_token")
 		return
 	}
 
+	// Warn the user about logins from devices they did not use before, in background
+	if tokens.NewDevice && user.Email != nil && h.emailService != nil {
+		go func(email, name string) {
+			if err := h.emailService.SendNewDeviceLoginEmail(email, name, client.Device(), client.IPAddress, time.Now()); err != nil {
+				fmt.Printf("Failed to send new device login email: %v\n", err)
+			}
+		}(*user.Email, user.Name)
+	}
+
 	respondJSON(w, http.StatusOK, models.LoginResponse{
-		Token:   token,
-		User:    user,
-		IsAdmin: isAdmin,
+		Token:         tokens.Token,
+		RefreshToken:  tokens.RefreshToken,
+		ExpiresIn:     tokens.ExpiresIn,
+		User:          user,
+		IsAdmin:       user.IsAdmin,
+		RecoveryCodes: recoveryCodes,
 	})
 }
 
+// findChallenge returns the open login challenge of the token and its user
+func (h *AuthHandler) findChallenge(w http.ResponseWriter, r *http.Request, token string) (*models.TwoFactorChallenge, *models.User, bool) {
+	challenge, err := h.twoFactor.Challenge(token)
+	if errors.Is(err, services.ErrInvalidChallenge) {
+		respondError(w, r, http.StatusUnauthorized, "invalid_two_factor_challenge")
+		return nil, nil, false
+	}
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "database_error")
+		return nil, nil, false
+	}
+
+	// The user may have been deactivated since the password was checked
+	user, err := h.userRepo.FindByID(challenge.UserID)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "database_error")
+		return nil, nil, false
+	}
+	if user == nil || !user.IsActive || user.IsDeleted {
+		respondError(w, r, http.StatusUnauthorized, "invalid_two_factor_challenge")
+		return nil, nil, false
+	}
+	return challenge, user, true
+}
+
+// respondTwoFactorError counts wrong codes against the challenge
+func (h *AuthHandler) respondTwoFactorError(w http.ResponseWriter, r *http.Request, challenge *models.TwoFactorChallenge, err error) {
+	if !errors.Is(err, services.ErrInvalidTwoFactorCode) {
+		respondCodeError(w, r, err)
+		return
+	}
+	if err := h.twoFactor.FailChallenge(challenge); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "database_error")
+		return
+	}
+	respondError(w, r, http.StatusUnauthorized, "invalid_two_factor_code")
+}
+
+// RefreshToken exchanges a refresh token for a new access token and refresh token
+func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var req models.RefreshTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+	if req.RefreshToken == "" {
+		respondError(w, r, http.StatusBadRequest, "refresh_token_required")
+		return
+	}
+
+	tokens, err := h.tokens.Refresh(req.RefreshToken, sessionClient(r))
+	if errors.Is(err, services.ErrInvalidRefreshToken) || errors.Is(err, services.ErrRefreshTokenReused) {
+		respondError(w, r, http.StatusUnauthorized, "invalid_refresh_token")
+		return
+	}
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_generate_token")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, tokens)
+}
+
+// Logout ends the login of the refresh token
+// The access token stays valid until it expires, clients discard it.
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req models.RefreshTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+	if req.RefreshToken == "" {
+		respondError(w, r, http.StatusBadRequest, "refresh_token_required")
+		return
+	}
+
+	if err := h.tokens.Revoke(req.RefreshToken); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_logout")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Logged out"})
+}
+
+// LogoutAll ends all logins of the current user on every device
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := h.tokens.RevokeAll(userID); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_logout")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Logged out on all devices"})
+}
+
+// loadUserRoles fills in the role names and permissions of the user
+func loadUserRoles(roleRepo *repository.RoleRepository, user *models.User) error {
+	roles, err := roleRepo.ForUser(user)
+	if err != nil {
+		return err
+	}
+	user.Roles = []string{}
+	for _, role := range roles {
+		user.Roles = append(user.Roles, role.Name)
+	}
+	user.Permissions = models.PermissionsOf(roles)
+	return nil
+}
+
 // ForgotPassword handles password reset request
 func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
 	var req models.ForgotPasswordRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
 		return
 	}
 
 	if strings.TrimSpace(req.Email) == "" {
-		respondError(w, http.StatusBadRequest, "Email is required")
+		respondError(w, r, http.StatusBadRequest, "email_required")
 		return
 	}
 
 	// Find user
 	user, err := h.userRepo.FindByEmail(req.Email)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Database error")
+		respondError(w, r, http.StatusInternalServerError, "database_error")
 		return
 	}
 
@@ -284,7 +950,7 @@ func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
 	// Generate reset token
 	resetToken, err := h.authService.GenerateToken()
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to generate reset token")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_generate_reset_token")
 		return
 	}
 
@@ -293,7 +959,7 @@ func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
 	user.PasswordResetExpires = &expires
 
 	if err := h.userRepo.Update(user); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to save reset token")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_save_reset_token")
 		return
 	}
 
@@ -313,47 +979,47 @@ func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
 func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 	var req models.ResetPasswordRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
 		return
 	}
 
 	if strings.TrimSpace(req.Token) == "" {
-		respondError(w, http.StatusBadRequest, "Token is required")
+		respondError(w, r, http.StatusBadRequest, "token_required")
 		return
 	}
 
 	if req.Password != req.ConfirmPassword {
-		respondError(w, http.StatusBadRequest, "Passwords do not match")
-		return
-	}
-
-	// Validate password
-	if err := h.authService.ValidatePassword(req.Password); err != nil {
-		respondError(w, http.StatusBadRequest, err.Error())
+		respondError(w, r, http.StatusBadRequest, "passwords_do_not_match")
 		return
 	}
 
 	// Find user by token
 	user, err := h.userRepo.FindByPasswordResetToken(req.Token)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Database error")
+		respondError(w, r, http.StatusInternalServerError, "database_error")
 		return
 	}
 	if user == nil {
-		respondError(w, http.StatusNotFound, "Invalid or expired reset token")
+		respondError(w, r, http.StatusNotFound, "invalid_reset_token")
 		return
 	}
 
 	// Check if token expired
 	if user.PasswordResetExpires != nil && time.Now().After(*user.PasswordResetExpires) {
-		respondError(w, http.StatusBadRequest, "Reset token expired")
+		respondError(w, r, http.StatusBadRequest, "reset_token_expired")
+		return
+	}
+
+	// Validate password, it must not contain the name or email of the user
+	if err := h.passwords.Validate(req.Password, passwordUserInputs(user)...); err != nil {
+		respondValidationError(w, r, err)
 		return
 	}
 
 	// Hash new password
 	passwordHash, err := h.authService.HashPassword(req.Password)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to hash password")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_hash_password")
 		return
 	}
 
@@ -363,7 +1029,13 @@ func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 	user.PasswordResetExpires = nil
 
 	if err := h.userRepo.Update(user); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to update password")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_update_password")
+		return
+	}
+
+	// Whoever knew the old password is logged out
+	if err := h.tokens.RevokeAll(user.ID); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_logout")
 		return
 	}
 
@@ -376,59 +1048,77 @@ func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
 	if !ok {
-		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	var req models.ChangePasswordRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
 		return
 	}
 
 	if req.NewPassword != req.ConfirmPassword {
-		respondError(w, http.StatusBadRequest, "Passwords do not match")
-		return
-	}
-
-	// Validate new password
-	if err := h.authService.ValidatePassword(req.NewPassword); err != nil {
-		respondError(w, http.StatusBadRequest, err.Error())
+		respondError(w, r, http.StatusBadRequest, "passwords_do_not_match")
 		return
 	}
 
 	// Get user
 	user, err := h.userRepo.FindByID(userID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Database error")
+		respondError(w, r, http.StatusInternalServerError, "database_error")
 		return
 	}
 	if user == nil || user.PasswordHash == nil {
-		respondError(w, http.StatusNotFound, "User not found")
+		respondError(w, r, http.StatusNotFound, "user_not_found")
+		return
+	}
+
+	// Validate new password
+	if err := h.passwords.Validate(req.NewPassword, passwordUserInputs(user)...); err != nil {
+		respondValidationError(w, r, err)
 		return
 	}
 
 	// Verify old password
 	if !h.authService.CheckPassword(req.OldPassword, *user.PasswordHash) {
-		respondError(w, http.StatusUnauthorized, "Incorrect old password")
+		respondError(w, r, http.StatusUnauthorized, "incorrect_old_password")
 		return
 	}
 
 	// Hash new password
 	newHash, err := h.authService.HashPassword(req.NewPassword)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to hash password")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_hash_password")
 		return
 	}
 
 	user.PasswordHash = &newHash
 	if err := h.userRepo.Update(user); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to update password")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_update_password")
 		return
 	}
 
-	respondJSON(w, http.StatusOK, map[string]string{
-		"message": "Password changed successfully",
+	// Other devices are logged out, this one continues with a new login
+	if err := h.tokens.RevokeAll(user.ID); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_logout")
+		return
+	}
+	if err := loadUserRoles(h.roleRepo, user); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "database_error")
+		return
+	}
+	tokens, err := h.tokens.Issue(user, sessionClient(r))
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_generate_token")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"message":       "Password changed successfully",
+		"token":         tokens.Token,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
 	})
 }
 
@@ -440,6 +1130,42 @@ func respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	json.NewEncoder(w).Encode(data)
 }
 
-func respondError(w http.ResponseWriter, status int, message string) {
-	respondJSON(w, status, map[string]string{"error": message})
+// passwordUserInputs returns name and email of the user, which passwords must not be made of
+func passwordUserInputs(user *models.User) []string {
+	inputs := []string{user.Name}
+	if user.Email != nil {
+		inputs = append(inputs, *user.Email)
+	}
+	return inputs
+}
+
+// respondError writes an error with a stable code and a message in the request language
+// args fill in placeholders of the translated message
+func respondError(w http.ResponseWriter, r *http.Request, status int, code string, args ...interface{}) {
+	respondJSON(w, status, models.ErrorResponse{
+		Error: i18n.T(i18n.FromRequest(r), "errors."+code, args...),
+		Code:  code,
+	})
+}
+
+// respondValidationError writes a 400 response for a validation error
+// Coded validation errors are translated; other errors are passed through
+func respondValidationError(w http.ResponseWriter, r *http.Request, err error) {
+	var validationErr *models.ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Code == "" {
+		respondJSON(w, http.StatusBadRequest, models.ErrorResponse{Error: err.Error(), Code: "validation_failed"})
+		return
+	}
+
+	lang := i18n.FromRequest(r)
+	message := validationErr.Message
+	if i18n.Has(lang, "errors."+validationErr.Code) {
+		message = i18n.T(lang, "errors."+validationErr.Code, validationErr.Args...)
+	}
+
+	respondJSON(w, http.StatusBadRequest, models.ErrorResponse{
+		Error: message,
+		Code:  validationErr.Code,
+		Field: validationErr.Field,
+	})
 }
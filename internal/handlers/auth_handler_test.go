@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 	"time"
@@ -223,7 +224,7 @@ func TestAuthHandler_Login(t *testing.T) {
 	handler := NewAuthHandler(db, cfg)
 
 	// Create test user
-	authService := services.NewAuthService(cfg.JWTSecret, cfg.JWTExpirationHours)
+	authService := services.NewAuthService(cfg.JWTSecret, cfg.GetAccessTokenMinutes())
 	hash, _ := authService.HashPassword("Test1234")
 	userRepo := repository.NewUserRepository(db)
 
@@ -510,7 +511,7 @@ func TestAuthHandler_ChangePassword(t *testing.T) {
 	handler := NewAuthHandler(db, cfg)
 
 	// Create test user
-	authService := services.NewAuthService(cfg.JWTSecret, cfg.JWTExpirationHours)
+	authService := services.NewAuthService(cfg.JWTSecret, cfg.GetAccessTokenMinutes())
 	hash, _ := authService.HashPassword("OldPass123")
 	userRepo := repository.NewUserRepository(db)
 
@@ -623,6 +624,333 @@ func TestAuthHandler_ChangePassword(t *testing.T) {
 	})
 }
 
+// TestAuthHandler_PasswordPolicy tests that new passwords are checked against the configured policy
+func TestAuthHandler_PasswordPolicy(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	cfg := &config.Config{JWTSecret: "test-secret", PasswordMinScore: 3, PasswordBreachCheck: true}
+	handler := NewAuthHandler(db, cfg)
+
+	hash, _ := services.NewAuthService(cfg.JWTSecret, 15).HashPassword("OldPass123")
+	email := "policy@example.com"
+	user := &models.User{
+		Name:            "Erika Musterfrau",
+		Email:           &email,
+		PasswordHash:    &hash,
+		ExperienceLevel: "green",
+		IsVerified:      true,
+		IsActive:        true,
+		TermsAcceptedAt: time.Now(),
+		LastActivityAt:  time.Now(),
+	}
+	repository.NewUserRepository(db).Create(user)
+
+	tests := []struct {
+		password string
+		status   int
+		code     string
+	}{
+		{"Passwort123!", http.StatusBadRequest, "password_breached"},
+		{"Musterfrau2024x", http.StatusBadRequest, "password_contains_personal_info"},
+		{"Leine7 Kompass Regentonne", http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			body, _ := json.Marshal(map[string]string{
+				"old_password":     "OldPass123",
+				"new_password":     tt.password,
+				"confirm_password": tt.password,
+			})
+			req := httptest.NewRequest("PUT", "/api/auth/change-password", bytes.NewReader(body))
+			req = req.WithContext(contextWithUser(req.Context(), user.ID, email, false))
+
+			rec := httptest.NewRecorder()
+			handler.ChangePassword(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			var response models.ErrorResponse
+			json.Unmarshal(rec.Body.Bytes(), &response)
+			if response.Code != tt.code {
+				t.Errorf("Expected code %q, got %q", tt.code, response.Code)
+			}
+		})
+	}
+
+	t.Run("reset password with the name of the user", func(t *testing.T) {
+		resetToken := "policy-reset-token"
+		expires := time.Now().Add(1 * time.Hour)
+		user.PasswordResetToken = &resetToken
+		user.PasswordResetExpires = &expires
+		repository.NewUserRepository(db).Update(user)
+
+		body, _ := json.Marshal(map[string]string{
+			"token":            resetToken,
+			"password":         "Musterfrau2024x",
+			"confirm_password": "Musterfrau2024x",
+		})
+		req := httptest.NewRequest("POST", "/api/auth/reset-password", bytes.NewReader(body))
+
+		rec := httptest.NewRecorder()
+		handler.ResetPassword(rec, req)
+
+		var response models.ErrorResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		if rec.Code != http.StatusBadRequest || response.Code != "password_contains_personal_info" {
+			t.Errorf("Expected password_contains_personal_info, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+}
+
+// TestAuthHandler_RegistrationModes tests invitation codes and the approval of registrations
+func TestAuthHandler_RegistrationModes(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	cfg := &config.Config{JWTSecret: "test-secret"}
+	handler := NewAuthHandler(db, cfg)
+	settingsRepo := repository.NewSettingsRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	invitations := services.NewInvitationService(db)
+
+	adminID := testutil.SeedTestUser(t, db, "admin@example.com", "Admin", "orange")
+	tag := "vorstand"
+	invitationReq := &models.CreateInvitationRequest{ExperienceLevel: "blue", Tag: &tag}
+	invitationReq.Validate()
+	invitation, err := invitations.Create(invitationReq, adminID)
+	if err != nil {
+		t.Fatalf("Failed to create invitation: %v", err)
+	}
+
+	register := func(email, code string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		body, _ := json.Marshal(map[string]interface{}{
+			"name":             "New User",
+			"email":            email,
+			"phone":            "+49 123 456789",
+			"password":         "Test1234",
+			"confirm_password": "Test1234",
+			"accept_terms":     true,
+			"invitation_code":  code,
+		})
+		req := httptest.NewRequest("POST", "/api/auth/register", bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+		handler.Register(rec, req)
+
+		var response map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		return rec, response
+	}
+
+	t.Run("registration status", func(t *testing.T) {
+		settingsRepo.Update(models.SettingRegistrationMode, models.RegistrationModeInviteOnly)
+		rec := httptest.NewRecorder()
+		handler.RegistrationStatus(rec, httptest.NewRequest("GET", "/api/auth/registration", nil))
+
+		var status models.RegistrationStatus
+		json.Unmarshal(rec.Body.Bytes(), &status)
+		if status.Mode != models.RegistrationModeInviteOnly {
+			t.Errorf("Expected invite_only, got %+v", status)
+		}
+	})
+
+	t.Run("invite only", func(t *testing.T) {
+		settingsRepo.Update(models.SettingRegistrationMode, models.RegistrationModeInviteOnly)
+
+		if rec, response := register("nocode@example.com", ""); rec.Code != http.StatusForbidden || response["code"] != "invitation_required" {
+			t.Errorf("Expected 403 invitation_required, got %d: %v", rec.Code, response)
+		}
+		if rec, response := register("wrong@example.com", "WRONGCODE123"); rec.Code != http.StatusBadRequest || response["code"] != "invalid_invitation" {
+			t.Errorf("Expected 400 invalid_invitation, got %d: %v", rec.Code, response)
+		}
+
+		code := strings.ToLower(invitation.Code[:6] + "-" + invitation.Code[6:])
+		rec, _ := register("invited@example.com", code)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+		}
+		user, _ := userRepo.FindByEmail("invited@example.com")
+		if user == nil || !user.IsActive || user.ExperienceLevel != "blue" {
+			t.Fatalf("Expected an active blue user, got %+v", user)
+		}
+		if tags, _ := userRepo.GetTags(user.ID); len(tags) != 1 || tags[0] != tag {
+			t.Errorf("Expected the tag of the invitation, got %v", tags)
+		}
+
+		if rec, response := register("second@example.com", invitation.Code); rec.Code != http.StatusBadRequest || response["code"] != "invalid_invitation" {
+			t.Errorf("Expected the used invitation to be rejected, got %d: %v", rec.Code, response)
+		}
+	})
+
+	t.Run("approval required", func(t *testing.T) {
+		settingsRepo.Update(models.SettingRegistrationMode, models.RegistrationModeApproval)
+
+		rec, response := register("pending@example.com", "")
+		if rec.Code != http.StatusCreated || response["approval_required"] != true {
+			t.Fatalf("Expected 201 with approval_required, got %d: %v", rec.Code, response)
+		}
+		user, _ := userRepo.FindByEmail("pending@example.com")
+		if user == nil || user.IsActive {
+			t.Fatalf("Expected an inactive user, got %+v", user)
+		}
+		if pending, _ := repository.NewRegistrationRequestRepository(db).HasPendingRequest(user.ID); !pending {
+			t.Error("Expected a pending registration request")
+		}
+
+		body, _ := json.Marshal(map[string]string{"token": *user.VerificationToken})
+		verifyRec := httptest.NewRecorder()
+		handler.VerifyEmail(verifyRec, httptest.NewRequest("POST", "/api/auth/verify", bytes.NewReader(body)))
+		var verified map[string]interface{}
+		json.Unmarshal(verifyRec.Body.Bytes(), &verified)
+		if verifyRec.Code != http.StatusOK || verified["approval_required"] != true {
+			t.Errorf("Expected the verification to mention the approval, got %d: %v", verifyRec.Code, verified)
+		}
+
+		// An invitation skips the approval
+		another, _ := invitations.Create(&models.CreateInvitationRequest{MaxUses: 1, ExpiresInDays: 1, ExperienceLevel: "green"}, adminID)
+		if rec, response := register("invited2@example.com", another.Code); rec.Code != http.StatusCreated || response["approval_required"] != nil {
+			t.Errorf("Expected an approved registration, got %d: %v", rec.Code, response)
+		}
+		if user, _ := userRepo.FindByEmail("invited2@example.com"); user == nil || !user.IsActive {
+			t.Errorf("Expected an active user, got %+v", user)
+		}
+	})
+}
+
+// TestAuthHandler_RefreshAndLogout tests exchanging refresh tokens and logging out
+func TestAuthHandler_RefreshAndLogout(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	cfg := &config.Config{
+		JWTSecret:          "test-secret",
+		JWTExpirationHours: 24,
+	}
+	handler := NewAuthHandler(db, cfg)
+
+	authService := services.NewAuthService(cfg.JWTSecret, cfg.GetAccessTokenMinutes())
+	hash, _ := authService.HashPassword("Test1234")
+	userRepo := repository.NewUserRepository(db)
+
+	email := "refresh@example.com"
+	user := &models.User{
+		Name:            "Test User",
+		Email:           &email,
+		PasswordHash:    &hash,
+		ExperienceLevel: "green",
+		IsVerified:      true,
+		IsActive:        true,
+		TermsAcceptedAt: time.Now(),
+		LastActivityAt:  time.Now(),
+	}
+	userRepo.Create(user)
+
+	login := func() models.LoginResponse {
+		body, _ := json.Marshal(map[string]string{"email": email, "password": "Test1234"})
+		req := httptest.NewRequest("POST", "/api/auth/login", bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+		handler.Login(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Login failed with %d: %s", rec.Code, rec.Body.String())
+		}
+		var response models.LoginResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		return response
+	}
+
+	post := func(handle http.HandlerFunc, refreshToken string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"refresh_token": refreshToken})
+		req := httptest.NewRequest("POST", "/api/auth/refresh", bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+		handle(rec, req)
+		return rec
+	}
+
+	t.Run("login returns a refresh token", func(t *testing.T) {
+		response := login()
+		if response.RefreshToken == "" || response.ExpiresIn != 15*60 {
+			t.Errorf("Expected a refresh token and a 15 minute access token, got %+v", response)
+		}
+	})
+
+	t.Run("refresh rotates the tokens", func(t *testing.T) {
+		response := login()
+
+		rec := post(handler.RefreshToken, response.RefreshToken)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", rec.Code, rec.Body.String())
+		}
+		var pair models.TokenPair
+		json.Unmarshal(rec.Body.Bytes(), &pair)
+		if pair.Token == "" || pair.RefreshToken == "" || pair.RefreshToken == response.RefreshToken {
+			t.Errorf("Expected a new token pair, got %+v", pair)
+		}
+
+		// The old refresh token is used up
+		if rec := post(handler.RefreshToken, response.RefreshToken); rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for a reused token, got %d", rec.Code)
+		}
+	})
+
+	t.Run("missing refresh token", func(t *testing.T) {
+		if rec := post(handler.RefreshToken, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", rec.Code)
+		}
+	})
+
+	t.Run("logout", func(t *testing.T) {
+		response := login()
+
+		if rec := post(handler.Logout, response.RefreshToken); rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", rec.Code, rec.Body.String())
+		}
+		if rec := post(handler.RefreshToken, response.RefreshToken); rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 after logout, got %d", rec.Code)
+		}
+	})
+
+	t.Run("logout everywhere", func(t *testing.T) {
+		phone := login()
+		laptop := login()
+
+		req := httptest.NewRequest("POST", "/api/auth/logout-all", nil)
+		req = req.WithContext(contextWithUser(req.Context(), user.ID, email, false))
+		rec := httptest.NewRecorder()
+		handler.LogoutAll(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", rec.Code, rec.Body.String())
+		}
+
+		for _, response := range []models.LoginResponse{phone, laptop} {
+			if rec := post(handler.RefreshToken, response.RefreshToken); rec.Code != http.StatusUnauthorized {
+				t.Errorf("Expected status 401 after logout everywhere, got %d", rec.Code)
+			}
+		}
+	})
+
+	t.Run("password change logs out other devices", func(t *testing.T) {
+		other := login()
+
+		body, _ := json.Marshal(map[string]string{
+			"old_password":     "Test1234",
+			"new_password":     "NewPass456",
+			"confirm_password": "NewPass456",
+		})
+		req := httptest.NewRequest("PUT", "/api/auth/change-password", bytes.NewReader(body))
+		req = req.WithContext(contextWithUser(req.Context(), user.ID, email, false))
+		rec := httptest.NewRecorder()
+		handler.ChangePassword(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", rec.Code, rec.Body.String())
+		}
+
+		var pair models.TokenPair
+		json.Unmarshal(rec.Body.Bytes(), &pair)
+		if rec := post(handler.RefreshToken, other.RefreshToken); rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for the other device, got %d", rec.Code)
+		}
+		if rec := post(handler.RefreshToken, pair.RefreshToken); rec.Code != http.StatusOK {
+			t.Errorf("Expected the new refresh token to work, got %d", rec.Code)
+		}
+	})
+}
+
 // DONE: TestAuthHandler_VerifyEmail tests email verification endpoint
 func TestAuthHandler_VerifyEmail(t *testing.T) {
 	db := testutil.SetupTestDB(t)
@@ -633,7 +961,7 @@ func TestAuthHandler_VerifyEmail(t *testing.T) {
 	handler := NewAuthHandler(db, cfg)
 
 	// Create unverified user with verification token
-	authService := services.NewAuthService(cfg.JWTSecret, cfg.JWTExpirationHours)
+	authService := services.NewAuthService(cfg.JWTSecret, cfg.GetAccessTokenMinutes())
 	hash, _ := authService.HashPassword("Test1234")
 	token, _ := authService.GenerateToken()
 	tokenExpires := time.Now().Add(24 * time.Hour)
@@ -899,8 +1227,17 @@ func TestAuthHandler_ResetPassword(t *testing.T) {
 	})
 
 	t.Run("invalid password - too short", func(t *testing.T) {
+		// The password is only checked for a valid token
+		userID := testutil.SeedTestUser(t, db, "resetshort@example.com", "Short User", "green")
+		resetToken, _ := authService.GenerateToken()
+		expires := time.Now().Add(1 * time.Hour)
+		user, _ := userRepo.FindByID(userID)
+		user.PasswordResetToken = &resetToken
+		user.PasswordResetExpires = &expires
+		userRepo.Update(user)
+
 		reqBody := map[string]string{
-			"token":            "some-token",
+			"token":            resetToken,
 			"password":         "short",
 			"confirm_password": "short",
 		}
@@ -978,6 +1315,250 @@ func TestAuthHandler_ResetPassword(t *testing.T) {
 	})
 }
 
+// TestAuthHandler_MagicLinkLogin tests requesting a login link and logging in with it
+func TestAuthHandler_MagicLinkLogin(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	cfg := &config.Config{JWTSecret: "test-secret", JWTExpirationHours: 24}
+	handler := NewAuthHandler(db, cfg)
+	magicLinks := services.NewMagicLinkService(db, cfg)
+	settingsRepo := repository.NewSettingsRepository(db)
+
+	userID := testutil.SeedTestUser(t, db, "magic@example.com", "Magic User", "green")
+
+	post := func(handle http.HandlerFunc, body interface{}) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		json.NewEncoder(&buf).Encode(body)
+		req := httptest.NewRequest("POST", "/api/auth/magic-link", &buf)
+		rec := httptest.NewRecorder()
+		handle(rec, req)
+		return rec
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		if rec := post(handler.RequestMagicLink, map[string]string{"email": "magic@example.com"}); rec.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403, got %d", rec.Code)
+		}
+		if rec := post(handler.LoginWithMagicLink, map[string]string{"token": "x"}); rec.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403, got %d", rec.Code)
+		}
+	})
+
+	settingsRepo.Update(models.SettingMagicLinkLogin, "true")
+
+	t.Run("same response for unknown accounts", func(t *testing.T) {
+		for _, email := range []string{"magic@example.com", "unknown@example.com"} {
+			rec := post(handler.RequestMagicLink, map[string]string{"email": email})
+			if rec.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+			}
+			var response models.MagicLinkResponse
+			json.Unmarshal(rec.Body.Bytes(), &response)
+			if response.DeviceToken == "" || response.ExpiresIn != 900 {
+				t.Errorf("Unexpected response for %s: %s", email, rec.Body.String())
+			}
+		}
+
+		var count int
+		db.QueryRow("SELECT COUNT(*) FROM magic_links WHERE user_id = ?", userID).Scan(&count)
+		if count != 1 {
+			t.Errorf("Expected 1 link for the user, got %d", count)
+		}
+	})
+
+	t.Run("login with the link", func(t *testing.T) {
+		token, _ := magicLinks.Create(userID, "device")
+
+		if rec := post(handler.LoginWithMagicLink, map[string]string{"token": token, "device_token": "other"}); rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 in another browser, got %d", rec.Code)
+		}
+
+		rec := post(handler.LoginWithMagicLink, map[string]string{"token": token, "device_token": "device"})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var response models.LoginResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		if response.Token == "" || response.User.ID != userID {
+			t.Errorf("Expected tokens for the user, got %s", rec.Body.String())
+		}
+
+		if rec := post(handler.LoginWithMagicLink, map[string]string{"token": token, "device_token": "device"}); rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for a used link, got %d", rec.Code)
+		}
+	})
+
+	t.Run("admins still need 2FA", func(t *testing.T) {
+		db.Exec("UPDATE users SET is_admin = 1 WHERE id = ?", userID)
+		token, _ := magicLinks.Create(userID, "device")
+
+		rec := post(handler.LoginWithMagicLink, map[string]string{"token": token, "device_token": "device"})
+		var challenge models.TwoFactorChallengeResponse
+		json.Unmarshal(rec.Body.Bytes(), &challenge)
+		if rec.Code != http.StatusOK || !challenge.TwoFactorSetupRequired || challenge.ChallengeToken == "" {
+			t.Errorf("Expected the 2FA setup, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+}
+
+// TestAuthHandler_OIDCLogin tests the single sign-on against the mock provider, from the redirect to the tokens
+func TestAuthHandler_OIDCLogin(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	provider := testutil.NewMockOIDCProvider(t)
+	cfg := &config.Config{
+		JWTSecret:          "test-secret",
+		JWTExpirationHours: 24,
+		BaseURL:            "http://localhost:8080",
+		OIDCIssuerURL:      provider.Issuer(),
+		OIDCClientID:       provider.ClientID,
+		OIDCClientSecret:   provider.ClientSecret,
+		OIDCProviderName:   "Tierheim SSO",
+		OIDCGroupsClaim:    "groups",
+	}
+	handler := NewAuthHandler(db, cfg)
+
+	userID := testutil.SeedTestUser(t, db, "staff@example.com", "Staff", "green")
+
+	// Follows the redirects like a browser and returns where the callback sends it
+	login := func(t *testing.T, withCookie bool) *url.URL {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.OIDCLogin(rec, httptest.NewRequest("GET", "/api/auth/oidc/login", nil))
+		if rec.Code != http.StatusFound {
+			t.Fatalf("Expected a redirect to the provider, got %d", rec.Code)
+		}
+		query := provider.Login(t, rec.Header().Get("Location"))
+
+		req := httptest.NewRequest("GET", "/api/auth/oidc/callback?"+query.Encode(), nil)
+		if withCookie {
+			for _, cookie := range rec.Result().Cookies() {
+				req.AddCookie(cookie)
+			}
+		}
+		rec = httptest.NewRecorder()
+		handler.OIDCCallback(rec, req)
+		if rec.Code != http.StatusFound {
+			t.Fatalf("Expected a redirect to the login page, got %d", rec.Code)
+		}
+		location, _ := url.Parse(rec.Header().Get("Location"))
+		return location
+	}
+	finish := func(h *AuthHandler, code string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(models.OIDCLoginRequest{Code: code})
+		rec := httptest.NewRecorder()
+		h.LoginWithOIDC(rec, httptest.NewRequest("POST", "/api/auth/oidc/finish", bytes.NewReader(body)))
+		return rec
+	}
+
+	t.Run("status", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.OIDCStatus(rec, httptest.NewRequest("GET", "/api/auth/oidc", nil))
+		var status models.OIDCStatus
+		json.Unmarshal(rec.Body.Bytes(), &status)
+		if !status.Enabled || status.ProviderName != "Tierheim SSO" {
+			t.Errorf("Unexpected status %s", rec.Body.String())
+		}
+
+		disabled := NewAuthHandler(db, &config.Config{JWTSecret: "test-secret"})
+		if rec := finish(disabled, "code"); rec.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403 without configuration, got %d", rec.Code)
+		}
+	})
+
+	t.Run("login", func(t *testing.T) {
+		provider.Email = "staff@example.com"
+		location := login(t, true)
+		code := location.Query().Get("oidc_code")
+		if location.Path != "/login.html" || code == "" {
+			t.Fatalf("Expected a login code, got %s", location)
+		}
+
+		rec := finish(handler, code)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var response models.LoginResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		if response.Token == "" || response.User.ID != userID {
+			t.Errorf("Expected tokens for the user, got %s", rec.Body.String())
+		}
+
+		if rec := finish(handler, code); rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for a used login code, got %d", rec.Code)
+		}
+	})
+
+	t.Run("state bound to the browser", func(t *testing.T) {
+		if location := login(t, false); location.Query().Get("oidc_error") != "invalid_oidc_login" {
+			t.Errorf("Expected invalid_oidc_login without the cookie, got %s", location)
+		}
+	})
+
+	t.Run("unknown account", func(t *testing.T) {
+		provider.Subject = "staff-2"
+		provider.Email = "unknown@example.com"
+		if location := login(t, true); location.Query().Get("oidc_error") != "oidc_account_not_found" {
+			t.Errorf("Expected oidc_account_not_found, got %s", location)
+		}
+	})
+}
+
+// TestAuthHandler_LoginLockout tests locking an account after repeated failed logins
+func TestAuthHandler_LoginLockout(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	cfg := &config.Config{JWTSecret: "test-secret"}
+	handler := NewAuthHandler(db, cfg)
+
+	userID := testutil.SeedTestUser(t, db, "locked@example.com", "Locked", "green")
+	hash, _ := services.NewAuthService(cfg.JWTSecret, cfg.GetAccessTokenMinutes()).HashPassword("Test1234")
+	db.Exec("UPDATE users SET password_hash = ? WHERE id = ?", hash, userID)
+
+	login := func(email, password string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"email": email, "password": password})
+		rec := httptest.NewRecorder()
+		handler.Login(rec, httptest.NewRequest("POST", "/api/auth/login", bytes.NewReader(body)))
+		return rec
+	}
+
+	t.Run("successful login resets failures", func(t *testing.T) {
+		for i := 0; i < 4; i++ {
+			login("locked@example.com", "WrongPassword")
+		}
+		if rec := login("locked@example.com", "Test1234"); rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if rec := login("locked@example.com", "WrongPassword"); rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 after the reset, got %d", rec.Code)
+		}
+	})
+
+	t.Run("locks after five failures", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			login("locked@example.com", "WrongPassword")
+		}
+		rec := login("locked@example.com", "WrongPassword")
+		if rec.Code != http.StatusTooManyRequests || !strings.Contains(rec.Body.String(), "account_locked") {
+			t.Fatalf("Expected account_locked, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if rec.Header().Get("Retry-After") != "60" {
+			t.Errorf("Expected Retry-After 60, got %q", rec.Header().Get("Retry-After"))
+		}
+
+		if rec := login("Locked@Example.com", "Test1234"); rec.Code != http.StatusTooManyRequests {
+			t.Errorf("Expected the correct password to be rejected while locked, got %d", rec.Code)
+		}
+	})
+
+	t.Run("locks unknown addresses alike", func(t *testing.T) {
+		var rec *httptest.ResponseRecorder
+		for i := 0; i < 5; i++ {
+			rec = login("unknown@example.com", "WrongPassword")
+		}
+		if rec.Code != http.StatusTooManyRequests {
+			t.Errorf("Expected status 429, got %d", rec.Code)
+		}
+	})
+}
+
 // Helper function to add user context to request
 // Note: Some handlers use middleware constants, others use string keys
 // This helper adds both for compatibility
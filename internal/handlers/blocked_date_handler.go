@@ -23,17 +23,11 @@ type BlockedDateHandler struct {
 	bookingRepo     *repository.BookingRepository
 	userRepo        *repository.UserRepository
 	dogRepo         *repository.DogRepository
-	emailService    *services.EmailService
+	outbox          *services.OutboxService
 }
 
 // NewBlockedDateHandler creates a new blocked date handler
 func NewBlockedDateHandler(db *sql.DB, cfg *config.Config) *BlockedDateHandler {
-	// Initialize email service (fail gracefully if email not configured)
-	emailService, err := services.NewEmailService(services.ConfigToEmailConfig(cfg))
-	if err != nil {
-		fmt.Printf("Warning: Failed to initialize email service in BlockedDateHandler: %v\n", err)
-	}
-
 	return &BlockedDateHandler{
 		db:              db,
 		cfg:             cfg,
@@ -41,7 +35,7 @@ func NewBlockedDateHandler(db *sql.DB, cfg *config.Config) *BlockedDateHandler {
 		bookingRepo:     repository.NewBookingRepository(db),
 		userRepo:        repository.NewUserRepository(db),
 		dogRepo:         repository.NewDogRepository(db),
-		emailService:    emailService,
+		outbox:          services.NewOutboxService(db),
 	}
 }
 
@@ -49,7 +43,7 @@ func NewBlockedDateHandler(db *sql.DB, cfg *config.Config) *BlockedDateHandler {
 func (h *BlockedDateHandler) ListBlockedDates(w http.ResponseWriter, r *http.Request) {
 	blockedDates, err := h.blockedDateRepo.FindAll()
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get blocked dates")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_blocked_dates")
 		return
 	}
 
@@ -64,13 +58,13 @@ func (h *BlockedDateHandler) CreateBlockedDate(w http.ResponseWriter, r *http.Re
 	// Parse request
 	var req models.CreateBlockedDateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
 		return
 	}
 
 	// Validate request
 	if err := req.Validate(); err != nil {
-		respondError(w, http.StatusBadRequest, err.Error())
+		respondValidationError(w, r, err)
 		return
 	}
 
@@ -81,12 +75,19 @@ func (h *BlockedDateHandler) CreateBlockedDate(w http.ResponseWriter, r *http.Re
 		CreatedBy: userID,
 	}
 
-	if err := h.blockedDateRepo.Create(blockedDate); err != nil {
+	err := h.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		if err := h.blockedDateRepo.WithTx(tx).Create(blockedDate); err != nil {
+			return nil, err
+		}
+		event, err := newBlockedDateEvent(models.EventBlockedDateCreated, userID, blockedDate)
+		return []*models.DomainEvent{event}, err
+	})
+	if err != nil {
 		if err.Error() == "date is already blocked" {
-			respondError(w, http.StatusConflict, err.Error())
+			respondError(w, r, http.StatusConflict, "date_already_blocked")
 			return
 		}
-		respondError(w, http.StatusInternalServerError, "Failed to create blocked date")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_create_blocked_date")
 		return
 	}
 
@@ -103,40 +104,41 @@ func (h *BlockedDateHandler) CreateBlockedDate(w http.ResponseWriter, r *http.Re
 		// Continue even if we can't find bookings - at least the date is blocked
 	}
 
-	// Cancel each booking and notify users
+	// Cancel each booking, users are notified through the booking.cancelled events
 	cancelledCount := 0
 	cancellationReason := fmt.Sprintf("Datum wurde durch Administration gesperrt: %s", req.Reason)
 
 	for _, booking := range bookings {
-		// Cancel the booking
-		if err := h.bookingRepo.Cancel(booking.ID, &cancellationReason); err != nil {
-			fmt.Printf("Warning: Failed to cancel booking %d: %v\n", booking.ID, err)
-			continue
+		// Names for the event, the booking is cancelled even if they cannot be loaded
+		userName, dogName := "", ""
+		if user, err := h.userRepo.FindByID(booking.UserID); err != nil {
+			fmt.Printf("Warning: Failed to get user %d for cancellation: %v\n", booking.UserID, err)
+		} else if user != nil {
+			userName = user.Name
 		}
-		cancelledCount++
-
-		// Get user details for email
-		user, err := h.userRepo.FindByID(booking.UserID)
-		if err != nil {
-			fmt.Printf("Warning: Failed to get user %d for cancellation email: %v\n", booking.UserID, err)
-			continue
+		if dog, err := h.dogRepo.FindByID(booking.DogID); err != nil {
+			fmt.Printf("Warning: Failed to get dog %d for cancellation: %v\n", booking.DogID, err)
+		} else if dog != nil {
+			dogName = dog.Name
 		}
 
-		// Get dog details for email
-		dog, err := h.dogRepo.FindByID(booking.DogID)
+		err := h.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+			if err := h.bookingRepo.WithTx(tx).Cancel(booking.ID, &cancellationReason); err != nil {
+				return nil, err
+			}
+
+			booking.Status = "cancelled"
+			data := models.NewBookingEventData(booking, dogName, userName)
+			data.Reason = &cancellationReason
+			data.CancelledBy = models.CancelledByBlockedDate
+			event, err := models.NewBookingEvent(models.EventBookingCancelled, &userID, data)
+			return []*models.DomainEvent{event}, err
+		})
 		if err != nil {
-			fmt.Printf("Warning: Failed to get dog %d for cancellation email: %v\n", booking.DogID, err)
+			fmt.Printf("Warning: Failed to cancel booking %d: %v\n", booking.ID, err)
 			continue
 		}
-
-		// Send cancellation email (in goroutine, don't block)
-		if h.emailService != nil && user.Email != nil {
-			go func(userEmail, userName, dogName, date, scheduledTime, reason string) {
-				if err := h.emailService.SendAdminCancellation(userEmail, userName, dogName, date, scheduledTime, reason); err != nil {
-					fmt.Printf("Warning: Failed to send cancellation email to %s: %v\n", userEmail, err)
-				}
-			}(*user.Email, user.Name, dog.Name, booking.Date, booking.ScheduledTime, cancellationReason)
-		}
+		cancelledCount++
 	}
 
 	// Return response with cancellation count
@@ -144,6 +146,8 @@ func (h *BlockedDateHandler) CreateBlockedDate(w http.ResponseWriter, r *http.Re
 		"blocked_date":      blockedDate,
 		"cancelled_bookings": cancelledCount,
 	}
+	middleware.AuditTarget(r, "blocked-date", blockedDate.ID)
+	middleware.AuditChange(r, nil, response)
 
 	respondJSON(w, http.StatusCreated, response)
 }
@@ -154,15 +158,40 @@ func (h *BlockedDateHandler) DeleteBlockedDate(w http.ResponseWriter, r *http.Re
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid blocked date ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_blocked_date_id")
+		return
+	}
+
+	blockedDate, err := h.blockedDateRepo.FindByID(id)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_delete_blocked_date")
 		return
 	}
 
-	// Delete blocked date
-	if err := h.blockedDateRepo.Delete(id); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to delete blocked date")
+	// Delete blocked date, deleting a date that does not exist succeeds without an event
+	adminID, _ := r.Context().Value(middleware.UserIDKey).(int)
+	err = h.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		if err := h.blockedDateRepo.WithTx(tx).Delete(id); err != nil {
+			return nil, err
+		}
+		if blockedDate == nil {
+			return nil, nil
+		}
+		event, err := newBlockedDateEvent(models.EventBlockedDateDeleted, adminID, blockedDate)
+		return []*models.DomainEvent{event}, err
+	})
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_delete_blocked_date")
 		return
 	}
+	if blockedDate != nil {
+		middleware.AuditChange(r, blockedDate, nil)
+	}
 
 	respondJSON(w, http.StatusOK, map[string]string{"message": "Blocked date deleted successfully"})
 }
+
+func newBlockedDateEvent(eventType string, adminID int, blockedDate *models.BlockedDate) (*models.DomainEvent, error) {
+	return models.NewDomainEvent(eventType, models.AggregateBlockedDate, blockedDate.ID, &adminID,
+		&models.BlockedDateEventData{BlockedDateID: blockedDate.ID, Date: blockedDate.Date, Reason: blockedDate.Reason})
+}
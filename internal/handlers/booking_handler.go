@@ -27,17 +27,11 @@ type BookingHandler struct {
 	blockedDateRepo      *repository.BlockedDateRepository
 	settingsRepo         *repository.SettingsRepository
 	bookingTimeService   *services.BookingTimeService
-	emailService         *services.EmailService
+	outbox               *services.OutboxService // Notifications, activity and webhooks are driven by the recorded events
 }
 
 // NewBookingHandler creates a new booking handler
 func NewBookingHandler(db *sql.DB, cfg *config.Config) *BookingHandler {
-	emailService, err := services.NewEmailService(services.ConfigToEmailConfig(cfg))
-	if err != nil {
-		// Log error but don't fail - emails will fail gracefully
-		fmt.Printf("Warning: Failed to initialize email service: %v\n", err)
-	}
-
 	// Initialize booking time service
 	settingsRepo := repository.NewSettingsRepository(db)
 	bookingTimeRepo := repository.NewBookingTimeRepository(db)
@@ -54,7 +48,7 @@ func NewBookingHandler(db *sql.DB, cfg *config.Config) *BookingHandler {
 		blockedDateRepo:      repository.NewBlockedDateRepository(db),
 		settingsRepo:         settingsRepo,
 		bookingTimeService:   bookingTimeService,
-		emailService:         emailService,
+		outbox:               services.NewOutboxService(db),
 	}
 }
 
@@ -63,60 +57,60 @@ func (h *BookingHandler) CreateBooking(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
 	if !ok {
-		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	// Parse request
 	var req models.CreateBookingRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
 		return
 	}
 
 	// Validate request
 	if err := req.Validate(); err != nil {
-		respondError(w, http.StatusBadRequest, err.Error())
+		respondValidationError(w, r, err)
 		return
 	}
 
 	// Get user to check experience level
 	user, err := h.userRepo.FindByID(userID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get user")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_user")
 		return
 	}
 	if user == nil {
-		respondError(w, http.StatusNotFound, "User not found")
+		respondError(w, r, http.StatusNotFound, "user_not_found")
 		return
 	}
 
 	// Check if user is active
 	if !user.IsActive {
-		respondError(w, http.StatusForbidden, "Your account is deactivated")
+		respondError(w, r, http.StatusForbidden, "account_deactivated")
 		return
 	}
 
 	// Get dog
 	dog, err := h.dogRepo.FindByID(req.DogID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get dog")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_dog")
 		return
 	}
 	if dog == nil {
-		respondError(w, http.StatusNotFound, "Dog not found")
+		respondError(w, r, http.StatusNotFound, "dog_not_found")
 		return
 	}
 
 	// Check if dog is available
 	if !dog.IsAvailable {
-		respondError(w, http.StatusBadRequest, "Dog is currently unavailable")
+		respondError(w, r, http.StatusBadRequest, "dog_unavailable")
 		return
 	}
 
 	// Check experience level access
 	if !repository.CanUserAccessDog(user.ExperienceLevel, dog.Category) {
-		respondError(w, http.StatusForbidden, "You don't have the required experience level for this dog")
+		respondError(w, r, http.StatusForbidden, "insufficient_experience_level")
 		return
 	}
 
@@ -124,7 +118,7 @@ func (h *BookingHandler) CreateBooking(w http.ResponseWriter, r *http.Request) {
 	// Parse date in UTC to match how dates are stored
 	bookingDate, parseErr := time.Parse("2006-01-02", req.Date)
 	if parseErr != nil {
-		respondError(w, http.StatusBadRequest, "Invalid date format")
+		respondError(w, r, http.StatusBadRequest, "invalid_date_format")
 		return
 	}
 
@@ -133,14 +127,14 @@ func (h *BookingHandler) CreateBooking(w http.ResponseWriter, r *http.Request) {
 	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
 
 	if bookingDate.Before(today) {
-		respondError(w, http.StatusBadRequest, "Cannot book dates in the past")
+		respondError(w, r, http.StatusBadRequest, "date_in_past")
 		return
 	}
 
 	// Check booking advance limit
 	advanceSetting, err := h.settingsRepo.Get("booking_advance_days")
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get settings")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_settings")
 		return
 	}
 	advanceDays := 14 // default
@@ -149,42 +143,42 @@ func (h *BookingHandler) CreateBooking(w http.ResponseWriter, r *http.Request) {
 	}
 	maxDate := today.AddDate(0, 0, advanceDays)
 	if bookingDate.After(maxDate) {
-		respondError(w, http.StatusBadRequest, fmt.Sprintf("Cannot book more than %d days in advance", advanceDays))
+		respondError(w, r, http.StatusBadRequest, "booking_too_far_in_advance", advanceDays)
 		return
 	}
 
 	// Check if date is blocked
 	isBlocked, err := h.blockedDateRepo.IsBlocked(req.Date)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to check blocked dates")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_check_blocked_dates")
 		return
 	}
 	if isBlocked {
-		respondError(w, http.StatusBadRequest, "This date is blocked")
+		respondError(w, r, http.StatusBadRequest, "date_blocked")
 		return
 	}
 
 	// Check for double-booking
 	isDoubleBooked, err := h.bookingRepo.CheckDoubleBooking(req.DogID, req.Date, req.ScheduledTime)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to check availability")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_check_availability")
 		return
 	}
 	if isDoubleBooked {
-		respondError(w, http.StatusConflict, "This dog is already booked for this time")
+		respondError(w, r, http.StatusConflict, "dog_already_booked")
 		return
 	}
 
 	// Validate booking time (check if time is allowed/blocked)
 	if err := h.bookingTimeService.ValidateBookingTime(req.Date, req.ScheduledTime); err != nil {
-		respondError(w, http.StatusBadRequest, err.Error())
+		respondValidationError(w, r, err)
 		return
 	}
 
 	// Check if booking requires approval
 	requiresApproval, err := h.bookingTimeService.RequiresApproval(req.ScheduledTime)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to check approval requirements")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_check_approval_requirements")
 		return
 	}
 
@@ -204,25 +198,24 @@ func (h *BookingHandler) CreateBooking(w http.ResponseWriter, r *http.Request) {
 		booking.ApprovalStatus = "approved"
 	}
 
-	if err := h.bookingRepo.Create(booking); err != nil {
+	err = h.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		if err := h.bookingRepo.WithTx(tx).Create(booking); err != nil {
+			return nil, err
+		}
+		event, err := models.NewBookingEvent(models.EventBookingCreated, &userID, models.NewBookingEventData(booking, dog.Name, user.Name))
+		return []*models.DomainEvent{event}, err
+	})
+	if err != nil {
 		// BUGFIX #2: Detect UNIQUE constraint violation (race condition scenario)
 		// SQLite returns error containing "UNIQUE constraint failed" when duplicate booking occurs
 		if strings.Contains(err.Error(), "UNIQUE constraint") || strings.Contains(err.Error(), "unique constraint") {
-			respondError(w, http.StatusConflict, "This dog is already booked for this time")
+			respondError(w, r, http.StatusConflict, "dog_already_booked")
 			return
 		}
-		respondError(w, http.StatusInternalServerError, "Failed to create booking")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_create_booking")
 		return
 	}
 
-	// Update user last activity
-	h.userRepo.UpdateLastActivity(userID)
-
-	// Send confirmation email
-	if user.Email != nil && h.emailService != nil {
-		go h.emailService.SendBookingConfirmation(*user.Email, user.Name, dog.Name, booking.Date, booking.ScheduledTime)
-	}
-
 	respondJSON(w, http.StatusCreated, booking)
 }
 
@@ -230,7 +223,7 @@ func (h *BookingHandler) CreateBooking(w http.ResponseWriter, r *http.Request) {
 func (h *BookingHandler) ListBookings(w http.ResponseWriter, r *http.Request) {
 	// Get user ID and admin status from context
 	userID, _ := r.Context().Value(middleware.UserIDKey).(int)
-	isAdmin, _ := r.Context().Value(middleware.IsAdminKey).(bool)
+	isAdmin := middleware.HasPermission(r, models.PermissionManageBookings)
 
 	// Parse query parameters
 	filter := &models.BookingFilterRequest{}
@@ -267,7 +260,7 @@ func (h *BookingHandler) ListBookings(w http.ResponseWriter, r *http.Request) {
 	// Get bookings
 	bookings, err := h.bookingRepo.FindAll(filter)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get bookings")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_bookings")
 		return
 	}
 
@@ -280,28 +273,28 @@ func (h *BookingHandler) GetBooking(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid booking ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_booking_id")
 		return
 	}
 
 	// Get user ID and admin status
 	userID, _ := r.Context().Value(middleware.UserIDKey).(int)
-	isAdmin, _ := r.Context().Value(middleware.IsAdminKey).(bool)
+	isAdmin := middleware.HasPermission(r, models.PermissionManageBookings)
 
 	// Get booking
 	booking, err := h.bookingRepo.FindByID(id)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get booking")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_booking")
 		return
 	}
 	if booking == nil {
-		respondError(w, http.StatusNotFound, "Booking not found")
+		respondError(w, r, http.StatusNotFound, "booking_not_found")
 		return
 	}
 
 	// Check authorization (user can only see their own bookings)
 	if !isAdmin && booking.UserID != userID {
-		respondError(w, http.StatusForbidden, "Access denied")
+		respondError(w, r, http.StatusForbidden, "access_denied")
 		return
 	}
 
@@ -314,13 +307,13 @@ func (h *BookingHandler) CancelBooking(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid booking ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_booking_id")
 		return
 	}
 
 	// Get user ID and admin status
 	userID, _ := r.Context().Value(middleware.UserIDKey).(int)
-	isAdmin, _ := r.Context().Value(middleware.IsAdminKey).(bool)
+	isAdmin := middleware.HasPermission(r, models.PermissionManageBookings)
 
 	// Parse request
 	var req models.CancelBookingRequest
@@ -332,23 +325,27 @@ func (h *BookingHandler) CancelBooking(w http.ResponseWriter, r *http.Request) {
 	// Get booking
 	booking, err := h.bookingRepo.FindByIDWithDetails(id)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get booking")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_booking")
 		return
 	}
 	if booking == nil {
-		respondError(w, http.StatusNotFound, "Booking not found")
+		respondError(w, r, http.StatusNotFound, "booking_not_found")
 		return
 	}
 
 	// Check authorization
 	if !isAdmin && booking.UserID != userID {
-		respondError(w, http.StatusForbidden, "Access denied")
+		respondError(w, r, http.StatusForbidden, "access_denied")
 		return
 	}
 
 	// Check if already cancelled or completed
 	if booking.Status != "scheduled" {
-		respondError(w, http.StatusBadRequest, "Booking is already "+booking.Status)
+		code := "booking_already_completed"
+		if booking.Status == "cancelled" {
+			code = "booking_already_cancelled"
+		}
+		respondError(w, r, http.StatusBadRequest, code)
 		return
 	}
 
@@ -356,7 +353,7 @@ func (h *BookingHandler) CancelBooking(w http.ResponseWriter, r *http.Request) {
 	if !isAdmin {
 		noticeSetting, err := h.settingsRepo.Get("cancellation_notice_hours")
 		if err != nil {
-			respondError(w, http.StatusInternalServerError, "Failed to get settings")
+			respondError(w, r, http.StatusInternalServerError, "failed_to_get_settings")
 			return
 		}
 		noticeHours := 12 // default
@@ -377,7 +374,7 @@ func (h *BookingHandler) CancelBooking(w http.ResponseWriter, r *http.Request) {
 			dateOnly, err = time.Parse("2006-01-02", booking.Date)
 			if err != nil {
 				fmt.Printf("[CANCEL ERROR] Failed to parse booking date: %v\n", err)
-				respondError(w, http.StatusInternalServerError, "Failed to parse booking date: "+err.Error())
+				respondError(w, r, http.StatusInternalServerError, "failed_to_parse_booking_date")
 				return
 			}
 		}
@@ -390,7 +387,7 @@ func (h *BookingHandler) CancelBooking(w http.ResponseWriter, r *http.Request) {
 		bookingTime, err := time.Parse("2006-01-02 15:04", bookingDateTime)
 		if err != nil {
 			fmt.Printf("[CANCEL ERROR] Failed to parse booking datetime: %v\n", err)
-			respondError(w, http.StatusInternalServerError, "Failed to parse booking date/time: "+err.Error())
+			respondError(w, r, http.StatusInternalServerError, "failed_to_parse_booking_date")
 			return
 		}
 
@@ -400,30 +397,34 @@ func (h *BookingHandler) CancelBooking(w http.ResponseWriter, r *http.Request) {
 			bookingTime, now, hoursUntilBooking, noticeHours)
 
 		if hoursUntilBooking < float64(noticeHours) {
-			respondError(w, http.StatusBadRequest, fmt.Sprintf("Buchungen müssen mindestens %d Stunden im Voraus storniert werden. Verbleibende Zeit: %.1f Stunden", noticeHours, hoursUntilBooking))
+			respondError(w, r, http.StatusBadRequest, "cancellation_notice_too_short", noticeHours, hoursUntilBooking)
 			return
 		}
 	}
 
 	// Cancel booking
-	if err := h.bookingRepo.Cancel(id, req.Reason); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to cancel booking")
-		return
-	}
-
-	// Update user last activity
-	h.userRepo.UpdateLastActivity(userID)
+	err = h.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		if err := h.bookingRepo.WithTx(tx).Cancel(id, req.Reason); err != nil {
+			return nil, err
+		}
 
-	// Send cancellation email
-	if booking.User.Email != nil && h.emailService != nil {
-		if isAdmin && req.Reason != nil {
-			// Admin cancelled
-			go h.emailService.SendAdminCancellation(*booking.User.Email, booking.User.Name, booking.Dog.Name, booking.Date, booking.ScheduledTime, *req.Reason)
-		} else {
-			// User cancelled
-			go h.emailService.SendBookingCancellation(*booking.User.Email, booking.User.Name, booking.Dog.Name, booking.Date, booking.ScheduledTime)
+		booking.Status = "cancelled"
+		data := models.NewBookingEventData(booking, booking.Dog.Name, booking.User.Name)
+		data.Reason = req.Reason
+		data.CancelledBy = models.CancelledByUser
+		if isAdmin {
+			data.CancelledBy = models.CancelledByAdmin
 		}
+		event, err := models.NewBookingEvent(models.EventBookingCancelled, &userID, data)
+		return []*models.DomainEvent{event}, err
+	})
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_cancel_booking")
+		return
 	}
+	middleware.AuditChange(r,
+		map[string]interface{}{"status": "scheduled"},
+		map[string]interface{}{"status": "cancelled", "reason": req.Reason})
 
 	respondJSON(w, http.StatusOK, map[string]string{"message": "Booking cancelled successfully"})
 }
@@ -434,7 +435,7 @@ func (h *BookingHandler) AddNotes(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid booking ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_booking_id")
 		return
 	}
 
@@ -444,41 +445,58 @@ func (h *BookingHandler) AddNotes(w http.ResponseWriter, r *http.Request) {
 	// Parse request
 	var req models.AddNotesRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
 		return
 	}
 
 	if req.Notes == "" {
-		respondError(w, http.StatusBadRequest, "Notes cannot be empty")
+		respondError(w, r, http.StatusBadRequest, "notes_required")
 		return
 	}
 
 	// Get booking
 	booking, err := h.bookingRepo.FindByID(id)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get booking")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_booking")
 		return
 	}
 	if booking == nil {
-		respondError(w, http.StatusNotFound, "Booking not found")
+		respondError(w, r, http.StatusNotFound, "booking_not_found")
 		return
 	}
 
 	// Check authorization
 	if booking.UserID != userID {
-		respondError(w, http.StatusForbidden, "Access denied")
+		respondError(w, r, http.StatusForbidden, "access_denied")
 		return
 	}
 
 	// Check if booking is completed
 	if booking.Status != "completed" {
-		respondError(w, http.StatusBadRequest, "Can only add notes to completed bookings")
+		respondError(w, r, http.StatusBadRequest, "notes_only_for_completed_bookings")
 		return
 	}
 
+	// Names for the event, the dog of a completed walk may have been deleted since
+	dogName, userName := "", ""
+	if dog, err := h.dogRepo.FindByID(booking.DogID); err == nil && dog != nil {
+		dogName = dog.Name
+	}
+	if user, err := h.userRepo.FindByID(userID); err == nil && user != nil {
+		userName = user.Name
+	}
+
 	// Add notes
-	if err := h.bookingRepo.AddNotes(id, req.Notes); err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+	err = h.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		if err := h.bookingRepo.WithTx(tx).AddNotes(id, req.Notes); err != nil {
+			return nil, err
+		}
+		data := models.NewBookingEventData(booking, dogName, userName)
+		event, err := models.NewBookingEvent(models.EventBookingNotesAdded, &userID, data)
+		return []*models.DomainEvent{event}, err
+	})
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_add_notes")
 		return
 	}
 
@@ -491,7 +509,7 @@ func (h *BookingHandler) MoveBooking(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid booking ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_booking_id")
 		return
 	}
 
@@ -501,30 +519,30 @@ func (h *BookingHandler) MoveBooking(w http.ResponseWriter, r *http.Request) {
 	// Parse request
 	var req models.MoveBookingRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
 		return
 	}
 
 	// Validate request
 	if err := req.Validate(); err != nil {
-		respondError(w, http.StatusBadRequest, err.Error())
+		respondValidationError(w, r, err)
 		return
 	}
 
 	// Get booking with details
 	booking, err := h.bookingRepo.FindByIDWithDetails(id)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get booking")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_booking")
 		return
 	}
 	if booking == nil {
-		respondError(w, http.StatusNotFound, "Booking not found")
+		respondError(w, r, http.StatusNotFound, "booking_not_found")
 		return
 	}
 
 	// Check if booking can be moved (only scheduled bookings)
 	if booking.Status != "scheduled" {
-		respondError(w, http.StatusBadRequest, "Can only move scheduled bookings")
+		respondError(w, r, http.StatusBadRequest, "can_only_move_scheduled_bookings")
 		return
 	}
 
@@ -535,22 +553,22 @@ func (h *BookingHandler) MoveBooking(w http.ResponseWriter, r *http.Request) {
 	// Check if new date is blocked
 	isBlocked, err := h.blockedDateRepo.IsBlocked(req.Date)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to check blocked dates")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_check_blocked_dates")
 		return
 	}
 	if isBlocked {
-		respondError(w, http.StatusBadRequest, "The new date is blocked")
+		respondError(w, r, http.StatusBadRequest, "new_date_blocked")
 		return
 	}
 
 	// Check for double-booking at new time
 	isDoubleBooked, err := h.bookingRepo.CheckDoubleBooking(booking.DogID, req.Date, req.ScheduledTime)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to check availability")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_check_availability")
 		return
 	}
 	if isDoubleBooked {
-		respondError(w, http.StatusConflict, "Dog is already booked for this time")
+		respondError(w, r, http.StatusConflict, "dog_already_booked")
 		return
 	}
 
@@ -558,27 +576,27 @@ func (h *BookingHandler) MoveBooking(w http.ResponseWriter, r *http.Request) {
 	booking.Date = req.Date
 	booking.ScheduledTime = req.ScheduledTime
 
-	if err := h.bookingRepo.Update(booking); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to move booking")
-		return
-	}
-
-	// Update user last activity
-	h.userRepo.UpdateLastActivity(userID)
+	err = h.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		if err := h.bookingRepo.WithTx(tx).Update(booking); err != nil {
+			return nil, err
+		}
 
-	// Send email notification to user
-	if booking.User.Email != nil && h.emailService != nil {
-		go h.emailService.SendBookingMoved(
-			*booking.User.Email,
-			booking.User.Name,
-			booking.Dog.Name,
-			oldDate,
-			oldTime,
-			req.Date,
-			req.ScheduledTime,
-			req.Reason,
-		)
+		data := models.NewBookingEventData(booking, booking.Dog.Name, booking.User.Name)
+		data.PreviousDate = oldDate
+		data.PreviousScheduledTime = oldTime
+		if req.Reason != "" {
+			data.Reason = &req.Reason
+		}
+		event, err := models.NewBookingEvent(models.EventBookingMoved, &userID, data)
+		return []*models.DomainEvent{event}, err
+	})
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_move_booking")
+		return
 	}
+	middleware.AuditChange(r,
+		map[string]string{"date": oldDate, "scheduled_time": oldTime},
+		map[string]string{"date": req.Date, "scheduled_time": req.ScheduledTime, "reason": req.Reason})
 
 	respondJSON(w, http.StatusOK, map[string]string{"message": "Booking moved successfully"})
 }
@@ -589,12 +607,12 @@ func (h *BookingHandler) GetCalendarData(w http.ResponseWriter, r *http.Request)
 	vars := mux.Vars(r)
 	year, err := strconv.Atoi(vars["year"])
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid year")
+		respondError(w, r, http.StatusBadRequest, "invalid_year")
 		return
 	}
 	month, err := strconv.Atoi(vars["month"])
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid month")
+		respondError(w, r, http.StatusBadRequest, "invalid_month")
 		return
 	}
 
@@ -609,14 +627,14 @@ func (h *BookingHandler) GetCalendarData(w http.ResponseWriter, r *http.Request)
 	}
 	bookings, err := h.bookingRepo.FindAll(filter)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get bookings")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_bookings")
 		return
 	}
 
 	// Get blocked dates
 	blockedDates, err := h.blockedDateRepo.FindAll()
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get blocked dates")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_blocked_dates")
 		return
 	}
 
@@ -670,16 +688,15 @@ func (h *BookingHandler) GetCalendarData(w http.ResponseWriter, r *http.Request)
 // GetPendingApprovals returns all bookings awaiting approval (admin only)
 // GET /api/bookings/pending-approvals
 func (h *BookingHandler) GetPendingApprovals(w http.ResponseWriter, r *http.Request) {
-	// Check admin permission
-	isAdmin, ok := r.Context().Value(middleware.IsAdminKey).(bool)
-	if !ok || !isAdmin {
-		respondError(w, http.StatusForbidden, "Admin access required")
+	// Check permission
+	if !middleware.HasPermission(r, models.PermissionManageBookings) {
+		respondError(w, r, http.StatusForbidden, "permission_denied")
 		return
 	}
 
 	bookings, err := h.bookingRepo.GetPendingApprovalBookings()
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to load pending bookings")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_pending_bookings")
 		return
 	}
 
@@ -689,10 +706,9 @@ func (h *BookingHandler) GetPendingApprovals(w http.ResponseWriter, r *http.Requ
 // ApprovePendingBooking approves a morning walk booking
 // PUT /api/bookings/:id/approve
 func (h *BookingHandler) ApprovePendingBooking(w http.ResponseWriter, r *http.Request) {
-	// Check admin permission
-	isAdmin, ok := r.Context().Value(middleware.IsAdminKey).(bool)
-	if !ok || !isAdmin {
-		respondError(w, http.StatusForbidden, "Admin access required")
+	// Check permission
+	if !middleware.HasPermission(r, models.PermissionManageBookings) {
+		respondError(w, r, http.StatusForbidden, "permission_denied")
 		return
 	}
 
@@ -701,7 +717,7 @@ func (h *BookingHandler) ApprovePendingBooking(w http.ResponseWriter, r *http.Re
 	// Extract booking ID from path
 	pathParts := strings.Split(r.URL.Path, "/")
 	if len(pathParts) < 5 {
-		respondError(w, http.StatusBadRequest, "Invalid booking ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_booking_id")
 		return
 	}
 	// Path is /api/bookings/{id}/approve, so ID is at index len-2
@@ -709,27 +725,26 @@ func (h *BookingHandler) ApprovePendingBooking(w http.ResponseWriter, r *http.Re
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid booking ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_booking_id")
 		return
 	}
 
-	if err := h.bookingRepo.ApproveBooking(id, adminID); err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
-		return
-	}
+	err = h.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		bookingRepo := h.bookingRepo.WithTx(tx)
+		if err := bookingRepo.ApproveBooking(id, adminID); err != nil {
+			return nil, err
+		}
 
-	// Send email notification to user
-	if h.emailService != nil {
-		booking, err := h.bookingRepo.FindByIDWithDetails(id)
-		if err == nil && booking != nil && booking.User != nil && booking.User.Email != nil && *booking.User.Email != "" {
-			go h.emailService.SendBookingApproved(
-				*booking.User.Email,
-				booking.User.Name,
-				booking.Dog.Name,
-				booking.Date,
-				booking.ScheduledTime,
-			)
+		booking, err := bookingRepo.FindByIDWithDetails(id)
+		if err != nil || booking == nil || booking.User == nil || booking.Dog == nil {
+			return nil, err
 		}
+		event, err := models.NewBookingEvent(models.EventBookingApproved, &adminID, models.NewBookingEventData(booking, booking.Dog.Name, booking.User.Name))
+		return []*models.DomainEvent{event}, err
+	})
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_approve_booking")
+		return
 	}
 
 	respondJSON(w, http.StatusOK, map[string]string{
@@ -740,10 +755,9 @@ func (h *BookingHandler) ApprovePendingBooking(w http.ResponseWriter, r *http.Re
 // RejectPendingBooking rejects a morning walk booking
 // PUT /api/bookings/:id/reject
 func (h *BookingHandler) RejectPendingBooking(w http.ResponseWriter, r *http.Request) {
-	// Check admin permission
-	isAdmin, ok := r.Context().Value(middleware.IsAdminKey).(bool)
-	if !ok || !isAdmin {
-		respondError(w, http.StatusForbidden, "Admin access required")
+	// Check permission
+	if !middleware.HasPermission(r, models.PermissionManageBookings) {
+		respondError(w, r, http.StatusForbidden, "permission_denied")
 		return
 	}
 
@@ -752,7 +766,7 @@ func (h *BookingHandler) RejectPendingBooking(w http.ResponseWriter, r *http.Req
 	// Extract booking ID from path
 	pathParts := strings.Split(r.URL.Path, "/")
 	if len(pathParts) < 5 {
-		respondError(w, http.StatusBadRequest, "Invalid booking ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_booking_id")
 		return
 	}
 	// Path is /api/bookings/{id}/reject, so ID is at index len-2
@@ -760,7 +774,7 @@ func (h *BookingHandler) RejectPendingBooking(w http.ResponseWriter, r *http.Req
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid booking ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_booking_id")
 		return
 	}
 
@@ -768,33 +782,37 @@ func (h *BookingHandler) RejectPendingBooking(w http.ResponseWriter, r *http.Req
 		Reason string `json:"reason"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
 		return
 	}
 
 	if req.Reason == "" {
-		respondError(w, http.StatusBadRequest, "Rejection reason required")
+		respondError(w, r, http.StatusBadRequest, "rejection_reason_required")
 		return
 	}
 
-	// Get booking details before rejecting (for email)
+	// Get booking details before rejecting (for the event)
 	booking, _ := h.bookingRepo.FindByIDWithDetails(id)
 
-	if err := h.bookingRepo.RejectBooking(id, adminID, req.Reason); err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
-		return
-	}
+	err = h.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		if err := h.bookingRepo.WithTx(tx).RejectBooking(id, adminID, req.Reason); err != nil {
+			return nil, err
+		}
+		if booking == nil || booking.User == nil || booking.Dog == nil {
+			return nil, nil
+		}
 
-	// Send email notification to user with reason
-	if h.emailService != nil && booking != nil && booking.User != nil && booking.User.Email != nil && *booking.User.Email != "" {
-		go h.emailService.SendBookingRejected(
-			*booking.User.Email,
-			booking.User.Name,
-			booking.Dog.Name,
-			booking.Date,
-			booking.ScheduledTime,
-			req.Reason,
-		)
+		// Rejected bookings are cancelled
+		booking.Status = "cancelled"
+		booking.ApprovalStatus = "rejected"
+		data := models.NewBookingEventData(booking, booking.Dog.Name, booking.User.Name)
+		data.Reason = &req.Reason
+		event, err := models.NewBookingEvent(models.EventBookingRejected, &adminID, data)
+		return []*models.DomainEvent{event}, err
+	})
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_reject_booking")
+		return
 	}
 
 	respondJSON(w, http.StatusOK, map[string]string{
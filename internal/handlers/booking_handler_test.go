@@ -27,7 +27,7 @@ func TestBookingHandler_CreateBooking(t *testing.T) {
 	handler := NewBookingHandler(db, cfg)
 
 	// Create test user and dog
-	authService := services.NewAuthService(cfg.JWTSecret, cfg.JWTExpirationHours)
+	authService := services.NewAuthService(cfg.JWTSecret, cfg.GetAccessTokenMinutes())
 	hash, _ := authService.HashPassword("Test1234")
 
 	email := "booking@example.com"
@@ -68,6 +68,12 @@ func TestBookingHandler_CreateBooking(t *testing.T) {
 		if response["id"] == nil {
 			t.Error("Expected booking ID in response")
 		}
+
+		// Notifications and webhooks are driven by the recorded event
+		events, _ := repository.NewDomainEventRepository(db).FindByAggregate(models.AggregateBooking, int(response["id"].(float64)))
+		if len(events) != 1 || events[0].EventType != models.EventBookingCreated || *events[0].ActorID != userID {
+			t.Errorf("Expected booking.created event, got %+v", events)
+		}
 	})
 
 	t.Run("missing required fields", func(t *testing.T) {
@@ -467,6 +473,12 @@ func TestBookingHandler_CancelBooking(t *testing.T) {
 		if status != "cancelled" {
 			t.Errorf("Expected status 'cancelled', got %s", status)
 		}
+
+		events, _ := repository.NewDomainEventRepository(db).FindByAggregate(models.AggregateBooking, bookingID)
+		var data models.BookingEventData
+		if len(events) != 1 || events[0].EventType != models.EventBookingCancelled || events[0].Decode(&data) != nil || data.CancelledBy != models.CancelledByAdmin {
+			t.Errorf("Expected booking.cancelled event by admin, got %+v", events)
+		}
 	})
 
 	t.Run("cancel booking of another user", func(t *testing.T) {
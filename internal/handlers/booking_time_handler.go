@@ -32,13 +32,13 @@ func NewBookingTimeHandler(
 func (h *BookingTimeHandler) GetAvailableSlots(w http.ResponseWriter, r *http.Request) {
 	date := r.URL.Query().Get("date")
 	if date == "" {
-		respondError(w, http.StatusBadRequest, "date parameter required")
+		respondError(w, r, http.StatusBadRequest, "date_parameter_required")
 		return
 	}
 
 	slots, err := h.bookingTimeService.GetAvailableTimeSlots(date)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, err.Error())
+		respondValidationError(w, r, err)
 		return
 	}
 
@@ -51,16 +51,15 @@ func (h *BookingTimeHandler) GetAvailableSlots(w http.ResponseWriter, r *http.Re
 // GetRules returns all time rules
 // GET /api/booking-times/rules
 func (h *BookingTimeHandler) GetRules(w http.ResponseWriter, r *http.Request) {
-	// Check admin permission
-	isAdmin, ok := r.Context().Value(middleware.IsAdminKey).(bool)
-	if !ok || !isAdmin {
-		respondError(w, http.StatusForbidden, "Admin access required")
+	// Check permission
+	if !middleware.HasPermission(r, models.PermissionManageCalendar) {
+		respondError(w, r, http.StatusForbidden, "permission_denied")
 		return
 	}
 
 	rules, err := h.bookingTimeRepo.GetAllRules()
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to load rules")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_rules")
 		return
 	}
 
@@ -72,13 +71,13 @@ func (h *BookingTimeHandler) GetRules(w http.ResponseWriter, r *http.Request) {
 func (h *BookingTimeHandler) GetRulesForDate(w http.ResponseWriter, r *http.Request) {
 	date := r.URL.Query().Get("date")
 	if date == "" {
-		respondError(w, http.StatusBadRequest, "date parameter required")
+		respondError(w, r, http.StatusBadRequest, "date_parameter_required")
 		return
 	}
 
 	rules, err := h.bookingTimeService.GetRulesForDate(date)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, err.Error())
+		respondValidationError(w, r, err)
 		return
 	}
 
@@ -88,23 +87,22 @@ func (h *BookingTimeHandler) GetRulesForDate(w http.ResponseWriter, r *http.Requ
 // UpdateRules updates time rules (admin only)
 // PUT /api/booking-times/rules
 func (h *BookingTimeHandler) UpdateRules(w http.ResponseWriter, r *http.Request) {
-	// Check admin permission
-	isAdmin, ok := r.Context().Value(middleware.IsAdminKey).(bool)
-	if !ok || !isAdmin {
-		respondError(w, http.StatusForbidden, "Admin access required")
+	// Check permission
+	if !middleware.HasPermission(r, models.PermissionManageCalendar) {
+		respondError(w, r, http.StatusForbidden, "permission_denied")
 		return
 	}
 
 	var rules []models.BookingTimeRule
 	if err := json.NewDecoder(r.Body).Decode(&rules); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
 		return
 	}
 
 	// Validate each rule
 	for _, rule := range rules {
 		if err := rule.Validate(); err != nil {
-			respondError(w, http.StatusBadRequest, err.Error())
+			respondValidationError(w, r, err)
 			return
 		}
 	}
@@ -112,7 +110,7 @@ func (h *BookingTimeHandler) UpdateRules(w http.ResponseWriter, r *http.Request)
 	// Update each rule
 	for _, rule := range rules {
 		if err := h.bookingTimeRepo.UpdateRule(rule.ID, &rule); err != nil {
-			respondError(w, http.StatusInternalServerError, "Failed to update rule")
+			respondError(w, r, http.StatusInternalServerError, "failed_to_update_rule")
 			return
 		}
 	}
@@ -125,26 +123,25 @@ func (h *BookingTimeHandler) UpdateRules(w http.ResponseWriter, r *http.Request)
 // CreateRule creates a new time rule (admin only)
 // POST /api/booking-times/rules
 func (h *BookingTimeHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
-	// Check admin permission
-	isAdmin, ok := r.Context().Value(middleware.IsAdminKey).(bool)
-	if !ok || !isAdmin {
-		respondError(w, http.StatusForbidden, "Admin access required")
+	// Check permission
+	if !middleware.HasPermission(r, models.PermissionManageCalendar) {
+		respondError(w, r, http.StatusForbidden, "permission_denied")
 		return
 	}
 
 	var rule models.BookingTimeRule
 	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
 		return
 	}
 
 	if err := rule.Validate(); err != nil {
-		respondError(w, http.StatusBadRequest, err.Error())
+		respondValidationError(w, r, err)
 		return
 	}
 
 	if err := h.bookingTimeRepo.CreateRule(&rule); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to create rule")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_create_rule")
 		return
 	}
 
@@ -154,29 +151,28 @@ func (h *BookingTimeHandler) CreateRule(w http.ResponseWriter, r *http.Request)
 // DeleteRule deletes a time rule (admin only)
 // DELETE /api/booking-times/rules/:id
 func (h *BookingTimeHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
-	// Check admin permission
-	isAdmin, ok := r.Context().Value(middleware.IsAdminKey).(bool)
-	if !ok || !isAdmin {
-		respondError(w, http.StatusForbidden, "Admin access required")
+	// Check permission
+	if !middleware.HasPermission(r, models.PermissionManageCalendar) {
+		respondError(w, r, http.StatusForbidden, "permission_denied")
 		return
 	}
 
 	// Extract ID from path
 	pathParts := strings.Split(r.URL.Path, "/")
 	if len(pathParts) < 4 {
-		respondError(w, http.StatusBadRequest, "Invalid rule ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_rule_id")
 		return
 	}
 	idStr := pathParts[len(pathParts)-1]
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid rule ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_rule_id")
 		return
 	}
 
 	if err := h.bookingTimeRepo.DeleteRule(id); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to delete rule")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_delete_rule")
 		return
 	}
 
@@ -3,13 +3,22 @@ package handlers
 import (
 	"database/sql"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/i18n"
 	"github.com/tranmh/gassigeher/internal/models"
 	"github.com/tranmh/gassigeher/internal/repository"
 )
 
+// Page size of the activity feed
+const (
+	defaultActivityLimit = 50
+	maxActivityLimit     = 200
+)
+
 // DashboardHandler handles admin dashboard endpoints
 type DashboardHandler struct {
 	db                   *sql.DB
@@ -19,6 +28,7 @@ type DashboardHandler struct {
 	dogRepo              *repository.DogRepository
 	experienceRepo       *repository.ExperienceRequestRepository
 	reactivationRepo     *repository.ReactivationRequestRepository
+	activityRepo         *repository.ActivityLogRepository
 }
 
 // NewDashboardHandler creates a new dashboard handler
@@ -31,6 +41,7 @@ func NewDashboardHandler(db *sql.DB, cfg *config.Config) *DashboardHandler {
 		dogRepo:          repository.NewDogRepository(db),
 		experienceRepo:   repository.NewExperienceRequestRepository(db),
 		reactivationRepo: repository.NewReactivationRequestRepository(db),
+		activityRepo:     repository.NewActivityLogRepository(db),
 	}
 }
 
@@ -104,61 +115,71 @@ func (h *DashboardHandler) GetStats(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, stats)
 }
 
-// GetRecentActivity returns recent activity feed (admin only)
+// GetRecentActivity returns a page of the activity feed, newest first (admin only)
+// Query parameters: type (e.g. "booking" or "booking.created"), user_id, dog_id, date_from, date_to (YYYY-MM-DD),
+// limit (default 50, max 200), offset
 func (h *DashboardHandler) GetRecentActivity(w http.ResponseWriter, r *http.Request) {
-	activities := []*models.ActivityItem{}
-
-	// Get recent bookings (last 24 hours)
-	yesterday := time.Now().Add(-24 * time.Hour).Format("2006-01-02")
-	recentBookings, err := h.bookingRepo.FindAll(&models.BookingFilterRequest{
-		DateFrom: &yesterday,
-	})
-
-	if err == nil {
-		for _, booking := range recentBookings {
-			// Get dog name
-			dog, err := h.dogRepo.FindByID(booking.DogID)
-			dogName := "Unknown"
-			if err == nil && dog != nil {
-				dogName = dog.Name
-			}
-
-			var activityType, message string
-			switch booking.Status {
-			case "scheduled":
-				activityType = "booking_created"
-				message = "Neue Buchung für " + dogName
-			case "completed":
-				activityType = "booking_completed"
-				message = "Spaziergang mit " + dogName + " abgeschlossen"
-			case "cancelled":
-				activityType = "booking_cancelled"
-				message = "Buchung für " + dogName + " storniert"
-			}
-
-			activity := &models.ActivityItem{
-				Type:      activityType,
-				Message:   message,
-				Timestamp: booking.CreatedAt.Format(time.RFC3339),
-				UserID:    &booking.UserID,
-				DogID:     &booking.DogID,
-				DogName:   dogName,
-			}
+	query := r.URL.Query()
+	filter := &models.ActivityFilter{
+		Type:     strings.TrimSpace(query.Get("type")),
+		DateFrom: query.Get("date_from"),
+		DateTo:   query.Get("date_to"),
+		Limit:    defaultActivityLimit,
+	}
 
-			activities = append(activities, activity)
+	if value := query.Get("user_id"); value != "" {
+		userID, err := strconv.Atoi(value)
+		if err != nil {
+			respondError(w, r, http.StatusBadRequest, "invalid_user_id")
+			return
 		}
+		filter.UserID = userID
+	}
+	if value := query.Get("dog_id"); value != "" {
+		dogID, err := strconv.Atoi(value)
+		if err != nil {
+			respondError(w, r, http.StatusBadRequest, "invalid_dog_id")
+			return
+		}
+		filter.DogID = dogID
+	}
+	for _, date := range []string{filter.DateFrom, filter.DateTo} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			respondError(w, r, http.StatusBadRequest, "invalid_date_format")
+			return
+		}
+	}
+
+	if value, err := strconv.Atoi(query.Get("limit")); err == nil && value > 0 {
+		filter.Limit = value
+	}
+	if filter.Limit > maxActivityLimit {
+		filter.Limit = maxActivityLimit
+	}
+	if value, err := strconv.Atoi(query.Get("offset")); err == nil && value > 0 {
+		filter.Offset = value
 	}
 
-	// Limit to 20 most recent activities
-	if len(activities) > 20 {
-		activities = activities[:20]
+	activities, total, err := h.activityRepo.Find(filter)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_activity")
+		return
 	}
 
-	response := &models.RecentActivityResponse{
-		Activities: activities,
+	lang := i18n.FromRequest(r)
+	for _, activity := range activities {
+		activity.Render(lang)
 	}
 
-	respondJSON(w, http.StatusOK, response)
+	respondJSON(w, http.StatusOK, &models.ActivityResponse{
+		Activities: activities,
+		Total:      total,
+		Limit:      filter.Limit,
+		Offset:     filter.Offset,
+	})
 }
 
 // Helper functions
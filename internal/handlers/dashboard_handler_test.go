@@ -2,12 +2,15 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
 	"github.com/tranmh/gassigeher/internal/testutil"
 )
 
@@ -66,7 +69,7 @@ func TestDashboardHandler_GetStats(t *testing.T) {
 	})
 }
 
-// DONE: TestDashboardHandler_GetRecentActivity tests getting recent activity
+// TestDashboardHandler_GetRecentActivity tests the paginated and filtered activity feed
 func TestDashboardHandler_GetRecentActivity(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	cfg := &config.Config{
@@ -79,31 +82,67 @@ func TestDashboardHandler_GetRecentActivity(t *testing.T) {
 	userID := testutil.SeedTestUser(t, db, "user@example.com", "User", "green")
 	dogID := testutil.SeedTestDog(t, db, "Bella", "Labrador", "green")
 
-	// Create recent booking
-	today := time.Now().Format("2006-01-02")
-	testutil.SeedTestBooking(t, db, userID, dogID, today, "09:00", "scheduled")
-
-	t.Run("admin gets recent activity", func(t *testing.T) {
-		req := httptest.NewRequest("GET", "/api/admin/activity", nil)
-		ctx := contextWithUser(req.Context(), adminID, "admin@example.com", true)
-		req = req.WithContext(ctx)
+	activityRepo := repository.NewActivityLogRepository(db)
+	for i, eventType := range []string{models.EventUserRegistered, models.EventBookingCreated, models.EventBookingCancelled} {
+		entry := &models.ActivityEntry{EventID: i + 1, Type: eventType, Params: []string{}, ActorID: &userID, UserID: &userID, CreatedAt: time.Now()}
+		if eventType != models.EventUserRegistered {
+			entry.DogID, entry.DogName, entry.Params = &dogID, "Bella", []string{"2030-01-10", "09:00"}
+		}
+		if err := activityRepo.Create(entry); err != nil {
+			t.Fatalf("Failed to create activity entry: %v", err)
+		}
+	}
 
+	get := func(query string) (*httptest.ResponseRecorder, models.ActivityResponse) {
+		req := httptest.NewRequest("GET", "/api/admin/activity"+query, nil)
+		req.Header.Set("Accept-Language", "en")
+		req = req.WithContext(contextWithUser(req.Context(), adminID, "admin@example.com", true))
 		rec := httptest.NewRecorder()
 		handler.GetRecentActivity(rec, req)
 
+		var response models.ActivityResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		return rec, response
+	}
+
+	t.Run("admin gets recent activity", func(t *testing.T) {
+		rec, response := get("")
 		if rec.Code != http.StatusOK {
-			t.Errorf("Expected status 200, got %d. Body: %s", rec.Code, rec.Body.String())
+			t.Fatalf("Expected status 200, got %d. Body: %s", rec.Code, rec.Body.String())
+		}
+		if response.Total != 3 || len(response.Activities) != 3 || response.Limit != 50 {
+			t.Fatalf("Expected 3 activities with default limit, got %+v", response)
+		}
+		latest := response.Activities[0]
+		if latest.Type != models.EventBookingCancelled || latest.Message != "Booking on 2030-01-10 at 09:00 cancelled" ||
+			latest.UserName != "User" || latest.DogName != "Bella" {
+			t.Errorf("Unexpected latest activity: %+v", latest)
 		}
+	})
 
-		var activities []map[string]interface{}
-		json.Unmarshal(rec.Body.Bytes(), &activities)
+	t.Run("pagination", func(t *testing.T) {
+		_, response := get("?limit=2&offset=2")
+		if response.Total != 3 || len(response.Activities) != 1 || response.Activities[0].Type != models.EventUserRegistered {
+			t.Errorf("Expected last page with the registration, got %+v", response)
+		}
+	})
 
-		// Log activity for debugging (may be empty depending on implementation)
-		t.Logf("Recent activities count: %d", len(activities))
+	t.Run("filters", func(t *testing.T) {
+		today := time.Now().Format("2006-01-02")
+		_, response := get(fmt.Sprintf("?type=booking&dog_id=%d&user_id=%d&date_from=%s&date_to=%s", dogID, userID, today, today))
+		if response.Total != 2 {
+			t.Errorf("Expected the 2 bookings, got %+v", response)
+		}
+		if _, response := get(fmt.Sprintf("?user_id=%d", adminID)); response.Total != 0 {
+			t.Errorf("Expected no activity of the admin, got %d", response.Total)
+		}
+	})
 
-		// Verify we got valid JSON response
-		if rec.Body.String() == "" {
-			t.Error("Expected non-empty response body")
+	t.Run("invalid filters", func(t *testing.T) {
+		for _, query := range []string{"?user_id=abc", "?dog_id=abc", "?date_from=10.01.2030"} {
+			if rec, _ := get(query); rec.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400 for %s, got %d", query, rec.Code)
+			}
 		}
 	})
 }
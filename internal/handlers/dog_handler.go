@@ -25,28 +25,39 @@ type DogHandler struct {
 	userRepo     *repository.UserRepository
 	bookingRepo  *repository.BookingRepository
 	imageService *services.ImageService
-	emailService *services.EmailService
+	outbox       *services.OutboxService
 	config       *config.Config
 }
 
 // NewDogHandler creates a new dog handler
 func NewDogHandler(db *sql.DB, cfg *config.Config) *DogHandler {
-	// Initialize email service (may fail gracefully)
-	emailService, err := services.NewEmailService(services.ConfigToEmailConfig(cfg))
-	if err != nil {
-		fmt.Printf("Warning: Failed to initialize email service in DogHandler: %v\n", err)
-	}
-
 	return &DogHandler{
 		dogRepo:      repository.NewDogRepository(db),
 		userRepo:     repository.NewUserRepository(db),
 		bookingRepo:  repository.NewBookingRepository(db),
 		imageService: services.NewImageService(cfg.UploadDir),
-		emailService: emailService,
+		outbox:       services.NewOutboxService(db),
 		config:       cfg,
 	}
 }
 
+// updateDog saves the dog and records a dog.updated event
+func (h *DogHandler) updateDog(r *http.Request, dog *models.Dog) error {
+	adminID, _ := r.Context().Value(middleware.UserIDKey).(int)
+	return h.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		if err := h.dogRepo.WithTx(tx).Update(dog); err != nil {
+			return nil, err
+		}
+		event, err := newDogEvent(models.EventDogUpdated, adminID, dog)
+		return []*models.DomainEvent{event}, err
+	})
+}
+
+func newDogEvent(eventType string, adminID int, dog *models.Dog) (*models.DomainEvent, error) {
+	return models.NewDomainEvent(eventType, models.AggregateDog, dog.ID, &adminID,
+		&models.DogEventData{DogID: dog.ID, DogName: dog.Name})
+}
+
 // ListDogs handles GET /api/dogs - list all dogs with optional filters
 func (h *DogHandler) ListDogs(w http.ResponseWriter, r *http.Request) {
 	// Parse query parameters for filtering
@@ -94,7 +105,7 @@ func (h *DogHandler) ListDogs(w http.ResponseWriter, r *http.Request) {
 	dogs, err := h.dogRepo.FindAll(filter)
 	if err != nil {
 		log.Printf("ERROR: Failed to fetch dogs: %v", err)
-		respondError(w, http.StatusInternalServerError, "Failed to fetch dogs")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_dogs")
 		return
 	}
 
@@ -125,18 +136,18 @@ func (h *DogHandler) GetDog(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid dog ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_dog_id")
 		return
 	}
 
 	dog, err := h.dogRepo.FindByID(id)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Database error")
+		respondError(w, r, http.StatusInternalServerError, "database_error")
 		return
 	}
 
 	if dog == nil {
-		respondError(w, http.StatusNotFound, "Dog not found")
+		respondError(w, r, http.StatusNotFound, "dog_not_found")
 		return
 	}
 
@@ -147,28 +158,28 @@ func (h *DogHandler) GetDog(w http.ResponseWriter, r *http.Request) {
 func (h *DogHandler) CreateDog(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateDogRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
 		return
 	}
 
 	// Validate required fields
 	if strings.TrimSpace(req.Name) == "" {
-		respondError(w, http.StatusBadRequest, "Name is required")
+		respondError(w, r, http.StatusBadRequest, "name_required")
 		return
 	}
 
 	if strings.TrimSpace(req.Breed) == "" {
-		respondError(w, http.StatusBadRequest, "Breed is required")
+		respondError(w, r, http.StatusBadRequest, "breed_required")
 		return
 	}
 
 	if req.Size != "small" && req.Size != "medium" && req.Size != "large" {
-		respondError(w, http.StatusBadRequest, "Size must be small, medium, or large")
+		respondError(w, r, http.StatusBadRequest, "invalid_dog_size")
 		return
 	}
 
 	if req.Category != "green" && req.Category != "blue" && req.Category != "orange" {
-		respondError(w, http.StatusBadRequest, "Category must be green, blue, or orange")
+		respondError(w, r, http.StatusBadRequest, "invalid_dog_category")
 		return
 	}
 
@@ -190,10 +201,20 @@ func (h *DogHandler) CreateDog(w http.ResponseWriter, r *http.Request) {
 		IsAvailable:         true, // Default to available
 	}
 
-	if err := h.dogRepo.Create(dog); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to create dog")
+	adminID, _ := r.Context().Value(middleware.UserIDKey).(int)
+	err := h.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		if err := h.dogRepo.WithTx(tx).Create(dog); err != nil {
+			return nil, err
+		}
+		event, err := newDogEvent(models.EventDogCreated, adminID, dog)
+		return []*models.DomainEvent{event}, err
+	})
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_create_dog")
 		return
 	}
+	middleware.AuditTarget(r, "dog", dog.ID)
+	middleware.AuditChange(r, nil, dog)
 
 	respondJSON(w, http.StatusCreated, dog)
 }
@@ -203,26 +224,28 @@ func (h *DogHandler) UpdateDog(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid dog ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_dog_id")
 		return
 	}
 
 	// Get existing dog
 	dog, err := h.dogRepo.FindByID(id)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Database error")
+		respondError(w, r, http.StatusInternalServerError, "database_error")
 		return
 	}
 
 	if dog == nil {
-		respondError(w, http.StatusNotFound, "Dog not found")
+		respondError(w, r, http.StatusNotFound, "dog_not_found")
 		return
 	}
 
+	before := *dog
+
 	// Parse update request
 	var req models.UpdateDogRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
 		return
 	}
 
@@ -268,10 +291,11 @@ func (h *DogHandler) UpdateDog(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Update in database
-	if err := h.dogRepo.Update(dog); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to update dog")
+	if err := h.updateDog(r, dog); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_update_dog")
 		return
 	}
+	middleware.AuditChange(r, &before, dog)
 
 	respondJSON(w, http.StatusOK, dog)
 }
@@ -281,7 +305,7 @@ func (h *DogHandler) DeleteDog(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid dog ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_dog_id")
 		return
 	}
 
@@ -292,48 +316,59 @@ func (h *DogHandler) DeleteDog(w http.ResponseWriter, r *http.Request) {
 		// Force delete: cancel all future bookings and delete dog
 		dog, err := h.dogRepo.FindByID(id)
 		if err != nil {
-			respondError(w, http.StatusInternalServerError, "Failed to fetch dog")
+			respondError(w, r, http.StatusInternalServerError, "failed_to_get_dog")
 			return
 		}
 		if dog == nil {
-			respondError(w, http.StatusNotFound, "Dog not found")
+			respondError(w, r, http.StatusNotFound, "dog_not_found")
 			return
 		}
 
 		// Get all future bookings
 		bookings, err := h.dogRepo.GetFutureBookings(id)
 		if err != nil {
-			respondError(w, http.StatusInternalServerError, "Failed to fetch bookings")
+			respondError(w, r, http.StatusInternalServerError, "failed_to_get_bookings")
 			return
 		}
 
-		// Cancel all future bookings
+		// Cancel all future bookings and delete the dog, users are notified through the booking.cancelled events
+		adminID, _ := r.Context().Value(middleware.UserIDKey).(int)
 		cancellationReason := fmt.Sprintf("Hund %s wurde aus dem System entfernt", dog.Name)
-		for _, booking := range bookings {
-			// Cancel the booking
-			err := h.bookingRepo.Cancel(booking.ID, &cancellationReason)
-			if err != nil {
-				log.Printf("ERROR: Failed to cancel booking %d: %v", booking.ID, err)
-				continue
-			}
+		err = h.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+			bookingRepo := h.bookingRepo.WithTx(tx)
+			events := []*models.DomainEvent{}
+			for _, booking := range bookings {
+				if err := bookingRepo.Cancel(booking.ID, &cancellationReason); err != nil {
+					return nil, err
+				}
 
-			// Send cancellation email to user if email service is available and user has email
-			if h.emailService != nil && booking.User != nil && booking.User.Email != nil && *booking.User.Email != "" {
-				go h.emailService.SendBookingCancellation(
-					*booking.User.Email,
-					booking.User.Name,
-					dog.Name,
-					booking.Date,
-					booking.ScheduledTime,
-				)
+				userName := ""
+				if booking.User != nil {
+					userName = booking.User.Name
+				}
+				booking.Status = "cancelled"
+				data := models.NewBookingEventData(booking, dog.Name, userName)
+				data.Reason = &cancellationReason
+				data.CancelledBy = models.CancelledByDogDeleted
+				event, err := models.NewBookingEvent(models.EventBookingCancelled, &adminID, data)
+				if err != nil {
+					return nil, err
+				}
+				events = append(events, event)
 			}
-		}
 
-		// Now delete the dog
-		if err := h.dogRepo.ForceDelete(id); err != nil {
-			respondError(w, http.StatusInternalServerError, "Failed to delete dog")
+			if err := h.dogRepo.WithTx(tx).ForceDelete(id); err != nil {
+				return nil, err
+			}
+			event, err := newDogEvent(models.EventDogDeleted, adminID, dog)
+			return append(events, event), err
+		})
+		if err != nil {
+			log.Printf("ERROR: Failed to force delete dog %d: %v", id, err)
+			respondError(w, r, http.StatusInternalServerError, "failed_to_delete_dog")
 			return
 		}
+		middleware.AuditChange(r, dog, map[string]int{"cancelled_bookings": len(bookings)})
 
 		respondJSON(w, http.StatusOK, map[string]interface{}{
 			"message":          "Hund erfolgreich gelöscht",
@@ -342,14 +377,31 @@ func (h *DogHandler) DeleteDog(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	dog, err := h.dogRepo.FindByID(id)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_dog")
+		return
+	}
+	if dog == nil {
+		respondError(w, r, http.StatusNotFound, "dog_not_found")
+		return
+	}
+
 	// Normal delete (will fail if future bookings exist)
-	err = h.dogRepo.Delete(id)
+	adminID, _ := r.Context().Value(middleware.UserIDKey).(int)
+	err = h.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		if err := h.dogRepo.WithTx(tx).Delete(id); err != nil {
+			return nil, err
+		}
+		event, err := newDogEvent(models.EventDogDeleted, adminID, dog)
+		return []*models.DomainEvent{event}, err
+	})
 	if err != nil {
 		if strings.Contains(err.Error(), "future bookings") {
 			// Get the future bookings to return to frontend
 			bookings, fetchErr := h.dogRepo.GetFutureBookings(id)
 			if fetchErr != nil {
-				respondError(w, http.StatusInternalServerError, "Failed to fetch bookings")
+				respondError(w, r, http.StatusInternalServerError, "failed_to_get_bookings")
 				return
 			}
 
@@ -359,10 +411,11 @@ func (h *DogHandler) DeleteDog(w http.ResponseWriter, r *http.Request) {
 				"bookings": bookings,
 			})
 		} else {
-			respondError(w, http.StatusInternalServerError, "Failed to delete dog")
+			respondError(w, r, http.StatusInternalServerError, "failed_to_delete_dog")
 		}
 		return
 	}
+	middleware.AuditChange(r, dog, nil)
 
 	respondJSON(w, http.StatusOK, map[string]string{
 		"message": "Hund erfolgreich gelöscht",
@@ -374,31 +427,31 @@ func (h *DogHandler) UploadDogPhoto(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid dog ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_dog_id")
 		return
 	}
 
 	// Get existing dog
 	dog, err := h.dogRepo.FindByID(id)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Database error")
+		respondError(w, r, http.StatusInternalServerError, "database_error")
 		return
 	}
 
 	if dog == nil {
-		respondError(w, http.StatusNotFound, "Dog not found")
+		respondError(w, r, http.StatusNotFound, "dog_not_found")
 		return
 	}
 
 	// Parse multipart form
 	if err := r.ParseMultipartForm(int64(h.config.MaxUploadSizeMB) << 20); err != nil {
-		respondError(w, http.StatusBadRequest, "File too large or invalid form")
+		respondError(w, r, http.StatusBadRequest, "file_too_large")
 		return
 	}
 
 	file, header, err := r.FormFile("photo")
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "No file uploaded")
+		respondError(w, r, http.StatusBadRequest, "no_file_uploaded")
 		return
 	}
 	defer file.Close()
@@ -406,7 +459,7 @@ func (h *DogHandler) UploadDogPhoto(w http.ResponseWriter, r *http.Request) {
 	// Validate file type (checking extension first for quick validation)
 	ext := strings.ToLower(filepath.Ext(header.Filename))
 	if ext != ".jpg" && ext != ".jpeg" && ext != ".png" {
-		respondError(w, http.StatusBadRequest, "Only JPEG and PNG files are allowed")
+		respondError(w, r, http.StatusBadRequest, "invalid_image_type")
 		return
 	}
 
@@ -424,7 +477,7 @@ func (h *DogHandler) UploadDogPhoto(w http.ResponseWriter, r *http.Request) {
 	// Process the uploaded photo (resize, compress, create thumbnail)
 	fullPath, thumbPath, err := h.imageService.ProcessDogPhoto(file, id)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to process image: %v", err))
+		respondError(w, r, http.StatusInternalServerError, "failed_to_process_image", err)
 		return
 	}
 
@@ -435,7 +488,7 @@ func (h *DogHandler) UploadDogPhoto(w http.ResponseWriter, r *http.Request) {
 	if err := h.dogRepo.Update(dog); err != nil {
 		// If database update fails, clean up the newly created files
 		h.imageService.DeleteDogPhotos(id)
-		respondError(w, http.StatusInternalServerError, "Failed to update dog")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_update_dog")
 		return
 	}
 
@@ -451,13 +504,13 @@ func (h *DogHandler) ToggleAvailability(w http.ResponseWriter, r *http.Request)
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid dog ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_dog_id")
 		return
 	}
 
 	var req models.ToggleAvailabilityRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
 		return
 	}
 
@@ -467,16 +520,44 @@ func (h *DogHandler) ToggleAvailability(w http.ResponseWriter, r *http.Request)
 		req.UnavailableReason = &defaultReason
 	}
 
+	// Remember the previous state to record only actual changes
+	previous, err := h.dogRepo.FindByID(id)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_dog")
+		return
+	}
+
 	// Toggle availability
-	if err := h.dogRepo.ToggleAvailability(id, req.IsAvailable, req.UnavailableReason); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to toggle availability")
+	adminID, _ := r.Context().Value(middleware.UserIDKey).(int)
+	err = h.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		if err := h.dogRepo.WithTx(tx).ToggleAvailability(id, req.IsAvailable, req.UnavailableReason); err != nil {
+			return nil, err
+		}
+		if previous == nil || previous.IsAvailable == req.IsAvailable {
+			return nil, nil
+		}
+
+		data := &models.DogAvailabilityEventData{DogID: id, DogName: previous.Name, IsAvailable: req.IsAvailable}
+		if !req.IsAvailable {
+			data.UnavailableReason = req.UnavailableReason
+		}
+		event, err := models.NewDomainEvent(models.EventDogAvailabilityChanged, models.AggregateDog, id, &adminID, data)
+		return []*models.DomainEvent{event}, err
+	})
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_toggle_availability")
 		return
 	}
+	if previous != nil {
+		middleware.AuditChange(r,
+			map[string]interface{}{"is_available": previous.IsAvailable, "unavailable_reason": previous.UnavailableReason},
+			map[string]interface{}{"is_available": req.IsAvailable, "unavailable_reason": req.UnavailableReason})
+	}
 
 	// Get updated dog
 	dog, err := h.dogRepo.FindByID(id)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to fetch updated dog")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_updated_dog")
 		return
 	}
 
@@ -487,7 +568,7 @@ func (h *DogHandler) ToggleAvailability(w http.ResponseWriter, r *http.Request)
 func (h *DogHandler) GetBreeds(w http.ResponseWriter, r *http.Request) {
 	breeds, err := h.dogRepo.GetBreeds()
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to fetch breeds")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_breeds")
 		return
 	}
 
@@ -499,7 +580,7 @@ func (h *DogHandler) GetFeaturedDogs(w http.ResponseWriter, r *http.Request) {
 	dogs, err := h.dogRepo.GetFeatured()
 	if err != nil {
 		log.Printf("Error fetching featured dogs: %v", err)
-		respondError(w, http.StatusInternalServerError, "Failed to fetch featured dogs")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_featured_dogs")
 		return
 	}
 
@@ -511,7 +592,7 @@ func (h *DogHandler) SetFeatured(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid dog ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_dog_id")
 		return
 	}
 
@@ -521,19 +602,19 @@ func (h *DogHandler) SetFeatured(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
 		return
 	}
 
 	// Check if dog exists
 	dog, err := h.dogRepo.FindByID(id)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Database error")
+		respondError(w, r, http.StatusInternalServerError, "database_error")
 		return
 	}
 
 	if dog == nil {
-		respondError(w, http.StatusNotFound, "Dog not found")
+		respondError(w, r, http.StatusNotFound, "dog_not_found")
 		return
 	}
 
@@ -541,15 +622,24 @@ func (h *DogHandler) SetFeatured(w http.ResponseWriter, r *http.Request) {
 	// This gives all featured dogs a chance to be shown to visitors
 
 	// Update featured status
-	if err := h.dogRepo.SetFeatured(id, req.IsFeatured); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to update featured status")
+	adminID, _ := r.Context().Value(middleware.UserIDKey).(int)
+	err = h.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		if err := h.dogRepo.WithTx(tx).SetFeatured(id, req.IsFeatured); err != nil {
+			return nil, err
+		}
+		event, err := newDogEvent(models.EventDogUpdated, adminID, dog)
+		return []*models.DomainEvent{event}, err
+	})
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_update_featured_status")
 		return
 	}
+	middleware.AuditChange(r, map[string]bool{"is_featured": dog.IsFeatured}, map[string]bool{"is_featured": req.IsFeatured})
 
 	// Get updated dog
 	dog, err = h.dogRepo.FindByID(id)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to fetch updated dog")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_updated_dog")
 		return
 	}
 
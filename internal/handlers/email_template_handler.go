@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/i18n"
+	"github.com/tranmh/gassigeher/internal/middleware"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/services"
+)
+
+// EmailTemplateHandler handles admin management of email templates
+type EmailTemplateHandler struct {
+	db    *sql.DB
+	cfg   *config.Config
+	store *services.EmailTemplateStore
+}
+
+// NewEmailTemplateHandler creates a new email template handler
+func NewEmailTemplateHandler(db *sql.DB, cfg *config.Config) *EmailTemplateHandler {
+	return &EmailTemplateHandler{
+		db:    db,
+		cfg:   cfg,
+		store: services.NewEmailTemplateStore(repository.NewEmailTemplateRepository(db), cfg.BaseURL),
+	}
+}
+
+// ListTemplates lists all email templates with their effective content (admin only)
+// The language is selected with ?lang= (default: German)
+func (h *EmailTemplateHandler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, err := h.store.List(templateLanguage(r))
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_email_templates")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, templates)
+}
+
+// GetTemplate gets a single email template (admin only)
+func (h *EmailTemplateHandler) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+
+	tmpl, err := h.store.Get(key, templateLanguage(r))
+	if err != nil {
+		h.respondStoreError(w, r, err, "failed_to_get_email_template")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, tmpl)
+}
+
+// UpdateTemplate overrides subject and body of an email template (admin only)
+func (h *EmailTemplateHandler) UpdateTemplate(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	lang := templateLanguage(r)
+	adminID, _ := r.Context().Value(middleware.UserIDKey).(int)
+
+	var req models.UpdateEmailTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respondValidationError(w, r, err)
+		return
+	}
+
+	// Variables and syntax are validated by the store before saving
+	if err := h.store.Save(key, lang, req.Subject, req.Body, adminID); err != nil {
+		h.respondStoreError(w, r, err, "failed_to_update_email_template")
+		return
+	}
+
+	tmpl, err := h.store.Get(key, lang)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_email_template")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, tmpl)
+}
+
+// PreviewTemplate renders an email template with sample data (admin only)
+// Unsaved subject/body can be passed in the request body
+func (h *EmailTemplateHandler) PreviewTemplate(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+
+	var req models.PreviewEmailTemplateRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, r, http.StatusBadRequest, "invalid_request_body")
+			return
+		}
+	}
+
+	preview, err := h.store.Preview(key, templateLanguage(r), req.Subject, req.Body)
+	if err != nil {
+		h.respondStoreError(w, r, err, "failed_to_render_email_template")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, preview)
+}
+
+// ResetTemplate removes the override so the built-in default is used again (admin only)
+func (h *EmailTemplateHandler) ResetTemplate(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+
+	if err := h.store.Reset(key, templateLanguage(r)); err != nil {
+		h.respondStoreError(w, r, err, "failed_to_reset_email_template")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Email template reset to default"})
+}
+
+// templateLanguage returns the template language from the ?lang= query parameter
+func templateLanguage(r *http.Request) string {
+	return i18n.Normalize(r.URL.Query().Get("lang"))
+}
+
+// respondStoreError maps template store errors to HTTP responses
+func (h *EmailTemplateHandler) respondStoreError(w http.ResponseWriter, r *http.Request, err error, fallbackCode string) {
+	if errors.Is(err, services.ErrUnknownEmailTemplate) {
+		respondError(w, r, http.StatusNotFound, "email_template_not_found")
+		return
+	}
+
+	var validationErr *models.ValidationError
+	if errors.As(err, &validationErr) {
+		respondValidationError(w, r, validationErr)
+		return
+	}
+
+	respondError(w, r, http.StatusInternalServerError, fallbackCode)
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestEmailTemplateHandler tests listing, updating, previewing and resetting templates
+func TestEmailTemplateHandler(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	cfg := &config.Config{
+		JWTSecret: "test-secret",
+		BaseURL:   "https://gassi.example.com",
+	}
+	handler := NewEmailTemplateHandler(db, cfg)
+
+	adminID := testutil.SeedTestUser(t, db, "admin@example.com", "Admin", "orange")
+
+	newRequest := func(method, key string, body interface{}) *http.Request {
+		var reader *bytes.Reader
+		if body != nil {
+			data, _ := json.Marshal(body)
+			reader = bytes.NewReader(data)
+		} else {
+			reader = bytes.NewReader(nil)
+		}
+		req := httptest.NewRequest(method, "/api/admin/email-templates/"+key, reader)
+		req = mux.SetURLVars(req, map[string]string{"key": key})
+		return req.WithContext(contextWithUser(req.Context(), adminID, "admin@example.com", true))
+	}
+
+	t.Run("list templates", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ListTemplates(rec, newRequest("GET", "", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rec.Code)
+		}
+
+		var templates []models.EmailTemplateResponse
+		json.Unmarshal(rec.Body.Bytes(), &templates)
+		if len(templates) != 21 {
+			t.Errorf("Expected 21 templates, got %d", len(templates))
+		}
+	})
+
+	t.Run("unknown template returns 404", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.GetTemplate(rec, newRequest("GET", "nope", nil))
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", rec.Code)
+		}
+	})
+
+	t.Run("update rejects unknown variables", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.UpdateTemplate(rec, newRequest("PUT", "welcome", map[string]string{
+			"subject": "Hallo {{.Name}}",
+			"body":    "<p>{{.Password}}</p>",
+		}))
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", rec.Code)
+		}
+		if !strings.Contains(rec.Body.String(), "Password") {
+			t.Errorf("Expected error to name the unknown variable, got %s", rec.Body.String())
+		}
+	})
+
+	t.Run("update saves override", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.UpdateTemplate(rec, newRequest("PUT", "welcome", map[string]string{
+			"subject": "Hallo {{.Name}}",
+			"body":    "<p>Willkommen {{.Name}}</p>",
+		}))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+
+		var tmpl models.EmailTemplateResponse
+		json.Unmarshal(rec.Body.Bytes(), &tmpl)
+		if !tmpl.IsCustomized {
+			t.Error("Expected template to be customized")
+		}
+	})
+
+	t.Run("preview renders sample data", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.PreviewTemplate(rec, newRequest("POST", "welcome", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+
+		var preview models.EmailTemplatePreview
+		json.Unmarshal(rec.Body.Bytes(), &preview)
+		if preview.Subject != "Hallo Max Mustermann" {
+			t.Errorf("Unexpected preview subject: %q", preview.Subject)
+		}
+	})
+
+	t.Run("reset restores default", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ResetTemplate(rec, newRequest("DELETE", "welcome", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rec.Code)
+		}
+		if count := testutil.CountRows(t, db, "email_templates"); count != 0 {
+			t.Errorf("Expected no overrides after reset, got %d", count)
+		}
+	})
+}
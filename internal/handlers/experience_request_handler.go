@@ -16,27 +16,29 @@ import (
 
 // ExperienceRequestHandler handles experience request-related HTTP requests
 type ExperienceRequestHandler struct {
-	db         *sql.DB
-	cfg        *config.Config
+	db          *sql.DB
+	cfg         *config.Config
 	requestRepo *repository.ExperienceRequestRepository
 	userRepo    *repository.UserRepository
-	emailService *services.EmailService
+	notifier    *services.NotificationService
+	outbox      *services.OutboxService
 }
 
 // NewExperienceRequestHandler creates a new experience request handler
 func NewExperienceRequestHandler(db *sql.DB, cfg *config.Config) *ExperienceRequestHandler {
-	emailService, err := services.NewEmailService(services.ConfigToEmailConfig(cfg))
+	emailService, err := services.NewEmailServiceFromConfig(db, cfg)
 	if err != nil {
 		// Log error but don't fail
 		println("Warning: Failed to initialize email service:", err.Error())
 	}
 
 	return &ExperienceRequestHandler{
-		db:           db,
-		cfg:          cfg,
-		requestRepo:  repository.NewExperienceRequestRepository(db),
-		userRepo:     repository.NewUserRepository(db),
-		emailService: emailService,
+		db:          db,
+		cfg:         cfg,
+		requestRepo: repository.NewExperienceRequestRepository(db),
+		userRepo:    repository.NewUserRepository(db),
+		notifier:    services.NewNotificationServiceFromConfig(db, cfg, emailService),
+		outbox:      services.NewOutboxService(db),
 	}
 }
 
@@ -45,31 +47,31 @@ func (h *ExperienceRequestHandler) CreateRequest(w http.ResponseWriter, r *http.
 	// Get user ID from context
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
 	if !ok {
-		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	// Parse request
 	var req models.CreateExperienceRequestRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
 		return
 	}
 
 	// Validate request
 	if err := req.Validate(); err != nil {
-		respondError(w, http.StatusBadRequest, err.Error())
+		respondValidationError(w, r, err)
 		return
 	}
 
 	// Get user
 	user, err := h.userRepo.FindByID(userID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get user")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_user")
 		return
 	}
 	if user == nil {
-		respondError(w, http.StatusNotFound, "User not found")
+		respondError(w, r, http.StatusNotFound, "user_not_found")
 		return
 	}
 
@@ -78,28 +80,28 @@ func (h *ExperienceRequestHandler) CreateRequest(w http.ResponseWriter, r *http.
 	requestedLevel := req.RequestedLevel
 
 	if currentLevel == "blue" {
-		respondError(w, http.StatusBadRequest, "You already have the highest level")
+		respondError(w, r, http.StatusBadRequest, "experience_level_highest")
 		return
 	}
 
 	if currentLevel == "orange" && requestedLevel == "orange" {
-		respondError(w, http.StatusBadRequest, "You already have this level")
+		respondError(w, r, http.StatusBadRequest, "experience_level_already_granted")
 		return
 	}
 
 	if currentLevel == "green" && requestedLevel == "blue" {
-		respondError(w, http.StatusBadRequest, "You must first get orange level")
+		respondError(w, r, http.StatusBadRequest, "orange_level_required")
 		return
 	}
 
 	// Check if user already has a pending request for this level
 	hasPending, err := h.requestRepo.HasPendingRequest(userID, requestedLevel)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to check pending requests")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_check_pending_requests")
 		return
 	}
 	if hasPending {
-		respondError(w, http.StatusConflict, "You already have a pending request for this level")
+		respondError(w, r, http.StatusConflict, "experience_request_pending")
 		return
 	}
 
@@ -109,11 +111,23 @@ func (h *ExperienceRequestHandler) CreateRequest(w http.ResponseWriter, r *http.
 		RequestedLevel: requestedLevel,
 	}
 
-	if err := h.requestRepo.Create(experienceRequest); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to create request")
+	err = h.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		if err := h.requestRepo.WithTx(tx).Create(experienceRequest); err != nil {
+			return nil, err
+		}
+		event, err := newExperienceRequestEvent(models.EventExperienceRequested, userID, experienceRequest, user, nil)
+		return []*models.DomainEvent{event}, err
+	})
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_create_request")
 		return
 	}
 
+	// Notify admins
+	if h.notifier != nil {
+		go h.notifier.NotifyAdmins(models.NotificationTypeAdminExperienceRequest, user.Name, models.NotificationParamKeyPrefix+"levels."+requestedLevel)
+	}
+
 	respondJSON(w, http.StatusCreated, experienceRequest)
 }
 
@@ -121,7 +135,7 @@ func (h *ExperienceRequestHandler) CreateRequest(w http.ResponseWriter, r *http.
 func (h *ExperienceRequestHandler) ListRequests(w http.ResponseWriter, r *http.Request) {
 	// Get user ID and admin status from context
 	userID, _ := r.Context().Value(middleware.UserIDKey).(int)
-	isAdmin, _ := r.Context().Value(middleware.IsAdminKey).(bool)
+	isAdmin := middleware.HasPermission(r, models.PermissionManageUsers)
 
 	var requests []*models.ExperienceRequest
 	var err error
@@ -135,7 +149,7 @@ func (h *ExperienceRequestHandler) ListRequests(w http.ResponseWriter, r *http.R
 	}
 
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get requests")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_requests")
 		return
 	}
 
@@ -158,7 +172,7 @@ func (h *ExperienceRequestHandler) ApproveRequest(w http.ResponseWriter, r *http
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_request_id")
 		return
 	}
 
@@ -175,47 +189,55 @@ func (h *ExperienceRequestHandler) ApproveRequest(w http.ResponseWriter, r *http
 	// Get experience request
 	experienceRequest, err := h.requestRepo.FindByID(id)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get request")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_request")
 		return
 	}
 	if experienceRequest == nil {
-		respondError(w, http.StatusNotFound, "Request not found")
+		respondError(w, r, http.StatusNotFound, "request_not_found")
 		return
 	}
 
 	// Check if already reviewed
 	if experienceRequest.Status != "pending" {
-		respondError(w, http.StatusBadRequest, "Request has already been reviewed")
+		respondError(w, r, http.StatusBadRequest, "request_already_reviewed")
 		return
 	}
 
 	// Get user
 	user, err := h.userRepo.FindByID(experienceRequest.UserID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get user")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_user")
 		return
 	}
 	if user == nil {
-		respondError(w, http.StatusNotFound, "User not found")
-		return
-	}
-
-	// Approve request
-	if err := h.requestRepo.Approve(id, reviewerID, req.Message); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to approve request")
+		respondError(w, r, http.StatusNotFound, "user_not_found")
 		return
 	}
 
-	// Update user experience level
-	user.ExperienceLevel = experienceRequest.RequestedLevel
-	if err := h.userRepo.Update(user); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to update user level")
+	// Approve request and update user experience level
+	previousLevel := user.ExperienceLevel
+	err = h.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		if err := h.requestRepo.WithTx(tx).Approve(id, reviewerID, req.Message); err != nil {
+			return nil, err
+		}
+		user.ExperienceLevel = experienceRequest.RequestedLevel
+		if err := h.userRepo.WithTx(tx).Update(user); err != nil {
+			return nil, err
+		}
+		event, err := newExperienceRequestEvent(models.EventExperienceApproved, reviewerID, experienceRequest, user, req.Message)
+		return []*models.DomainEvent{event}, err
+	})
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_approve_request")
 		return
 	}
+	middleware.AuditChange(r,
+		map[string]interface{}{"user_id": user.ID, "experience_level": previousLevel},
+		map[string]interface{}{"user_id": user.ID, "experience_level": user.ExperienceLevel})
 
-	// Send email notification
-	if user.Email != nil && h.emailService != nil {
-		go h.emailService.SendExperienceLevelApproved(*user.Email, user.Name, experienceRequest.RequestedLevel, req.Message)
+	// Notify user
+	if user.Email != nil && h.notifier != nil {
+		go h.notifier.SendExperienceLevelApproved(*user.Email, user.Name, experienceRequest.RequestedLevel, req.Message)
 	}
 
 	respondJSON(w, http.StatusOK, map[string]string{"message": "Request approved"})
@@ -227,7 +249,7 @@ func (h *ExperienceRequestHandler) DenyRequest(w http.ResponseWriter, r *http.Re
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_request_id")
 		return
 	}
 
@@ -244,41 +266,59 @@ func (h *ExperienceRequestHandler) DenyRequest(w http.ResponseWriter, r *http.Re
 	// Get experience request
 	experienceRequest, err := h.requestRepo.FindByID(id)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get request")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_request")
 		return
 	}
 	if experienceRequest == nil {
-		respondError(w, http.StatusNotFound, "Request not found")
+		respondError(w, r, http.StatusNotFound, "request_not_found")
 		return
 	}
 
 	// Check if already reviewed
 	if experienceRequest.Status != "pending" {
-		respondError(w, http.StatusBadRequest, "Request has already been reviewed")
+		respondError(w, r, http.StatusBadRequest, "request_already_reviewed")
 		return
 	}
 
 	// Get user
 	user, err := h.userRepo.FindByID(experienceRequest.UserID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get user")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_user")
 		return
 	}
 	if user == nil {
-		respondError(w, http.StatusNotFound, "User not found")
+		respondError(w, r, http.StatusNotFound, "user_not_found")
 		return
 	}
 
 	// Deny request
-	if err := h.requestRepo.Deny(id, reviewerID, req.Message); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to deny request")
+	err = h.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		if err := h.requestRepo.WithTx(tx).Deny(id, reviewerID, req.Message); err != nil {
+			return nil, err
+		}
+		event, err := newExperienceRequestEvent(models.EventExperienceDenied, reviewerID, experienceRequest, user, req.Message)
+		return []*models.DomainEvent{event}, err
+	})
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_deny_request")
 		return
 	}
 
-	// Send email notification
-	if user.Email != nil && h.emailService != nil {
-		go h.emailService.SendExperienceLevelDenied(*user.Email, user.Name, experienceRequest.RequestedLevel, req.Message)
+	// Notify user
+	if user.Email != nil && h.notifier != nil {
+		go h.notifier.SendExperienceLevelDenied(*user.Email, user.Name, experienceRequest.RequestedLevel, req.Message)
 	}
 
 	respondJSON(w, http.StatusOK, map[string]string{"message": "Request denied"})
 }
+
+func newExperienceRequestEvent(eventType string, actorID int, request *models.ExperienceRequest, user *models.User, message *string) (*models.DomainEvent, error) {
+	return models.NewDomainEvent(eventType, models.AggregateExperienceRequest, request.ID, &actorID,
+		&models.ExperienceRequestEventData{
+			RequestID:      request.ID,
+			UserID:         request.UserID,
+			UserName:       user.Name,
+			RequestedLevel: request.RequestedLevel,
+			Message:        message,
+		})
+}
@@ -10,6 +10,8 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
 	"github.com/tranmh/gassigeher/internal/testutil"
 )
 
@@ -244,6 +246,11 @@ func TestExperienceRequestHandler_ApproveRequest(t *testing.T) {
 		if userLevel != "blue" {
 			t.Errorf("Expected user level upgraded to 'blue', got %s", userLevel)
 		}
+
+		events, _ := repository.NewDomainEventRepository(db).FindByAggregate(models.AggregateExperienceRequest, requestID)
+		if len(events) != 1 || events[0].EventType != models.EventExperienceApproved || *events[0].ActorID != adminID {
+			t.Errorf("Expected experience_request.approved event by the admin, got %+v", events)
+		}
 	})
 
 	t.Run("approve non-existent request", func(t *testing.T) {
@@ -43,7 +43,7 @@ func (h *HolidayHandler) GetHolidays(w http.ResponseWriter, r *http.Request) {
 
 	holidays, err := h.holidayService.GetHolidaysForYear(year)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to load holidays")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_holidays")
 		return
 	}
 
@@ -53,10 +53,9 @@ func (h *HolidayHandler) GetHolidays(w http.ResponseWriter, r *http.Request) {
 // CreateHoliday adds a custom holiday (admin only)
 // POST /api/holidays
 func (h *HolidayHandler) CreateHoliday(w http.ResponseWriter, r *http.Request) {
-	// Check admin permission
-	isAdmin, ok := r.Context().Value(middleware.IsAdminKey).(bool)
-	if !ok || !isAdmin {
-		respondError(w, http.StatusForbidden, "Admin access required")
+	// Check permission
+	if !middleware.HasPermission(r, models.PermissionManageCalendar) {
+		respondError(w, r, http.StatusForbidden, "permission_denied")
 		return
 	}
 
@@ -64,7 +63,7 @@ func (h *HolidayHandler) CreateHoliday(w http.ResponseWriter, r *http.Request) {
 
 	var holiday models.CustomHoliday
 	if err := json.NewDecoder(r.Body).Decode(&holiday); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
 		return
 	}
 
@@ -73,12 +72,12 @@ func (h *HolidayHandler) CreateHoliday(w http.ResponseWriter, r *http.Request) {
 	holiday.CreatedBy = &adminID
 
 	if err := holiday.Validate(); err != nil {
-		respondError(w, http.StatusBadRequest, err.Error())
+		respondValidationError(w, r, err)
 		return
 	}
 
 	if err := h.holidayRepo.CreateHoliday(&holiday); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to create holiday")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_create_holiday")
 		return
 	}
 
@@ -88,35 +87,34 @@ func (h *HolidayHandler) CreateHoliday(w http.ResponseWriter, r *http.Request) {
 // UpdateHoliday updates a holiday (admin only)
 // PUT /api/holidays/:id
 func (h *HolidayHandler) UpdateHoliday(w http.ResponseWriter, r *http.Request) {
-	// Check admin permission
-	isAdmin, ok := r.Context().Value(middleware.IsAdminKey).(bool)
-	if !ok || !isAdmin {
-		respondError(w, http.StatusForbidden, "Admin access required")
+	// Check permission
+	if !middleware.HasPermission(r, models.PermissionManageCalendar) {
+		respondError(w, r, http.StatusForbidden, "permission_denied")
 		return
 	}
 
 	// Extract ID from path
 	pathParts := strings.Split(r.URL.Path, "/")
 	if len(pathParts) < 4 {
-		respondError(w, http.StatusBadRequest, "Invalid holiday ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_holiday_id")
 		return
 	}
 	idStr := pathParts[len(pathParts)-1]
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid holiday ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_holiday_id")
 		return
 	}
 
 	var holiday models.CustomHoliday
 	if err := json.NewDecoder(r.Body).Decode(&holiday); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
 		return
 	}
 
 	if err := h.holidayRepo.UpdateHoliday(id, &holiday); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to update holiday")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_update_holiday")
 		return
 	}
 
@@ -128,29 +126,28 @@ func (h *HolidayHandler) UpdateHoliday(w http.ResponseWriter, r *http.Request) {
 // DeleteHoliday deletes a holiday (admin only)
 // DELETE /api/holidays/:id
 func (h *HolidayHandler) DeleteHoliday(w http.ResponseWriter, r *http.Request) {
-	// Check admin permission
-	isAdmin, ok := r.Context().Value(middleware.IsAdminKey).(bool)
-	if !ok || !isAdmin {
-		respondError(w, http.StatusForbidden, "Admin access required")
+	// Check permission
+	if !middleware.HasPermission(r, models.PermissionManageCalendar) {
+		respondError(w, r, http.StatusForbidden, "permission_denied")
 		return
 	}
 
 	// Extract ID from path
 	pathParts := strings.Split(r.URL.Path, "/")
 	if len(pathParts) < 4 {
-		respondError(w, http.StatusBadRequest, "Invalid holiday ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_holiday_id")
 		return
 	}
 	idStr := pathParts[len(pathParts)-1]
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid holiday ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_holiday_id")
 		return
 	}
 
 	if err := h.holidayRepo.DeleteHoliday(id); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to delete holiday")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_delete_holiday")
 		return
 	}
 
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/middleware"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/services"
+)
+
+// InvitationHandler handles the invitation codes for the registration (admin only)
+type InvitationHandler struct {
+	invitations  *services.InvitationService
+	emailService *services.EmailService
+	config       *config.Config
+}
+
+// NewInvitationHandler creates a new invitation handler
+func NewInvitationHandler(db *sql.DB, cfg *config.Config) *InvitationHandler {
+	emailService, err := services.NewEmailServiceFromConfig(db, cfg)
+	if err != nil {
+		fmt.Printf("Warning: Failed to initialize email service: %v\n", err)
+	}
+
+	return &InvitationHandler{
+		invitations:  services.NewInvitationService(db),
+		emailService: emailService,
+		config:       cfg,
+	}
+}
+
+// ListInvitations lists all invitations with their status, newest first (admin only)
+func (h *InvitationHandler) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	invitations, err := h.invitations.List()
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_invitations")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"invitations":       invitations,
+		"registration_mode": h.invitations.RegistrationMode(),
+	})
+}
+
+// CreateInvitation creates an invitation code, it is sent by email if an address is given (admin only)
+func (h *InvitationHandler) CreateInvitation(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respondValidationError(w, r, err)
+		return
+	}
+
+	adminID, _ := r.Context().Value(middleware.UserIDKey).(int)
+	invitation, err := h.invitations.Create(&req, adminID)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_create_invitation")
+		return
+	}
+	middleware.AuditTarget(r, "invitation", invitation.ID)
+
+	if invitation.Email != nil && h.emailService != nil {
+		go func(email, code string) {
+			if err := h.emailService.SendInvitationEmail(email, code, invitation.ExpiresAt); err != nil {
+				fmt.Printf("Failed to send invitation email: %v\n", err)
+			}
+		}(*invitation.Email, invitation.Code)
+	}
+
+	respondJSON(w, http.StatusCreated, invitation)
+}
+
+// RevokeInvitation makes an invitation unusable, accounts registered with it are kept (admin only)
+func (h *InvitationHandler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_invitation_id")
+		return
+	}
+
+	invitation, err := h.invitations.Find(id)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_invitations")
+		return
+	}
+	if invitation == nil {
+		respondError(w, r, http.StatusNotFound, "invitation_not_found")
+		return
+	}
+
+	if err := h.invitations.Revoke(invitation.ID); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_revoke_invitation")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Invitation revoked"})
+}
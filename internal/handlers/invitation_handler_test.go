@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestInvitationHandler tests creating, listing and revoking invitations
+func TestInvitationHandler(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	cfg := &config.Config{JWTSecret: "test-secret"}
+	handler := NewInvitationHandler(db, cfg)
+	adminID := testutil.SeedTestUser(t, db, "admin@example.com", "Admin", "orange")
+
+	newRequest := func(method, path string, body interface{}, id int) *http.Request {
+		var buf bytes.Buffer
+		if body != nil {
+			json.NewEncoder(&buf).Encode(body)
+		}
+		req := httptest.NewRequest(method, path, &buf)
+		req = req.WithContext(contextWithUser(req.Context(), adminID, "admin@example.com", true))
+		if id != 0 {
+			req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(id)})
+		}
+		return req
+	}
+
+	t.Run("validation", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.CreateInvitation(rec, newRequest("POST", "/api/invitations", map[string]interface{}{
+			"email": "neu@example.com", "max_uses": 5,
+		}, 0))
+
+		var resp map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		if rec.Code != http.StatusBadRequest || resp["code"] != "invitation_email_single_use" {
+			t.Errorf("Expected 400 invitation_email_single_use, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	var created models.Invitation
+	t.Run("create", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.CreateInvitation(rec, newRequest("POST", "/api/invitations", map[string]interface{}{
+			"max_uses": 20, "expires_in_days": 30, "experience_level": "blue", "tag": "Sommerfest",
+		}, 0))
+
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+		}
+		json.Unmarshal(rec.Body.Bytes(), &created)
+		if created.Code == "" || created.MaxUses != 20 || created.ExperienceLevel != "blue" ||
+			created.Tag == nil || *created.Tag != "sommerfest" || created.Status != models.InvitationStatusActive {
+			t.Errorf("Unexpected invitation %+v", created)
+		}
+		if created.CreatedBy == nil || *created.CreatedBy != adminID {
+			t.Errorf("Expected the invitation to be created by the admin, got %v", created.CreatedBy)
+		}
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.RevokeInvitation(rec, newRequest("DELETE", "/api/invitations/x", nil, created.ID))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+
+		rec = httptest.NewRecorder()
+		handler.RevokeInvitation(rec, newRequest("DELETE", "/api/invitations/x", nil, 9999))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for an unknown invitation, got %d", rec.Code)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ListInvitations(rec, newRequest("GET", "/api/invitations", nil, 0))
+
+		var resp struct {
+			Invitations      []*models.Invitation `json:"invitations"`
+			RegistrationMode string               `json:"registration_mode"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		if rec.Code != http.StatusOK || len(resp.Invitations) != 1 || resp.Invitations[0].Status != models.InvitationStatusRevoked {
+			t.Errorf("Expected the revoked invitation, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if resp.RegistrationMode != models.RegistrationModeOpen {
+			t.Errorf("Expected the open registration mode, got %q", resp.RegistrationMode)
+		}
+	})
+}
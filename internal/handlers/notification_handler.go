@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/i18n"
+	"github.com/tranmh/gassigeher/internal/middleware"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+)
+
+// Page size of the notification list
+const (
+	defaultNotificationLimit = 50
+	maxNotificationLimit     = 100
+)
+
+// NotificationHandler serves the current user's in-app notification center
+type NotificationHandler struct {
+	notificationRepo *repository.NotificationRepository
+	config           *config.Config
+}
+
+// NewNotificationHandler creates a new notification handler
+func NewNotificationHandler(db *sql.DB, cfg *config.Config) *NotificationHandler {
+	return &NotificationHandler{
+		notificationRepo: repository.NewNotificationRepository(db),
+		config:           cfg,
+	}
+}
+
+// ListNotifications returns the current user's notifications, newest first
+// Query parameters: unread=true, limit (default 50, max 100), offset
+func (h *NotificationHandler) ListNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	limit := defaultNotificationLimit
+	if value, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && value > 0 {
+		limit = value
+	}
+	if limit > maxNotificationLimit {
+		limit = maxNotificationLimit
+	}
+
+	offset := 0
+	if value, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && value > 0 {
+		offset = value
+	}
+
+	unreadOnly := r.URL.Query().Get("unread") == "true"
+
+	notifications, err := h.notificationRepo.FindByUser(userID, unreadOnly, limit, offset)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_notifications")
+		return
+	}
+
+	lang := i18n.FromRequest(r)
+	for _, notification := range notifications {
+		notification.Render(lang)
+	}
+
+	respondJSON(w, http.StatusOK, notifications)
+}
+
+// GetUnreadCount returns the number of unread notifications of the current user
+func (h *NotificationHandler) GetUnreadCount(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	count, err := h.notificationRepo.CountUnread(userID)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_notifications")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.NotificationUnreadCountResponse{Count: count})
+}
+
+// MarkRead marks one notification of the current user as read
+func (h *NotificationHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_notification_id")
+		return
+	}
+
+	found, err := h.notificationRepo.MarkRead(userID, id)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_update_notifications")
+		return
+	}
+	if !found {
+		respondError(w, r, http.StatusNotFound, "notification_not_found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Notification marked as read"})
+}
+
+// MarkAllRead marks all notifications of the current user as read
+func (h *NotificationHandler) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	marked, err := h.notificationRepo.MarkAllRead(userID)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_update_notifications")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]int{"marked": marked})
+}
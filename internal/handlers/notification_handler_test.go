@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestNotificationHandler tests listing and reading in-app notifications
+func TestNotificationHandler(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	handler := NewNotificationHandler(db, &config.Config{JWTSecret: "test-secret"})
+
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "User", "green")
+	otherID := testutil.SeedTestUser(t, db, "other@example.com", "Other", "green")
+
+	repo := repository.NewNotificationRepository(db)
+	booking := &models.Notification{UserID: userID, Type: "booking_approved", Params: []string{"Bella", "01.12.2025", "20:00"}, Link: models.NotificationLink("booking_approved")}
+	repo.Create(booking)
+	repo.Create(&models.Notification{UserID: userID, Type: "experience_denied", Params: []string{"@levels.blue", ""}})
+	other := &models.Notification{UserID: otherID, Type: "welcome"}
+	repo.Create(other)
+
+	newRequest := func(method, path string, id int) *http.Request {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Accept-Language", "en")
+		return req.WithContext(contextWithUser(req.Context(), id, "user@example.com", false))
+	}
+
+	t.Run("list renders in the request language", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ListNotifications(rec, newRequest("GET", "/api/notifications", userID))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+
+		var notifications []models.Notification
+		json.Unmarshal(rec.Body.Bytes(), &notifications)
+		if len(notifications) != 2 {
+			t.Fatalf("Expected 2 notifications, got %d", len(notifications))
+		}
+		if notifications[0].Title != "Experience level denied" || notifications[0].Message != "Your request for level Blue was denied." {
+			t.Errorf("Unexpected rendering: %+v", notifications[0])
+		}
+		if notifications[1].Message != "Your walk with Bella on 01.12.2025 at 20:00 has been approved." {
+			t.Errorf("Unexpected message %q", notifications[1].Message)
+		}
+	})
+
+	t.Run("unread count", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.GetUnreadCount(rec, newRequest("GET", "/api/notifications/unread-count", userID))
+
+		var resp models.NotificationUnreadCountResponse
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		if rec.Code != http.StatusOK || resp.Count != 2 {
+			t.Errorf("Expected 2 unread, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("cannot mark another user's notification", func(t *testing.T) {
+		req := mux.SetURLVars(newRequest("PUT", "/api/notifications/"+strconv.Itoa(other.ID)+"/read", userID), map[string]string{"id": strconv.Itoa(other.ID)})
+		rec := httptest.NewRecorder()
+		handler.MarkRead(rec, req)
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", rec.Code)
+		}
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		req := mux.SetURLVars(newRequest("PUT", "/api/notifications/abc/read", userID), map[string]string{"id": "abc"})
+		rec := httptest.NewRecorder()
+		handler.MarkRead(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", rec.Code)
+		}
+	})
+
+	t.Run("mark read", func(t *testing.T) {
+		req := mux.SetURLVars(newRequest("PUT", "/api/notifications/"+strconv.Itoa(booking.ID)+"/read", userID), map[string]string{"id": strconv.Itoa(booking.ID)})
+		rec := httptest.NewRecorder()
+		handler.MarkRead(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+
+		rec = httptest.NewRecorder()
+		handler.ListNotifications(rec, newRequest("GET", "/api/notifications?unread=true", userID))
+		var notifications []models.Notification
+		json.Unmarshal(rec.Body.Bytes(), &notifications)
+		if len(notifications) != 1 || notifications[0].ID == booking.ID {
+			t.Errorf("Expected only the unread notification, got %+v", notifications)
+		}
+	})
+
+	t.Run("mark all read", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.MarkAllRead(rec, newRequest("PUT", "/api/notifications/read-all", userID))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rec.Code)
+		}
+		if count, _ := repo.CountUnread(userID); count != 0 {
+			t.Errorf("Expected no unread notifications, got %d", count)
+		}
+		if count, _ := repo.CountUnread(otherID); count != 1 {
+			t.Errorf("Expected other user's notification to stay unread, got %d", count)
+		}
+	})
+}
@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/middleware"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/services"
+)
+
+// NotificationPreferenceHandler handles notification preferences, reminder schedules and unsubscribe links
+type NotificationPreferenceHandler struct {
+	preferenceRepo  *repository.NotificationPreferenceRepository
+	userRepo        *repository.UserRepository
+	pushRepo        *repository.PushSubscriptionRepository
+	reminderService *services.ReminderService
+	tokens          *services.UnsubscribeTokens
+	channels        []string // Configured channels, email first
+	config          *config.Config
+}
+
+// NewNotificationPreferenceHandler creates a new notification preference handler
+func NewNotificationPreferenceHandler(db *sql.DB, cfg *config.Config) *NotificationPreferenceHandler {
+	userRepo := repository.NewUserRepository(db)
+
+	return &NotificationPreferenceHandler{
+		preferenceRepo: repository.NewNotificationPreferenceRepository(db),
+		userRepo:       userRepo,
+		pushRepo:       repository.NewPushSubscriptionRepository(db),
+		reminderService: services.NewReminderService(
+			repository.NewBookingRepository(db),
+			repository.NewBookingReminderRepository(db),
+			userRepo,
+			repository.NewSettingsRepository(db),
+		),
+		tokens:   services.NewUnsubscribeTokens(cfg.JWTSecret),
+		channels: services.ChannelNames(services.ConfiguredChannels(db, cfg)),
+		config:   cfg,
+	}
+}
+
+// GetPreferences returns the current user's notification preferences
+func (h *NotificationPreferenceHandler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	preferences, err := h.preferenceRepo.GetEffective(userID, h.channels)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_notification_preferences")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, preferences)
+}
+
+// UpdatePreferences changes the current user's notification preferences
+func (h *NotificationPreferenceHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req models.UpdateNotificationPreferencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respondValidationError(w, r, err)
+		return
+	}
+
+	for _, pref := range req.Preferences {
+		if !h.isConfiguredChannel(pref.Channel) {
+			respondValidationError(w, r, &models.ValidationError{Field: "channel", Message: "Invalid notification channel", Code: "invalid_notification_channel"})
+			return
+		}
+	}
+
+	for _, pref := range req.Preferences {
+		if err := h.preferenceRepo.Set(userID, pref.Category, pref.Channel, pref.Enabled); err != nil {
+			respondError(w, r, http.StatusInternalServerError, "failed_to_update_notification_preferences")
+			return
+		}
+	}
+
+	preferences, err := h.preferenceRepo.GetEffective(userID, h.channels)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_notification_preferences")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, preferences)
+}
+
+// GetChannels returns the configured notification channels and whether the current user can be reached on them
+func (h *NotificationPreferenceHandler) GetChannels(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	user, err := h.userRepo.FindByID(userID)
+	if err != nil || user == nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_notification_channels")
+		return
+	}
+
+	chatID, err := h.userRepo.GetTelegramChatID(userID)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_notification_channels")
+		return
+	}
+
+	statuses := []models.NotificationChannelStatus{}
+	for _, channel := range h.channels {
+		status := models.NotificationChannelStatus{Channel: channel}
+		switch channel {
+		case models.NotificationChannelEmail:
+			status.Connected = user.Email != nil && *user.Email != ""
+		case models.NotificationChannelPush:
+			count, err := h.pushRepo.CountByUser(userID)
+			if err != nil {
+				respondError(w, r, http.StatusInternalServerError, "failed_to_get_notification_channels")
+				return
+			}
+			status.Connected = count > 0
+		case models.NotificationChannelSMS:
+			status.Connected = user.Phone != nil && *user.Phone != ""
+		case models.NotificationChannelTelegram:
+			status.Connected = chatID != nil
+		}
+		statuses = append(statuses, status)
+	}
+
+	respondJSON(w, http.StatusOK, statuses)
+}
+
+func (h *NotificationPreferenceHandler) isConfiguredChannel(channel string) bool {
+	for _, c := range h.channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// GetReminderSchedule returns the booking reminders the current user receives
+func (h *NotificationPreferenceHandler) GetReminderSchedule(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	h.respondReminderSchedule(w, r, userID)
+}
+
+// UpdateReminderSchedule sets a personal reminder schedule within the admin-defined limits
+func (h *NotificationPreferenceHandler) UpdateReminderSchedule(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req models.UpdateReminderScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+
+	schedule, err := req.Parse()
+	if err != nil {
+		respondValidationError(w, r, err)
+		return
+	}
+
+	if err := h.reminderService.SetUserSchedule(userID, schedule); err != nil {
+		if _, ok := err.(*models.ValidationError); ok {
+			respondValidationError(w, r, err)
+			return
+		}
+		respondError(w, r, http.StatusInternalServerError, "failed_to_update_reminder_schedule")
+		return
+	}
+
+	h.respondReminderSchedule(w, r, userID)
+}
+
+// ResetReminderSchedule makes the current user use the default reminder schedule again
+func (h *NotificationPreferenceHandler) ResetReminderSchedule(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := h.reminderService.ResetUserSchedule(userID); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_update_reminder_schedule")
+		return
+	}
+
+	h.respondReminderSchedule(w, r, userID)
+}
+
+func (h *NotificationPreferenceHandler) respondReminderSchedule(w http.ResponseWriter, r *http.Request, userID int) {
+	schedule, isDefault, err := h.reminderService.ScheduleForUser(userID)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_reminder_schedule")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.ReminderScheduleResponse{
+		Reminders: schedule.Keys(),
+		IsDefault: isDefault,
+		Default:   h.reminderService.DefaultSchedule().Keys(),
+		Limits:    h.reminderService.Limits(),
+	})
+}
+
+// GetUnsubscribe shows which category an unsubscribe link refers to (public)
+// Does not change anything, so link scanners in mail clients cannot unsubscribe users
+func (h *NotificationPreferenceHandler) GetUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	userID, category, ok := h.parseToken(w, r, r.URL.Query().Get("token"))
+	if !ok {
+		return
+	}
+
+	enabled, err := h.preferenceRepo.IsEnabled(userID, category, models.NotificationChannelEmail)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_notification_preferences")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.UnsubscribeInfo{Category: category, Enabled: enabled})
+}
+
+// Unsubscribe disables email notifications of a category via a signed link (public)
+// Supports RFC 8058 one-click unsubscribe (token in the query string, form-encoded body)
+// and the unsubscribe page (token in a JSON body)
+func (h *NotificationPreferenceHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		var req models.UnsubscribeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, r, http.StatusBadRequest, "invalid_request_body")
+			return
+		}
+		token = req.Token
+	}
+
+	userID, category, ok := h.parseToken(w, r, token)
+	if !ok {
+		return
+	}
+
+	if err := h.preferenceRepo.Set(userID, category, models.NotificationChannelEmail, false); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_update_notification_preferences")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.UnsubscribeInfo{Category: category, Enabled: false})
+}
+
+// parseToken verifies an unsubscribe token and checks the user still exists
+func (h *NotificationPreferenceHandler) parseToken(w http.ResponseWriter, r *http.Request, token string) (int, string, bool) {
+	if token == "" {
+		respondError(w, r, http.StatusBadRequest, "token_required")
+		return 0, "", false
+	}
+
+	userID, category, err := h.tokens.Parse(token)
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_unsubscribe_token")
+		return 0, "", false
+	}
+
+	user, err := h.userRepo.FindByID(userID)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "database_error")
+		return 0, "", false
+	}
+	if user == nil {
+		respondError(w, r, http.StatusNotFound, "user_not_found")
+		return 0, "", false
+	}
+
+	return userID, category, true
+}
@@ -0,0 +1,358 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/services"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestNotificationPreferenceHandler_Preferences tests reading and updating preferences
+func TestNotificationPreferenceHandler_Preferences(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	cfg := &config.Config{JWTSecret: "test-secret"}
+	handler := NewNotificationPreferenceHandler(db, cfg)
+
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "User", "green")
+
+	newRequest := func(method string, body interface{}) *http.Request {
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, "/api/users/me/notification-preferences", bytes.NewReader(data))
+		return req.WithContext(contextWithUser(req.Context(), userID, "user@example.com", false))
+	}
+
+	findPreference := func(preferences []models.NotificationPreferenceResponse, category string) *models.NotificationPreferenceResponse {
+		for i := range preferences {
+			if preferences[i].Category == category {
+				return &preferences[i]
+			}
+		}
+		return nil
+	}
+
+	t.Run("defaults to enabled", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.GetPreferences(rec, newRequest("GET", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rec.Code)
+		}
+
+		var preferences []models.NotificationPreferenceResponse
+		json.Unmarshal(rec.Body.Bytes(), &preferences)
+		for _, pref := range preferences {
+			if !pref.Enabled {
+				t.Errorf("Expected %s to be enabled by default", pref.Category)
+			}
+		}
+		if pref := findPreference(preferences, models.NotificationCategoryAccount); pref == nil || !pref.Mandatory {
+			t.Error("Expected account category to be mandatory")
+		}
+	})
+
+	t.Run("disable reminders", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.UpdatePreferences(rec, newRequest("PUT", map[string]interface{}{
+			"preferences": []map[string]interface{}{
+				{"category": "reminders", "enabled": false},
+			},
+		}))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+
+		var preferences []models.NotificationPreferenceResponse
+		json.Unmarshal(rec.Body.Bytes(), &preferences)
+		if pref := findPreference(preferences, models.NotificationCategoryReminders); pref == nil || pref.Enabled {
+			t.Error("Expected reminders to be disabled")
+		}
+	})
+
+	t.Run("mandatory category cannot be disabled", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.UpdatePreferences(rec, newRequest("PUT", map[string]interface{}{
+			"preferences": []map[string]interface{}{
+				{"category": "account", "enabled": false},
+			},
+		}))
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("Expected status 400, got %d", rec.Code)
+		}
+		if !strings.Contains(rec.Body.String(), "notification_category_mandatory") {
+			t.Errorf("Expected notification_category_mandatory code, got %s", rec.Body.String())
+		}
+	})
+
+	t.Run("unknown category", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.UpdatePreferences(rec, newRequest("PUT", map[string]interface{}{
+			"preferences": []map[string]interface{}{
+				{"category": "spam", "enabled": false},
+			},
+		}))
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", rec.Code)
+		}
+	})
+}
+
+// TestNotificationPreferenceHandler_Channels tests channel status and preferences for text channels
+func TestNotificationPreferenceHandler_Channels(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "User", "green")
+
+	newRequest := func(method string, body interface{}) *http.Request {
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, "/api/users/me/notification-channels", bytes.NewReader(data))
+		return req.WithContext(contextWithUser(req.Context(), userID, "user@example.com", false))
+	}
+
+	t.Run("only email without gateways", func(t *testing.T) {
+		handler := NewNotificationPreferenceHandler(db, &config.Config{JWTSecret: "test-secret"})
+
+		rec := httptest.NewRecorder()
+		handler.GetChannels(rec, newRequest("GET", nil))
+
+		var statuses []models.NotificationChannelStatus
+		json.Unmarshal(rec.Body.Bytes(), &statuses)
+		if len(statuses) != 1 || statuses[0].Channel != models.NotificationChannelEmail || !statuses[0].Connected {
+			t.Errorf("Expected connected email channel only, got %+v", statuses)
+		}
+
+		rec = httptest.NewRecorder()
+		handler.UpdatePreferences(rec, newRequest("PUT", map[string]interface{}{
+			"preferences": []map[string]interface{}{
+				{"category": "reminders", "channel": "sms", "enabled": true},
+			},
+		}))
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "invalid_notification_channel") {
+			t.Errorf("Expected invalid_notification_channel, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("configured text channels", func(t *testing.T) {
+		handler := NewNotificationPreferenceHandler(db, &config.Config{
+			JWTSecret:        "test-secret",
+			SMSGatewayURL:    "http://localhost/sms",
+			TelegramBotToken: "123:abc",
+		})
+
+		rec := httptest.NewRecorder()
+		handler.GetChannels(rec, newRequest("GET", nil))
+
+		var statuses []models.NotificationChannelStatus
+		json.Unmarshal(rec.Body.Bytes(), &statuses)
+		if len(statuses) != 3 {
+			t.Fatalf("Expected 3 channels, got %+v", statuses)
+		}
+		if !statuses[1].Connected || statuses[2].Connected {
+			t.Errorf("Expected SMS connected (phone) and Telegram not linked, got %+v", statuses)
+		}
+
+		rec = httptest.NewRecorder()
+		handler.UpdatePreferences(rec, newRequest("PUT", map[string]interface{}{
+			"preferences": []map[string]interface{}{
+				{"category": "reminders", "channel": "sms", "enabled": true},
+			},
+		}))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+
+		var preferences []models.NotificationPreferenceResponse
+		json.Unmarshal(rec.Body.Bytes(), &preferences)
+		for _, pref := range preferences {
+			if pref.Channel == models.NotificationChannelSMS && pref.Category == models.NotificationCategoryReminders && !pref.Enabled {
+				t.Error("Expected SMS reminders to be enabled")
+			}
+			if pref.Channel != models.NotificationChannelEmail && pref.Category == models.NotificationCategoryAccount {
+				t.Error("Account notifications should be email-only")
+			}
+		}
+
+		rec = httptest.NewRecorder()
+		handler.UpdatePreferences(rec, newRequest("PUT", map[string]interface{}{
+			"preferences": []map[string]interface{}{
+				{"category": "account", "channel": "telegram", "enabled": true},
+			},
+		}))
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "notification_channel_not_supported") {
+			t.Errorf("Expected notification_channel_not_supported, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+}
+
+// TestNotificationPreferenceHandler_ReminderSchedule tests personal reminder schedules
+func TestNotificationPreferenceHandler_ReminderSchedule(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	cfg := &config.Config{JWTSecret: "test-secret"}
+	handler := NewNotificationPreferenceHandler(db, cfg)
+
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "User", "green")
+
+	newRequest := func(method string, body interface{}) *http.Request {
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, "/api/users/me/reminder-schedule", bytes.NewReader(data))
+		return req.WithContext(contextWithUser(req.Context(), userID, "user@example.com", false))
+	}
+
+	decode := func(rec *httptest.ResponseRecorder) models.ReminderScheduleResponse {
+		var resp models.ReminderScheduleResponse
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return resp
+	}
+
+	t.Run("default schedule with limits", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.GetReminderSchedule(rec, newRequest("GET", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rec.Code)
+		}
+
+		resp := decode(rec)
+		if !resp.IsDefault || strings.Join(resp.Reminders, ",") != "1d@18:00,1h" {
+			t.Errorf("Expected default schedule, got %+v", resp)
+		}
+		if resp.Limits.MaxCount != 3 || resp.Limits.MaxDaysBefore != 3 || resp.Limits.MinMinutesBefore != 15 {
+			t.Errorf("Unexpected limits: %+v", resp.Limits)
+		}
+	})
+
+	t.Run("set personal schedule", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.UpdateReminderSchedule(rec, newRequest("PUT", map[string]interface{}{
+			"reminders": []string{"2d@09:00", "120m"},
+		}))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+
+		resp := decode(rec)
+		if resp.IsDefault || strings.Join(resp.Reminders, ",") != "2d@09:00,2h" {
+			t.Errorf("Expected personal schedule, got %+v", resp)
+		}
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.UpdateReminderSchedule(rec, newRequest("PUT", map[string]interface{}{
+			"reminders": []string{"soon"},
+		}))
+
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "invalid_reminder_format") {
+			t.Errorf("Expected invalid_reminder_format, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("outside limits", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.UpdateReminderSchedule(rec, newRequest("PUT", map[string]interface{}{
+			"reminders": []string{"1d@18:00", "2h", "1h", "30m"},
+		}))
+
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "too_many_reminders") {
+			t.Errorf("Expected too_many_reminders, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("reset to default", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ResetReminderSchedule(rec, newRequest("DELETE", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rec.Code)
+		}
+		if resp := decode(rec); !resp.IsDefault {
+			t.Errorf("Expected default schedule after reset, got %+v", resp)
+		}
+	})
+}
+
+// TestNotificationPreferenceHandler_Unsubscribe tests the public unsubscribe endpoints
+func TestNotificationPreferenceHandler_Unsubscribe(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	cfg := &config.Config{JWTSecret: "test-secret"}
+	handler := NewNotificationPreferenceHandler(db, cfg)
+	preferences := repository.NewNotificationPreferenceRepository(db)
+
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "User", "green")
+	token := services.NewUnsubscribeTokens(cfg.JWTSecret).Generate(userID, models.NotificationCategoryAnnouncements)
+
+	t.Run("lookup does not unsubscribe", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.GetUnsubscribe(rec, httptest.NewRequest("GET", "/api/unsubscribe?token="+token, nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rec.Code)
+		}
+
+		var info models.UnsubscribeInfo
+		json.Unmarshal(rec.Body.Bytes(), &info)
+		if info.Category != models.NotificationCategoryAnnouncements || !info.Enabled {
+			t.Errorf("Unexpected info: %+v", info)
+		}
+	})
+
+	t.Run("one-click unsubscribe", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/unsubscribe?token="+token, strings.NewReader("List-Unsubscribe=One-Click"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		handler.Unsubscribe(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+
+		enabled, _ := preferences.IsEnabled(userID, models.NotificationCategoryAnnouncements, models.NotificationChannelEmail)
+		if enabled {
+			t.Error("Expected announcements to be disabled")
+		}
+	})
+
+	t.Run("unsubscribe page", func(t *testing.T) {
+		reminderToken := services.NewUnsubscribeTokens(cfg.JWTSecret).Generate(userID, models.NotificationCategoryReminders)
+		body, _ := json.Marshal(map[string]string{"token": reminderToken})
+		rec := httptest.NewRecorder()
+		handler.Unsubscribe(rec, httptest.NewRequest("POST", "/api/unsubscribe", bytes.NewReader(body)))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rec.Code)
+		}
+
+		enabled, _ := preferences.IsEnabled(userID, models.NotificationCategoryReminders, models.NotificationChannelEmail)
+		if enabled {
+			t.Error("Expected reminders to be disabled")
+		}
+	})
+
+	t.Run("tampered token", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.Unsubscribe(rec, httptest.NewRequest("POST", "/api/unsubscribe?token="+token+"x", nil))
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", rec.Code)
+		}
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.GetUnsubscribe(rec, httptest.NewRequest("GET", "/api/unsubscribe", nil))
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", rec.Code)
+		}
+	})
+}
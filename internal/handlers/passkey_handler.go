@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/middleware"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/services"
+)
+
+// PasskeyHandler handles the passkeys of logged-in users
+// The login with a passkey is part of AuthHandler.
+type PasskeyHandler struct {
+	userRepo    *repository.UserRepository
+	passkeyRepo *repository.PasskeyRepository
+	passkeys    *services.PasskeyService
+	config      *config.Config
+}
+
+// NewPasskeyHandler creates a new passkey handler
+func NewPasskeyHandler(db *sql.DB, cfg *config.Config) *PasskeyHandler {
+	return &PasskeyHandler{
+		userRepo:    repository.NewUserRepository(db),
+		passkeyRepo: repository.NewPasskeyRepository(db),
+		passkeys:    services.NewPasskeyService(db, cfg),
+		config:      cfg,
+	}
+}
+
+// ListPasskeys returns the passkeys of the current user
+func (h *PasskeyHandler) ListPasskeys(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(middleware.UserIDKey).(int)
+
+	passkeys, err := h.passkeyRepo.FindByUser(userID)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_passkeys")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, passkeys)
+}
+
+// BeginRegistration returns the options to create a passkey for the current user
+func (h *PasskeyHandler) BeginRegistration(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(middleware.UserIDKey).(int)
+
+	user, err := h.userRepo.FindByID(userID)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "database_error")
+		return
+	}
+	if user == nil {
+		respondError(w, r, http.StatusNotFound, "user_not_found")
+		return
+	}
+
+	options, err := h.passkeys.BeginRegistration(user)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_register_passkey")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, options)
+}
+
+// FinishRegistration stores the passkey created by the browser
+func (h *PasskeyHandler) FinishRegistration(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(middleware.UserIDKey).(int)
+
+	var req models.PasskeyRegistrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondValidationError(w, r, err)
+		return
+	}
+
+	passkey, err := h.passkeys.FinishRegistration(userID, &req)
+	switch {
+	case errors.Is(err, services.ErrInvalidPasskey):
+		respondError(w, r, http.StatusBadRequest, "invalid_passkey")
+		return
+	case errors.Is(err, services.ErrPasskeyExists):
+		respondError(w, r, http.StatusConflict, "passkey_already_registered")
+		return
+	case err != nil:
+		respondError(w, r, http.StatusInternalServerError, "failed_to_register_passkey")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, passkey)
+}
+
+// RenamePasskey changes the name of a passkey of the current user
+func (h *PasskeyHandler) RenamePasskey(w http.ResponseWriter, r *http.Request) {
+	passkey, ok := h.findPasskey(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.RenamePasskeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondValidationError(w, r, err)
+		return
+	}
+
+	if err := h.passkeyRepo.Rename(passkey.UserID, passkey.ID, req.Name); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_update_passkey")
+		return
+	}
+	passkey.Name = req.Name
+
+	respondJSON(w, http.StatusOK, passkey)
+}
+
+// DeletePasskey removes a passkey of the current user, it can no longer be used to log in
+func (h *PasskeyHandler) DeletePasskey(w http.ResponseWriter, r *http.Request) {
+	passkey, ok := h.findPasskey(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.passkeyRepo.Delete(passkey.UserID, passkey.ID); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_delete_passkey")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Passkey deleted"})
+}
+
+// findPasskey returns the passkey of the route, only passkeys of the current user are found
+func (h *PasskeyHandler) findPasskey(w http.ResponseWriter, r *http.Request) (*models.Passkey, bool) {
+	userID, _ := r.Context().Value(middleware.UserIDKey).(int)
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_passkey_id")
+		return nil, false
+	}
+
+	passkey, err := h.passkeyRepo.FindByID(userID, id)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "database_error")
+		return nil, false
+	}
+	if passkey == nil {
+		respondError(w, r, http.StatusNotFound, "passkey_not_found")
+		return nil, false
+	}
+	return passkey, true
+}
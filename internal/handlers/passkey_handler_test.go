@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/services"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestPasskeyHandler tests registering, listing, renaming and deleting passkeys and the login with a passkey
+func TestPasskeyHandler(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	cfg := &config.Config{JWTSecret: "test-secret", JWTExpirationHours: 24, BaseURL: "https://gassi.example.com"}
+	handler := NewPasskeyHandler(db, cfg)
+	authHandler := NewAuthHandler(db, cfg)
+	userRepo := repository.NewUserRepository(db)
+
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "User", "green")
+	otherID := testutil.SeedTestUser(t, db, "other@example.com", "Other", "green")
+
+	send := func(handle http.HandlerFunc, currentID int, body interface{}, passkeyID int) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if body != nil {
+			json.NewEncoder(&buf).Encode(body)
+		}
+		req := httptest.NewRequest("POST", "/api/users/me/passkeys", &buf)
+		if currentID != 0 {
+			req = req.WithContext(contextWithUser(req.Context(), currentID, "", false))
+		}
+		if passkeyID != 0 {
+			req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(passkeyID)})
+		}
+		rec := httptest.NewRecorder()
+		handle(rec, req)
+		return rec
+	}
+	register := func(id int, authenticator *testutil.PasskeyAuthenticator, name string) models.Passkey {
+		rec := send(handler.BeginRegistration, id, nil, 0)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var options struct {
+			PublicKey models.PasskeyCreationOptions `json:"publicKey"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &options)
+
+		credential := authenticator.Register(t, &options.PublicKey)
+		rec = send(handler.FinishRegistration, id, models.PasskeyRegistrationRequest{Name: name, Credential: credential}, 0)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var passkey models.Passkey
+		json.Unmarshal(rec.Body.Bytes(), &passkey)
+		return passkey
+	}
+	login := func(authenticator *testutil.PasskeyAuthenticator) *httptest.ResponseRecorder {
+		rec := send(authHandler.PasskeyLoginBegin, 0, nil, 0)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var options struct {
+			PublicKey models.PasskeyRequestOptions `json:"publicKey"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &options)
+		return send(authHandler.PasskeyLoginFinish, 0, models.PasskeyLoginRequest{Credential: authenticator.Login(t, &options.PublicKey)}, 0)
+	}
+
+	authenticator := testutil.NewPasskeyAuthenticator(t, "https://gassi.example.com", "gassi.example.com")
+	passkey := register(userID, authenticator, "Phone")
+
+	t.Run("manage passkeys", func(t *testing.T) {
+		rec := send(handler.ListPasskeys, userID, nil, 0)
+		var passkeys []models.Passkey
+		json.Unmarshal(rec.Body.Bytes(), &passkeys)
+		if len(passkeys) != 1 || passkeys[0].Name != "Phone" {
+			t.Fatalf("Expected the passkey, got %s", rec.Body.String())
+		}
+
+		if rec := send(handler.RenamePasskey, userID, map[string]string{"name": " "}, passkey.ID); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for an empty name, got %d", rec.Code)
+		}
+		if rec := send(handler.RenamePasskey, otherID, map[string]string{"name": "Mine"}, passkey.ID); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for the passkey of another user, got %d", rec.Code)
+		}
+		rec = send(handler.RenamePasskey, userID, map[string]string{"name": "Laptop"}, passkey.ID)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+
+		if rec := send(handler.DeletePasskey, otherID, nil, passkey.ID); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for the passkey of another user, got %d", rec.Code)
+		}
+	})
+
+	t.Run("login with a passkey", func(t *testing.T) {
+		rec := login(authenticator)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var response models.LoginResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		if response.Token == "" || response.RefreshToken == "" || response.User.ID != userID {
+			t.Errorf("Expected tokens for the user, got %s", rec.Body.String())
+		}
+
+		// Unknown passkeys and deactivated users are rejected alike
+		if rec := login(testutil.NewPasskeyAuthenticator(t, "https://gassi.example.com", "gassi.example.com")); rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for an unknown passkey, got %d", rec.Code)
+		}
+		db.Exec("UPDATE users SET is_active = 0 WHERE id = ?", userID)
+		defer db.Exec("UPDATE users SET is_active = 1 WHERE id = ?", userID)
+		if rec := login(authenticator); rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for a deactivated user, got %d", rec.Code)
+		}
+	})
+
+	t.Run("passkey replaces the 2FA code", func(t *testing.T) {
+		twoFactor := services.NewTwoFactorService(db)
+		user, _ := userRepo.FindByID(userID)
+		setup, _ := twoFactor.Setup(user)
+		code, _ := services.TOTPCode(setup.Secret, time.Now().Add(-30*time.Second))
+		if _, err := twoFactor.Enable(userID, code); err != nil {
+			t.Fatalf("Enable() failed: %v", err)
+		}
+
+		rec := login(authenticator)
+		var response models.LoginResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		if rec.Code != http.StatusOK || response.Token == "" {
+			t.Errorf("Expected tokens without a 2FA code, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("admin without 2FA sets it up first", func(t *testing.T) {
+		db.Exec("UPDATE users SET is_admin = 1 WHERE id = ?", otherID)
+		adminAuthenticator := testutil.NewPasskeyAuthenticator(t, "https://gassi.example.com", "gassi.example.com")
+		register(otherID, adminAuthenticator, "Admin key")
+
+		rec := login(adminAuthenticator)
+		var challenge models.TwoFactorChallengeResponse
+		json.Unmarshal(rec.Body.Bytes(), &challenge)
+		if rec.Code != http.StatusOK || !challenge.TwoFactorSetupRequired || challenge.ChallengeToken == "" {
+			t.Errorf("Expected the 2FA setup, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("deleted passkey no longer logs in", func(t *testing.T) {
+		if rec := send(handler.DeletePasskey, userID, nil, passkey.ID); rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if rec := login(authenticator); rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got %d", rec.Code)
+		}
+	})
+}
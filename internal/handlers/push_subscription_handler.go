@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/middleware"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/services"
+)
+
+// PushSubscriptionHandler manages Web Push subscriptions of the current user's devices
+type PushSubscriptionHandler struct {
+	pushRepo *repository.PushSubscriptionRepository
+	keys     *services.VAPIDKeys // nil = Web Push disabled
+	config   *config.Config
+}
+
+// NewPushSubscriptionHandler creates a new push subscription handler
+func NewPushSubscriptionHandler(db *sql.DB, cfg *config.Config) *PushSubscriptionHandler {
+	pushRepo := repository.NewPushSubscriptionRepository(db)
+
+	var keys *services.VAPIDKeys
+	if cfg.WebPushEnabled {
+		var err error
+		keys, err = services.LoadVAPIDKeys(cfg, pushRepo)
+		if err != nil {
+			log.Printf("Warning: Web Push disabled, failed to load VAPID keys: %v", err)
+		}
+	}
+
+	return &PushSubscriptionHandler{
+		pushRepo: pushRepo,
+		keys:     keys,
+		config:   cfg,
+	}
+}
+
+// GetPublicKey returns the VAPID public key for PushManager.subscribe() (public)
+func (h *PushSubscriptionHandler) GetPublicKey(w http.ResponseWriter, r *http.Request) {
+	if h.keys == nil {
+		respondError(w, r, http.StatusNotFound, "push_not_configured")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.VAPIDPublicKeyResponse{PublicKey: h.keys.PublicKey})
+}
+
+// ListSubscriptions returns the subscribed devices of the current user
+func (h *PushSubscriptionHandler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	subscriptions, err := h.pushRepo.FindByUser(userID)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_push_subscriptions")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, subscriptions)
+}
+
+// Subscribe stores the push subscription of the current device
+func (h *PushSubscriptionHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if h.keys == nil {
+		respondError(w, r, http.StatusNotFound, "push_not_configured")
+		return
+	}
+
+	var req models.CreatePushSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respondValidationError(w, r, err)
+		return
+	}
+
+	sub := &models.PushSubscription{
+		UserID:   userID,
+		Endpoint: req.Endpoint,
+		P256dh:   req.Keys.P256dh,
+		Auth:     req.Keys.Auth,
+	}
+	if userAgent := r.UserAgent(); userAgent != "" {
+		if len(userAgent) > 500 {
+			userAgent = userAgent[:500]
+		}
+		sub.UserAgent = &userAgent
+	}
+
+	if err := h.pushRepo.Save(sub); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_save_push_subscription")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, sub)
+}
+
+// DeleteSubscription removes a device of the current user
+func (h *PushSubscriptionHandler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_push_subscription_id")
+		return
+	}
+
+	deleted, err := h.pushRepo.DeleteForUser(userID, id)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_delete_push_subscription")
+		return
+	}
+	if !deleted {
+		respondError(w, r, http.StatusNotFound, "push_subscription_not_found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Push subscription deleted"})
+}
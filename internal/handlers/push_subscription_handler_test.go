@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestPushSubscriptionHandler tests subscribing and unsubscribing devices
+func TestPushSubscriptionHandler(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	handler := NewPushSubscriptionHandler(db, &config.Config{JWTSecret: "test-secret", WebPushEnabled: true})
+
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "User", "green")
+	otherID := testutil.SeedTestUser(t, db, "other@example.com", "Other", "green")
+
+	clientKey, _ := ecdh.P256().GenerateKey(rand.Reader)
+	validKeys := map[string]string{
+		"p256dh": base64.RawURLEncoding.EncodeToString(clientKey.PublicKey().Bytes()),
+		"auth":   base64.RawURLEncoding.EncodeToString(make([]byte, 16)),
+	}
+
+	newRequest := func(method, path string, id int, body interface{}) *http.Request {
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewReader(data))
+		req.Header.Set("User-Agent", "Mozilla/5.0 (Android)")
+		return req.WithContext(contextWithUser(req.Context(), id, "user@example.com", false))
+	}
+
+	t.Run("public key", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.GetPublicKey(rec, httptest.NewRequest("GET", "/api/push/vapid-public-key", nil))
+
+		var resp models.VAPIDPublicKeyResponse
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		if rec.Code != http.StatusOK || resp.PublicKey == "" {
+			t.Errorf("Expected public key, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	var subscriptionID int
+
+	t.Run("subscribe", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.Subscribe(rec, newRequest("POST", "/api/users/me/push-subscriptions", userID, map[string]interface{}{
+			"endpoint": "https://fcm.googleapis.com/fcm/send/abc",
+			"keys":     validKeys,
+		}))
+
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+		}
+
+		var sub models.PushSubscription
+		json.Unmarshal(rec.Body.Bytes(), &sub)
+		subscriptionID = sub.ID
+		if sub.UserAgent == nil || *sub.UserAgent != "Mozilla/5.0 (Android)" {
+			t.Errorf("Expected user agent to be stored, got %+v", sub)
+		}
+		if strings.Contains(rec.Body.String(), validKeys["p256dh"]) {
+			t.Error("Keys must not be returned")
+		}
+	})
+
+	t.Run("invalid subscriptions", func(t *testing.T) {
+		tests := []map[string]interface{}{
+			{"endpoint": "http://insecure.example.com/abc", "keys": validKeys},
+			{"endpoint": "https://push.example.com/abc", "keys": validKeys},
+			{"endpoint": "https://10.0.0.1/abc", "keys": validKeys},
+			{"endpoint": "https://localhost:8443/abc", "keys": validKeys},
+			{"endpoint": "https://fcm.googleapis.com.evil.example/abc", "keys": validKeys},
+			{"endpoint": "https://fcm.googleapis.com:8443/abc", "keys": validKeys},
+			{"endpoint": "https://fcm.googleapis.com/abc", "keys": map[string]string{"p256dh": "short", "auth": validKeys["auth"]}},
+			{"endpoint": "https://fcm.googleapis.com/abc", "keys": map[string]string{"p256dh": validKeys["p256dh"], "auth": ""}},
+		}
+
+		for _, body := range tests {
+			rec := httptest.NewRecorder()
+			handler.Subscribe(rec, newRequest("POST", "/api/users/me/push-subscriptions", userID, body))
+			if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "invalid_push_subscription") {
+				t.Errorf("Expected invalid_push_subscription for %v, got %d: %s", body, rec.Code, rec.Body.String())
+			}
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ListSubscriptions(rec, newRequest("GET", "/api/users/me/push-subscriptions", userID, nil))
+
+		var subscriptions []models.PushSubscription
+		json.Unmarshal(rec.Body.Bytes(), &subscriptions)
+		if len(subscriptions) != 1 {
+			t.Errorf("Expected 1 device, got %d", len(subscriptions))
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		deleteAs := func(id int) *httptest.ResponseRecorder {
+			req := newRequest("DELETE", "/api/users/me/push-subscriptions/"+strconv.Itoa(subscriptionID), id, nil)
+			req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(subscriptionID)})
+			rec := httptest.NewRecorder()
+			handler.DeleteSubscription(rec, req)
+			return rec
+		}
+
+		if rec := deleteAs(otherID); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 for another user's device, got %d", rec.Code)
+		}
+		if rec := deleteAs(userID); rec.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", rec.Code)
+		}
+	})
+}
+
+// TestPushSubscriptionHandler_Disabled tests the endpoints with Web Push disabled
+func TestPushSubscriptionHandler_Disabled(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	handler := NewPushSubscriptionHandler(db, &config.Config{JWTSecret: "test-secret"})
+
+	rec := httptest.NewRecorder()
+	handler.GetPublicKey(rec, httptest.NewRequest("GET", "/api/push/vapid-public-key", nil))
+
+	if rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), "push_not_configured") {
+		t.Errorf("Expected push_not_configured, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -19,23 +19,27 @@ type ReactivationRequestHandler struct {
 	db          *sql.DB
 	cfg         *config.Config
 	requestRepo *repository.ReactivationRequestRepository
+	approvals   *repository.RegistrationRequestRepository // Registrations waiting for an admin
 	userRepo    *repository.UserRepository
-	emailService *services.EmailService
+	notifier    *services.NotificationService
+	outbox      *services.OutboxService
 }
 
 // NewReactivationRequestHandler creates a new reactivation request handler
 func NewReactivationRequestHandler(db *sql.DB, cfg *config.Config) *ReactivationRequestHandler {
-	emailService, err := services.NewEmailService(services.ConfigToEmailConfig(cfg))
+	emailService, err := services.NewEmailServiceFromConfig(db, cfg)
 	if err != nil {
 		println("Warning: Failed to initialize email service:", err.Error())
 	}
 
 	return &ReactivationRequestHandler{
-		db:           db,
-		cfg:          cfg,
-		requestRepo:  repository.NewReactivationRequestRepository(db),
-		userRepo:     repository.NewUserRepository(db),
-		emailService: emailService,
+		db:          db,
+		cfg:         cfg,
+		requestRepo: repository.NewReactivationRequestRepository(db),
+		approvals:   repository.NewRegistrationRequestRepository(db),
+		userRepo:    repository.NewUserRepository(db),
+		notifier:    services.NewNotificationServiceFromConfig(db, cfg, emailService),
+		outbox:      services.NewOutboxService(db),
 	}
 }
 
@@ -46,19 +50,19 @@ func (h *ReactivationRequestHandler) CreateRequest(w http.ResponseWriter, r *htt
 		Email string `json:"email"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
 		return
 	}
 
 	if req.Email == "" {
-		respondError(w, http.StatusBadRequest, "Email is required")
+		respondError(w, r, http.StatusBadRequest, "email_required")
 		return
 	}
 
 	// Find user by email
 	user, err := h.userRepo.FindByEmail(req.Email)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Database error")
+		respondError(w, r, http.StatusInternalServerError, "database_error")
 		return
 	}
 	if user == nil {
@@ -73,10 +77,21 @@ func (h *ReactivationRequestHandler) CreateRequest(w http.ResponseWriter, r *htt
 		return
 	}
 
+	// New accounts waiting for approval are not reactivated, admins review the registration
+	pendingRegistration, err := h.approvals.HasPendingRequest(user.ID)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_check_pending_requests")
+		return
+	}
+	if pendingRegistration {
+		respondJSON(w, http.StatusOK, map[string]string{"message": "Your registration is waiting for approval"})
+		return
+	}
+
 	// Check if user already has a pending request
 	hasPending, err := h.requestRepo.HasPendingRequest(user.ID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to check pending requests")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_check_pending_requests")
 		return
 	}
 	if hasPending {
@@ -89,11 +104,23 @@ func (h *ReactivationRequestHandler) CreateRequest(w http.ResponseWriter, r *htt
 		UserID: user.ID,
 	}
 
-	if err := h.requestRepo.Create(reactivationRequest); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to create request")
+	err = h.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		if err := h.requestRepo.WithTx(tx).Create(reactivationRequest); err != nil {
+			return nil, err
+		}
+		event, err := newReactivationRequestEvent(models.EventReactivationRequested, user.ID, reactivationRequest, user, nil)
+		return []*models.DomainEvent{event}, err
+	})
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_create_request")
 		return
 	}
 
+	// Notify admins
+	if h.notifier != nil {
+		go h.notifier.NotifyAdmins(models.NotificationTypeAdminReactivationRequest, user.Name, req.Email)
+	}
+
 	respondJSON(w, http.StatusCreated, map[string]string{"message": "Reactivation request submitted"})
 }
 
@@ -101,7 +128,7 @@ func (h *ReactivationRequestHandler) CreateRequest(w http.ResponseWriter, r *htt
 func (h *ReactivationRequestHandler) ListRequests(w http.ResponseWriter, r *http.Request) {
 	requests, err := h.requestRepo.FindAllPending()
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get requests")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_requests")
 		return
 	}
 
@@ -122,7 +149,7 @@ func (h *ReactivationRequestHandler) ApproveRequest(w http.ResponseWriter, r *ht
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_request_id")
 		return
 	}
 
@@ -139,48 +166,50 @@ func (h *ReactivationRequestHandler) ApproveRequest(w http.ResponseWriter, r *ht
 	// Get reactivation request
 	reactivationRequest, err := h.requestRepo.FindByID(id)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get request")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_request")
 		return
 	}
 	if reactivationRequest == nil {
-		respondError(w, http.StatusNotFound, "Request not found")
+		respondError(w, r, http.StatusNotFound, "request_not_found")
 		return
 	}
 
 	// Check if already reviewed
 	if reactivationRequest.Status != "pending" {
-		respondError(w, http.StatusBadRequest, "Request has already been reviewed")
+		respondError(w, r, http.StatusBadRequest, "request_already_reviewed")
 		return
 	}
 
 	// Get user
 	user, err := h.userRepo.FindByID(reactivationRequest.UserID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get user")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_user")
 		return
 	}
 	if user == nil {
-		respondError(w, http.StatusNotFound, "User not found")
-		return
-	}
-
-	// Approve request
-	if err := h.requestRepo.Approve(id, reviewerID, req.Message); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to approve request")
+		respondError(w, r, http.StatusNotFound, "user_not_found")
 		return
 	}
 
-	// Activate user
-	if err := h.userRepo.Activate(reactivationRequest.UserID); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to activate user")
+	// Approve request and activate user, the user is notified through the user.activated event
+	errorCode := "failed_to_approve_request"
+	err = h.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		if err := h.requestRepo.WithTx(tx).Approve(id, reviewerID, req.Message); err != nil {
+			return nil, err
+		}
+		if err := h.userRepo.WithTx(tx).Activate(reactivationRequest.UserID); err != nil {
+			errorCode = "failed_to_activate_user"
+			return nil, err
+		}
+		event, err := models.NewDomainEvent(models.EventUserActivated, models.AggregateUser, user.ID, &reviewerID,
+			&models.UserActivatedEventData{UserID: user.ID, Name: user.Name, Message: req.Message})
+		return []*models.DomainEvent{event}, err
+	})
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, errorCode)
 		return
 	}
 
-	// Send email notification
-	if user.Email != nil && h.emailService != nil {
-		go h.emailService.SendAccountReactivated(*user.Email, user.Name, req.Message)
-	}
-
 	respondJSON(w, http.StatusOK, map[string]string{"message": "Request approved and user reactivated"})
 }
 
@@ -190,7 +219,7 @@ func (h *ReactivationRequestHandler) DenyRequest(w http.ResponseWriter, r *http.
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_request_id")
 		return
 	}
 
@@ -207,41 +236,53 @@ func (h *ReactivationRequestHandler) DenyRequest(w http.ResponseWriter, r *http.
 	// Get reactivation request
 	reactivationRequest, err := h.requestRepo.FindByID(id)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get request")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_request")
 		return
 	}
 	if reactivationRequest == nil {
-		respondError(w, http.StatusNotFound, "Request not found")
+		respondError(w, r, http.StatusNotFound, "request_not_found")
 		return
 	}
 
 	// Check if already reviewed
 	if reactivationRequest.Status != "pending" {
-		respondError(w, http.StatusBadRequest, "Request has already been reviewed")
+		respondError(w, r, http.StatusBadRequest, "request_already_reviewed")
 		return
 	}
 
 	// Get user
 	user, err := h.userRepo.FindByID(reactivationRequest.UserID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get user")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_user")
 		return
 	}
 	if user == nil {
-		respondError(w, http.StatusNotFound, "User not found")
+		respondError(w, r, http.StatusNotFound, "user_not_found")
 		return
 	}
 
 	// Deny request
-	if err := h.requestRepo.Deny(id, reviewerID, req.Message); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to deny request")
+	err = h.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		if err := h.requestRepo.WithTx(tx).Deny(id, reviewerID, req.Message); err != nil {
+			return nil, err
+		}
+		event, err := newReactivationRequestEvent(models.EventReactivationDenied, reviewerID, reactivationRequest, user, req.Message)
+		return []*models.DomainEvent{event}, err
+	})
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_deny_request")
 		return
 	}
 
-	// Send email notification
-	if user.Email != nil && h.emailService != nil {
-		go h.emailService.SendReactivationDenied(*user.Email, user.Name, req.Message)
+	// Notify user
+	if user.Email != nil && h.notifier != nil {
+		go h.notifier.SendReactivationDenied(*user.Email, user.Name, req.Message)
 	}
 
 	respondJSON(w, http.StatusOK, map[string]string{"message": "Request denied"})
 }
+
+func newReactivationRequestEvent(eventType string, actorID int, request *models.ReactivationRequest, user *models.User, message *string) (*models.DomainEvent, error) {
+	return models.NewDomainEvent(eventType, models.AggregateReactivationRequest, request.ID, &actorID,
+		&models.ReactivationRequestEventData{RequestID: request.ID, UserID: request.UserID, UserName: user.Name, Message: message})
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/middleware"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/services"
+)
+
+// RegistrationRequestHandler handles the registrations admins approve in the approval_required mode
+type RegistrationRequestHandler struct {
+	db           *sql.DB
+	cfg          *config.Config
+	requestRepo  *repository.RegistrationRequestRepository
+	userRepo     *repository.UserRepository
+	emailService *services.EmailService
+	notifier     *services.NotificationService
+	outbox       *services.OutboxService
+}
+
+// NewRegistrationRequestHandler creates a new registration request handler
+func NewRegistrationRequestHandler(db *sql.DB, cfg *config.Config) *RegistrationRequestHandler {
+	emailService, err := services.NewEmailServiceFromConfig(db, cfg)
+	if err != nil {
+		println("Warning: Failed to initialize email service:", err.Error())
+	}
+
+	return &RegistrationRequestHandler{
+		db:           db,
+		cfg:          cfg,
+		requestRepo:  repository.NewRegistrationRequestRepository(db),
+		userRepo:     repository.NewUserRepository(db),
+		emailService: emailService,
+		notifier:     services.NewNotificationServiceFromConfig(db, cfg, emailService),
+		outbox:       services.NewOutboxService(db),
+	}
+}
+
+// ListRequests lists pending registrations, oldest first (admin only)
+func (h *RegistrationRequestHandler) ListRequests(w http.ResponseWriter, r *http.Request) {
+	requests, err := h.requestRepo.FindAllPending()
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_requests")
+		return
+	}
+
+	// Populate user details
+	for _, req := range requests {
+		user, err := h.userRepo.FindByID(req.UserID)
+		if err == nil && user != nil {
+			req.User = user
+		}
+	}
+
+	respondJSON(w, http.StatusOK, requests)
+}
+
+// ApproveRequest activates the account of a pending registration (admin only)
+// Users who already verified their email address get the welcome notification.
+func (h *RegistrationRequestHandler) ApproveRequest(w http.ResponseWriter, r *http.Request) {
+	request, user, req, ok := h.findPendingRequest(w, r)
+	if !ok {
+		return
+	}
+	reviewerID, _ := r.Context().Value(middleware.UserIDKey).(int)
+
+	user.IsActive = true
+	user.LastActivityAt = time.Now()
+
+	err := h.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		if err := h.requestRepo.WithTx(tx).Approve(request.ID, reviewerID, req.Message); err != nil {
+			return nil, err
+		}
+		if err := h.userRepo.WithTx(tx).Update(user); err != nil {
+			return nil, err
+		}
+		event, err := newRegistrationRequestEvent(models.EventRegistrationApproved, reviewerID, request, user, req.Message)
+		return []*models.DomainEvent{event}, err
+	})
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_approve_request")
+		return
+	}
+	middleware.AuditTarget(r, "user", user.ID)
+
+	if user.IsVerified && user.Email != nil && h.notifier != nil {
+		go h.notifier.SendWelcome(*user.Email, user.Name)
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Registration approved"})
+}
+
+// DenyRequest denies a pending registration and deletes the account (admin only)
+func (h *RegistrationRequestHandler) DenyRequest(w http.ResponseWriter, r *http.Request) {
+	request, user, req, ok := h.findPendingRequest(w, r)
+	if !ok {
+		return
+	}
+	reviewerID, _ := r.Context().Value(middleware.UserIDKey).(int)
+
+	// The account is anonymized, the email is sent to the address it had
+	email, lang := user.Email, user.PreferredLanguage
+
+	err := h.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		if err := h.requestRepo.WithTx(tx).Deny(request.ID, reviewerID, req.Message); err != nil {
+			return nil, err
+		}
+		if err := h.userRepo.WithTx(tx).DeleteAccount(user.ID); err != nil {
+			return nil, err
+		}
+		event, err := newRegistrationRequestEvent(models.EventRegistrationDenied, reviewerID, request, user, req.Message)
+		return []*models.DomainEvent{event}, err
+	})
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_deny_request")
+		return
+	}
+	middleware.AuditTarget(r, "user", user.ID)
+
+	if email != nil && h.emailService != nil {
+		go func() {
+			if err := h.emailService.SendRegistrationDenied(*email, user.Name, lang, req.Message); err != nil {
+				fmt.Printf("Failed to send registration denied email: %v\n", err)
+			}
+		}()
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Registration denied"})
+}
+
+// findPendingRequest loads the pending request of the URL, its user and the review body
+// It responds with an error if the request does not exist or was already reviewed.
+func (h *RegistrationRequestHandler) findPendingRequest(w http.ResponseWriter, r *http.Request) (*models.RegistrationRequest, *models.User, *models.ReviewRegistrationRequestRequest, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_request_id")
+		return nil, nil, nil, false
+	}
+
+	// Allow empty body
+	req := &models.ReviewRegistrationRequestRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		req = &models.ReviewRegistrationRequestRequest{}
+	}
+
+	request, err := h.requestRepo.FindByID(id)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_request")
+		return nil, nil, nil, false
+	}
+	if request == nil {
+		respondError(w, r, http.StatusNotFound, "request_not_found")
+		return nil, nil, nil, false
+	}
+	if request.Status != "pending" {
+		respondError(w, r, http.StatusBadRequest, "request_already_reviewed")
+		return nil, nil, nil, false
+	}
+
+	user, err := h.userRepo.FindByID(request.UserID)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_user")
+		return nil, nil, nil, false
+	}
+	if user == nil || user.IsDeleted {
+		respondError(w, r, http.StatusNotFound, "user_not_found")
+		return nil, nil, nil, false
+	}
+
+	return request, user, req, true
+}
+
+func newRegistrationRequestEvent(eventType string, actorID int, request *models.RegistrationRequest, user *models.User, message *string) (*models.DomainEvent, error) {
+	return models.NewDomainEvent(eventType, models.AggregateRegistrationRequest, request.ID, &actorID,
+		&models.RegistrationRequestEventData{RequestID: request.ID, UserID: request.UserID, UserName: user.Name, Message: message})
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestRegistrationRequestHandler tests approving and denying pending registrations
+func TestRegistrationRequestHandler(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	cfg := &config.Config{JWTSecret: "test-secret"}
+	handler := NewRegistrationRequestHandler(db, cfg)
+	requestRepo := repository.NewRegistrationRequestRepository(db)
+	userRepo := repository.NewUserRepository(db)
+
+	adminID := testutil.SeedTestUser(t, db, "admin@example.com", "Admin", "orange")
+
+	pendingRegistration := func(email string) *models.RegistrationRequest {
+		t.Helper()
+		userID := testutil.SeedTestUser(t, db, email, "New User", "green")
+		db.Exec("UPDATE users SET is_active = 0 WHERE id = ?", userID)
+		request := &models.RegistrationRequest{UserID: userID}
+		if err := requestRepo.Create(request); err != nil {
+			t.Fatalf("Failed to create registration request: %v", err)
+		}
+		return request
+	}
+	review := func(action func(http.ResponseWriter, *http.Request), id int, body interface{}) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest("PUT", "/api/registration-requests/x", bytes.NewReader(payload))
+		req = req.WithContext(contextWithUser(req.Context(), adminID, "admin@example.com", true))
+		req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(id)})
+		rec := httptest.NewRecorder()
+		action(rec, req)
+		return rec
+	}
+
+	approved := pendingRegistration("approve@example.com")
+	denied := pendingRegistration("deny@example.com")
+
+	t.Run("list pending", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/registration-requests", nil)
+		rec := httptest.NewRecorder()
+		handler.ListRequests(rec, req)
+
+		var requests []*models.RegistrationRequest
+		json.Unmarshal(rec.Body.Bytes(), &requests)
+		if rec.Code != http.StatusOK || len(requests) != 2 || requests[0].User == nil {
+			t.Errorf("Expected 2 pending registrations with users, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("approve", func(t *testing.T) {
+		rec := review(handler.ApproveRequest, approved.ID, map[string]string{"message": "Willkommen"})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if user, _ := userRepo.FindByID(approved.UserID); !user.IsActive {
+			t.Error("Expected the approved user to be active")
+		}
+
+		if rec := review(handler.ApproveRequest, approved.ID, nil); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for a reviewed request, got %d", rec.Code)
+		}
+	})
+
+	t.Run("deny deletes the account", func(t *testing.T) {
+		rec := review(handler.DenyRequest, denied.ID, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		user, _ := userRepo.FindByID(denied.UserID)
+		if !user.IsDeleted || user.Email != nil || user.IsActive {
+			t.Errorf("Expected the denied account to be anonymized, got %+v", user)
+		}
+		if request, _ := requestRepo.FindByID(denied.ID); request.Status != "denied" {
+			t.Errorf("Expected the request to be denied, got %s", request.Status)
+		}
+	})
+
+	t.Run("unknown request", func(t *testing.T) {
+		if rec := review(handler.DenyRequest, 9999, nil); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", rec.Code)
+		}
+	})
+}
@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/middleware"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+)
+
+// RoleHandler handles roles and role assignments (super admin only)
+// Changed roles take effect with the next request: the access tokens of the affected users are invalidated
+// and reissued with the new permissions on refresh.
+type RoleHandler struct {
+	db       *sql.DB
+	roleRepo *repository.RoleRepository
+	userRepo *repository.UserRepository
+	config   *config.Config
+}
+
+// NewRoleHandler creates a new role handler
+func NewRoleHandler(db *sql.DB, cfg *config.Config) *RoleHandler {
+	return &RoleHandler{
+		db:       db,
+		roleRepo: repository.NewRoleRepository(db),
+		userRepo: repository.NewUserRepository(db),
+		config:   cfg,
+	}
+}
+
+// ListRoles lists all roles and the available permissions
+func (h *RoleHandler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.roleRepo.FindAll()
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_roles")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"roles":       roles,
+		"permissions": models.AllPermissions,
+	})
+}
+
+// CreateRole creates a custom role
+func (h *RoleHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
+	var req models.RoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respondValidationError(w, r, err)
+		return
+	}
+
+	if !h.nameAvailable(w, r, req.Name, 0) {
+		return
+	}
+
+	role := &models.Role{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+		CreatedAt:   time.Now(),
+	}
+	if err := h.inTx(func(repo *repository.RoleRepository) error { return repo.Create(role) }); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_create_role")
+		return
+	}
+	middleware.AuditTarget(r, "role", role.ID)
+	middleware.AuditChange(r, nil, role)
+
+	respondJSON(w, http.StatusCreated, role)
+}
+
+// UpdateRole changes name, description and permissions of a custom role
+func (h *RoleHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
+	role, ok := h.findCustomRole(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.RoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respondValidationError(w, r, err)
+		return
+	}
+
+	if !h.nameAvailable(w, r, req.Name, role.ID) {
+		return
+	}
+
+	before := *role
+	role.Name = req.Name
+	role.Description = req.Description
+	role.Permissions = req.Permissions
+	if err := h.inTx(func(repo *repository.RoleRepository) error { return repo.Update(role) }); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_update_role")
+		return
+	}
+	middleware.AuditChange(r, before, role)
+
+	respondJSON(w, http.StatusOK, role)
+}
+
+// DeleteRole deletes a custom role, users holding it lose its permissions
+func (h *RoleHandler) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	role, ok := h.findCustomRole(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.inTx(func(repo *repository.RoleRepository) error { return repo.Delete(role.ID) }); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_delete_role")
+		return
+	}
+	middleware.AuditChange(r, role, nil)
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Role deleted"})
+}
+
+// GetUserRoles returns the roles of a user and the permissions they grant
+func (h *RoleHandler) GetUserRoles(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.findUser(w, r)
+	if !ok {
+		return
+	}
+
+	roles, err := h.roleRepo.ForUser(user)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_roles")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, &models.UserRolesResponse{
+		Roles:       roles,
+		Permissions: models.PermissionsOf(roles),
+	})
+}
+
+// UpdateUserRoles replaces the assigned roles of a user
+// admin and super_admin follow the admin flags, they are changed with promote and demote.
+func (h *RoleHandler) UpdateUserRoles(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.findUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateUserRolesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+
+	roleIDs := []int{}
+	seen := map[int]bool{}
+	for _, id := range req.RoleIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		role, err := h.roleRepo.FindByID(id)
+		if err != nil {
+			respondError(w, r, http.StatusInternalServerError, "failed_to_get_roles")
+			return
+		}
+		if role == nil {
+			respondError(w, r, http.StatusBadRequest, "role_not_found")
+			return
+		}
+		if models.IsFlagRole(role.Name) {
+			respondError(w, r, http.StatusBadRequest, "cannot_assign_admin_role")
+			return
+		}
+		roleIDs = append(roleIDs, id)
+	}
+
+	before, err := h.roleRepo.ForUser(user)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_roles")
+		return
+	}
+
+	if err := h.inTx(func(repo *repository.RoleRepository) error { return repo.SetUserRoles(user.ID, roleIDs) }); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_update_user_roles")
+		return
+	}
+
+	roles, err := h.roleRepo.ForUser(user)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_roles")
+		return
+	}
+	middleware.AuditTarget(r, "user", user.ID)
+	middleware.AuditChange(r, map[string][]string{"roles": roleNames(before)}, map[string][]string{"roles": roleNames(roles)})
+
+	respondJSON(w, http.StatusOK, &models.UserRolesResponse{
+		Roles:       roles,
+		Permissions: models.PermissionsOf(roles),
+	})
+}
+
+// inTx runs fn with a repository bound to a transaction, role and permission rows change together
+func (h *RoleHandler) inTx(fn func(repo *repository.RoleRepository) error) error {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(h.roleRepo.WithTx(tx)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// nameAvailable responds with a conflict if another role already has the name
+func (h *RoleHandler) nameAvailable(w http.ResponseWriter, r *http.Request, name string, roleID int) bool {
+	existing, err := h.roleRepo.FindByName(name)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_roles")
+		return false
+	}
+	if existing != nil && existing.ID != roleID {
+		respondError(w, r, http.StatusConflict, "role_name_taken")
+		return false
+	}
+	return true
+}
+
+func (h *RoleHandler) findCustomRole(w http.ResponseWriter, r *http.Request) (*models.Role, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_role_id")
+		return nil, false
+	}
+
+	role, err := h.roleRepo.FindByID(id)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_roles")
+		return nil, false
+	}
+	if role == nil {
+		respondError(w, r, http.StatusNotFound, "role_not_found")
+		return nil, false
+	}
+	if role.IsBuiltin {
+		respondError(w, r, http.StatusBadRequest, "role_builtin")
+		return nil, false
+	}
+	return role, true
+}
+
+func (h *RoleHandler) findUser(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_user_id")
+		return nil, false
+	}
+
+	user, err := h.userRepo.FindByID(id)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "database_error")
+		return nil, false
+	}
+	if user == nil {
+		respondError(w, r, http.StatusNotFound, "user_not_found")
+		return nil, false
+	}
+	return user, true
+}
+
+func roleNames(roles []*models.Role) []string {
+	names := []string{}
+	for _, role := range roles {
+		names = append(names, role.Name)
+	}
+	return names
+}
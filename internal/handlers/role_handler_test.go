@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestRoleHandler tests managing custom roles and assigning roles to users
+func TestRoleHandler(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	handler := NewRoleHandler(db, &config.Config{JWTSecret: "test-secret"})
+	superAdminID := testutil.SeedTestUser(t, db, "super@example.com", "Super", "orange")
+	staffID := testutil.SeedTestUser(t, db, "staff@example.com", "Staff", "green")
+
+	send := func(handle http.HandlerFunc, method string, body interface{}, id int) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if body != nil {
+			json.NewEncoder(&buf).Encode(body)
+		}
+		req := httptest.NewRequest(method, "/api/admin/roles", &buf)
+		req = req.WithContext(contextWithUser(req.Context(), superAdminID, "super@example.com", true))
+		if id != 0 {
+			req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(id)})
+		}
+		rec := httptest.NewRecorder()
+		handle(rec, req)
+		return rec
+	}
+
+	roleRepo := repository.NewRoleRepository(db)
+	admin, _ := roleRepo.FindByName(models.RoleAdmin)
+	scheduler, _ := roleRepo.FindByName(models.RoleScheduler)
+
+	var created models.Role
+	t.Run("creates a custom role", func(t *testing.T) {
+		rec := send(handler.CreateRole, "POST", map[string]interface{}{
+			"name": "front_desk", "description": "Empfang", "permissions": []string{models.PermissionViewDashboard, models.PermissionManageDogs},
+		}, 0)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+		}
+		json.Unmarshal(rec.Body.Bytes(), &created)
+		if created.ID == 0 || created.IsBuiltin || len(created.Permissions) != 2 {
+			t.Errorf("Unexpected role %+v", created)
+		}
+
+		rec = send(handler.CreateRole, "POST", map[string]interface{}{
+			"name": "front_desk", "permissions": []string{models.PermissionManageDogs},
+		}, 0)
+		if rec.Code != http.StatusConflict {
+			t.Errorf("Expected status 409 for a duplicate name, got %d", rec.Code)
+		}
+
+		rec = send(handler.CreateRole, "POST", map[string]interface{}{
+			"name": "auditor", "permissions": []string{models.PermissionViewAuditLog},
+		}, 0)
+		if rec.Code != http.StatusBadRequest || !bytes.Contains(rec.Body.Bytes(), []byte("permission_not_assignable")) {
+			t.Errorf("Expected super admin permissions to be rejected, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("built-in roles cannot be changed", func(t *testing.T) {
+		rec := send(handler.UpdateRole, "PUT", map[string]interface{}{
+			"name": "planner", "permissions": []string{models.PermissionManageDogs},
+		}, scheduler.ID)
+		if rec.Code != http.StatusBadRequest || !bytes.Contains(rec.Body.Bytes(), []byte("role_builtin")) {
+			t.Errorf("Expected role_builtin, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if rec := send(handler.DeleteRole, "DELETE", nil, admin.ID); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 deleting a built-in role, got %d", rec.Code)
+		}
+	})
+
+	t.Run("assigns roles to a user", func(t *testing.T) {
+		rec := send(handler.UpdateUserRoles, "PUT", map[string]interface{}{"role_ids": []int{admin.ID}}, staffID)
+		if rec.Code != http.StatusBadRequest || !bytes.Contains(rec.Body.Bytes(), []byte("cannot_assign_admin_role")) {
+			t.Errorf("Expected admin role to be rejected, got %d: %s", rec.Code, rec.Body.String())
+		}
+
+		rec = send(handler.UpdateUserRoles, "PUT", map[string]interface{}{"role_ids": []int{scheduler.ID, created.ID, scheduler.ID}}, staffID)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+
+		rec = send(handler.GetUserRoles, "GET", nil, staffID)
+		var response models.UserRolesResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		if len(response.Roles) != 2 || len(response.Permissions) != 3 {
+			t.Errorf("Expected 2 roles granting 3 permissions, got %+v", response)
+		}
+	})
+
+	t.Run("login token carries the permissions", func(t *testing.T) {
+		user, _ := repository.NewUserRepository(db).FindByID(staffID)
+		if err := loadUserRoles(roleRepo, user); err != nil {
+			t.Fatalf("loadUserRoles() failed: %v", err)
+		}
+		if len(user.Roles) != 2 || len(user.Permissions) != 3 {
+			t.Errorf("Expected roles and permissions of the user, got %v %v", user.Roles, user.Permissions)
+		}
+	})
+
+	t.Run("deleting a role removes its permissions", func(t *testing.T) {
+		if rec := send(handler.DeleteRole, "DELETE", nil, created.ID); rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		rec := send(handler.GetUserRoles, "GET", nil, staffID)
+		var response models.UserRolesResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		if len(response.Roles) != 1 || response.Permissions[0] != models.PermissionManageBookings {
+			t.Errorf("Expected only the scheduler role, got %+v", response)
+		}
+	})
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/i18n"
+	"github.com/tranmh/gassigeher/internal/services"
+)
+
+// RunSheetHandler serves the daily run sheet for staff
+type RunSheetHandler struct {
+	service *services.RunSheetService
+	config  *config.Config
+}
+
+// NewRunSheetHandler creates a new run sheet handler
+func NewRunSheetHandler(db *sql.DB, cfg *config.Config) *RunSheetHandler {
+	return &RunSheetHandler{
+		service: services.NewRunSheetService(db, nil),
+		config:  cfg,
+	}
+}
+
+// GetRunSheet returns the run sheet of a date (admin only)
+// Query: date=YYYY-MM-DD (default today), format=html for a printable page (print to PDF in the browser)
+func (h *RunSheetHandler) GetRunSheet(w http.ResponseWriter, r *http.Request) {
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		date = time.Now().Format("2006-01-02")
+	} else if _, err := time.Parse("2006-01-02", date); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_date_format")
+		return
+	}
+
+	sheet, err := h.service.Build(date)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_run_sheet")
+		return
+	}
+
+	if r.URL.Query().Get("format") != "html" {
+		respondJSON(w, http.StatusOK, sheet)
+		return
+	}
+
+	page, err := h.service.RenderHTML(sheet, i18n.FromRequest(r))
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_run_sheet")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(page))
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestRunSheetHandler tests getting the run sheet as JSON and as printable HTML
+func TestRunSheetHandler(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	handler := NewRunSheetHandler(db, &config.Config{JWTSecret: "test-secret"})
+
+	adminID := testutil.SeedTestUser(t, db, "admin@example.com", "Admin", "orange")
+	walker := testutil.SeedTestUser(t, db, "walker@example.com", "Walker", "green")
+	dogID := testutil.SeedTestDog(t, db, "Bella", "Labrador", "green")
+	testutil.SeedTestBooking(t, db, walker, dogID, "2025-12-10", "09:00", "scheduled")
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/admin/run-sheet"+query, nil)
+		req.Header.Set("Accept-Language", "en")
+		req = req.WithContext(contextWithUser(req.Context(), adminID, "admin@example.com", true))
+		rec := httptest.NewRecorder()
+		handler.GetRunSheet(rec, req)
+		return rec
+	}
+
+	t.Run("json", func(t *testing.T) {
+		rec := get("?date=2025-12-10")
+
+		var sheet models.RunSheet
+		json.Unmarshal(rec.Body.Bytes(), &sheet)
+		if rec.Code != http.StatusOK || sheet.Date != "2025-12-10" || len(sheet.Walks) != 1 {
+			t.Fatalf("Unexpected run sheet %d: %s", rec.Code, rec.Body.String())
+		}
+		if sheet.Walks[0].WalkerName != "Walker" || sheet.Walks[0].DogName != "Bella" {
+			t.Errorf("Unexpected walk: %+v", sheet.Walks[0])
+		}
+	})
+
+	t.Run("html", func(t *testing.T) {
+		rec := get("?date=2025-12-10&format=html")
+
+		if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+			t.Fatalf("Expected HTML page, got %d (%s)", rec.Code, rec.Header().Get("Content-Type"))
+		}
+		if !strings.Contains(rec.Body.String(), "Run sheet 10.12.2025") || !strings.Contains(rec.Body.String(), "Bella") {
+			t.Errorf("Unexpected page: %s", rec.Body.String())
+		}
+	})
+
+	t.Run("invalid date", func(t *testing.T) {
+		rec := get("?date=10.12.2025")
+
+		var resp map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		if rec.Code != http.StatusBadRequest || resp["code"] != "invalid_date_format" {
+			t.Errorf("Expected 400 invalid_date_format, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+}
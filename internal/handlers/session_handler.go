@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/middleware"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/services"
+)
+
+// SessionHandler lists and ends the login sessions of users
+// Sessions are created by AuthHandler on every login, see services.TokenService.
+type SessionHandler struct {
+	userRepo    *repository.UserRepository
+	sessionRepo *repository.SessionRepository
+	tokens      *services.TokenService
+	config      *config.Config
+}
+
+// NewSessionHandler creates a new session handler
+func NewSessionHandler(db *sql.DB, cfg *config.Config) *SessionHandler {
+	return &SessionHandler{
+		userRepo:    repository.NewUserRepository(db),
+		sessionRepo: repository.NewSessionRepository(db),
+		tokens:      services.NewTokenService(db, cfg),
+		config:      cfg,
+	}
+}
+
+// ListSessions returns the active sessions of the current user, the session of the request is marked current
+func (h *SessionHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(middleware.UserIDKey).(int)
+	h.listSessions(w, r, userID)
+}
+
+// RevokeSession ends a session of the current user
+// Ending the current session logs out, like POST /auth/logout.
+func (h *SessionHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(middleware.UserIDKey).(int)
+	h.revokeSession(w, r, userID, mux.Vars(r)["id"])
+}
+
+// ListUserSessions returns the active sessions of a user (admin)
+func (h *SessionHandler) ListUserSessions(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.findUser(w, r)
+	if !ok {
+		return
+	}
+	h.listSessions(w, r, user)
+}
+
+// RevokeUserSession ends a session of a user (admin)
+func (h *SessionHandler) RevokeUserSession(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.findUser(w, r)
+	if !ok {
+		return
+	}
+	middleware.AuditTarget(r, "user", user)
+	h.revokeSession(w, r, user, mux.Vars(r)["sessionId"])
+}
+
+func (h *SessionHandler) listSessions(w http.ResponseWriter, r *http.Request, userID int) {
+	sessions, err := h.sessionRepo.ListActive(userID, time.Now())
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_sessions")
+		return
+	}
+
+	currentID, _ := r.Context().Value(middleware.SessionIDKey).(int)
+	for _, session := range sessions {
+		session.Current = session.ID == currentID
+	}
+	respondJSON(w, http.StatusOK, sessions)
+}
+
+func (h *SessionHandler) revokeSession(w http.ResponseWriter, r *http.Request, userID int, rawID string) {
+	sessionID, err := strconv.Atoi(rawID)
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_session_id")
+		return
+	}
+
+	err = h.tokens.RevokeSession(userID, sessionID)
+	if errors.Is(err, services.ErrSessionNotFound) {
+		respondError(w, r, http.StatusNotFound, "session_not_found")
+		return
+	}
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_revoke_session")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Session ended"})
+}
+
+// findUser returns the ID of the user of the route, if the user exists
+func (h *SessionHandler) findUser(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_user_id")
+		return 0, false
+	}
+
+	user, err := h.userRepo.FindByID(id)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "database_error")
+		return 0, false
+	}
+	if user == nil {
+		respondError(w, r, http.StatusNotFound, "user_not_found")
+		return 0, false
+	}
+	return user.ID, true
+}
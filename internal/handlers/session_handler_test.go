@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/middleware"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/services"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestSessionHandler tests listing and ending sessions by the user and by admins
+func TestSessionHandler(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	cfg := &config.Config{JWTSecret: "test-secret", AccessTokenMinutes: 15, JWTExpirationHours: 24}
+	handler := NewSessionHandler(db, cfg)
+	tokens := services.NewTokenService(db, cfg)
+
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "User", "green")
+	adminID := testutil.SeedTestUser(t, db, "admin@example.com", "Admin", "green")
+	user, _ := repository.NewUserRepository(db).FindByID(userID)
+
+	laptop, _ := tokens.Issue(user, models.NewSessionClient("Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:128.0) Gecko/20100101 Firefox/128.0", "203.0.113.1"))
+	phone, _ := tokens.Issue(user, models.NewSessionClient("Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1", "198.51.100.2"))
+
+	send := func(handle http.HandlerFunc, currentID, sessionID int, vars map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/users/me/sessions", nil)
+		ctx := contextWithUser(req.Context(), currentID, "", currentID == adminID)
+		if sessionID != 0 {
+			ctx = context.WithValue(ctx, middleware.SessionIDKey, sessionID)
+		}
+		req = mux.SetURLVars(req.WithContext(ctx), vars)
+		rec := httptest.NewRecorder()
+		handle(rec, req)
+		return rec
+	}
+	list := func(rec *httptest.ResponseRecorder) []models.UserSession {
+		t.Helper()
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var sessions []models.UserSession
+		json.Unmarshal(rec.Body.Bytes(), &sessions)
+		return sessions
+	}
+
+	sessions := list(send(handler.ListSessions, userID, 0, nil))
+	if len(sessions) != 2 {
+		t.Fatalf("Expected 2 sessions, got %d", len(sessions))
+	}
+	phoneSession, laptopSession := sessions[0], sessions[1]
+	if phoneSession.Device != "Safari (iOS)" || phoneSession.IPAddress != "198.51.100.2" || laptopSession.Device != "Firefox (Windows)" {
+		t.Errorf("Unexpected sessions %+v", sessions)
+	}
+
+	t.Run("marks the current session", func(t *testing.T) {
+		for _, session := range list(send(handler.ListSessions, userID, laptopSession.ID, nil)) {
+			if session.Current != (session.ID == laptopSession.ID) {
+				t.Errorf("Unexpected current flag of session %d", session.ID)
+			}
+		}
+	})
+
+	t.Run("ends only own sessions", func(t *testing.T) {
+		vars := map[string]string{"id": strconv.Itoa(phoneSession.ID)}
+		if rec := send(handler.RevokeSession, adminID, 0, vars); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for the session of another user, got %d", rec.Code)
+		}
+		if rec := send(handler.RevokeSession, userID, 0, map[string]string{"id": "abc"}); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for an invalid ID, got %d", rec.Code)
+		}
+
+		if rec := send(handler.RevokeSession, userID, laptopSession.ID, vars); rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if _, err := tokens.Refresh(phone.RefreshToken, models.SessionClient{}); !errors.Is(err, services.ErrInvalidRefreshToken) {
+			t.Errorf("Expected the refresh token of the ended session to be rejected, got %v", err)
+		}
+		if rec := send(handler.RevokeSession, userID, 0, vars); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for an ended session, got %d", rec.Code)
+		}
+	})
+
+	t.Run("admins end sessions of users", func(t *testing.T) {
+		vars := map[string]string{"id": strconv.Itoa(userID)}
+		sessions := list(send(handler.ListUserSessions, adminID, 0, vars))
+		if len(sessions) != 1 || sessions[0].ID != laptopSession.ID {
+			t.Fatalf("Expected the laptop session, got %+v", sessions)
+		}
+
+		vars["sessionId"] = strconv.Itoa(laptopSession.ID)
+		if rec := send(handler.RevokeUserSession, adminID, 0, vars); rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if _, err := tokens.Refresh(laptop.RefreshToken, models.SessionClient{}); !errors.Is(err, services.ErrInvalidRefreshToken) {
+			t.Errorf("Expected the refresh token of the ended session to be rejected, got %v", err)
+		}
+
+		if rec := send(handler.ListUserSessions, adminID, 0, map[string]string{"id": "9999"}); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for an unknown user, got %d", rec.Code)
+		}
+	})
+}
@@ -5,11 +5,14 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/middleware"
 	"github.com/tranmh/gassigeher/internal/models"
 	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/services"
 )
 
 // SettingsHandler handles system settings-related HTTP requests
@@ -17,6 +20,7 @@ type SettingsHandler struct {
 	db           *sql.DB
 	cfg          *config.Config
 	settingsRepo *repository.SettingsRepository
+	outbox       *services.OutboxService
 }
 
 // NewSettingsHandler creates a new settings handler
@@ -25,6 +29,7 @@ func NewSettingsHandler(db *sql.DB, cfg *config.Config) *SettingsHandler {
 		db:           db,
 		cfg:          cfg,
 		settingsRepo: repository.NewSettingsRepository(db),
+		outbox:       services.NewOutboxService(db),
 	}
 }
 
@@ -32,7 +37,7 @@ func NewSettingsHandler(db *sql.DB, cfg *config.Config) *SettingsHandler {
 func (h *SettingsHandler) GetAllSettings(w http.ResponseWriter, r *http.Request) {
 	settings, err := h.settingsRepo.GetAll()
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get settings")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_settings")
 		return
 	}
 
@@ -48,13 +53,13 @@ func (h *SettingsHandler) UpdateSetting(w http.ResponseWriter, r *http.Request)
 	// Parse request
 	var req models.UpdateSettingRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
 		return
 	}
 
 	// Validate request
 	if err := req.Validate(); err != nil {
-		respondError(w, http.StatusBadRequest, err.Error())
+		respondValidationError(w, r, err)
 		return
 	}
 
@@ -64,24 +69,89 @@ func (h *SettingsHandler) UpdateSetting(w http.ResponseWriter, r *http.Request)
 		"booking_advance_days":      true,
 		"cancellation_notice_hours": true,
 		"auto_deactivation_days":    true,
+		"reminder_max_count":        true,
+		"reminder_max_days_before":  true,
 	}
 
 	if numericSettings[key] {
 		if val, err := strconv.Atoi(req.Value); err != nil || val <= 0 {
-			respondError(w, http.StatusBadRequest, "Value must be a positive integer")
+			respondError(w, r, http.StatusBadRequest, "value_must_be_positive_integer")
 			return
 		}
 	}
 
+	// Switches are stored as "true" or "false"
+	booleanSettings := map[string]bool{
+		models.SettingMagicLinkLogin:      true,
+		models.SettingMagicLinkSameDevice: true,
+	}
+
+	if booleanSettings[key] && req.Value != "true" && req.Value != "false" {
+		respondError(w, r, http.StatusBadRequest, "value_must_be_boolean")
+		return
+	}
+
+	// Who can register: open, invite_only or approval_required
+	if key == models.SettingRegistrationMode && !models.IsValidRegistrationMode(req.Value) {
+		respondError(w, r, http.StatusBadRequest, "invalid_registration_mode")
+		return
+	}
+
+	// Default reminder schedule, e.g. "1d@18:00,1h"
+	if key == models.SettingBookingReminders {
+		schedule, err := models.ParseReminderSchedule(req.Value)
+		if err != nil {
+			respondError(w, r, http.StatusBadRequest, "invalid_reminder_format")
+			return
+		}
+		req.Value = schedule.String()
+	}
+
+	// Run sheet digest: send time "HH:MM" or "off", recipients as comma separated emails
+	if key == models.SettingRunSheetSendTime {
+		sendTime, err := models.ParseRunSheetSendTime(req.Value)
+		if err != nil {
+			respondError(w, r, http.StatusBadRequest, "invalid_run_sheet_send_time")
+			return
+		}
+		req.Value = sendTime
+	}
+
+	if key == models.SettingRunSheetRecipients {
+		recipients, err := models.ParseRunSheetRecipients(req.Value)
+		if err != nil {
+			respondError(w, r, http.StatusBadRequest, "invalid_run_sheet_recipients")
+			return
+		}
+		req.Value = strings.Join(recipients, ", ")
+	}
+
+	// Previous value for the audit log
+	var previous *string
+	if setting, err := h.settingsRepo.Get(key); err == nil && setting != nil {
+		previous = &setting.Value
+	}
+
 	// Update setting
-	if err := h.settingsRepo.Update(key, req.Value); err != nil {
+	adminID, _ := r.Context().Value(middleware.UserIDKey).(int)
+	err := h.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		if err := h.settingsRepo.WithTx(tx).Update(key, req.Value); err != nil {
+			return nil, err
+		}
+		event, err := models.NewDomainEvent(models.EventSettingUpdated, models.AggregateSetting, 0, &adminID,
+			&models.SettingEventData{Key: key, Value: req.Value})
+		return []*models.DomainEvent{event}, err
+	})
+	if err != nil {
 		if err.Error() == "setting not found" {
-			respondError(w, http.StatusNotFound, err.Error())
+			respondError(w, r, http.StatusNotFound, "setting_not_found")
 			return
 		}
-		respondError(w, http.StatusInternalServerError, "Failed to update setting")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_update_setting")
 		return
 	}
+	middleware.AuditTarget(r, "setting", key)
+	middleware.AuditChange(r, map[string]interface{}{"value": previous}, map[string]string{"value": req.Value})
 
 	respondJSON(w, http.StatusOK, map[string]string{"message": "Setting updated successfully"})
 }
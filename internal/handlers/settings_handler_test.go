@@ -9,6 +9,8 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
 	"github.com/tranmh/gassigeher/internal/testutil"
 )
 
@@ -89,6 +91,12 @@ func TestSettingsHandler_UpdateSetting(t *testing.T) {
 		if value != "21" {
 			t.Errorf("Expected value '21', got %s", value)
 		}
+
+		events, _ := repository.NewDomainEventRepository(db).FindByAggregate(models.AggregateSetting, 0)
+		var data models.SettingEventData
+		if len(events) != 1 || events[0].Decode(&data) != nil || data.Key != "booking_advance_days" || data.Value != "21" {
+			t.Errorf("Expected setting.updated event, got %+v", events)
+		}
 	})
 
 	t.Run("non-admin cannot update settings", func(t *testing.T) {
@@ -175,6 +183,7 @@ func TestSettingsHandler_UpdateSetting(t *testing.T) {
 		body, _ := json.Marshal(reqBody)
 		req := httptest.NewRequest("PUT", "/api/settings/booking_advance_days", bytes.NewReader(body))
 		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Language", "en")
 		req = mux.SetURLVars(req, map[string]string{"key": "booking_advance_days"})
 		ctx := contextWithUser(req.Context(), adminID, "admin@example.com", true)
 		req = req.WithContext(ctx)
@@ -193,6 +202,9 @@ func TestSettingsHandler_UpdateSetting(t *testing.T) {
 		if errorMsg != "Value must be a positive integer" {
 			t.Errorf("Expected clear validation error, got %q", errorMsg)
 		}
+		if response["code"] != "value_must_be_positive_integer" {
+			t.Errorf("Expected error code value_must_be_positive_integer, got %v", response["code"])
+		}
 	})
 
 	t.Run("BUGFIX: reject negative value for numeric setting", func(t *testing.T) {
@@ -234,4 +246,65 @@ func TestSettingsHandler_UpdateSetting(t *testing.T) {
 			t.Errorf("BUGFIX: Expected status 400 for zero value, got %d", rec.Code)
 		}
 	})
+
+	t.Run("reminder schedule is validated and normalized", func(t *testing.T) {
+		update := func(value string) *httptest.ResponseRecorder {
+			body, _ := json.Marshal(map[string]interface{}{"value": value})
+			req := httptest.NewRequest("PUT", "/api/settings/booking_reminders", bytes.NewReader(body))
+			req = mux.SetURLVars(req, map[string]string{"key": "booking_reminders"})
+			req = req.WithContext(contextWithUser(req.Context(), adminID, "admin@example.com", true))
+
+			rec := httptest.NewRecorder()
+			handler.UpdateSetting(rec, req)
+			return rec
+		}
+
+		if rec := update("1d@18:00, tomorrow"); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for invalid schedule, got %d", rec.Code)
+		}
+
+		if rec := update("2d@19:00, 120m"); rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", rec.Code, rec.Body.String())
+		}
+
+		var value string
+		db.QueryRow("SELECT value FROM system_settings WHERE key = ?", "booking_reminders").Scan(&value)
+		if value != "2d@19:00,2h" {
+			t.Errorf("Expected normalized schedule '2d@19:00,2h', got %s", value)
+		}
+	})
+
+	t.Run("run sheet settings are validated and normalized", func(t *testing.T) {
+		update := func(key, value string) *httptest.ResponseRecorder {
+			body, _ := json.Marshal(map[string]interface{}{"value": value})
+			req := httptest.NewRequest("PUT", "/api/settings/"+key, bytes.NewReader(body))
+			req = mux.SetURLVars(req, map[string]string{"key": key})
+			req = req.WithContext(contextWithUser(req.Context(), adminID, "admin@example.com", true))
+
+			rec := httptest.NewRecorder()
+			handler.UpdateSetting(rec, req)
+			return rec
+		}
+
+		if rec := update("run_sheet_send_time", "6 Uhr"); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for invalid send time, got %d", rec.Code)
+		}
+		if rec := update("run_sheet_recipients", "staff@example.com, nobody"); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for invalid recipients, got %d", rec.Code)
+		}
+
+		if rec := update("run_sheet_send_time", "OFF"); rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", rec.Code, rec.Body.String())
+		}
+		if rec := update("run_sheet_recipients", "Staff@Example.com,office@example.com"); rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", rec.Code, rec.Body.String())
+		}
+
+		var sendTime, recipients string
+		db.QueryRow("SELECT value FROM system_settings WHERE key = ?", "run_sheet_send_time").Scan(&sendTime)
+		db.QueryRow("SELECT value FROM system_settings WHERE key = ?", "run_sheet_recipients").Scan(&recipients)
+		if sendTime != "off" || recipients != "staff@example.com, office@example.com" {
+			t.Errorf("Expected normalized values, got %q and %q", sendTime, recipients)
+		}
+	})
 }
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/i18n"
+	"github.com/tranmh/gassigeher/internal/middleware"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/services"
+)
+
+// TelegramHandler connects Telegram chats to user accounts
+type TelegramHandler struct {
+	userRepo *repository.UserRepository
+	telegram *services.TelegramChannel // nil = no bot configured
+	config   *config.Config
+}
+
+// telegramUpdate is the part of a Telegram update the bot handles
+type telegramUpdate struct {
+	Message *struct {
+		Text string `json:"text"`
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+		From *struct {
+			LanguageCode string `json:"language_code"`
+		} `json:"from"`
+	} `json:"message"`
+}
+
+// NewTelegramHandler creates a new Telegram handler
+func NewTelegramHandler(db *sql.DB, cfg *config.Config) *TelegramHandler {
+	return &TelegramHandler{
+		userRepo: repository.NewUserRepository(db),
+		telegram: services.NewTelegramChannelFromConfig(cfg),
+		config:   cfg,
+	}
+}
+
+// CreateLink returns a deep link that connects a Telegram chat to the current user
+func (h *TelegramHandler) CreateLink(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if h.telegram == nil {
+		respondError(w, r, http.StatusNotFound, "telegram_not_configured")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.TelegramLinkResponse{URL: h.telegram.LinkURL(userID, time.Now())})
+}
+
+// DeleteLink disconnects the Telegram chat of the current user
+func (h *TelegramHandler) DeleteLink(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := h.userRepo.SetTelegramChatID(userID, nil); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_update_telegram_link")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Telegram disconnected"})
+}
+
+// Webhook receives updates from the Telegram Bot API (public)
+// "/start <token>" from a deep link connects the chat to the user in the token.
+// Always answers 200 for handled requests, otherwise Telegram retries the update.
+func (h *TelegramHandler) Webhook(w http.ResponseWriter, r *http.Request) {
+	if h.telegram == nil {
+		respondError(w, r, http.StatusNotFound, "telegram_not_configured")
+		return
+	}
+
+	if h.config.TelegramWebhookSecret != "" {
+		secret := r.Header.Get("X-Telegram-Bot-Api-Secret-Token")
+		if subtle.ConstantTimeCompare([]byte(secret), []byte(h.config.TelegramWebhookSecret)) != 1 {
+			respondError(w, r, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+	}
+
+	var update telegramUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+
+	if update.Message == nil || !strings.HasPrefix(update.Message.Text, "/start") {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	chatID := strconv.FormatInt(update.Message.Chat.ID, 10)
+	lang := i18n.DefaultLanguage
+	if update.Message.From != nil {
+		lang = i18n.Normalize(update.Message.From.LanguageCode)
+	}
+
+	token := strings.TrimSpace(strings.TrimPrefix(update.Message.Text, "/start"))
+	userID, err := h.telegram.ParseLinkToken(token, time.Now())
+	if err != nil {
+		h.reply(chatID, i18n.T(lang, "notifications.telegram_link_invalid"))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	user, err := h.userRepo.FindByID(userID)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "database_error")
+		return
+	}
+	if user == nil || user.IsDeleted {
+		h.reply(chatID, i18n.T(lang, "notifications.telegram_link_invalid"))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if err := h.userRepo.SetTelegramChatID(userID, &chatID); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_update_telegram_link")
+		return
+	}
+
+	h.reply(chatID, i18n.T(i18n.Normalize(user.PreferredLanguage), "notifications.telegram_linked"))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *TelegramHandler) reply(chatID, message string) {
+	if err := h.telegram.Send(chatID, message); err != nil {
+		log.Printf("Failed to reply to Telegram chat %s: %v", chatID, err)
+	}
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/services"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestTelegramHandler_LinkAndWebhook tests connecting a chat via deep link and the bot webhook
+func TestTelegramHandler_LinkAndWebhook(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	// Local stand-in for the Telegram Bot API
+	var replies []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		replies = append(replies, payload["text"].(string))
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer api.Close()
+
+	cfg := &config.Config{
+		JWTSecret:             "test-secret",
+		TelegramBotToken:      "123:abc",
+		TelegramBotUsername:   "gassi_bot",
+		TelegramAPIURL:        api.URL,
+		TelegramWebhookSecret: "hook-secret",
+	}
+	handler := NewTelegramHandler(db, cfg)
+	userRepo := repository.NewUserRepository(db)
+
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "User", "green")
+
+	webhook := func(secret, text string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]interface{}{
+			"update_id": 1,
+			"message": map[string]interface{}{
+				"text": text,
+				"chat": map[string]interface{}{"id": 987654321},
+				"from": map[string]interface{}{"language_code": "en"},
+			},
+		})
+		req := httptest.NewRequest("POST", "/api/telegram/webhook", bytes.NewReader(body))
+		req.Header.Set("X-Telegram-Bot-Api-Secret-Token", secret)
+		rec := httptest.NewRecorder()
+		handler.Webhook(rec, req)
+		return rec
+	}
+
+	t.Run("create link", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/users/me/telegram-link", nil)
+		req = req.WithContext(contextWithUser(req.Context(), userID, "user@example.com", false))
+		rec := httptest.NewRecorder()
+		handler.CreateLink(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rec.Code)
+		}
+
+		var resp models.TelegramLinkResponse
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		if !strings.HasPrefix(resp.URL, "https://t.me/gassi_bot?start=") {
+			t.Errorf("Unexpected link %q", resp.URL)
+		}
+	})
+
+	t.Run("wrong webhook secret", func(t *testing.T) {
+		if rec := webhook("wrong", "/start x"); rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got %d", rec.Code)
+		}
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		rec := webhook("hook-secret", "/start 1_1_invalid")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rec.Code)
+		}
+		if chatID, _ := userRepo.GetTelegramChatID(userID); chatID != nil {
+			t.Error("Chat should not be linked")
+		}
+		if len(replies) != 1 || !strings.Contains(replies[0], "invalid") {
+			t.Errorf("Expected English error reply, got %v", replies)
+		}
+	})
+
+	t.Run("valid token links chat", func(t *testing.T) {
+		token := services.NewTelegramChannelFromConfig(cfg).GenerateLinkToken(userID, time.Now())
+		rec := webhook("hook-secret", "/start "+token)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+
+		chatID, _ := userRepo.GetTelegramChatID(userID)
+		if chatID == nil || *chatID != "987654321" {
+			t.Errorf("Expected chat 987654321 to be linked, got %v", chatID)
+		}
+		// Confirmation uses the account language
+		if last := replies[len(replies)-1]; !strings.Contains(last, "verbunden") {
+			t.Errorf("Expected German confirmation, got %q", last)
+		}
+	})
+
+	t.Run("unlink", func(t *testing.T) {
+		req := httptest.NewRequest("DELETE", "/api/users/me/telegram-link", nil)
+		req = req.WithContext(contextWithUser(req.Context(), userID, "user@example.com", false))
+		rec := httptest.NewRecorder()
+		handler.DeleteLink(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rec.Code)
+		}
+		if chatID, _ := userRepo.GetTelegramChatID(userID); chatID != nil {
+			t.Error("Chat should be unlinked")
+		}
+	})
+}
+
+// TestTelegramHandler_NotConfigured tests the endpoints without a bot token
+func TestTelegramHandler_NotConfigured(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	handler := NewTelegramHandler(db, &config.Config{JWTSecret: "test-secret"})
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "User", "green")
+
+	req := httptest.NewRequest("POST", "/api/users/me/telegram-link", nil)
+	req = req.WithContext(contextWithUser(req.Context(), userID, "user@example.com", false))
+	rec := httptest.NewRecorder()
+	handler.CreateLink(rec, req)
+
+	if rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), "telegram_not_configured") {
+		t.Errorf("Expected telegram_not_configured, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/middleware"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/services"
+)
+
+// TwoFactorHandler handles the 2FA settings of logged-in users and the super admin reset
+// The second login step is part of AuthHandler.
+type TwoFactorHandler struct {
+	userRepo    *repository.UserRepository
+	authService *services.AuthService
+	twoFactor   *services.TwoFactorService
+	tokens      *services.TokenService
+	config      *config.Config
+}
+
+// NewTwoFactorHandler creates a new two-factor handler
+func NewTwoFactorHandler(db *sql.DB, cfg *config.Config) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		userRepo:    repository.NewUserRepository(db),
+		authService: services.NewAuthService(cfg.JWTSecret, cfg.GetAccessTokenMinutes()),
+		twoFactor:   services.NewTwoFactorService(db),
+		tokens:      services.NewTokenService(db, cfg),
+		config:      cfg,
+	}
+}
+
+// GetStatus returns whether the current user uses 2FA
+func (h *TwoFactorHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	status, err := h.twoFactor.Status(user)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "database_error")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, status)
+}
+
+// Setup creates a new secret for the authenticator app, confirmed with Enable
+func (h *TwoFactorHandler) Setup(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	setup, err := h.twoFactor.Setup(user)
+	if errors.Is(err, services.ErrTwoFactorAlreadyEnabled) {
+		respondError(w, r, http.StatusBadRequest, "two_factor_already_enabled")
+		return
+	}
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_set_up_two_factor")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, setup)
+}
+
+// Enable turns on 2FA with the first code of the authenticator app and returns the recovery codes
+func (h *TwoFactorHandler) Enable(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+
+	codes, err := h.twoFactor.Enable(user.ID, req.Code)
+	if err != nil {
+		respondCodeError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// Disable turns off 2FA, it requires the password and a code
+// Staff cannot turn it off.
+func (h *TwoFactorHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+
+	required, err := h.twoFactor.RequiredFor(user)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "database_error")
+		return
+	}
+	if required {
+		respondError(w, r, http.StatusForbidden, "two_factor_required")
+		return
+	}
+	if user.PasswordHash == nil || !h.authService.CheckPassword(req.Password, *user.PasswordHash) {
+		respondError(w, r, http.StatusUnauthorized, "invalid_password")
+		return
+	}
+	if err := h.twoFactor.Verify(user.ID, req.Code, req.RecoveryCode); err != nil {
+		respondCodeError(w, r, err)
+		return
+	}
+
+	if err := h.twoFactor.Disable(user.ID); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_disable_two_factor")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes, it requires a code of the authenticator app
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+
+	if err := h.twoFactor.Verify(user.ID, req.Code, ""); err != nil {
+		respondCodeError(w, r, err)
+		return
+	}
+	codes, err := h.twoFactor.RegenerateRecoveryCodes(user.ID)
+	if err != nil {
+		respondCodeError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// ResetUser removes the 2FA of another user who lost their device (super admin only)
+// The user is logged out everywhere and sets up 2FA again with the next login if it is required.
+func (h *TwoFactorHandler) ResetUser(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_user_id")
+		return
+	}
+
+	if currentID, _ := r.Context().Value(middleware.UserIDKey).(int); currentID == id {
+		respondError(w, r, http.StatusBadRequest, "cannot_reset_own_two_factor")
+		return
+	}
+
+	user, err := h.userRepo.FindByID(id)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "database_error")
+		return
+	}
+	if user == nil {
+		respondError(w, r, http.StatusNotFound, "user_not_found")
+		return
+	}
+
+	if err := h.twoFactor.Disable(user.ID); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_disable_two_factor")
+		return
+	}
+	if err := h.tokens.RevokeAll(user.ID); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_logout")
+		return
+	}
+	middleware.AuditTarget(r, "user", user.ID)
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Two-factor authentication reset"})
+}
+
+func (h *TwoFactorHandler) currentUser(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return nil, false
+	}
+
+	user, err := h.userRepo.FindByID(userID)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "database_error")
+		return nil, false
+	}
+	if user == nil {
+		respondError(w, r, http.StatusNotFound, "user_not_found")
+		return nil, false
+	}
+	return user, true
+}
+
+// respondCodeError responds to a failed check of a 2FA code
+func respondCodeError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidTwoFactorCode):
+		respondError(w, r, http.StatusBadRequest, "invalid_two_factor_code")
+	case errors.Is(err, services.ErrTwoFactorNotSetUp):
+		respondError(w, r, http.StatusBadRequest, "two_factor_not_set_up")
+	case errors.Is(err, services.ErrTwoFactorAlreadyEnabled):
+		respondError(w, r, http.StatusBadRequest, "two_factor_already_enabled")
+	default:
+		respondError(w, r, http.StatusInternalServerError, "database_error")
+	}
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/services"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestAuthHandler_TwoFactorLogin tests the second login step and the mandatory setup for admins
+func TestAuthHandler_TwoFactorLogin(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	cfg := &config.Config{JWTSecret: "test-secret", JWTExpirationHours: 24}
+	handler := NewAuthHandler(db, cfg)
+
+	hash, _ := services.NewAuthService(cfg.JWTSecret, cfg.GetAccessTokenMinutes()).HashPassword("Test1234")
+	adminID := testutil.SeedTestUser(t, db, "admin@example.com", "Admin", "blue")
+	db.Exec("UPDATE users SET is_admin = 1, password_hash = ? WHERE id = ?", hash, adminID)
+
+	post := func(handle http.HandlerFunc, body interface{}) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		json.NewEncoder(&buf).Encode(body)
+		req := httptest.NewRequest("POST", "/api/auth/login", &buf)
+		rec := httptest.NewRecorder()
+		handle(rec, req)
+		return rec
+	}
+	login := func() models.TwoFactorChallengeResponse {
+		rec := post(handler.Login, map[string]string{"email": "admin@example.com", "password": "Test1234"})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Login failed with %d: %s", rec.Code, rec.Body.String())
+		}
+		var challenge models.TwoFactorChallengeResponse
+		json.Unmarshal(rec.Body.Bytes(), &challenge)
+		return challenge
+	}
+
+	var recoveryCodes []string
+	t.Run("admin sets up 2FA during login", func(t *testing.T) {
+		challenge := login()
+		if !challenge.TwoFactorSetupRequired || challenge.TwoFactorRequired || challenge.ChallengeToken == "" {
+			t.Fatalf("Expected the admin to set up 2FA, got %+v", challenge)
+		}
+
+		rec := post(handler.SetupTwoFactorLogin, map[string]string{"challenge_token": challenge.ChallengeToken})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var setup models.TwoFactorSetup
+		json.Unmarshal(rec.Body.Bytes(), &setup)
+
+		rec = post(handler.ConfirmTwoFactorLogin, map[string]string{"challenge_token": challenge.ChallengeToken, "code": "000000"})
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for a wrong code, got %d", rec.Code)
+		}
+
+		code, _ := services.TOTPCode(setup.Secret, time.Now())
+		rec = post(handler.ConfirmTwoFactorLogin, map[string]string{"challenge_token": challenge.ChallengeToken, "code": code})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var response models.LoginResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		if response.Token == "" || response.RefreshToken == "" || len(response.RecoveryCodes) != 10 {
+			t.Fatalf("Expected tokens and recovery codes, got %+v", response)
+		}
+		recoveryCodes = response.RecoveryCodes
+	})
+
+	t.Run("login asks for a code", func(t *testing.T) {
+		challenge := login()
+		if !challenge.TwoFactorRequired || challenge.TwoFactorSetupRequired {
+			t.Fatalf("Expected a code to be required, got %+v", challenge)
+		}
+
+		rec := post(handler.VerifyTwoFactor, map[string]string{"challenge_token": challenge.ChallengeToken, "code": "000000"})
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for a wrong code, got %d", rec.Code)
+		}
+
+		rec = post(handler.VerifyTwoFactor, map[string]string{"challenge_token": challenge.ChallengeToken, "recovery_code": recoveryCodes[0]})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var response models.LoginResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		if response.Token == "" || !response.IsAdmin || response.RecoveryCodes != nil {
+			t.Errorf("Unexpected login response %+v", response)
+		}
+
+		// The challenge is used up
+		rec = post(handler.VerifyTwoFactor, map[string]string{"challenge_token": challenge.ChallengeToken, "recovery_code": recoveryCodes[1]})
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for a used challenge, got %d", rec.Code)
+		}
+	})
+
+	t.Run("too many wrong codes end the challenge", func(t *testing.T) {
+		challenge := login()
+		for i := 0; i < 5; i++ {
+			post(handler.VerifyTwoFactor, map[string]string{"challenge_token": challenge.ChallengeToken, "code": "000000"})
+		}
+		rec := post(handler.VerifyTwoFactor, map[string]string{"challenge_token": challenge.ChallengeToken, "recovery_code": recoveryCodes[1]})
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 after too many wrong codes, got %d", rec.Code)
+		}
+	})
+}
+
+// TestTwoFactorHandler tests the 2FA settings of users and the super admin reset
+func TestTwoFactorHandler(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	cfg := &config.Config{JWTSecret: "test-secret", JWTExpirationHours: 24}
+	handler := NewTwoFactorHandler(db, cfg)
+	twoFactor := services.NewTwoFactorService(db)
+	userRepo := repository.NewUserRepository(db)
+
+	hash, _ := services.NewAuthService(cfg.JWTSecret, cfg.GetAccessTokenMinutes()).HashPassword("Test1234")
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "User", "green")
+	adminID := testutil.SeedTestUser(t, db, "admin@example.com", "Admin", "blue")
+	superAdminID := testutil.SeedTestUser(t, db, "super@example.com", "Super", "orange")
+	db.Exec("UPDATE users SET password_hash = ?", hash)
+	db.Exec("UPDATE users SET is_admin = 1 WHERE id = ?", adminID)
+
+	send := func(handle http.HandlerFunc, currentID int, body interface{}, targetID int) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if body != nil {
+			json.NewEncoder(&buf).Encode(body)
+		}
+		req := httptest.NewRequest("POST", "/api/users/me/2fa", &buf)
+		req = req.WithContext(contextWithUser(req.Context(), currentID, "", currentID != userID))
+		if targetID != 0 {
+			req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(targetID)})
+		}
+		rec := httptest.NewRecorder()
+		handle(rec, req)
+		return rec
+	}
+	enable := func(id int) {
+		user, _ := userRepo.FindByID(id)
+		setup, _ := twoFactor.Setup(user)
+		code, _ := services.TOTPCode(setup.Secret, time.Now().Add(-30*time.Second))
+		if _, err := twoFactor.Enable(id, code); err != nil {
+			t.Fatalf("Enable() failed: %v", err)
+		}
+	}
+
+	t.Run("user enables and disables 2FA", func(t *testing.T) {
+		rec := send(handler.Setup, userID, nil, 0)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var setup models.TwoFactorSetup
+		json.Unmarshal(rec.Body.Bytes(), &setup)
+		if setup.Secret == "" || setup.QRCode == "" {
+			t.Fatalf("Unexpected setup %+v", setup)
+		}
+
+		code, _ := services.TOTPCode(setup.Secret, time.Now().Add(-30*time.Second))
+		rec = send(handler.Enable, userID, map[string]string{"code": code}, 0)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var codes models.RecoveryCodesResponse
+		json.Unmarshal(rec.Body.Bytes(), &codes)
+		if len(codes.RecoveryCodes) != 10 {
+			t.Errorf("Expected 10 recovery codes, got %v", codes.RecoveryCodes)
+		}
+
+		rec = send(handler.GetStatus, userID, nil, 0)
+		var status models.TwoFactorStatus
+		json.Unmarshal(rec.Body.Bytes(), &status)
+		if !status.Enabled || status.Required || status.RecoveryCodesRemaining != 10 {
+			t.Errorf("Unexpected status %+v", status)
+		}
+
+		code, _ = services.TOTPCode(setup.Secret, time.Now())
+		rec = send(handler.Disable, userID, map[string]string{"code": code, "password": "wrong"}, 0)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for a wrong password, got %d", rec.Code)
+		}
+		rec = send(handler.Disable, userID, map[string]string{"code": code, "password": "Test1234"}, 0)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if enabled, _ := twoFactor.IsEnabled(userID); enabled {
+			t.Error("Expected 2FA to be disabled")
+		}
+	})
+
+	t.Run("admins cannot disable 2FA", func(t *testing.T) {
+		enable(adminID)
+		rec := send(handler.Disable, adminID, map[string]string{"recovery_code": "x", "password": "Test1234"}, 0)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403, got %d", rec.Code)
+		}
+	})
+
+	t.Run("super admin resets 2FA of another user", func(t *testing.T) {
+		rec := send(handler.ResetUser, superAdminID, nil, superAdminID)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for the own account, got %d", rec.Code)
+		}
+
+		before, _ := userRepo.FindAuthState(adminID)
+		rec = send(handler.ResetUser, superAdminID, nil, adminID)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if enabled, _ := twoFactor.IsEnabled(adminID); enabled {
+			t.Error("Expected 2FA to be reset")
+		}
+		// Logged out everywhere
+		after, _ := userRepo.FindAuthState(adminID)
+		if after.TokenVersion != before.TokenVersion+1 {
+			t.Errorf("Expected the token version to be increased, got %d -> %d", before.TokenVersion, after.TokenVersion)
+		}
+	})
+}
@@ -22,22 +22,32 @@ import (
 // UserHandler handles user-related endpoints
 type UserHandler struct {
 	userRepo     *repository.UserRepository
+	roleRepo     *repository.RoleRepository
+	tokenRepo    *repository.RefreshTokenRepository
+	passkeyRepo  *repository.PasskeyRepository
+	oidcRepo     *repository.OIDCRepository
 	authService  *services.AuthService
 	emailService *services.EmailService
+	outbox       *services.OutboxService
 	config       *config.Config
 }
 
 // NewUserHandler creates a new user handler
 func NewUserHandler(db *sql.DB, cfg *config.Config) *UserHandler {
-	emailService, err := services.NewEmailService(services.ConfigToEmailConfig(cfg))
+	emailService, err := services.NewEmailServiceFromConfig(db, cfg)
 	if err != nil {
 		println("Warning: Failed to initialize email service:", err.Error())
 	}
 
 	return &UserHandler{
 		userRepo:     repository.NewUserRepository(db),
-		authService:  services.NewAuthService(cfg.JWTSecret, cfg.JWTExpirationHours),
+		roleRepo:     repository.NewRoleRepository(db),
+		tokenRepo:    repository.NewRefreshTokenRepository(db),
+		passkeyRepo:  repository.NewPasskeyRepository(db),
+		oidcRepo:     repository.NewOIDCRepository(db),
+		authService:  services.NewAuthService(cfg.JWTSecret, cfg.GetAccessTokenMinutes()),
 		emailService: emailService,
+		outbox:       services.NewOutboxService(db),
 		config:       cfg,
 	}
 }
@@ -46,7 +56,7 @@ func NewUserHandler(db *sql.DB, cfg *config.Config) *UserHandler {
 func (h *UserHandler) GetMe(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
 	if !ok {
-		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
@@ -55,11 +65,11 @@ func (h *UserHandler) GetMe(w http.ResponseWriter, r *http.Request) {
 
 	user, err := h.userRepo.FindByID(userID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Database error")
+		respondError(w, r, http.StatusInternalServerError, "database_error")
 		return
 	}
 	if user == nil {
-		respondError(w, http.StatusNotFound, "User not found")
+		respondError(w, r, http.StatusNotFound, "user_not_found")
 		return
 	}
 
@@ -68,6 +78,12 @@ func (h *UserHandler) GetMe(w http.ResponseWriter, r *http.Request) {
 	user.VerificationToken = nil
 	user.PasswordResetToken = nil
 
+	// Roles and permissions decide which admin pages the user can open
+	if err := loadUserRoles(h.roleRepo, user); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "database_error")
+		return
+	}
+
 	// Create response with user data + is_admin flag
 	// Keep user fields at top level for backward compatibility
 	type UserResponse struct {
@@ -87,29 +103,29 @@ func (h *UserHandler) GetMe(w http.ResponseWriter, r *http.Request) {
 func (h *UserHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
 	if !ok {
-		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	var req models.UpdateProfileRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
 		return
 	}
 
 	// Validate input (includes phone number validation)
 	if err := req.Validate(); err != nil {
-		respondError(w, http.StatusBadRequest, err.Error())
+		respondValidationError(w, r, err)
 		return
 	}
 
 	user, err := h.userRepo.FindByID(userID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Database error")
+		respondError(w, r, http.StatusInternalServerError, "database_error")
 		return
 	}
 	if user == nil {
-		respondError(w, http.StatusNotFound, "User not found")
+		respondError(w, r, http.StatusNotFound, "user_not_found")
 		return
 	}
 
@@ -125,6 +141,10 @@ func (h *UserHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
 		user.Phone = req.Phone
 	}
 
+	if req.PreferredLanguage != nil {
+		user.PreferredLanguage = *req.PreferredLanguage
+	}
+
 	// Handle email change - requires re-verification
 	if req.Email != nil && strings.TrimSpace(*req.Email) != "" {
 		newEmail := strings.TrimSpace(*req.Email)
@@ -134,18 +154,18 @@ func (h *UserHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
 			// Check if new email already exists
 			existingUser, err := h.userRepo.FindByEmail(newEmail)
 			if err != nil {
-				respondError(w, http.StatusInternalServerError, "Database error")
+				respondError(w, r, http.StatusInternalServerError, "database_error")
 				return
 			}
 			if existingUser != nil {
-				respondError(w, http.StatusConflict, "Email already in use")
+				respondError(w, r, http.StatusConflict, "email_in_use")
 				return
 			}
 
 			// Generate new verification token
 			token, err := h.authService.GenerateToken()
 			if err != nil {
-				respondError(w, http.StatusInternalServerError, "Failed to generate token")
+				respondError(w, r, http.StatusInternalServerError, "failed_to_generate_token")
 				return
 			}
 
@@ -161,7 +181,7 @@ func (h *UserHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.userRepo.Update(user); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to update profile")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_update_profile")
 		return
 	}
 
@@ -190,19 +210,19 @@ func (h *UserHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
 func (h *UserHandler) UploadPhoto(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
 	if !ok {
-		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	// Parse multipart form
 	if err := r.ParseMultipartForm(int64(h.config.MaxUploadSizeMB) << 20); err != nil {
-		respondError(w, http.StatusBadRequest, "File too large or invalid form")
+		respondError(w, r, http.StatusBadRequest, "file_too_large")
 		return
 	}
 
 	file, header, err := r.FormFile("photo")
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "No file uploaded")
+		respondError(w, r, http.StatusBadRequest, "no_file_uploaded")
 		return
 	}
 	defer file.Close()
@@ -210,14 +230,14 @@ func (h *UserHandler) UploadPhoto(w http.ResponseWriter, r *http.Request) {
 	// Validate file type
 	ext := strings.ToLower(filepath.Ext(header.Filename))
 	if ext != ".jpg" && ext != ".jpeg" && ext != ".png" {
-		respondError(w, http.StatusBadRequest, "Only JPEG and PNG files are allowed")
+		respondError(w, r, http.StatusBadRequest, "invalid_image_type")
 		return
 	}
 
 	// Create upload directory if it doesn't exist
 	userDir := filepath.Join(h.config.UploadDir, "users")
 	if err := os.MkdirAll(userDir, 0755); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to create upload directory")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_create_upload_directory")
 		return
 	}
 
@@ -228,24 +248,24 @@ func (h *UserHandler) UploadPhoto(w http.ResponseWriter, r *http.Request) {
 	// Save file
 	dest, err := os.Create(destPath)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to save file")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_save_file")
 		return
 	}
 	defer dest.Close()
 
 	if _, err := io.Copy(dest, file); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to save file")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_save_file")
 		return
 	}
 
 	// Update user profile
 	user, err := h.userRepo.FindByID(userID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Database error")
+		respondError(w, r, http.StatusInternalServerError, "database_error")
 		return
 	}
 	if user == nil {
-		respondError(w, http.StatusNotFound, "User not found")
+		respondError(w, r, http.StatusNotFound, "user_not_found")
 		return
 	}
 
@@ -257,7 +277,7 @@ func (h *UserHandler) UploadPhoto(w http.ResponseWriter, r *http.Request) {
 
 	user.ProfilePhoto = &filename
 	if err := h.userRepo.Update(user); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to update profile")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_update_profile")
 		return
 	}
 
@@ -271,7 +291,7 @@ func (h *UserHandler) UploadPhoto(w http.ResponseWriter, r *http.Request) {
 func (h *UserHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int)
 	if !ok {
-		respondError(w, http.StatusUnauthorized, "Unauthorized")
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
@@ -280,29 +300,29 @@ func (h *UserHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
 		Password string `json:"password"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
 		return
 	}
 
 	if req.Password == "" {
-		respondError(w, http.StatusBadRequest, "Password is required to confirm deletion")
+		respondError(w, r, http.StatusBadRequest, "password_required_for_deletion")
 		return
 	}
 
 	// Get user
 	user, err := h.userRepo.FindByID(userID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Database error")
+		respondError(w, r, http.StatusInternalServerError, "database_error")
 		return
 	}
 	if user == nil {
-		respondError(w, http.StatusNotFound, "User not found")
+		respondError(w, r, http.StatusNotFound, "user_not_found")
 		return
 	}
 
 	// Verify password
 	if user.PasswordHash == nil || !h.authService.CheckPassword(req.Password, *user.PasswordHash) {
-		respondError(w, http.StatusUnauthorized, "Invalid password")
+		respondError(w, r, http.StatusUnauthorized, "invalid_password")
 		return
 	}
 
@@ -313,8 +333,26 @@ func (h *UserHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Delete account (GDPR anonymization)
-	if err := h.userRepo.DeleteAccount(userID); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to delete account")
+	err = h.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		if err := h.userRepo.WithTx(tx).DeleteAccount(userID); err != nil {
+			return nil, err
+		}
+		if err := h.tokenRepo.WithTx(tx).RevokeAllForUser(userID); err != nil {
+			return nil, err
+		}
+		// Anonymized accounts keep their row, passkeys and linked identities would still log in
+		if err := h.passkeyRepo.WithTx(tx).DeleteAllForUser(userID); err != nil {
+			return nil, err
+		}
+		if err := h.oidcRepo.WithTx(tx).DeleteIdentitiesForUser(userID); err != nil {
+			return nil, err
+		}
+		event, err := models.NewDomainEvent(models.EventUserDeleted, models.AggregateUser, userID, &userID,
+			&models.UserEventData{UserID: userID})
+		return []*models.DomainEvent{event}, err
+	})
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_delete_account")
 		return
 	}
 
@@ -337,7 +375,13 @@ func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
 
 	users, err := h.userRepo.FindAll(activeOnly)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get users")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_users")
+		return
+	}
+
+	tags, err := h.userRepo.GetTagsByUser()
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_user_tags")
 		return
 	}
 
@@ -346,6 +390,7 @@ func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
 		user.PasswordHash = nil
 		user.VerificationToken = nil
 		user.PasswordResetToken = nil
+		user.Tags = tags[user.ID]
 	}
 
 	respondJSON(w, http.StatusOK, users)
@@ -357,17 +402,23 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	userID, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_user_id")
 		return
 	}
 
 	user, err := h.userRepo.FindByID(userID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Database error")
+		respondError(w, r, http.StatusInternalServerError, "database_error")
 		return
 	}
 	if user == nil {
-		respondError(w, http.StatusNotFound, "User not found")
+		respondError(w, r, http.StatusNotFound, "user_not_found")
+		return
+	}
+
+	user.Tags, err = h.userRepo.GetTags(userID)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_user_tags")
 		return
 	}
 
@@ -379,13 +430,69 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, user)
 }
 
+// ListTags lists all tags assigned to any user, for segment selection (admin only)
+func (h *UserHandler) ListTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.userRepo.GetAllTags()
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_user_tags")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, tags)
+}
+
+// UpdateUserTags replaces the tags of a user (admin only)
+func (h *UserHandler) UpdateUserTags(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_user_id")
+		return
+	}
+
+	var req models.UpdateUserTagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respondValidationError(w, r, err)
+		return
+	}
+
+	user, err := h.userRepo.FindByID(userID)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "database_error")
+		return
+	}
+	if user == nil || user.IsDeleted {
+		respondError(w, r, http.StatusNotFound, "user_not_found")
+		return
+	}
+
+	previousTags, err := h.userRepo.GetTags(userID)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "database_error")
+		return
+	}
+
+	if err := h.userRepo.SetTags(userID, req.Tags); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_update_user_tags")
+		return
+	}
+	middleware.AuditChange(r, map[string][]string{"tags": previousTags}, map[string][]string{"tags": req.Tags})
+
+	respondJSON(w, http.StatusOK, map[string][]string{"tags": req.Tags})
+}
+
 // DeactivateUser deactivates a user account (admin only)
 func (h *UserHandler) DeactivateUser(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from URL
 	vars := mux.Vars(r)
 	userID, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_user_id")
 		return
 	}
 
@@ -394,36 +501,46 @@ func (h *UserHandler) DeactivateUser(w http.ResponseWriter, r *http.Request) {
 		Reason string `json:"reason"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
 		return
 	}
 
 	if req.Reason == "" {
-		respondError(w, http.StatusBadRequest, "Reason is required")
+		respondError(w, r, http.StatusBadRequest, "reason_required")
 		return
 	}
 
 	// Get user
 	user, err := h.userRepo.FindByID(userID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Database error")
+		respondError(w, r, http.StatusInternalServerError, "database_error")
 		return
 	}
 	if user == nil {
-		respondError(w, http.StatusNotFound, "User not found")
+		respondError(w, r, http.StatusNotFound, "user_not_found")
 		return
 	}
 
-	// Deactivate
-	if err := h.userRepo.Deactivate(userID, req.Reason); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to deactivate user")
+	// Deactivate, the user is notified through the user.deactivated event
+	adminID, _ := r.Context().Value(middleware.UserIDKey).(int)
+	err = h.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		if err := h.userRepo.WithTx(tx).Deactivate(userID, req.Reason); err != nil {
+			return nil, err
+		}
+		if err := h.tokenRepo.WithTx(tx).RevokeAllForUser(userID); err != nil {
+			return nil, err
+		}
+		event, err := models.NewDomainEvent(models.EventUserDeactivated, models.AggregateUser, userID, &adminID,
+			&models.UserDeactivatedEventData{UserID: userID, Name: user.Name, Reason: req.Reason})
+		return []*models.DomainEvent{event}, err
+	})
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_deactivate_user")
 		return
 	}
-
-	// Send email notification
-	if user.Email != nil && h.emailService != nil {
-		go h.emailService.SendAccountDeactivated(*user.Email, user.Name, req.Reason)
-	}
+	middleware.AuditChange(r,
+		map[string]interface{}{"is_active": user.IsActive},
+		map[string]interface{}{"is_active": false, "reason": req.Reason})
 
 	respondJSON(w, http.StatusOK, map[string]string{"message": "User deactivated successfully"})
 }
@@ -434,7 +551,7 @@ func (h *UserHandler) ActivateUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	userID, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_user_id")
 		return
 	}
 
@@ -447,24 +564,31 @@ func (h *UserHandler) ActivateUser(w http.ResponseWriter, r *http.Request) {
 	// Get user
 	user, err := h.userRepo.FindByID(userID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Database error")
+		respondError(w, r, http.StatusInternalServerError, "database_error")
 		return
 	}
 	if user == nil {
-		respondError(w, http.StatusNotFound, "User not found")
+		respondError(w, r, http.StatusNotFound, "user_not_found")
 		return
 	}
 
-	// Activate
-	if err := h.userRepo.Activate(userID); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to activate user")
+	// Activate, the user is notified through the user.activated event
+	adminID, _ := r.Context().Value(middleware.UserIDKey).(int)
+	err = h.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		if err := h.userRepo.WithTx(tx).Activate(userID); err != nil {
+			return nil, err
+		}
+		event, err := models.NewDomainEvent(models.EventUserActivated, models.AggregateUser, userID, &adminID,
+			&models.UserActivatedEventData{UserID: userID, Name: user.Name, Message: req.Message})
+		return []*models.DomainEvent{event}, err
+	})
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_activate_user")
 		return
 	}
-
-	// Send email notification
-	if user.Email != nil && h.emailService != nil {
-		go h.emailService.SendAccountReactivated(*user.Email, user.Name, req.Message)
-	}
+	middleware.AuditChange(r,
+		map[string]interface{}{"is_active": user.IsActive},
+		map[string]interface{}{"is_active": true, "message": req.Message})
 
 	respondJSON(w, http.StatusOK, map[string]string{"message": "User activated successfully"})
 }
@@ -475,7 +599,7 @@ func (h *UserHandler) PromoteToAdmin(w http.ResponseWriter, r *http.Request) {
 	// Extract super admin from context (middleware already verified)
 	isSuperAdmin, _ := r.Context().Value(middleware.IsSuperAdminKey).(bool)
 	if !isSuperAdmin {
-		respondError(w, http.StatusForbidden, "Only Super Admin can promote users")
+		respondError(w, r, http.StatusForbidden, "super_admin_required_to_promote")
 		return
 	}
 
@@ -484,43 +608,52 @@ func (h *UserHandler) PromoteToAdmin(w http.ResponseWriter, r *http.Request) {
 	userIDStr := vars["id"]
 	userID, err := strconv.Atoi(userIDStr)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_user_id")
 		return
 	}
 
 	// Get target user
 	targetUser, err := h.userRepo.FindByID(userID)
 	if err != nil {
-		respondError(w, http.StatusNotFound, "User not found")
+		respondError(w, r, http.StatusNotFound, "user_not_found")
 		return
 	}
 	if targetUser == nil {
-		respondError(w, http.StatusNotFound, "User not found")
+		respondError(w, r, http.StatusNotFound, "user_not_found")
 		return
 	}
 
 	// Validation checks
 	if targetUser.IsSuperAdmin {
-		respondError(w, http.StatusBadRequest, "Cannot modify Super Admin")
+		respondError(w, r, http.StatusBadRequest, "cannot_modify_super_admin")
 		return
 	}
 
 	if targetUser.IsAdmin {
-		respondError(w, http.StatusBadRequest, "User is already an admin")
+		respondError(w, r, http.StatusBadRequest, "user_already_admin")
 		return
 	}
 
 	// Promote user
-	err = h.userRepo.PromoteToAdmin(userID)
+	superAdminID, _ := r.Context().Value(middleware.UserIDKey).(int)
+	err = h.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		if err := h.userRepo.WithTx(tx).PromoteToAdmin(userID); err != nil {
+			return nil, err
+		}
+		event, err := models.NewDomainEvent(models.EventUserPromoted, models.AggregateUser, userID, &superAdminID,
+			&models.UserEventData{UserID: userID, Name: targetUser.Name})
+		return []*models.DomainEvent{event}, err
+	})
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to promote user")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_promote_user")
 		return
 	}
+	middleware.AuditChange(r, map[string]bool{"is_admin": false}, map[string]bool{"is_admin": true})
 
 	// Get updated user
 	updatedUser, err := h.userRepo.FindByID(userID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to retrieve updated user")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_updated_user")
 		return
 	}
 
@@ -536,7 +669,7 @@ func (h *UserHandler) DemoteAdmin(w http.ResponseWriter, r *http.Request) {
 	// Extract super admin from context
 	isSuperAdmin, _ := r.Context().Value(middleware.IsSuperAdminKey).(bool)
 	if !isSuperAdmin {
-		respondError(w, http.StatusForbidden, "Only Super Admin can demote admins")
+		respondError(w, r, http.StatusForbidden, "super_admin_required_to_demote")
 		return
 	}
 
@@ -545,43 +678,55 @@ func (h *UserHandler) DemoteAdmin(w http.ResponseWriter, r *http.Request) {
 	userIDStr := vars["id"]
 	userID, err := strconv.Atoi(userIDStr)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		respondError(w, r, http.StatusBadRequest, "invalid_user_id")
 		return
 	}
 
 	// Get target user
 	targetUser, err := h.userRepo.FindByID(userID)
 	if err != nil {
-		respondError(w, http.StatusNotFound, "User not found")
+		respondError(w, r, http.StatusNotFound, "user_not_found")
 		return
 	}
 	if targetUser == nil {
-		respondError(w, http.StatusNotFound, "User not found")
+		respondError(w, r, http.StatusNotFound, "user_not_found")
 		return
 	}
 
 	// Validation checks
 	if targetUser.IsSuperAdmin {
-		respondError(w, http.StatusBadRequest, "Cannot demote Super Admin")
+		respondError(w, r, http.StatusBadRequest, "cannot_demote_super_admin")
 		return
 	}
 
 	if !targetUser.IsAdmin {
-		respondError(w, http.StatusBadRequest, "User is not an admin")
+		respondError(w, r, http.StatusBadRequest, "user_not_admin")
 		return
 	}
 
 	// Demote user
-	err = h.userRepo.DemoteAdmin(userID)
+	superAdminID, _ := r.Context().Value(middleware.UserIDKey).(int)
+	err = h.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		if err := h.userRepo.WithTx(tx).DemoteAdmin(userID); err != nil {
+			return nil, err
+		}
+		if err := h.tokenRepo.WithTx(tx).RevokeAllForUser(userID); err != nil {
+			return nil, err
+		}
+		event, err := models.NewDomainEvent(models.EventUserDemoted, models.AggregateUser, userID, &superAdminID,
+			&models.UserEventData{UserID: userID, Name: targetUser.Name})
+		return []*models.DomainEvent{event}, err
+	})
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to demote admin")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_demote_admin")
 		return
 	}
+	middleware.AuditChange(r, map[string]bool{"is_admin": true}, map[string]bool{"is_admin": false})
 
 	// Get updated user
 	updatedUser, err := h.userRepo.FindByID(userID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to retrieve updated user")
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_updated_user")
 		return
 	}
 
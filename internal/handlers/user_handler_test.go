@@ -27,7 +27,7 @@ func TestUserHandler_GetMe(t *testing.T) {
 	handler := NewUserHandler(db, cfg)
 
 	// Create test user
-	authService := services.NewAuthService(cfg.JWTSecret, cfg.JWTExpirationHours)
+	authService := services.NewAuthService(cfg.JWTSecret, cfg.GetAccessTokenMinutes())
 	hash, _ := authService.HashPassword("Test1234")
 	userRepo := repository.NewUserRepository(db)
 
@@ -108,7 +108,7 @@ func TestUserHandler_UpdateMe(t *testing.T) {
 	handler := NewUserHandler(db, cfg)
 
 	// Create test user
-	authService := services.NewAuthService(cfg.JWTSecret, cfg.JWTExpirationHours)
+	authService := services.NewAuthService(cfg.JWTSecret, cfg.GetAccessTokenMinutes())
 	hash, _ := authService.HashPassword("Test1234")
 	userRepo := repository.NewUserRepository(db)
 
@@ -235,6 +235,63 @@ func TestUserHandler_UpdateMe(t *testing.T) {
 			t.Errorf("Expected status 409 for duplicate email, got %d", rec.Code)
 		}
 	})
+
+	t.Run("update preferred language", func(t *testing.T) {
+		reqBody := map[string]interface{}{
+			"preferred_language": "en",
+		}
+
+		body, _ := json.Marshal(reqBody)
+		req := httptest.NewRequest("PUT", "/api/users/me", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		ctx := contextWithUser(req.Context(), user.ID, *user.Email, false)
+		req = req.WithContext(ctx)
+
+		rec := httptest.NewRecorder()
+		handler.UpdateMe(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d. Body: %s", rec.Code, rec.Body.String())
+		}
+
+		updatedUser, _ := userRepo.FindByID(user.ID)
+		if updatedUser.PreferredLanguage != "en" {
+			t.Errorf("Expected preferred language 'en', got '%s'", updatedUser.PreferredLanguage)
+		}
+	})
+
+	t.Run("reject unsupported language with localized error", func(t *testing.T) {
+		reqBody := map[string]interface{}{
+			"preferred_language": "xx",
+		}
+
+		body, _ := json.Marshal(reqBody)
+		req := httptest.NewRequest("PUT", "/api/users/me", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Language", "en-US,en;q=0.9,de;q=0.8")
+		ctx := contextWithUser(req.Context(), user.ID, *user.Email, false)
+		req = req.WithContext(ctx)
+
+		rec := httptest.NewRecorder()
+		handler.UpdateMe(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", rec.Code)
+		}
+
+		var response models.ErrorResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		if response.Code != "unsupported_language" {
+			t.Errorf("Expected code 'unsupported_language', got '%s'", response.Code)
+		}
+		if response.Error != "Language is not supported" {
+			t.Errorf("Expected English error message, got '%s'", response.Error)
+		}
+		if response.Field != "preferred_language" {
+			t.Errorf("Expected field 'preferred_language', got '%s'", response.Field)
+		}
+	})
 }
 
 // DONE: TestUserHandler_DeleteAccount tests GDPR-compliant account deletion
@@ -247,7 +304,7 @@ func TestUserHandler_DeleteAccount(t *testing.T) {
 	handler := NewUserHandler(db, cfg)
 
 	// Create test user
-	authService := services.NewAuthService(cfg.JWTSecret, cfg.JWTExpirationHours)
+	authService := services.NewAuthService(cfg.JWTSecret, cfg.GetAccessTokenMinutes())
 	hash, _ := authService.HashPassword("Test1234")
 	userRepo := repository.NewUserRepository(db)
 
@@ -478,6 +535,11 @@ func TestUserHandler_DeactivateUser(t *testing.T) {
 
 	t.Run("admin can deactivate user with reason", func(t *testing.T) {
 		userID := testutil.SeedTestUser(t, db, "deactivate@example.com", "Deactivate Me", "green")
+		deactivated, _ := userRepo.FindByID(userID)
+		login, err := services.NewTokenService(db, cfg).Issue(deactivated, models.SessionClient{})
+		if err != nil {
+			t.Fatalf("Issue() failed: %v", err)
+		}
 
 		reqBody := map[string]string{
 			"reason": "Policy violation",
@@ -504,6 +566,12 @@ func TestUserHandler_DeactivateUser(t *testing.T) {
 		if user.DeactivationReason == nil || *user.DeactivationReason != "Policy violation" {
 			t.Errorf("Expected reason 'Policy violation', got %v", user.DeactivationReason)
 		}
+
+		// Logged out on all devices
+		stored, _ := repository.NewRefreshTokenRepository(db).FindByHash(models.HashRefreshToken(login.RefreshToken))
+		if stored == nil || stored.RevokedAt == nil {
+			t.Error("Expected the refresh tokens of the user to be revoked")
+		}
 	})
 
 	t.Run("missing reason", func(t *testing.T) {
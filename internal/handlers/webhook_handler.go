@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/services"
+)
+
+// recentWebhookDeliveries is the number of deliveries shown in the delivery log
+const recentWebhookDeliveries = 50
+
+// WebhookHandler handles admin-managed webhook subscriptions
+// Events are delivered by the cron job, see WebhookService
+type WebhookHandler struct {
+	webhookRepo *repository.WebhookRepository
+	config      *config.Config
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(db *sql.DB, cfg *config.Config) *WebhookHandler {
+	return &WebhookHandler{
+		webhookRepo: repository.NewWebhookRepository(db),
+		config:      cfg,
+	}
+}
+
+// ListWebhooks lists all webhooks without their secrets (admin only)
+func (h *WebhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := h.webhookRepo.FindAll()
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_webhooks")
+		return
+	}
+
+	for _, webhook := range webhooks {
+		webhook.Secret = ""
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"webhooks": webhooks,
+		"events":   models.WebhookEvents,
+	})
+}
+
+// CreateWebhook creates a webhook with a new signing secret (admin only)
+// The secret is only returned in this response and when rotated
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req models.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respondValidationError(w, r, err)
+		return
+	}
+
+	secret, err := services.GenerateWebhookSecret()
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_create_webhook")
+		return
+	}
+
+	webhook := &models.Webhook{
+		Name:     req.Name,
+		URL:      req.URL,
+		Secret:   secret,
+		Events:   req.Events,
+		IsActive: req.IsActive == nil || *req.IsActive,
+	}
+	if err := h.webhookRepo.Create(webhook); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_create_webhook")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, webhook)
+}
+
+// UpdateWebhook updates name, URL, events and active flag of a webhook (admin only)
+func (h *WebhookHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.findWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_request_body")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respondValidationError(w, r, err)
+		return
+	}
+
+	webhook.Name = req.Name
+	webhook.URL = req.URL
+	webhook.Events = req.Events
+	if req.IsActive != nil {
+		webhook.IsActive = *req.IsActive
+	}
+	if err := h.webhookRepo.Update(webhook); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_update_webhook")
+		return
+	}
+
+	webhook.Secret = ""
+	respondJSON(w, http.StatusOK, webhook)
+}
+
+// DeleteWebhook deletes a webhook and its delivery log (admin only)
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.findWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.webhookRepo.Delete(webhook.ID); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_delete_webhook")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Webhook deleted"})
+}
+
+// RotateWebhookSecret replaces the signing secret and returns the new one (admin only)
+func (h *WebhookHandler) RotateWebhookSecret(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.findWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	secret, err := services.GenerateWebhookSecret()
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_update_webhook")
+		return
+	}
+	if err := h.webhookRepo.UpdateSecret(webhook.ID, secret); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_update_webhook")
+		return
+	}
+
+	webhook.Secret = secret
+	respondJSON(w, http.StatusOK, webhook)
+}
+
+// ListWebhookDeliveries returns the recent deliveries of a webhook, newest first (admin only)
+func (h *WebhookHandler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.findWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	deliveries, err := h.webhookRepo.FindDeliveries(webhook.ID, recentWebhookDeliveries)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_webhook_deliveries")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, deliveries)
+}
+
+// findWebhook loads the webhook of the URL and responds with an error if it does not exist
+func (h *WebhookHandler) findWebhook(w http.ResponseWriter, r *http.Request) (*models.Webhook, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid_webhook_id")
+		return nil, false
+	}
+
+	webhook, err := h.webhookRepo.FindByID(id)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "failed_to_get_webhooks")
+		return nil, false
+	}
+	if webhook == nil {
+		respondError(w, r, http.StatusNotFound, "webhook_not_found")
+		return nil, false
+	}
+	return webhook, true
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/services"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestWebhookHandler tests managing webhooks
+func TestWebhookHandler(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	cfg := &config.Config{JWTSecret: "test-secret", UploadDir: t.TempDir()}
+	handler := NewWebhookHandler(db, cfg)
+	adminID := testutil.SeedTestUser(t, db, "admin@example.com", "Admin", "orange")
+
+	newRequest := func(method, path string, body interface{}, id int) *http.Request {
+		var buf bytes.Buffer
+		if body != nil {
+			json.NewEncoder(&buf).Encode(body)
+		}
+		req := httptest.NewRequest(method, path, &buf)
+		req = req.WithContext(contextWithUser(req.Context(), adminID, "admin@example.com", true))
+		if id != 0 {
+			req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(id)})
+		}
+		return req
+	}
+
+	t.Run("validation", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.CreateWebhook(rec, newRequest("POST", "/api/admin/webhooks", map[string]interface{}{
+			"name": "Bot", "url": "not a url", "events": []string{"booking.created"},
+		}, 0))
+
+		var resp map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		if rec.Code != http.StatusBadRequest || resp["code"] != "invalid_webhook_url" {
+			t.Errorf("Expected 400 invalid_webhook_url, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	var created models.Webhook
+	t.Run("create returns the secret once", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.CreateWebhook(rec, newRequest("POST", "/api/admin/webhooks", map[string]interface{}{
+			"name": "Bot", "url": "https://bot.example.com/hook", "events": []string{"booking.created", "dog.availability_changed"},
+		}, 0))
+
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+		}
+		json.Unmarshal(rec.Body.Bytes(), &created)
+		if len(created.Secret) != 64 || !created.IsActive {
+			t.Errorf("Expected active webhook with secret, got %+v", created)
+		}
+
+		rec = httptest.NewRecorder()
+		handler.ListWebhooks(rec, newRequest("GET", "/api/admin/webhooks", nil, 0))
+		var list struct {
+			Webhooks []models.Webhook `json:"webhooks"`
+			Events   []string         `json:"events"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &list)
+		if len(list.Webhooks) != 1 || list.Webhooks[0].Secret != "" || len(list.Events) != len(models.WebhookEvents) {
+			t.Errorf("Expected list without secrets, got %s", rec.Body.String())
+		}
+	})
+
+	t.Run("update and rotate secret", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.UpdateWebhook(rec, newRequest("PUT", "/api/admin/webhooks/x", map[string]interface{}{
+			"name": "Chat bot", "url": "https://bot.example.com/hook", "events": []string{"booking.cancelled"}, "is_active": false,
+		}, created.ID))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+
+		stored, _ := repository.NewWebhookRepository(db).FindByID(created.ID)
+		if stored.Name != "Chat bot" || stored.IsActive || stored.Events[0] != "booking.cancelled" || stored.Secret != created.Secret {
+			t.Errorf("Unexpected webhook after update: %+v", stored)
+		}
+
+		rec = httptest.NewRecorder()
+		handler.RotateWebhookSecret(rec, newRequest("POST", "/api/admin/webhooks/x/rotate-secret", nil, created.ID))
+		var rotated models.Webhook
+		json.Unmarshal(rec.Body.Bytes(), &rotated)
+		if rec.Code != http.StatusOK || rotated.Secret == "" || rotated.Secret == created.Secret {
+			t.Errorf("Expected new secret, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ListWebhookDeliveries(rec, newRequest("GET", "/api/admin/webhooks/999/deliveries", nil, 999))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", rec.Code)
+		}
+	})
+
+	t.Run("toggling dog availability queues an event once dispatched", func(t *testing.T) {
+		repo := repository.NewWebhookRepository(db)
+		dogsHook := &models.Webhook{Name: "Dogs", URL: "https://intranet.example.com", Secret: "s", Events: []string{models.WebhookEventDogAvailabilityChanged}, IsActive: true}
+		repo.Create(dogsHook)
+		dogID := testutil.SeedTestDog(t, db, "Bella", "Labrador", "green")
+
+		dogHandler := NewDogHandler(db, cfg)
+		toggle := func(available bool) {
+			rec := httptest.NewRecorder()
+			req := newRequest("PUT", "/api/dogs/x/availability", map[string]interface{}{"is_available": available}, dogID)
+			dogHandler.ToggleAvailability(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+			}
+		}
+		toggle(false)
+		toggle(false) // No change, no event
+
+		if _, err := services.NewEventDispatcherFromConfig(db, cfg).DispatchPending(); err != nil {
+			t.Fatalf("DispatchPending() failed: %v", err)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ListWebhookDeliveries(rec, newRequest("GET", "/api/admin/webhooks/x/deliveries", nil, dogsHook.ID))
+		var deliveries []models.WebhookDelivery
+		json.Unmarshal(rec.Body.Bytes(), &deliveries)
+		if rec.Code != http.StatusOK || len(deliveries) != 1 || deliveries[0].Event != models.WebhookEventDogAvailabilityChanged {
+			t.Fatalf("Expected one queued event, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.DeleteWebhook(rec, newRequest("DELETE", "/api/admin/webhooks/x", nil, created.ID))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rec.Code)
+		}
+		if found, _ := repository.NewWebhookRepository(db).FindByID(created.ID); found != nil {
+			t.Error("Expected webhook to be deleted")
+		}
+	})
+}
@@ -0,0 +1,165 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLanguage is used when no supported language is requested
+const DefaultLanguage = "de"
+
+// Translation catalogs (one nested JSON file per language)
+//
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// catalogs maps language -> flattened key ("errors.dog_not_found") -> message
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[string]map[string]string {
+	entries, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic(fmt.Sprintf("i18n: failed to read locales: %v", err))
+	}
+
+	result := make(map[string]map[string]string)
+	for _, entry := range entries {
+		lang := strings.TrimSuffix(entry.Name(), ".json")
+
+		content, err := localeFiles.ReadFile("locales/" + entry.Name())
+		if err != nil {
+			panic(fmt.Sprintf("i18n: failed to read %s: %v", entry.Name(), err))
+		}
+
+		var nested map[string]interface{}
+		if err := json.Unmarshal(content, &nested); err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog %s: %v", entry.Name(), err))
+		}
+
+		flat := make(map[string]string)
+		flatten("", nested, flat)
+		result[lang] = flat
+	}
+
+	return result
+}
+
+// flatten converts nested catalog objects into dotted keys
+func flatten(prefix string, nested map[string]interface{}, flat map[string]string) {
+	for k, v := range nested {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+
+		switch value := v.(type) {
+		case string:
+			flat[key] = value
+		case map[string]interface{}:
+			flatten(key, value, flat)
+		}
+	}
+}
+
+// SupportedLanguages returns all languages with a translation catalog
+func SupportedLanguages() []string {
+	langs := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// IsSupported checks if a translation catalog exists for the language
+func IsSupported(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// Normalize maps a language tag like "en-US" to a supported language
+// Falls back to DefaultLanguage for empty or unsupported values
+func Normalize(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	if IsSupported(lang) {
+		return lang
+	}
+	return DefaultLanguage
+}
+
+// ParseAcceptLanguage picks the best supported language from an Accept-Language header
+func ParseAcceptLanguage(header string) string {
+	best := ""
+	bestQ := -1.0
+
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if i := strings.IndexAny(tag, "-_"); i >= 0 {
+			tag = tag[:i]
+		}
+		if !IsSupported(tag) {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if parsed, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+
+		if q > bestQ {
+			best = tag
+			bestQ = q
+		}
+	}
+
+	if best == "" || bestQ <= 0 {
+		return DefaultLanguage
+	}
+	return best
+}
+
+// FromRequest determines the response language from the Accept-Language header
+func FromRequest(r *http.Request) string {
+	if r == nil {
+		return DefaultLanguage
+	}
+	return ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+}
+
+// Has checks if a key exists in the catalog of the given language
+func Has(lang, key string) bool {
+	_, ok := catalogs[Normalize(lang)][key]
+	return ok
+}
+
+// T translates a key into the given language
+// Missing keys fall back to the default language, then to the key itself.
+// If args are given, the message is formatted with fmt.Sprintf.
+func T(lang, key string, args ...interface{}) string {
+	message, ok := catalogs[Normalize(lang)][key]
+	if !ok {
+		message, ok = catalogs[DefaultLanguage][key]
+	}
+	if !ok {
+		return key
+	}
+
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
@@ -0,0 +1,184 @@
+package i18n
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestCatalogs_SameKeys ensures every language translates the same keys
+// with the same format verbs
+func TestCatalogs_SameKeys(t *testing.T) {
+	reference := catalogs[DefaultLanguage]
+	if len(reference) == 0 {
+		t.Fatal("Default catalog is empty")
+	}
+
+	verbs := regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z]`)
+
+	for _, lang := range SupportedLanguages() {
+		if lang == DefaultLanguage {
+			continue
+		}
+		catalog := catalogs[lang]
+
+		for key, message := range reference {
+			translated, ok := catalog[key]
+			if !ok {
+				t.Errorf("%s: missing key %s", lang, key)
+				continue
+			}
+			want := strings.Join(verbs.FindAllString(message, -1), " ")
+			got := strings.Join(verbs.FindAllString(translated, -1), " ")
+			if want != got {
+				t.Errorf("%s: key %s has format verbs %q, expected %q", lang, key, got, want)
+			}
+		}
+
+		for key := range catalog {
+			if _, ok := reference[key]; !ok {
+				t.Errorf("%s: key %s does not exist in %s", lang, key, DefaultLanguage)
+			}
+		}
+	}
+}
+
+// TestCatalogs_CoverErrorCodes ensures every error code used in the
+// handlers, middleware, models and services has a translation
+func TestCatalogs_CoverErrorCodes(t *testing.T) {
+	patterns := []*regexp.Regexp{
+		regexp.MustCompile(`respondError\(w, r, [^,]+, "([a-z0-9_]+)"`),
+		regexp.MustCompile(`respondStoreError\(w, r, err, "([a-z0-9_]+)"`),
+		regexp.MustCompile(`Code:\s*"([a-z0-9_]+)"`),
+	}
+
+	codes := make(map[string]string)
+	for _, dir := range []string{"../handlers", "../middleware", "../models", "../services"} {
+		files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+		if err != nil {
+			t.Fatalf("Failed to list %s: %v", dir, err)
+		}
+
+		for _, file := range files {
+			if strings.HasSuffix(file, "_test.go") {
+				continue
+			}
+			content, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatalf("Failed to read %s: %v", file, err)
+			}
+			for _, pattern := range patterns {
+				for _, match := range pattern.FindAllStringSubmatch(string(content), -1) {
+					codes[match[1]] = file
+				}
+			}
+		}
+	}
+
+	if len(codes) == 0 {
+		t.Fatal("No error codes found")
+	}
+
+	for code, file := range codes {
+		for _, lang := range SupportedLanguages() {
+			if !Has(lang, "errors."+code) {
+				t.Errorf("%s: error code %s (%s) is not translated", lang, code, file)
+			}
+		}
+	}
+}
+
+// TestParseAcceptLanguage tests language negotiation
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{"", "de"},
+		{"en", "en"},
+		{"en-US,en;q=0.9", "en"},
+		{"de-DE,de;q=0.9,en;q=0.8", "de"},
+		{"fr-FR,fr;q=0.9,en;q=0.5", "en"},
+		{"de;q=0.3,en;q=0.7", "en"},
+		{"fr", "de"},
+		{"en;q=0", "de"},
+		{"EN-gb", "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := ParseAcceptLanguage(tt.header); got != tt.expected {
+				t.Errorf("ParseAcceptLanguage(%q) = %s, expected %s", tt.header, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestFromRequest tests reading the language from a request
+func TestFromRequest(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	if lang := FromRequest(req); lang != DefaultLanguage {
+		t.Errorf("Expected default language, got %s", lang)
+	}
+
+	req.Header.Set("Accept-Language", "en-US")
+	if lang := FromRequest(req); lang != "en" {
+		t.Errorf("Expected en, got %s", lang)
+	}
+
+	if lang := FromRequest(nil); lang != DefaultLanguage {
+		t.Errorf("Expected default language for nil request, got %s", lang)
+	}
+}
+
+// TestNormalize tests mapping language tags to supported languages
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"de":    "de",
+		"en":    "en",
+		"en-US": "en",
+		"en_GB": "en",
+		" EN ":  "en",
+		"fr":    "de",
+		"":      "de",
+	}
+
+	for input, expected := range tests {
+		if got := Normalize(input); got != expected {
+			t.Errorf("Normalize(%q) = %s, expected %s", input, got, expected)
+		}
+	}
+}
+
+// TestT tests translation, formatting and fallbacks
+func TestT(t *testing.T) {
+	t.Run("translates", func(t *testing.T) {
+		if got := T("en", "errors.dog_not_found"); got != "Dog not found" {
+			t.Errorf("Unexpected translation: %s", got)
+		}
+		if got := T("de", "errors.dog_not_found"); got != "Hund nicht gefunden" {
+			t.Errorf("Unexpected translation: %s", got)
+		}
+	})
+
+	t.Run("formats arguments", func(t *testing.T) {
+		if got := T("en", "errors.booking_too_far_in_advance", 14); !strings.Contains(got, "14") {
+			t.Errorf("Expected formatted argument, got: %s", got)
+		}
+	})
+
+	t.Run("unsupported language falls back to default", func(t *testing.T) {
+		if got := T("fr", "errors.dog_not_found"); got != T(DefaultLanguage, "errors.dog_not_found") {
+			t.Errorf("Expected default language, got: %s", got)
+		}
+	})
+
+	t.Run("unknown key returns key", func(t *testing.T) {
+		if got := T("en", "errors.does_not_exist"); got != "errors.does_not_exist" {
+			t.Errorf("Expected key, got: %s", got)
+		}
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"net"
 	"net/http"
 	"strings"
 	"time"
@@ -142,29 +143,97 @@ func GenerateRequestID() string {
 	return hex.EncodeToString(b)
 }
 
+// trustedProxies are the networks whose forwarding headers are believed, loopback unless configured
+var trustedProxies = mustParseNetworks("127.0.0.0/8,::1/128")
+
+// SetTrustedProxies sets the reverse proxies allowed to report the client IP, comma separated IPs or CIDRs
+// Headers of other clients are ignored, anyone can send X-Forwarded-For.
+func SetTrustedProxies(list string) error {
+	networks, err := parseNetworks(list)
+	if err != nil {
+		return err
+	}
+	trustedProxies = networks
+	return nil
+}
+
 // GetClientIP extracts the real client IP from request
+// X-Forwarded-For and X-Real-IP only count if the request comes from a trusted proxy. The forwarded
+// addresses are read from the right, the first one that is not a trusted proxy is the client.
 func GetClientIP(r *http.Request) string {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	if !isTrustedProxy(ip) {
+		return ip
+	}
+
 	// Check X-Forwarded-For header (from reverse proxies)
-	xff := r.Header.Get("X-Forwarded-For")
-	if xff != "" {
-		// Take the first IP (original client)
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
 		parts := strings.Split(xff, ",")
-		return strings.TrimSpace(parts[0])
+		for i := len(parts) - 1; i >= 0; i-- {
+			forwarded := strings.TrimSpace(parts[i])
+			if net.ParseIP(forwarded) == nil {
+				break
+			}
+			ip = forwarded
+			if !isTrustedProxy(forwarded) {
+				break
+			}
+		}
+		return ip
 	}
 
 	// Check X-Real-IP header
-	xri := r.Header.Get("X-Real-IP")
-	if xri != "" {
+	if xri := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(xri) != nil {
 		return xri
 	}
+	return ip
+}
 
-	// Fall back to RemoteAddr
-	ip := r.RemoteAddr
-	// Remove port if present
-	if idx := strings.LastIndex(ip, ":"); idx != -1 {
-		ip = ip[:idx]
+func isTrustedProxy(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
 	}
-	return ip
+	for _, network := range trustedProxies {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseNetworks(list string) ([]*net.IPNet, error) {
+	networks := []*net.IPNet{}
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+func mustParseNetworks(list string) []*net.IPNet {
+	networks, err := parseNetworks(list)
+	if err != nil {
+		panic(err)
+	}
+	return networks
 }
 
 // formatDuration formats duration in human-readable form
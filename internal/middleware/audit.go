@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/tranmh/gassigeher/internal/logging"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/services"
+)
+
+const auditEntryKey contextKey = "auditEntry"
+
+// Request bodies up to this size are stored as the after state when the handler does not set one
+const maxAuditBodyBytes = 64 * 1024
+
+// Values of keys containing one of these words are never written to the audit log
+var auditRedactedKeys = []string{"password", "secret", "token", "code"}
+
+// AuditMiddleware appends an entry to the audit log for every changing request of an admin or staff member
+// It must run after AuthMiddleware. The entry is recorded after the handler ran, with its status code,
+// so rejected attempts are logged as well. Handlers describe the change with AuditTarget and AuditChange,
+// otherwise the target is taken from the route and the (redacted) JSON body is stored as the after state.
+func AuditMiddleware(audit *services.AuditService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			isStaff := len(Permissions(r.Context())) > 0
+			if !isStaff || r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			entry := newAuditEntry(r)
+			body := readAuditBody(r)
+
+			wrapped := logging.NewResponseWriter(w)
+			next.ServeHTTP(wrapped, r.WithContext(context.WithValue(r.Context(), auditEntryKey, entry)))
+
+			entry.StatusCode = wrapped.StatusCode()
+			if entry.After == nil && entry.Before == nil {
+				entry.After = body
+			}
+			if err := audit.Record(entry); err != nil {
+				log.Printf("Failed to record audit entry for %s (request %s): %v", entry.Action, entry.RequestID, err)
+			}
+		})
+	}
+}
+
+// AuditTarget sets the target of the audited action, e.g. ("user", 42)
+// It does nothing for requests that are not audited.
+func AuditTarget(r *http.Request, targetType string, targetID interface{}) {
+	entry, ok := r.Context().Value(auditEntryKey).(*models.AuditEntry)
+	if !ok {
+		return
+	}
+	entry.TargetType = targetType
+	entry.TargetID = fmt.Sprint(targetID)
+}
+
+// AuditChange records the state of the target before and after the action, nil for created or deleted targets
+// It does nothing for requests that are not audited.
+func AuditChange(r *http.Request, before, after interface{}) {
+	entry, ok := r.Context().Value(auditEntryKey).(*models.AuditEntry)
+	if !ok {
+		return
+	}
+	entry.Before = auditJSON(before)
+	entry.After = auditJSON(after)
+}
+
+func newAuditEntry(r *http.Request) *models.AuditEntry {
+	actorID, _ := r.Context().Value(UserIDKey).(int)
+	email, _ := r.Context().Value(EmailKey).(string)
+	requestID, _ := r.Context().Value(RequestIDKey).(string)
+
+	path := r.URL.Path
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			path = template
+		}
+	}
+
+	// Default target: the resource of the route ("/api/admin/webhooks/{id}" -> webhook) and its id or key
+	targetType := ""
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" && segment != "api" && segment != "admin" {
+			targetType = strings.TrimSuffix(segment, "s")
+			break
+		}
+	}
+	vars := mux.Vars(r)
+	targetID := vars["id"]
+	if targetID == "" {
+		targetID = vars["key"]
+	}
+
+	return &models.AuditEntry{
+		ActorID:    actorID,
+		ActorEmail: email,
+		Action:     r.Method + " " + path,
+		TargetType: targetType,
+		TargetID:   targetID,
+		IPAddress:  logging.GetClientIP(r),
+		RequestID:  requestID,
+	}
+}
+
+// readAuditBody returns the redacted JSON body and leaves r.Body readable for the handler
+func readAuditBody(r *http.Request) json.RawMessage {
+	if r.Body == nil || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		return nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxAuditBodyBytes+1))
+	r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(data), r.Body))
+	if err != nil || len(data) > maxAuditBodyBytes {
+		return nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil
+	}
+	return auditJSON(value)
+}
+
+// auditJSON encodes value with secrets redacted, nil stays nil
+func auditJSON(value interface{}) json.RawMessage {
+	if value == nil {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil
+	}
+	data, _ = json.Marshal(redactAudit(decoded))
+	return data
+}
+
+func redactAudit(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range v {
+			lower := strings.ToLower(key)
+			redacted := false
+			for _, word := range auditRedactedKeys {
+				if strings.Contains(lower, word) {
+					redacted = true
+					break
+				}
+			}
+			if redacted {
+				v[key] = "[REDACTED]"
+			} else {
+				v[key] = redactAudit(nested)
+			}
+		}
+	case []interface{}:
+		for i, nested := range v {
+			v[i] = redactAudit(nested)
+		}
+	}
+	return value
+}
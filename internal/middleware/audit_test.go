@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/services"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestAuditMiddleware tests which requests are audited and what is recorded
+func TestAuditMiddleware(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := repository.NewAuditLogRepository(db)
+
+	// Stands in for AuthMiddleware and LoggingMiddleware
+	authenticate := func(isAdmin bool) mux.MiddlewareFunc {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctx := context.WithValue(r.Context(), UserIDKey, 7)
+				ctx = context.WithValue(ctx, EmailKey, "admin@example.com")
+				ctx = context.WithValue(ctx, IsAdminKey, isAdmin)
+				ctx = context.WithValue(ctx, RequestIDKey, "req-123")
+				next.ServeHTTP(w, r.WithContext(ctx))
+			})
+		}
+	}
+
+	newRouter := func(isAdmin bool) *mux.Router {
+		router := mux.NewRouter()
+		api := router.PathPrefix("/api").Subrouter()
+		api.Use(authenticate(isAdmin))
+		api.Use(AuditMiddleware(services.NewAuditService(db)))
+		api.HandleFunc("/settings/{key}", func(w http.ResponseWriter, r *http.Request) {
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			if body["value"] == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			AuditChange(r, map[string]string{"value": "14"}, map[string]string{"value": body["value"]})
+		}).Methods("PUT", "GET")
+		api.HandleFunc("/admin/webhooks/{id}", func(w http.ResponseWriter, r *http.Request) {
+			var body map[string]string
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body["url"] == "" {
+				t.Errorf("Expected the handler to read the body, got %v (%v)", body, err)
+			}
+		}).Methods("PUT")
+		return router
+	}
+
+	send := func(router *mux.Router, method, path, body string) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = "192.0.2.1:1234"
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	find := func() []*models.AuditEntry {
+		entries, _, err := repo.Find(&models.AuditFilter{Limit: 10})
+		if err != nil {
+			t.Fatalf("Find() failed: %v", err)
+		}
+		return entries
+	}
+
+	admin := newRouter(true)
+	send(admin, "GET", "/api/settings/booking_advance_days", "")
+	send(newRouter(false), "PUT", "/api/settings/booking_advance_days", `{"value":"21"}`)
+	if entries := find(); len(entries) != 0 {
+		t.Fatalf("Expected reads and requests of users not to be audited, got %d entries", len(entries))
+	}
+
+	send(admin, "PUT", "/api/settings/booking_advance_days", `{"value":"21"}`)
+	entries := find()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.ActorID != 7 || entry.ActorEmail != "admin@example.com" || entry.Action != "PUT /api/settings/{key}" ||
+		entry.TargetType != "setting" || entry.TargetID != "booking_advance_days" || entry.StatusCode != http.StatusOK ||
+		entry.IPAddress != "192.0.2.1" || entry.RequestID != "req-123" {
+		t.Errorf("Unexpected entry %+v", entry)
+	}
+	if string(entry.Before) != `{"value":"14"}` || string(entry.After) != `{"value":"21"}` {
+		t.Errorf("Expected before and after set by the handler, got %s -> %s", entry.Before, entry.After)
+	}
+
+	// Rejected requests are recorded with the request body
+	send(admin, "PUT", "/api/settings/booking_advance_days", `{"value":""}`)
+	if entry := find()[0]; entry.StatusCode != http.StatusBadRequest || string(entry.After) != `{"value":""}` || entry.Before != nil {
+		t.Errorf("Expected rejected request with its body, got %+v", entry)
+	}
+
+	// Secrets in the body are redacted, the handler still sees them
+	send(admin, "PUT", "/api/admin/webhooks/3", `{"url":"https://example.com/hook","secret":"s3cr3t"}`)
+	entry = find()[0]
+	if entry.TargetType != "webhook" || entry.TargetID != "3" {
+		t.Errorf("Expected target from the route, got %s %s", entry.TargetType, entry.TargetID)
+	}
+	if strings.Contains(string(entry.After), "s3cr3t") || !strings.Contains(string(entry.After), "[REDACTED]") {
+		t.Errorf("Expected the secret to be redacted, got %s", entry.After)
+	}
+
+	if result, err := services.NewAuditService(db).Verify(); err != nil || !result.Valid || result.EntriesChecked != 3 {
+		t.Errorf("Expected a valid chain of 3 entries, got %+v (%v)", result, err)
+	}
+}
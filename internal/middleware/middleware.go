@@ -2,12 +2,15 @@ package middleware
 
 import (
 	"context"
+	"encoding/json"
 	"log"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/tranmh/gassigeher/internal/i18n"
 	"github.com/tranmh/gassigeher/internal/logging"
+	"github.com/tranmh/gassigeher/internal/models"
 	"github.com/tranmh/gassigeher/internal/services"
 )
 
@@ -18,6 +21,8 @@ const EmailKey contextKey = "email"
 const IsAdminKey contextKey = "isAdmin"
 const IsSuperAdminKey contextKey = "isSuperAdmin" // DONE: Phase 3
 const RequestIDKey contextKey = "requestID"
+const PermissionsKey contextKey = "permissions"
+const SessionIDKey contextKey = "sessionID"
 
 // LoggingMiddleware logs HTTP requests with comprehensive information
 // Includes: timestamp, request ID, client IP, method, path, status code,
@@ -122,20 +127,28 @@ func CORSMiddleware(baseURL string) func(http.Handler) http.Handler {
 
 // DONE: BUG #1 FIXED - CORS now restricted to specific allowed origins
 
+// AuthStateFinder loads the current token version and status of a user, see repository.UserRepository
+type AuthStateFinder interface {
+	FindAuthState(userID int) (*models.AuthState, error)
+}
+
 // AuthMiddleware validates JWT tokens
-func AuthMiddleware(jwtSecret string) func(http.Handler) http.Handler {
+// Tokens of deactivated or deleted users and tokens issued before the user's token version was increased
+// (role changes, logout everywhere) are rejected, so RequireAdmin, RequireSuperAdmin and RequirePermission
+// always see the current roles. Clients get a new token with the refresh token.
+func AuthMiddleware(jwtSecret string, users AuthStateFinder) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
-				http.Error(w, `{"error":"Missing authorization header"}`, http.StatusUnauthorized)
+				respondError(w, r, http.StatusUnauthorized, "missing_authorization_header")
 				return
 			}
 
 			// Extract token from "Bearer <token>"
 			parts := strings.Split(authHeader, " ")
 			if len(parts) != 2 || parts[0] != "Bearer" {
-				http.Error(w, `{"error":"Invalid authorization header format"}`, http.StatusUnauthorized)
+				respondError(w, r, http.StatusUnauthorized, "invalid_authorization_header")
 				return
 			}
 
@@ -145,20 +158,20 @@ func AuthMiddleware(jwtSecret string) func(http.Handler) http.Handler {
 			authService := services.NewAuthService(jwtSecret, 24) // expiration not used here
 			claims, err := authService.ValidateJWT(tokenString)
 			if err != nil {
-			http.Error(w, `{"error":"Unauthorized"}`, http.StatusUnauthorized) // BUG FIX #3
+			respondError(w, r, http.StatusUnauthorized, "unauthorized") // BUG FIX #3
 				return
 			}
 
 			// Extract claims
 			userID, ok := (*claims)["user_id"].(float64)
 			if !ok {
-				http.Error(w, `{"error":"Invalid token claims"}`, http.StatusUnauthorized)
+				respondError(w, r, http.StatusUnauthorized, "invalid_token_claims")
 				return
 			}
 
 			email, ok := (*claims)["email"].(string)
 			if !ok {
-				http.Error(w, `{"error":"Invalid token claims"}`, http.StatusUnauthorized)
+				respondError(w, r, http.StatusUnauthorized, "invalid_token_claims")
 				return
 			}
 
@@ -173,11 +186,41 @@ func AuthMiddleware(jwtSecret string) func(http.Handler) http.Handler {
 				isSuperAdmin = false
 			}
 
+			// Permissions of the user's roles, tokens issued before roles existed get those of the admin flags
+			var permissions []string
+			if claimed, ok := (*claims)["permissions"].([]interface{}); ok {
+				permissions = []string{}
+				for _, permission := range claimed {
+					if name, ok := permission.(string); ok {
+						permissions = append(permissions, name)
+					}
+				}
+			}
+
+			// Tokens issued before token versions existed have version 0
+			tokenVersion, _ := (*claims)["token_version"].(float64)
+			state, err := users.FindAuthState(int(userID))
+			if err != nil {
+				respondError(w, r, http.StatusInternalServerError, "database_error")
+				return
+			}
+			if state == nil || !state.IsActive || state.IsDeleted || state.TokenVersion != int(tokenVersion) {
+				respondError(w, r, http.StatusUnauthorized, "token_revoked")
+				return
+			}
+
 			// Add to context
 			ctx := context.WithValue(r.Context(), UserIDKey, int(userID))
 			ctx = context.WithValue(ctx, EmailKey, email)
 			ctx = context.WithValue(ctx, IsAdminKey, isAdmin)
 			ctx = context.WithValue(ctx, IsSuperAdminKey, isSuperAdmin) // DONE: Phase 3
+			if permissions != nil {
+				ctx = context.WithValue(ctx, PermissionsKey, permissions)
+			}
+			// Login session of the token, missing in tokens issued before sessions existed
+			if sessionID, ok := (*claims)["session_id"].(float64); ok {
+				ctx = context.WithValue(ctx, SessionIDKey, int(sessionID))
+			}
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
@@ -189,7 +232,7 @@ func RequireAdmin(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		isAdmin, ok := r.Context().Value(IsAdminKey).(bool)
 		if !ok || !isAdmin {
-			http.Error(w, `{"error":"Admin access required"}`, http.StatusForbidden)
+			respondError(w, r, http.StatusForbidden, "admin_access_required")
 			return
 		}
 		next.ServeHTTP(w, r)
@@ -202,7 +245,7 @@ func RequireSuperAdmin(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		isSuperAdmin, ok := r.Context().Value(IsSuperAdminKey).(bool)
 		if !ok || !isSuperAdmin {
-			http.Error(w, `{"error":"Super Admin access required"}`, http.StatusForbidden)
+			respondError(w, r, http.StatusForbidden, "super_admin_access_required")
 			return
 		}
 		next.ServeHTTP(w, r)
@@ -211,6 +254,45 @@ func RequireSuperAdmin(next http.Handler) http.Handler {
 
 // DONE: Phase 3 - Middleware updates complete
 
+// RequirePermission middleware checks if the user holds the permission through one of their roles
+func RequirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !HasPermission(r, permission) {
+				respondError(w, r, http.StatusForbidden, "permission_denied")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// HasPermission reports whether the authenticated user holds the permission
+func HasPermission(r *http.Request, permission string) bool {
+	for _, granted := range Permissions(r.Context()) {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Permissions returns the permissions of the authenticated user
+// Without a permissions claim they are those of the built-in role of the admin flags.
+func Permissions(ctx context.Context) []string {
+	if permissions, ok := ctx.Value(PermissionsKey).([]string); ok {
+		return permissions
+	}
+
+	isAdmin, _ := ctx.Value(IsAdminKey).(bool)
+	isSuperAdmin, _ := ctx.Value(IsSuperAdminKey).(bool)
+	permissions := []string{}
+	for _, role := range models.FlagRoles(isAdmin, isSuperAdmin) {
+		permissions = append(permissions, models.BuiltinRolePermissions[role]...)
+	}
+	return permissions
+}
+
 // SecurityHeadersMiddleware adds security headers
 func SecurityHeadersMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -232,3 +314,13 @@ func SecurityHeadersMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// respondError writes a JSON error with a stable code in the request language
+func respondError(w http.ResponseWriter, r *http.Request, status int, code string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(models.ErrorResponse{
+		Error: i18n.T(i18n.FromRequest(r), "errors."+code),
+		Code:  code,
+	})
+}
@@ -7,6 +7,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/tranmh/gassigeher/internal/models"
 	"github.com/tranmh/gassigeher/internal/services"
 )
 
@@ -14,7 +16,7 @@ import (
 func TestAuthMiddleware(t *testing.T) {
 	jwtSecret := "test-secret"
 	authService := services.NewAuthService(jwtSecret, 24)
-	middleware := AuthMiddleware(jwtSecret)
+	middleware := AuthMiddleware(jwtSecret, authStates{})
 
 	// Create a test handler that checks context values
 	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -37,7 +39,7 @@ func TestAuthMiddleware(t *testing.T) {
 
 	t.Run("valid token", func(t *testing.T) {
 		// Generate valid token
-		token, _ := authService.GenerateJWT(1, "test@example.com", false, false)
+		token, _ := authService.GenerateJWT(1, "test@example.com", false, false, nil, 0)
 
 		req := httptest.NewRequest("GET", "/api/test", nil)
 		req.Header.Set("Authorization", "Bearer "+token)
@@ -89,7 +91,7 @@ func TestAuthMiddleware(t *testing.T) {
 		// Create service with 0 expiration
 		expiredService := &services.AuthService{}
 		expiredService = services.NewAuthService(jwtSecret, 0)
-		token, _ := expiredService.GenerateJWT(1, "test@example.com", false, false)
+		token, _ := expiredService.GenerateJWT(1, "test@example.com", false, false, nil, 0)
 
 		// Wait for expiration
 		time.Sleep(1 * time.Second)
@@ -106,7 +108,7 @@ func TestAuthMiddleware(t *testing.T) {
 	})
 
 	t.Run("admin user context", func(t *testing.T) {
-		token, _ := authService.GenerateJWT(1, "admin@example.com", true, false)
+		token, _ := authService.GenerateJWT(1, "admin@example.com", true, false, nil, 0)
 
 		req := httptest.NewRequest("GET", "/api/test", nil)
 		req.Header.Set("Authorization", "Bearer "+token)
@@ -176,6 +178,106 @@ func TestRequireAdmin(t *testing.T) {
 	})
 }
 
+// authStates is an AuthStateFinder for tests, users without an entry are active with token version 0
+type authStates map[int]*models.AuthState
+
+func (s authStates) FindAuthState(userID int) (*models.AuthState, error) {
+	if state, ok := s[userID]; ok {
+		return state, nil
+	}
+	return &models.AuthState{IsActive: true}, nil
+}
+
+// TestAuthMiddleware_TokenVersion tests that role changes and deactivation take effect with the next request
+func TestAuthMiddleware_TokenVersion(t *testing.T) {
+	jwtSecret := "test-secret"
+	authService := services.NewAuthService(jwtSecret, 24)
+	states := authStates{
+		1: {TokenVersion: 3, IsActive: true},
+		2: {TokenVersion: 0, IsActive: false},
+		3: {TokenVersion: 0, IsActive: true, IsDeleted: true},
+		4: nil,
+	}
+	handler := AuthMiddleware(jwtSecret, states)(RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	tests := []struct {
+		name         string
+		userID       int
+		tokenVersion int
+		expected     int
+	}{
+		{"current token version", 1, 3, http.StatusOK},
+		{"token issued before a role change", 1, 2, http.StatusUnauthorized},
+		{"deactivated user", 2, 0, http.StatusUnauthorized},
+		{"deleted user", 3, 0, http.StatusUnauthorized},
+		{"unknown user", 4, 0, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, _ := authService.GenerateJWT(tt.userID, "admin@example.com", true, false, nil, tt.tokenVersion)
+
+			req := httptest.NewRequest("GET", "/api/admin/stats", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, rec.Code)
+			}
+		})
+	}
+}
+
+// TestRequirePermission tests the route-level permission check
+func TestRequirePermission(t *testing.T) {
+	jwtSecret := "test-secret"
+	authService := services.NewAuthService(jwtSecret, 24)
+	handler := AuthMiddleware(jwtSecret, authStates{})(RequirePermission(models.PermissionManageDogs)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})))
+
+	tests := []struct {
+		name        string
+		isAdmin     bool
+		permissions []string
+		expected    int
+	}{
+		{"role with permission", false, []string{models.PermissionManageDogs}, http.StatusOK},
+		{"role without permission", false, []string{models.PermissionManageBookings}, http.StatusForbidden},
+		{"admin whose role lacks the permission", true, []string{models.PermissionManageBookings}, http.StatusForbidden},
+		{"user without roles", false, []string{}, http.StatusForbidden},
+		{"admin token without permissions claim", true, nil, http.StatusOK},
+		{"user token without permissions claim", false, nil, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var token string
+			if tt.permissions == nil {
+				// Token issued before roles existed
+				claims := jwt.MapClaims{"user_id": float64(1), "email": "user@example.com", "is_admin": tt.isAdmin,
+					"is_super_admin": false, "exp": time.Now().Add(time.Hour).Unix()}
+				token, _ = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(jwtSecret))
+			} else {
+				token, _ = authService.GenerateJWT(1, "user@example.com", tt.isAdmin, false, tt.permissions, 0)
+			}
+
+			req := httptest.NewRequest("PUT", "/api/dogs/1", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, rec.Code)
+			}
+		})
+	}
+}
+
 // DONE: TestCORSMiddleware tests CORS headers middleware
 func TestCORSMiddleware(t *testing.T) {
 	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -1,63 +1,37 @@
 package middleware
 
 import (
+	"log"
+	"math"
 	"net/http"
-	"sync"
-	"time"
-)
-
-// BUG FIX #6: Simple rate limiting for login endpoint
-// Prevents brute force attacks
-
-type rateLimiter struct {
-	requests map[string][]time.Time
-	mu       sync.Mutex
-	limit    int
-	window   time.Duration
-}
-
-var loginLimiter = &rateLimiter{
-	requests: make(map[string][]time.Time),
-	limit:    5,                 // 5 attempts
-	window:   1 * time.Minute,   // per minute
-}
-
-// RateLimitLogin limits login attempts per IP address
-func RateLimitLogin(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Get client IP
-		ip := r.RemoteAddr
-		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
-			ip = forwarded
-		}
+	"strconv"
 
-		loginLimiter.mu.Lock()
-		defer loginLimiter.mu.Unlock()
-
-		now := time.Now()
+	"github.com/tranmh/gassigeher/internal/logging"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/services"
+)
 
-		// Clean old requests outside window
-		if requests, exists := loginLimiter.requests[ip]; exists {
-			validRequests := []time.Time{}
-			for _, reqTime := range requests {
-				if now.Sub(reqTime) < loginLimiter.window {
-					validRequests = append(validRequests, reqTime)
-				}
+// RateLimit limits the requests per client IP with the policy
+// The client IP comes from logging.GetClientIP, which only trusts forwarding headers set by trusted
+// proxies. Rejected requests get 429 with a Retry-After header. If the counters cannot be stored
+// the request is let through, an outage of the database should not lock everybody out.
+func RateLimit(limiter *services.RateLimitService, policy models.RateLimitPolicy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client := logging.GetClientIP(r)
+			allowed, retryAfter, err := limiter.Allow(policy, client)
+			if err != nil {
+				log.Printf("Rate limit %s failed for %s: %v", policy.Name, client, err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				respondError(w, r, http.StatusTooManyRequests, policy.Code)
+				return
 			}
-			loginLimiter.requests[ip] = validRequests
-		}
-
-		// Check if limit exceeded
-		if len(loginLimiter.requests[ip]) >= loginLimiter.limit {
-			http.Error(w, `{"error":"Zu viele Anmeldeversuche. Bitte versuchen Sie es in einer Minute erneut."}`, http.StatusTooManyRequests)
-			return
-		}
-
-		// Add current request
-		loginLimiter.requests[ip] = append(loginLimiter.requests[ip], now)
 
-		next.ServeHTTP(w, r)
-	})
+			next.ServeHTTP(w, r)
+		})
+	}
 }
-
-// DONE: BUG #6 FIXED - Rate limiting implemented for login endpoint
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/services"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestRateLimit tests the limit per client IP, the Retry-After header and spoofed forwarding headers
+func TestRateLimit(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	limiter := services.NewRateLimitService(db)
+	policy := models.RateLimitPolicy{Name: "test", Limit: 2, Window: time.Minute, Code: "too_many_requests"}
+
+	handler := RateLimit(limiter, policy)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	request := func(remoteAddr, forwardedFor string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/auth/register", nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("rejects requests over the limit", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			if rec := request("203.0.113.1:1234", ""); rec.Code != http.StatusOK {
+				t.Fatalf("Request %d: expected 200, got %d", i+1, rec.Code)
+			}
+		}
+
+		rec := request("203.0.113.1:1234", "")
+		if rec.Code != http.StatusTooManyRequests {
+			t.Fatalf("Expected 429, got %d", rec.Code)
+		}
+		if retryAfter, err := strconv.Atoi(rec.Header().Get("Retry-After")); err != nil || retryAfter < 1 || retryAfter > 60 {
+			t.Errorf("Expected Retry-After within the window, got %q", rec.Header().Get("Retry-After"))
+		}
+	})
+
+	t.Run("ignores forwarding headers of untrusted clients", func(t *testing.T) {
+		if rec := request("203.0.113.1:1234", "198.51.100.7"); rec.Code != http.StatusTooManyRequests {
+			t.Errorf("Expected a spoofed X-Forwarded-For to be ignored, got %d", rec.Code)
+		}
+		if rec := request("203.0.113.2:1234", ""); rec.Code != http.StatusOK {
+			t.Errorf("Expected other clients to be allowed, got %d", rec.Code)
+		}
+	})
+
+	t.Run("uses forwarding headers of trusted proxies", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			if rec := request("127.0.0.1:1234", "203.0.113.1, 198.51.100.8"); rec.Code != http.StatusOK {
+				t.Fatalf("Request %d: expected 200, got %d", i+1, rec.Code)
+			}
+		}
+		if rec := request("127.0.0.1:1234", "198.51.100.8"); rec.Code != http.StatusTooManyRequests {
+			t.Errorf("Expected the client behind the proxy to be limited, got %d", rec.Code)
+		}
+		if rec := request("127.0.0.1:1234", "198.51.100.9"); rec.Code != http.StatusOK {
+			t.Errorf("Expected other clients behind the proxy to be allowed, got %d", rec.Code)
+		}
+	})
+}
@@ -0,0 +1,148 @@
+package models
+
+import (
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/i18n"
+)
+
+// ActivityEntry is an entry of the admin activity feed, recorded for every domain event
+// Only the type and params are stored, the message is rendered in the reader's language.
+// User names are read from the users table, so entries of deleted accounts show the anonymized name.
+type ActivityEntry struct {
+	ID        int       `json:"id"`
+	EventID   int       `json:"-"`
+	Type      string    `json:"type"`
+	Params    []string  `json:"-"`
+	Message   string    `json:"message"`
+	ActorID   *int      `json:"actor_id,omitempty"` // nil = system (cron job)
+	ActorName string    `json:"actor_name,omitempty"`
+	UserID    *int      `json:"user_id,omitempty"`
+	UserName  string    `json:"user_name,omitempty"`
+	DogID     *int      `json:"dog_id,omitempty"`
+	DogName   string    `json:"dog_name,omitempty"`
+	BookingID *int      `json:"booking_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ActivityFilter selects entries of the activity feed, zero values match everything
+type ActivityFilter struct {
+	Type     string // Event type ("booking.created") or its prefix ("booking")
+	UserID   int    // Entries about the user or done by the user
+	DogID    int
+	DateFrom string // YYYY-MM-DD, inclusive
+	DateTo   string // YYYY-MM-DD, inclusive
+	Limit    int
+	Offset   int
+}
+
+// ActivityResponse is a page of the activity feed
+type ActivityResponse struct {
+	Activities []*ActivityEntry `json:"activities"`
+	Total      int              `json:"total"`
+	Limit      int              `json:"limit"`
+	Offset     int              `json:"offset"`
+}
+
+// NewActivityEntry creates the activity entry of a domain event
+func NewActivityEntry(event *DomainEvent) (*ActivityEntry, error) {
+	// All event data types use the same names for the ids they reference
+	var ref struct {
+		BookingID int    `json:"booking_id"`
+		UserID    int    `json:"user_id"`
+		DogID     int    `json:"dog_id"`
+		DogName   string `json:"dog_name"`
+	}
+	if err := event.Decode(&ref); err != nil {
+		return nil, err
+	}
+
+	params, err := activityParams(event)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ActivityEntry{
+		EventID:   event.ID,
+		Type:      event.EventType,
+		Params:    params,
+		ActorID:   event.ActorID,
+		UserID:    optionalID(ref.UserID),
+		DogID:     optionalID(ref.DogID),
+		DogName:   ref.DogName,
+		BookingID: optionalID(ref.BookingID),
+		CreatedAt: event.CreatedAt,
+	}, nil
+}
+
+// activityParams returns the message params of an event, names are not part of them
+func activityParams(event *DomainEvent) ([]string, error) {
+	switch event.AggregateType {
+	case AggregateBooking:
+		var data BookingEventData
+		if err := event.Decode(&data); err != nil {
+			return nil, err
+		}
+		if event.EventType == EventBookingMoved {
+			return []string{data.PreviousDate, data.PreviousScheduledTime, data.Date, data.ScheduledTime}, nil
+		}
+		return []string{data.Date, data.ScheduledTime}, nil
+
+	case AggregateExperienceRequest:
+		var data ExperienceRequestEventData
+		if err := event.Decode(&data); err != nil {
+			return nil, err
+		}
+		return []string{NotificationParamKeyPrefix + "levels." + data.RequestedLevel}, nil
+
+	case AggregateBlockedDate:
+		var data BlockedDateEventData
+		if err := event.Decode(&data); err != nil {
+			return nil, err
+		}
+		return []string{data.Date, data.Reason}, nil
+
+	case AggregateSetting:
+		var data SettingEventData
+		if err := event.Decode(&data); err != nil {
+			return nil, err
+		}
+		return []string{data.Key, data.Value}, nil
+	}
+
+	switch event.EventType {
+	case EventDogAvailabilityChanged:
+		var data DogAvailabilityEventData
+		if err := event.Decode(&data); err != nil {
+			return nil, err
+		}
+		if data.IsAvailable {
+			return []string{NotificationParamKeyPrefix + "activity.values.available"}, nil
+		}
+		return []string{NotificationParamKeyPrefix + "activity.values.unavailable"}, nil
+
+	case EventUserDeactivated:
+		var data UserDeactivatedEventData
+		if err := event.Decode(&data); err != nil {
+			return nil, err
+		}
+		if data.Reason == DeactivationReasonInactivity {
+			return []string{NotificationParamKeyPrefix + "activity.values.auto_inactivity"}, nil
+		}
+		return []string{data.Reason}, nil
+	}
+
+	return []string{}, nil
+}
+
+// Render fills the message in the given language
+func (a *ActivityEntry) Render(lang string) {
+	a.Message = i18n.T(lang, "activity."+a.Type, LocalizeNotificationParams(lang, a.Params)...)
+}
+
+func optionalID(id int) *int {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
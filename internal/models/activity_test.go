@@ -0,0 +1,68 @@
+package models
+
+import (
+	"testing"
+)
+
+// TestNewActivityEntry tests creating and rendering activity entries of domain events
+func TestNewActivityEntry(t *testing.T) {
+	adminID := 1
+	booking := &Booking{ID: 7, UserID: 3, DogID: 5, Date: "2030-01-10", ScheduledTime: "09:00"}
+	moved := NewBookingEventData(booking, "Bella", "Anna")
+	moved.PreviousDate = "2030-01-09"
+	moved.PreviousScheduledTime = "15:00"
+
+	event := func(eventType, aggregateType string, data interface{}) *DomainEvent {
+		event, err := NewDomainEvent(eventType, aggregateType, 1, &adminID, data)
+		if err != nil {
+			t.Fatalf("NewDomainEvent() failed: %v", err)
+		}
+		return event
+	}
+
+	tests := []struct {
+		name    string
+		event   *DomainEvent
+		de      string
+		en      string
+		userID  int
+		dogName string
+	}{
+		{"booking moved", event(EventBookingMoved, AggregateBooking, moved),
+			"Buchung von 2030-01-09 15:00 auf 2030-01-10 09:00 verschoben", "Booking moved from 2030-01-09 15:00 to 2030-01-10 09:00", 3, "Bella"},
+		{"experience level", event(EventExperienceApproved, AggregateExperienceRequest, &ExperienceRequestEventData{UserID: 3, RequestedLevel: "orange"}),
+			"Erfahrungsstufe Orange genehmigt", "Experience level Orange approved", 3, ""},
+		{"dog unavailable", event(EventDogAvailabilityChanged, AggregateDog, &DogAvailabilityEventData{DogID: 5, DogName: "Bella"}),
+			"Hund ist jetzt nicht verfügbar", "Dog is now unavailable", 0, "Bella"},
+		{"blocked date deleted", event(EventBlockedDateDeleted, AggregateBlockedDate, &BlockedDateEventData{Date: "2030-12-24", Reason: "Feiertag"}),
+			"Sperrung am 2030-12-24 aufgehoben", "Unblocked 2030-12-24", 0, ""},
+		{"auto deactivated", event(EventUserDeactivated, AggregateUser, &UserDeactivatedEventData{UserID: 3, Reason: DeactivationReasonInactivity}),
+			"Konto deaktiviert: automatisch wegen Inaktivität", "Account deactivated: automatically due to inactivity", 3, ""},
+		{"registered", event(EventUserRegistered, AggregateUser, &UserEventData{UserID: 3, Name: "Anna"}),
+			"Neu registriert", "Registered", 3, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := NewActivityEntry(tt.event)
+			if err != nil {
+				t.Fatalf("NewActivityEntry() failed: %v", err)
+			}
+			if entry.ActorID == nil || *entry.ActorID != adminID || entry.DogName != tt.dogName {
+				t.Errorf("Unexpected entry: %+v", entry)
+			}
+			if (tt.userID == 0) != (entry.UserID == nil) || (entry.UserID != nil && *entry.UserID != tt.userID) {
+				t.Errorf("Expected user %d, got %v", tt.userID, entry.UserID)
+			}
+
+			entry.Render("de")
+			if entry.Message != tt.de {
+				t.Errorf("Expected %q, got %q", tt.de, entry.Message)
+			}
+			entry.Render("en")
+			if entry.Message != tt.en {
+				t.Errorf("Expected %q, got %q", tt.en, entry.Message)
+			}
+		})
+	}
+}
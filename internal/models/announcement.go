@@ -0,0 +1,183 @@
+package models
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Announcement statuses
+const (
+	AnnouncementStatusScheduled = "scheduled"
+	AnnouncementStatusSending   = "sending"
+	AnnouncementStatusSent      = "sent"
+	AnnouncementStatusCancelled = "cancelled"
+)
+
+// Announcement delivery statuses (of the email, the in-app notification is always created)
+const (
+	AnnouncementDeliveryPending = "pending"
+	AnnouncementDeliverySent    = "sent"
+	AnnouncementDeliverySkipped = "skipped" // Opted out of announcement emails or email not configured
+	AnnouncementDeliveryFailed  = "failed"
+)
+
+// NotificationTypeAnnouncement is the in-app notification type of announcements
+const NotificationTypeAnnouncement = "announcement"
+
+// Limits for announcements and user tags
+const (
+	MaxAnnouncementSubjectLength = 200
+	MaxAnnouncementMessageLength = 10000
+	MaxUserTagLength             = 50
+	MaxUserTags                  = 20
+)
+
+// User status filters of a segment
+const (
+	SegmentStatusActive   = "active"
+	SegmentStatusInactive = "inactive"
+	SegmentStatusAll      = "all"
+)
+
+// AnnouncementSegment selects the recipients of an announcement
+// All given filters must match, empty filters match everyone
+type AnnouncementSegment struct {
+	ExperienceLevels []string `json:"experience_levels,omitempty"`  // Any of green, blue, orange
+	Status           string   `json:"status,omitempty"`             // active (default), inactive or all
+	BookedWithinDays *int     `json:"booked_within_days,omitempty"` // Made a booking in the last N days
+	Tags             []string `json:"tags,omitempty"`               // Has any of the tags
+}
+
+// Announcement is a message from the admins to a segment of users, sent by email and in-app
+type Announcement struct {
+	ID          int                 `json:"id"`
+	Subject     string              `json:"subject"`
+	Message     string              `json:"message"`
+	Segment     AnnouncementSegment `json:"segment"`
+	Status      string              `json:"status"`
+	ScheduledAt time.Time           `json:"scheduled_at"`
+	CreatedBy   *int                `json:"created_by,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+	StartedAt   *time.Time          `json:"started_at,omitempty"`
+	CompletedAt *time.Time          `json:"completed_at,omitempty"`
+
+	// Delivery counts, filled for lists and reports
+	Report *AnnouncementReport `json:"report,omitempty"`
+}
+
+// AnnouncementReport summarizes the deliveries of an announcement
+type AnnouncementReport struct {
+	Total    int                     `json:"total"`
+	Pending  int                     `json:"pending"`
+	Sent     int                     `json:"sent"`
+	Skipped  int                     `json:"skipped"`
+	Failed   int                     `json:"failed"`
+	Failures []*AnnouncementDelivery `json:"failures,omitempty"`
+}
+
+// AnnouncementDelivery is the delivery of an announcement to one user
+type AnnouncementDelivery struct {
+	ID             int        `json:"id"`
+	AnnouncementID int        `json:"announcement_id"`
+	UserID         int        `json:"user_id"`
+	UserName       string     `json:"user_name,omitempty"`
+	UserEmail      string     `json:"user_email,omitempty"`
+	Status         string     `json:"status"`
+	Error          *string    `json:"error,omitempty"`
+	ProcessedAt    *time.Time `json:"processed_at,omitempty"`
+}
+
+// CreateAnnouncementRequest creates (and schedules) an announcement
+type CreateAnnouncementRequest struct {
+	Subject     string              `json:"subject"`
+	Message     string              `json:"message"`
+	Segment     AnnouncementSegment `json:"segment"`
+	ScheduledAt *time.Time          `json:"scheduled_at,omitempty"` // nil = send now
+}
+
+// AnnouncementPreview shows the rendered email and the number of recipients before sending
+type AnnouncementPreview struct {
+	Subject        string `json:"subject"`
+	Body           string `json:"body"`
+	RecipientCount int    `json:"recipient_count"`
+}
+
+// UpdateUserTagsRequest replaces the tags of a user
+type UpdateUserTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// Validate validates the announcement request
+func (r *CreateAnnouncementRequest) Validate() error {
+	r.Subject = strings.TrimSpace(r.Subject)
+	r.Message = strings.TrimSpace(r.Message)
+
+	if r.Subject == "" || utf8.RuneCountInString(r.Subject) > MaxAnnouncementSubjectLength {
+		return &ValidationError{Field: "subject", Message: "Subject is required (max 200 characters)", Code: "invalid_announcement_subject", Args: []interface{}{MaxAnnouncementSubjectLength}}
+	}
+	if r.Message == "" || utf8.RuneCountInString(r.Message) > MaxAnnouncementMessageLength {
+		return &ValidationError{Field: "message", Message: "Message is required (max 10000 characters)", Code: "invalid_announcement_message", Args: []interface{}{MaxAnnouncementMessageLength}}
+	}
+
+	return r.Segment.Validate()
+}
+
+// Validate validates the segment and normalizes the status filter
+func (s *AnnouncementSegment) Validate() error {
+	for _, level := range s.ExperienceLevels {
+		if level != "green" && level != "blue" && level != "orange" {
+			return &ValidationError{Field: "segment.experience_levels", Message: "Invalid experience level", Code: "invalid_segment_experience_level"}
+		}
+	}
+
+	if s.Status == "" {
+		s.Status = SegmentStatusActive
+	}
+	if s.Status != SegmentStatusActive && s.Status != SegmentStatusInactive && s.Status != SegmentStatusAll {
+		return &ValidationError{Field: "segment.status", Message: "Status must be active, inactive or all", Code: "invalid_segment_status"}
+	}
+
+	if s.BookedWithinDays != nil && (*s.BookedWithinDays < 1 || *s.BookedWithinDays > 3650) {
+		return &ValidationError{Field: "segment.booked_within_days", Message: "Days must be between 1 and 3650", Code: "invalid_segment_days"}
+	}
+
+	tags, err := NormalizeUserTags(s.Tags)
+	if err != nil {
+		return err
+	}
+	s.Tags = tags
+	return nil
+}
+
+// Validate validates and normalizes the tags
+func (r *UpdateUserTagsRequest) Validate() error {
+	tags, err := NormalizeUserTags(r.Tags)
+	if err != nil {
+		return err
+	}
+	r.Tags = tags
+	return nil
+}
+
+// NormalizeUserTags trims, lowercases and de-duplicates tags
+func NormalizeUserTags(tags []string) ([]string, error) {
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || utf8.RuneCountInString(tag) > MaxUserTagLength {
+			return nil, &ValidationError{Field: "tags", Message: "Tags must be 1-50 characters", Code: "invalid_user_tags", Args: []interface{}{MaxUserTagLength, MaxUserTags}}
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	if len(normalized) > MaxUserTags {
+		return nil, &ValidationError{Field: "tags", Message: "At most 20 tags", Code: "invalid_user_tags", Args: []interface{}{MaxUserTagLength, MaxUserTags}}
+	}
+	return normalized, nil
+}
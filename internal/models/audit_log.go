@@ -0,0 +1,97 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// AuditGenesisHash is the prev_hash of the first audit entry
+var AuditGenesisHash = strings.Repeat("0", 64)
+
+// AuditEntry is an append-only record of an action done by an admin or super admin
+// Entries are hash-chained: Hash covers all fields and the Hash of the previous entry (PrevHash),
+// so changing or deleting an entry breaks the chain from that entry on.
+type AuditEntry struct {
+	ID         int             `json:"id"`
+	ActorID    int             `json:"actor_id"`
+	ActorEmail string          `json:"actor_email"` // Snapshot, accounts can be deleted and anonymized
+	Action     string          `json:"action"`      // Method and route, e.g. "PUT /api/settings/{key}"
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	IPAddress  string          `json:"ip_address"`
+	RequestID  string          `json:"request_id"`
+	StatusCode int             `json:"status_code"`
+	CreatedAt  time.Time       `json:"created_at"`
+	PrevHash   string          `json:"prev_hash"`
+	Hash       string          `json:"hash"`
+}
+
+// ComputeHash returns the SHA-256 hash of the entry chained to PrevHash
+// The ID is not covered because it is assigned by the database, the order is covered by the chain.
+// CreatedAt is hashed in UTC with second precision, which every supported database stores exactly.
+func (e *AuditEntry) ComputeHash() string {
+	payload, _ := json.Marshal(struct {
+		PrevHash   string `json:"prev_hash"`
+		ActorID    int    `json:"actor_id"`
+		ActorEmail string `json:"actor_email"`
+		Action     string `json:"action"`
+		TargetType string `json:"target_type"`
+		TargetID   string `json:"target_id"`
+		Before     string `json:"before"`
+		After      string `json:"after"`
+		IPAddress  string `json:"ip_address"`
+		RequestID  string `json:"request_id"`
+		StatusCode int    `json:"status_code"`
+		CreatedAt  string `json:"created_at"`
+	}{
+		PrevHash:   e.PrevHash,
+		ActorID:    e.ActorID,
+		ActorEmail: e.ActorEmail,
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		Before:     string(e.Before),
+		After:      string(e.After),
+		IPAddress:  e.IPAddress,
+		RequestID:  e.RequestID,
+		StatusCode: e.StatusCode,
+		CreatedAt:  e.CreatedAt.UTC().Format(time.RFC3339),
+	})
+
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditFilter selects audit entries, zero values match everything
+type AuditFilter struct {
+	ActorID    int
+	Action     string // Part of the action, e.g. "/settings" or "DELETE"
+	TargetType string
+	TargetID   string
+	DateFrom   string // YYYY-MM-DD, inclusive
+	DateTo     string // YYYY-MM-DD, inclusive
+	Limit      int
+	Offset     int
+}
+
+// AuditLogResponse is a page of the audit log
+type AuditLogResponse struct {
+	Entries []*AuditEntry `json:"entries"`
+	Total   int           `json:"total"`
+	Limit   int           `json:"limit"`
+	Offset  int           `json:"offset"`
+}
+
+// AuditVerification is the result of checking the hash chain of the audit log
+type AuditVerification struct {
+	Valid          bool   `json:"valid"`
+	EntriesChecked int    `json:"entries_checked"`
+	FirstInvalidID *int   `json:"first_invalid_id,omitempty"`
+	Reason         string `json:"reason,omitempty"` // "hash_mismatch" or "chain_broken"
+	HeadHash       string `json:"head_hash"`        // Hash of the newest entry, keep a copy to detect truncation
+}
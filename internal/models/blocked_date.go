@@ -20,16 +20,16 @@ type CreateBlockedDateRequest struct {
 // Validate validates the create blocked date request
 func (r *CreateBlockedDateRequest) Validate() error {
 	if r.Date == "" {
-		return &ValidationError{Field: "date", Message: "Date is required"}
+		return &ValidationError{Field: "date", Message: "Date is required", Code: "date_required"}
 	}
 
 	// Validate date format (YYYY-MM-DD)
 	if _, err := time.Parse("2006-01-02", r.Date); err != nil {
-		return &ValidationError{Field: "date", Message: "Date must be in YYYY-MM-DD format"}
+		return &ValidationError{Field: "date", Message: "Date must be in YYYY-MM-DD format", Code: "invalid_date_format"}
 	}
 
 	if r.Reason == "" {
-		return &ValidationError{Field: "reason", Message: "Reason is required"}
+		return &ValidationError{Field: "reason", Message: "Reason is required", Code: "reason_required"}
 	}
 
 	return nil
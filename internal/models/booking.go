@@ -11,7 +11,6 @@ type Booking struct {
 	ScheduledTime           string     `json:"scheduled_time"` // HH:MM format
 	Status                  string     `json:"status"`
 	CompletedAt             *time.Time `json:"completed_at,omitempty"`
-	ReminderSentAt          *time.Time `json:"reminder_sent_at,omitempty"`
 	UserNotes               *string    `json:"user_notes,omitempty"`
 	AdminCancellationReason *string    `json:"admin_cancellation_reason,omitempty"`
 	CreatedAt               time.Time  `json:"created_at"`
@@ -56,23 +55,23 @@ type MoveBookingRequest struct {
 // Validate validates the move booking request
 func (r *MoveBookingRequest) Validate() error {
 	if r.Date == "" {
-		return &ValidationError{Field: "date", Message: "Date is required"}
+		return &ValidationError{Field: "date", Message: "Date is required", Code: "date_required"}
 	}
 
 	if _, err := time.Parse("2006-01-02", r.Date); err != nil {
-		return &ValidationError{Field: "date", Message: "Date must be in YYYY-MM-DD format"}
+		return &ValidationError{Field: "date", Message: "Date must be in YYYY-MM-DD format", Code: "invalid_date_format"}
 	}
 
 	if r.ScheduledTime == "" {
-		return &ValidationError{Field: "scheduled_time", Message: "Scheduled time is required"}
+		return &ValidationError{Field: "scheduled_time", Message: "Scheduled time is required", Code: "scheduled_time_required"}
 	}
 
 	if _, err := time.Parse("15:04", r.ScheduledTime); err != nil {
-		return &ValidationError{Field: "scheduled_time", Message: "Scheduled time must be in HH:MM format"}
+		return &ValidationError{Field: "scheduled_time", Message: "Scheduled time must be in HH:MM format", Code: "invalid_time_format"}
 	}
 
 	if r.Reason == "" {
-		return &ValidationError{Field: "reason", Message: "Reason is required"}
+		return &ValidationError{Field: "reason", Message: "Reason is required", Code: "reason_required"}
 	}
 
 	return nil
@@ -107,25 +106,25 @@ type CalendarResponse struct {
 // Validate validates the create booking request
 func (r *CreateBookingRequest) Validate() error {
 	if r.DogID <= 0 {
-		return &ValidationError{Field: "dog_id", Message: "Dog ID is required"}
+		return &ValidationError{Field: "dog_id", Message: "Dog ID is required", Code: "dog_id_required"}
 	}
 
 	if r.Date == "" {
-		return &ValidationError{Field: "date", Message: "Date is required"}
+		return &ValidationError{Field: "date", Message: "Date is required", Code: "date_required"}
 	}
 
 	// Validate date format (YYYY-MM-DD)
 	if _, err := time.Parse("2006-01-02", r.Date); err != nil {
-		return &ValidationError{Field: "date", Message: "Date must be in YYYY-MM-DD format"}
+		return &ValidationError{Field: "date", Message: "Date must be in YYYY-MM-DD format", Code: "invalid_date_format"}
 	}
 
 	if r.ScheduledTime == "" {
-		return &ValidationError{Field: "scheduled_time", Message: "Scheduled time is required"}
+		return &ValidationError{Field: "scheduled_time", Message: "Scheduled time is required", Code: "scheduled_time_required"}
 	}
 
 	// Validate time format (HH:MM)
 	if _, err := time.Parse("15:04", r.ScheduledTime); err != nil {
-		return &ValidationError{Field: "scheduled_time", Message: "Scheduled time must be in HH:MM format"}
+		return &ValidationError{Field: "scheduled_time", Message: "Scheduled time must be in HH:MM format", Code: "invalid_time_format"}
 	}
 
 	return nil
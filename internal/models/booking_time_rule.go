@@ -1,7 +1,6 @@
 package models
 
 import (
-	"fmt"
 	"time"
 )
 
@@ -19,23 +18,23 @@ type BookingTimeRule struct {
 // Validate validates booking time rule
 func (r *BookingTimeRule) Validate() error {
 	if r.DayType != "weekday" && r.DayType != "weekend" && r.DayType != "holiday" {
-		return fmt.Errorf("day_type must be 'weekday', 'weekend', or 'holiday'")
+		return &ValidationError{Field: "day_type", Message: "must be 'weekday', 'weekend', or 'holiday'", Code: "invalid_day_type"}
 	}
 	if r.RuleName == "" {
-		return fmt.Errorf("rule_name is required")
+		return &ValidationError{Field: "rule_name", Message: "is required", Code: "rule_name_required"}
 	}
 
 	// Validate time format
 	if !isValidTimeFormat(r.StartTime) {
-		return fmt.Errorf("start_time must be in HH:MM format")
+		return &ValidationError{Field: "start_time", Message: "must be in HH:MM format", Code: "invalid_time_format"}
 	}
 	if !isValidTimeFormat(r.EndTime) {
-		return fmt.Errorf("end_time must be in HH:MM format")
+		return &ValidationError{Field: "end_time", Message: "must be in HH:MM format", Code: "invalid_time_format"}
 	}
 
 	// Validate end > start
 	if r.EndTime <= r.StartTime {
-		return fmt.Errorf("end_time must be after start_time")
+		return &ValidationError{Field: "end_time", Message: "must be after start_time", Code: "end_time_before_start_time"}
 	}
 
 	return nil
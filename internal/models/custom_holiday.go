@@ -1,7 +1,6 @@
 package models
 
 import (
-	"fmt"
 	"time"
 )
 
@@ -17,21 +16,21 @@ type CustomHoliday struct {
 
 func (h *CustomHoliday) Validate() error {
 	if h.Date == "" {
-		return fmt.Errorf("date is required")
+		return &ValidationError{Field: "date", Message: "is required", Code: "date_required"}
 	}
 
 	// Validate date format
 	_, err := time.Parse("2006-01-02", h.Date)
 	if err != nil {
-		return fmt.Errorf("date must be in YYYY-MM-DD format")
+		return &ValidationError{Field: "date", Message: "must be in YYYY-MM-DD format", Code: "invalid_date_format"}
 	}
 
 	if h.Name == "" {
-		return fmt.Errorf("name is required")
+		return &ValidationError{Field: "name", Message: "is required", Code: "name_required"}
 	}
 
 	if h.Source != "api" && h.Source != "admin" {
-		return fmt.Errorf("source must be 'api' or 'admin'")
+		return &ValidationError{Field: "source", Message: "must be 'api' or 'admin'", Code: "invalid_holiday_source"}
 	}
 
 	return nil
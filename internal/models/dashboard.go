@@ -12,19 +12,3 @@ type DashboardStats struct {
 	PendingExperienceReqs int `json:"pending_experience_requests"`
 	PendingReactivationReqs int `json:"pending_reactivation_requests"`
 }
-
-// ActivityItem represents a recent activity item
-type ActivityItem struct {
-	Type      string `json:"type"`
-	Message   string `json:"message"`
-	Timestamp string `json:"timestamp"`
-	UserID    *int   `json:"user_id,omitempty"`
-	UserName  string `json:"user_name,omitempty"`
-	DogID     *int   `json:"dog_id,omitempty"`
-	DogName   string `json:"dog_name,omitempty"`
-}
-
-// RecentActivityResponse represents the recent activity feed
-type RecentActivityResponse struct {
-	Activities []*ActivityItem `json:"activities"`
-}
@@ -0,0 +1,211 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Domain event types
+const (
+	EventBookingCreated         = "booking.created"
+	EventBookingCancelled       = "booking.cancelled"
+	EventBookingApproved        = "booking.approved"
+	EventBookingRejected        = "booking.rejected"
+	EventBookingMoved           = "booking.moved"
+	EventBookingCompleted       = "booking.completed"
+	EventBookingNotesAdded      = "booking.notes_added"
+	EventDogCreated             = "dog.created"
+	EventDogUpdated             = "dog.updated"
+	EventDogDeleted             = "dog.deleted"
+	EventDogAvailabilityChanged = "dog.availability_changed"
+	EventUserRegistered         = "user.registered"
+	EventUserDeleted            = "user.deleted"
+	EventUserPromoted           = "user.promoted"
+	EventUserDemoted            = "user.demoted"
+	EventUserDeactivated        = "user.deactivated"
+	EventUserActivated          = "user.activated"
+	EventExperienceRequested    = "experience_request.created"
+	EventExperienceApproved     = "experience_request.approved"
+	EventExperienceDenied       = "experience_request.denied"
+	EventReactivationRequested  = "reactivation_request.created"
+	EventReactivationDenied     = "reactivation_request.denied"
+	EventRegistrationRequested  = "registration_request.created"
+	EventRegistrationApproved   = "registration_request.approved"
+	EventRegistrationDenied     = "registration_request.denied"
+	EventBlockedDateCreated     = "blocked_date.created"
+	EventBlockedDateDeleted     = "blocked_date.deleted"
+	EventSettingUpdated         = "setting.updated"
+)
+
+// Aggregate types of domain events
+const (
+	AggregateBooking             = "booking"
+	AggregateDog                 = "dog"
+	AggregateUser                = "user"
+	AggregateExperienceRequest   = "experience_request"
+	AggregateReactivationRequest = "reactivation_request"
+	AggregateRegistrationRequest = "registration_request"
+	AggregateBlockedDate         = "blocked_date"
+	AggregateSetting             = "setting" // aggregate_id is 0, the key is in the event data
+)
+
+// Who cancelled a booking (BookingEventData.CancelledBy)
+const (
+	CancelledByUser        = "user"
+	CancelledByAdmin       = "admin"
+	CancelledByBlockedDate = "blocked_date"
+	CancelledByDogDeleted  = "dog_deleted"
+)
+
+// DeactivationReasonInactivity is the reason stored for users deactivated by the cron job
+const DeactivationReasonInactivity = "auto_inactivity"
+
+// DomainEvent is a state change recorded in the outbox
+// It is written in the same transaction as the change and dispatched to subscribers afterwards.
+type DomainEvent struct {
+	ID            int        `json:"id"`
+	EventType     string     `json:"event_type"`
+	AggregateType string     `json:"aggregate_type"`
+	AggregateID   int        `json:"aggregate_id"`
+	ActorID       *int       `json:"actor_id,omitempty"` // nil = system (cron job)
+	Payload       string     `json:"payload"`            // JSON encoded event data
+	CreatedAt     time.Time  `json:"created_at"`
+	DispatchedAt  *time.Time `json:"dispatched_at,omitempty"`
+	Attempts      int        `json:"-"` // Failed dispatch attempts, see EventDispatcher
+}
+
+// NewDomainEvent creates an event with the JSON encoded data as payload
+func NewDomainEvent(eventType, aggregateType string, aggregateID int, actorID *int, data interface{}) (*DomainEvent, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+	return &DomainEvent{
+		EventType:     eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		ActorID:       actorID,
+		Payload:       string(payload),
+	}, nil
+}
+
+// Decode decodes the payload into the event data type
+func (e *DomainEvent) Decode(data interface{}) error {
+	if err := json.Unmarshal([]byte(e.Payload), data); err != nil {
+		return fmt.Errorf("failed to decode %s event %d: %w", e.EventType, e.ID, err)
+	}
+	return nil
+}
+
+// BookingEventData is the data of booking.* events
+type BookingEventData struct {
+	BookingID             int     `json:"booking_id"`
+	DogID                 int     `json:"dog_id"`
+	DogName               string  `json:"dog_name"`
+	UserID                int     `json:"user_id"`
+	UserName              string  `json:"user_name"`
+	Date                  string  `json:"date"`
+	ScheduledTime         string  `json:"scheduled_time"`
+	Status                string  `json:"status"`
+	ApprovalStatus        string  `json:"approval_status,omitempty"`
+	RequiresApproval      bool    `json:"requires_approval,omitempty"`
+	Reason                *string `json:"reason,omitempty"`                  // Cancellation, rejection or move reason
+	CancelledBy           string  `json:"cancelled_by,omitempty"`            // booking.cancelled only
+	PreviousDate          string  `json:"previous_date,omitempty"`           // booking.moved only
+	PreviousScheduledTime string  `json:"previous_scheduled_time,omitempty"` // booking.moved only
+}
+
+// NewBookingEventData creates the event data of a booking
+func NewBookingEventData(booking *Booking, dogName, userName string) *BookingEventData {
+	return &BookingEventData{
+		BookingID:        booking.ID,
+		DogID:            booking.DogID,
+		DogName:          dogName,
+		UserID:           booking.UserID,
+		UserName:         userName,
+		Date:             booking.Date,
+		ScheduledTime:    booking.ScheduledTime,
+		Status:           booking.Status,
+		ApprovalStatus:   booking.ApprovalStatus,
+		RequiresApproval: booking.RequiresApproval,
+	}
+}
+
+// NewBookingEvent creates a booking.* event
+func NewBookingEvent(eventType string, actorID *int, data *BookingEventData) (*DomainEvent, error) {
+	return NewDomainEvent(eventType, AggregateBooking, data.BookingID, actorID, data)
+}
+
+// DogAvailabilityEventData is the data of dog.availability_changed events
+type DogAvailabilityEventData struct {
+	DogID             int     `json:"dog_id"`
+	DogName           string  `json:"dog_name"`
+	IsAvailable       bool    `json:"is_available"`
+	UnavailableReason *string `json:"unavailable_reason,omitempty"`
+}
+
+// UserDeactivatedEventData is the data of user.deactivated events
+type UserDeactivatedEventData struct {
+	UserID       int    `json:"user_id"`
+	Name         string `json:"name"`
+	Reason       string `json:"reason"`
+	InactiveDays int    `json:"inactive_days,omitempty"` // Set for DeactivationReasonInactivity
+}
+
+// UserActivatedEventData is the data of user.activated events
+type UserActivatedEventData struct {
+	UserID  int     `json:"user_id"`
+	Name    string  `json:"name"`
+	Message *string `json:"message,omitempty"` // Message of the admin to the user
+}
+
+// DogEventData is the data of dog.created, dog.updated and dog.deleted events
+type DogEventData struct {
+	DogID   int    `json:"dog_id"`
+	DogName string `json:"dog_name"`
+}
+
+// UserEventData is the data of user.registered, user.deleted, user.promoted and user.demoted events
+type UserEventData struct {
+	UserID int    `json:"user_id"`
+	Name   string `json:"name,omitempty"` // Empty for user.deleted, the account is anonymized
+}
+
+// ExperienceRequestEventData is the data of experience_request.* events
+type ExperienceRequestEventData struct {
+	RequestID      int     `json:"request_id"`
+	UserID         int     `json:"user_id"`
+	UserName       string  `json:"user_name"`
+	RequestedLevel string  `json:"requested_level"`
+	Message        *string `json:"message,omitempty"` // Message of the reviewing admin
+}
+
+// ReactivationRequestEventData is the data of reactivation_request.* events
+type ReactivationRequestEventData struct {
+	RequestID int     `json:"request_id"`
+	UserID    int     `json:"user_id"`
+	UserName  string  `json:"user_name"`
+	Message   *string `json:"message,omitempty"` // Message of the reviewing admin
+}
+
+// RegistrationRequestEventData is the data of registration_request.* events
+type RegistrationRequestEventData struct {
+	RequestID int     `json:"request_id"`
+	UserID    int     `json:"user_id"`
+	UserName  string  `json:"user_name"`
+	Message   *string `json:"message,omitempty"` // Message of the reviewing admin
+}
+
+// BlockedDateEventData is the data of blocked_date.* events
+type BlockedDateEventData struct {
+	BlockedDateID int    `json:"blocked_date_id"`
+	Date          string `json:"date"`
+	Reason        string `json:"reason"`
+}
+
+// SettingEventData is the data of setting.updated events
+type SettingEventData struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// EmailTemplate represents an admin override of an embedded email template
+type EmailTemplate struct {
+	Key       string    `json:"key"`
+	Language  string    `json:"language"`
+	Subject   string    `json:"subject"`
+	Body      string    `json:"body"`
+	UpdatedBy *int      `json:"updated_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// EmailTemplateResponse describes a template as shown in the admin UI
+// Subject and Body contain the effective template (override or default)
+type EmailTemplateResponse struct {
+	Key          string     `json:"key"`
+	Language     string     `json:"language"`
+	Description  string     `json:"description"`
+	Variables    []string   `json:"variables"`
+	Subject      string     `json:"subject"`
+	Body         string     `json:"body"`
+	IsCustomized bool       `json:"is_customized"`
+	UpdatedBy    *int       `json:"updated_by,omitempty"`
+	UpdatedAt    *time.Time `json:"updated_at,omitempty"`
+}
+
+// UpdateEmailTemplateRequest represents a request to override a template
+type UpdateEmailTemplateRequest struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Validate validates the update email template request
+func (r *UpdateEmailTemplateRequest) Validate() error {
+	if strings.TrimSpace(r.Subject) == "" {
+		return &ValidationError{Field: "subject", Message: "Subject is required", Code: "subject_required"}
+	}
+
+	if strings.ContainsAny(r.Subject, "\r\n") {
+		return &ValidationError{Field: "subject", Message: "Subject must be a single line", Code: "subject_single_line"}
+	}
+
+	if strings.TrimSpace(r.Body) == "" {
+		return &ValidationError{Field: "body", Message: "Body is required", Code: "body_required"}
+	}
+
+	return nil
+}
+
+// PreviewEmailTemplateRequest represents a request to preview a template
+// Omitted fields fall back to the currently effective template
+type PreviewEmailTemplateRequest struct {
+	Subject *string `json:"subject,omitempty"`
+	Body    *string `json:"body,omitempty"`
+}
+
+// EmailTemplatePreview represents a rendered template with sample data
+type EmailTemplatePreview struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
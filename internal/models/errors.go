@@ -6,9 +6,18 @@ import "fmt"
 type ValidationError struct {
 	Field   string
 	Message string
+	Code    string        // Stable error code, translated via the i18n catalogs
+	Args    []interface{} // Arguments for the translated message
 }
 
 // Error implements the error interface
 func (e *ValidationError) Error() string {
 	return fmt.Sprintf("%s: %s", e.Field, e.Message)
 }
+
+// ErrorResponse is the JSON body of an API error
+type ErrorResponse struct {
+	Error string `json:"error"`           // Message in the language of the request
+	Code  string `json:"code"`            // Stable error code for clients
+	Field string `json:"field,omitempty"` // Invalid field (validation errors only)
+}
@@ -31,7 +31,7 @@ type ReviewExperienceRequestRequest struct {
 // Validate validates the create experience request
 func (r *CreateExperienceRequestRequest) Validate() error {
 	if r.RequestedLevel != "blue" && r.RequestedLevel != "orange" {
-		return &ValidationError{Field: "requested_level", Message: "Requested level must be 'blue' or 'orange'"}
+		return &ValidationError{Field: "requested_level", Message: "Requested level must be 'blue' or 'orange'", Code: "invalid_requested_level"}
 	}
 
 	return nil
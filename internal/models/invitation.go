@@ -0,0 +1,145 @@
+package models
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// SettingRegistrationMode decides who can register at /api/auth/register
+const SettingRegistrationMode = "registration_mode"
+
+// Registration modes
+const (
+	RegistrationModeOpen       = "open"              // Everyone can register
+	RegistrationModeInviteOnly = "invite_only"       // Only with an invitation code
+	RegistrationModeApproval   = "approval_required" // Without an invitation code an admin approves the account
+)
+
+// Invitation statuses, computed from the counters and dates
+const (
+	InvitationStatusActive  = "active"
+	InvitationStatusUsed    = "used"
+	InvitationStatusExpired = "expired"
+	InvitationStatusRevoked = "revoked"
+)
+
+// Limits of invitations
+const (
+	MaxInvitationUses       = 1000
+	MaxInvitationExpiryDays = 365
+	DefaultInvitationDays   = 14
+)
+
+// InvitationCodeAlphabet has no characters that are easily confused (0/O, 1/I/L)
+const InvitationCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+// IsValidRegistrationMode reports whether the value is a known registration mode
+func IsValidRegistrationMode(mode string) bool {
+	return mode == RegistrationModeOpen || mode == RegistrationModeInviteOnly || mode == RegistrationModeApproval
+}
+
+// Invitation is a code admins hand out to let people register
+// Users registering with it get its experience level and tag and skip the approval.
+type Invitation struct {
+	ID              int        `json:"id"`
+	Code            string     `json:"code"`
+	Email           *string    `json:"email,omitempty"` // Only this address can use the code
+	MaxUses         int        `json:"max_uses"`
+	UseCount        int        `json:"use_count"`
+	ExperienceLevel string     `json:"experience_level"`
+	Tag             *string    `json:"tag,omitempty"`
+	ExpiresAt       time.Time  `json:"expires_at"`
+	RevokedAt       *time.Time `json:"revoked_at,omitempty"`
+	CreatedBy       *int       `json:"created_by,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	Status          string     `json:"status"`
+}
+
+// StatusAt returns the status of the invitation at the time
+func (i *Invitation) StatusAt(now time.Time) string {
+	switch {
+	case i.RevokedAt != nil:
+		return InvitationStatusRevoked
+	case i.UseCount >= i.MaxUses:
+		return InvitationStatusUsed
+	case !now.Before(i.ExpiresAt):
+		return InvitationStatusExpired
+	default:
+		return InvitationStatusActive
+	}
+}
+
+// AcceptsEmail reports whether the email address may register with the invitation
+func (i *Invitation) AcceptsEmail(email string) bool {
+	return i.Email == nil || strings.EqualFold(strings.TrimSpace(*i.Email), strings.TrimSpace(email))
+}
+
+// CreateInvitationRequest creates an invitation, an email address sends it by email
+type CreateInvitationRequest struct {
+	Email           *string `json:"email,omitempty"`
+	MaxUses         int     `json:"max_uses"`
+	ExpiresInDays   int     `json:"expires_in_days"`
+	ExperienceLevel string  `json:"experience_level"`
+	Tag             *string `json:"tag,omitempty"`
+}
+
+// Validate validates the invitation request and fills in the defaults
+func (r *CreateInvitationRequest) Validate() error {
+	if r.Email != nil {
+		email := strings.TrimSpace(*r.Email)
+		if email == "" {
+			r.Email = nil
+		} else if !strings.Contains(email, "@") {
+			return &ValidationError{Field: "email", Message: "Invalid email address", Code: "invalid_invitation_email"}
+		} else {
+			r.Email = &email
+		}
+	}
+
+	if r.MaxUses == 0 {
+		r.MaxUses = 1
+	}
+	if r.MaxUses < 1 || r.MaxUses > MaxInvitationUses {
+		return &ValidationError{Field: "max_uses", Message: "Uses must be between 1 and 1000", Code: "invalid_invitation_uses", Args: []interface{}{MaxInvitationUses}}
+	}
+	if r.Email != nil && r.MaxUses != 1 {
+		return &ValidationError{Field: "max_uses", Message: "Invitations for an email address can be used once", Code: "invitation_email_single_use"}
+	}
+
+	if r.ExpiresInDays == 0 {
+		r.ExpiresInDays = DefaultInvitationDays
+	}
+	if r.ExpiresInDays < 1 || r.ExpiresInDays > MaxInvitationExpiryDays {
+		return &ValidationError{Field: "expires_in_days", Message: "Expiry must be between 1 and 365 days", Code: "invalid_invitation_expiry", Args: []interface{}{MaxInvitationExpiryDays}}
+	}
+
+	if r.ExperienceLevel == "" {
+		r.ExperienceLevel = "green"
+	}
+	if r.ExperienceLevel != "green" && r.ExperienceLevel != "blue" && r.ExperienceLevel != "orange" {
+		return &ValidationError{Field: "experience_level", Message: "Invalid experience level", Code: "invalid_invitation_level"}
+	}
+
+	if r.Tag != nil {
+		tag := strings.ToLower(strings.TrimSpace(*r.Tag))
+		if tag == "" {
+			r.Tag = nil
+		} else if utf8.RuneCountInString(tag) > MaxUserTagLength {
+			return &ValidationError{Field: "tag", Message: "Tags must be 1-50 characters", Code: "invalid_user_tags", Args: []interface{}{MaxUserTagLength, MaxUserTags}}
+		} else {
+			r.Tag = &tag
+		}
+	}
+	return nil
+}
+
+// NormalizeInvitationCode ignores case, spaces and dashes, codes are typed in by hand
+func NormalizeInvitationCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToUpper(strings.TrimSpace(code)))
+}
+
+// RegistrationStatus tells the registration page which registration mode is set
+type RegistrationStatus struct {
+	Mode string `json:"mode"`
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCreateInvitationRequest_Validate tests defaults and limits of new invitations
+func TestCreateInvitationRequest_Validate(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name     string
+		req      CreateInvitationRequest
+		wantCode string
+	}{
+		{"defaults", CreateInvitationRequest{}, ""},
+		{"multi-use with tag", CreateInvitationRequest{MaxUses: 50, ExpiresInDays: 365, ExperienceLevel: "orange", Tag: str(" Vorstand ")}, ""},
+		{"single use for an email address", CreateInvitationRequest{Email: str(" neu@example.com ")}, ""},
+		{"invalid email", CreateInvitationRequest{Email: str("neu")}, "invalid_invitation_email"},
+		{"email with several uses", CreateInvitationRequest{Email: str("neu@example.com"), MaxUses: 2}, "invitation_email_single_use"},
+		{"negative uses", CreateInvitationRequest{MaxUses: -1}, "invalid_invitation_uses"},
+		{"too many uses", CreateInvitationRequest{MaxUses: MaxInvitationUses + 1}, "invalid_invitation_uses"},
+		{"expiry too long", CreateInvitationRequest{ExpiresInDays: MaxInvitationExpiryDays + 1}, "invalid_invitation_expiry"},
+		{"unknown level", CreateInvitationRequest{ExperienceLevel: "red"}, "invalid_invitation_level"},
+		{"tag too long", CreateInvitationRequest{Tag: str(strings.Repeat("a", MaxUserTagLength+1))}, "invalid_user_tags"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			code := ""
+			if verr, ok := err.(*ValidationError); ok {
+				code = verr.Code
+			} else if err != nil {
+				t.Fatalf("Expected a ValidationError, got %v", err)
+			}
+			if code != tt.wantCode {
+				t.Errorf("Validate() code = %q, want %q", code, tt.wantCode)
+			}
+		})
+	}
+
+	req := CreateInvitationRequest{Email: str(" "), Tag: str(" Vorstand ")}
+	if err := req.Validate(); err != nil {
+		t.Fatalf("Validate() failed: %v", err)
+	}
+	if req.Email != nil || req.MaxUses != 1 || req.ExpiresInDays != DefaultInvitationDays || req.ExperienceLevel != "green" || *req.Tag != "vorstand" {
+		t.Errorf("Unexpected normalized request %+v", req)
+	}
+}
+
+// TestInvitation_StatusAt tests the computed status and the email restriction of invitations
+func TestInvitation_StatusAt(t *testing.T) {
+	now := time.Now()
+	revoked := now.Add(-time.Minute)
+	email := "Neu@Example.com"
+
+	tests := []struct {
+		name       string
+		invitation Invitation
+		want       string
+	}{
+		{"active", Invitation{MaxUses: 2, UseCount: 1, ExpiresAt: now.Add(time.Hour)}, InvitationStatusActive},
+		{"used", Invitation{MaxUses: 2, UseCount: 2, ExpiresAt: now.Add(time.Hour)}, InvitationStatusUsed},
+		{"expired", Invitation{MaxUses: 1, ExpiresAt: now}, InvitationStatusExpired},
+		{"revoked", Invitation{MaxUses: 1, ExpiresAt: now.Add(time.Hour), RevokedAt: &revoked}, InvitationStatusRevoked},
+	}
+	for _, tt := range tests {
+		if got := tt.invitation.StatusAt(now); got != tt.want {
+			t.Errorf("%s: StatusAt() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	invitation := Invitation{Email: &email}
+	if !invitation.AcceptsEmail("neu@example.com ") || invitation.AcceptsEmail("other@example.com") {
+		t.Error("Expected the invitation to accept only its email address, ignoring case")
+	}
+	if got := NormalizeInvitationCode(" k7qm-3xpa 9trw "); got != "K7QM3XPA9TRW" {
+		t.Errorf("NormalizeInvitationCode() = %q", got)
+	}
+}
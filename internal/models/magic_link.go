@@ -0,0 +1,56 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Settings of the magic link login
+const (
+	SettingMagicLinkLogin      = "magic_link_login"       // "true" enables the login with an email link
+	SettingMagicLinkSameDevice = "magic_link_same_device" // "true" accepts links only in the browser that requested them
+)
+
+// MagicLink is a single-use login link sent by email, only hashes of the tokens are stored
+type MagicLink struct {
+	ID         int
+	UserID     int
+	TokenHash  string
+	DeviceHash string
+	ExpiresAt  time.Time
+	UsedAt     *time.Time
+	CreatedAt  time.Time
+}
+
+// MagicLinkRequest requests a login link for an email address
+type MagicLinkRequest struct {
+	Email string `json:"email"`
+}
+
+// Validate validates the magic link request
+func (r *MagicLinkRequest) Validate() error {
+	r.Email = strings.TrimSpace(r.Email)
+	if r.Email == "" {
+		return &ValidationError{Field: "email", Message: "Email is required", Code: "email_required"}
+	}
+	return nil
+}
+
+// MagicLinkResponse is returned for every request, also for unknown email addresses
+// The client keeps DeviceToken and sends it with the link token.
+type MagicLinkResponse struct {
+	Message     string `json:"message"`
+	DeviceToken string `json:"device_token"`
+	ExpiresIn   int    `json:"expires_in"` // Seconds
+}
+
+// MagicLinkLoginRequest logs in with the token of the link
+type MagicLinkLoginRequest struct {
+	Token       string `json:"token"`
+	DeviceToken string `json:"device_token"`
+}
+
+// MagicLinkStatus tells the login page whether the magic link login is offered
+type MagicLinkStatus struct {
+	Enabled bool `json:"enabled"`
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/i18n"
+)
+
+// Admin notification types (in-app only)
+const (
+	NotificationTypeAdminExperienceRequest   = "admin_experience_request"
+	NotificationTypeAdminReactivationRequest = "admin_reactivation_request"
+	NotificationTypeAdminRegistrationRequest = "admin_registration_request"
+	NotificationTypeAdminBookingPending      = "admin_booking_pending"
+)
+
+// AdminNotificationPermissions maps admin notification types to the permission of the staff who work the queue
+var AdminNotificationPermissions = map[string]string{
+	NotificationTypeAdminExperienceRequest:   PermissionManageUsers,
+	NotificationTypeAdminReactivationRequest: PermissionManageUsers,
+	NotificationTypeAdminRegistrationRequest: PermissionManageUsers,
+	NotificationTypeAdminBookingPending:      PermissionManageBookings,
+}
+
+// NotificationParamKeyPrefix marks a param that is an i18n key (e.g. "@levels.orange")
+// and translated into the reader's language when rendered
+const NotificationParamKeyPrefix = "@"
+
+// notificationLinks maps notification types to the page they open
+var notificationLinks = map[string]string{
+	"welcome":                                "/dashboard.html",
+	"booking_confirmation":                   "/dashboard.html",
+	"booking_cancellation":                   "/dashboard.html",
+	"admin_cancellation":                     "/dashboard.html",
+	"booking_reminder":                       "/dashboard.html",
+	"booking_moved":                          "/dashboard.html",
+	"booking_approved":                       "/dashboard.html",
+	"booking_rejected":                       "/dashboard.html",
+	"experience_approved":                    "/profile.html",
+	"experience_denied":                      "/profile.html",
+	"account_deactivated":                    "/profile.html",
+	"account_reactivated":                    "/profile.html",
+	"reactivation_denied":                    "/profile.html",
+	NotificationTypeAdminExperienceRequest:   "/admin-experience-requests.html",
+	NotificationTypeAdminReactivationRequest: "/admin-reactivation-requests.html",
+	NotificationTypeAdminRegistrationRequest: "/admin-invitations.html",
+	NotificationTypeAdminBookingPending:      "/admin-booking-approvals.html",
+}
+
+// Notification is an entry in a user's in-app notification center
+// Only the type and params are stored, title and message are rendered in the reader's language
+type Notification struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	Type      string     `json:"type"`
+	Params    []string   `json:"-"`
+	Title     string     `json:"title"`
+	Message   string     `json:"message"`
+	Link      *string    `json:"link,omitempty"`
+	EventID   *int       `json:"-"` // Domain event that caused the notification, recorded once per event
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// NotificationUnreadCountResponse is the response of the unread count endpoint
+type NotificationUnreadCountResponse struct {
+	Count int `json:"count"`
+}
+
+// NotificationLink returns the page a notification type opens, or nil
+func NotificationLink(notificationType string) *string {
+	link, ok := notificationLinks[notificationType]
+	if !ok {
+		return nil
+	}
+	return &link
+}
+
+// Render fills title and message in the given language
+// Titles only use params if they reference them with explicit indexes (e.g. "%[1]s")
+func (n *Notification) Render(lang string) {
+	args := LocalizeNotificationParams(lang, n.Params)
+	n.Title = i18n.T(lang, "notification_center."+n.Type+".title")
+	if strings.Contains(n.Title, "%[") {
+		n.Title = i18n.T(lang, "notification_center."+n.Type+".title", args...)
+	}
+	n.Message = strings.TrimSpace(i18n.T(lang, "notification_center."+n.Type+".message", args...))
+}
+
+// LocalizeNotificationParams translates params marked with NotificationParamKeyPrefix
+func LocalizeNotificationParams(lang string, params []string) []interface{} {
+	args := make([]interface{}, len(params))
+	for i, param := range params {
+		key := strings.TrimPrefix(param, NotificationParamKeyPrefix)
+		if key != param && i18n.Has(lang, key) {
+			args[i] = i18n.T(lang, key)
+			continue
+		}
+		args[i] = param
+	}
+	return args
+}
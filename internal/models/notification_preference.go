@@ -0,0 +1,167 @@
+package models
+
+import "time"
+
+// Notification categories
+const (
+	NotificationCategoryAccount        = "account"         // Verification, password reset, account status (mandatory)
+	NotificationCategoryBookingChanges = "booking_changes" // Admin cancellations, moves, approvals, request decisions (mandatory)
+	NotificationCategoryConfirmations  = "confirmations"   // Confirmations of the user's own bookings and cancellations
+	NotificationCategoryReminders      = "reminders"       // Reminders before a walk
+	NotificationCategoryAnnouncements  = "announcements"   // News and announcements from the shelter
+)
+
+// Notification channels
+const (
+	NotificationChannelEmail    = "email"
+	NotificationChannelSMS      = "sms"
+	NotificationChannelTelegram = "telegram"
+	NotificationChannelPush     = "push"
+)
+
+// NotificationCategory describes a notification category
+type NotificationCategory struct {
+	Key       string
+	Mandatory bool // Mandatory categories cannot be disabled
+}
+
+// NotificationCategories lists all categories in display order
+var NotificationCategories = []NotificationCategory{
+	{Key: NotificationCategoryAccount, Mandatory: true},
+	{Key: NotificationCategoryBookingChanges, Mandatory: true},
+	{Key: NotificationCategoryConfirmations},
+	{Key: NotificationCategoryReminders},
+	{Key: NotificationCategoryAnnouncements},
+}
+
+// NotificationChannels lists all supported channels
+// Email and push are opt-out (subscribing a device is the opt-in), SMS and Telegram are opt-in
+var NotificationChannels = []string{NotificationChannelEmail, NotificationChannelPush, NotificationChannelSMS, NotificationChannelTelegram}
+
+// IsValidNotificationCategory checks if a category exists
+func IsValidNotificationCategory(category string) bool {
+	for _, c := range NotificationCategories {
+		if c.Key == category {
+			return true
+		}
+	}
+	return false
+}
+
+// IsMandatoryNotificationCategory checks if a category cannot be disabled
+func IsMandatoryNotificationCategory(category string) bool {
+	for _, c := range NotificationCategories {
+		if c.Key == category {
+			return c.Mandatory
+		}
+	}
+	return false
+}
+
+// IsValidNotificationChannel checks if a channel exists
+func IsValidNotificationChannel(channel string) bool {
+	for _, c := range NotificationChannels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNotificationCategorySupported checks if a category is delivered on a channel
+// Account notifications (verification, password reset, ...) are only sent by email
+func IsNotificationCategorySupported(category, channel string) bool {
+	return channel == NotificationChannelEmail || category != NotificationCategoryAccount
+}
+
+// IsMandatoryNotification checks if a category cannot be disabled on a channel
+// Only email is mandatory, text channels are always optional
+func IsMandatoryNotification(category, channel string) bool {
+	return channel == NotificationChannelEmail && IsMandatoryNotificationCategory(category)
+}
+
+// IsNotificationEnabledByDefault checks if a channel is enabled without a stored preference
+func IsNotificationEnabledByDefault(channel string) bool {
+	return channel == NotificationChannelEmail || channel == NotificationChannelPush
+}
+
+// NotificationPreference represents a user's stored choice for a category and channel
+// Without a stored preference email and push are enabled, SMS and Telegram are disabled
+type NotificationPreference struct {
+	UserID    int       `json:"user_id"`
+	Category  string    `json:"category"`
+	Channel   string    `json:"channel"`
+	Enabled   bool      `json:"enabled"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NotificationPreferenceResponse describes the effective setting of a category and channel
+type NotificationPreferenceResponse struct {
+	Category  string `json:"category"`
+	Channel   string `json:"channel"`
+	Enabled   bool   `json:"enabled"`
+	Mandatory bool   `json:"mandatory"`
+}
+
+// NotificationPreferenceUpdate represents a single change of a preference
+type NotificationPreferenceUpdate struct {
+	Category string `json:"category"`
+	Channel  string `json:"channel"`
+	Enabled  bool   `json:"enabled"`
+}
+
+// UpdateNotificationPreferencesRequest represents a request to change notification preferences
+type UpdateNotificationPreferencesRequest struct {
+	Preferences []NotificationPreferenceUpdate `json:"preferences"`
+}
+
+// Validate validates the update notification preferences request
+func (r *UpdateNotificationPreferencesRequest) Validate() error {
+	if len(r.Preferences) == 0 {
+		return &ValidationError{Field: "preferences", Message: "At least one preference is required", Code: "preferences_required"}
+	}
+
+	for i := range r.Preferences {
+		p := &r.Preferences[i]
+		if p.Channel == "" {
+			p.Channel = NotificationChannelEmail
+		}
+
+		if !IsValidNotificationCategory(p.Category) {
+			return &ValidationError{Field: "category", Message: "Invalid notification category", Code: "invalid_notification_category"}
+		}
+		if !IsValidNotificationChannel(p.Channel) {
+			return &ValidationError{Field: "channel", Message: "Invalid notification channel", Code: "invalid_notification_channel"}
+		}
+		if !IsNotificationCategorySupported(p.Category, p.Channel) {
+			return &ValidationError{Field: "channel", Message: "This notification category is not available on this channel", Code: "notification_channel_not_supported"}
+		}
+		if !p.Enabled && IsMandatoryNotification(p.Category, p.Channel) {
+			return &ValidationError{Field: "category", Message: "This notification category cannot be disabled", Code: "notification_category_mandatory"}
+		}
+	}
+
+	return nil
+}
+
+// NotificationChannelStatus describes whether a user can be reached on a channel
+type NotificationChannelStatus struct {
+	Channel   string `json:"channel"`
+	Connected bool   `json:"connected"` // Email address, subscribed device, phone number or linked Telegram chat present
+}
+
+// TelegramLinkResponse contains the deep link that connects a Telegram chat to the account
+type TelegramLinkResponse struct {
+	URL string `json:"url"`
+}
+
+// UnsubscribeRequest represents a request to unsubscribe via a signed link
+type UnsubscribeRequest struct {
+	Token string `json:"token"`
+}
+
+// UnsubscribeInfo describes what an unsubscribe token refers to
+type UnsubscribeInfo struct {
+	Category string `json:"category"`
+	Enabled  bool   `json:"enabled"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// UserIdentity links an account at the OpenID Connect provider to a user
+type UserIdentity struct {
+	ID          int
+	UserID      int
+	Issuer      string
+	Subject     string
+	Email       string
+	CreatedAt   time.Time
+	LastLoginAt *time.Time
+}
+
+// OIDCLogin is a login at the identity provider in progress, only hashes of state and login code are stored
+// UserID and LoginCodeHash are set once the provider redirected back and the user was found.
+type OIDCLogin struct {
+	ID            int
+	StateHash     string
+	Nonce         string
+	CodeVerifier  string
+	UserID        *int
+	LoginCodeHash *string
+	ExpiresAt     time.Time
+	CreatedAt     time.Time
+}
+
+// OIDCStatus tells the login page whether the single sign-on is offered and how to label it
+type OIDCStatus struct {
+	Enabled      bool   `json:"enabled"`
+	ProviderName string `json:"provider_name,omitempty"`
+}
+
+// OIDCLoginRequest exchanges the login code of the redirect back to the login page for the tokens
+type OIDCLoginRequest struct {
+	Code string `json:"code"`
+}
@@ -0,0 +1,151 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// WebAuthn ceremonies a challenge is issued for
+const (
+	CeremonyRegistration = "registration"
+	CeremonyLogin        = "login"
+)
+
+// Passkey is a WebAuthn credential a user logs in with
+type Passkey struct {
+	ID           int        `json:"id"`
+	UserID       int        `json:"-"`
+	CredentialID string     `json:"credential_id"` // base64url
+	PublicKey    string     `json:"-"`             // COSE key, base64url
+	SignCount    uint32     `json:"-"`
+	Name         string     `json:"name"`
+	Transports   []string   `json:"transports"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LastUsedAt   *time.Time `json:"last_used_at,omitempty"`
+}
+
+// WebAuthnChallenge is the challenge of a registration or login ceremony, UserID is nil for logins
+type WebAuthnChallenge struct {
+	ID        int
+	UserID    *int
+	Challenge string // base64url, as returned in clientDataJSON
+	Ceremony  string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// PasskeyCredential is the PublicKeyCredential returned by navigator.credentials, binary fields base64url encoded
+type PasskeyCredential struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string   `json:"clientDataJSON"`
+		AttestationObject string   `json:"attestationObject"` // Registration
+		Transports        []string `json:"transports"`        // Registration
+		AuthenticatorData string   `json:"authenticatorData"` // Login
+		Signature         string   `json:"signature"`         // Login
+		UserHandle        string   `json:"userHandle"`        // Login
+	} `json:"response"`
+}
+
+// PasskeyRegistrationRequest finishes adding a passkey
+type PasskeyRegistrationRequest struct {
+	Name       string            `json:"name"`
+	Credential PasskeyCredential `json:"credential"`
+}
+
+// Validate validates the passkey registration request
+func (r *PasskeyRegistrationRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		r.Name = "Passkey"
+	}
+	if len(r.Name) > 100 {
+		return &ValidationError{Field: "name", Message: "Name must be at most 100 characters", Code: "passkey_name_too_long"}
+	}
+	return nil
+}
+
+// PasskeyLoginRequest finishes a login with a passkey
+type PasskeyLoginRequest struct {
+	Credential PasskeyCredential `json:"credential"`
+}
+
+// RenamePasskeyRequest changes the name of a passkey
+type RenamePasskeyRequest struct {
+	Name string `json:"name"`
+}
+
+// Validate validates the rename request
+func (r *RenamePasskeyRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return &ValidationError{Field: "name", Message: "Name is required", Code: "passkey_name_required"}
+	}
+	if len(r.Name) > 100 {
+		return &ValidationError{Field: "name", Message: "Name must be at most 100 characters", Code: "passkey_name_too_long"}
+	}
+	return nil
+}
+
+// PasskeyOptions wraps the options for navigator.credentials.create or get, passed as publicKey
+// Binary fields are base64url encoded, the client decodes them.
+type PasskeyOptions struct {
+	PublicKey interface{} `json:"publicKey"`
+}
+
+// PasskeyCreationOptions are the PublicKeyCredentialCreationOptions of a registration
+type PasskeyCreationOptions struct {
+	Challenge              string                        `json:"challenge"`
+	RP                     PasskeyRelyingParty           `json:"rp"`
+	User                   PasskeyUserEntity             `json:"user"`
+	PubKeyCredParams       []PasskeyCredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int                           `json:"timeout"` // Milliseconds
+	Attestation            string                        `json:"attestation"`
+	AuthenticatorSelection PasskeyAuthenticatorSelection `json:"authenticatorSelection"`
+	ExcludeCredentials     []PasskeyCredentialDescriptor `json:"excludeCredentials"`
+}
+
+// PasskeyRequestOptions are the PublicKeyCredentialRequestOptions of a login
+// AllowCredentials stays empty, the browser offers all passkeys of the site.
+type PasskeyRequestOptions struct {
+	Challenge        string                        `json:"challenge"`
+	RPID             string                        `json:"rpId"`
+	Timeout          int                           `json:"timeout"` // Milliseconds
+	UserVerification string                        `json:"userVerification"`
+	AllowCredentials []PasskeyCredentialDescriptor `json:"allowCredentials"`
+}
+
+// PasskeyRelyingParty identifies the site
+type PasskeyRelyingParty struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// PasskeyUserEntity identifies the account in the authenticator, ID is the base64url user handle
+type PasskeyUserEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// PasskeyCredentialParameter is an accepted signature algorithm (COSE algorithm identifier)
+type PasskeyCredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+// PasskeyAuthenticatorSelection requires discoverable credentials with user verification
+type PasskeyAuthenticatorSelection struct {
+	ResidentKey        string `json:"residentKey"`
+	RequireResidentKey bool   `json:"requireResidentKey"`
+	UserVerification   string `json:"userVerification"`
+}
+
+// PasskeyCredentialDescriptor refers to an existing passkey
+type PasskeyCredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// PushSubscription represents a browser/device subscribed to Web Push notifications
+type PushSubscription struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	Endpoint   string     `json:"endpoint"`
+	P256dh     string     `json:"-"` // Browser public key (base64url, uncompressed P-256 point)
+	Auth       string     `json:"-"` // Browser auth secret (base64url, 16 bytes)
+	UserAgent  *string    `json:"user_agent,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// PushServiceHosts are the push services of the browsers, subscriptions may only point to these
+// The server posts to the endpoint, so any other host (e.g. an internal address) is rejected.
+var PushServiceHosts = []string{
+	"fcm.googleapis.com",        // Chrome, Edge (Android), Opera
+	"push.services.mozilla.com", // Firefox
+	"notify.windows.com",        // Edge (Windows)
+	"push.apple.com",            // Safari
+}
+
+// IsPushServiceHost checks whether host is one of PushServiceHosts or a subdomain of one
+func IsPushServiceHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, allowed := range PushServiceHosts {
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// CreatePushSubscriptionRequest is the PushSubscription object from the browser (PushSubscription.toJSON())
+type CreatePushSubscriptionRequest struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+// Validate validates the push subscription
+func (r *CreatePushSubscriptionRequest) Validate() error {
+	invalid := &ValidationError{Field: "endpoint", Message: "Invalid push subscription", Code: "invalid_push_subscription"}
+
+	endpoint, err := url.Parse(r.Endpoint)
+	if err != nil || endpoint.Scheme != "https" || endpoint.Port() != "" || !IsPushServiceHost(endpoint.Hostname()) || len(r.Endpoint) > 500 {
+		return invalid
+	}
+
+	if key, err := DecodePushKey(r.Keys.P256dh); err != nil || len(key) != 65 || key[0] != 0x04 {
+		invalid.Field = "keys.p256dh"
+		return invalid
+	}
+
+	if secret, err := DecodePushKey(r.Keys.Auth); err != nil || len(secret) != 16 {
+		invalid.Field = "keys.auth"
+		return invalid
+	}
+
+	return nil
+}
+
+// DecodePushKey decodes a base64url key from a push subscription (padding optional)
+func DecodePushKey(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
+
+// VAPIDPublicKeyResponse contains the application server key for PushManager.subscribe()
+type VAPIDPublicKeyResponse struct {
+	PublicKey string `json:"public_key"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// RateLimitPolicy limits the requests of a client IP to a group of routes
+// Requests are counted per policy, so routes sharing a policy share the limit.
+type RateLimitPolicy struct {
+	Name   string
+	Limit  int           // Requests allowed per window
+	Window time.Duration // Fixed window, starting with the first request
+	Code   string        // Error code returned when the limit is exceeded
+}
+
+// Rate limit policies of the public auth routes
+var (
+	RateLimitLogin        = RateLimitPolicy{Name: "login", Limit: 5, Window: time.Minute, Code: "too_many_login_attempts"}
+	RateLimitTwoFactor    = RateLimitPolicy{Name: "two_factor", Limit: 5, Window: time.Minute, Code: "too_many_login_attempts"}
+	RateLimitPasskey      = RateLimitPolicy{Name: "passkey", Limit: 10, Window: time.Minute, Code: "too_many_login_attempts"}
+	RateLimitMagicLink    = RateLimitPolicy{Name: "magic_link", Limit: 5, Window: time.Minute, Code: "too_many_login_attempts"}
+	RateLimitOIDC         = RateLimitPolicy{Name: "oidc", Limit: 10, Window: time.Minute, Code: "too_many_login_attempts"}
+	RateLimitRegister     = RateLimitPolicy{Name: "register", Limit: 5, Window: time.Hour, Code: "too_many_requests"}
+	RateLimitVerifyEmail  = RateLimitPolicy{Name: "verify_email", Limit: 10, Window: 15 * time.Minute, Code: "too_many_requests"}
+	RateLimitPassword     = RateLimitPolicy{Name: "password_reset", Limit: 5, Window: 15 * time.Minute, Code: "too_many_requests"}
+	RateLimitReactivation = RateLimitPolicy{Name: "reactivation", Limit: 3, Window: time.Hour, Code: "too_many_requests"}
+)
+
+// LoginLockout counts the failed password logins of an account
+// AccountKey is the SHA-256 hash of the normalized email address, so unknown addresses are not stored in plain text.
+type LoginLockout struct {
+	AccountKey    string
+	Failures      int
+	LockedUntil   *time.Time
+	LastFailureAt time.Time
+}
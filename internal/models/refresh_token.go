@@ -0,0 +1,39 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// RefreshToken is a stored refresh token, only the hash of the token is kept
+// Each refresh replaces the token with a new one of the same family (one family per login).
+type RefreshToken struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	TokenHash string     `json:"-"`
+	FamilyID  string     `json:"family_id"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`    // Set when the token was exchanged for a new one
+	RevokedAt *time.Time `json:"revoked_at,omitempty"` // Set on logout and revocation
+}
+
+// HashRefreshToken returns the stored form of a refresh token
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// TokenPair is a short-lived access token (JWT) with the refresh token to renew it
+type TokenPair struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // Lifetime of the access token in seconds
+	NewDevice    bool   `json:"-"`          // The login is from a device the user did not log in with before
+}
+
+// RefreshTokenRequest is the payload to refresh the access token or to log out
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
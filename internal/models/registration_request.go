@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// RegistrationRequest is a registration waiting for an admin in the approval_required mode
+// The account is inactive until the request is approved, a denied account is deleted.
+type RegistrationRequest struct {
+	ID           int        `json:"id"`
+	UserID       int        `json:"user_id"`
+	Status       string     `json:"status"`
+	AdminMessage *string    `json:"admin_message,omitempty"`
+	ReviewedBy   *int       `json:"reviewed_by,omitempty"`
+	ReviewedAt   *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+
+	// Joined data for responses
+	User *User `json:"user,omitempty"`
+}
+
+// ReviewRegistrationRequestRequest represents a request to approve or deny a registration
+type ReviewRegistrationRequestRequest struct {
+	Message *string `json:"message,omitempty"`
+}
@@ -0,0 +1,193 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Reminder settings
+const (
+	SettingBookingReminders      = "booking_reminders"        // Default schedule, e.g. "1d@18:00,1h"
+	SettingReminderMaxCount      = "reminder_max_count"       // Maximum reminders per booking in a personal schedule
+	SettingReminderMaxDaysBefore = "reminder_max_days_before" // Earliest reminder a user may choose
+)
+
+// Reminder defaults used when settings are missing
+const (
+	DefaultBookingReminders      = "1d@18:00,1h"
+	DefaultReminderMaxCount      = 3
+	DefaultReminderMaxDaysBefore = 3
+
+	// MinReminderMinutesBefore matches the reminder cron interval
+	MinReminderMinutesBefore = 15
+)
+
+var (
+	reminderAtPattern       = regexp.MustCompile(`^(\d+)d@([01]\d|2[0-3]):([0-5]\d)$`)
+	reminderDurationPattern = regexp.MustCompile(`^(\d+)([mhd])$`)
+)
+
+// ReminderOffset describes when a reminder is sent relative to a walk
+// Either at a fixed time a number of days before ("1d@18:00")
+// or a fixed duration before ("1h", "90m", "2d")
+type ReminderOffset struct {
+	DaysBefore int           // Days before the walk (fixed time reminders)
+	At         string        // Time of day "HH:MM" (fixed time reminders)
+	Before     time.Duration // Duration before the walk (relative reminders)
+}
+
+// ParseReminderOffset parses a single reminder like "1d@18:00" or "1h"
+func ParseReminderOffset(value string) (ReminderOffset, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	if m := reminderAtPattern.FindStringSubmatch(value); m != nil {
+		days, _ := strconv.Atoi(m[1])
+		return ReminderOffset{DaysBefore: days, At: m[2] + ":" + m[3]}, nil
+	}
+
+	if m := reminderDurationPattern.FindStringSubmatch(value); m != nil {
+		amount, _ := strconv.Atoi(m[1])
+		unit := map[string]time.Duration{"m": time.Minute, "h": time.Hour, "d": 24 * time.Hour}[m[2]]
+		if amount <= 0 {
+			return ReminderOffset{}, fmt.Errorf("invalid reminder %q", value)
+		}
+		return ReminderOffset{Before: time.Duration(amount) * unit}, nil
+	}
+
+	return ReminderOffset{}, fmt.Errorf("invalid reminder %q", value)
+}
+
+// Key returns the normalized form, used to track sent reminders
+func (o ReminderOffset) Key() string {
+	if o.At != "" {
+		return fmt.Sprintf("%dd@%s", o.DaysBefore, o.At)
+	}
+
+	minutes := int(o.Before / time.Minute)
+	switch {
+	case minutes%(24*60) == 0:
+		return fmt.Sprintf("%dd", minutes/(24*60))
+	case minutes%60 == 0:
+		return fmt.Sprintf("%dh", minutes/60)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
+
+// DueAt returns when the reminder for a walk starting at start is due
+func (o ReminderOffset) DueAt(start time.Time) time.Time {
+	if o.At != "" {
+		hour, _ := strconv.Atoi(o.At[:2])
+		minute, _ := strconv.Atoi(o.At[3:])
+		day := start.AddDate(0, 0, -o.DaysBefore)
+		return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, start.Location())
+	}
+	return start.Add(-o.Before)
+}
+
+// ReminderSchedule is an ordered list of reminders for a booking
+type ReminderSchedule []ReminderOffset
+
+// ParseReminderSchedule parses a comma-separated schedule like "1d@18:00,1h"
+// Duplicates are removed, an empty string is an empty schedule
+func ParseReminderSchedule(value string) (ReminderSchedule, error) {
+	schedule := ReminderSchedule{}
+	if strings.TrimSpace(value) == "" {
+		return schedule, nil
+	}
+
+	return ParseReminderList(strings.Split(value, ","))
+}
+
+// ParseReminderList parses a list of reminders like ["1d@18:00", "1h"]
+func ParseReminderList(values []string) (ReminderSchedule, error) {
+	schedule := ReminderSchedule{}
+	seen := make(map[string]bool)
+
+	for _, value := range values {
+		offset, err := ParseReminderOffset(value)
+		if err != nil {
+			return nil, err
+		}
+		if seen[offset.Key()] {
+			continue
+		}
+		seen[offset.Key()] = true
+		schedule = append(schedule, offset)
+	}
+
+	return schedule, nil
+}
+
+// Keys returns the normalized reminders
+func (s ReminderSchedule) Keys() []string {
+	keys := make([]string, len(s))
+	for i, offset := range s {
+		keys[i] = offset.Key()
+	}
+	return keys
+}
+
+// String returns the schedule in its stored form
+func (s ReminderSchedule) String() string {
+	return strings.Join(s.Keys(), ",")
+}
+
+// ReminderLimits restricts personal reminder schedules
+type ReminderLimits struct {
+	MaxCount         int `json:"max_count"`
+	MaxDaysBefore    int `json:"max_days_before"`
+	MinMinutesBefore int `json:"min_minutes_before"`
+}
+
+// Validate checks the schedule against the limits
+func (s ReminderSchedule) Validate(limits ReminderLimits) error {
+	if len(s) > limits.MaxCount {
+		return &ValidationError{Field: "reminders", Message: fmt.Sprintf("At most %d reminders are allowed", limits.MaxCount), Code: "too_many_reminders", Args: []interface{}{limits.MaxCount}}
+	}
+
+	minBefore := time.Duration(limits.MinMinutesBefore) * time.Minute
+	maxBefore := time.Duration(limits.MaxDaysBefore) * 24 * time.Hour
+
+	for _, offset := range s {
+		tooEarly := offset.DaysBefore > limits.MaxDaysBefore
+		if offset.At == "" {
+			tooEarly = offset.Before > maxBefore
+		}
+		if tooEarly || (offset.At == "" && offset.Before < minBefore) {
+			return &ValidationError{
+				Field:   "reminders",
+				Message: fmt.Sprintf("Reminders must be between %d minutes and %d days before the walk", limits.MinMinutesBefore, limits.MaxDaysBefore),
+				Code:    "reminder_out_of_range",
+				Args:    []interface{}{limits.MinMinutesBefore, limits.MaxDaysBefore},
+			}
+		}
+	}
+
+	return nil
+}
+
+// ReminderScheduleResponse describes the reminders a user receives
+type ReminderScheduleResponse struct {
+	Reminders []string       `json:"reminders"`
+	IsDefault bool           `json:"is_default"`
+	Default   []string       `json:"default"`
+	Limits    ReminderLimits `json:"limits"`
+}
+
+// UpdateReminderScheduleRequest represents a request to set a personal reminder schedule
+type UpdateReminderScheduleRequest struct {
+	Reminders []string `json:"reminders"`
+}
+
+// Parse validates the format of the request and returns the schedule
+func (r *UpdateReminderScheduleRequest) Parse() (ReminderSchedule, error) {
+	schedule, err := ParseReminderList(r.Reminders)
+	if err != nil {
+		return nil, &ValidationError{Field: "reminders", Message: "Invalid reminder format (examples: 1d@18:00, 2h, 30m)", Code: "invalid_reminder_format"}
+	}
+	return schedule, nil
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseReminderOffset tests parsing and normalizing single reminders
+func TestParseReminderOffset(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantKey string
+		wantErr bool
+	}{
+		{"1d@18:00", "1d@18:00", false},
+		{" 0d@07:30 ", "0d@07:30", false},
+		{"1h", "1h", false},
+		{"60m", "1h", false},
+		{"90m", "90m", false},
+		{"48h", "2d", false},
+		{"2D", "2d", false},
+		{"0h", "", true},
+		{"1d@24:00", "", true},
+		{"tomorrow", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			offset, err := ParseReminderOffset(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseReminderOffset() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && offset.Key() != tt.wantKey {
+				t.Errorf("Expected key %q, got %q", tt.wantKey, offset.Key())
+			}
+		})
+	}
+}
+
+// TestReminderOffset_DueAt tests computing when a reminder is due
+func TestReminderOffset_DueAt(t *testing.T) {
+	start := time.Date(2025, 12, 10, 9, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{"1d@18:00", time.Date(2025, 12, 9, 18, 0, 0, 0, time.Local)},
+		{"0d@07:00", time.Date(2025, 12, 10, 7, 0, 0, 0, time.Local)},
+		{"1h", time.Date(2025, 12, 10, 8, 0, 0, 0, time.Local)},
+		{"2d", time.Date(2025, 12, 8, 9, 0, 0, 0, time.Local)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			offset, _ := ParseReminderOffset(tt.input)
+			if got := offset.DueAt(start); !got.Equal(tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestReminderSchedule_Validate tests limits for personal schedules
+func TestReminderSchedule_Validate(t *testing.T) {
+	limits := ReminderLimits{MaxCount: 2, MaxDaysBefore: 2, MinMinutesBefore: 15}
+
+	tests := []struct {
+		name     string
+		schedule string
+		wantCode string
+	}{
+		{"valid", "1d@18:00,1h", ""},
+		{"empty", "", ""},
+		{"duplicates count once", "1h,60m,1d@18:00", ""},
+		{"too many", "1d@18:00,2h,1h", "too_many_reminders"},
+		{"too short", "10m", "reminder_out_of_range"},
+		{"too early", "3d@18:00", "reminder_out_of_range"},
+		{"too early duration", "49h", "reminder_out_of_range"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseReminderSchedule(tt.schedule)
+			if err != nil {
+				t.Fatalf("ParseReminderSchedule() failed: %v", err)
+			}
+
+			err = schedule.Validate(limits)
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+
+			validationErr, ok := err.(*ValidationError)
+			if !ok || validationErr.Code != tt.wantCode {
+				t.Errorf("Expected code %s, got %v", tt.wantCode, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package models
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Permissions grant access to groups of staff endpoints
+const (
+	PermissionManageDogs          = "dogs.manage"          // Create, edit, delete dogs, photos, availability, featured
+	PermissionManageBookings      = "bookings.manage"      // See all bookings, approve, reject, move and cancel them
+	PermissionManageCalendar      = "calendar.manage"      // Blocked dates, booking time rules, holidays
+	PermissionManageUsers         = "users.manage"         // Users, tags, experience and reactivation requests
+	PermissionManageAnnouncements = "announcements.manage" // Announcements to user segments
+	PermissionManageSettings      = "settings.manage"      // System settings, email templates, webhooks
+	PermissionViewDashboard       = "dashboard.view"       // Statistics, activity log, run sheet
+	PermissionManageRoles         = "roles.manage"         // Roles, role assignments, promoting and demoting admins, resetting 2FA
+	PermissionViewAuditLog        = "audit.view"           // Audit log of admin actions
+)
+
+// AllPermissions lists every permission, in display order
+var AllPermissions = []string{
+	PermissionManageDogs,
+	PermissionManageBookings,
+	PermissionManageCalendar,
+	PermissionManageUsers,
+	PermissionManageAnnouncements,
+	PermissionManageSettings,
+	PermissionViewDashboard,
+	PermissionManageRoles,
+	PermissionViewAuditLog,
+}
+
+// Built-in roles, they cannot be changed or deleted
+const (
+	RoleSuperAdmin  = "super_admin"
+	RoleAdmin       = "admin"
+	RoleKennelStaff = "kennel_staff"
+	RoleScheduler   = "scheduler"
+)
+
+// BuiltinRolePermissions are the permissions of the built-in roles
+// super_admin and admin follow the is_super_admin and is_admin flags of a user and are not assigned through roles.
+var BuiltinRolePermissions = map[string][]string{
+	RoleSuperAdmin: AllPermissions,
+	RoleAdmin: {
+		PermissionManageDogs,
+		PermissionManageBookings,
+		PermissionManageCalendar,
+		PermissionManageUsers,
+		PermissionManageAnnouncements,
+		PermissionManageSettings,
+		PermissionViewDashboard,
+	},
+	RoleKennelStaff: {PermissionManageDogs},
+	RoleScheduler:   {PermissionManageBookings},
+}
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,49}$`)
+
+// Role is a named set of permissions
+type Role struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	IsBuiltin   bool      `json:"is_builtin"`
+	Permissions []string  `json:"permissions"`
+	UserCount   int       `json:"user_count"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// IsFlagRole reports whether the role follows the admin flags instead of role assignments
+func IsFlagRole(name string) bool {
+	return name == RoleSuperAdmin || name == RoleAdmin
+}
+
+// FlagRoles returns the built-in roles a user holds through the is_admin and is_super_admin flags
+func FlagRoles(isAdmin, isSuperAdmin bool) []string {
+	switch {
+	case isSuperAdmin:
+		return []string{RoleSuperAdmin}
+	case isAdmin:
+		return []string{RoleAdmin}
+	}
+	return nil
+}
+
+// BuiltinRolesWith returns the built-in roles that grant the permission, including admin and super_admin
+func BuiltinRolesWith(permission string) []string {
+	roles := []string{}
+	for _, name := range []string{RoleSuperAdmin, RoleAdmin, RoleKennelStaff, RoleScheduler} {
+		for _, granted := range BuiltinRolePermissions[name] {
+			if granted == permission {
+				roles = append(roles, name)
+				break
+			}
+		}
+	}
+	return roles
+}
+
+// PermissionsOf returns the sorted, de-duplicated permissions of the roles
+func PermissionsOf(roles []*Role) []string {
+	set := map[string]bool{}
+	for _, role := range roles {
+		for _, permission := range role.Permissions {
+			set[permission] = true
+		}
+	}
+
+	permissions := []string{}
+	for permission := range set {
+		permissions = append(permissions, permission)
+	}
+	sort.Strings(permissions)
+	return permissions
+}
+
+// RoleRequest is the payload to create or update a custom role
+type RoleRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// Validate validates the request and normalizes name and permissions
+func (r *RoleRequest) Validate() error {
+	r.Name = strings.ToLower(strings.TrimSpace(r.Name))
+	if !roleNamePattern.MatchString(r.Name) {
+		return &ValidationError{Field: "name", Message: "Name must be 2-50 lowercase letters, digits or underscores", Code: "invalid_role_name"}
+	}
+	if _, builtin := BuiltinRolePermissions[r.Name]; builtin {
+		return &ValidationError{Field: "name", Message: "Name is reserved for a built-in role", Code: "role_name_reserved"}
+	}
+
+	r.Description = strings.TrimSpace(r.Description)
+	if len(r.Description) > 255 {
+		return &ValidationError{Field: "description", Message: "Description must be at most 255 characters", Code: "invalid_role_description"}
+	}
+
+	if len(r.Permissions) == 0 {
+		return &ValidationError{Field: "permissions", Message: "At least one permission is required", Code: "role_permissions_required"}
+	}
+	set := map[string]bool{}
+	for _, permission := range r.Permissions {
+		if !IsPermission(permission) {
+			return &ValidationError{Field: "permissions", Message: "Unknown permission: " + permission, Code: "invalid_permission", Args: []interface{}{permission}}
+		}
+		// Would allow custom roles to grant themselves more permissions
+		if permission == PermissionManageRoles || permission == PermissionViewAuditLog {
+			return &ValidationError{Field: "permissions", Message: "Permission is reserved for the super admin: " + permission, Code: "permission_not_assignable", Args: []interface{}{permission}}
+		}
+		set[permission] = true
+	}
+	r.Permissions = r.Permissions[:0]
+	for _, permission := range AllPermissions {
+		if set[permission] {
+			r.Permissions = append(r.Permissions, permission)
+		}
+	}
+	return nil
+}
+
+// IsPermission reports whether the permission exists
+func IsPermission(permission string) bool {
+	for _, known := range AllPermissions {
+		if known == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateUserRolesRequest replaces the assigned roles of a user
+type UpdateUserRolesRequest struct {
+	RoleIDs []int `json:"role_ids"`
+}
+
+// UserRolesResponse lists the roles of a user and the permissions they grant
+type UserRolesResponse struct {
+	Roles       []*Role  `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestRoleRequest_Validate tests validating custom roles
+func TestRoleRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		req  RoleRequest
+		code string
+	}{
+		{"valid", RoleRequest{Name: "vet", Permissions: []string{PermissionManageDogs}}, ""},
+		{"invalid name", RoleRequest{Name: "Vet Staff!", Permissions: []string{PermissionManageDogs}}, "invalid_role_name"},
+		{"too short", RoleRequest{Name: "v", Permissions: []string{PermissionManageDogs}}, "invalid_role_name"},
+		{"reserved name", RoleRequest{Name: "scheduler", Permissions: []string{PermissionManageDogs}}, "role_name_reserved"},
+		{"no permissions", RoleRequest{Name: "vet"}, "role_permissions_required"},
+		{"unknown permission", RoleRequest{Name: "vet", Permissions: []string{"dogs.delete"}}, "invalid_permission"},
+		{"super admin permission", RoleRequest{Name: "vet", Permissions: []string{PermissionManageRoles}}, "permission_not_assignable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if tt.code == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			validationErr, ok := err.(*ValidationError)
+			if !ok || validationErr.Code != tt.code {
+				t.Errorf("Expected %s, got %v", tt.code, err)
+			}
+		})
+	}
+
+	req := RoleRequest{Name: " Front_Desk ", Permissions: []string{PermissionViewDashboard, PermissionManageBookings, PermissionViewDashboard}}
+	if err := req.Validate(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if req.Name != "front_desk" || !reflect.DeepEqual(req.Permissions, []string{PermissionManageBookings, PermissionViewDashboard}) {
+		t.Errorf("Expected normalized name and permissions, got %s %v", req.Name, req.Permissions)
+	}
+}
+
+// TestPermissionsOf tests combining the permissions of several roles
+func TestPermissionsOf(t *testing.T) {
+	roles := []*Role{
+		{Name: RoleKennelStaff, Permissions: BuiltinRolePermissions[RoleKennelStaff]},
+		{Name: "front_desk", Permissions: []string{PermissionManageDogs, PermissionViewDashboard}},
+	}
+	if got := PermissionsOf(roles); !reflect.DeepEqual(got, []string{PermissionViewDashboard, PermissionManageDogs}) {
+		t.Errorf("Expected sorted unique permissions, got %v", got)
+	}
+	if got := PermissionsOf(nil); got == nil || len(got) != 0 {
+		t.Errorf("Expected an empty list, got %v", got)
+	}
+	if got := FlagRoles(true, true); !reflect.DeepEqual(got, []string{RoleSuperAdmin}) {
+		t.Errorf("Expected super admins to hold only super_admin, got %v", got)
+	}
+}
+
+// TestBuiltinRolesWith tests finding the built-in roles of a permission, e.g. for admin notifications
+func TestBuiltinRolesWith(t *testing.T) {
+	if got := BuiltinRolesWith(PermissionManageBookings); !reflect.DeepEqual(got, []string{RoleSuperAdmin, RoleAdmin, RoleScheduler}) {
+		t.Errorf("Unexpected roles for bookings.manage: %v", got)
+	}
+	if got := BuiltinRolesWith(PermissionManageRoles); !reflect.DeepEqual(got, []string{RoleSuperAdmin}) {
+		t.Errorf("Unexpected roles for roles.manage: %v", got)
+	}
+
+	for _, notificationType := range []string{NotificationTypeAdminExperienceRequest, NotificationTypeAdminReactivationRequest,
+		NotificationTypeAdminRegistrationRequest, NotificationTypeAdminBookingPending} {
+		if _, ok := AdminNotificationPermissions[notificationType]; !ok {
+			t.Errorf("Expected a permission for %s", notificationType)
+		}
+	}
+}
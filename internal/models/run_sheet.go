@@ -0,0 +1,79 @@
+package models
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Run sheet digest settings
+const (
+	SettingRunSheetRecipients = "run_sheet_recipients" // Comma separated emails, empty = digest disabled
+	SettingRunSheetSendTime   = "run_sheet_send_time"  // Time of day "HH:MM" or "off"
+)
+
+// Run sheet defaults used when settings are missing
+const (
+	DefaultRunSheetSendTime = "06:30"
+	RunSheetSendTimeOff     = "off"
+)
+
+var runSheetSendTimePattern = regexp.MustCompile(`^([01]\d|2[0-3]):([0-5]\d)$`)
+
+// RunSheet is the daily overview for shelter staff
+type RunSheet struct {
+	Date             string          `json:"date"` // YYYY-MM-DD
+	Walks            []*RunSheetWalk `json:"walks"`
+	PendingApprovals []*RunSheetWalk `json:"pending_approvals"`
+	UnwalkedDogs     []*RunSheetDog  `json:"unwalked_dogs"`
+	GeneratedAt      time.Time       `json:"generated_at"`
+}
+
+// RunSheetWalk is a booked walk with the walker's contact details
+type RunSheetWalk struct {
+	BookingID       int     `json:"booking_id"`
+	DogID           int     `json:"dog_id"`
+	DogName         string  `json:"dog_name"`
+	Date            string  `json:"date"`
+	ScheduledTime   string  `json:"scheduled_time"`
+	Status          string  `json:"status"`
+	WalkerName      string  `json:"walker_name"`
+	WalkerPhone     *string `json:"walker_phone,omitempty"`
+	ExperienceLevel string  `json:"experience_level"`
+}
+
+// RunSheetDog is an available dog without a walk on the run sheet's date
+type RunSheetDog struct {
+	ID           int     `json:"id"`
+	Name         string  `json:"name"`
+	Category     string  `json:"category"`
+	LastWalkDate *string `json:"last_walk_date,omitempty"` // Last completed walk, nil = never walked
+}
+
+// ParseRunSheetRecipients parses a comma separated list of email addresses
+func ParseRunSheetRecipients(value string) ([]string, error) {
+	recipients := []string{}
+	for _, part := range strings.Split(value, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		address, err := mail.ParseAddress(part)
+		if err != nil || address.Address != part {
+			return nil, fmt.Errorf("invalid email address %q", part)
+		}
+		recipients = append(recipients, part)
+	}
+	return recipients, nil
+}
+
+// ParseRunSheetSendTime validates a send time "HH:MM" or "off"
+func ParseRunSheetSendTime(value string) (string, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == RunSheetSendTimeOff || runSheetSendTimePattern.MatchString(value) {
+		return value, nil
+	}
+	return "", fmt.Errorf("invalid send time %q", value)
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"testing"
+)
+
+// TestParseRunSheetRecipients tests parsing the digest recipient list
+func TestParseRunSheetRecipients(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"staff@example.com", 1, false},
+		{" Staff@Example.com , office@example.com,", 2, false},
+		{"staff@example.com, not-an-email", 0, true},
+		{"Staff <staff@example.com>", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			recipients, err := ParseRunSheetRecipients(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRunSheetRecipients() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && len(recipients) != tt.want {
+				t.Errorf("Expected %d recipients, got %v", tt.want, recipients)
+			}
+		})
+	}
+
+	recipients, _ := ParseRunSheetRecipients("Staff@Example.com")
+	if recipients[0] != "staff@example.com" {
+		t.Errorf("Expected lowercased address, got %q", recipients[0])
+	}
+}
+
+// TestParseRunSheetSendTime tests validating the digest send time
+func TestParseRunSheetSendTime(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"06:30", "06:30", false},
+		{" 23:59 ", "23:59", false},
+		{"OFF", "off", false},
+		{"6:30", "", true},
+		{"24:00", "", true},
+		{"morning", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseRunSheetSendTime(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRunSheetSendTime() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
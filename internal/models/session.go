@@ -0,0 +1,85 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// UserSession is a login of a user on a device, it lasts as long as the refresh tokens of the login
+// Sessions are listed to the user, who can end them, e.g. on a lost phone.
+type UserSession struct {
+	ID         int       `json:"id"`
+	UserID     int       `json:"user_id"`
+	FamilyID   string    `json:"-"`
+	Device     string    `json:"device"` // Browser and operating system, empty if unknown
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"` // Login or last refresh of the access token
+	Current    bool      `json:"current"`      // The session of the request
+}
+
+// SessionClient describes the device of a login or refresh
+type SessionClient struct {
+	UserAgent string
+	IPAddress string
+}
+
+// maxUserAgentLength is the stored length of user agents, longer ones are cut
+const maxUserAgentLength = 512
+
+// Browsers and operating systems recognized in user agents, checked in order
+// Most browsers also name the engine of others (Edge claims Chrome and Safari), so specific ones come first.
+var (
+	userAgentBrowsers = [][2]string{
+		{"Edg", "Edge"},
+		{"OPR/", "Opera"},
+		{"SamsungBrowser", "Samsung Internet"},
+		{"Firefox/", "Firefox"},
+		{"FxiOS", "Firefox"},
+		{"CriOS", "Chrome"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	}
+	userAgentSystems = [][2]string{
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Android", "Android"},
+		{"Windows", "Windows"},
+		{"CrOS", "ChromeOS"},
+		{"Mac OS X", "macOS"},
+		{"Linux", "Linux"},
+	}
+)
+
+// NewSessionClient returns the client with the user agent cut to the stored length
+func NewSessionClient(userAgent, ipAddress string) SessionClient {
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	return SessionClient{UserAgent: userAgent, IPAddress: ipAddress}
+}
+
+// Device returns browser and operating system of the client, e.g. "Firefox (Windows)"
+// Logins from a device not seen before are reported to the user by email.
+func (c SessionClient) Device() string {
+	browser := matchUserAgent(c.UserAgent, userAgentBrowsers)
+	system := matchUserAgent(c.UserAgent, userAgentSystems)
+	switch {
+	case browser != "" && system != "":
+		return browser + " (" + system + ")"
+	case browser != "":
+		return browser
+	default:
+		return system
+	}
+}
+
+func matchUserAgent(userAgent string, names [][2]string) string {
+	for _, name := range names {
+		if strings.Contains(userAgent, name[0]) {
+			return name[1]
+		}
+	}
+	return ""
+}
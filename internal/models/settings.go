@@ -17,7 +17,7 @@ type UpdateSettingRequest struct {
 // Validate validates the update setting request
 func (r *UpdateSettingRequest) Validate() error {
 	if r.Value == "" {
-		return &ValidationError{Field: "value", Message: "Value is required"}
+		return &ValidationError{Field: "value", Message: "Value is required", Code: "value_required"}
 	}
 
 	return nil
@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// TwoFactor is the TOTP secret of a user, EnabledAt is nil until the first code was confirmed
+type TwoFactor struct {
+	UserID       int
+	Secret       string // Base32, as shown to the user
+	EnabledAt    *time.Time
+	LastUsedStep int64 // Time step of the last accepted code, older and equal steps are rejected
+	CreatedAt    time.Time
+}
+
+// TwoFactorChallenge is the second login step of a user whose password was checked
+type TwoFactorChallenge struct {
+	ID        int
+	UserID    int
+	TokenHash string
+	Attempts  int
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// TwoFactorStatus is the two-factor state of the user's own account
+type TwoFactorStatus struct {
+	Enabled                bool `json:"enabled"`
+	Required               bool `json:"required"` // Staff cannot disable 2FA
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorSetup is the new secret for the authenticator app
+type TwoFactorSetup struct {
+	Secret          string `json:"secret"`           // For manual entry
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth:// URI
+	QRCode          string `json:"qr_code"`          // QR code of the URI as SVG data URI
+}
+
+// TwoFactorCodeRequest carries a code of the authenticator app or a recovery code
+type TwoFactorCodeRequest struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+	Password     string `json:"password"` // Required to disable 2FA
+}
+
+// TwoFactorChallengeRequest is the second login step
+type TwoFactorChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+}
+
+// TwoFactorChallengeResponse is returned by login instead of tokens when a second step is needed
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired      bool   `json:"two_factor_required"`       // Enter a code of the authenticator app
+	TwoFactorSetupRequired bool   `json:"two_factor_setup_required"` // Staff without 2FA set it up first
+	ChallengeToken         string `json:"challenge_token"`
+	ExpiresIn              int    `json:"expires_in"` // Seconds
+}
+
+// RecoveryCodesResponse lists new recovery codes, they are shown only once
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
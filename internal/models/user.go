@@ -1,10 +1,11 @@
 package models
 
 import (
-	"errors"
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/tranmh/gassigeher/internal/i18n"
 )
 
 // User represents a user in the system
@@ -15,6 +16,7 @@ type User struct {
 	Phone                    *string    `json:"phone,omitempty"`
 	PasswordHash             *string    `json:"-"`
 	ExperienceLevel          string     `json:"experience_level"`
+	PreferredLanguage        string     `json:"preferred_language"`
 	// DONE: Admin flags
 	IsAdmin                  bool       `json:"is_admin"`
 	IsSuperAdmin             bool       `json:"is_super_admin"`
@@ -35,6 +37,13 @@ type User struct {
 	DeletedAt                *time.Time `json:"deleted_at,omitempty"`
 	CreatedAt                time.Time  `json:"created_at"`
 	UpdatedAt                time.Time  `json:"updated_at"`
+
+	// Joined data for admin responses
+	Tags []string `json:"tags,omitempty"`
+
+	// Roles of the user and the permissions they grant, set for the user's own profile
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
 }
 
 // RegisterRequest represents the registration payload
@@ -45,6 +54,10 @@ type RegisterRequest struct {
 	Password        string `json:"password"`
 	ConfirmPassword string `json:"confirm_password"`
 	AcceptTerms     bool   `json:"accept_terms"`
+	// PreferredLanguage is optional; defaults to the request language
+	PreferredLanguage string `json:"preferred_language,omitempty"`
+	// InvitationCode is required in the invite_only registration mode
+	InvitationCode string `json:"invitation_code,omitempty"`
 }
 
 // LoginRequest represents the login payload
@@ -53,11 +66,23 @@ type LoginRequest struct {
 	Password string `json:"password"`
 }
 
+// AuthState decides whether the access tokens of a user are still accepted
+type AuthState struct {
+	TokenVersion int
+	IsActive     bool
+	IsDeleted    bool
+}
+
 // LoginResponse represents the login response
 type LoginResponse struct {
-	Token   string `json:"token"`
-	User    *User  `json:"user"`
-	IsAdmin bool   `json:"is_admin"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // Lifetime of the access token in seconds
+	User         *User  `json:"user"`
+	IsAdmin      bool   `json:"is_admin"`
+
+	// Set when 2FA was enabled during login, shown only once
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
 }
 
 // VerifyEmailRequest represents email verification payload
@@ -86,9 +111,10 @@ type ChangePasswordRequest struct {
 
 // UpdateProfileRequest represents profile update payload
 type UpdateProfileRequest struct {
-	Name  *string `json:"name,omitempty"`
-	Email *string `json:"email,omitempty"`
-	Phone *string `json:"phone,omitempty"`
+	Name              *string `json:"name,omitempty"`
+	Email             *string `json:"email,omitempty"`
+	Phone             *string `json:"phone,omitempty"`
+	PreferredLanguage *string `json:"preferred_language,omitempty"`
 }
 
 // Phone regex: allows digits, country code, separators, and balanced parentheses
@@ -99,7 +125,7 @@ var phoneRegex = regexp.MustCompile(`^\+?[\s\-\.]?(?:\()?[0-9]{1,4}(?:\))?[\s\-\
 func ValidatePhone(phone string) error {
 	phone = strings.TrimSpace(phone)
 	if phone == "" {
-		return errors.New("Telefonnummer ist erforderlich")
+		return &ValidationError{Field: "phone", Message: "Telefonnummer ist erforderlich", Code: "phone_required"}
 	}
 
 	// Remove all spaces, hyphens, dots for length check
@@ -112,23 +138,23 @@ func ValidatePhone(phone string) error {
 
 	// Minimum 7 digits required
 	if len(digitsOnly) < 7 {
-		return errors.New("Telefonnummer muss mindestens 7 Ziffern enthalten")
+		return &ValidationError{Field: "phone", Message: "Telefonnummer muss mindestens 7 Ziffern enthalten", Code: "phone_too_short"}
 	}
 
 	// Check for balanced parentheses
 	openParen := strings.Count(phone, "(")
 	closeParen := strings.Count(phone, ")")
 	if openParen != closeParen {
-		return errors.New("Ungültige Telefonnummer. Bitte verwenden Sie ein gültiges Format (z.B. 0123 456789 oder +49 123 456789)")
+		return &ValidationError{Field: "phone", Message: "Ungültige Telefonnummer. Bitte verwenden Sie ein gültiges Format (z.B. 0123 456789 oder +49 123 456789)", Code: "invalid_phone"}
 	}
 
 	// Check that phone doesn't end with separator
 	if len(phone) > 0 && (phone[len(phone)-1] == '-' || phone[len(phone)-1] == '.' || phone[len(phone)-1] == ' ') {
-		return errors.New("Ungültige Telefonnummer. Bitte verwenden Sie ein gültiges Format (z.B. 0123 456789 oder +49 123 456789)")
+		return &ValidationError{Field: "phone", Message: "Ungültige Telefonnummer. Bitte verwenden Sie ein gültiges Format (z.B. 0123 456789 oder +49 123 456789)", Code: "invalid_phone"}
 	}
 
 	if !phoneRegex.MatchString(phone) {
-		return errors.New("Ungültige Telefonnummer. Bitte verwenden Sie ein gültiges Format (z.B. 0123 456789 oder +49 123 456789)")
+		return &ValidationError{Field: "phone", Message: "Ungültige Telefonnummer. Bitte verwenden Sie ein gültiges Format (z.B. 0123 456789 oder +49 123 456789)", Code: "invalid_phone"}
 	}
 	return nil
 }
@@ -136,25 +162,28 @@ func ValidatePhone(phone string) error {
 // Validate validates the RegisterRequest
 func (r *RegisterRequest) Validate() error {
 	if strings.TrimSpace(r.Name) == "" {
-		return errors.New("Name ist erforderlich")
+		return &ValidationError{Field: "name", Message: "Name ist erforderlich", Code: "name_required"}
 	}
 	if strings.TrimSpace(r.Email) == "" {
-		return errors.New("E-Mail ist erforderlich")
+		return &ValidationError{Field: "email", Message: "E-Mail ist erforderlich", Code: "email_required"}
 	}
 	if err := ValidatePhone(r.Phone); err != nil {
 		return err
 	}
 	if r.Password == "" {
-		return errors.New("Passwort ist erforderlich")
+		return &ValidationError{Field: "password", Message: "Passwort ist erforderlich", Code: "password_required"}
 	}
 	if len(r.Password) < 8 {
-		return errors.New("Passwort muss mindestens 8 Zeichen lang sein")
+		return &ValidationError{Field: "password", Message: "Passwort muss mindestens 8 Zeichen lang sein", Code: "password_too_short", Args: []interface{}{8}}
 	}
 	if r.Password != r.ConfirmPassword {
-		return errors.New("Passwörter stimmen nicht überein")
+		return &ValidationError{Field: "confirm_password", Message: "Passwörter stimmen nicht überein", Code: "passwords_do_not_match"}
 	}
 	if !r.AcceptTerms {
-		return errors.New("Sie müssen die AGB akzeptieren")
+		return &ValidationError{Field: "accept_terms", Message: "Sie müssen die AGB akzeptieren", Code: "terms_not_accepted"}
+	}
+	if r.PreferredLanguage != "" && !i18n.IsSupported(r.PreferredLanguage) {
+		return &ValidationError{Field: "preferred_language", Message: "Sprache wird nicht unterstützt", Code: "unsupported_language"}
 	}
 	return nil
 }
@@ -162,15 +191,18 @@ func (r *RegisterRequest) Validate() error {
 // Validate validates the UpdateProfileRequest
 func (u *UpdateProfileRequest) Validate() error {
 	if u.Name != nil && strings.TrimSpace(*u.Name) == "" {
-		return errors.New("Name darf nicht leer sein")
+		return &ValidationError{Field: "name", Message: "Name darf nicht leer sein", Code: "name_required"}
 	}
 	if u.Email != nil && strings.TrimSpace(*u.Email) == "" {
-		return errors.New("E-Mail darf nicht leer sein")
+		return &ValidationError{Field: "email", Message: "E-Mail darf nicht leer sein", Code: "email_required"}
 	}
 	if u.Phone != nil {
 		if err := ValidatePhone(*u.Phone); err != nil {
 			return err
 		}
 	}
+	if u.PreferredLanguage != nil && !i18n.IsSupported(*u.PreferredLanguage) {
+		return &ValidationError{Field: "preferred_language", Message: "Sprache wird nicht unterstützt", Code: "unsupported_language"}
+	}
 	return nil
 }
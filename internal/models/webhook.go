@@ -0,0 +1,132 @@
+package models
+
+import (
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Webhook event types, a subset of the domain events
+const (
+	WebhookEventBookingCreated         = EventBookingCreated
+	WebhookEventBookingCancelled       = EventBookingCancelled // Also sent for rejected bookings
+	WebhookEventDogAvailabilityChanged = EventDogAvailabilityChanged
+	WebhookEventUserDeactivated        = EventUserDeactivated
+)
+
+// WebhookEvents lists all event types a webhook can subscribe to
+var WebhookEvents = []string{
+	WebhookEventBookingCreated,
+	WebhookEventBookingCancelled,
+	WebhookEventDogAvailabilityChanged,
+	WebhookEventUserDeactivated,
+}
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending" // Waiting for the first attempt or a retry
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed" // Gave up after the last retry
+)
+
+// Limits for webhooks
+const (
+	MaxWebhookNameLength = 100
+	MaxWebhookURLLength  = 500
+)
+
+// Webhook is an admin-managed subscription that receives events as signed JSON POST requests
+type Webhook struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"` // Only returned when created or rotated
+	Events    []string  `json:"events"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Subscribes returns true if the webhook receives the event
+func (w *Webhook) Subscribes(event string) bool {
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one event sent (or to be sent) to a webhook
+type WebhookDelivery struct {
+	ID             int        `json:"id"`
+	WebhookID      int        `json:"webhook_id"`
+	Event          string     `json:"event"`
+	Payload        string     `json:"payload"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at,omitempty"`
+	ResponseStatus *int       `json:"response_status,omitempty"`
+	Error          *string    `json:"error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// WebhookPayload is the JSON body of a webhook request
+type WebhookPayload struct {
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// WebhookRequest creates or updates a webhook
+type WebhookRequest struct {
+	Name     string   `json:"name"`
+	URL      string   `json:"url"`
+	Events   []string `json:"events"`
+	IsActive *bool    `json:"is_active,omitempty"` // nil = active
+}
+
+// Validate validates the webhook request and normalizes the event list
+func (r *WebhookRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	r.URL = strings.TrimSpace(r.URL)
+
+	if r.Name == "" || utf8.RuneCountInString(r.Name) > MaxWebhookNameLength {
+		return &ValidationError{Field: "name", Message: "Name is required (max 100 characters)", Code: "invalid_webhook_name", Args: []interface{}{MaxWebhookNameLength}}
+	}
+
+	parsed, err := url.Parse(r.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" || len(r.URL) > MaxWebhookURLLength {
+		return &ValidationError{Field: "url", Message: "URL must be a valid http or https URL", Code: "invalid_webhook_url"}
+	}
+
+	events := []string{}
+	seen := map[string]bool{}
+	for _, event := range r.Events {
+		event = strings.TrimSpace(event)
+		if !isWebhookEvent(event) {
+			return &ValidationError{Field: "events", Message: "Unknown event type", Code: "invalid_webhook_event"}
+		}
+		if !seen[event] {
+			seen[event] = true
+			events = append(events, event)
+		}
+	}
+	if len(events) == 0 {
+		return &ValidationError{Field: "events", Message: "At least one event is required", Code: "webhook_events_required"}
+	}
+	r.Events = events
+
+	return nil
+}
+
+func isWebhookEvent(event string) bool {
+	for _, e := range WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"testing"
+)
+
+// TestWebhookRequest_Validate tests validating webhook subscriptions
+func TestWebhookRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		req  WebhookRequest
+		code string
+	}{
+		{"valid", WebhookRequest{Name: "Bot", URL: "https://bot.example.com/hook", Events: []string{"booking.created"}}, ""},
+		{"missing name", WebhookRequest{Name: " ", URL: "https://bot.example.com", Events: []string{"booking.created"}}, "invalid_webhook_name"},
+		{"invalid scheme", WebhookRequest{Name: "Bot", URL: "ftp://bot.example.com", Events: []string{"booking.created"}}, "invalid_webhook_url"},
+		{"missing host", WebhookRequest{Name: "Bot", URL: "https://", Events: []string{"booking.created"}}, "invalid_webhook_url"},
+		{"unknown event", WebhookRequest{Name: "Bot", URL: "https://bot.example.com", Events: []string{"booking.deleted"}}, "invalid_webhook_event"},
+		{"no events", WebhookRequest{Name: "Bot", URL: "https://bot.example.com"}, "webhook_events_required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if tt.code == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			validationErr, ok := err.(*ValidationError)
+			if !ok || validationErr.Code != tt.code {
+				t.Errorf("Expected %s, got %v", tt.code, err)
+			}
+		})
+	}
+
+	req := WebhookRequest{Name: "Bot", URL: "https://bot.example.com", Events: []string{"user.deactivated", " user.deactivated "}}
+	if err := req.Validate(); err != nil || len(req.Events) != 1 {
+		t.Errorf("Expected de-duplicated events, got %v (%v)", req.Events, err)
+	}
+}
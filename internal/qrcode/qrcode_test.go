@@ -0,0 +1,79 @@
+package qrcode
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestEncode tests version selection and the fixed patterns of the code
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		length int
+		size   int
+	}{
+		{1, 21},   // Version 1
+		{14, 21},  // Largest version 1
+		{15, 25},  // Version 2
+		{120, 45}, // Version 7, typical provisioning URI
+		{213, 57}, // Largest version 10
+	}
+
+	for _, tt := range tests {
+		code, err := Encode([]byte(strings.Repeat("a", tt.length)))
+		if err != nil {
+			t.Fatalf("Encode(%d bytes) failed: %v", tt.length, err)
+		}
+		if code.Size() != tt.size {
+			t.Errorf("Expected size %d for %d bytes, got %d", tt.size, tt.length, code.Size())
+		}
+
+		// Finder patterns: dark ring, light ring, dark center
+		for _, corner := range [][2]int{{0, 0}, {code.Size() - 7, 0}, {0, code.Size() - 7}} {
+			x, y := corner[0], corner[1]
+			if !code.Dark(x, y) || code.Dark(x+1, y+1) || !code.Dark(x+3, y+3) {
+				t.Errorf("Expected a finder pattern at %v", corner)
+			}
+		}
+		// Dark module
+		if !code.Dark(8, code.Size()-8) {
+			t.Error("Expected the dark module to be set")
+		}
+	}
+
+	if _, err := Encode([]byte(strings.Repeat("a", 214))); !errors.Is(err, ErrTooLong) {
+		t.Errorf("Expected ErrTooLong, got %v", err)
+	}
+}
+
+// TestReedSolomon tests the error correction codewords of the version 1-M example of ISO/IEC 18004 annex I
+func TestReedSolomon(t *testing.T) {
+	data := []byte{0x10, 0x20, 0x0C, 0x56, 0x61, 0x80, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11}
+	expected := []byte{0xA5, 0x24, 0xD4, 0xC1, 0xED, 0x36, 0xC7, 0x87, 0x2C, 0x55}
+
+	got := reedSolomonRemainder(data, reedSolomonDivisor(10))
+	if string(got) != string(expected) {
+		t.Errorf("Expected %X, got %X", expected, got)
+	}
+}
+
+// TestSVG tests that the image contains the quiet zone and one square per dark module
+func TestSVG(t *testing.T) {
+	code, _ := Encode([]byte("otpauth://totp/Test"))
+	svg := code.SVG()
+
+	if !strings.HasPrefix(svg, "<svg") || !strings.Contains(svg, `viewBox="0 0 33 33"`) {
+		t.Errorf("Unexpected SVG header: %.120s", svg)
+	}
+	dark := 0
+	for y := 0; y < code.Size(); y++ {
+		for x := 0; x < code.Size(); x++ {
+			if code.Dark(x, y) {
+				dark++
+			}
+		}
+	}
+	if squares := strings.Count(svg, "h1v1h-1z"); squares != dark {
+		t.Errorf("Expected %d squares, got %d", dark, squares)
+	}
+}
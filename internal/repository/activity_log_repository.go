@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+)
+
+// ActivityLogRepository handles the admin activity feed
+type ActivityLogRepository struct {
+	db *sql.DB
+}
+
+// NewActivityLogRepository creates a new activity log repository
+func NewActivityLogRepository(db *sql.DB) *ActivityLogRepository {
+	return &ActivityLogRepository{db: db}
+}
+
+// Create stores an activity entry
+// Events are dispatched at least once, an entry for an event that was already logged is skipped.
+func (r *ActivityLogRepository) Create(entry *models.ActivityEntry) error {
+	var exists int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM activity_log WHERE event_id = ?`, entry.EventID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check activity log: %w", err)
+	}
+	if exists > 0 {
+		return nil
+	}
+
+	params, err := json.Marshal(entry.Params)
+	if err != nil {
+		return fmt.Errorf("failed to encode activity params: %w", err)
+	}
+
+	var dogName *string
+	if entry.DogName != "" {
+		dogName = &entry.DogName
+	}
+
+	result, err := r.db.Exec(`
+		INSERT INTO activity_log (event_id, type, actor_id, user_id, dog_id, dog_name, booking_id, params, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, entry.EventID, entry.Type, entry.ActorID, entry.UserID, entry.DogID, dogName, entry.BookingID, string(params), entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create activity entry: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get activity entry ID: %w", err)
+	}
+	entry.ID = int(id)
+	return nil
+}
+
+// Find returns a page of entries matching the filter, newest first, and the total number of matches
+func (r *ActivityLogRepository) Find(filter *models.ActivityFilter) ([]*models.ActivityEntry, int, error) {
+	where, args, err := activityFilterClause(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM activity_log a`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count activity entries: %w", err)
+	}
+
+	query := `
+		SELECT a.id, a.event_id, a.type, a.actor_id, actor.name, a.user_id, u.name, a.dog_id, a.dog_name, a.booking_id,
+		       a.params, a.created_at
+		FROM activity_log a
+		LEFT JOIN users actor ON actor.id = a.actor_id
+		LEFT JOIN users u ON u.id = a.user_id
+	` + where + `
+		ORDER BY a.created_at DESC, a.id DESC
+		LIMIT ? OFFSET ?
+	`
+	rows, err := r.db.Query(query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query activity log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*models.ActivityEntry{}
+	for rows.Next() {
+		entry := &models.ActivityEntry{}
+		var actorID, userID, dogID, bookingID sql.NullInt64
+		var actorName, userName, dogName sql.NullString
+		var params string
+		err := rows.Scan(&entry.ID, &entry.EventID, &entry.Type, &actorID, &actorName, &userID, &userName, &dogID, &dogName,
+			&bookingID, &params, &entry.CreatedAt)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan activity entry: %w", err)
+		}
+		if err := json.Unmarshal([]byte(params), &entry.Params); err != nil {
+			return nil, 0, fmt.Errorf("failed to decode activity params: %w", err)
+		}
+
+		entry.ActorID = nullIntPtr(actorID)
+		entry.ActorName = actorName.String
+		entry.UserID = nullIntPtr(userID)
+		entry.UserName = userName.String
+		entry.DogID = nullIntPtr(dogID)
+		entry.DogName = dogName.String
+		entry.BookingID = nullIntPtr(bookingID)
+		entries = append(entries, entry)
+	}
+
+	return entries, total, nil
+}
+
+func activityFilterClause(filter *models.ActivityFilter) (string, []interface{}, error) {
+	conditions := []string{}
+	args := []interface{}{}
+
+	if filter.Type != "" {
+		conditions = append(conditions, "(a.type = ? OR a.type LIKE ?)")
+		args = append(args, filter.Type, filter.Type+".%")
+	}
+	if filter.UserID != 0 {
+		conditions = append(conditions, "(a.user_id = ? OR a.actor_id = ?)")
+		args = append(args, filter.UserID, filter.UserID)
+	}
+	if filter.DogID != 0 {
+		conditions = append(conditions, "a.dog_id = ?")
+		args = append(args, filter.DogID)
+	}
+	if filter.DateFrom != "" {
+		from, err := time.ParseInLocation("2006-01-02", filter.DateFrom, time.Local)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid date_from: %w", err)
+		}
+		conditions = append(conditions, "a.created_at >= ?")
+		args = append(args, from)
+	}
+	if filter.DateTo != "" {
+		to, err := time.ParseInLocation("2006-01-02", filter.DateTo, time.Local)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid date_to: %w", err)
+		}
+		conditions = append(conditions, "a.created_at < ?")
+		args = append(args, to.AddDate(0, 0, 1))
+	}
+
+	if len(conditions) == 0 {
+		return "", args, nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args, nil
+}
+
+func nullIntPtr(value sql.NullInt64) *int {
+	if !value.Valid {
+		return nil
+	}
+	id := int(value.Int64)
+	return &id
+}
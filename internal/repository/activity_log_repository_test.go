@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestActivityLogRepository tests recording entries and filtering the feed
+func TestActivityLogRepository(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewActivityLogRepository(db)
+	adminID := testutil.SeedTestUser(t, db, "admin@example.com", "Admin", "orange")
+	annaID := testutil.SeedTestUser(t, db, "anna@example.com", "Anna", "green")
+	dogID := testutil.SeedTestDog(t, db, "Bella", "Labrador", "green")
+
+	yesterday := time.Now().AddDate(0, 0, -1)
+	record := func(eventID int, eventType string, actorID, userID, dog *int, createdAt time.Time) {
+		entry := &models.ActivityEntry{EventID: eventID, Type: eventType, Params: []string{"2030-01-10"},
+			ActorID: actorID, UserID: userID, DogID: dog, CreatedAt: createdAt}
+		if dog != nil {
+			entry.DogName = "Bella"
+		}
+		if err := repo.Create(entry); err != nil {
+			t.Fatalf("Create() failed: %v", err)
+		}
+	}
+	record(1, models.EventUserRegistered, &annaID, &annaID, nil, yesterday)
+	record(2, models.EventBookingCreated, &annaID, &annaID, &dogID, time.Now())
+	record(3, models.EventDogUpdated, &adminID, nil, &dogID, time.Now())
+	record(3, models.EventDogUpdated, &adminID, nil, &dogID, time.Now()) // Dispatched twice
+
+	find := func(filter models.ActivityFilter) ([]*models.ActivityEntry, int) {
+		if filter.Limit == 0 {
+			filter.Limit = 10
+		}
+		entries, total, err := repo.Find(&filter)
+		if err != nil {
+			t.Fatalf("Find(%+v) failed: %v", filter, err)
+		}
+		return entries, total
+	}
+
+	entries, total := find(models.ActivityFilter{})
+	if total != 3 || len(entries) != 3 {
+		t.Fatalf("Expected 3 entries without duplicates, got %d", total)
+	}
+	if entries[0].EventID != 3 || entries[2].EventID != 1 {
+		t.Errorf("Expected newest first, got %d ... %d", entries[0].EventID, entries[2].EventID)
+	}
+	if entries[1].UserName != "Anna" || entries[1].ActorName != "Anna" || entries[1].DogName != "Bella" || entries[1].Params[0] != "2030-01-10" {
+		t.Errorf("Expected names and params, got %+v", entries[1])
+	}
+
+	if entries, total := find(models.ActivityFilter{Limit: 1, Offset: 1}); total != 3 || len(entries) != 1 || entries[0].EventID != 2 {
+		t.Errorf("Expected second page with one entry, got %d of %d", len(entries), total)
+	}
+	if _, total := find(models.ActivityFilter{Type: "booking"}); total != 1 {
+		t.Errorf("Expected type prefix to match booking.created, got %d", total)
+	}
+	if _, total := find(models.ActivityFilter{Type: models.EventUserRegistered}); total != 1 {
+		t.Errorf("Expected exact type match, got %d", total)
+	}
+	if _, total := find(models.ActivityFilter{UserID: adminID}); total != 1 {
+		t.Errorf("Expected the admin's own action, got %d", total)
+	}
+	if _, total := find(models.ActivityFilter{DogID: dogID}); total != 2 {
+		t.Errorf("Expected 2 entries of the dog, got %d", total)
+	}
+
+	today := time.Now().Format("2006-01-02")
+	if _, total := find(models.ActivityFilter{DateFrom: today, DateTo: today}); total != 2 {
+		t.Errorf("Expected 2 entries of today, got %d", total)
+	}
+	if _, total := find(models.ActivityFilter{DateTo: yesterday.Format("2006-01-02")}); total != 1 {
+		t.Errorf("Expected 1 entry until yesterday, got %d", total)
+	}
+
+	// Deleted accounts show the anonymized name
+	NewUserRepository(db).DeleteAccount(annaID)
+	if entries, _ := find(models.ActivityFilter{UserID: annaID}); len(entries) != 2 || entries[0].UserName == "Anna" {
+		t.Errorf("Expected anonymized user name, got %+v", entries)
+	}
+}
+
+// TestBookingRepository_CompleteDue tests completing past scheduled bookings
+func TestBookingRepository_CompleteDue(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewBookingRepository(db)
+	userID := testutil.SeedTestUser(t, db, "anna@example.com", "Anna", "green")
+	dogID := testutil.SeedTestDog(t, db, "Bella", "Labrador", "green")
+	pastID := testutil.SeedTestBooking(t, db, userID, dogID, "2020-01-10", "09:00", "scheduled")
+	testutil.SeedTestBooking(t, db, userID, dogID, "2020-01-11", "09:00", "cancelled")
+	futureID := testutil.SeedTestBooking(t, db, userID, dogID, "2099-01-10", "09:00", "scheduled")
+
+	bookings, err := repo.CompleteDue(time.Now())
+	if err != nil {
+		t.Fatalf("CompleteDue() failed: %v", err)
+	}
+	if len(bookings) != 1 || bookings[0].ID != pastID || bookings[0].Date != "2020-01-10" || bookings[0].Dog.Name != "Bella" || bookings[0].User.Name != "Anna" {
+		t.Fatalf("Expected the past scheduled booking with names, got %+v", bookings)
+	}
+
+	past, _ := repo.FindByID(pastID)
+	future, _ := repo.FindByID(futureID)
+	if past.Status != "completed" || future.Status != "scheduled" {
+		t.Errorf("Expected only the past booking to be completed, got %s and %s", past.Status, future.Status)
+	}
+}
@@ -0,0 +1,400 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+)
+
+// AnnouncementRepository handles admin announcements and their deliveries
+type AnnouncementRepository struct {
+	db *sql.DB
+}
+
+// NewAnnouncementRepository creates a new announcement repository
+func NewAnnouncementRepository(db *sql.DB) *AnnouncementRepository {
+	return &AnnouncementRepository{db: db}
+}
+
+// Create stores a scheduled announcement
+func (r *AnnouncementRepository) Create(announcement *models.Announcement) error {
+	segment, err := json.Marshal(announcement.Segment)
+	if err != nil {
+		return fmt.Errorf("failed to encode announcement segment: %w", err)
+	}
+
+	now := time.Now()
+	announcement.Status = models.AnnouncementStatusScheduled
+	result, err := r.db.Exec(`
+		INSERT INTO announcements (subject, message, segment, status, scheduled_at, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, announcement.Subject, announcement.Message, string(segment), announcement.Status, announcement.ScheduledAt, announcement.CreatedBy, now)
+	if err != nil {
+		return fmt.Errorf("failed to create announcement: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get announcement ID: %w", err)
+	}
+	announcement.ID = int(id)
+	announcement.CreatedAt = now
+	return nil
+}
+
+const announcementColumns = `id, subject, message, segment, status, scheduled_at, created_by, created_at, started_at, completed_at`
+
+func scanAnnouncement(scanner interface{ Scan(...interface{}) error }) (*models.Announcement, error) {
+	announcement := &models.Announcement{}
+	var segment string
+	var createdBy sql.NullInt64
+	var startedAt, completedAt sql.NullTime
+	err := scanner.Scan(&announcement.ID, &announcement.Subject, &announcement.Message, &segment, &announcement.Status,
+		&announcement.ScheduledAt, &createdBy, &announcement.CreatedAt, &startedAt, &completedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(segment), &announcement.Segment); err != nil {
+		return nil, fmt.Errorf("failed to decode announcement segment: %w", err)
+	}
+	if createdBy.Valid {
+		id := int(createdBy.Int64)
+		announcement.CreatedBy = &id
+	}
+	if startedAt.Valid {
+		announcement.StartedAt = &startedAt.Time
+	}
+	if completedAt.Valid {
+		announcement.CompletedAt = &completedAt.Time
+	}
+	return announcement, nil
+}
+
+// FindByID returns an announcement, or nil if it does not exist
+func (r *AnnouncementRepository) FindByID(id int) (*models.Announcement, error) {
+	row := r.db.QueryRow(`SELECT `+announcementColumns+` FROM announcements WHERE id = ?`, id)
+	announcement, err := scanAnnouncement(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get announcement: %w", err)
+	}
+	return announcement, nil
+}
+
+// FindAll returns all announcements, newest first
+func (r *AnnouncementRepository) FindAll() ([]*models.Announcement, error) {
+	return r.query(`SELECT ` + announcementColumns + ` FROM announcements ORDER BY scheduled_at DESC, id DESC`)
+}
+
+func (r *AnnouncementRepository) query(query string, args ...interface{}) ([]*models.Announcement, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query announcements: %w", err)
+	}
+	defer rows.Close()
+
+	announcements := []*models.Announcement{}
+	for rows.Next() {
+		announcement, err := scanAnnouncement(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan announcement: %w", err)
+		}
+		announcements = append(announcements, announcement)
+	}
+	return announcements, nil
+}
+
+// ClaimDue marks scheduled announcements that are due as sending and returns them
+// An announcement is only claimed once, even if several runs overlap
+func (r *AnnouncementRepository) ClaimDue(now time.Time) ([]*models.Announcement, error) {
+	due, err := r.query(`SELECT `+announcementColumns+` FROM announcements WHERE status = ? AND scheduled_at <= ? ORDER BY scheduled_at, id`,
+		models.AnnouncementStatusScheduled, now)
+	if err != nil {
+		return nil, err
+	}
+
+	claimed := []*models.Announcement{}
+	for _, announcement := range due {
+		result, err := r.db.Exec(`UPDATE announcements SET status = ?, started_at = ?, claimed_at = ? WHERE id = ? AND status = ?`,
+			models.AnnouncementStatusSending, now, now, announcement.ID, models.AnnouncementStatusScheduled)
+		if err != nil {
+			return nil, fmt.Errorf("failed to claim announcement: %w", err)
+		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected == 1 {
+			announcement.Status = models.AnnouncementStatusSending
+			announcement.StartedAt = &now
+			claimed = append(claimed, announcement)
+		}
+	}
+	return claimed, nil
+}
+
+// ClaimStale claims announcements whose sending was interrupted (e.g. by a restart)
+// An announcement is interrupted if its claim was not renewed since staleBefore, so an instance
+// that is still sending keeps it.
+func (r *AnnouncementRepository) ClaimStale(now, staleBefore time.Time) ([]*models.Announcement, error) {
+	stale, err := r.query(`SELECT `+announcementColumns+` FROM announcements WHERE status = ? AND (claimed_at IS NULL OR claimed_at < ?) ORDER BY id`,
+		models.AnnouncementStatusSending, staleBefore)
+	if err != nil {
+		return nil, err
+	}
+
+	claimed := []*models.Announcement{}
+	for _, announcement := range stale {
+		result, err := r.db.Exec(`UPDATE announcements SET claimed_at = ? WHERE id = ? AND status = ? AND (claimed_at IS NULL OR claimed_at < ?)`,
+			now, announcement.ID, models.AnnouncementStatusSending, staleBefore)
+		if err != nil {
+			return nil, fmt.Errorf("failed to claim announcement: %w", err)
+		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected != 1 {
+			continue
+		}
+		claimed = append(claimed, announcement)
+	}
+	return claimed, nil
+}
+
+// Renew renews the claim of an announcement that is being sent
+func (r *AnnouncementRepository) Renew(id int, now time.Time) error {
+	_, err := r.db.Exec(`UPDATE announcements SET claimed_at = ? WHERE id = ? AND status = ?`, now, id, models.AnnouncementStatusSending)
+	if err != nil {
+		return fmt.Errorf("failed to renew announcement claim: %w", err)
+	}
+	return nil
+}
+
+// Cancel cancels a scheduled announcement
+// Returns false if the announcement does not exist or already started sending
+func (r *AnnouncementRepository) Cancel(id int) (bool, error) {
+	result, err := r.db.Exec(`UPDATE announcements SET status = ? WHERE id = ? AND status = ?`,
+		models.AnnouncementStatusCancelled, id, models.AnnouncementStatusScheduled)
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel announcement: %w", err)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected == 1, nil
+}
+
+// Complete marks an announcement as sent
+func (r *AnnouncementRepository) Complete(id int) error {
+	_, err := r.db.Exec(`UPDATE announcements SET status = ?, completed_at = ? WHERE id = ?`, models.AnnouncementStatusSent, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to complete announcement: %w", err)
+	}
+	return nil
+}
+
+// FindRecipients returns the users matching a segment (ID, name, email)
+// Deleted, unverified and users without email are never included
+func (r *AnnouncementRepository) FindRecipients(segment models.AnnouncementSegment, now time.Time) ([]*models.User, error) {
+	query, args := segmentQuery(`SELECT u.id, u.name, u.email`, segment, now)
+	rows, err := r.db.Query(query+` ORDER BY u.id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query announcement recipients: %w", err)
+	}
+	defer rows.Close()
+
+	users := []*models.User{}
+	for rows.Next() {
+		user := &models.User{}
+		if err := rows.Scan(&user.ID, &user.Name, &user.Email); err != nil {
+			return nil, fmt.Errorf("failed to scan announcement recipient: %w", err)
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// CountRecipients returns the number of users matching a segment
+func (r *AnnouncementRepository) CountRecipients(segment models.AnnouncementSegment, now time.Time) (int, error) {
+	query, args := segmentQuery(`SELECT COUNT(*)`, segment, now)
+	var count int
+	if err := r.db.QueryRow(query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count announcement recipients: %w", err)
+	}
+	return count, nil
+}
+
+// segmentQuery builds the recipient query for a segment
+func segmentQuery(selectClause string, segment models.AnnouncementSegment, now time.Time) (string, []interface{}) {
+	conditions := []string{"u.is_deleted = 0", "u.is_verified = 1", "u.email IS NOT NULL"}
+	args := []interface{}{}
+
+	switch segment.Status {
+	case models.SegmentStatusInactive:
+		conditions = append(conditions, "u.is_active = 0")
+	case models.SegmentStatusAll:
+	default:
+		conditions = append(conditions, "u.is_active = 1")
+	}
+
+	if len(segment.ExperienceLevels) > 0 {
+		conditions = append(conditions, "u.experience_level IN ("+placeholders(len(segment.ExperienceLevels))+")")
+		for _, level := range segment.ExperienceLevels {
+			args = append(args, level)
+		}
+	}
+
+	if segment.BookedWithinDays != nil {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM bookings b WHERE b.user_id = u.id AND b.status != 'cancelled' AND b.created_at >= ?)")
+		args = append(args, now.AddDate(0, 0, -*segment.BookedWithinDays))
+	}
+
+	if len(segment.Tags) > 0 {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM user_tags t WHERE t.user_id = u.id AND t.tag IN ("+placeholders(len(segment.Tags))+"))")
+		for _, tag := range segment.Tags {
+			args = append(args, tag)
+		}
+	}
+
+	return selectClause + ` FROM users u WHERE ` + strings.Join(conditions, " AND "), args
+}
+
+// placeholders returns n comma separated placeholders
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// CreateDeliveries creates a pending delivery for each recipient
+func (r *AnnouncementRepository) CreateDeliveries(announcementID int, userIDs []int) error {
+	for _, userID := range userIDs {
+		_, err := r.db.Exec(`INSERT INTO announcement_deliveries (announcement_id, user_id, status) VALUES (?, ?, ?)`,
+			announcementID, userID, models.AnnouncementDeliveryPending)
+		if err != nil {
+			return fmt.Errorf("failed to create announcement delivery: %w", err)
+		}
+	}
+	return nil
+}
+
+// CountDeliveries returns the number of deliveries of an announcement
+func (r *AnnouncementRepository) CountDeliveries(announcementID int) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM announcement_deliveries WHERE announcement_id = ?`, announcementID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count announcement deliveries: %w", err)
+	}
+	return count, nil
+}
+
+// FindDeliveries returns the deliveries of an announcement with the given status, including user name and email
+func (r *AnnouncementRepository) FindDeliveries(announcementID int, status string) ([]*models.AnnouncementDelivery, error) {
+	rows, err := r.db.Query(`
+		SELECT d.id, d.announcement_id, d.user_id, u.name, COALESCE(u.email, ''), d.status, d.error, d.processed_at
+		FROM announcement_deliveries d
+		JOIN users u ON u.id = d.user_id
+		WHERE d.announcement_id = ? AND d.status = ?
+		ORDER BY d.id
+	`, announcementID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query announcement deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []*models.AnnouncementDelivery{}
+	for rows.Next() {
+		delivery := &models.AnnouncementDelivery{}
+		var deliveryError sql.NullString
+		var processedAt sql.NullTime
+		if err := rows.Scan(&delivery.ID, &delivery.AnnouncementID, &delivery.UserID, &delivery.UserName, &delivery.UserEmail,
+			&delivery.Status, &deliveryError, &processedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan announcement delivery: %w", err)
+		}
+		if deliveryError.Valid {
+			delivery.Error = &deliveryError.String
+		}
+		if processedAt.Valid {
+			delivery.ProcessedAt = &processedAt.Time
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
+
+// ClaimDelivery claims a pending delivery before its email is sent
+// Returns false if another instance claimed it since staleBefore. An older claim is taken over, its
+// sender stopped before recording the result, so the email of such a delivery may be sent twice.
+func (r *AnnouncementRepository) ClaimDelivery(id int, now, staleBefore time.Time) (bool, error) {
+	result, err := r.db.Exec(`UPDATE announcement_deliveries SET claimed_at = ? WHERE id = ? AND status = ? AND (claimed_at IS NULL OR claimed_at < ?)`,
+		now, id, models.AnnouncementDeliveryPending, staleBefore)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim announcement delivery: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim announcement delivery: %w", err)
+	}
+	return rowsAffected == 1, nil
+}
+
+// UpdateDelivery records the result of a delivery
+func (r *AnnouncementRepository) UpdateDelivery(id int, status string, deliveryError *string) error {
+	_, err := r.db.Exec(`UPDATE announcement_deliveries SET status = ?, error = ?, processed_at = ? WHERE id = ?`,
+		status, deliveryError, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update announcement delivery: %w", err)
+	}
+	return nil
+}
+
+// Reports returns the delivery counts of all announcements, keyed by announcement ID
+func (r *AnnouncementRepository) Reports() (map[int]*models.AnnouncementReport, error) {
+	rows, err := r.db.Query(`SELECT announcement_id, status, COUNT(*) FROM announcement_deliveries GROUP BY announcement_id, status`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query announcement reports: %w", err)
+	}
+	defer rows.Close()
+
+	reports := map[int]*models.AnnouncementReport{}
+	for rows.Next() {
+		var announcementID, count int
+		var status string
+		if err := rows.Scan(&announcementID, &status, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan announcement report: %w", err)
+		}
+
+		report, ok := reports[announcementID]
+		if !ok {
+			report = &models.AnnouncementReport{}
+			reports[announcementID] = report
+		}
+		report.Total += count
+		switch status {
+		case models.AnnouncementDeliveryPending:
+			report.Pending = count
+		case models.AnnouncementDeliverySent:
+			report.Sent = count
+		case models.AnnouncementDeliverySkipped:
+			report.Skipped = count
+		case models.AnnouncementDeliveryFailed:
+			report.Failed = count
+		}
+	}
+	return reports, nil
+}
+
+// Report returns the delivery counts and failed deliveries of an announcement
+func (r *AnnouncementRepository) Report(announcementID int) (*models.AnnouncementReport, error) {
+	reports, err := r.Reports()
+	if err != nil {
+		return nil, err
+	}
+
+	report, ok := reports[announcementID]
+	if !ok {
+		report = &models.AnnouncementReport{}
+	}
+
+	report.Failures, err = r.FindDeliveries(announcementID, models.AnnouncementDeliveryFailed)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestAnnouncementRepository_FindRecipients tests resolving segments to users
+func TestAnnouncementRepository_FindRecipients(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewAnnouncementRepository(db)
+	userRepo := NewUserRepository(db)
+	now := time.Now()
+
+	green := testutil.SeedTestUser(t, db, "green@example.com", "Green", "green")
+	orange := testutil.SeedTestUser(t, db, "orange@example.com", "Orange", "orange")
+	inactive := testutil.SeedTestUser(t, db, "inactive@example.com", "Inactive", "green")
+	deleted := testutil.SeedTestUser(t, db, "deleted@example.com", "Deleted", "green")
+	unverified := testutil.SeedTestUser(t, db, "unverified@example.com", "Unverified", "green")
+	db.Exec("UPDATE users SET is_active = 0 WHERE id = ?", inactive)
+	db.Exec("UPDATE users SET is_deleted = 1 WHERE id = ?", deleted)
+	db.Exec("UPDATE users SET is_verified = 0 WHERE id = ?", unverified)
+
+	dogID := testutil.SeedTestDog(t, db, "Bella", "Labrador", "green")
+	testutil.SeedTestBooking(t, db, green, dogID, "2025-12-01", "09:00", "scheduled")
+	oldBooking := testutil.SeedTestBooking(t, db, orange, dogID, "2025-10-01", "09:00", "completed")
+	db.Exec("UPDATE bookings SET created_at = ? WHERE id = ?", now.AddDate(0, 0, -60), oldBooking)
+
+	if err := userRepo.SetTags(orange, []string{"team"}); err != nil {
+		t.Fatalf("SetTags() failed: %v", err)
+	}
+
+	days := 30
+	tests := []struct {
+		name     string
+		segment  models.AnnouncementSegment
+		expected []int
+	}{
+		{"active users", models.AnnouncementSegment{Status: models.SegmentStatusActive}, []int{green, orange}},
+		{"inactive users", models.AnnouncementSegment{Status: models.SegmentStatusInactive}, []int{inactive}},
+		{"all users", models.AnnouncementSegment{Status: models.SegmentStatusAll}, []int{green, orange, inactive}},
+		{"experience level", models.AnnouncementSegment{Status: models.SegmentStatusAll, ExperienceLevels: []string{"orange"}}, []int{orange}},
+		{"booked recently", models.AnnouncementSegment{Status: models.SegmentStatusActive, BookedWithinDays: &days}, []int{green}},
+		{"tag", models.AnnouncementSegment{Status: models.SegmentStatusActive, Tags: []string{"team", "other"}}, []int{orange}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, err := repo.FindRecipients(tt.segment, now)
+			if err != nil {
+				t.Fatalf("FindRecipients() failed: %v", err)
+			}
+			if len(users) != len(tt.expected) {
+				t.Fatalf("Expected %d recipients, got %d", len(tt.expected), len(users))
+			}
+			for i, user := range users {
+				if user.ID != tt.expected[i] {
+					t.Errorf("Expected recipient %d, got %d", tt.expected[i], user.ID)
+				}
+			}
+
+			count, err := repo.CountRecipients(tt.segment, now)
+			if err != nil || count != len(tt.expected) {
+				t.Errorf("Expected count %d, got %d (%v)", len(tt.expected), count, err)
+			}
+		})
+	}
+}
+
+// TestAnnouncementRepository_Lifecycle tests scheduling, claiming, cancelling and reporting
+func TestAnnouncementRepository_Lifecycle(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewAnnouncementRepository(db)
+	adminID := testutil.SeedTestUser(t, db, "admin@example.com", "Admin", "orange")
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "User", "green")
+	now := time.Now()
+
+	due := &models.Announcement{Subject: "Due", Message: "Hello", Segment: models.AnnouncementSegment{Status: models.SegmentStatusActive}, ScheduledAt: now.Add(-time.Minute), CreatedBy: &adminID}
+	later := &models.Announcement{Subject: "Later", Message: "Hello", Segment: models.AnnouncementSegment{Tags: []string{"team"}}, ScheduledAt: now.Add(time.Hour)}
+	for _, announcement := range []*models.Announcement{due, later} {
+		if err := repo.Create(announcement); err != nil {
+			t.Fatalf("Create() failed: %v", err)
+		}
+	}
+
+	found, err := repo.FindByID(later.ID)
+	if err != nil || found == nil {
+		t.Fatalf("FindByID() failed: %v", err)
+	}
+	if found.Status != models.AnnouncementStatusScheduled || len(found.Segment.Tags) != 1 || found.CreatedBy != nil {
+		t.Errorf("Expected announcement to round-trip, got %+v", found)
+	}
+
+	claimed, err := repo.ClaimDue(now)
+	if err != nil {
+		t.Fatalf("ClaimDue() failed: %v", err)
+	}
+	if len(claimed) != 1 || claimed[0].ID != due.ID {
+		t.Fatalf("Expected only the due announcement to be claimed, got %v", claimed)
+	}
+	if again, _ := repo.ClaimDue(now); len(again) != 0 {
+		t.Errorf("Expected announcement to be claimed once, got %d", len(again))
+	}
+	if stale, _ := repo.ClaimStale(now, now.Add(-time.Minute)); len(stale) != 0 {
+		t.Errorf("Expected a fresh claim to be kept, got %d", len(stale))
+	}
+	if stale, _ := repo.ClaimStale(now.Add(time.Hour), now.Add(time.Minute)); len(stale) != 1 {
+		t.Errorf("Expected 1 stale announcement, got %d", len(stale))
+	}
+	if stale, _ := repo.ClaimStale(now.Add(time.Hour), now.Add(time.Minute)); len(stale) != 0 {
+		t.Errorf("Expected a stale announcement to be claimed once, got %d", len(stale))
+	}
+
+	t.Run("cancel", func(t *testing.T) {
+		if cancelled, err := repo.Cancel(due.ID); err != nil || cancelled {
+			t.Errorf("Expected sending announcement not to be cancellable, got %v (%v)", cancelled, err)
+		}
+		if cancelled, err := repo.Cancel(later.ID); err != nil || !cancelled {
+			t.Errorf("Expected scheduled announcement to be cancelled, got %v (%v)", cancelled, err)
+		}
+	})
+
+	t.Run("report", func(t *testing.T) {
+		if err := repo.CreateDeliveries(due.ID, []int{adminID, userID}); err != nil {
+			t.Fatalf("CreateDeliveries() failed: %v", err)
+		}
+
+		pending, err := repo.FindDeliveries(due.ID, models.AnnouncementDeliveryPending)
+		if err != nil || len(pending) != 2 {
+			t.Fatalf("Expected 2 pending deliveries, got %d (%v)", len(pending), err)
+		}
+		if pending[1].UserEmail != "user@example.com" || pending[1].UserName != "User" {
+			t.Errorf("Expected delivery to include the user, got %+v", pending[1])
+		}
+
+		if claimed, err := repo.ClaimDelivery(pending[0].ID, now, now.Add(-time.Minute)); err != nil || !claimed {
+			t.Fatalf("Expected delivery to be claimed, got %v (%v)", claimed, err)
+		}
+		if claimed, _ := repo.ClaimDelivery(pending[0].ID, now, now.Add(-time.Minute)); claimed {
+			t.Error("Expected delivery to be claimed once")
+		}
+		if claimed, _ := repo.ClaimDelivery(pending[0].ID, now.Add(time.Hour), now.Add(time.Minute)); !claimed {
+			t.Error("Expected a stale delivery claim to be taken over")
+		}
+
+		failure := "mailbox full"
+		repo.UpdateDelivery(pending[0].ID, models.AnnouncementDeliverySent, nil)
+		repo.UpdateDelivery(pending[1].ID, models.AnnouncementDeliveryFailed, &failure)
+		if err := repo.Complete(due.ID); err != nil {
+			t.Fatalf("Complete() failed: %v", err)
+		}
+
+		report, err := repo.Report(due.ID)
+		if err != nil {
+			t.Fatalf("Report() failed: %v", err)
+		}
+		if report.Total != 2 || report.Sent != 1 || report.Failed != 1 || report.Pending != 0 {
+			t.Errorf("Unexpected report: %+v", report)
+		}
+		if len(report.Failures) != 1 || report.Failures[0].Error == nil || *report.Failures[0].Error != failure {
+			t.Errorf("Expected the failed delivery with its error, got %v", report.Failures)
+		}
+
+		sent, _ := repo.FindByID(due.ID)
+		if sent.Status != models.AnnouncementStatusSent || sent.CompletedAt == nil {
+			t.Errorf("Expected announcement to be sent, got %s", sent.Status)
+		}
+	})
+}
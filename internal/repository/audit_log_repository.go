@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+)
+
+// AuditLogRepository handles the append-only audit log
+// There are deliberately no update or delete methods.
+type AuditLogRepository struct {
+	db DBTX
+}
+
+// NewAuditLogRepository creates a new audit log repository
+func NewAuditLogRepository(db *sql.DB) *AuditLogRepository {
+	return &AuditLogRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries in the transaction
+func (r *AuditLogRepository) WithTx(tx *sql.Tx) *AuditLogRepository {
+	return &AuditLogRepository{db: tx}
+}
+
+// LastHash returns the hash of the newest entry, or the genesis hash if the log is empty
+func (r *AuditLogRepository) LastHash() (string, error) {
+	var hash string
+	err := r.db.QueryRow(`SELECT hash FROM audit_log ORDER BY id DESC LIMIT 1`).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return models.AuditGenesisHash, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get last audit hash: %w", err)
+	}
+	return hash, nil
+}
+
+// Create appends an entry, PrevHash and Hash must already be set
+func (r *AuditLogRepository) Create(entry *models.AuditEntry) error {
+	result, err := r.db.Exec(`
+		INSERT INTO audit_log (actor_id, actor_email, action, target_type, target_id, before_state, after_state,
+		                       ip_address, request_id, status_code, created_at, prev_hash, hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, entry.ActorID, entry.ActorEmail, entry.Action, entry.TargetType, entry.TargetID,
+		nullRawJSON(entry.Before), nullRawJSON(entry.After), entry.IPAddress, entry.RequestID, entry.StatusCode,
+		entry.CreatedAt, entry.PrevHash, entry.Hash)
+	if err != nil {
+		return fmt.Errorf("failed to create audit entry: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get audit entry ID: %w", err)
+	}
+	entry.ID = int(id)
+	return nil
+}
+
+// Find returns a page of entries matching the filter, newest first, and the total number of matches
+func (r *AuditLogRepository) Find(filter *models.AuditFilter) ([]*models.AuditEntry, int, error) {
+	where, args, err := auditFilterClause(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM audit_log`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit entries: %w", err)
+	}
+
+	rows, err := r.db.Query(auditSelect+where+` ORDER BY id DESC LIMIT ? OFFSET ?`,
+		append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*models.AuditEntry{}
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, total, rows.Err()
+}
+
+// Each calls fn for every entry matching the filter, oldest first, without loading the whole log
+// fn must not run queries itself, the rows are still open while it is called.
+func (r *AuditLogRepository) Each(filter *models.AuditFilter, fn func(entry *models.AuditEntry) error) error {
+	where, args, err := auditFilterClause(filter)
+	if err != nil {
+		return err
+	}
+
+	rows, err := r.db.Query(auditSelect+where+` ORDER BY id ASC`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+const auditSelect = `
+	SELECT id, actor_id, actor_email, action, target_type, target_id, before_state, after_state,
+	       ip_address, request_id, status_code, created_at, prev_hash, hash
+	FROM audit_log`
+
+func scanAuditEntry(rows *sql.Rows) (*models.AuditEntry, error) {
+	entry := &models.AuditEntry{}
+	var before, after sql.NullString
+	err := rows.Scan(&entry.ID, &entry.ActorID, &entry.ActorEmail, &entry.Action, &entry.TargetType, &entry.TargetID,
+		&before, &after, &entry.IPAddress, &entry.RequestID, &entry.StatusCode, &entry.CreatedAt,
+		&entry.PrevHash, &entry.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+	}
+	if before.Valid {
+		entry.Before = []byte(before.String)
+	}
+	if after.Valid {
+		entry.After = []byte(after.String)
+	}
+	// CHAR columns are padded by some databases
+	entry.PrevHash = strings.TrimSpace(entry.PrevHash)
+	entry.Hash = strings.TrimSpace(entry.Hash)
+	return entry, nil
+}
+
+func auditFilterClause(filter *models.AuditFilter) (string, []interface{}, error) {
+	conditions := []string{}
+	args := []interface{}{}
+
+	if filter.ActorID != 0 {
+		conditions = append(conditions, "actor_id = ?")
+		args = append(args, filter.ActorID)
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action LIKE ?")
+		args = append(args, "%"+filter.Action+"%")
+	}
+	if filter.TargetType != "" {
+		conditions = append(conditions, "target_type = ?")
+		args = append(args, filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		conditions = append(conditions, "target_id = ?")
+		args = append(args, filter.TargetID)
+	}
+	if filter.DateFrom != "" {
+		from, err := time.ParseInLocation("2006-01-02", filter.DateFrom, time.Local)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid date_from: %w", err)
+		}
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, from.UTC())
+	}
+	if filter.DateTo != "" {
+		to, err := time.ParseInLocation("2006-01-02", filter.DateTo, time.Local)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid date_to: %w", err)
+		}
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, to.AddDate(0, 0, 1).UTC())
+	}
+
+	if len(conditions) == 0 {
+		return "", args, nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args, nil
+}
+
+func nullRawJSON(value []byte) interface{} {
+	if len(value) == 0 {
+		return nil
+	}
+	return string(value)
+}
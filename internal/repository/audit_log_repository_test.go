@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestAuditLogRepository tests appending entries and filtering the log
+func TestAuditLogRepository(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewAuditLogRepository(db)
+
+	if hash, err := repo.LastHash(); err != nil || hash != models.AuditGenesisHash {
+		t.Fatalf("Expected genesis hash for an empty log, got %q (%v)", hash, err)
+	}
+
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Truncate(time.Second)
+	now := time.Now().UTC().Truncate(time.Second)
+	create := func(actorID int, action, targetType, targetID string, before string, createdAt time.Time) *models.AuditEntry {
+		prevHash, err := repo.LastHash()
+		if err != nil {
+			t.Fatalf("LastHash() failed: %v", err)
+		}
+		entry := &models.AuditEntry{ActorID: actorID, ActorEmail: "admin@example.com", Action: action,
+			TargetType: targetType, TargetID: targetID, IPAddress: "192.0.2.1", RequestID: "req",
+			StatusCode: 200, CreatedAt: createdAt, PrevHash: prevHash}
+		if before != "" {
+			entry.Before = []byte(before)
+		}
+		entry.Hash = entry.ComputeHash()
+		if err := repo.Create(entry); err != nil {
+			t.Fatalf("Create() failed: %v", err)
+		}
+		return entry
+	}
+	first := create(1, "PUT /api/dogs/{id}", "dog", "3", `{"name":"Bella"}`, yesterday)
+	create(2, "PUT /api/settings/{key}", "setting", "booking_advance_days", "", now)
+	last := create(1, "DELETE /api/dogs/{id}", "dog", "4", "", now)
+
+	if hash, _ := repo.LastHash(); hash != last.Hash {
+		t.Errorf("Expected the hash of the newest entry, got %q", hash)
+	}
+
+	find := func(filter models.AuditFilter) ([]*models.AuditEntry, int) {
+		if filter.Limit == 0 {
+			filter.Limit = 10
+		}
+		entries, total, err := repo.Find(&filter)
+		if err != nil {
+			t.Fatalf("Find(%+v) failed: %v", filter, err)
+		}
+		return entries, total
+	}
+
+	entries, total := find(models.AuditFilter{})
+	if total != 3 || entries[0].ID != last.ID || entries[2].ID != first.ID {
+		t.Fatalf("Expected 3 entries newest first, got %d", total)
+	}
+	if string(entries[2].Before) != `{"name":"Bella"}` || entries[2].After != nil || entries[2].Hash != first.Hash {
+		t.Errorf("Expected stored states and hash, got %+v", entries[2])
+	}
+	if entries, total := find(models.AuditFilter{Limit: 1, Offset: 1}); total != 3 || len(entries) != 1 {
+		t.Errorf("Expected second page with one entry, got %d of %d", len(entries), total)
+	}
+	if _, total := find(models.AuditFilter{ActorID: 1}); total != 2 {
+		t.Errorf("Expected 2 entries of actor 1, got %d", total)
+	}
+	if _, total := find(models.AuditFilter{TargetType: "dog", TargetID: "3"}); total != 1 {
+		t.Errorf("Expected 1 entry for dog 3, got %d", total)
+	}
+	if _, total := find(models.AuditFilter{Action: "DELETE"}); total != 1 {
+		t.Errorf("Expected 1 delete, got %d", total)
+	}
+	today := time.Now().Format("2006-01-02")
+	if _, total := find(models.AuditFilter{DateFrom: today, DateTo: today}); total != 2 {
+		t.Errorf("Expected 2 entries today, got %d", total)
+	}
+
+	ids := []int{}
+	err := repo.Each(&models.AuditFilter{TargetType: "dog"}, func(entry *models.AuditEntry) error {
+		ids = append(ids, entry.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Each() failed: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != first.ID || ids[1] != last.ID {
+		t.Errorf("Expected dog entries oldest first, got %v", ids)
+	}
+}
@@ -11,7 +11,7 @@ import (
 
 // BlockedDateRepository handles blocked date database operations
 type BlockedDateRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 // NewBlockedDateRepository creates a new blocked date repository
@@ -19,6 +19,11 @@ func NewBlockedDateRepository(db *sql.DB) *BlockedDateRepository {
 	return &BlockedDateRepository{db: db}
 }
 
+// WithTx returns a copy of the repository that runs its queries in the transaction
+func (r *BlockedDateRepository) WithTx(tx *sql.Tx) *BlockedDateRepository {
+	return &BlockedDateRepository{db: tx}
+}
+
 // Create creates a new blocked date
 func (r *BlockedDateRepository) Create(blockedDate *models.BlockedDate) error {
 	query := `
@@ -114,6 +119,34 @@ func (r *BlockedDateRepository) FindByDate(date string) (*models.BlockedDate, er
 	return blockedDate, nil
 }
 
+// FindByID finds a blocked date by ID
+func (r *BlockedDateRepository) FindByID(id int) (*models.BlockedDate, error) {
+	query := `
+		SELECT id, date, reason, created_by, created_at
+		FROM blocked_dates
+		WHERE id = ?
+	`
+
+	blockedDate := &models.BlockedDate{}
+	err := r.db.QueryRow(query, id).Scan(
+		&blockedDate.ID,
+		&blockedDate.Date,
+		&blockedDate.Reason,
+		&blockedDate.CreatedBy,
+		&blockedDate.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to find blocked date: %w", err)
+	}
+
+	return blockedDate, nil
+}
+
 // Delete deletes a blocked date
 func (r *BlockedDateRepository) Delete(id int) error {
 	query := `DELETE FROM blocked_dates WHERE id = ?`
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// BookingReminderRepository tracks which reminders were sent for a booking
+type BookingReminderRepository struct {
+	db *sql.DB
+}
+
+// NewBookingReminderRepository creates a new booking reminder repository
+func NewBookingReminderRepository(db *sql.DB) *BookingReminderRepository {
+	return &BookingReminderRepository{db: db}
+}
+
+// FindSentKeys returns the keys of all reminders sent for a booking
+func (r *BookingReminderRepository) FindSentKeys(bookingID int) ([]string, error) {
+	rows, err := r.db.Query(`SELECT reminder_key FROM booking_reminders WHERE booking_id = ?`, bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query booking reminders: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []string{}
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("failed to scan booking reminder: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// Claim records a reminder as sent before it is delivered
+// Returns false if the reminder was already claimed, e.g. by an overlapping run.
+// The primary key guarantees that only one claim can succeed.
+func (r *BookingReminderRepository) Claim(bookingID int, key string) (bool, error) {
+	claimed, err := r.exists(bookingID, key)
+	if err != nil || claimed {
+		return false, err
+	}
+
+	_, err = r.db.Exec(`
+		INSERT INTO booking_reminders (booking_id, reminder_key, sent_at)
+		VALUES (?, ?, ?)
+	`, bookingID, key, time.Now())
+	if err != nil {
+		// Lost the race against a concurrent claim
+		if claimed, existsErr := r.exists(bookingID, key); existsErr == nil && claimed {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to claim booking reminder: %w", err)
+	}
+
+	return true, nil
+}
+
+// Release removes a claim so the reminder is retried (used when sending failed)
+func (r *BookingReminderRepository) Release(bookingID int, key string) error {
+	_, err := r.db.Exec(`DELETE FROM booking_reminders WHERE booking_id = ? AND reminder_key = ?`, bookingID, key)
+	if err != nil {
+		return fmt.Errorf("failed to release booking reminder: %w", err)
+	}
+	return nil
+}
+
+func (r *BookingReminderRepository) exists(bookingID int, key string) (bool, error) {
+	var count int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM booking_reminders
+		WHERE booking_id = ? AND reminder_key = ?
+	`, bookingID, key).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check booking reminder: %w", err)
+	}
+	return count > 0, nil
+}
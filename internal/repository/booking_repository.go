@@ -10,7 +10,7 @@ import (
 
 // BookingRepository handles booking database operations
 type BookingRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 // NewBookingRepository creates a new booking repository
@@ -18,6 +18,11 @@ func NewBookingRepository(db *sql.DB) *BookingRepository {
 	return &BookingRepository{db: db}
 }
 
+// WithTx returns a copy of the repository that runs its queries in the transaction
+func (r *BookingRepository) WithTx(tx *sql.Tx) *BookingRepository {
+	return &BookingRepository{db: tx}
+}
+
 // Create creates a new booking
 func (r *BookingRepository) Create(booking *models.Booking) error {
 	query := `
@@ -271,6 +276,67 @@ func (r *BookingRepository) AutoComplete() (int, error) {
 	return int(rows), nil
 }
 
+// CompleteDue marks scheduled bookings before now as completed and returns them with user and dog names
+// Bind the repository to a transaction (WithTx) so that the returned bookings are exactly the completed ones.
+func (r *BookingRepository) CompleteDue(now time.Time) ([]*models.Booking, error) {
+	currentDate := now.Format("2006-01-02")
+	currentTime := now.Format("15:04")
+
+	rows, err := r.db.Query(`
+		SELECT b.id, b.user_id, b.dog_id, b.date, b.scheduled_time, u.name, d.name
+		FROM bookings b
+		LEFT JOIN users u ON b.user_id = u.id
+		LEFT JOIN dogs d ON b.dog_id = d.id
+		WHERE b.status = 'scheduled'
+		AND (
+			b.date < ?
+			OR (b.date = ? AND b.scheduled_time < ?)
+		)
+		ORDER BY b.date, b.scheduled_time, b.id
+	`, currentDate, currentDate, currentTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query due bookings: %w", err)
+	}
+
+	bookings := []*models.Booking{}
+	for rows.Next() {
+		booking := &models.Booking{User: &models.User{}, Dog: &models.Dog{}}
+		var userName, dogName sql.NullString
+		if err := rows.Scan(&booking.ID, &booking.UserID, &booking.DogID, &booking.Date, &booking.ScheduledTime, &userName, &dogName); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan due booking: %w", err)
+		}
+		if len(booking.Date) > 10 {
+			booking.Date = booking.Date[:10]
+		}
+		booking.User.ID = booking.UserID
+		booking.User.Name = userName.String
+		booking.Dog.ID = booking.DogID
+		booking.Dog.Name = dogName.String
+		bookings = append(bookings, booking)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read due bookings: %w", err)
+	}
+
+	// The rows are closed before updating, a transaction has only one connection
+	for _, booking := range bookings {
+		_, err := r.db.Exec(`
+			UPDATE bookings
+			SET status = 'completed', completed_at = ?, updated_at = ?
+			WHERE id = ? AND status = 'scheduled'
+		`, now, now, booking.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to complete booking %d: %w", booking.ID, err)
+		}
+		booking.Status = "completed"
+		booking.CompletedAt = &now
+	}
+
+	return bookings, nil
+}
+
 // GetUpcoming gets upcoming bookings for a user
 func (r *BookingRepository) GetUpcoming(userID int, limit int) ([]*models.Booking, error) {
 	query := `
@@ -314,19 +380,9 @@ func (r *BookingRepository) GetUpcoming(userID int, limit int) ([]*models.Bookin
 	return bookings, nil
 }
 
-// GetForReminders gets bookings that need reminders (1 hour before scheduled time)
-// Returns bookings with user and dog details, excluding already-sent reminders
-func (r *BookingRepository) GetForReminders() ([]*models.Booking, error) {
-	// Get bookings scheduled within the next 1-2 hours
-	now := time.Now()
-	oneHourFromNow := now.Add(1 * time.Hour)
-	twoHoursFromNow := now.Add(2 * time.Hour)
-
-	currentDate := now.Format("2006-01-02")
-	oneHourTime := oneHourFromNow.Format("15:04")
-	twoHoursTime := twoHoursFromNow.Format("15:04")
-
-	// Query with user and dog details, excluding already-sent reminders
+// GetUpcomingForReminders gets scheduled bookings between two dates (inclusive)
+// Returns bookings with user and dog details, reminder timing is decided by the caller
+func (r *BookingRepository) GetUpcomingForReminders(fromDate, toDate string) ([]*models.Booking, error) {
 	query := `
 		SELECT b.id, b.user_id, b.dog_id, b.date, b.scheduled_time, b.status,
 		       b.completed_at, b.user_notes, b.admin_cancellation_reason, b.created_at, b.updated_at,
@@ -336,13 +392,12 @@ func (r *BookingRepository) GetForReminders() ([]*models.Booking, error) {
 		LEFT JOIN users u ON b.user_id = u.id
 		LEFT JOIN dogs d ON b.dog_id = d.id
 		WHERE b.status = 'scheduled'
-		AND b.reminder_sent_at IS NULL
-		AND b.date = ?
-		AND b.scheduled_time >= ?
-		AND b.scheduled_time < ?
+		AND b.date >= ?
+		AND b.date <= ?
+		ORDER BY b.date ASC, b.scheduled_time ASC
 	`
 
-	rows, err := r.db.Query(query, currentDate, oneHourTime, twoHoursTime)
+	rows, err := r.db.Query(query, fromDate, toDate)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query bookings for reminders: %w", err)
 	}
@@ -376,7 +431,13 @@ func (r *BookingRepository) GetForReminders() ([]*models.Booking, error) {
 			return nil, fmt.Errorf("failed to scan booking: %w", err)
 		}
 
+		// SQLite returns DATE columns in ISO 8601 format ("2025-11-27T00:00:00Z")
+		if len(booking.Date) > 10 {
+			booking.Date = booking.Date[:10]
+		}
+
 		// Populate user details
+		booking.User.ID = booking.UserID
 		if userName.Valid {
 			booking.User.Name = userName.String
 		}
@@ -395,16 +456,6 @@ func (r *BookingRepository) GetForReminders() ([]*models.Booking, error) {
 	return bookings, nil
 }
 
-// MarkReminderSent marks a booking's reminder as sent
-func (r *BookingRepository) MarkReminderSent(bookingID int) error {
-	query := `UPDATE bookings SET reminder_sent_at = ? WHERE id = ?`
-	_, err := r.db.Exec(query, time.Now(), bookingID)
-	if err != nil {
-		return fmt.Errorf("failed to mark reminder sent: %w", err)
-	}
-	return nil
-}
-
 // Update updates a booking (for admin to move bookings)
 func (r *BookingRepository) Update(booking *models.Booking) error {
 	query := `
@@ -424,6 +475,11 @@ func (r *BookingRepository) Update(booking *models.Booking) error {
 		return fmt.Errorf("failed to update booking: %w", err)
 	}
 
+	// Reminders sent for the old time no longer apply
+	if _, err := r.db.Exec(`DELETE FROM booking_reminders WHERE booking_id = ?`, booking.ID); err != nil {
+		return fmt.Errorf("failed to reset booking reminders: %w", err)
+	}
+
 	return nil
 }
 
@@ -82,7 +82,6 @@ func setupTestDB(t *testing.T) *sql.DB {
 		scheduled_time TEXT NOT NULL,
 		status TEXT DEFAULT 'scheduled' CHECK(status IN ('scheduled', 'completed', 'cancelled')),
 		completed_at TIMESTAMP,
-		reminder_sent_at TIMESTAMP,
 		user_notes TEXT,
 		admin_cancellation_reason TEXT,
 		requires_approval INTEGER DEFAULT 0,
@@ -95,6 +94,13 @@ func setupTestDB(t *testing.T) *sql.DB {
 		UNIQUE(dog_id, date, scheduled_time)
 	);
 
+	CREATE TABLE booking_reminders (
+		booking_id INTEGER NOT NULL,
+		reminder_key TEXT NOT NULL,
+		sent_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (booking_id, reminder_key)
+	);
+
 	-- Insert test users
 	INSERT INTO users (id, name, email) VALUES (1, 'Test User', 'test@example.com');
 	INSERT INTO users (id, name, email) VALUES (2, 'Test User 2', 'test2@example.com');
@@ -829,145 +835,41 @@ func TestBookingRepository_Update(t *testing.T) {
 	})
 }
 
-// DONE: TestBookingRepository_GetForReminders tests getting bookings for reminder emails
-func TestBookingRepository_GetForReminders(t *testing.T) {
+// TestBookingRepository_GetUpcomingForReminders tests getting bookings for reminder emails
+func TestBookingRepository_GetUpcomingForReminders(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
 	repo := NewBookingRepository(db)
-	now := time.Now()
-
-	t.Run("returns bookings in reminder window", func(t *testing.T) {
-		// Create booking scheduled 1.5 hours from now
-		reminderTime := now.Add(90 * time.Minute)
-		reminderDate := reminderTime.Format("2006-01-02")
-		reminderScheduledTime := reminderTime.Format("15:04")
-
-		booking := &models.Booking{
-			UserID:        1,
-			DogID:         1,
-			Date:          reminderDate,
-			ScheduledTime: reminderScheduledTime,
-			Status:        "scheduled",
-		}
-		repo.Create(booking)
-
-		// Get reminders
-		reminders, err := repo.GetForReminders()
-		if err != nil {
-			t.Fatalf("GetForReminders() failed: %v", err)
-		}
-
-		// Should find the booking (if time is within 1-2 hour window)
-		found := false
-		for _, r := range reminders {
-			if r.ID == booking.ID {
-				found = true
-				break
-			}
-		}
-
-		if !found && reminderTime.Sub(now) >= 1*time.Hour && reminderTime.Sub(now) < 2*time.Hour {
-			t.Error("Expected to find booking in reminder window")
-		}
-
-		t.Logf("GetForReminders() found %d bookings", len(reminders))
-	})
 
-	t.Run("does not return bookings too far in future", func(t *testing.T) {
-		// Create booking 5 hours from now
-		futureTime := now.Add(5 * time.Hour)
-		futureDate := futureTime.Format("2006-01-02")
-		futureScheduledTime := futureTime.Format("15:04")
-
-		booking := &models.Booking{
-			UserID:        2,
-			DogID:         2,
-			Date:          futureDate,
-			ScheduledTime: futureScheduledTime,
-			Status:        "scheduled",
+	scheduled := &models.Booking{UserID: 1, DogID: 1, Date: "2025-12-02", ScheduledTime: "09:00"}
+	tooLate := &models.Booking{UserID: 1, DogID: 2, Date: "2025-12-05", ScheduledTime: "09:00"}
+	cancelled := &models.Booking{UserID: 2, DogID: 2, Date: "2025-12-02", ScheduledTime: "10:00"}
+	completed := &models.Booking{UserID: 2, DogID: 1, Date: "2025-12-01", ScheduledTime: "10:00"}
+	for _, b := range []*models.Booking{scheduled, tooLate, cancelled, completed} {
+		if err := repo.Create(b); err != nil {
+			t.Fatalf("Create() failed: %v", err)
 		}
-		repo.Create(booking)
-
-		// Get reminders
-		reminders, err := repo.GetForReminders()
-		if err != nil {
-			t.Fatalf("GetForReminders() failed: %v", err)
-		}
-
-		// Should not find the booking
-		for _, r := range reminders {
-			if r.ID == booking.ID {
-				t.Error("Should not find booking too far in future")
-			}
-		}
-	})
-
-	t.Run("does not return completed bookings", func(t *testing.T) {
-		// Create completed booking in reminder window
-		reminderTime := now.Add(90 * time.Minute)
-		reminderDate := reminderTime.Format("2006-01-02")
-		reminderScheduledTime := reminderTime.Format("15:04")
-
-		completedTime := time.Now()
-		booking := &models.Booking{
-			UserID:        3,
-			DogID:         3,
-			Date:          reminderDate,
-			ScheduledTime: reminderScheduledTime,
-			Status:        "completed",
-			CompletedAt:   &completedTime,
-		}
-		repo.Create(booking)
-
-		// Manually update status since Create sets it to scheduled
-		db.Exec("UPDATE bookings SET status = 'completed', completed_at = ? WHERE id = ?", completedTime, booking.ID)
-
-		// Get reminders
-		reminders, err := repo.GetForReminders()
-		if err != nil {
-			t.Fatalf("GetForReminders() failed: %v", err)
-		}
-
-		// Should not find completed booking
-		for _, r := range reminders {
-			if r.ID == booking.ID {
-				t.Error("Should not find completed booking")
-			}
-		}
-	})
-
-	t.Run("does not return cancelled bookings", func(t *testing.T) {
-		// Create cancelled booking in reminder window
-		reminderTime := now.Add(90 * time.Minute)
-		reminderDate := reminderTime.Format("2006-01-02")
-		reminderScheduledTime := reminderTime.Format("15:04")
-
-		booking := &models.Booking{
-			UserID:        4,
-			DogID:         4,
-			Date:          reminderDate,
-			ScheduledTime: reminderScheduledTime,
-			Status:        "cancelled",
-		}
-		repo.Create(booking)
+	}
+	db.Exec("UPDATE bookings SET status = 'cancelled' WHERE id = ?", cancelled.ID)
+	db.Exec("UPDATE bookings SET status = 'completed' WHERE id = ?", completed.ID)
 
-		// Manually update status
-		db.Exec("UPDATE bookings SET status = 'cancelled' WHERE id = ?", booking.ID)
+	bookings, err := repo.GetUpcomingForReminders("2025-12-01", "2025-12-03")
+	if err != nil {
+		t.Fatalf("GetUpcomingForReminders() failed: %v", err)
+	}
 
-		// Get reminders
-		reminders, err := repo.GetForReminders()
-		if err != nil {
-			t.Fatalf("GetForReminders() failed: %v", err)
-		}
+	if len(bookings) != 1 || bookings[0].ID != scheduled.ID {
+		t.Fatalf("Expected only the scheduled booking in range, got %d bookings", len(bookings))
+	}
 
-		// Should not find cancelled booking
-		for _, r := range reminders {
-			if r.ID == booking.ID {
-				t.Error("Should not find cancelled booking")
-			}
-		}
-	})
+	booking := bookings[0]
+	if booking.User == nil || booking.User.Email == nil || *booking.User.Email != "test@example.com" {
+		t.Error("Expected user details")
+	}
+	if booking.Dog == nil || booking.Dog.Name != "Buddy" {
+		t.Error("Expected dog details")
+	}
 }
 
 // DONE: TestBookingRepository_FindByIDWithDetails tests finding booking with joined data
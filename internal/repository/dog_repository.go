@@ -12,7 +12,7 @@ import (
 
 // DogRepository handles dog database operations
 type DogRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 // NewDogRepository creates a new dog repository
@@ -20,6 +20,11 @@ func NewDogRepository(db *sql.DB) *DogRepository {
 	return &DogRepository{db: db}
 }
 
+// WithTx returns a copy of the repository that runs its queries in the transaction
+func (r *DogRepository) WithTx(tx *sql.Tx) *DogRepository {
+	return &DogRepository{db: tx}
+}
+
 // Create creates a new dog
 func (r *DogRepository) Create(dog *models.Dog) error {
 	query := `
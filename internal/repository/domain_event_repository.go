@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+)
+
+// DomainEventRepository handles the domain event outbox
+type DomainEventRepository struct {
+	db DBTX
+}
+
+// NewDomainEventRepository creates a new domain event repository
+func NewDomainEventRepository(db *sql.DB) *DomainEventRepository {
+	return &DomainEventRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries in the transaction
+func (r *DomainEventRepository) WithTx(tx *sql.Tx) *DomainEventRepository {
+	return &DomainEventRepository{db: tx}
+}
+
+// Create appends an event to the outbox
+func (r *DomainEventRepository) Create(event *models.DomainEvent) error {
+	now := time.Now()
+	result, err := r.db.Exec(`
+		INSERT INTO domain_events (event_type, aggregate_type, aggregate_id, actor_id, payload, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, event.EventType, event.AggregateType, event.AggregateID, event.ActorID, event.Payload, now)
+	if err != nil {
+		return fmt.Errorf("failed to create domain event: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get domain event ID: %w", err)
+	}
+	event.ID = int(id)
+	event.CreatedAt = now
+	return nil
+}
+
+const domainEventColumns = `id, event_type, aggregate_type, aggregate_id, actor_id, payload, created_at, dispatched_at, attempts`
+
+func scanDomainEvent(scanner interface{ Scan(...interface{}) error }) (*models.DomainEvent, error) {
+	event := &models.DomainEvent{}
+	var actorID sql.NullInt64
+	var dispatchedAt sql.NullTime
+	err := scanner.Scan(&event.ID, &event.EventType, &event.AggregateType, &event.AggregateID, &actorID, &event.Payload,
+		&event.CreatedAt, &dispatchedAt, &event.Attempts)
+	if err != nil {
+		return nil, err
+	}
+
+	if actorID.Valid {
+		id := int(actorID.Int64)
+		event.ActorID = &id
+	}
+	if dispatchedAt.Valid {
+		event.DispatchedAt = &dispatchedAt.Time
+	}
+	return event, nil
+}
+
+// FindUndispatched returns events that were not dispatched yet, in the order they were recorded
+func (r *DomainEventRepository) FindUndispatched(limit int) ([]*models.DomainEvent, error) {
+	return r.query(`
+		SELECT `+domainEventColumns+` FROM domain_events
+		WHERE dispatched_at IS NULL
+		ORDER BY id
+		LIMIT ?
+	`, limit)
+}
+
+// ClaimUndispatched claims up to limit events that are due for dispatching, in the order they were recorded
+// Events claimed by another instance are skipped until the claim is older than staleBefore, so each event is
+// dispatched by one instance at a time. An event is only claimed once, even if several instances race for it.
+func (r *DomainEventRepository) ClaimUndispatched(claimedBy string, now, staleBefore time.Time, limit int) ([]*models.DomainEvent, error) {
+	due, err := r.query(`
+		SELECT `+domainEventColumns+` FROM domain_events
+		WHERE dispatched_at IS NULL
+		  AND (next_attempt_at IS NULL OR next_attempt_at <= ?)
+		  AND (claimed_at IS NULL OR claimed_at < ?)
+		ORDER BY id
+		LIMIT ?
+	`, now, staleBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	claimed := []*models.DomainEvent{}
+	for _, event := range due {
+		result, err := r.db.Exec(`
+			UPDATE domain_events SET claimed_by = ?, claimed_at = ?
+			WHERE id = ? AND dispatched_at IS NULL AND (claimed_at IS NULL OR claimed_at < ?)
+		`, claimedBy, now, event.ID, staleBefore)
+		if err != nil {
+			return nil, fmt.Errorf("failed to claim domain event: %w", err)
+		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected == 1 {
+			claimed = append(claimed, event)
+		}
+	}
+	return claimed, nil
+}
+
+// FindByAggregate returns the events of one booking, dog or user, oldest first
+func (r *DomainEventRepository) FindByAggregate(aggregateType string, aggregateID int) ([]*models.DomainEvent, error) {
+	return r.query(`
+		SELECT `+domainEventColumns+` FROM domain_events
+		WHERE aggregate_type = ? AND aggregate_id = ?
+		ORDER BY id
+	`, aggregateType, aggregateID)
+}
+
+func (r *DomainEventRepository) query(query string, args ...interface{}) ([]*models.DomainEvent, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query domain events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*models.DomainEvent{}
+	for rows.Next() {
+		event, err := scanDomainEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan domain event: %w", err)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// MarkDispatched records that all subscribers received the event
+func (r *DomainEventRepository) MarkDispatched(id int, dispatchedAt time.Time) error {
+	_, err := r.db.Exec(`UPDATE domain_events SET dispatched_at = ?, claimed_by = NULL, claimed_at = NULL WHERE id = ?`, dispatchedAt, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark domain event as dispatched: %w", err)
+	}
+	return nil
+}
+
+// Retry releases the claim of an event with a failed subscriber and schedules the next attempt
+func (r *DomainEventRepository) Retry(id, attempts int, nextAttemptAt time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE domain_events SET attempts = ?, next_attempt_at = ?, claimed_by = NULL, claimed_at = NULL
+		WHERE id = ?
+	`, attempts, nextAttemptAt, id)
+	if err != nil {
+		return fmt.Errorf("failed to schedule domain event retry: %w", err)
+	}
+	return nil
+}
+
+// FindDeliveredSubscribers returns the names of the subscribers that already handled the event
+func (r *DomainEventRepository) FindDeliveredSubscribers(eventID int) (map[string]bool, error) {
+	rows, err := r.db.Query(`SELECT subscriber FROM domain_event_deliveries WHERE event_id = ?`, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query domain event deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	delivered := map[string]bool{}
+	for rows.Next() {
+		var subscriber string
+		if err := rows.Scan(&subscriber); err != nil {
+			return nil, fmt.Errorf("failed to scan domain event delivery: %w", err)
+		}
+		delivered[subscriber] = true
+	}
+	return delivered, rows.Err()
+}
+
+// MarkDelivered records that a subscriber handled the event, so a retry of the event skips it
+func (r *DomainEventRepository) MarkDelivered(eventID int, subscriber string, deliveredAt time.Time) error {
+	_, err := r.db.Exec(`INSERT INTO domain_event_deliveries (event_id, subscriber, delivered_at) VALUES (?, ?, ?)`,
+		eventID, subscriber, deliveredAt)
+	if err != nil {
+		return fmt.Errorf("failed to mark domain event as delivered: %w", err)
+	}
+	return nil
+}
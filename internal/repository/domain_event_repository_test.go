@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestDomainEventRepository tests appending events in a transaction and marking them as dispatched
+func TestDomainEventRepository(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewDomainEventRepository(db)
+	actorID := 4
+
+	record := func(bookingID int, commit bool) {
+		tx, err := db.Begin()
+		if err != nil {
+			t.Fatalf("Begin() failed: %v", err)
+		}
+		event, _ := models.NewBookingEvent(models.EventBookingCreated, &actorID, &models.BookingEventData{BookingID: bookingID, DogName: "Bella"})
+		if err := repo.WithTx(tx).Create(event); err != nil {
+			t.Fatalf("Create() failed: %v", err)
+		}
+		if commit {
+			tx.Commit()
+		} else {
+			tx.Rollback()
+		}
+	}
+	record(1, true)
+	record(2, false) // Rolled back with the state change
+	record(3, true)
+
+	events, err := repo.FindUndispatched(10)
+	if err != nil {
+		t.Fatalf("FindUndispatched() failed: %v", err)
+	}
+	if len(events) != 2 || events[0].AggregateID != 1 || events[1].AggregateID != 3 {
+		t.Fatalf("Expected the two committed events in order, got %+v", events)
+	}
+	if events[0].ActorID == nil || *events[0].ActorID != actorID || events[0].AggregateType != models.AggregateBooking {
+		t.Errorf("Unexpected event: %+v", events[0])
+	}
+
+	var data models.BookingEventData
+	if err := events[0].Decode(&data); err != nil || data.DogName != "Bella" {
+		t.Errorf("Expected decoded payload, got %+v (%v)", data, err)
+	}
+
+	if err := repo.MarkDispatched(events[0].ID, time.Now()); err != nil {
+		t.Fatalf("MarkDispatched() failed: %v", err)
+	}
+	if events, _ := repo.FindUndispatched(10); len(events) != 1 || events[0].AggregateID != 3 {
+		t.Errorf("Expected only the second event to be pending, got %+v", events)
+	}
+
+	history, _ := repo.FindByAggregate(models.AggregateBooking, 1)
+	if len(history) != 1 || history[0].DispatchedAt == nil {
+		t.Errorf("Expected dispatched event in the history, got %+v", history)
+	}
+}
+
+// TestBookingRepository_WithTx tests that changes of a transaction bound repository are rolled back
+func TestBookingRepository_WithTx(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	userID := testutil.SeedTestUser(t, db, "anna@example.com", "Anna", "green")
+	dogID := testutil.SeedTestDog(t, db, "Bella", "Labrador", "green")
+	bookingID := testutil.SeedTestBooking(t, db, userID, dogID, "2030-01-10", "09:00", "scheduled")
+
+	tx, _ := db.Begin()
+	if err := NewBookingRepository(db).WithTx(tx).Cancel(bookingID, nil); err != nil {
+		t.Fatalf("Cancel() failed: %v", err)
+	}
+	tx.Rollback()
+
+	booking, _ := NewBookingRepository(db).FindByID(bookingID)
+	if booking.Status != "scheduled" {
+		t.Errorf("Expected cancellation to be rolled back, got %s", booking.Status)
+	}
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+)
+
+// EmailTemplateRepository handles email template override database operations
+type EmailTemplateRepository struct {
+	db *sql.DB
+}
+
+// NewEmailTemplateRepository creates a new email template repository
+func NewEmailTemplateRepository(db *sql.DB) *EmailTemplateRepository {
+	return &EmailTemplateRepository{db: db}
+}
+
+// Get retrieves the override for a template key in the given language
+// Returns nil if the template has not been customized
+func (r *EmailTemplateRepository) Get(key, language string) (*models.EmailTemplate, error) {
+	query := `
+		SELECT template_key, language, subject, body, updated_by, updated_at
+		FROM email_templates
+		WHERE template_key = ? AND language = ?
+	`
+
+	tmpl := &models.EmailTemplate{}
+	err := r.db.QueryRow(query, key, language).Scan(
+		&tmpl.Key,
+		&tmpl.Language,
+		&tmpl.Subject,
+		&tmpl.Body,
+		&tmpl.UpdatedBy,
+		&tmpl.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email template: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+// GetAll retrieves all template overrides of a language keyed by template key
+func (r *EmailTemplateRepository) GetAll(language string) (map[string]*models.EmailTemplate, error) {
+	query := `
+		SELECT template_key, language, subject, body, updated_by, updated_at
+		FROM email_templates
+		WHERE language = ?
+		ORDER BY template_key ASC
+	`
+
+	rows, err := r.db.Query(query, language)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query email templates: %w", err)
+	}
+	defer rows.Close()
+
+	templates := make(map[string]*models.EmailTemplate)
+	for rows.Next() {
+		tmpl := &models.EmailTemplate{}
+		err := rows.Scan(
+			&tmpl.Key,
+			&tmpl.Language,
+			&tmpl.Subject,
+			&tmpl.Body,
+			&tmpl.UpdatedBy,
+			&tmpl.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan email template: %w", err)
+		}
+		templates[tmpl.Key] = tmpl
+	}
+
+	return templates, nil
+}
+
+// Save creates or replaces the override for a template key and language
+func (r *EmailTemplateRepository) Save(tmpl *models.EmailTemplate) error {
+	now := time.Now()
+
+	// Try update first, insert if the template has no override yet
+	// (portable across SQLite, MySQL and PostgreSQL)
+	result, err := r.db.Exec(`
+		UPDATE email_templates
+		SET subject = ?, body = ?, updated_by = ?, updated_at = ?
+		WHERE template_key = ? AND language = ?
+	`, tmpl.Subject, tmpl.Body, tmpl.UpdatedBy, now, tmpl.Key, tmpl.Language)
+	if err != nil {
+		return fmt.Errorf("failed to update email template: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		_, err = r.db.Exec(`
+			INSERT INTO email_templates (template_key, language, subject, body, updated_by, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, tmpl.Key, tmpl.Language, tmpl.Subject, tmpl.Body, tmpl.UpdatedBy, now)
+		if err != nil {
+			return fmt.Errorf("failed to create email template: %w", err)
+		}
+	}
+
+	tmpl.UpdatedAt = now
+	return nil
+}
+
+// Delete removes the override for a template key and language (resets to default)
+func (r *EmailTemplateRepository) Delete(key, language string) error {
+	_, err := r.db.Exec(`DELETE FROM email_templates WHERE template_key = ? AND language = ?`, key, language)
+	if err != nil {
+		return fmt.Errorf("failed to delete email template: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestEmailTemplateRepository_SaveAndGet tests creating and updating overrides
+func TestEmailTemplateRepository_SaveAndGet(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewEmailTemplateRepository(db)
+	adminID := testutil.SeedTestUser(t, db, "admin@example.com", "Admin", "orange")
+
+	t.Run("no override", func(t *testing.T) {
+		tmpl, err := repo.Get("welcome", "de")
+		if err != nil {
+			t.Fatalf("Get() failed: %v", err)
+		}
+		if tmpl != nil {
+			t.Error("Expected nil for template without override")
+		}
+	})
+
+	t.Run("create override", func(t *testing.T) {
+		err := repo.Save(&models.EmailTemplate{Key: "welcome", Language: "de", Subject: "Hallo", Body: "<p>1</p>", UpdatedBy: &adminID})
+		if err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+
+		tmpl, err := repo.Get("welcome", "de")
+		if err != nil {
+			t.Fatalf("Get() failed: %v", err)
+		}
+		if tmpl == nil || tmpl.Subject != "Hallo" || tmpl.Body != "<p>1</p>" {
+			t.Fatalf("Unexpected template: %+v", tmpl)
+		}
+		if tmpl.UpdatedBy == nil || *tmpl.UpdatedBy != adminID {
+			t.Error("Expected updated_by to be set")
+		}
+	})
+
+	t.Run("update override", func(t *testing.T) {
+		err := repo.Save(&models.EmailTemplate{Key: "welcome", Language: "de", Subject: "Servus", Body: "<p>2</p>", UpdatedBy: &adminID})
+		if err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+
+		tmpl, _ := repo.Get("welcome", "de")
+		if tmpl.Subject != "Servus" || tmpl.Body != "<p>2</p>" {
+			t.Errorf("Override was not updated: %+v", tmpl)
+		}
+
+		if count := testutil.CountRows(t, db, "email_templates"); count != 1 {
+			t.Errorf("Expected 1 row, got %d", count)
+		}
+	})
+}
+
+// TestEmailTemplateRepository_GetAllAndDelete tests listing and removing overrides
+func TestEmailTemplateRepository_GetAllAndDelete(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewEmailTemplateRepository(db)
+
+	repo.Save(&models.EmailTemplate{Key: "welcome", Language: "de", Subject: "A", Body: "a"})
+	repo.Save(&models.EmailTemplate{Key: "booking_reminder", Language: "de", Subject: "B", Body: "b"})
+
+	all, err := repo.GetAll("de")
+	if err != nil {
+		t.Fatalf("GetAll() failed: %v", err)
+	}
+	if len(all) != 2 || all["welcome"] == nil || all["booking_reminder"] == nil {
+		t.Fatalf("Expected both overrides, got %v", all)
+	}
+
+	if err := repo.Delete("welcome", "de"); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+
+	tmpl, _ := repo.Get("welcome", "de")
+	if tmpl != nil {
+		t.Error("Expected override to be deleted")
+	}
+
+	// Deleting a template without override is not an error
+	if err := repo.Delete("welcome", "de"); err != nil {
+		t.Errorf("Delete() of missing override failed: %v", err)
+	}
+}
+
+// TestEmailTemplateRepository_Languages tests that overrides are stored per language
+func TestEmailTemplateRepository_Languages(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewEmailTemplateRepository(db)
+
+	repo.Save(&models.EmailTemplate{Key: "welcome", Language: "de", Subject: "Hallo", Body: "<p>de</p>"})
+	repo.Save(&models.EmailTemplate{Key: "welcome", Language: "en", Subject: "Hello", Body: "<p>en</p>"})
+
+	if count := testutil.CountRows(t, db, "email_templates"); count != 2 {
+		t.Fatalf("Expected 2 rows, got %d", count)
+	}
+
+	tmpl, err := repo.Get("welcome", "en")
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	if tmpl == nil || tmpl.Language != "en" || tmpl.Subject != "Hello" {
+		t.Errorf("Unexpected template: %+v", tmpl)
+	}
+
+	if err := repo.Delete("welcome", "en"); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+
+	if tmpl, _ := repo.Get("welcome", "de"); tmpl == nil {
+		t.Error("Deleting the English override should keep the German one")
+	}
+	if all, _ := repo.GetAll("en"); len(all) != 0 {
+		t.Errorf("Expected no English overrides, got %d", len(all))
+	}
+}
@@ -10,7 +10,7 @@ import (
 
 // ExperienceRequestRepository handles experience request database operations
 type ExperienceRequestRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 // NewExperienceRequestRepository creates a new experience request repository
@@ -18,6 +18,11 @@ func NewExperienceRequestRepository(db *sql.DB) *ExperienceRequestRepository {
 	return &ExperienceRequestRepository{db: db}
 }
 
+// WithTx returns a copy of the repository that runs its queries in the transaction
+func (r *ExperienceRequestRepository) WithTx(tx *sql.Tx) *ExperienceRequestRepository {
+	return &ExperienceRequestRepository{db: tx}
+}
+
 // Create creates a new experience request
 func (r *ExperienceRequestRepository) Create(request *models.ExperienceRequest) error {
 	query := `
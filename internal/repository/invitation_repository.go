@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+)
+
+const invitationColumns = `id, code, email, max_uses, use_count, experience_level, tag, expires_at, revoked_at, created_by, created_at`
+
+// InvitationRepository handles invitation codes for the registration
+type InvitationRepository struct {
+	db DBTX
+}
+
+// NewInvitationRepository creates a new invitation repository
+func NewInvitationRepository(db *sql.DB) *InvitationRepository {
+	return &InvitationRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries in the transaction
+func (r *InvitationRepository) WithTx(tx *sql.Tx) *InvitationRepository {
+	return &InvitationRepository{db: tx}
+}
+
+// Create stores a new invitation
+func (r *InvitationRepository) Create(invitation *models.Invitation) error {
+	result, err := r.db.Exec(`
+		INSERT INTO invitations (code, email, max_uses, use_count, experience_level, tag, expires_at, created_by, created_at)
+		VALUES (?, ?, ?, 0, ?, ?, ?, ?, ?)
+	`, invitation.Code, invitation.Email, invitation.MaxUses, invitation.ExperienceLevel, invitation.Tag,
+		invitation.ExpiresAt, invitation.CreatedBy, invitation.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get invitation ID: %w", err)
+	}
+	invitation.ID = int(id)
+	invitation.UseCount = 0
+	return nil
+}
+
+// FindByID returns the invitation, nil if there is none
+func (r *InvitationRepository) FindByID(id int) (*models.Invitation, error) {
+	invitations, err := r.find(`WHERE id = ?`, id)
+	if err != nil || len(invitations) == 0 {
+		return nil, err
+	}
+	return invitations[0], nil
+}
+
+// FindByCode returns the invitation of a normalized code, nil if there is none
+func (r *InvitationRepository) FindByCode(code string) (*models.Invitation, error) {
+	invitations, err := r.find(`WHERE code = ?`, code)
+	if err != nil || len(invitations) == 0 {
+		return nil, err
+	}
+	return invitations[0], nil
+}
+
+// List returns all invitations, newest first
+func (r *InvitationRepository) List() ([]*models.Invitation, error) {
+	return r.find(`ORDER BY created_at DESC, id DESC`)
+}
+
+// Redeem counts a registration with the invitation
+// It returns false if the invitation was revoked, used up or expired in the meantime.
+func (r *InvitationRepository) Redeem(id int, now time.Time) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE invitations SET use_count = use_count + 1
+		WHERE id = ? AND revoked_at IS NULL AND use_count < max_uses AND expires_at > ?
+	`, id, now)
+	if err != nil {
+		return false, fmt.Errorf("failed to redeem invitation: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to redeem invitation: %w", err)
+	}
+	return rows > 0, nil
+}
+
+// Revoke makes the invitation unusable, revoking it again keeps the first date
+func (r *InvitationRepository) Revoke(id int, now time.Time) error {
+	_, err := r.db.Exec(`UPDATE invitations SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`, now, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke invitation: %w", err)
+	}
+	return nil
+}
+
+func (r *InvitationRepository) find(condition string, args ...interface{}) ([]*models.Invitation, error) {
+	rows, err := r.db.Query(`SELECT `+invitationColumns+` FROM invitations `+condition, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query invitations: %w", err)
+	}
+	defer rows.Close()
+
+	invitations := []*models.Invitation{}
+	for rows.Next() {
+		invitation := &models.Invitation{}
+		err := rows.Scan(
+			&invitation.ID,
+			&invitation.Code,
+			&invitation.Email,
+			&invitation.MaxUses,
+			&invitation.UseCount,
+			&invitation.ExperienceLevel,
+			&invitation.Tag,
+			&invitation.ExpiresAt,
+			&invitation.RevokedAt,
+			&invitation.CreatedBy,
+			&invitation.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan invitation: %w", err)
+		}
+		invitations = append(invitations, invitation)
+	}
+	return invitations, rows.Err()
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestInvitationRepository tests creating, redeeming and revoking invitations
+func TestInvitationRepository(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewInvitationRepository(db)
+
+	adminID := testutil.SeedTestUser(t, db, "admin@example.com", "Admin", "orange")
+	now := time.Now()
+	tag := "vorstand"
+
+	create := func(code string, maxUses int, expiresAt time.Time) *models.Invitation {
+		t.Helper()
+		invitation := &models.Invitation{Code: code, MaxUses: maxUses, ExperienceLevel: "blue", Tag: &tag,
+			ExpiresAt: expiresAt, CreatedBy: &adminID, CreatedAt: now}
+		if err := repo.Create(invitation); err != nil {
+			t.Fatalf("Create() failed: %v", err)
+		}
+		return invitation
+	}
+	twice := create("AAAABBBBCCCC", 2, now.Add(time.Hour))
+	expired := create("DDDDEEEEFFFF", 1, now.Add(-time.Hour))
+
+	found, err := repo.FindByCode("AAAABBBBCCCC")
+	if err != nil || found == nil || found.ID != twice.ID || found.ExperienceLevel != "blue" || found.Tag == nil || *found.Tag != tag {
+		t.Fatalf("FindByCode() = %+v (%v)", found, err)
+	}
+	if missing, err := repo.FindByCode("UNKNOWN"); err != nil || missing != nil {
+		t.Errorf("Expected no invitation for an unknown code, got %+v (%v)", missing, err)
+	}
+
+	for i, want := range []bool{true, true, false} {
+		if ok, err := repo.Redeem(twice.ID, now); err != nil || ok != want {
+			t.Errorf("Redeem() #%d = %v (%v), want %v", i+1, ok, err, want)
+		}
+	}
+	if ok, _ := repo.Redeem(expired.ID, now); ok {
+		t.Error("Expected the expired invitation not to be redeemed")
+	}
+
+	found, _ = repo.FindByID(twice.ID)
+	if found.UseCount != 2 || found.StatusAt(now) != models.InvitationStatusUsed {
+		t.Errorf("Expected the invitation to be used up, got %+v", found)
+	}
+
+	single := create("GGGGHHHHJJJJ", 1, now.Add(time.Hour))
+	if err := repo.Revoke(single.ID, now); err != nil {
+		t.Fatalf("Revoke() failed: %v", err)
+	}
+	if ok, _ := repo.Redeem(single.ID, now); ok {
+		t.Error("Expected the revoked invitation not to be redeemed")
+	}
+
+	invitations, err := repo.List()
+	if err != nil || len(invitations) != 3 || invitations[0].ID != single.ID {
+		t.Errorf("List() = %d invitations (%v), want 3 newest first", len(invitations), err)
+	}
+}
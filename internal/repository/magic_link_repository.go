@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+)
+
+// MagicLinkRepository handles the login links sent by email
+type MagicLinkRepository struct {
+	db DBTX
+}
+
+// NewMagicLinkRepository creates a new magic link repository
+func NewMagicLinkRepository(db *sql.DB) *MagicLinkRepository {
+	return &MagicLinkRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries in the transaction
+func (r *MagicLinkRepository) WithTx(tx *sql.Tx) *MagicLinkRepository {
+	return &MagicLinkRepository{db: tx}
+}
+
+// Create stores a new magic link
+func (r *MagicLinkRepository) Create(link *models.MagicLink) error {
+	result, err := r.db.Exec(`
+		INSERT INTO magic_links (user_id, token_hash, device_hash, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, link.UserID, link.TokenHash, link.DeviceHash, link.ExpiresAt, link.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create magic link: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get magic link ID: %w", err)
+	}
+	link.ID = int(id)
+	return nil
+}
+
+// FindByTokenHash returns the magic link with the token hash, nil if there is none
+func (r *MagicLinkRepository) FindByTokenHash(tokenHash string) (*models.MagicLink, error) {
+	link := &models.MagicLink{}
+	var usedAt sql.NullTime
+	err := r.db.QueryRow(`
+		SELECT id, user_id, token_hash, device_hash, expires_at, used_at, created_at
+		FROM magic_links WHERE token_hash = ?
+	`, tokenHash).Scan(&link.ID, &link.UserID, &link.TokenHash, &link.DeviceHash, &link.ExpiresAt, &usedAt, &link.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find magic link: %w", err)
+	}
+	if usedAt.Valid {
+		link.UsedAt = &usedAt.Time
+	}
+	return link, nil
+}
+
+// CountSince returns the number of links sent to the user since the given time
+func (r *MagicLinkRepository) CountSince(userID int, since time.Time) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM magic_links WHERE user_id = ? AND created_at >= ?`, userID, since).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count magic links: %w", err)
+	}
+	return count, nil
+}
+
+// MarkUsed marks the link as used, false if it was used already
+func (r *MagicLinkRepository) MarkUsed(id int, usedAt time.Time) (bool, error) {
+	result, err := r.db.Exec(`UPDATE magic_links SET used_at = ? WHERE id = ? AND used_at IS NULL`, usedAt, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to use magic link: %w", err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to use magic link: %w", err)
+	}
+	return updated == 1, nil
+}
+
+// DeleteExpired deletes links that expired before the given time and returns their number
+// Used links are kept until they expire, they count against the rate limit.
+func (r *MagicLinkRepository) DeleteExpired(before time.Time) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM magic_links WHERE expires_at < ?`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired magic links: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestMagicLinkRepository tests finding, counting, using and deleting links
+func TestMagicLinkRepository(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewMagicLinkRepository(db)
+
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "User", "green")
+	now := time.Now()
+
+	link := &models.MagicLink{UserID: userID, TokenHash: "hash-1", DeviceHash: "device", ExpiresAt: now.Add(15 * time.Minute), CreatedAt: now}
+	if err := repo.Create(link); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	repo.Create(&models.MagicLink{UserID: userID, TokenHash: "hash-2", DeviceHash: "device", ExpiresAt: now.Add(-time.Minute), CreatedAt: now.Add(-time.Hour)})
+
+	found, err := repo.FindByTokenHash("hash-1")
+	if err != nil || found == nil || found.UserID != userID || found.DeviceHash != "device" || found.UsedAt != nil {
+		t.Fatalf("Unexpected link %+v (%v)", found, err)
+	}
+	if found, _ := repo.FindByTokenHash("unknown"); found != nil {
+		t.Error("Expected no link for an unknown hash")
+	}
+
+	if count, _ := repo.CountSince(userID, now.Add(-time.Minute)); count != 1 {
+		t.Errorf("Expected 1 recent link, got %d", count)
+	}
+
+	if used, err := repo.MarkUsed(link.ID, now); err != nil || !used {
+		t.Fatalf("MarkUsed() = %v (%v), want true", used, err)
+	}
+	if used, _ := repo.MarkUsed(link.ID, now); used {
+		t.Error("Expected the link to be used only once")
+	}
+	if found, _ := repo.FindByTokenHash("hash-1"); found.UsedAt == nil {
+		t.Error("Expected the link to be marked as used")
+	}
+
+	if deleted, _ := repo.DeleteExpired(now); deleted != 1 {
+		t.Errorf("Expected 1 expired link to be deleted, got %d", deleted)
+	}
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+)
+
+// NotificationPreferenceRepository handles notification preference database operations
+type NotificationPreferenceRepository struct {
+	db *sql.DB
+}
+
+// NewNotificationPreferenceRepository creates a new notification preference repository
+func NewNotificationPreferenceRepository(db *sql.DB) *NotificationPreferenceRepository {
+	return &NotificationPreferenceRepository{db: db}
+}
+
+// FindByUser retrieves all stored preferences of a user
+func (r *NotificationPreferenceRepository) FindByUser(userID int) ([]*models.NotificationPreference, error) {
+	query := `
+		SELECT user_id, category, channel, enabled, updated_at
+		FROM notification_preferences
+		WHERE user_id = ?
+		ORDER BY category ASC, channel ASC
+	`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notification preferences: %w", err)
+	}
+	defer rows.Close()
+
+	preferences := []*models.NotificationPreference{}
+	for rows.Next() {
+		pref := &models.NotificationPreference{}
+		err := rows.Scan(
+			&pref.UserID,
+			&pref.Category,
+			&pref.Channel,
+			&pref.Enabled,
+			&pref.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification preference: %w", err)
+		}
+		preferences = append(preferences, pref)
+	}
+
+	return preferences, nil
+}
+
+// IsEnabled checks if a user receives notifications of a category on a channel
+// Mandatory email categories are always enabled, missing preferences use the channel default
+func (r *NotificationPreferenceRepository) IsEnabled(userID int, category, channel string) (bool, error) {
+	if !models.IsNotificationCategorySupported(category, channel) {
+		return false, nil
+	}
+	if models.IsMandatoryNotification(category, channel) {
+		return true, nil
+	}
+
+	var enabled bool
+	err := r.db.QueryRow(`
+		SELECT enabled FROM notification_preferences
+		WHERE user_id = ? AND category = ? AND channel = ?
+	`, userID, category, channel).Scan(&enabled)
+
+	if err == sql.ErrNoRows {
+		return models.IsNotificationEnabledByDefault(channel), nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed to get notification preference: %w", err)
+	}
+
+	return enabled, nil
+}
+
+// Set stores a preference for a category and channel
+func (r *NotificationPreferenceRepository) Set(userID int, category, channel string, enabled bool) error {
+	now := time.Now()
+
+	// Try update first, insert if no preference exists yet
+	result, err := r.db.Exec(`
+		UPDATE notification_preferences
+		SET enabled = ?, updated_at = ?
+		WHERE user_id = ? AND category = ? AND channel = ?
+	`, enabled, now, userID, category, channel)
+	if err != nil {
+		return fmt.Errorf("failed to update notification preference: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		_, err = r.db.Exec(`
+			INSERT INTO notification_preferences (user_id, category, channel, enabled, updated_at)
+			VALUES (?, ?, ?, ?, ?)
+		`, userID, category, channel, enabled, now)
+		if err != nil {
+			return fmt.Errorf("failed to create notification preference: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetEffective returns the effective preferences of a user for all categories on the given channels
+func (r *NotificationPreferenceRepository) GetEffective(userID int, channels []string) ([]*models.NotificationPreferenceResponse, error) {
+	stored, err := r.FindByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	storedMap := make(map[string]bool)
+	for _, pref := range stored {
+		storedMap[pref.Category+"/"+pref.Channel] = pref.Enabled
+	}
+
+	responses := []*models.NotificationPreferenceResponse{}
+	for _, category := range models.NotificationCategories {
+		for _, channel := range channels {
+			if !models.IsNotificationCategorySupported(category.Key, channel) {
+				continue
+			}
+
+			mandatory := models.IsMandatoryNotification(category.Key, channel)
+			enabled, ok := storedMap[category.Key+"/"+channel]
+			if !ok {
+				enabled = models.IsNotificationEnabledByDefault(channel)
+			}
+			if mandatory {
+				enabled = true
+			}
+
+			responses = append(responses, &models.NotificationPreferenceResponse{
+				Category:  category.Key,
+				Channel:   channel,
+				Enabled:   enabled,
+				Mandatory: mandatory,
+			})
+		}
+	}
+
+	return responses, nil
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestNotificationPreferenceRepository_SetAndIsEnabled tests storing and reading preferences
+func TestNotificationPreferenceRepository_SetAndIsEnabled(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewNotificationPreferenceRepository(db)
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "User", "green")
+
+	t.Run("enabled by default", func(t *testing.T) {
+		enabled, err := repo.IsEnabled(userID, models.NotificationCategoryReminders, models.NotificationChannelEmail)
+		if err != nil {
+			t.Fatalf("IsEnabled() failed: %v", err)
+		}
+		if !enabled {
+			t.Error("Expected category without preference to be enabled")
+		}
+	})
+
+	t.Run("disable and enable", func(t *testing.T) {
+		if err := repo.Set(userID, models.NotificationCategoryReminders, models.NotificationChannelEmail, false); err != nil {
+			t.Fatalf("Set() failed: %v", err)
+		}
+
+		enabled, _ := repo.IsEnabled(userID, models.NotificationCategoryReminders, models.NotificationChannelEmail)
+		if enabled {
+			t.Error("Expected reminders to be disabled")
+		}
+
+		if err := repo.Set(userID, models.NotificationCategoryReminders, models.NotificationChannelEmail, true); err != nil {
+			t.Fatalf("Set() failed: %v", err)
+		}
+
+		enabled, _ = repo.IsEnabled(userID, models.NotificationCategoryReminders, models.NotificationChannelEmail)
+		if !enabled {
+			t.Error("Expected reminders to be enabled again")
+		}
+
+		if count := testutil.CountRows(t, db, "notification_preferences"); count != 1 {
+			t.Errorf("Expected 1 row, got %d", count)
+		}
+	})
+
+	t.Run("text channels are opt-in", func(t *testing.T) {
+		enabled, _ := repo.IsEnabled(userID, models.NotificationCategoryReminders, models.NotificationChannelSMS)
+		if enabled {
+			t.Error("Expected SMS to be disabled without preference")
+		}
+
+		repo.Set(userID, models.NotificationCategoryReminders, models.NotificationChannelSMS, true)
+
+		enabled, _ = repo.IsEnabled(userID, models.NotificationCategoryReminders, models.NotificationChannelSMS)
+		if !enabled {
+			t.Error("Expected SMS to be enabled after opting in")
+		}
+	})
+
+	t.Run("account notifications are email-only", func(t *testing.T) {
+		repo.Set(userID, models.NotificationCategoryAccount, models.NotificationChannelTelegram, true)
+
+		enabled, _ := repo.IsEnabled(userID, models.NotificationCategoryAccount, models.NotificationChannelTelegram)
+		if enabled {
+			t.Error("Expected account notifications to be unavailable on Telegram")
+		}
+	})
+
+	t.Run("mandatory category always enabled", func(t *testing.T) {
+		repo.Set(userID, models.NotificationCategoryAccount, models.NotificationChannelEmail, false)
+
+		enabled, err := repo.IsEnabled(userID, models.NotificationCategoryAccount, models.NotificationChannelEmail)
+		if err != nil {
+			t.Fatalf("IsEnabled() failed: %v", err)
+		}
+		if !enabled {
+			t.Error("Expected mandatory category to be enabled")
+		}
+	})
+}
+
+// TestNotificationPreferenceRepository_GetEffective tests the merged view of all categories
+func TestNotificationPreferenceRepository_GetEffective(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewNotificationPreferenceRepository(db)
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "User", "green")
+
+	repo.Set(userID, models.NotificationCategoryAnnouncements, models.NotificationChannelEmail, false)
+
+	preferences, err := repo.GetEffective(userID, models.NotificationChannels)
+	if err != nil {
+		t.Fatalf("GetEffective() failed: %v", err)
+	}
+
+	// Account notifications are email-only
+	expected := len(models.NotificationCategories)*len(models.NotificationChannels) - (len(models.NotificationChannels) - 1)
+	if len(preferences) != expected {
+		t.Fatalf("Expected %d entries, got %d", expected, len(preferences))
+	}
+
+	for _, pref := range preferences {
+		disabled := pref.Channel == models.NotificationChannelEmail && pref.Category == models.NotificationCategoryAnnouncements
+		expectEnabled := models.IsNotificationEnabledByDefault(pref.Channel) && !disabled
+		if pref.Enabled != expectEnabled {
+			t.Errorf("Category %s/%s: expected enabled=%v, got %v", pref.Category, pref.Channel, expectEnabled, pref.Enabled)
+		}
+		if pref.Mandatory != models.IsMandatoryNotification(pref.Category, pref.Channel) {
+			t.Errorf("Category %s/%s: unexpected mandatory flag", pref.Category, pref.Channel)
+		}
+	}
+
+	t.Run("only requested channels", func(t *testing.T) {
+		preferences, err := repo.GetEffective(userID, []string{models.NotificationChannelEmail})
+		if err != nil {
+			t.Fatalf("GetEffective() failed: %v", err)
+		}
+		if len(preferences) != len(models.NotificationCategories) {
+			t.Errorf("Expected one entry per category, got %d", len(preferences))
+		}
+	})
+}
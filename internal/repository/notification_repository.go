@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+)
+
+// NotificationRepository handles the in-app notification center
+type NotificationRepository struct {
+	db *sql.DB
+}
+
+// NewNotificationRepository creates a new notification repository
+func NewNotificationRepository(db *sql.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// Create stores a notification for a user
+// A notification with an event ID is stored once per event, user and type, storing it again does nothing.
+func (r *NotificationRepository) Create(notification *models.Notification) error {
+	params, err := encodeNotificationParams(notification.Params)
+	if err != nil {
+		return err
+	}
+
+	if notification.EventID != nil {
+		var existing int
+		err := r.db.QueryRow(`SELECT COUNT(*) FROM notifications WHERE event_id = ? AND user_id = ? AND type = ?`,
+			*notification.EventID, notification.UserID, notification.Type).Scan(&existing)
+		if err != nil {
+			return fmt.Errorf("failed to check notification: %w", err)
+		}
+		if existing > 0 {
+			return nil
+		}
+	}
+
+	now := time.Now()
+	result, err := r.db.Exec(`
+		INSERT INTO notifications (user_id, type, params, link, event_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, notification.UserID, notification.Type, params, notification.Link, notification.EventID, now)
+	if err != nil {
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get notification ID: %w", err)
+	}
+	notification.ID = int(id)
+	notification.CreatedAt = now
+	return nil
+}
+
+// CreateForStaff stores a notification for every active user who holds the permission
+// The permission is granted by the admin flags (see models.FlagRoles), assigned built-in roles or custom roles.
+// With an event ID, users who already have the notification of that event are skipped.
+// Returns the number of users notified
+func (r *NotificationRepository) CreateForStaff(permission, notificationType string, params []string, link *string, eventID *int) (int, error) {
+	encoded, err := encodeNotificationParams(params)
+	if err != nil {
+		return 0, err
+	}
+
+	// Super admins hold every permission
+	flags := "is_super_admin = 1"
+	args := []interface{}{notificationType, encoded, link, eventID, time.Now()}
+	assigned := []string{}
+	for _, name := range models.BuiltinRolesWith(permission) {
+		switch name {
+		case models.RoleSuperAdmin:
+		case models.RoleAdmin:
+			flags += " OR is_admin = 1"
+		default:
+			assigned = append(assigned, name)
+			args = append(args, name)
+		}
+	}
+	roles := "r.is_builtin = 0 AND EXISTS (SELECT 1 FROM role_permissions rp WHERE rp.role_id = r.id AND rp.permission = ?)"
+	if len(assigned) > 0 {
+		roles = "r.name IN (" + placeholders(len(assigned)) + ") OR (" + roles + ")"
+	}
+	args = append(args, permission)
+
+	recorded := ""
+	if eventID != nil {
+		recorded = " AND id NOT IN (SELECT n.user_id FROM notifications n WHERE n.event_id = ? AND n.type = ?)"
+		args = append(args, *eventID, notificationType)
+	}
+
+	result, err := r.db.Exec(`
+		INSERT INTO notifications (user_id, type, params, link, event_id, created_at)
+		SELECT id, ?, ?, ?, ?, ?
+		FROM users
+		WHERE is_active = 1 AND is_deleted = 0 AND (`+flags+` OR id IN (
+			SELECT ur.user_id FROM user_roles ur JOIN roles r ON r.id = ur.role_id
+			WHERE `+roles+`
+		))`+recorded, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create admin notifications: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return int(rowsAffected), nil
+}
+
+// FindByUser returns a user's notifications, newest first
+func (r *NotificationRepository) FindByUser(userID int, unreadOnly bool, limit, offset int) ([]*models.Notification, error) {
+	query := `
+		SELECT id, user_id, type, params, link, read_at, created_at
+		FROM notifications
+		WHERE user_id = ?
+	`
+	if unreadOnly {
+		query += ` AND read_at IS NULL`
+	}
+	query += ` ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?`
+
+	rows, err := r.db.Query(query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []*models.Notification{}
+	for rows.Next() {
+		notification := &models.Notification{}
+		var params string
+		var link sql.NullString
+		var readAt sql.NullTime
+		if err := rows.Scan(&notification.ID, &notification.UserID, &notification.Type, &params, &link, &readAt, &notification.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		if err := json.Unmarshal([]byte(params), &notification.Params); err != nil {
+			return nil, fmt.Errorf("failed to decode notification params: %w", err)
+		}
+		if link.Valid {
+			notification.Link = &link.String
+		}
+		if readAt.Valid {
+			notification.ReadAt = &readAt.Time
+		}
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, nil
+}
+
+// CountUnread returns the number of unread notifications of a user
+func (r *NotificationRepository) CountUnread(userID int) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+	return count, nil
+}
+
+// MarkRead marks a notification of the user as read
+// Returns false if the notification does not exist or belongs to another user
+func (r *NotificationRepository) MarkRead(userID, id int) (bool, error) {
+	var exists int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM notifications WHERE id = ? AND user_id = ?`, id, userID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to get notification: %w", err)
+	}
+	if exists == 0 {
+		return false, nil
+	}
+
+	_, err = r.db.Exec(`
+		UPDATE notifications SET read_at = ?
+		WHERE id = ? AND user_id = ? AND read_at IS NULL
+	`, time.Now(), id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark notification as read: %w", err)
+	}
+	return true, nil
+}
+
+// MarkAllRead marks all notifications of the user as read
+// Returns the number of notifications marked
+func (r *NotificationRepository) MarkAllRead(userID int) (int, error) {
+	result, err := r.db.Exec(`
+		UPDATE notifications SET read_at = ?
+		WHERE user_id = ? AND read_at IS NULL
+	`, time.Now(), userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications as read: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return int(rowsAffected), nil
+}
+
+// encodeNotificationParams stores params as a JSON array
+func encodeNotificationParams(params []string) (string, error) {
+	if params == nil {
+		params = []string{}
+	}
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode notification params: %w", err)
+	}
+	return string(encoded), nil
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestNotificationRepository_CreateAndList tests storing and listing notifications per user
+func TestNotificationRepository_CreateAndList(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewNotificationRepository(db)
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "User", "green")
+	otherID := testutil.SeedTestUser(t, db, "other@example.com", "Other", "green")
+
+	first := &models.Notification{UserID: userID, Type: "booking_confirmation", Params: []string{"Bella", "2025-12-01", "09:00"}, Link: models.NotificationLink("booking_confirmation")}
+	second := &models.Notification{UserID: userID, Type: "welcome"}
+	if err := repo.Create(first); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	if err := repo.Create(second); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	if err := repo.Create(&models.Notification{UserID: otherID, Type: "welcome"}); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+
+	notifications, err := repo.FindByUser(userID, false, 50, 0)
+	if err != nil {
+		t.Fatalf("FindByUser() failed: %v", err)
+	}
+	if len(notifications) != 2 {
+		t.Fatalf("Expected 2 notifications, got %d", len(notifications))
+	}
+	if notifications[0].ID != second.ID {
+		t.Errorf("Expected newest notification first, got %d", notifications[0].ID)
+	}
+	if got := notifications[1].Params; len(got) != 3 || got[0] != "Bella" {
+		t.Errorf("Expected params to round-trip, got %v", got)
+	}
+	if notifications[1].Link == nil || *notifications[1].Link != "/dashboard.html" {
+		t.Errorf("Expected link /dashboard.html, got %v", notifications[1].Link)
+	}
+	if notifications[0].Params == nil {
+		t.Error("Expected empty params, got nil")
+	}
+
+	t.Run("pagination", func(t *testing.T) {
+		page, _ := repo.FindByUser(userID, false, 1, 1)
+		if len(page) != 1 || page[0].ID != first.ID {
+			t.Errorf("Expected second page to contain the older notification, got %v", page)
+		}
+	})
+
+	t.Run("mark read", func(t *testing.T) {
+		found, err := repo.MarkRead(otherID, first.ID)
+		if err != nil || found {
+			t.Errorf("Expected another user's notification to be untouched, got %v (%v)", found, err)
+		}
+
+		found, err = repo.MarkRead(userID, first.ID)
+		if err != nil || !found {
+			t.Fatalf("MarkRead() = %v, %v", found, err)
+		}
+
+		if count, _ := repo.CountUnread(userID); count != 1 {
+			t.Errorf("Expected 1 unread notification, got %d", count)
+		}
+		unread, _ := repo.FindByUser(userID, true, 50, 0)
+		if len(unread) != 1 || unread[0].ID != second.ID {
+			t.Errorf("Expected only the unread notification, got %v", unread)
+		}
+	})
+
+	t.Run("mark all read", func(t *testing.T) {
+		marked, err := repo.MarkAllRead(userID)
+		if err != nil || marked != 1 {
+			t.Errorf("MarkAllRead() = %d, %v, expected 1", marked, err)
+		}
+		if count, _ := repo.CountUnread(userID); count != 0 {
+			t.Errorf("Expected no unread notifications, got %d", count)
+		}
+		if count, _ := repo.CountUnread(otherID); count != 1 {
+			t.Errorf("Expected other user's notification to stay unread, got %d", count)
+		}
+	})
+}
+
+// TestNotificationRepository_CreateForEvent tests that a notification of a domain event is stored once
+func TestNotificationRepository_CreateForEvent(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewNotificationRepository(db)
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "User", "green")
+	adminID := testutil.SeedTestUser(t, db, "admin@example.com", "Admin", "orange")
+	db.Exec("UPDATE users SET is_admin = 1 WHERE id = ?", adminID)
+
+	eventID := 7
+	for i := 0; i < 2; i++ {
+		if err := repo.Create(&models.Notification{UserID: userID, Type: "booking_confirmation", EventID: &eventID}); err != nil {
+			t.Fatalf("Create() failed: %v", err)
+		}
+		if _, err := repo.CreateForStaff(models.PermissionManageBookings, models.NotificationTypeAdminBookingPending, nil, nil, &eventID); err != nil {
+			t.Fatalf("CreateForStaff() failed: %v", err)
+		}
+	}
+	repo.Create(&models.Notification{UserID: userID, Type: "welcome"})
+	repo.Create(&models.Notification{UserID: userID, Type: "welcome"})
+
+	if count, _ := repo.CountUnread(userID); count != 3 {
+		t.Errorf("Expected the event notification once and both others, got %d", count)
+	}
+	if count, _ := repo.CountUnread(adminID); count != 1 {
+		t.Errorf("Expected the admin notification once, got %d", count)
+	}
+}
+
+// TestNotificationRepository_CreateForStaff tests notifying all active staff who hold the permission
+func TestNotificationRepository_CreateForStaff(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewNotificationRepository(db)
+	roles := NewRoleRepository(db)
+	testutil.SeedTestUser(t, db, "user@example.com", "User", "green")
+	adminID := testutil.SeedTestUser(t, db, "admin@example.com", "Admin", "blue")
+	inactiveID := testutil.SeedTestUser(t, db, "inactive@example.com", "Inactive", "blue")
+	staffID := testutil.SeedTestUser(t, db, "staff@example.com", "Staff", "blue")
+	schedulerID := testutil.SeedTestUser(t, db, "scheduler@example.com", "Scheduler", "blue")
+	db.Exec("UPDATE users SET is_admin = 1 WHERE id IN (?, ?)", adminID, inactiveID)
+	db.Exec("UPDATE users SET is_active = 0 WHERE id = ?", inactiveID)
+
+	volunteers := &models.Role{Name: "volunteer_coordinator", Permissions: []string{models.PermissionManageUsers}}
+	if err := roles.Create(volunteers); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	scheduler, _ := roles.FindByName(models.RoleScheduler)
+	roles.SetUserRoles(staffID, []int{volunteers.ID})
+	roles.SetUserRoles(schedulerID, []int{scheduler.ID})
+
+	count, err := repo.CreateForStaff(models.PermissionManageUsers, models.NotificationTypeAdminExperienceRequest, []string{"User", "@levels.orange"}, nil, nil)
+	if err != nil {
+		t.Fatalf("CreateForStaff() failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected the admin and the custom role staff to be notified, got %d", count)
+	}
+
+	for _, id := range []int{adminID, staffID} {
+		notifications, _ := repo.FindByUser(id, false, 50, 0)
+		if len(notifications) != 1 || notifications[0].Type != models.NotificationTypeAdminExperienceRequest {
+			t.Errorf("Expected admin notification for user %d, got %v", id, notifications)
+		}
+	}
+
+	t.Run("built-in role", func(t *testing.T) {
+		count, err := repo.CreateForStaff(models.PermissionManageBookings, models.NotificationTypeAdminBookingPending, []string{"User", "Rex"}, nil, nil)
+		if err != nil || count != 2 {
+			t.Errorf("Expected the admin and the scheduler to be notified, got %d (%v)", count, err)
+		}
+		if notifications, _ := repo.FindByUser(staffID, false, 50, 0); len(notifications) != 1 {
+			t.Errorf("Expected staff without the permission not to be notified, got %d", len(notifications))
+		}
+	})
+}
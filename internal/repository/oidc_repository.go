@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+)
+
+// OIDCRepository handles the identities linked to users and the logins at the identity provider in progress
+type OIDCRepository struct {
+	db DBTX
+}
+
+// NewOIDCRepository creates a new OIDC repository
+func NewOIDCRepository(db *sql.DB) *OIDCRepository {
+	return &OIDCRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries in the transaction
+func (r *OIDCRepository) WithTx(tx *sql.Tx) *OIDCRepository {
+	return &OIDCRepository{db: tx}
+}
+
+// FindIdentity returns the identity with the issuer and subject, nil if it is not linked
+func (r *OIDCRepository) FindIdentity(issuer, subject string) (*models.UserIdentity, error) {
+	identity := &models.UserIdentity{}
+	var lastLoginAt sql.NullTime
+	err := r.db.QueryRow(`
+		SELECT id, user_id, issuer, subject, email, created_at, last_login_at
+		FROM user_identities WHERE issuer = ? AND subject = ?
+	`, issuer, subject).Scan(&identity.ID, &identity.UserID, &identity.Issuer, &identity.Subject, &identity.Email,
+		&identity.CreatedAt, &lastLoginAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find identity: %w", err)
+	}
+	if lastLoginAt.Valid {
+		identity.LastLoginAt = &lastLoginAt.Time
+	}
+	return identity, nil
+}
+
+// CreateIdentity links an identity to a user
+func (r *OIDCRepository) CreateIdentity(identity *models.UserIdentity) error {
+	result, err := r.db.Exec(`
+		INSERT INTO user_identities (user_id, issuer, subject, email, created_at, last_login_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, identity.UserID, identity.Issuer, identity.Subject, identity.Email, identity.CreatedAt, identity.LastLoginAt)
+	if err != nil {
+		return fmt.Errorf("failed to create identity: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get identity ID: %w", err)
+	}
+	identity.ID = int(id)
+	return nil
+}
+
+// UpdateIdentityLogin records a login with the identity and the email address the provider returned
+func (r *OIDCRepository) UpdateIdentityLogin(id int, email string, at time.Time) error {
+	_, err := r.db.Exec(`UPDATE user_identities SET email = ?, last_login_at = ? WHERE id = ?`, email, at, id)
+	if err != nil {
+		return fmt.Errorf("failed to update identity: %w", err)
+	}
+	return nil
+}
+
+// DeleteIdentitiesForUser unlinks all identities of a user
+func (r *OIDCRepository) DeleteIdentitiesForUser(userID int) error {
+	if _, err := r.db.Exec(`DELETE FROM user_identities WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("failed to delete identities: %w", err)
+	}
+	return nil
+}
+
+// CreateLogin stores a login that was sent to the identity provider
+func (r *OIDCRepository) CreateLogin(login *models.OIDCLogin) error {
+	result, err := r.db.Exec(`
+		INSERT INTO oidc_logins (state_hash, nonce, code_verifier, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, login.StateHash, login.Nonce, login.CodeVerifier, login.ExpiresAt, login.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create OIDC login: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get OIDC login ID: %w", err)
+	}
+	login.ID = int(id)
+	return nil
+}
+
+// FindLoginByState returns the login with the state hash, nil if there is none
+func (r *OIDCRepository) FindLoginByState(stateHash string) (*models.OIDCLogin, error) {
+	return r.findLogin(`state_hash = ?`, stateHash)
+}
+
+// CompleteLogin stores the user and the login code, false if the login was completed already
+func (r *OIDCRepository) CompleteLogin(id, userID int, loginCodeHash string, expiresAt time.Time) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE oidc_logins SET user_id = ?, login_code_hash = ?, expires_at = ?
+		WHERE id = ? AND login_code_hash IS NULL
+	`, userID, loginCodeHash, expiresAt, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to complete OIDC login: %w", err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to complete OIDC login: %w", err)
+	}
+	return updated == 1, nil
+}
+
+// TakeLoginByCode deletes and returns the completed login with the code hash, nil if there is none
+// Each login code can be exchanged only once.
+func (r *OIDCRepository) TakeLoginByCode(loginCodeHash string) (*models.OIDCLogin, error) {
+	login, err := r.findLogin(`login_code_hash = ?`, loginCodeHash)
+	if err != nil || login == nil {
+		return nil, err
+	}
+
+	result, err := r.db.Exec(`DELETE FROM oidc_logins WHERE id = ?`, login.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete OIDC login: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete OIDC login: %w", err)
+	}
+	if deleted == 0 {
+		// Taken concurrently
+		return nil, nil
+	}
+	return login, nil
+}
+
+// DeleteExpiredLogins deletes logins that expired before the given time and returns their number
+func (r *OIDCRepository) DeleteExpiredLogins(before time.Time) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM oidc_logins WHERE expires_at < ?`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired OIDC logins: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+func (r *OIDCRepository) findLogin(where string, args ...interface{}) (*models.OIDCLogin, error) {
+	login := &models.OIDCLogin{}
+	var userID sql.NullInt64
+	var loginCodeHash sql.NullString
+	err := r.db.QueryRow(`
+		SELECT id, state_hash, nonce, code_verifier, user_id, login_code_hash, expires_at, created_at
+		FROM oidc_logins WHERE `+where, args...).Scan(&login.ID, &login.StateHash, &login.Nonce, &login.CodeVerifier,
+		&userID, &loginCodeHash, &login.ExpiresAt, &login.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find OIDC login: %w", err)
+	}
+	if userID.Valid {
+		id := int(userID.Int64)
+		login.UserID = &id
+	}
+	if loginCodeHash.Valid {
+		login.LoginCodeHash = &loginCodeHash.String
+	}
+	return login, nil
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestOIDCRepository_Identities tests linking, finding and unlinking identities
+func TestOIDCRepository_Identities(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewOIDCRepository(db)
+
+	userID := testutil.SeedTestUser(t, db, "staff@example.com", "Staff", "green")
+	now := time.Now()
+
+	identity := &models.UserIdentity{UserID: userID, Issuer: "https://idp.example.com", Subject: "staff-1", Email: "staff@example.com", CreatedAt: now}
+	if err := repo.CreateIdentity(identity); err != nil {
+		t.Fatalf("CreateIdentity() failed: %v", err)
+	}
+	if err := repo.CreateIdentity(&models.UserIdentity{UserID: userID, Issuer: "https://idp.example.com", Subject: "staff-1", Email: "x", CreatedAt: now}); err == nil {
+		t.Error("Expected a subject to be linked only once per issuer")
+	}
+
+	if err := repo.UpdateIdentityLogin(identity.ID, "renamed@example.com", now); err != nil {
+		t.Fatalf("UpdateIdentityLogin() failed: %v", err)
+	}
+	found, err := repo.FindIdentity("https://idp.example.com", "staff-1")
+	if err != nil || found == nil || found.UserID != userID || found.Email != "renamed@example.com" || found.LastLoginAt == nil {
+		t.Fatalf("Unexpected identity %+v (%v)", found, err)
+	}
+	if found, _ := repo.FindIdentity("https://other.example.com", "staff-1"); found != nil {
+		t.Error("Expected no identity for another issuer")
+	}
+
+	repo.DeleteIdentitiesForUser(userID)
+	if found, _ := repo.FindIdentity("https://idp.example.com", "staff-1"); found != nil {
+		t.Error("Expected the identity to be deleted")
+	}
+}
+
+// TestOIDCRepository_Logins tests completing and taking logins
+func TestOIDCRepository_Logins(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewOIDCRepository(db)
+
+	userID := testutil.SeedTestUser(t, db, "staff@example.com", "Staff", "green")
+	now := time.Now()
+
+	login := &models.OIDCLogin{StateHash: "state", Nonce: "nonce", CodeVerifier: "verifier", ExpiresAt: now.Add(10 * time.Minute), CreatedAt: now}
+	if err := repo.CreateLogin(login); err != nil {
+		t.Fatalf("CreateLogin() failed: %v", err)
+	}
+	repo.CreateLogin(&models.OIDCLogin{StateHash: "expired", Nonce: "n", CodeVerifier: "v", ExpiresAt: now.Add(-time.Minute), CreatedAt: now})
+
+	found, err := repo.FindLoginByState("state")
+	if err != nil || found == nil || found.Nonce != "nonce" || found.CodeVerifier != "verifier" || found.UserID != nil {
+		t.Fatalf("Unexpected login %+v (%v)", found, err)
+	}
+
+	if completed, err := repo.CompleteLogin(login.ID, userID, "code", now.Add(time.Minute)); err != nil || !completed {
+		t.Fatalf("CompleteLogin() = %v (%v), want true", completed, err)
+	}
+	if completed, _ := repo.CompleteLogin(login.ID, userID, "code-2", now.Add(time.Minute)); completed {
+		t.Error("Expected the login to be completed only once")
+	}
+
+	taken, err := repo.TakeLoginByCode("code")
+	if err != nil || taken == nil || taken.UserID == nil || *taken.UserID != userID {
+		t.Fatalf("Unexpected login %+v (%v)", taken, err)
+	}
+	if taken, _ := repo.TakeLoginByCode("code"); taken != nil {
+		t.Error("Expected the login code to be taken only once")
+	}
+
+	if deleted, _ := repo.DeleteExpiredLogins(now); deleted != 1 {
+		t.Errorf("Expected 1 expired login to be deleted, got %d", deleted)
+	}
+}
@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+)
+
+// PasskeyRepository handles passkeys and the challenges of their ceremonies
+type PasskeyRepository struct {
+	db DBTX
+}
+
+// NewPasskeyRepository creates a new passkey repository
+func NewPasskeyRepository(db *sql.DB) *PasskeyRepository {
+	return &PasskeyRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries in the transaction
+func (r *PasskeyRepository) WithTx(tx *sql.Tx) *PasskeyRepository {
+	return &PasskeyRepository{db: tx}
+}
+
+const passkeyColumns = `id, user_id, credential_id, public_key, sign_count, name, transports, created_at, last_used_at`
+
+// Create stores a new passkey
+func (r *PasskeyRepository) Create(passkey *models.Passkey) error {
+	result, err := r.db.Exec(`
+		INSERT INTO passkeys (user_id, credential_id, public_key, sign_count, name, transports, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, passkey.UserID, passkey.CredentialID, passkey.PublicKey, passkey.SignCount, passkey.Name,
+		strings.Join(passkey.Transports, ","), passkey.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create passkey: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get passkey ID: %w", err)
+	}
+	passkey.ID = int(id)
+	return nil
+}
+
+// FindByCredentialID returns the passkey with the credential ID, nil if there is none
+func (r *PasskeyRepository) FindByCredentialID(credentialID string) (*models.Passkey, error) {
+	passkey, err := scanPasskey(r.db.QueryRow(`SELECT `+passkeyColumns+` FROM passkeys WHERE credential_id = ?`, credentialID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find passkey: %w", err)
+	}
+	return passkey, nil
+}
+
+// FindByID returns a passkey of the user, nil if the user has no passkey with the ID
+func (r *PasskeyRepository) FindByID(userID, id int) (*models.Passkey, error) {
+	passkey, err := scanPasskey(r.db.QueryRow(`SELECT `+passkeyColumns+` FROM passkeys WHERE id = ? AND user_id = ?`, id, userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find passkey: %w", err)
+	}
+	return passkey, nil
+}
+
+// FindByUser returns the passkeys of a user, oldest first
+func (r *PasskeyRepository) FindByUser(userID int) ([]*models.Passkey, error) {
+	rows, err := r.db.Query(`SELECT `+passkeyColumns+` FROM passkeys WHERE user_id = ? ORDER BY created_at, id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list passkeys: %w", err)
+	}
+	defer rows.Close()
+
+	passkeys := []*models.Passkey{}
+	for rows.Next() {
+		passkey, err := scanPasskey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan passkey: %w", err)
+		}
+		passkeys = append(passkeys, passkey)
+	}
+	return passkeys, rows.Err()
+}
+
+// Rename changes the name of a passkey of the user
+func (r *PasskeyRepository) Rename(userID, id int, name string) error {
+	_, err := r.db.Exec(`UPDATE passkeys SET name = ? WHERE id = ? AND user_id = ?`, name, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to rename passkey: %w", err)
+	}
+	return nil
+}
+
+// UpdateSignCount records a login with the passkey
+// The update only applies if the counter did not change in between, false otherwise.
+func (r *PasskeyRepository) UpdateSignCount(id int, oldCount, newCount uint32, usedAt time.Time) (bool, error) {
+	result, err := r.db.Exec(`UPDATE passkeys SET sign_count = ?, last_used_at = ? WHERE id = ? AND sign_count = ?`,
+		newCount, usedAt, id, oldCount)
+	if err != nil {
+		return false, fmt.Errorf("failed to update passkey: %w", err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to update passkey: %w", err)
+	}
+	return updated == 1, nil
+}
+
+// Delete deletes a passkey of the user
+func (r *PasskeyRepository) Delete(userID, id int) error {
+	if _, err := r.db.Exec(`DELETE FROM passkeys WHERE id = ? AND user_id = ?`, id, userID); err != nil {
+		return fmt.Errorf("failed to delete passkey: %w", err)
+	}
+	return nil
+}
+
+// DeleteAllForUser deletes all passkeys of a user, e.g. when the account is deleted
+func (r *PasskeyRepository) DeleteAllForUser(userID int) error {
+	if _, err := r.db.Exec(`DELETE FROM passkeys WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("failed to delete passkeys: %w", err)
+	}
+	return nil
+}
+
+// CreateChallenge stores the challenge of a ceremony
+func (r *PasskeyRepository) CreateChallenge(challenge *models.WebAuthnChallenge) error {
+	result, err := r.db.Exec(`
+		INSERT INTO webauthn_challenges (user_id, challenge, ceremony, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, challenge.UserID, challenge.Challenge, challenge.Ceremony, challenge.ExpiresAt, challenge.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create WebAuthn challenge: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get WebAuthn challenge ID: %w", err)
+	}
+	challenge.ID = int(id)
+	return nil
+}
+
+// TakeChallenge deletes and returns a challenge, nil if it does not exist or was taken already
+// Each challenge can be answered only once.
+func (r *PasskeyRepository) TakeChallenge(value string) (*models.WebAuthnChallenge, error) {
+	challenge := &models.WebAuthnChallenge{}
+	var userID sql.NullInt64
+	err := r.db.QueryRow(`
+		SELECT id, user_id, challenge, ceremony, expires_at, created_at FROM webauthn_challenges WHERE challenge = ?
+	`, value).Scan(&challenge.ID, &userID, &challenge.Challenge, &challenge.Ceremony, &challenge.ExpiresAt, &challenge.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find WebAuthn challenge: %w", err)
+	}
+	if userID.Valid {
+		id := int(userID.Int64)
+		challenge.UserID = &id
+	}
+
+	result, err := r.db.Exec(`DELETE FROM webauthn_challenges WHERE id = ?`, challenge.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete WebAuthn challenge: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete WebAuthn challenge: %w", err)
+	}
+	if deleted == 0 {
+		// Taken concurrently
+		return nil, nil
+	}
+	return challenge, nil
+}
+
+// DeleteExpiredChallenges deletes challenges that expired before the given time and returns their number
+func (r *PasskeyRepository) DeleteExpiredChallenges(before time.Time) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM webauthn_challenges WHERE expires_at < ?`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired WebAuthn challenges: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+func scanPasskey(scanner interface{ Scan(...interface{}) error }) (*models.Passkey, error) {
+	passkey := &models.Passkey{}
+	var transports string
+	var lastUsedAt sql.NullTime
+	err := scanner.Scan(&passkey.ID, &passkey.UserID, &passkey.CredentialID, &passkey.PublicKey, &passkey.SignCount,
+		&passkey.Name, &transports, &passkey.CreatedAt, &lastUsedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	passkey.Transports = []string{}
+	if transports != "" {
+		passkey.Transports = strings.Split(transports, ",")
+	}
+	if lastUsedAt.Valid {
+		passkey.LastUsedAt = &lastUsedAt.Time
+	}
+	return passkey, nil
+}
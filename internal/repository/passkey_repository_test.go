@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestPasskeyRepository tests passkeys of several users, the counter update and single-use challenges
+func TestPasskeyRepository(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewPasskeyRepository(db)
+
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "User", "green")
+	otherID := testutil.SeedTestUser(t, db, "other@example.com", "Other", "green")
+
+	passkey := &models.Passkey{UserID: userID, CredentialID: "cred-1", PublicKey: "key", Name: "Phone",
+		Transports: []string{"internal", "hybrid"}, CreatedAt: time.Now()}
+	if err := repo.Create(passkey); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	repo.Create(&models.Passkey{UserID: otherID, CredentialID: "cred-2", PublicKey: "key", Name: "Key", CreatedAt: time.Now()})
+
+	if err := repo.Create(&models.Passkey{UserID: otherID, CredentialID: "cred-1", PublicKey: "key", Name: "Copy", CreatedAt: time.Now()}); err == nil {
+		t.Error("Expected the credential ID to be unique")
+	}
+
+	found, err := repo.FindByCredentialID("cred-1")
+	if err != nil || found == nil || found.UserID != userID || len(found.Transports) != 2 || found.LastUsedAt != nil {
+		t.Fatalf("Unexpected passkey %+v (%v)", found, err)
+	}
+	if found, _ := repo.FindByID(otherID, passkey.ID); found != nil {
+		t.Error("Expected passkeys of other users not to be found")
+	}
+	if passkeys, _ := repo.FindByUser(otherID); len(passkeys) != 1 || len(passkeys[0].Transports) != 0 {
+		t.Errorf("Expected one passkey without transports, got %+v", passkeys)
+	}
+
+	repo.Rename(otherID, passkey.ID, "Stolen")
+	repo.Rename(userID, passkey.ID, "Laptop")
+	if found, _ := repo.FindByID(userID, passkey.ID); found.Name != "Laptop" {
+		t.Errorf("Expected name Laptop, got %s", found.Name)
+	}
+
+	// The counter is only updated from the expected value
+	if updated, err := repo.UpdateSignCount(passkey.ID, 0, 5, time.Now()); err != nil || !updated {
+		t.Fatalf("UpdateSignCount() = %v (%v), want true", updated, err)
+	}
+	if updated, _ := repo.UpdateSignCount(passkey.ID, 0, 6, time.Now()); updated {
+		t.Error("Expected the update from an outdated counter to fail")
+	}
+	if found, _ := repo.FindByID(userID, passkey.ID); found.SignCount != 5 || found.LastUsedAt == nil {
+		t.Errorf("Expected counter 5 and a last use, got %+v", found)
+	}
+
+	// Challenges are taken once
+	repo.CreateChallenge(&models.WebAuthnChallenge{UserID: &userID, Challenge: "abc", Ceremony: models.CeremonyRegistration,
+		ExpiresAt: time.Now().Add(time.Minute), CreatedAt: time.Now()})
+	repo.CreateChallenge(&models.WebAuthnChallenge{Challenge: "old", Ceremony: models.CeremonyLogin,
+		ExpiresAt: time.Now().Add(-time.Minute), CreatedAt: time.Now()})
+
+	challenge, err := repo.TakeChallenge("abc")
+	if err != nil || challenge == nil || challenge.UserID == nil || *challenge.UserID != userID {
+		t.Fatalf("Unexpected challenge %+v (%v)", challenge, err)
+	}
+	if challenge, _ := repo.TakeChallenge("abc"); challenge != nil {
+		t.Error("Expected the challenge to be taken only once")
+	}
+	if deleted, _ := repo.DeleteExpiredChallenges(time.Now()); deleted != 1 {
+		t.Errorf("Expected 1 expired challenge to be deleted, got %d", deleted)
+	}
+
+	repo.Delete(otherID, passkey.ID)
+	if found, _ := repo.FindByID(userID, passkey.ID); found == nil {
+		t.Error("Expected other users not to delete the passkey")
+	}
+	repo.DeleteAllForUser(userID)
+	if passkeys, _ := repo.FindByUser(userID); len(passkeys) != 0 {
+		t.Errorf("Expected no passkeys, got %d", len(passkeys))
+	}
+}
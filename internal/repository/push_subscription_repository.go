@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+)
+
+// PushSubscriptionRepository handles Web Push subscriptions and the VAPID key pair
+type PushSubscriptionRepository struct {
+	db *sql.DB
+}
+
+// NewPushSubscriptionRepository creates a new push subscription repository
+func NewPushSubscriptionRepository(db *sql.DB) *PushSubscriptionRepository {
+	return &PushSubscriptionRepository{db: db}
+}
+
+// Save stores a subscription, the endpoint identifies the device
+// Re-subscribing a device (e.g. after a key rotation or another user logging in) replaces the old row
+func (r *PushSubscriptionRepository) Save(sub *models.PushSubscription) error {
+	now := time.Now()
+
+	result, err := r.db.Exec(`
+		UPDATE push_subscriptions SET user_id = ?, p256dh = ?, auth = ?, user_agent = ?
+		WHERE endpoint = ?
+	`, sub.UserID, sub.P256dh, sub.Auth, sub.UserAgent, sub.Endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to update push subscription: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		result, err = r.db.Exec(`
+			INSERT INTO push_subscriptions (user_id, endpoint, p256dh, auth, user_agent, created_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, sub.UserID, sub.Endpoint, sub.P256dh, sub.Auth, sub.UserAgent, now)
+		if err != nil {
+			return fmt.Errorf("failed to create push subscription: %w", err)
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get push subscription ID: %w", err)
+		}
+		sub.ID = int(id)
+		sub.CreatedAt = now
+		return nil
+	}
+
+	err = r.db.QueryRow(`SELECT id, created_at FROM push_subscriptions WHERE endpoint = ?`, sub.Endpoint).Scan(&sub.ID, &sub.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to get push subscription: %w", err)
+	}
+	return nil
+}
+
+// FindByUser returns all subscriptions of a user, newest first
+func (r *PushSubscriptionRepository) FindByUser(userID int) ([]*models.PushSubscription, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, endpoint, p256dh, auth, user_agent, created_at, last_used_at
+		FROM push_subscriptions
+		WHERE user_id = ?
+		ORDER BY created_at DESC, id DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query push subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subscriptions := []*models.PushSubscription{}
+	for rows.Next() {
+		sub := &models.PushSubscription{}
+		var userAgent sql.NullString
+		var lastUsedAt sql.NullTime
+		if err := rows.Scan(&sub.ID, &sub.UserID, &sub.Endpoint, &sub.P256dh, &sub.Auth, &userAgent, &sub.CreatedAt, &lastUsedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan push subscription: %w", err)
+		}
+		if userAgent.Valid {
+			sub.UserAgent = &userAgent.String
+		}
+		if lastUsedAt.Valid {
+			sub.LastUsedAt = &lastUsedAt.Time
+		}
+		subscriptions = append(subscriptions, sub)
+	}
+
+	return subscriptions, nil
+}
+
+// CountByUser returns the number of subscribed devices of a user
+func (r *PushSubscriptionRepository) CountByUser(userID int) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM push_subscriptions WHERE user_id = ?`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count push subscriptions: %w", err)
+	}
+	return count, nil
+}
+
+// MarkUsed records a successful delivery to a subscription
+func (r *PushSubscriptionRepository) MarkUsed(id int) error {
+	_, err := r.db.Exec(`UPDATE push_subscriptions SET last_used_at = ? WHERE id = ?`, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update push subscription: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a subscription (used for expired endpoints)
+func (r *PushSubscriptionRepository) Delete(id int) error {
+	_, err := r.db.Exec(`DELETE FROM push_subscriptions WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete push subscription: %w", err)
+	}
+	return nil
+}
+
+// DeleteForUser removes a subscription of a user
+// Returns false if the subscription does not exist or belongs to another user
+func (r *PushSubscriptionRepository) DeleteForUser(userID, id int) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM push_subscriptions WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete push subscription: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0, nil
+}
+
+// GetVAPIDKeys returns the stored VAPID key pair (base64url)
+// Returns empty strings if no key pair was generated yet
+func (r *PushSubscriptionRepository) GetVAPIDKeys() (string, string, error) {
+	var publicKey, privateKey string
+	err := r.db.QueryRow(`SELECT public_key, private_key FROM vapid_keys WHERE id = 1`).Scan(&publicKey, &privateKey)
+
+	if err == sql.ErrNoRows {
+		return "", "", nil
+	}
+
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get VAPID keys: %w", err)
+	}
+
+	return publicKey, privateKey, nil
+}
+
+// SaveVAPIDKeys stores a generated VAPID key pair unless one exists already
+// Returns the stored key pair, so concurrent first starts agree on one pair
+func (r *PushSubscriptionRepository) SaveVAPIDKeys(publicKey, privateKey string) (string, string, error) {
+	_, err := r.db.Exec(`
+		INSERT INTO vapid_keys (id, public_key, private_key, created_at)
+		VALUES (1, ?, ?, ?)
+	`, publicKey, privateKey, time.Now())
+	if err != nil {
+		// Another process stored a key pair first
+		if storedPublic, storedPrivate, getErr := r.GetVAPIDKeys(); getErr == nil && storedPublic != "" {
+			return storedPublic, storedPrivate, nil
+		}
+		return "", "", fmt.Errorf("failed to save VAPID keys: %w", err)
+	}
+
+	return publicKey, privateKey, nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestPushSubscriptionRepository_SaveAndFind tests storing subscriptions per device
+func TestPushSubscriptionRepository_SaveAndFind(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewPushSubscriptionRepository(db)
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "User", "green")
+	otherID := testutil.SeedTestUser(t, db, "other@example.com", "Other", "green")
+
+	phone := &models.PushSubscription{UserID: userID, Endpoint: "https://push.example.com/phone", P256dh: "key1", Auth: "auth1"}
+	laptop := &models.PushSubscription{UserID: userID, Endpoint: "https://push.example.com/laptop", P256dh: "key2", Auth: "auth2"}
+
+	if err := repo.Save(phone); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	if err := repo.Save(laptop); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	if phone.ID == 0 || laptop.ID == 0 {
+		t.Fatal("Expected IDs to be set")
+	}
+
+	subscriptions, err := repo.FindByUser(userID)
+	if err != nil {
+		t.Fatalf("FindByUser() failed: %v", err)
+	}
+	if len(subscriptions) != 2 {
+		t.Errorf("Expected 2 devices, got %d", len(subscriptions))
+	}
+
+	t.Run("same endpoint replaces subscription", func(t *testing.T) {
+		again := &models.PushSubscription{UserID: otherID, Endpoint: phone.Endpoint, P256dh: "key3", Auth: "auth3"}
+		if err := repo.Save(again); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+		if again.ID != phone.ID {
+			t.Errorf("Expected existing row %d to be reused, got %d", phone.ID, again.ID)
+		}
+
+		if count, _ := repo.CountByUser(userID); count != 1 {
+			t.Errorf("Expected 1 device for the first user, got %d", count)
+		}
+		if count, _ := repo.CountByUser(otherID); count != 1 {
+			t.Errorf("Expected 1 device for the second user, got %d", count)
+		}
+	})
+
+	t.Run("delete only own subscriptions", func(t *testing.T) {
+		deleted, err := repo.DeleteForUser(otherID, laptop.ID)
+		if err != nil || deleted {
+			t.Errorf("Expected no deletion for another user's device, got %v (%v)", deleted, err)
+		}
+
+		deleted, err = repo.DeleteForUser(userID, laptop.ID)
+		if err != nil || !deleted {
+			t.Errorf("Expected deletion, got %v (%v)", deleted, err)
+		}
+	})
+}
+
+// TestPushSubscriptionRepository_VAPIDKeys tests that only one key pair is stored
+func TestPushSubscriptionRepository_VAPIDKeys(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewPushSubscriptionRepository(db)
+
+	publicKey, _, err := repo.GetVAPIDKeys()
+	if err != nil || publicKey != "" {
+		t.Fatalf("Expected no keys, got %q (%v)", publicKey, err)
+	}
+
+	if _, _, err := repo.SaveVAPIDKeys("public1", "private1"); err != nil {
+		t.Fatalf("SaveVAPIDKeys() failed: %v", err)
+	}
+
+	publicKey, privateKey, err := repo.SaveVAPIDKeys("public2", "private2")
+	if err != nil {
+		t.Fatalf("SaveVAPIDKeys() failed: %v", err)
+	}
+	if publicKey != "public1" || privateKey != "private1" {
+		t.Errorf("Expected the first key pair to win, got %q/%q", publicKey, privateKey)
+	}
+}
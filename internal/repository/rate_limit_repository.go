@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+)
+
+// RateLimitRepository stores the request counters of the rate limits and the failed logins per account
+// The queries avoid dialect specific upserts: counters are updated first and inserted when missing,
+// an insert that loses against a concurrent request is retried once as an update.
+type RateLimitRepository struct {
+	db DBTX
+}
+
+// NewRateLimitRepository creates a new rate limit repository
+func NewRateLimitRepository(db *sql.DB) *RateLimitRepository {
+	return &RateLimitRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries in the transaction
+func (r *RateLimitRepository) WithTx(tx *sql.Tx) *RateLimitRepository {
+	return &RateLimitRepository{db: tx}
+}
+
+// Hit counts a request in the bucket and returns the requests in the current window and its end
+// A new window of the given length starts when the bucket is missing or expired.
+func (r *RateLimitRepository) Hit(key string, window time.Duration, now time.Time) (int, time.Time, error) {
+	hits, expiresAt, err := r.hit(key, window, now)
+	if err != nil {
+		// Another request created the bucket at the same time
+		hits, expiresAt, err = r.hit(key, window, now)
+	}
+	return hits, expiresAt, err
+}
+
+func (r *RateLimitRepository) hit(key string, window time.Duration, now time.Time) (int, time.Time, error) {
+	// An expired window is restarted in the same statement, so concurrent requests at the end of a
+	// window restart it once instead of replacing a bucket another request just started
+	result, err := r.db.Exec(`
+		UPDATE rate_limit_buckets
+		SET hits = CASE WHEN expires_at <= ? THEN 1 ELSE hits + 1 END,
+		    expires_at = CASE WHEN expires_at <= ? THEN ? ELSE expires_at END
+		WHERE bucket_key = ?
+	`, now, now, now.Add(window), key)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to count request: %w", err)
+	}
+	if updated, err := result.RowsAffected(); err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to count request: %w", err)
+	} else if updated == 1 {
+		var hits int
+		var expiresAt time.Time
+		err := r.db.QueryRow(`SELECT hits, expires_at FROM rate_limit_buckets WHERE bucket_key = ?`, key).Scan(&hits, &expiresAt)
+		if err != nil {
+			return 0, time.Time{}, fmt.Errorf("failed to read request count: %w", err)
+		}
+		return hits, expiresAt, nil
+	}
+
+	expiresAt := now.Add(window)
+	_, err = r.db.Exec(`
+		INSERT INTO rate_limit_buckets (bucket_key, hits, expires_at) VALUES (?, 1, ?)
+	`, key, expiresAt)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to count request: %w", err)
+	}
+	return 1, expiresAt, nil
+}
+
+// FindLockout returns the failed logins of the account, nil if there are none
+func (r *RateLimitRepository) FindLockout(accountKey string) (*models.LoginLockout, error) {
+	lockout := &models.LoginLockout{}
+	var lockedUntil sql.NullTime
+	err := r.db.QueryRow(`
+		SELECT account_key, failures, locked_until, last_failure_at
+		FROM login_lockouts WHERE account_key = ?
+	`, accountKey).Scan(&lockout.AccountKey, &lockout.Failures, &lockedUntil, &lockout.LastFailureAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find login lockout: %w", err)
+	}
+	if lockedUntil.Valid {
+		lockout.LockedUntil = &lockedUntil.Time
+	}
+	return lockout, nil
+}
+
+// RecordFailure counts a failed login of the account and returns the number of failures
+// Failures before resetBefore are forgotten, the count then starts again at one.
+func (r *RateLimitRepository) RecordFailure(accountKey string, now, resetBefore time.Time) (int, error) {
+	failures, err := r.recordFailure(accountKey, now, resetBefore)
+	if err != nil {
+		// Another request inserted the account at the same time
+		failures, err = r.recordFailure(accountKey, now, resetBefore)
+	}
+	return failures, err
+}
+
+func (r *RateLimitRepository) recordFailure(accountKey string, now, resetBefore time.Time) (int, error) {
+	result, err := r.db.Exec(`
+		UPDATE login_lockouts
+		SET failures = CASE WHEN last_failure_at < ? THEN 1 ELSE failures + 1 END, last_failure_at = ?
+		WHERE account_key = ?
+	`, resetBefore, now, accountKey)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record failed login: %w", err)
+	}
+	if updated, err := result.RowsAffected(); err != nil {
+		return 0, fmt.Errorf("failed to record failed login: %w", err)
+	} else if updated == 1 {
+		var failures int
+		if err := r.db.QueryRow(`SELECT failures FROM login_lockouts WHERE account_key = ?`, accountKey).Scan(&failures); err != nil {
+			return 0, fmt.Errorf("failed to read failed logins: %w", err)
+		}
+		return failures, nil
+	}
+
+	_, err = r.db.Exec(`
+		INSERT INTO login_lockouts (account_key, failures, last_failure_at) VALUES (?, 1, ?)
+	`, accountKey, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record failed login: %w", err)
+	}
+	return 1, nil
+}
+
+// SetLockedUntil locks the account for password logins until the given time
+func (r *RateLimitRepository) SetLockedUntil(accountKey string, lockedUntil time.Time) error {
+	_, err := r.db.Exec(`UPDATE login_lockouts SET locked_until = ? WHERE account_key = ?`, lockedUntil, accountKey)
+	if err != nil {
+		return fmt.Errorf("failed to lock account: %w", err)
+	}
+	return nil
+}
+
+// DeleteLockout forgets the failed logins of the account
+func (r *RateLimitRepository) DeleteLockout(accountKey string) error {
+	_, err := r.db.Exec(`DELETE FROM login_lockouts WHERE account_key = ?`, accountKey)
+	if err != nil {
+		return fmt.Errorf("failed to delete login lockout: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired deletes expired buckets and the failed logins of accounts that are not locked
+// and had no failure since staleBefore, and returns the number of deleted rows.
+func (r *RateLimitRepository) DeleteExpired(now, staleBefore time.Time) (int64, error) {
+	buckets, err := r.db.Exec(`DELETE FROM rate_limit_buckets WHERE expires_at <= ?`, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired rate limits: %w", err)
+	}
+	lockouts, err := r.db.Exec(`
+		DELETE FROM login_lockouts
+		WHERE last_failure_at < ? AND (locked_until IS NULL OR locked_until <= ?)
+	`, staleBefore, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete stale login lockouts: %w", err)
+	}
+
+	deletedBuckets, _ := buckets.RowsAffected()
+	deletedLockouts, _ := lockouts.RowsAffected()
+	return deletedBuckets + deletedLockouts, nil
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestRateLimitRepository_Hit tests counting requests and starting a new window
+func TestRateLimitRepository_Hit(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRateLimitRepository(db)
+	now := time.Now()
+
+	for want := 1; want <= 3; want++ {
+		hits, expiresAt, err := repo.Hit("login:203.0.113.1", time.Minute, now)
+		if err != nil || hits != want {
+			t.Fatalf("Hit() = %d (%v), want %d", hits, err, want)
+		}
+		if !expiresAt.Equal(now.Add(time.Minute)) {
+			t.Errorf("Expected the window to end a minute after the first request, got %v", expiresAt)
+		}
+	}
+
+	if hits, _, _ := repo.Hit("login:203.0.113.2", time.Minute, now); hits != 1 {
+		t.Errorf("Expected separate counters per key, got %d", hits)
+	}
+	later := now.Add(2 * time.Minute)
+	if hits, expiresAt, _ := repo.Hit("login:203.0.113.1", time.Minute, later); hits != 1 || !expiresAt.Equal(later.Add(time.Minute)) {
+		t.Errorf("Expected a new window after expiry, got %d until %v", hits, expiresAt)
+	}
+	if hits, _, _ := repo.Hit("login:203.0.113.1", time.Minute, later); hits != 2 {
+		t.Errorf("Expected the new window to keep counting, got %d", hits)
+	}
+
+	if deleted, _ := repo.DeleteExpired(now.Add(90*time.Second), now); deleted != 1 {
+		t.Errorf("Expected 1 expired bucket to be deleted, got %d", deleted)
+	}
+}
+
+// TestRateLimitRepository_Lockouts tests counting, resetting and cleaning up failed logins
+func TestRateLimitRepository_Lockouts(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRateLimitRepository(db)
+	now := time.Now()
+	resetBefore := now.Add(-24 * time.Hour)
+
+	for want := 1; want <= 3; want++ {
+		if failures, err := repo.RecordFailure("account", now, resetBefore); err != nil || failures != want {
+			t.Fatalf("RecordFailure() = %d (%v), want %d", failures, err, want)
+		}
+	}
+	if err := repo.SetLockedUntil("account", now.Add(time.Minute)); err != nil {
+		t.Fatalf("SetLockedUntil() failed: %v", err)
+	}
+
+	lockout, err := repo.FindLockout("account")
+	if err != nil || lockout == nil || lockout.Failures != 3 || lockout.LockedUntil == nil {
+		t.Fatalf("Unexpected lockout %+v (%v)", lockout, err)
+	}
+	if found, _ := repo.FindLockout("other"); found != nil {
+		t.Error("Expected no lockout for another account")
+	}
+
+	// Failures older than a day are forgotten
+	later := now.Add(25 * time.Hour)
+	if failures, _ := repo.RecordFailure("account", later, later.Add(-24*time.Hour)); failures != 1 {
+		t.Errorf("Expected the count to start again, got %d", failures)
+	}
+
+	repo.RecordFailure("stale", now, resetBefore)
+	if deleted, _ := repo.DeleteExpired(later, later.Add(-24*time.Hour)); deleted != 1 {
+		t.Errorf("Expected 1 stale lockout to be deleted, got %d", deleted)
+	}
+
+	repo.DeleteLockout("account")
+	if found, _ := repo.FindLockout("account"); found != nil {
+		t.Error("Expected the lockout to be deleted")
+	}
+}
@@ -10,7 +10,7 @@ import (
 
 // ReactivationRequestRepository handles reactivation request database operations
 type ReactivationRequestRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 // NewReactivationRequestRepository creates a new reactivation request repository
@@ -18,6 +18,11 @@ func NewReactivationRequestRepository(db *sql.DB) *ReactivationRequestRepository
 	return &ReactivationRequestRepository{db: db}
 }
 
+// WithTx returns a copy of the repository that runs its queries in the transaction
+func (r *ReactivationRequestRepository) WithTx(tx *sql.Tx) *ReactivationRequestRepository {
+	return &ReactivationRequestRepository{db: tx}
+}
+
 // Create creates a new reactivation request
 func (r *ReactivationRequestRepository) Create(request *models.ReactivationRequest) error {
 	query := `
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+)
+
+// RefreshTokenRepository handles refresh tokens
+type RefreshTokenRepository struct {
+	db DBTX
+}
+
+// NewRefreshTokenRepository creates a new refresh token repository
+func NewRefreshTokenRepository(db *sql.DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries in the transaction
+func (r *RefreshTokenRepository) WithTx(tx *sql.Tx) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db: tx}
+}
+
+// Create stores a refresh token
+func (r *RefreshTokenRepository) Create(token *models.RefreshToken) error {
+	result, err := r.db.Exec(`
+		INSERT INTO refresh_tokens (user_id, token_hash, family_id, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, token.UserID, token.TokenHash, token.FamilyID, token.ExpiresAt, token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get refresh token ID: %w", err)
+	}
+	token.ID = int(id)
+	return nil
+}
+
+// FindByHash returns the refresh token with the hash, nil if it does not exist
+func (r *RefreshTokenRepository) FindByHash(tokenHash string) (*models.RefreshToken, error) {
+	token := &models.RefreshToken{}
+	var usedAt, revokedAt sql.NullTime
+	err := r.db.QueryRow(`
+		SELECT id, user_id, token_hash, family_id, expires_at, created_at, used_at, revoked_at
+		FROM refresh_tokens WHERE token_hash = ?
+	`, tokenHash).Scan(&token.ID, &token.UserID, &token.TokenHash, &token.FamilyID,
+		&token.ExpiresAt, &token.CreatedAt, &usedAt, &revokedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find refresh token: %w", err)
+	}
+
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	return token, nil
+}
+
+// MarkUsed marks a token as exchanged, false if it was already used or revoked
+// The check and the update are one statement, so a token can only be exchanged once.
+func (r *RefreshTokenRepository) MarkUsed(id int, usedAt time.Time) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE refresh_tokens SET used_at = ? WHERE id = ? AND used_at IS NULL AND revoked_at IS NULL
+	`, usedAt, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark refresh token as used: %w", err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to mark refresh token as used: %w", err)
+	}
+	return updated == 1, nil
+}
+
+// RevokeFamily revokes all tokens of a login
+func (r *RefreshTokenRepository) RevokeFamily(familyID string) error {
+	_, err := r.db.Exec(`UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL`,
+		time.Now(), familyID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
+
+// RevokeAllForUser revokes all tokens of a user, logging them out on every device
+func (r *RefreshTokenRepository) RevokeAllForUser(userID int) error {
+	_, err := r.db.Exec(`UPDATE refresh_tokens SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL`,
+		time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired deletes tokens that expired before the given time and returns their number
+func (r *RefreshTokenRepository) DeleteExpired(before time.Time) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM refresh_tokens WHERE expires_at < ?`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired refresh tokens: %w", err)
+	}
+	return result.RowsAffected()
+}
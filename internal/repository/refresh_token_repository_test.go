@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestRefreshTokenRepository tests storing, exchanging, revoking and cleaning up refresh tokens
+func TestRefreshTokenRepository(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRefreshTokenRepository(db)
+
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "User", "green")
+	otherID := testutil.SeedTestUser(t, db, "other@example.com", "Other", "green")
+
+	create := func(userID int, raw, familyID string, expiresAt time.Time) *models.RefreshToken {
+		token := &models.RefreshToken{UserID: userID, TokenHash: models.HashRefreshToken(raw),
+			FamilyID: familyID, ExpiresAt: expiresAt, CreatedAt: time.Now()}
+		if err := repo.Create(token); err != nil {
+			t.Fatalf("Create() failed: %v", err)
+		}
+		return token
+	}
+	find := func(raw string) *models.RefreshToken {
+		token, err := repo.FindByHash(models.HashRefreshToken(raw))
+		if err != nil {
+			t.Fatalf("FindByHash() failed: %v", err)
+		}
+		return token
+	}
+
+	future := time.Now().Add(time.Hour)
+	first := create(userID, "first", "family-a", future)
+	create(userID, "second", "family-a", future)
+	create(userID, "laptop", "family-b", future)
+	create(otherID, "other", "family-c", future)
+	create(userID, "expired", "family-d", time.Now().Add(-time.Hour))
+
+	if token := find("unknown"); token != nil {
+		t.Errorf("Expected nil for an unknown token, got %+v", token)
+	}
+	if token := find("first"); token == nil || token.ID != first.ID || token.UsedAt != nil || token.RevokedAt != nil {
+		t.Fatalf("Expected the stored token, got %+v", token)
+	}
+
+	// A token can be exchanged only once
+	if marked, err := repo.MarkUsed(first.ID, time.Now()); err != nil || !marked {
+		t.Fatalf("Expected MarkUsed() to succeed, got %v (%v)", marked, err)
+	}
+	if marked, _ := repo.MarkUsed(first.ID, time.Now()); marked {
+		t.Error("Expected a second MarkUsed() to fail")
+	}
+	if token := find("first"); token.UsedAt == nil {
+		t.Error("Expected used_at to be set")
+	}
+
+	if err := repo.RevokeFamily("family-a"); err != nil {
+		t.Fatalf("RevokeFamily() failed: %v", err)
+	}
+	if find("second").RevokedAt == nil || find("laptop").RevokedAt != nil {
+		t.Error("Expected only the tokens of the family to be revoked")
+	}
+
+	if err := repo.RevokeAllForUser(userID); err != nil {
+		t.Fatalf("RevokeAllForUser() failed: %v", err)
+	}
+	if find("laptop").RevokedAt == nil || find("other").RevokedAt != nil {
+		t.Error("Expected only the tokens of the user to be revoked")
+	}
+
+	deleted, err := repo.DeleteExpired(time.Now())
+	if err != nil || deleted != 1 {
+		t.Fatalf("Expected 1 expired token to be deleted, got %d (%v)", deleted, err)
+	}
+	if find("expired") != nil || find("other") == nil {
+		t.Error("Expected only the expired token to be deleted")
+	}
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+)
+
+// RegistrationRequestRepository handles registrations waiting for an admin
+type RegistrationRequestRepository struct {
+	db DBTX
+}
+
+// NewRegistrationRequestRepository creates a new registration request repository
+func NewRegistrationRequestRepository(db *sql.DB) *RegistrationRequestRepository {
+	return &RegistrationRequestRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries in the transaction
+func (r *RegistrationRequestRepository) WithTx(tx *sql.Tx) *RegistrationRequestRepository {
+	return &RegistrationRequestRepository{db: tx}
+}
+
+// Create creates a new pending registration request
+func (r *RegistrationRequestRepository) Create(request *models.RegistrationRequest) error {
+	query := `
+		INSERT INTO registration_requests (user_id, status, created_at)
+		VALUES (?, 'pending', ?)
+	`
+
+	now := time.Now()
+	result, err := r.db.Exec(query, request.UserID, now)
+	if err != nil {
+		return fmt.Errorf("failed to create registration request: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get request ID: %w", err)
+	}
+
+	request.ID = int(id)
+	request.Status = "pending"
+	request.CreatedAt = now
+
+	return nil
+}
+
+// FindByID finds a registration request by ID
+func (r *RegistrationRequestRepository) FindByID(id int) (*models.RegistrationRequest, error) {
+	query := `
+		SELECT id, user_id, status, admin_message, reviewed_by, reviewed_at, created_at
+		FROM registration_requests
+		WHERE id = ?
+	`
+
+	request := &models.RegistrationRequest{}
+	err := r.db.QueryRow(query, id).Scan(
+		&request.ID,
+		&request.UserID,
+		&request.Status,
+		&request.AdminMessage,
+		&request.ReviewedBy,
+		&request.ReviewedAt,
+		&request.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to find registration request: %w", err)
+	}
+
+	return request, nil
+}
+
+// FindAllPending finds all pending registration requests, oldest first
+func (r *RegistrationRequestRepository) FindAllPending() ([]*models.RegistrationRequest, error) {
+	query := `
+		SELECT id, user_id, status, admin_message, reviewed_by, reviewed_at, created_at
+		FROM registration_requests
+		WHERE status = 'pending'
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending registrations: %w", err)
+	}
+	defer rows.Close()
+
+	requests := []*models.RegistrationRequest{}
+	for rows.Next() {
+		request := &models.RegistrationRequest{}
+		err := rows.Scan(
+			&request.ID,
+			&request.UserID,
+			&request.Status,
+			&request.AdminMessage,
+			&request.ReviewedBy,
+			&request.ReviewedAt,
+			&request.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan registration request: %w", err)
+		}
+		requests = append(requests, request)
+	}
+
+	return requests, nil
+}
+
+// HasPendingRequest checks if the registration of the user waits for an admin
+func (r *RegistrationRequestRepository) HasPendingRequest(userID int) (bool, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM registration_requests
+		WHERE user_id = ? AND status = 'pending'
+	`
+
+	var count int
+	err := r.db.QueryRow(query, userID).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check pending registration: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// Approve approves a registration request
+func (r *RegistrationRequestRepository) Approve(id int, reviewerID int, message *string) error {
+	return r.review(id, "approved", reviewerID, message)
+}
+
+// Deny denies a registration request
+func (r *RegistrationRequestRepository) Deny(id int, reviewerID int, message *string) error {
+	return r.review(id, "denied", reviewerID, message)
+}
+
+func (r *RegistrationRequestRepository) review(id int, status string, reviewerID int, message *string) error {
+	query := `
+		UPDATE registration_requests
+		SET status = ?, reviewed_by = ?, reviewed_at = ?, admin_message = ?
+		WHERE id = ?
+	`
+
+	_, err := r.db.Exec(query, status, reviewerID, time.Now(), message, id)
+	if err != nil {
+		return fmt.Errorf("failed to review registration request: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestRegistrationRequestRepository tests the queue of registrations waiting for an admin
+func TestRegistrationRequestRepository(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRegistrationRequestRepository(db)
+
+	adminID := testutil.SeedTestUser(t, db, "admin@example.com", "Admin", "orange")
+	firstID := testutil.SeedTestUser(t, db, "first@example.com", "First", "green")
+	secondID := testutil.SeedTestUser(t, db, "second@example.com", "Second", "green")
+
+	first := &models.RegistrationRequest{UserID: firstID}
+	second := &models.RegistrationRequest{UserID: secondID}
+	for _, request := range []*models.RegistrationRequest{first, second} {
+		if err := repo.Create(request); err != nil {
+			t.Fatalf("Create() failed: %v", err)
+		}
+		if request.ID == 0 || request.Status != "pending" {
+			t.Errorf("Expected a pending request with ID, got %+v", request)
+		}
+	}
+
+	if pending, err := repo.HasPendingRequest(firstID); err != nil || !pending {
+		t.Errorf("HasPendingRequest() = %v (%v), want true", pending, err)
+	}
+
+	message := "Willkommen"
+	if err := repo.Approve(first.ID, adminID, &message); err != nil {
+		t.Fatalf("Approve() failed: %v", err)
+	}
+	if err := repo.Deny(second.ID, adminID, nil); err != nil {
+		t.Fatalf("Deny() failed: %v", err)
+	}
+
+	found, err := repo.FindByID(first.ID)
+	if err != nil || found.Status != "approved" || found.ReviewedBy == nil || *found.ReviewedBy != adminID ||
+		found.AdminMessage == nil || *found.AdminMessage != message {
+		t.Errorf("Unexpected approved request %+v (%v)", found, err)
+	}
+	if found, _ := repo.FindByID(second.ID); found.Status != "denied" {
+		t.Errorf("Expected the second request to be denied, got %s", found.Status)
+	}
+	if pending, _ := repo.HasPendingRequest(firstID); pending {
+		t.Error("Expected no pending request after the review")
+	}
+
+	requests, err := repo.FindAllPending()
+	if err != nil || len(requests) != 0 {
+		t.Errorf("FindAllPending() = %d requests (%v), want 0", len(requests), err)
+	}
+	if missing, err := repo.FindByID(9999); err != nil || missing != nil {
+		t.Errorf("Expected no request for an unknown ID, got %+v (%v)", missing, err)
+	}
+}
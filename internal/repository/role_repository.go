@@ -0,0 +1,270 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/tranmh/gassigeher/internal/models"
+)
+
+// RoleRepository handles roles, their permissions and role assignments
+type RoleRepository struct {
+	db DBTX
+}
+
+// NewRoleRepository creates a new role repository
+func NewRoleRepository(db *sql.DB) *RoleRepository {
+	return &RoleRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries in the transaction
+func (r *RoleRepository) WithTx(tx *sql.Tx) *RoleRepository {
+	return &RoleRepository{db: tx}
+}
+
+// FindAll returns all roles with their permissions and number of users, built-in roles first
+func (r *RoleRepository) FindAll() ([]*models.Role, error) {
+	roles, err := r.query(`
+		SELECT id, name, description, is_builtin, created_at FROM roles ORDER BY is_builtin DESC, name
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[int]int{}
+	rows, err := r.db.Query(`SELECT role_id, COUNT(*) FROM user_roles GROUP BY role_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count role users: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var roleID, count int
+		if err := rows.Scan(&roleID, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan role users: %w", err)
+		}
+		counts[roleID] = count
+	}
+	rows.Close()
+
+	// admin and super_admin are held through the user flags
+	var admins, superAdmins int
+	err = r.db.QueryRow(`
+		SELECT
+			COALESCE(SUM(CASE WHEN is_admin = ? AND is_super_admin = ? THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN is_super_admin = ? THEN 1 ELSE 0 END), 0)
+		FROM users WHERE is_deleted = ?
+	`, true, false, true, false).Scan(&admins, &superAdmins)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count admins: %w", err)
+	}
+
+	for _, role := range roles {
+		switch role.Name {
+		case models.RoleAdmin:
+			role.UserCount = admins
+		case models.RoleSuperAdmin:
+			role.UserCount = superAdmins
+		default:
+			role.UserCount = counts[role.ID]
+		}
+	}
+	return roles, nil
+}
+
+// FindByID returns a role with its permissions, nil if it does not exist
+func (r *RoleRepository) FindByID(id int) (*models.Role, error) {
+	roles, err := r.query(`SELECT id, name, description, is_builtin, created_at FROM roles WHERE id = ?`, id)
+	if err != nil || len(roles) == 0 {
+		return nil, err
+	}
+	return roles[0], nil
+}
+
+// FindByName returns a role with its permissions, nil if it does not exist
+func (r *RoleRepository) FindByName(name string) (*models.Role, error) {
+	roles, err := r.query(`SELECT id, name, description, is_builtin, created_at FROM roles WHERE name = ?`, name)
+	if err != nil || len(roles) == 0 {
+		return nil, err
+	}
+	return roles[0], nil
+}
+
+// FindByUser returns the roles assigned to a user, without the roles held through the admin flags
+func (r *RoleRepository) FindByUser(userID int) ([]*models.Role, error) {
+	return r.query(`
+		SELECT r.id, r.name, r.description, r.is_builtin, r.created_at
+		FROM roles r
+		JOIN user_roles ur ON ur.role_id = r.id
+		WHERE ur.user_id = ?
+		ORDER BY r.name
+	`, userID)
+}
+
+// ForUser returns all roles of a user: admin or super_admin from the flags and the assigned roles
+func (r *RoleRepository) ForUser(user *models.User) ([]*models.Role, error) {
+	roles := []*models.Role{}
+	for _, name := range models.FlagRoles(user.IsAdmin, user.IsSuperAdmin) {
+		role, err := r.FindByName(name)
+		if err != nil {
+			return nil, err
+		}
+		if role != nil {
+			roles = append(roles, role)
+		}
+	}
+
+	assigned, err := r.FindByUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return append(roles, assigned...), nil
+}
+
+// Create creates a custom role with its permissions
+func (r *RoleRepository) Create(role *models.Role) error {
+	result, err := r.db.Exec(`INSERT INTO roles (name, description, is_builtin, created_at) VALUES (?, ?, ?, ?)`,
+		role.Name, role.Description, false, role.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create role: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get role ID: %w", err)
+	}
+	role.ID = int(id)
+	return r.setPermissions(role.ID, role.Permissions)
+}
+
+// Update changes name, description and permissions of a custom role
+// The access tokens of its users are invalidated, so the new permissions apply with the next request.
+func (r *RoleRepository) Update(role *models.Role) error {
+	_, err := r.db.Exec(`UPDATE roles SET name = ?, description = ? WHERE id = ? AND is_builtin = ?`,
+		role.Name, role.Description, role.ID, false)
+	if err != nil {
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+	if err := r.incrementTokenVersions(role.ID); err != nil {
+		return err
+	}
+	return r.setPermissions(role.ID, role.Permissions)
+}
+
+// Delete deletes a custom role and its assignments, built-in roles are left unchanged
+// Like Update, it invalidates the access tokens of the role's users.
+func (r *RoleRepository) Delete(id int) error {
+	// Before the assignments are gone
+	if err := r.incrementTokenVersions(id); err != nil {
+		return err
+	}
+
+	result, err := r.db.Exec(`DELETE FROM roles WHERE id = ? AND is_builtin = ?`, id, false)
+	if err != nil {
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+	if deleted, err := result.RowsAffected(); err != nil || deleted == 0 {
+		return err
+	}
+
+	if _, err := r.db.Exec(`DELETE FROM user_roles WHERE role_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete role assignments: %w", err)
+	}
+	if _, err := r.db.Exec(`DELETE FROM role_permissions WHERE role_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete role permissions: %w", err)
+	}
+	return nil
+}
+
+// SetUserRoles replaces the roles assigned to a user and invalidates their access tokens
+func (r *RoleRepository) SetUserRoles(userID int, roleIDs []int) error {
+	if _, err := r.db.Exec(`DELETE FROM user_roles WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("failed to delete user roles: %w", err)
+	}
+	for _, roleID := range roleIDs {
+		if _, err := r.db.Exec(`INSERT INTO user_roles (user_id, role_id) VALUES (?, ?)`, userID, roleID); err != nil {
+			return fmt.Errorf("failed to assign role: %w", err)
+		}
+	}
+	if _, err := r.db.Exec(`UPDATE users SET token_version = token_version + 1 WHERE id = ?`, userID); err != nil {
+		return fmt.Errorf("failed to increment token version: %w", err)
+	}
+	return nil
+}
+
+// incrementTokenVersions invalidates the access tokens of all users holding the custom role
+func (r *RoleRepository) incrementTokenVersions(roleID int) error {
+	_, err := r.db.Exec(`
+		UPDATE users SET token_version = token_version + 1
+		WHERE id IN (
+			SELECT ur.user_id FROM user_roles ur JOIN roles r ON r.id = ur.role_id
+			WHERE ur.role_id = ? AND r.is_builtin = ?
+		)
+	`, roleID, false)
+	if err != nil {
+		return fmt.Errorf("failed to increment token versions: %w", err)
+	}
+	return nil
+}
+
+func (r *RoleRepository) setPermissions(roleID int, permissions []string) error {
+	if _, err := r.db.Exec(`DELETE FROM role_permissions WHERE role_id = ?`, roleID); err != nil {
+		return fmt.Errorf("failed to delete role permissions: %w", err)
+	}
+	for _, permission := range permissions {
+		if _, err := r.db.Exec(`INSERT INTO role_permissions (role_id, permission) VALUES (?, ?)`, roleID, permission); err != nil {
+			return fmt.Errorf("failed to create role permission: %w", err)
+		}
+	}
+	return nil
+}
+
+// query loads roles and fills in their permissions
+// Built-in roles take their permissions from the code, custom roles from role_permissions.
+func (r *RoleRepository) query(query string, args ...interface{}) ([]*models.Role, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query roles: %w", err)
+	}
+	defer rows.Close()
+
+	roles := []*models.Role{}
+	for rows.Next() {
+		role := &models.Role{}
+		if err := rows.Scan(&role.ID, &role.Name, &role.Description, &role.IsBuiltin, &role.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan role: %w", err)
+		}
+		roles = append(roles, role)
+	}
+	rows.Close()
+
+	for _, role := range roles {
+		if role.IsBuiltin {
+			role.Permissions = append([]string{}, models.BuiltinRolePermissions[role.Name]...)
+			continue
+		}
+
+		permissionRows, err := r.db.Query(`SELECT permission FROM role_permissions WHERE role_id = ?`, role.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query role permissions: %w", err)
+		}
+		set := map[string]bool{}
+		for permissionRows.Next() {
+			var permission string
+			if err := permissionRows.Scan(&permission); err != nil {
+				permissionRows.Close()
+				return nil, fmt.Errorf("failed to scan role permission: %w", err)
+			}
+			set[permission] = true
+		}
+		permissionRows.Close()
+
+		// Display order, permissions that no longer exist are dropped
+		role.Permissions = []string{}
+		for _, permission := range models.AllPermissions {
+			if set[permission] {
+				role.Permissions = append(role.Permissions, permission)
+			}
+		}
+	}
+	return roles, nil
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestRoleRepository tests custom roles, role assignments and the roles held through the admin flags
+func TestRoleRepository(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRoleRepository(db)
+	userRepo := NewUserRepository(db)
+
+	adminID := testutil.SeedTestUser(t, db, "admin@example.com", "Admin", "orange")
+	staffID := testutil.SeedTestUser(t, db, "staff@example.com", "Staff", "green")
+	db.Exec(`UPDATE users SET is_admin = 1 WHERE id = ?`, adminID)
+
+	scheduler, err := repo.FindByName(models.RoleScheduler)
+	if err != nil || scheduler == nil {
+		t.Fatalf("Expected the built-in scheduler role, got %v (%v)", scheduler, err)
+	}
+	if !scheduler.IsBuiltin || !reflect.DeepEqual(scheduler.Permissions, []string{models.PermissionManageBookings}) {
+		t.Errorf("Unexpected built-in role %+v", scheduler)
+	}
+
+	frontDesk := &models.Role{Name: "front_desk", Description: "Empfang", CreatedAt: time.Now(),
+		Permissions: []string{models.PermissionManageDogs, models.PermissionViewDashboard}}
+	if err := repo.Create(frontDesk); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	if err := repo.SetUserRoles(staffID, []int{scheduler.ID, frontDesk.ID}); err != nil {
+		t.Fatalf("SetUserRoles() failed: %v", err)
+	}
+
+	staff, _ := userRepo.FindByID(staffID)
+	roles, err := repo.ForUser(staff)
+	if err != nil || len(roles) != 2 {
+		t.Fatalf("Expected 2 assigned roles, got %d (%v)", len(roles), err)
+	}
+	expected := []string{models.PermissionManageBookings, models.PermissionViewDashboard, models.PermissionManageDogs}
+	if got := models.PermissionsOf(roles); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected combined permissions, got %v", got)
+	}
+
+	// Admins hold the built-in admin role through their flag
+	admin, _ := userRepo.FindByID(adminID)
+	roles, _ = repo.ForUser(admin)
+	if len(roles) != 1 || roles[0].Name != models.RoleAdmin {
+		t.Fatalf("Expected the admin role, got %v", roleNamesOf(roles))
+	}
+
+	frontDesk.Permissions = []string{models.PermissionManageCalendar}
+	if err := repo.Update(frontDesk); err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	if found, _ := repo.FindByID(frontDesk.ID); !reflect.DeepEqual(found.Permissions, []string{models.PermissionManageCalendar}) {
+		t.Errorf("Expected updated permissions, got %v", found.Permissions)
+	}
+
+	all, err := repo.FindAll()
+	if err != nil {
+		t.Fatalf("FindAll() failed: %v", err)
+	}
+	counts := map[string]int{}
+	for _, role := range all {
+		counts[role.Name] = role.UserCount
+	}
+	if counts[models.RoleAdmin] != 1 || counts[models.RoleScheduler] != 1 || counts["front_desk"] != 1 || counts[models.RoleKennelStaff] != 0 {
+		t.Errorf("Unexpected user counts %v", counts)
+	}
+
+	if err := repo.Delete(frontDesk.ID); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if roles, _ := repo.FindByUser(staffID); len(roles) != 1 || roles[0].Name != models.RoleScheduler {
+		t.Errorf("Expected the assignment of the deleted role to be removed, got %v", roleNamesOf(roles))
+	}
+
+	// Built-in roles and their assignments are never deleted
+	repo.Delete(scheduler.ID)
+	if found, _ := repo.FindByID(scheduler.ID); found == nil {
+		t.Error("Expected the built-in role to remain")
+	}
+	if roles, _ := repo.FindByUser(staffID); len(roles) != 1 {
+		t.Error("Expected the built-in role assignment to remain")
+	}
+}
+
+// TestRoleRepository_TokenVersions tests that role changes invalidate the access tokens of the affected users
+func TestRoleRepository_TokenVersions(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRoleRepository(db)
+	userRepo := NewUserRepository(db)
+
+	staffID := testutil.SeedTestUser(t, db, "staff@example.com", "Staff", "green")
+	otherID := testutil.SeedTestUser(t, db, "other@example.com", "Other", "green")
+
+	version := func(userID int) int {
+		state, err := userRepo.FindAuthState(userID)
+		if err != nil || state == nil {
+			t.Fatalf("FindAuthState() failed: %v", err)
+		}
+		return state.TokenVersion
+	}
+
+	role := &models.Role{Name: "front_desk", CreatedAt: time.Now(), Permissions: []string{models.PermissionManageDogs}}
+	repo.Create(role)
+
+	if err := repo.SetUserRoles(staffID, []int{role.ID}); err != nil {
+		t.Fatalf("SetUserRoles() failed: %v", err)
+	}
+	if version(staffID) != 1 {
+		t.Errorf("Expected version 1 after the role assignment, got %d", version(staffID))
+	}
+
+	role.Permissions = []string{models.PermissionManageBookings}
+	repo.Update(role)
+	if version(staffID) != 2 {
+		t.Errorf("Expected version 2 after the permission change, got %d", version(staffID))
+	}
+
+	repo.Delete(role.ID)
+	if version(staffID) != 3 {
+		t.Errorf("Expected version 3 after the role was deleted, got %d", version(staffID))
+	}
+	if version(otherID) != 0 {
+		t.Errorf("Expected users without the role to keep their version, got %d", version(otherID))
+	}
+}
+
+func roleNamesOf(roles []*models.Role) []string {
+	names := []string{}
+	for _, role := range roles {
+		names = append(names, role.Name)
+	}
+	return names
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+)
+
+// RunSheetRepository reads the data of the daily run sheet and tracks sent digests
+type RunSheetRepository struct {
+	db *sql.DB
+}
+
+// NewRunSheetRepository creates a new run sheet repository
+func NewRunSheetRepository(db *sql.DB) *RunSheetRepository {
+	return &RunSheetRepository{db: db}
+}
+
+const runSheetWalkQuery = `
+	SELECT b.id, b.dog_id, d.name, b.date, b.scheduled_time, b.status, u.name, u.phone, u.experience_level
+	FROM bookings b
+	JOIN users u ON b.user_id = u.id
+	JOIN dogs d ON b.dog_id = d.id
+`
+
+// FindWalks returns the confirmed walks of a date ordered by time and dog
+func (r *RunSheetRepository) FindWalks(date string) ([]*models.RunSheetWalk, error) {
+	return r.queryWalks(runSheetWalkQuery+`
+		WHERE b.date = ? AND b.status != 'cancelled' AND COALESCE(b.approval_status, 'approved') = 'approved'
+		ORDER BY b.scheduled_time ASC, d.name ASC
+	`, date)
+}
+
+// FindPendingApprovals returns scheduled walks from a date on that still wait for approval
+func (r *RunSheetRepository) FindPendingApprovals(fromDate string) ([]*models.RunSheetWalk, error) {
+	return r.queryWalks(runSheetWalkQuery+`
+		WHERE b.date >= ? AND b.status = 'scheduled' AND b.approval_status = 'pending'
+		ORDER BY b.date ASC, b.scheduled_time ASC
+	`, fromDate)
+}
+
+func (r *RunSheetRepository) queryWalks(query string, args ...interface{}) ([]*models.RunSheetWalk, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query run sheet walks: %w", err)
+	}
+	defer rows.Close()
+
+	walks := []*models.RunSheetWalk{}
+	for rows.Next() {
+		walk := &models.RunSheetWalk{}
+		var phone sql.NullString
+		err := rows.Scan(&walk.BookingID, &walk.DogID, &walk.DogName, &walk.Date, &walk.ScheduledTime, &walk.Status,
+			&walk.WalkerName, &phone, &walk.ExperienceLevel)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan run sheet walk: %w", err)
+		}
+		if phone.Valid && phone.String != "" {
+			walk.WalkerPhone = &phone.String
+		}
+		walks = append(walks, walk)
+	}
+	return walks, nil
+}
+
+// FindUnwalkedDogs returns available dogs without a walk on a date
+// Dogs that waited longest since their last completed walk come first
+func (r *RunSheetRepository) FindUnwalkedDogs(date string) ([]*models.RunSheetDog, error) {
+	rows, err := r.db.Query(`
+		SELECT d.id, d.name, d.category,
+		       (SELECT MAX(c.date) FROM bookings c WHERE c.dog_id = d.id AND c.status = 'completed') AS last_walk_date
+		FROM dogs d
+		WHERE d.is_available = 1
+		  AND NOT EXISTS (
+		    SELECT 1 FROM bookings b
+		    WHERE b.dog_id = d.id AND b.date = ? AND b.status != 'cancelled'
+		      AND COALESCE(b.approval_status, 'approved') != 'rejected'
+		  )
+		ORDER BY last_walk_date ASC, d.name ASC
+	`, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unwalked dogs: %w", err)
+	}
+	defer rows.Close()
+
+	dogs := []*models.RunSheetDog{}
+	for rows.Next() {
+		dog := &models.RunSheetDog{}
+		var lastWalkDate sql.NullString
+		if err := rows.Scan(&dog.ID, &dog.Name, &dog.Category, &lastWalkDate); err != nil {
+			return nil, fmt.Errorf("failed to scan unwalked dog: %w", err)
+		}
+		if lastWalkDate.Valid {
+			dog.LastWalkDate = &lastWalkDate.String
+		}
+		dogs = append(dogs, dog)
+	}
+	return dogs, nil
+}
+
+// ClaimDigest records the digest of a date as sent before it is delivered
+// Returns false if the digest was already claimed, e.g. before a restart.
+// The primary key guarantees that only one claim can succeed.
+func (r *RunSheetRepository) ClaimDigest(date string) (bool, error) {
+	claimed, err := r.digestExists(date)
+	if err != nil || claimed {
+		return false, err
+	}
+
+	_, err = r.db.Exec(`INSERT INTO run_sheet_digests (digest_date, sent_at) VALUES (?, ?)`, date, time.Now())
+	if err != nil {
+		// Lost the race against a concurrent claim
+		if claimed, existsErr := r.digestExists(date); existsErr == nil && claimed {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to claim run sheet digest: %w", err)
+	}
+
+	return true, nil
+}
+
+// ReleaseDigest removes a claim so the digest is retried (used when sending failed)
+func (r *RunSheetRepository) ReleaseDigest(date string) error {
+	_, err := r.db.Exec(`DELETE FROM run_sheet_digests WHERE digest_date = ?`, date)
+	if err != nil {
+		return fmt.Errorf("failed to release run sheet digest: %w", err)
+	}
+	return nil
+}
+
+func (r *RunSheetRepository) digestExists(date string) (bool, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM run_sheet_digests WHERE digest_date = ?`, date).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check run sheet digest: %w", err)
+	}
+	return count > 0, nil
+}
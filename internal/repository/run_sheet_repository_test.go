@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestRunSheetRepository tests collecting walks, pending approvals and unwalked dogs of a day
+func TestRunSheetRepository(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRunSheetRepository(db)
+
+	walker := testutil.SeedTestUser(t, db, "walker@example.com", "Walker", "blue")
+	bella := testutil.SeedTestDog(t, db, "Bella", "Labrador", "green")
+	maxDog := testutil.SeedTestDog(t, db, "Max", "Schäferhund", "blue")
+	luna := testutil.SeedTestDog(t, db, "Luna", "Mischling", "green")
+	rex := testutil.SeedTestDog(t, db, "Rex", "Dobermann", "orange")
+	unavailable := testutil.SeedTestDog(t, db, "Sam", "Pudel", "green")
+	db.Exec("UPDATE dogs SET is_available = 0 WHERE id = ?", unavailable)
+
+	date := "2025-12-10"
+	testutil.SeedTestBooking(t, db, walker, bella, date, "15:00", "scheduled")
+	testutil.SeedTestBooking(t, db, walker, maxDog, date, "09:00", "scheduled")
+	testutil.SeedTestBooking(t, db, walker, luna, date, "10:00", "cancelled")
+	pending := testutil.SeedTestBooking(t, db, walker, rex, date, "11:00", "scheduled")
+	db.Exec("UPDATE bookings SET approval_status = 'pending' WHERE id = ?", pending)
+	testutil.SeedTestBooking(t, db, walker, luna, "2025-12-01", "09:00", "completed")
+
+	t.Run("walks", func(t *testing.T) {
+		walks, err := repo.FindWalks(date)
+		if err != nil {
+			t.Fatalf("FindWalks() failed: %v", err)
+		}
+		if len(walks) != 2 || walks[0].DogName != "Max" || walks[1].DogName != "Bella" {
+			t.Fatalf("Expected walks of Max and Bella ordered by time, got %d", len(walks))
+		}
+		if walks[0].WalkerName != "Walker" || walks[0].WalkerPhone == nil || walks[0].ExperienceLevel != "blue" {
+			t.Errorf("Expected walker details, got %+v", walks[0])
+		}
+	})
+
+	t.Run("pending approvals", func(t *testing.T) {
+		walks, err := repo.FindPendingApprovals(date)
+		if err != nil {
+			t.Fatalf("FindPendingApprovals() failed: %v", err)
+		}
+		if len(walks) != 1 || walks[0].BookingID != pending {
+			t.Errorf("Expected pending booking %d, got %d walk(s)", pending, len(walks))
+		}
+
+		if walks, _ := repo.FindPendingApprovals("2025-12-11"); len(walks) != 0 {
+			t.Errorf("Expected no pending approvals after the date, got %d", len(walks))
+		}
+	})
+
+	t.Run("unwalked dogs", func(t *testing.T) {
+		dogs, err := repo.FindUnwalkedDogs(date)
+		if err != nil {
+			t.Fatalf("FindUnwalkedDogs() failed: %v", err)
+		}
+		// Rex has a pending booking, Luna's walk was cancelled, Sam is unavailable
+		if len(dogs) != 1 || dogs[0].ID != luna {
+			t.Fatalf("Expected only Luna to be unwalked, got %d dog(s)", len(dogs))
+		}
+		if dogs[0].LastWalkDate == nil || *dogs[0].LastWalkDate != "2025-12-01" {
+			t.Errorf("Expected last walk 2025-12-01, got %v", dogs[0].LastWalkDate)
+		}
+	})
+}
+
+// TestRunSheetRepository_ClaimDigest tests that a digest can only be claimed once per day
+func TestRunSheetRepository_ClaimDigest(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRunSheetRepository(db)
+
+	if claimed, err := repo.ClaimDigest("2025-12-10"); err != nil || !claimed {
+		t.Fatalf("Expected first claim to succeed, got %v (%v)", claimed, err)
+	}
+	if claimed, _ := repo.ClaimDigest("2025-12-10"); claimed {
+		t.Error("Expected second claim of the same day to fail")
+	}
+	if claimed, _ := repo.ClaimDigest("2025-12-11"); !claimed {
+		t.Error("Expected claim of the next day to succeed")
+	}
+
+	if err := repo.ReleaseDigest("2025-12-10"); err != nil {
+		t.Fatalf("ReleaseDigest() failed: %v", err)
+	}
+	if claimed, _ := repo.ClaimDigest("2025-12-10"); !claimed {
+		t.Error("Expected claim to succeed after release")
+	}
+}
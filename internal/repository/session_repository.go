@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+)
+
+// activeSessionCondition selects sessions whose login can still be refreshed
+// Revocations (logout, logout everywhere, reused tokens) only touch the refresh tokens.
+const activeSessionCondition = `EXISTS (
+	SELECT 1 FROM refresh_tokens t
+	WHERE t.family_id = s.family_id AND t.used_at IS NULL AND t.revoked_at IS NULL AND t.expires_at > ?
+)`
+
+// SessionRepository handles the logins of users on their devices
+type SessionRepository struct {
+	db DBTX
+}
+
+// NewSessionRepository creates a new session repository
+func NewSessionRepository(db *sql.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries in the transaction
+func (r *SessionRepository) WithTx(tx *sql.Tx) *SessionRepository {
+	return &SessionRepository{db: tx}
+}
+
+// Create stores a new session
+func (r *SessionRepository) Create(session *models.UserSession) error {
+	result, err := r.db.Exec(`
+		INSERT INTO user_sessions (user_id, family_id, device, user_agent, ip_address, created_at, last_used_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, session.UserID, session.FamilyID, session.Device, session.UserAgent, session.IPAddress, session.CreatedAt, session.LastUsedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get session ID: %w", err)
+	}
+	session.ID = int(id)
+	return nil
+}
+
+// FindByFamily returns the session of a refresh token family, nil if there is none
+func (r *SessionRepository) FindByFamily(familyID string) (*models.UserSession, error) {
+	sessions, err := r.find(`s.family_id = ?`, familyID)
+	if err != nil || len(sessions) == 0 {
+		return nil, err
+	}
+	return sessions[0], nil
+}
+
+// FindActive returns the active session of the user, nil if there is none
+func (r *SessionRepository) FindActive(userID, id int, now time.Time) (*models.UserSession, error) {
+	sessions, err := r.find(`s.user_id = ? AND s.id = ? AND `+activeSessionCondition, userID, id, now)
+	if err != nil || len(sessions) == 0 {
+		return nil, err
+	}
+	return sessions[0], nil
+}
+
+// ListActive returns the active sessions of the user, most recently used first
+func (r *SessionRepository) ListActive(userID int, now time.Time) ([]*models.UserSession, error) {
+	return r.find(`s.user_id = ? AND `+activeSessionCondition, userID, now)
+}
+
+// Touch records a refresh of the session from the client
+func (r *SessionRepository) Touch(id int, client models.SessionClient, usedAt time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE user_sessions SET user_agent = ?, ip_address = ?, last_used_at = ? WHERE id = ?
+	`, client.UserAgent, client.IPAddress, usedAt, id)
+	if err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	return nil
+}
+
+// CountDevice returns the number of stored sessions of the user and how many of them are from the device
+func (r *SessionRepository) CountDevice(userID int, device string) (int, int, error) {
+	var total, sameDevice int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(CASE WHEN device = ? THEN 1 ELSE 0 END), 0)
+		FROM user_sessions WHERE user_id = ?
+	`, device, userID).Scan(&total, &sameDevice)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count sessions: %w", err)
+	}
+	return total, sameDevice, nil
+}
+
+// DeleteStale deletes sessions that ended and were last used before the given time and returns their number
+// Ended sessions are kept for a while, they tell which devices the user logged in with before.
+func (r *SessionRepository) DeleteStale(before, now time.Time) (int64, error) {
+	result, err := r.db.Exec(`
+		DELETE FROM user_sessions WHERE last_used_at < ? AND NOT EXISTS (
+			SELECT 1 FROM refresh_tokens t
+			WHERE t.family_id = user_sessions.family_id AND t.used_at IS NULL AND t.revoked_at IS NULL AND t.expires_at > ?
+		)
+	`, before, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete stale sessions: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+func (r *SessionRepository) find(where string, args ...interface{}) ([]*models.UserSession, error) {
+	rows, err := r.db.Query(`
+		SELECT s.id, s.user_id, s.family_id, s.device, s.user_agent, s.ip_address, s.created_at, s.last_used_at
+		FROM user_sessions s WHERE `+where+`
+		ORDER BY s.last_used_at DESC, s.id DESC
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []*models.UserSession{}
+	for rows.Next() {
+		session := &models.UserSession{}
+		var userAgent, ipAddress sql.NullString
+		if err := rows.Scan(&session.ID, &session.UserID, &session.FamilyID, &session.Device, &userAgent, &ipAddress,
+			&session.CreatedAt, &session.LastUsedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		session.UserAgent = userAgent.String
+		session.IPAddress = ipAddress.String
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestSessionRepository tests active sessions, known devices and the cleanup of ended sessions
+func TestSessionRepository(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewSessionRepository(db)
+	tokenRepo := NewRefreshTokenRepository(db)
+
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "User", "green")
+	now := time.Now()
+
+	create := func(familyID, device string, lastUsed time.Time, tokenExpires time.Time) *models.UserSession {
+		t.Helper()
+		session := &models.UserSession{UserID: userID, FamilyID: familyID, Device: device, CreatedAt: lastUsed, LastUsedAt: lastUsed}
+		if err := repo.Create(session); err != nil {
+			t.Fatalf("Create() failed: %v", err)
+		}
+		tokenRepo.Create(&models.RefreshToken{UserID: userID, TokenHash: "hash-" + familyID, FamilyID: familyID, ExpiresAt: tokenExpires, CreatedAt: lastUsed})
+		return session
+	}
+	laptop := create("laptop", "Firefox (Windows)", now.Add(-time.Hour), now.Add(time.Hour))
+	phone := create("phone", "Safari (iOS)", now, now.Add(time.Hour))
+	old := create("old", "Chrome (Android)", now.AddDate(0, 0, -100), now.AddDate(0, 0, -99))
+
+	sessions, err := repo.ListActive(userID, now)
+	if err != nil || len(sessions) != 2 || sessions[0].ID != phone.ID || sessions[1].ID != laptop.ID {
+		t.Fatalf("Expected phone and laptop, got %+v (%v)", sessions, err)
+	}
+	if found, _ := repo.FindActive(userID, old.ID, now); found != nil {
+		t.Error("Expected the expired session not to be active")
+	}
+
+	tokenRepo.RevokeFamily("laptop")
+	if found, _ := repo.FindActive(userID, laptop.ID, now); found != nil {
+		t.Error("Expected the revoked session not to be active")
+	}
+
+	if err := repo.Touch(phone.ID, models.SessionClient{UserAgent: "agent", IPAddress: "198.51.100.1"}, now.Add(time.Minute)); err != nil {
+		t.Fatalf("Touch() failed: %v", err)
+	}
+	found, _ := repo.FindByFamily("phone")
+	if found == nil || found.IPAddress != "198.51.100.1" || found.UserAgent != "agent" {
+		t.Errorf("Unexpected session %+v", found)
+	}
+
+	if total, sameDevice, _ := repo.CountDevice(userID, "Chrome (Android)"); total != 3 || sameDevice != 1 {
+		t.Errorf("CountDevice() = %d, %d, want 3, 1", total, sameDevice)
+	}
+
+	if deleted, _ := repo.DeleteStale(now.AddDate(0, 0, -90), now); deleted != 1 {
+		t.Errorf("Expected 1 stale session to be deleted, got %d", deleted)
+	}
+}
@@ -10,7 +10,7 @@ import (
 
 // SettingsRepository handles system settings database operations
 type SettingsRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 // NewSettingsRepository creates a new settings repository
@@ -18,6 +18,11 @@ func NewSettingsRepository(db *sql.DB) *SettingsRepository {
 	return &SettingsRepository{db: db}
 }
 
+// WithTx returns a copy of the repository that runs its queries in the transaction
+func (r *SettingsRepository) WithTx(tx *sql.Tx) *SettingsRepository {
+	return &SettingsRepository{db: tx}
+}
+
 // Get retrieves a setting by key
 func (r *SettingsRepository) Get(key string) (*models.SystemSetting, error) {
 	query := `
@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+)
+
+// TwoFactorRepository handles TOTP secrets, recovery codes and login challenges
+type TwoFactorRepository struct {
+	db DBTX
+}
+
+// NewTwoFactorRepository creates a new two-factor repository
+func NewTwoFactorRepository(db *sql.DB) *TwoFactorRepository {
+	return &TwoFactorRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries in the transaction
+func (r *TwoFactorRepository) WithTx(tx *sql.Tx) *TwoFactorRepository {
+	return &TwoFactorRepository{db: tx}
+}
+
+// Find returns the TOTP secret of a user, nil if 2FA was never set up
+func (r *TwoFactorRepository) Find(userID int) (*models.TwoFactor, error) {
+	totp := &models.TwoFactor{}
+	var enabledAt sql.NullTime
+	err := r.db.QueryRow(`
+		SELECT user_id, secret, enabled_at, last_used_step, created_at FROM user_totp WHERE user_id = ?
+	`, userID).Scan(&totp.UserID, &totp.Secret, &enabledAt, &totp.LastUsedStep, &totp.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find TOTP secret: %w", err)
+	}
+
+	if enabledAt.Valid {
+		totp.EnabledAt = &enabledAt.Time
+	}
+	return totp, nil
+}
+
+// IsEnabled reports whether the user confirmed a TOTP secret
+func (r *TwoFactorRepository) IsEnabled(userID int) (bool, error) {
+	totp, err := r.Find(userID)
+	if err != nil {
+		return false, err
+	}
+	return totp != nil && totp.EnabledAt != nil, nil
+}
+
+// SavePending replaces a secret that was not confirmed yet
+func (r *TwoFactorRepository) SavePending(totp *models.TwoFactor) error {
+	if _, err := r.db.Exec(`DELETE FROM user_totp WHERE user_id = ? AND enabled_at IS NULL`, totp.UserID); err != nil {
+		return fmt.Errorf("failed to delete pending TOTP secret: %w", err)
+	}
+	_, err := r.db.Exec(`INSERT INTO user_totp (user_id, secret, last_used_step, created_at) VALUES (?, ?, ?, ?)`,
+		totp.UserID, totp.Secret, 0, totp.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save TOTP secret: %w", err)
+	}
+	return nil
+}
+
+// Enable confirms the secret with the time step of the first accepted code
+func (r *TwoFactorRepository) Enable(userID int, step int64, enabledAt time.Time) error {
+	_, err := r.db.Exec(`UPDATE user_totp SET enabled_at = ?, last_used_step = ? WHERE user_id = ?`,
+		enabledAt, step, userID)
+	if err != nil {
+		return fmt.Errorf("failed to enable TOTP: %w", err)
+	}
+	return nil
+}
+
+// UseStep records an accepted code, false if a code of the same or a later step was already used
+// The check and the update are one statement, so a code is accepted only once.
+func (r *TwoFactorRepository) UseStep(userID int, step int64) (bool, error) {
+	result, err := r.db.Exec(`UPDATE user_totp SET last_used_step = ? WHERE user_id = ? AND last_used_step < ?`,
+		step, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to record TOTP step: %w", err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to record TOTP step: %w", err)
+	}
+	return updated == 1, nil
+}
+
+// Delete removes the secret, recovery codes and pending challenges of a user
+func (r *TwoFactorRepository) Delete(userID int) error {
+	for _, table := range []string{"user_totp", "recovery_codes", "two_factor_challenges"} {
+		if _, err := r.db.Exec(`DELETE FROM `+table+` WHERE user_id = ?`, userID); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes replaces all recovery codes of a user with the given hashes
+func (r *TwoFactorRepository) ReplaceRecoveryCodes(userID int, codeHashes []string, createdAt time.Time) error {
+	if _, err := r.db.Exec(`DELETE FROM recovery_codes WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	for _, hash := range codeHashes {
+		_, err := r.db.Exec(`INSERT INTO recovery_codes (user_id, code_hash, created_at) VALUES (?, ?, ?)`,
+			userID, hash, createdAt)
+		if err != nil {
+			return fmt.Errorf("failed to create recovery code: %w", err)
+		}
+	}
+	return nil
+}
+
+// UseRecoveryCode marks an unused recovery code as used, false if there is none with the hash
+func (r *TwoFactorRepository) UseRecoveryCode(userID int, codeHash string, usedAt time.Time) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE recovery_codes SET used_at = ? WHERE user_id = ? AND code_hash = ? AND used_at IS NULL
+	`, usedAt, userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	return updated > 0, nil
+}
+
+// CountRecoveryCodes returns the number of unused recovery codes of a user
+func (r *TwoFactorRepository) CountRecoveryCodes(userID int) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM recovery_codes WHERE user_id = ? AND used_at IS NULL`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+	return count, nil
+}
+
+// CreateChallenge stores a login challenge
+func (r *TwoFactorRepository) CreateChallenge(challenge *models.TwoFactorChallenge) error {
+	result, err := r.db.Exec(`
+		INSERT INTO two_factor_challenges (user_id, token_hash, attempts, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, challenge.UserID, challenge.TokenHash, 0, challenge.ExpiresAt, challenge.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create two-factor challenge: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get two-factor challenge ID: %w", err)
+	}
+	challenge.ID = int(id)
+	return nil
+}
+
+// FindChallenge returns the login challenge with the token hash, nil if it does not exist
+func (r *TwoFactorRepository) FindChallenge(tokenHash string) (*models.TwoFactorChallenge, error) {
+	challenge := &models.TwoFactorChallenge{}
+	err := r.db.QueryRow(`
+		SELECT id, user_id, token_hash, attempts, expires_at, created_at
+		FROM two_factor_challenges WHERE token_hash = ?
+	`, tokenHash).Scan(&challenge.ID, &challenge.UserID, &challenge.TokenHash, &challenge.Attempts,
+		&challenge.ExpiresAt, &challenge.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find two-factor challenge: %w", err)
+	}
+	return challenge, nil
+}
+
+// IncrementChallengeAttempts counts a wrong code entered for the challenge
+func (r *TwoFactorRepository) IncrementChallengeAttempts(id int) error {
+	_, err := r.db.Exec(`UPDATE two_factor_challenges SET attempts = attempts + 1 WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to update two-factor challenge: %w", err)
+	}
+	return nil
+}
+
+// DeleteChallenge deletes a completed login challenge
+func (r *TwoFactorRepository) DeleteChallenge(id int) error {
+	if _, err := r.db.Exec(`DELETE FROM two_factor_challenges WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete two-factor challenge: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpiredChallenges deletes challenges that expired before the given time and returns their number
+func (r *TwoFactorRepository) DeleteExpiredChallenges(before time.Time) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM two_factor_challenges WHERE expires_at < ?`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired two-factor challenges: %w", err)
+	}
+	return result.RowsAffected()
+}
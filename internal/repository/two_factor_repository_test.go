@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestTwoFactorRepository tests pending and enabled secrets, used time steps, recovery codes and deletion
+func TestTwoFactorRepository(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewTwoFactorRepository(db)
+
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "User", "green")
+
+	if totp, err := repo.Find(userID); err != nil || totp != nil {
+		t.Fatalf("Expected no secret, got %+v (%v)", totp, err)
+	}
+
+	repo.SavePending(&models.TwoFactor{UserID: userID, Secret: "FIRST", CreatedAt: time.Now()})
+	if err := repo.SavePending(&models.TwoFactor{UserID: userID, Secret: "SECOND", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("SavePending() failed: %v", err)
+	}
+	totp, _ := repo.Find(userID)
+	if totp == nil || totp.Secret != "SECOND" || totp.EnabledAt != nil {
+		t.Fatalf("Expected the pending secret to be replaced, got %+v", totp)
+	}
+
+	if err := repo.Enable(userID, 100, time.Now()); err != nil {
+		t.Fatalf("Enable() failed: %v", err)
+	}
+	if enabled, _ := repo.IsEnabled(userID); !enabled {
+		t.Error("Expected 2FA to be enabled")
+	}
+
+	// Only later steps are accepted
+	for _, tt := range []struct {
+		step int64
+		want bool
+	}{{100, false}, {99, false}, {101, true}, {101, false}} {
+		used, err := repo.UseStep(userID, tt.step)
+		if err != nil {
+			t.Fatalf("UseStep() failed: %v", err)
+		}
+		if used != tt.want {
+			t.Errorf("UseStep(%d) = %v, want %v", tt.step, used, tt.want)
+		}
+	}
+
+	if err := repo.ReplaceRecoveryCodes(userID, []string{"hash-a", "hash-b"}, time.Now()); err != nil {
+		t.Fatalf("ReplaceRecoveryCodes() failed: %v", err)
+	}
+	if used, _ := repo.UseRecoveryCode(userID, "hash-a", time.Now()); !used {
+		t.Error("Expected the recovery code to be used")
+	}
+	if used, _ := repo.UseRecoveryCode(userID, "hash-a", time.Now()); used {
+		t.Error("Expected a used recovery code to be rejected")
+	}
+	if count, _ := repo.CountRecoveryCodes(userID); count != 1 {
+		t.Errorf("Expected 1 unused recovery code, got %d", count)
+	}
+
+	repo.CreateChallenge(&models.TwoFactorChallenge{UserID: userID, TokenHash: "challenge",
+		ExpiresAt: time.Now().Add(time.Minute), CreatedAt: time.Now()})
+
+	if err := repo.Delete(userID); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if totp, _ := repo.Find(userID); totp != nil {
+		t.Error("Expected the secret to be deleted")
+	}
+	if count, _ := repo.CountRecoveryCodes(userID); count != 0 {
+		t.Errorf("Expected the recovery codes to be deleted, got %d", count)
+	}
+	if challenge, _ := repo.FindChallenge("challenge"); challenge != nil {
+		t.Error("Expected the challenge to be deleted")
+	}
+}
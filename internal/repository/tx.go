@@ -0,0 +1,11 @@
+package repository
+
+import "database/sql"
+
+// DBTX is implemented by *sql.DB and *sql.Tx
+// Repositories that take part in transactions use it so that WithTx can bind them to a transaction.
+type DBTX interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
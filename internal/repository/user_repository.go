@@ -5,12 +5,13 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/tranmh/gassigeher/internal/i18n"
 	"github.com/tranmh/gassigeher/internal/models"
 )
 
 // UserRepository handles user database operations
 type UserRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 // NewUserRepository creates a new user repository
@@ -18,15 +19,24 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
+// WithTx returns a copy of the repository that runs its queries in the transaction
+func (r *UserRepository) WithTx(tx *sql.Tx) *UserRepository {
+	return &UserRepository{db: tx}
+}
+
 // Create creates a new user
 func (r *UserRepository) Create(user *models.User) error {
+	if user.PreferredLanguage == "" {
+		user.PreferredLanguage = i18n.DefaultLanguage
+	}
+
 	query := `
 		INSERT INTO users (
 			name, email, phone, password_hash, experience_level,
 			is_admin, is_super_admin, is_verified, is_active,
 			verification_token, verification_token_expires,
-			terms_accepted_at, last_activity_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			terms_accepted_at, last_activity_at, preferred_language
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	result, err := r.db.Exec(
@@ -44,6 +54,7 @@ func (r *UserRepository) Create(user *models.User) error {
 		user.VerificationTokenExpires,
 		user.TermsAcceptedAt,
 		user.LastActivityAt,
+		user.PreferredLanguage,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create user: %w", err)
@@ -67,7 +78,7 @@ func (r *UserRepository) FindByEmail(email string) (*models.User, error) {
 		       password_reset_expires, profile_photo, anonymous_id,
 		       terms_accepted_at, last_activity_at, deactivated_at,
 		       deactivation_reason, reactivated_at, deleted_at,
-		       created_at, updated_at
+		       created_at, updated_at, COALESCE(preferred_language, 'de')
 		FROM users
 		WHERE email = ? AND is_deleted = 0
 	`
@@ -99,6 +110,7 @@ func (r *UserRepository) FindByEmail(email string) (*models.User, error) {
 		&user.DeletedAt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.PreferredLanguage,
 	)
 
 	if err == sql.ErrNoRows {
@@ -120,7 +132,7 @@ func (r *UserRepository) FindByID(id int) (*models.User, error) {
 		       password_reset_expires, profile_photo, anonymous_id,
 		       terms_accepted_at, last_activity_at, deactivated_at,
 		       deactivation_reason, reactivated_at, deleted_at,
-		       created_at, updated_at
+		       created_at, updated_at, COALESCE(preferred_language, 'de')
 		FROM users
 		WHERE id = ?
 	`
@@ -152,6 +164,7 @@ func (r *UserRepository) FindByID(id int) (*models.User, error) {
 		&user.DeletedAt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.PreferredLanguage,
 	)
 
 	if err == sql.ErrNoRows {
@@ -173,7 +186,7 @@ func (r *UserRepository) FindByVerificationToken(token string) (*models.User, er
 		       password_reset_expires, profile_photo, anonymous_id,
 		       terms_accepted_at, last_activity_at, deactivated_at,
 		       deactivation_reason, reactivated_at, deleted_at,
-		       created_at, updated_at
+		       created_at, updated_at, COALESCE(preferred_language, 'de')
 		FROM users
 		WHERE verification_token = ? AND is_deleted = 0
 	`
@@ -205,6 +218,7 @@ func (r *UserRepository) FindByVerificationToken(token string) (*models.User, er
 		&user.DeletedAt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.PreferredLanguage,
 	)
 
 	if err == sql.ErrNoRows {
@@ -226,7 +240,7 @@ func (r *UserRepository) FindByPasswordResetToken(token string) (*models.User, e
 		       password_reset_expires, profile_photo, anonymous_id,
 		       terms_accepted_at, last_activity_at, deactivated_at,
 		       deactivation_reason, reactivated_at, deleted_at,
-		       created_at, updated_at
+		       created_at, updated_at, COALESCE(preferred_language, 'de')
 		FROM users
 		WHERE password_reset_token = ? AND is_deleted = 0
 	`
@@ -258,6 +272,7 @@ func (r *UserRepository) FindByPasswordResetToken(token string) (*models.User, e
 		&user.DeletedAt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.PreferredLanguage,
 	)
 
 	if err == sql.ErrNoRows {
@@ -295,6 +310,7 @@ func (r *UserRepository) Update(user *models.User) error {
 			deactivation_reason = ?,
 			reactivated_at = ?,
 			deleted_at = ?,
+			preferred_language = ?,
 			updated_at = ?
 		WHERE id = ?
 	`
@@ -322,6 +338,7 @@ func (r *UserRepository) Update(user *models.User) error {
 		user.DeactivationReason,
 		user.ReactivatedAt,
 		user.DeletedAt,
+		user.PreferredLanguage,
 		time.Now(),
 		user.ID,
 	)
@@ -340,6 +357,151 @@ func (r *UserRepository) UpdateLastActivity(userID int) error {
 	return err
 }
 
+// GetReminderSchedule returns the personal reminder schedule of a user
+// Returns nil if the user uses the default schedule
+func (r *UserRepository) GetReminderSchedule(userID int) (*string, error) {
+	var schedule sql.NullString
+	err := r.db.QueryRow(`SELECT reminder_schedule FROM users WHERE id = ?`, userID).Scan(&schedule)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reminder schedule: %w", err)
+	}
+
+	if !schedule.Valid {
+		return nil, nil
+	}
+
+	return &schedule.String, nil
+}
+
+// SetReminderSchedule stores a personal reminder schedule (nil resets to the default)
+func (r *UserRepository) SetReminderSchedule(userID int, schedule *string) error {
+	query := `UPDATE users SET reminder_schedule = ?, updated_at = ? WHERE id = ?`
+	_, err := r.db.Exec(query, schedule, time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to update reminder schedule: %w", err)
+	}
+	return nil
+}
+
+// GetTelegramChatID returns the Telegram chat linked to a user
+// Returns nil if no chat is linked
+func (r *UserRepository) GetTelegramChatID(userID int) (*string, error) {
+	var chatID sql.NullString
+	err := r.db.QueryRow(`SELECT telegram_chat_id FROM users WHERE id = ?`, userID).Scan(&chatID)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get telegram chat: %w", err)
+	}
+
+	if !chatID.Valid {
+		return nil, nil
+	}
+
+	return &chatID.String, nil
+}
+
+// SetTelegramChatID links a Telegram chat to a user (nil unlinks)
+// A chat can only be linked to one user, previous links are removed
+func (r *UserRepository) SetTelegramChatID(userID int, chatID *string) error {
+	now := time.Now()
+
+	if chatID != nil {
+		_, err := r.db.Exec(`UPDATE users SET telegram_chat_id = NULL, updated_at = ? WHERE telegram_chat_id = ? AND id != ?`, now, *chatID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to update telegram chat: %w", err)
+		}
+	}
+
+	_, err := r.db.Exec(`UPDATE users SET telegram_chat_id = ?, updated_at = ? WHERE id = ?`, chatID, now, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update telegram chat: %w", err)
+	}
+	return nil
+}
+
+// GetTags returns the tags of a user, sorted
+func (r *UserRepository) GetTags(userID int) ([]string, error) {
+	rows, err := r.db.Query(`SELECT tag FROM user_tags WHERE user_id = ? ORDER BY tag`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, fmt.Errorf("failed to scan user tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// SetTags replaces the tags of a user
+func (r *UserRepository) SetTags(userID int, tags []string) error {
+	_, err := r.db.Exec(`DELETE FROM user_tags WHERE user_id = ?`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete user tags: %w", err)
+	}
+
+	for _, tag := range tags {
+		_, err := r.db.Exec(`INSERT INTO user_tags (user_id, tag) VALUES (?, ?)`, userID, tag)
+		if err != nil {
+			return fmt.Errorf("failed to create user tag: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetAllTags returns all tags in use, sorted
+func (r *UserRepository) GetAllTags() ([]string, error) {
+	rows, err := r.db.Query(`SELECT DISTINCT tag FROM user_tags ORDER BY tag`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, fmt.Errorf("failed to scan user tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// GetTagsByUser returns the tags of all users, keyed by user ID
+func (r *UserRepository) GetTagsByUser() (map[int][]string, error) {
+	rows, err := r.db.Query(`SELECT user_id, tag FROM user_tags ORDER BY user_id, tag`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := map[int][]string{}
+	for rows.Next() {
+		var userID int
+		var tag string
+		if err := rows.Scan(&userID, &tag); err != nil {
+			return nil, fmt.Errorf("failed to scan user tag: %w", err)
+		}
+		tags[userID] = append(tags[userID], tag)
+	}
+	return tags, nil
+}
+
 // DeleteAccount performs GDPR-compliant account deletion (anonymization)
 func (r *UserRepository) DeleteAccount(userID int) error {
 	// Check if user is Super Admin by querying the database
@@ -362,6 +524,7 @@ func (r *UserRepository) DeleteAccount(userID int) error {
 			name = 'Deleted User',
 			email = NULL,
 			phone = NULL,
+			telegram_chat_id = NULL,
 			password_hash = NULL,
 			profile_photo = NULL,
 			is_deleted = 1,
@@ -377,6 +540,23 @@ func (r *UserRepository) DeleteAccount(userID int) error {
 		return fmt.Errorf("failed to delete account: %w", err)
 	}
 
+	// Push subscriptions identify the user's devices
+	_, err = r.db.Exec("DELETE FROM push_subscriptions WHERE user_id = ?", userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete push subscriptions: %w", err)
+	}
+
+	// In-app notifications contain booking details
+	_, err = r.db.Exec("DELETE FROM notifications WHERE user_id = ?", userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete notifications: %w", err)
+	}
+
+	_, err = r.db.Exec("DELETE FROM user_tags WHERE user_id = ?", userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete user tags: %w", err)
+	}
+
 	return nil
 }
 
@@ -430,7 +610,7 @@ func (r *UserRepository) FindInactiveUsers(days int) ([]*models.User, error) {
 		       password_reset_expires, profile_photo, anonymous_id,
 		       terms_accepted_at, last_activity_at, deactivated_at,
 		       deactivation_reason, reactivated_at, deleted_at,
-		       created_at, updated_at
+		       created_at, updated_at, COALESCE(preferred_language, 'de')
 		FROM users
 		WHERE is_active = 1
 		  AND is_deleted = 0
@@ -475,6 +655,7 @@ func (r *UserRepository) FindInactiveUsers(days int) ([]*models.User, error) {
 			&user.DeletedAt,
 			&user.CreatedAt,
 			&user.UpdatedAt,
+			&user.PreferredLanguage,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan user: %w", err)
@@ -494,7 +675,7 @@ func (r *UserRepository) FindAll(activeOnly *bool) ([]*models.User, error) {
 		       password_reset_expires, profile_photo, anonymous_id,
 		       terms_accepted_at, last_activity_at, deactivated_at,
 		       deactivation_reason, reactivated_at, deleted_at,
-		       created_at, updated_at
+		       created_at, updated_at, COALESCE(preferred_language, 'de')
 		FROM users
 		WHERE is_deleted = 0
 	`
@@ -546,6 +727,7 @@ func (r *UserRepository) FindAll(activeOnly *bool) ([]*models.User, error) {
 			&user.DeletedAt,
 			&user.CreatedAt,
 			&user.UpdatedAt,
+			&user.PreferredLanguage,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan user: %w", err)
@@ -558,8 +740,9 @@ func (r *UserRepository) FindAll(activeOnly *bool) ([]*models.User, error) {
 
 // PromoteToAdmin promotes a user to admin role
 // DONE
+// The token version is increased, so the user's access tokens are reissued with the admin permissions.
 func (r *UserRepository) PromoteToAdmin(userID int) error {
-	query := `UPDATE users SET is_admin = ?, updated_at = ? WHERE id = ?`
+	query := `UPDATE users SET is_admin = ?, token_version = token_version + 1, updated_at = ? WHERE id = ?`
 	_, err := r.db.Exec(query, true, time.Now(), userID)
 	if err != nil {
 		return fmt.Errorf("failed to promote user to admin: %w", err)
@@ -569,8 +752,9 @@ func (r *UserRepository) PromoteToAdmin(userID int) error {
 
 // DemoteAdmin revokes admin privileges from a user
 // DONE
+// The token version is increased, so the admin access ends with the next request.
 func (r *UserRepository) DemoteAdmin(userID int) error {
-	query := `UPDATE users SET is_admin = ?, updated_at = ? WHERE id = ?`
+	query := `UPDATE users SET is_admin = ?, token_version = token_version + 1, updated_at = ? WHERE id = ?`
 	_, err := r.db.Exec(query, false, time.Now(), userID)
 	if err != nil {
 		return fmt.Errorf("failed to demote admin: %w", err)
@@ -578,6 +762,29 @@ func (r *UserRepository) DemoteAdmin(userID int) error {
 	return nil
 }
 
+// FindAuthState returns the token version and status of a user, nil if the user does not exist
+func (r *UserRepository) FindAuthState(userID int) (*models.AuthState, error) {
+	state := &models.AuthState{}
+	err := r.db.QueryRow(`SELECT token_version, is_active, is_deleted FROM users WHERE id = ?`, userID).
+		Scan(&state.TokenVersion, &state.IsActive, &state.IsDeleted)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find auth state: %w", err)
+	}
+	return state, nil
+}
+
+// IncrementTokenVersion invalidates all access tokens issued to the user so far
+func (r *UserRepository) IncrementTokenVersion(userID int) error {
+	_, err := r.db.Exec(`UPDATE users SET token_version = token_version + 1 WHERE id = ?`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to increment token version: %w", err)
+	}
+	return nil
+}
+
 // IsSuperAdmin checks if a user is a super admin
 // DONE
 func (r *UserRepository) IsSuperAdmin(userID int) (bool, error) {
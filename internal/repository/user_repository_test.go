@@ -331,6 +331,35 @@ func TestUserRepository_Activate(t *testing.T) {
 	})
 }
 
+// TestUserRepository_TokenVersion tests the auth state and that admin flag changes invalidate access tokens
+func TestUserRepository_TokenVersion(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewUserRepository(db)
+
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "User", "green")
+
+	state, err := repo.FindAuthState(userID)
+	if err != nil || state == nil || state.TokenVersion != 0 || !state.IsActive || state.IsDeleted {
+		t.Fatalf("Unexpected auth state %+v (%v)", state, err)
+	}
+
+	repo.PromoteToAdmin(userID)
+	repo.DemoteAdmin(userID)
+	repo.IncrementTokenVersion(userID)
+	if state, _ := repo.FindAuthState(userID); state.TokenVersion != 3 {
+		t.Errorf("Expected token version 3, got %d", state.TokenVersion)
+	}
+
+	repo.Deactivate(userID, "test")
+	if state, _ := repo.FindAuthState(userID); state.IsActive {
+		t.Error("Expected the user to be inactive")
+	}
+
+	if state, err := repo.FindAuthState(99999); err != nil || state != nil {
+		t.Errorf("Expected nil for an unknown user, got %+v (%v)", state, err)
+	}
+}
+
 // DONE: TestUserRepository_FindAll tests listing all users
 func TestUserRepository_FindAll(t *testing.T) {
 	db := testutil.SetupTestDB(t)
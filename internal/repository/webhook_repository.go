@@ -0,0 +1,285 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+)
+
+// WebhookRepository handles webhook subscriptions and their deliveries
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// Create stores a webhook including its secret
+func (r *WebhookRepository) Create(webhook *models.Webhook) error {
+	now := time.Now()
+	result, err := r.db.Exec(`
+		INSERT INTO webhooks (name, url, secret, events, is_active, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, webhook.Name, webhook.URL, webhook.Secret, strings.Join(webhook.Events, ","), webhook.IsActive, now, now)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get webhook ID: %w", err)
+	}
+	webhook.ID = int(id)
+	webhook.CreatedAt = now
+	webhook.UpdatedAt = now
+	return nil
+}
+
+const webhookColumns = `id, name, url, secret, events, is_active, created_at, updated_at`
+
+func scanWebhook(scanner interface{ Scan(...interface{}) error }) (*models.Webhook, error) {
+	webhook := &models.Webhook{}
+	var events string
+	err := scanner.Scan(&webhook.ID, &webhook.Name, &webhook.URL, &webhook.Secret, &events, &webhook.IsActive,
+		&webhook.CreatedAt, &webhook.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.Events = []string{}
+	for _, event := range strings.Split(events, ",") {
+		if event != "" {
+			webhook.Events = append(webhook.Events, event)
+		}
+	}
+	return webhook, nil
+}
+
+// FindByID returns a webhook including its secret, or nil if it does not exist
+func (r *WebhookRepository) FindByID(id int) (*models.Webhook, error) {
+	row := r.db.QueryRow(`SELECT `+webhookColumns+` FROM webhooks WHERE id = ?`, id)
+	webhook, err := scanWebhook(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+// FindAll returns all webhooks including their secrets, ordered by name
+func (r *WebhookRepository) FindAll() ([]*models.Webhook, error) {
+	return r.query(`SELECT ` + webhookColumns + ` FROM webhooks ORDER BY name, id`)
+}
+
+// FindActiveByEvent returns the active webhooks subscribed to an event
+func (r *WebhookRepository) FindActiveByEvent(event string) ([]*models.Webhook, error) {
+	webhooks, err := r.query(`SELECT ` + webhookColumns + ` FROM webhooks WHERE is_active = 1 ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+
+	subscribed := []*models.Webhook{}
+	for _, webhook := range webhooks {
+		if webhook.Subscribes(event) {
+			subscribed = append(subscribed, webhook)
+		}
+	}
+	return subscribed, nil
+}
+
+func (r *WebhookRepository) query(query string, args ...interface{}) ([]*models.Webhook, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := []*models.Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, nil
+}
+
+// Update updates name, URL, events and active flag of a webhook (not the secret)
+func (r *WebhookRepository) Update(webhook *models.Webhook) error {
+	webhook.UpdatedAt = time.Now()
+	_, err := r.db.Exec(`
+		UPDATE webhooks SET name = ?, url = ?, events = ?, is_active = ?, updated_at = ?
+		WHERE id = ?
+	`, webhook.Name, webhook.URL, strings.Join(webhook.Events, ","), webhook.IsActive, webhook.UpdatedAt, webhook.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+	return nil
+}
+
+// UpdateSecret replaces the signing secret of a webhook
+func (r *WebhookRepository) UpdateSecret(id int, secret string) error {
+	_, err := r.db.Exec(`UPDATE webhooks SET secret = ?, updated_at = ? WHERE id = ?`, secret, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook secret: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes a webhook and its deliveries
+func (r *WebhookRepository) Delete(id int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM webhook_deliveries WHERE webhook_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete webhook deliveries: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM webhooks WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// CreateDelivery queues an event for a webhook
+func (r *WebhookRepository) CreateDelivery(delivery *models.WebhookDelivery) error {
+	now := time.Now()
+	delivery.Status = models.WebhookDeliveryPending
+	result, err := r.db.Exec(`
+		INSERT INTO webhook_deliveries (webhook_id, event, payload, status, attempts, next_attempt_at, created_at)
+		VALUES (?, ?, ?, ?, 0, ?, ?)
+	`, delivery.WebhookID, delivery.Event, delivery.Payload, delivery.Status, delivery.NextAttemptAt, now)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get webhook delivery ID: %w", err)
+	}
+	delivery.ID = int(id)
+	delivery.CreatedAt = now
+	return nil
+}
+
+const webhookDeliveryColumns = `id, webhook_id, event, payload, status, attempts, next_attempt_at, last_attempt_at, response_status, error, created_at`
+
+func scanWebhookDelivery(scanner interface{ Scan(...interface{}) error }) (*models.WebhookDelivery, error) {
+	delivery := &models.WebhookDelivery{}
+	var lastAttemptAt sql.NullTime
+	var responseStatus sql.NullInt64
+	var deliveryError sql.NullString
+	err := scanner.Scan(&delivery.ID, &delivery.WebhookID, &delivery.Event, &delivery.Payload, &delivery.Status, &delivery.Attempts,
+		&delivery.NextAttemptAt, &lastAttemptAt, &responseStatus, &deliveryError, &delivery.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if lastAttemptAt.Valid {
+		delivery.LastAttemptAt = &lastAttemptAt.Time
+	}
+	if responseStatus.Valid {
+		status := int(responseStatus.Int64)
+		delivery.ResponseStatus = &status
+	}
+	if deliveryError.Valid {
+		delivery.Error = &deliveryError.String
+	}
+	return delivery, nil
+}
+
+// ClaimDue claims pending deliveries whose next attempt is due for one instance, oldest first
+// Deliveries claimed by another instance since staleBefore are skipped, so every attempt is sent once.
+func (r *WebhookRepository) ClaimDue(claimedBy string, now, staleBefore time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	due, err := r.queryDeliveries(`
+		SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries
+		WHERE status = ? AND next_attempt_at <= ? AND (claimed_at IS NULL OR claimed_at < ?)
+		ORDER BY next_attempt_at, id
+		LIMIT ?
+	`, models.WebhookDeliveryPending, now, staleBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	claimed := []*models.WebhookDelivery{}
+	for _, delivery := range due {
+		result, err := r.db.Exec(`
+			UPDATE webhook_deliveries SET claimed_by = ?, claimed_at = ?
+			WHERE id = ? AND status = ? AND (claimed_at IS NULL OR claimed_at < ?)
+		`, claimedBy, now, delivery.ID, models.WebhookDeliveryPending, staleBefore)
+		if err != nil {
+			return nil, fmt.Errorf("failed to claim webhook delivery: %w", err)
+		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected == 1 {
+			claimed = append(claimed, delivery)
+		}
+	}
+	return claimed, nil
+}
+
+// FindDeliveries returns the most recent deliveries of a webhook, newest first
+func (r *WebhookRepository) FindDeliveries(webhookID, limit int) ([]*models.WebhookDelivery, error) {
+	return r.queryDeliveries(`
+		SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries
+		WHERE webhook_id = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?
+	`, webhookID, limit)
+}
+
+func (r *WebhookRepository) queryDeliveries(query string, args ...interface{}) ([]*models.WebhookDelivery, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []*models.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
+
+// UpdateDelivery records the result of a delivery attempt and releases the claim
+func (r *WebhookRepository) UpdateDelivery(delivery *models.WebhookDelivery) error {
+	_, err := r.db.Exec(`
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = ?, next_attempt_at = ?, last_attempt_at = ?, response_status = ?, error = ?,
+		    claimed_by = NULL, claimed_at = NULL
+		WHERE id = ?
+	`, delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastAttemptAt, delivery.ResponseStatus, delivery.Error, delivery.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// DeleteFinishedBefore removes delivered and failed deliveries created before the cutoff
+func (r *WebhookRepository) DeleteFinishedBefore(cutoff time.Time) (int64, error) {
+	result, err := r.db.Exec(`
+		DELETE FROM webhook_deliveries WHERE status != ? AND created_at < ?
+	`, models.WebhookDeliveryPending, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old webhook deliveries: %w", err)
+	}
+	return result.RowsAffected()
+}
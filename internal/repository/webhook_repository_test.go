@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestWebhookRepository tests storing webhooks and finding subscribers of an event
+func TestWebhookRepository(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewWebhookRepository(db)
+
+	bookings := &models.Webhook{Name: "Chat bot", URL: "https://bot.example.com/hook", Secret: "s1",
+		Events: []string{models.WebhookEventBookingCreated, models.WebhookEventBookingCancelled}, IsActive: true}
+	dogs := &models.Webhook{Name: "Intranet", URL: "https://intranet.example.com/hook", Secret: "s2",
+		Events: []string{models.WebhookEventDogAvailabilityChanged}, IsActive: true}
+	for _, webhook := range []*models.Webhook{bookings, dogs} {
+		if err := repo.Create(webhook); err != nil {
+			t.Fatalf("Create() failed: %v", err)
+		}
+	}
+
+	found, err := repo.FindByID(bookings.ID)
+	if err != nil || found == nil {
+		t.Fatalf("FindByID() failed: %v", err)
+	}
+	if found.Secret != "s1" || len(found.Events) != 2 || !found.IsActive {
+		t.Errorf("Unexpected webhook: %+v", found)
+	}
+	if missing, _ := repo.FindByID(999); missing != nil {
+		t.Error("Expected nil for unknown webhook")
+	}
+
+	subscribed, _ := repo.FindActiveByEvent(models.WebhookEventBookingCancelled)
+	if len(subscribed) != 1 || subscribed[0].ID != bookings.ID {
+		t.Errorf("Expected only the booking webhook, got %d", len(subscribed))
+	}
+
+	bookings.IsActive = false
+	if err := repo.Update(bookings); err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	if subscribed, _ := repo.FindActiveByEvent(models.WebhookEventBookingCancelled); len(subscribed) != 0 {
+		t.Errorf("Expected inactive webhook to be skipped, got %d", len(subscribed))
+	}
+
+	if err := repo.UpdateSecret(dogs.ID, "rotated"); err != nil {
+		t.Fatalf("UpdateSecret() failed: %v", err)
+	}
+	if found, _ := repo.FindByID(dogs.ID); found.Secret != "rotated" {
+		t.Errorf("Expected rotated secret, got %s", found.Secret)
+	}
+
+	repo.CreateDelivery(&models.WebhookDelivery{WebhookID: dogs.ID, Event: models.WebhookEventDogAvailabilityChanged, Payload: "{}", NextAttemptAt: time.Now()})
+	if err := repo.Delete(dogs.ID); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if testutil.CountRows(t, db, "webhook_deliveries") != 0 {
+		t.Error("Expected deliveries to be deleted with the webhook")
+	}
+	if all, _ := repo.FindAll(); len(all) != 1 {
+		t.Errorf("Expected 1 webhook left, got %d", len(all))
+	}
+}
+
+// TestWebhookRepository_Deliveries tests the retry queue and the delivery log
+func TestWebhookRepository_Deliveries(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewWebhookRepository(db)
+	now := time.Now()
+
+	webhook := &models.Webhook{Name: "Bot", URL: "https://bot.example.com", Secret: "s", Events: []string{models.WebhookEventBookingCreated}, IsActive: true}
+	repo.Create(webhook)
+
+	due := &models.WebhookDelivery{WebhookID: webhook.ID, Event: models.WebhookEventBookingCreated, Payload: `{"a":1}`, NextAttemptAt: now.Add(-time.Minute)}
+	later := &models.WebhookDelivery{WebhookID: webhook.ID, Event: models.WebhookEventBookingCreated, Payload: `{"a":2}`, NextAttemptAt: now.Add(time.Hour)}
+	repo.CreateDelivery(due)
+	repo.CreateDelivery(later)
+
+	deliveries, err := repo.ClaimDue("a", now, now.Add(-time.Minute), 10)
+	if err != nil {
+		t.Fatalf("ClaimDue() failed: %v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].ID != due.ID || deliveries[0].Payload != `{"a":1}` {
+		t.Fatalf("Expected only the due delivery, got %d", len(deliveries))
+	}
+	if again, _ := repo.ClaimDue("b", now, now.Add(-time.Minute), 10); len(again) != 0 {
+		t.Errorf("Expected a claimed delivery to be skipped by other instances, got %d", len(again))
+	}
+	if stale, _ := repo.ClaimDue("b", now.Add(30*time.Minute), now.Add(time.Minute), 10); len(stale) != 1 {
+		t.Errorf("Expected a stale claim to be taken over, got %d", len(stale))
+	}
+
+	status := 200
+	due.Status = models.WebhookDeliveryDelivered
+	due.Attempts = 1
+	due.LastAttemptAt = &now
+	due.ResponseStatus = &status
+	if err := repo.UpdateDelivery(due); err != nil {
+		t.Fatalf("UpdateDelivery() failed: %v", err)
+	}
+	if deliveries, _ := repo.ClaimDue("a", now, now.Add(-time.Minute), 10); len(deliveries) != 0 {
+		t.Errorf("Expected delivered delivery not to be due, got %d", len(deliveries))
+	}
+
+	log, _ := repo.FindDeliveries(webhook.ID, 10)
+	if len(log) != 2 || log[1].ResponseStatus == nil || *log[1].ResponseStatus != 200 || log[1].Attempts != 1 {
+		t.Errorf("Unexpected delivery log: %d entries", len(log))
+	}
+
+	// Only finished deliveries are removed
+	deleted, err := repo.DeleteFinishedBefore(now.Add(time.Minute))
+	if err != nil || deleted != 1 {
+		t.Errorf("Expected 1 deleted delivery, got %d (%v)", deleted, err)
+	}
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+)
+
+// announcementClaimLease is how long an announcement stays with the instance sending it without
+// renewing the claim, afterwards another instance resumes it. The claim is renewed before every
+// email, so the lease only runs out if the sender stopped.
+const announcementClaimLease = 10 * time.Minute
+
+// AnnouncementService sends admin announcements to a segment of users by email and in-app
+type AnnouncementService struct {
+	repo        *repository.AnnouncementRepository
+	inbox       *repository.NotificationRepository
+	preferences *repository.NotificationPreferenceRepository
+	templates   *EmailTemplateStore
+	email       *EmailService       // nil = in-app only
+	interval    time.Duration       // Minimum time between two emails (0 = no throttling)
+	lastEmail   time.Time           // Time the last email was handed to the provider
+	sleep       func(time.Duration) // Replaced in tests
+}
+
+// NewAnnouncementService creates an announcement service
+// emailsPerMinute throttles the email provider, 0 disables throttling
+func NewAnnouncementService(repo *repository.AnnouncementRepository, inbox *repository.NotificationRepository, preferences *repository.NotificationPreferenceRepository, templates *EmailTemplateStore, email *EmailService, emailsPerMinute int) *AnnouncementService {
+	var interval time.Duration
+	if emailsPerMinute > 0 {
+		interval = time.Minute / time.Duration(emailsPerMinute)
+	}
+
+	return &AnnouncementService{
+		repo:        repo,
+		inbox:       inbox,
+		preferences: preferences,
+		templates:   templates,
+		email:       email,
+		interval:    interval,
+		sleep:       time.Sleep,
+	}
+}
+
+// NewAnnouncementServiceFromConfig creates an announcement service from the application config
+// email may be nil if no email provider is configured
+func NewAnnouncementServiceFromConfig(db *sql.DB, cfg *config.Config, email *EmailService) *AnnouncementService {
+	return NewAnnouncementService(
+		repository.NewAnnouncementRepository(db),
+		repository.NewNotificationRepository(db),
+		repository.NewNotificationPreferenceRepository(db),
+		NewEmailTemplateStore(repository.NewEmailTemplateRepository(db), cfg.BaseURL),
+		email,
+		cfg.AnnouncementEmailsPerMinute,
+	)
+}
+
+// Preview renders the announcement email for the admin and counts the current recipients
+func (s *AnnouncementService) Preview(req *models.CreateAnnouncementRequest, lang, name string) (*models.AnnouncementPreview, error) {
+	subject, body, err := s.templates.Render("announcement", lang, map[string]interface{}{
+		"Name":    name,
+		"Subject": req.Subject,
+		"Message": req.Message,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.repo.CountRecipients(req.Segment, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AnnouncementPreview{Subject: subject, Body: body, RecipientCount: count}, nil
+}
+
+// ProcessDue sends all announcements that are due and resumes interrupted ones
+// Returns the number of announcements sent
+func (s *AnnouncementService) ProcessDue(now time.Time) (int, error) {
+	interrupted, err := s.repo.ClaimStale(now, now.Add(-announcementClaimLease))
+	if err != nil {
+		return 0, err
+	}
+
+	due, err := s.repo.ClaimDue(now)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, announcement := range append(interrupted, due...) {
+		completed, err := s.send(announcement, now)
+		if err != nil {
+			log.Printf("Failed to send announcement %d: %v", announcement.ID, err)
+			continue
+		}
+		if completed {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// send delivers an announcement to all pending recipients
+// Recipients are resolved once when sending starts, so a resumed announcement reaches the same users.
+// Returns false if deliveries are left to another instance and the announcement is not sent yet.
+func (s *AnnouncementService) send(announcement *models.Announcement, now time.Time) (bool, error) {
+	count, err := s.repo.CountDeliveries(announcement.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if count == 0 {
+		recipients, err := s.repo.FindRecipients(announcement.Segment, now)
+		if err != nil {
+			return false, err
+		}
+
+		userIDs := make([]int, len(recipients))
+		for i, user := range recipients {
+			userIDs[i] = user.ID
+		}
+		if err := s.repo.CreateDeliveries(announcement.ID, userIDs); err != nil {
+			return false, err
+		}
+	}
+
+	deliveries, err := s.repo.FindDeliveries(announcement.ID, models.AnnouncementDeliveryPending)
+	if err != nil {
+		return false, err
+	}
+
+	held := 0
+	for _, delivery := range deliveries {
+		// Keeps other instances from resuming the announcement while it is sent
+		if err := s.repo.Renew(announcement.ID, time.Now()); err != nil {
+			return false, err
+		}
+		claimed, err := s.repo.ClaimDelivery(delivery.ID, time.Now(), time.Now().Add(-announcementClaimLease))
+		if err != nil {
+			return false, err
+		}
+		if !claimed {
+			held++
+			continue
+		}
+
+		s.record(announcement, delivery.UserID)
+
+		status, deliveryErr := s.deliver(announcement, delivery)
+		if err := s.repo.UpdateDelivery(delivery.ID, status, deliveryErr); err != nil {
+			return false, err
+		}
+	}
+
+	// Deliveries claimed by another instance stay pending until it finishes them, or until their
+	// claim is stale and the announcement is resumed. Completing now would leave them pending forever.
+	if held > 0 {
+		log.Printf("Announcement %d: %d delivery(s) still claimed by another instance, completing later", announcement.ID, held)
+		return false, nil
+	}
+
+	log.Printf("Announcement %d sent to %d recipient(s)", announcement.ID, len(deliveries))
+	return true, s.repo.Complete(announcement.ID)
+}
+
+// deliver sends the announcement email to one recipient and returns the delivery status
+func (s *AnnouncementService) deliver(announcement *models.Announcement, delivery *models.AnnouncementDelivery) (string, *string) {
+	if s.email == nil || delivery.UserEmail == "" {
+		return models.AnnouncementDeliverySkipped, nil
+	}
+
+	enabled, err := s.preferences.IsEnabled(delivery.UserID, models.NotificationCategoryAnnouncements, models.NotificationChannelEmail)
+	if err != nil {
+		log.Printf("Failed to check notification preferences for user %d, sending anyway: %v", delivery.UserID, err)
+		enabled = true
+	}
+	if !enabled {
+		return models.AnnouncementDeliverySkipped, nil
+	}
+
+	s.throttle()
+	if err := s.email.SendAnnouncement(delivery.UserEmail, delivery.UserName, announcement.Subject, announcement.Message); err != nil {
+		log.Printf("Failed to send announcement %d to user %d: %v", announcement.ID, delivery.UserID, err)
+		message := err.Error()
+		return models.AnnouncementDeliveryFailed, &message
+	}
+	return models.AnnouncementDeliverySent, nil
+}
+
+// throttle waits until the email provider may receive the next email
+func (s *AnnouncementService) throttle() {
+	if s.interval > 0 && !s.lastEmail.IsZero() {
+		if wait := s.interval - time.Since(s.lastEmail); wait > 0 {
+			s.sleep(wait)
+		}
+	}
+	s.lastEmail = time.Now()
+}
+
+// record stores the announcement in the user's notification center
+func (s *AnnouncementService) record(announcement *models.Announcement, userID int) {
+	notification := &models.Notification{
+		UserID: userID,
+		Type:   models.NotificationTypeAnnouncement,
+		Params: []string{announcement.Subject, announcement.Message},
+	}
+	if err := s.inbox.Create(notification); err != nil {
+		log.Printf("Failed to record announcement %d for user %d: %v", announcement.ID, userID, err)
+	}
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// rejectingEmailProvider fails for one address and records all other emails
+type rejectingEmailProvider struct {
+	recordingEmailProvider
+	reject string
+}
+
+func (p *rejectingEmailProvider) SendEmail(to, subject, body string) error {
+	return p.SendEmailWithHeaders(to, subject, body, nil)
+}
+
+func (p *rejectingEmailProvider) SendEmailWithHeaders(to, subject, body string, headers map[string]string) error {
+	if to == p.reject {
+		return errors.New("mailbox unavailable")
+	}
+	return p.recordingEmailProvider.SendEmailWithHeaders(to, subject, body, headers)
+}
+
+// TestAnnouncementService_ProcessDue tests sending an announcement by email and in-app
+func TestAnnouncementService_ProcessDue(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	annaID := testutil.SeedTestUser(t, db, "anna@example.com", "Anna", "green")
+	optedOutID := testutil.SeedTestUser(t, db, "optout@example.com", "Otto", "green")
+	failingID := testutil.SeedTestUser(t, db, "broken@example.com", "Bernd", "green")
+	otherID := testutil.SeedTestUser(t, db, "orange@example.com", "Olga", "orange")
+	repository.NewNotificationPreferenceRepository(db).Set(optedOutID, models.NotificationCategoryAnnouncements, models.NotificationChannelEmail, false)
+
+	provider := &rejectingEmailProvider{reject: "broken@example.com"}
+	email := &EmailService{
+		provider:    provider,
+		baseURL:     "https://example.com",
+		templates:   NewEmailTemplateStore(nil, "https://example.com"),
+		userRepo:    repository.NewUserRepository(db),
+		preferences: repository.NewNotificationPreferenceRepository(db),
+		unsubscribe: NewUnsubscribeTokens("secret"),
+	}
+
+	service := NewAnnouncementServiceFromConfig(db, &config.Config{AnnouncementEmailsPerMinute: 60}, email)
+	var pauses []time.Duration
+	service.sleep = func(d time.Duration) { pauses = append(pauses, d) }
+
+	repo := repository.NewAnnouncementRepository(db)
+	now := time.Now()
+	announcement := &models.Announcement{
+		Subject:     "Sommerfest",
+		Message:     "Am Samstag bleibt das Tierheim geschlossen.",
+		Segment:     models.AnnouncementSegment{Status: models.SegmentStatusActive, ExperienceLevels: []string{"green"}},
+		ScheduledAt: now.Add(-time.Minute),
+	}
+	scheduled := &models.Announcement{Subject: "Later", Message: "Later", ScheduledAt: now.Add(time.Hour)}
+	repo.Create(announcement)
+	repo.Create(scheduled)
+
+	sent, err := service.ProcessDue(now)
+	if err != nil {
+		t.Fatalf("ProcessDue() failed: %v", err)
+	}
+	if sent != 1 {
+		t.Fatalf("Expected 1 announcement to be sent, got %d", sent)
+	}
+
+	if provider.sent != 1 || provider.to != "anna@example.com" {
+		t.Errorf("Expected one email to anna@example.com, got %d (last to %s)", provider.sent, provider.to)
+	}
+	if provider.subject != "Sommerfest" || !strings.Contains(provider.body, "Tierheim geschlossen") {
+		t.Errorf("Unexpected email %q: %s", provider.subject, provider.body)
+	}
+	if provider.headers["List-Unsubscribe"] == "" {
+		t.Error("Expected List-Unsubscribe header")
+	}
+	// Skipped recipients do not reach the provider, so only the second attempt waits
+	if len(pauses) != 1 || pauses[0] <= 0 || pauses[0] > time.Second {
+		t.Errorf("Expected one pause of up to 1s between the two attempts, got %v", pauses)
+	}
+
+	report, _ := repo.Report(announcement.ID)
+	if report.Total != 3 || report.Sent != 1 || report.Skipped != 1 || report.Failed != 1 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+	if len(report.Failures) != 1 || report.Failures[0].UserID != failingID {
+		t.Errorf("Expected failure of user %d, got %v", failingID, report.Failures)
+	}
+
+	t.Run("records in-app notifications for all recipients", func(t *testing.T) {
+		inbox := repository.NewNotificationRepository(db)
+		for _, userID := range []int{annaID, optedOutID, failingID} {
+			notifications, _ := inbox.FindByUser(userID, false, 50, 0)
+			if len(notifications) != 1 {
+				t.Fatalf("Expected 1 notification for user %d, got %d", userID, len(notifications))
+			}
+			notifications[0].Render("en")
+			if notifications[0].Title != "Sommerfest" || notifications[0].Message != announcement.Message {
+				t.Errorf("Unexpected notification %q: %q", notifications[0].Title, notifications[0].Message)
+			}
+		}
+		if count, _ := inbox.CountUnread(otherID); count != 0 {
+			t.Errorf("Expected no notification outside the segment, got %d", count)
+		}
+	})
+
+	t.Run("does not send twice", func(t *testing.T) {
+		if sent, _ := service.ProcessDue(now); sent != 0 {
+			t.Errorf("Expected nothing to send, got %d", sent)
+		}
+		done, _ := repo.FindByID(announcement.ID)
+		if done.Status != models.AnnouncementStatusSent {
+			t.Errorf("Expected status sent, got %s", done.Status)
+		}
+		if later, _ := repo.FindByID(scheduled.ID); later.Status != models.AnnouncementStatusScheduled {
+			t.Errorf("Expected future announcement to stay scheduled, got %s", later.Status)
+		}
+	})
+}
+
+// TestAnnouncementService_Resume tests that an interrupted announcement is resumed once its claim is stale
+// and only reaches the remaining recipients
+func TestAnnouncementService_Resume(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	doneID := testutil.SeedTestUser(t, db, "done@example.com", "Done", "green")
+	pendingID := testutil.SeedTestUser(t, db, "pending@example.com", "Pending", "green")
+	testutil.SeedTestUser(t, db, "new@example.com", "New", "green")
+
+	repo := repository.NewAnnouncementRepository(db)
+	now := time.Now()
+	announcement := &models.Announcement{Subject: "News", Message: "Hello", ScheduledAt: now.Add(-time.Hour)}
+	repo.Create(announcement)
+	repo.ClaimDue(now)
+	repo.CreateDeliveries(announcement.ID, []int{doneID, pendingID})
+	deliveries, _ := repo.FindDeliveries(announcement.ID, models.AnnouncementDeliveryPending)
+	repo.UpdateDelivery(deliveries[0].ID, models.AnnouncementDeliverySent, nil)
+	// The interrupted sender claimed the second delivery but stopped before sending it
+	repo.ClaimDelivery(deliveries[1].ID, now.Add(-2*announcementClaimLease), now.Add(-3*announcementClaimLease))
+
+	provider := &recordingEmailProvider{}
+	email := &EmailService{provider: provider, templates: NewEmailTemplateStore(nil, "")}
+	service := NewAnnouncementServiceFromConfig(db, &config.Config{}, email)
+
+	// The instance that claimed it may still be sending
+	if sent, err := service.ProcessDue(now); err != nil || sent != 0 {
+		t.Fatalf("Expected announcement with a fresh claim not to be resumed, got %d (%v)", sent, err)
+	}
+
+	later := now.Add(announcementClaimLease + time.Minute)
+	if sent, err := service.ProcessDue(later); err != nil || sent != 1 {
+		t.Fatalf("Expected interrupted announcement to be resumed, got %d (%v)", sent, err)
+	}
+	if provider.sent != 1 || provider.to != "pending@example.com" {
+		t.Errorf("Expected only the pending recipient to get an email, got %d (last to %s)", provider.sent, provider.to)
+	}
+
+	report, _ := repo.Report(announcement.ID)
+	if report.Total != 2 || report.Sent != 2 {
+		t.Errorf("Expected the original recipients to be kept, got %+v", report)
+	}
+}
+
+// TestAnnouncementService_DeliveryClaimedElsewhere tests that an announcement is not completed while
+// another instance still sends one of its deliveries
+func TestAnnouncementService_DeliveryClaimedElsewhere(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	firstID := testutil.SeedTestUser(t, db, "first@example.com", "First", "green")
+	secondID := testutil.SeedTestUser(t, db, "second@example.com", "Second", "green")
+
+	repo := repository.NewAnnouncementRepository(db)
+	now := time.Now()
+	announcement := &models.Announcement{Subject: "News", Message: "Hello", ScheduledAt: now.Add(-time.Hour)}
+	repo.Create(announcement)
+	repo.ClaimDue(now.Add(-2 * announcementClaimLease))
+	repo.CreateDeliveries(announcement.ID, []int{firstID, secondID})
+	deliveries, _ := repo.FindDeliveries(announcement.ID, models.AnnouncementDeliveryPending)
+	repo.ClaimDelivery(deliveries[0].ID, now, now.Add(-announcementClaimLease))
+
+	provider := &recordingEmailProvider{}
+	email := &EmailService{provider: provider, templates: NewEmailTemplateStore(nil, "")}
+	service := NewAnnouncementServiceFromConfig(db, &config.Config{}, email)
+
+	if sent, err := service.ProcessDue(now); err != nil || sent != 0 {
+		t.Fatalf("Expected announcement not to be completed, got %d (%v)", sent, err)
+	}
+	if provider.sent != 1 || provider.to != "second@example.com" {
+		t.Errorf("Expected only the unclaimed delivery to be sent, got %d (last to %s)", provider.sent, provider.to)
+	}
+
+	sending, _ := repo.FindByID(announcement.ID)
+	if sending.Status != models.AnnouncementStatusSending {
+		t.Errorf("Expected announcement to keep sending, got %s", sending.Status)
+	}
+}
+
+// TestAnnouncementService_Preview tests rendering the email and counting recipients
+func TestAnnouncementService_Preview(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	testutil.SeedTestUser(t, db, "green@example.com", "Green", "green")
+	testutil.SeedTestUser(t, db, "blue@example.com", "Blue", "blue")
+
+	service := NewAnnouncementServiceFromConfig(db, &config.Config{}, nil)
+	req := &models.CreateAnnouncementRequest{
+		Subject: "Summer party",
+		Message: "The shelter is closed on Saturday.",
+		Segment: models.AnnouncementSegment{Status: models.SegmentStatusActive, ExperienceLevels: []string{"blue"}},
+	}
+
+	preview, err := service.Preview(req, "en", "Admin")
+	if err != nil {
+		t.Fatalf("Preview() failed: %v", err)
+	}
+	if preview.Subject != "Summer party" || preview.RecipientCount != 1 {
+		t.Errorf("Unexpected preview: %q to %d recipient(s)", preview.Subject, preview.RecipientCount)
+	}
+	if !strings.Contains(preview.Body, "Hello Admin") || !strings.Contains(preview.Body, "closed on Saturday") {
+		t.Errorf("Expected rendered body, got %s", preview.Body)
+	}
+}
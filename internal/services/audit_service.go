@@ -0,0 +1,114 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+)
+
+// auditMu serializes appends within this process, the unique prev_hash catches writers of other processes
+var auditMu sync.Mutex
+
+// auditAppendAttempts is how often Record retries when another writer appended first
+const auditAppendAttempts = 3
+
+// AuditService appends entries to the hash-chained audit log and verifies the chain
+type AuditService struct {
+	db   *sql.DB
+	repo *repository.AuditLogRepository
+}
+
+// NewAuditService creates a new audit service
+func NewAuditService(db *sql.DB) *AuditService {
+	return &AuditService{
+		db:   db,
+		repo: repository.NewAuditLogRepository(db),
+	}
+}
+
+// Record chains the entry to the newest entry and appends it
+// CreatedAt defaults to now and is stored in UTC with second precision, PrevHash and Hash are set by Record.
+func (s *AuditService) Record(entry *models.AuditEntry) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	entry.CreatedAt = entry.CreatedAt.UTC().Truncate(time.Second)
+
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	var err error
+	for attempt := 0; attempt < auditAppendAttempts; attempt++ {
+		err = s.append(entry)
+		if err == nil || !isUniqueViolation(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func (s *AuditService) append(entry *models.AuditEntry) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	repo := s.repo.WithTx(tx)
+	prevHash, err := repo.LastHash()
+	if err != nil {
+		return err
+	}
+	entry.PrevHash = prevHash
+	entry.Hash = entry.ComputeHash()
+
+	if err := repo.Create(entry); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Verify recomputes every hash and checks that each entry links to its predecessor
+// It stops at the first entry that was changed (hash_mismatch) or whose predecessor is missing (chain_broken).
+func (s *AuditService) Verify() (*models.AuditVerification, error) {
+	result := &models.AuditVerification{Valid: true, HeadHash: models.AuditGenesisHash}
+
+	err := s.repo.Each(&models.AuditFilter{}, func(entry *models.AuditEntry) error {
+		if !result.Valid {
+			return nil
+		}
+
+		switch {
+		case entry.PrevHash != result.HeadHash:
+			result.Reason = "chain_broken"
+		case entry.ComputeHash() != entry.Hash:
+			result.Reason = "hash_mismatch"
+		default:
+			result.EntriesChecked++
+			result.HeadHash = entry.Hash
+			return nil
+		}
+
+		id := entry.ID
+		result.Valid = false
+		result.FirstInvalidID = &id
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func isUniqueViolation(err error) bool {
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "unique") || strings.Contains(message, "duplicate")
+}
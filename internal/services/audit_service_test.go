@@ -0,0 +1,91 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestAuditService tests that entries are hash-chained and that changes to the log are detected
+func TestAuditService(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	audit := NewAuditService(db)
+
+	record := func(action, targetID string, after string) *models.AuditEntry {
+		entry := &models.AuditEntry{ActorID: 1, ActorEmail: "admin@example.com", Action: action,
+			TargetType: "setting", TargetID: targetID, After: json.RawMessage(after),
+			IPAddress: "192.0.2.1", RequestID: "req-" + targetID, StatusCode: 200,
+			CreatedAt: time.Now().Add(123 * time.Millisecond)}
+		if err := audit.Record(entry); err != nil {
+			t.Fatalf("Record() failed: %v", err)
+		}
+		return entry
+	}
+
+	verify := func() *models.AuditVerification {
+		result, err := audit.Verify()
+		if err != nil {
+			t.Fatalf("Verify() failed: %v", err)
+		}
+		return result
+	}
+
+	if result := verify(); !result.Valid || result.EntriesChecked != 0 || result.HeadHash != models.AuditGenesisHash {
+		t.Errorf("Expected an empty log to be valid, got %+v", result)
+	}
+
+	first := record("PUT /api/settings/{key}", "booking_advance_days", `{"value":"14"}`)
+	second := record("PUT /api/settings/{key}", "cancellation_notice_hours", `{"value":"24"}`)
+	third := record("DELETE /api/dogs/{id}", "7", `{"name":"Bella"}`)
+
+	if first.PrevHash != models.AuditGenesisHash || second.PrevHash != first.Hash || third.PrevHash != second.Hash {
+		t.Fatal("Expected each entry to be chained to its predecessor")
+	}
+	if first.CreatedAt.Nanosecond() != 0 || first.CreatedAt.Location() != time.UTC {
+		t.Errorf("Expected created_at in UTC with second precision, got %v", first.CreatedAt)
+	}
+
+	// Hashes survive the round trip through the database
+	if result := verify(); !result.Valid || result.EntriesChecked != 3 || result.HeadHash != third.Hash {
+		t.Fatalf("Expected a valid chain of 3 entries, got %+v", result)
+	}
+
+	// Changing an entry breaks its hash
+	if _, err := db.Exec(`UPDATE audit_log SET after_state = ? WHERE id = ?`, `{"value":"99"}`, second.ID); err != nil {
+		t.Fatalf("Failed to tamper with entry: %v", err)
+	}
+	result := verify()
+	if result.Valid || result.Reason != "hash_mismatch" || result.FirstInvalidID == nil || *result.FirstInvalidID != second.ID {
+		t.Errorf("Expected hash_mismatch at entry %d, got %+v", second.ID, result)
+	}
+	if result.EntriesChecked != 1 || result.HeadHash != first.Hash {
+		t.Errorf("Expected the chain to be valid up to the first entry, got %+v", result)
+	}
+
+	// Deleting an entry breaks the link of its successor
+	if _, err := db.Exec(`DELETE FROM audit_log WHERE id = ?`, second.ID); err != nil {
+		t.Fatalf("Failed to delete entry: %v", err)
+	}
+	result = verify()
+	if result.Valid || result.Reason != "chain_broken" || result.FirstInvalidID == nil || *result.FirstInvalidID != third.ID {
+		t.Errorf("Expected chain_broken at entry %d, got %+v", third.ID, result)
+	}
+
+	// New entries are chained to the newest entry
+	fourth := record("POST /api/admin/users/{id}/promote", "5", `{"is_admin":true}`)
+	if fourth.PrevHash != third.Hash {
+		t.Errorf("Expected new entry to follow the newest entry")
+	}
+
+	entries, total, err := repository.NewAuditLogRepository(db).Find(&models.AuditFilter{Action: "/settings", Limit: 10})
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+	if total != 1 || entries[0].ID != first.ID || string(entries[0].After) != `{"value":"14"}` {
+		t.Errorf("Expected the remaining settings entry, got %d entries", total)
+	}
+}
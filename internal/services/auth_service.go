@@ -7,23 +7,29 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/tranmh/gassigeher/internal/models"
 	"golang.org/x/crypto/bcrypt"
 )
 
 // AuthService provides authentication utilities
 type AuthService struct {
 	jwtSecret          string
-	jwtExpirationHours int
+	accessTokenMinutes int // Lifetime of access tokens, renewed with refresh tokens (see TokenService)
 }
 
 // NewAuthService creates a new auth service
-func NewAuthService(jwtSecret string, jwtExpirationHours int) *AuthService {
+func NewAuthService(jwtSecret string, accessTokenMinutes int) *AuthService {
 	return &AuthService{
 		jwtSecret:          jwtSecret,
-		jwtExpirationHours: jwtExpirationHours,
+		accessTokenMinutes: accessTokenMinutes,
 	}
 }
 
+// AccessTokenLifetime returns how long tokens from GenerateJWT are valid
+func (s *AuthService) AccessTokenLifetime() time.Duration {
+	return time.Minute * time.Duration(s.accessTokenMinutes)
+}
+
 // HashPassword hashes a password using bcrypt
 func (s *AuthService) HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 12)
@@ -50,13 +56,28 @@ func (s *AuthService) GenerateToken() (string, error) {
 
 // GenerateJWT generates a JWT token for a user
 // DONE: Phase 3 - Updated to include is_super_admin claim
-func (s *AuthService) GenerateJWT(userID int, email string, isAdmin bool, isSuperAdmin bool) (string, error) {
+// permissions are those of all roles of the user, see RoleRepository.ForUser
+// tokenVersion is the user's current token version, the token is rejected once it was increased.
+func (s *AuthService) GenerateJWT(userID int, email string, isAdmin bool, isSuperAdmin bool, permissions []string, tokenVersion int) (string, error) {
+	return s.GenerateSessionJWT(userID, email, isAdmin, isSuperAdmin, permissions, tokenVersion, 0)
+}
+
+// GenerateSessionJWT generates a JWT token like GenerateJWT for the login with the session ID, see TokenService
+func (s *AuthService) GenerateSessionJWT(userID int, email string, isAdmin bool, isSuperAdmin bool, permissions []string, tokenVersion int, sessionID int) (string, error) {
+	if permissions == nil {
+		permissions = []string{}
+	}
 	claims := jwt.MapClaims{
 		"user_id":        userID,
 		"email":          email,
 		"is_admin":       isAdmin,
 		"is_super_admin": isSuperAdmin,
-		"exp":            time.Now().Add(time.Hour * time.Duration(s.jwtExpirationHours)).Unix(),
+		"permissions":    permissions,
+		"token_version":  tokenVersion,
+		"exp":            time.Now().Add(s.AccessTokenLifetime()).Unix(),
+	}
+	if sessionID > 0 {
+		claims["session_id"] = sessionID
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -90,10 +111,21 @@ func (s *AuthService) ValidateJWT(tokenString string) (*jwt.MapClaims, error) {
 	return nil, fmt.Errorf("invalid token")
 }
 
-// ValidatePassword checks if a password meets requirements
+// ValidatePassword checks the length and character classes every password needs
+// PasswordPolicy.Validate adds the configured length, breach and strength checks.
 func (s *AuthService) ValidatePassword(password string) error {
-	if len(password) < 8 {
-		return fmt.Errorf("password must be at least 8 characters long")
+	return validatePasswordRules(password, 8)
+}
+
+// validatePasswordRules checks the minimum length and that upper and lower case letters and numbers are used
+func validatePasswordRules(password string, minLength int) error {
+	if len(password) < minLength {
+		return &models.ValidationError{
+			Field:   "password",
+			Message: fmt.Sprintf("password must be at least %d characters long", minLength),
+			Code:    "password_too_short",
+			Args:    []interface{}{minLength},
+		}
 	}
 
 	hasUpper := false
@@ -112,13 +144,13 @@ func (s *AuthService) ValidatePassword(password string) error {
 	}
 
 	if !hasUpper {
-		return fmt.Errorf("password must contain at least one uppercase letter")
+		return &models.ValidationError{Field: "password", Message: "password must contain at least one uppercase letter", Code: "password_missing_uppercase"}
 	}
 	if !hasLower {
-		return fmt.Errorf("password must contain at least one lowercase letter")
+		return &models.ValidationError{Field: "password", Message: "password must contain at least one lowercase letter", Code: "password_missing_lowercase"}
 	}
 	if !hasNumber {
-		return fmt.Errorf("password must contain at least one number")
+		return &models.ValidationError{Field: "password", Message: "password must contain at least one number", Code: "password_missing_number"}
 	}
 
 	return nil
@@ -65,7 +65,7 @@ func TestAuthService_GenerateToken(t *testing.T) {
 func TestAuthService_GenerateJWT(t *testing.T) {
 	service := NewAuthService("test-secret", 24)
 
-	tokenString, err := service.GenerateJWT(1, "test@example.com", false, false)
+	tokenString, err := service.GenerateJWT(1, "test@example.com", false, false, nil, 0)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -110,7 +110,7 @@ func TestAuthService_ValidateJWT(t *testing.T) {
 	service := NewAuthService("test-secret", 24)
 
 	// Generate valid token
-	tokenString, _ := service.GenerateJWT(1, "test@example.com", true, false)
+	tokenString, _ := service.GenerateJWT(1, "test@example.com", true, false, nil, 0)
 
 	// Validate token
 	claims, err := service.ValidateJWT(tokenString)
@@ -161,14 +161,14 @@ func TestAuthService_ValidatePassword(t *testing.T) {
 
 // DONE: TestAuthService_JWTExpiration tests token expiration
 func TestAuthService_JWTExpiration(t *testing.T) {
-	// Create service with 0 hour expiration for testing
+	// Create service with 0 minute expiration for testing
 	service := &AuthService{
 		jwtSecret:          "test-secret",
-		jwtExpirationHours: 0,
+		accessTokenMinutes: 0,
 	}
 
 	// Generate token that expires immediately
-	tokenString, _ := service.GenerateJWT(1, "test@example.com", false, false)
+	tokenString, _ := service.GenerateJWT(1, "test@example.com", false, false, nil, 0)
 
 	// Wait a moment
 	time.Sleep(1 * time.Second)
@@ -217,7 +217,7 @@ func TestAuthService_GenerateJWT_AdminClaims(t *testing.T) {
 	service := NewAuthService("test-secret", 24)
 
 	t.Run("admin user", func(t *testing.T) {
-		tokenString, err := service.GenerateJWT(1, "admin@example.com", true, false)
+		tokenString, err := service.GenerateJWT(1, "admin@example.com", true, false, nil, 0)
 		if err != nil {
 			t.Fatalf("GenerateJWT() failed: %v", err)
 		}
@@ -233,7 +233,7 @@ func TestAuthService_GenerateJWT_AdminClaims(t *testing.T) {
 	})
 
 	t.Run("non-admin user", func(t *testing.T) {
-		tokenString, err := service.GenerateJWT(2, "user@example.com", false, false)
+		tokenString, err := service.GenerateJWT(2, "user@example.com", false, false, nil, 0)
 		if err != nil {
 			t.Fatalf("GenerateJWT() failed: %v", err)
 		}
@@ -282,7 +282,7 @@ func TestAuthService_ValidateJWT_WrongSecret(t *testing.T) {
 	service2 := NewAuthService("secret-2", 24)
 
 	// Generate token with service1
-	tokenString, _ := service1.GenerateJWT(1, "test@example.com", false, false)
+	tokenString, _ := service1.GenerateJWT(1, "test@example.com", false, false, nil, 0)
 
 	// Try to validate with service2 (different secret)
 	claims, err := service2.ValidateJWT(tokenString)
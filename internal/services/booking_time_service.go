@@ -32,13 +32,13 @@ func (s *BookingTimeService) ValidateBookingTime(date string, scheduledTime stri
 	// Parse date
 	dateObj, err := time.Parse("2006-01-02", date)
 	if err != nil {
-		return fmt.Errorf("invalid date format")
+		return &models.ValidationError{Field: "date", Message: "invalid date format", Code: "invalid_date_format"}
 	}
 
 	// Parse time
 	timeObj, err := time.Parse("15:04", scheduledTime)
 	if err != nil {
-		return fmt.Errorf("invalid time format")
+		return &models.ValidationError{Field: "scheduled_time", Message: "invalid time format", Code: "invalid_time_format"}
 	}
 
 	// Determine day type
@@ -65,7 +65,12 @@ func (s *BookingTimeService) ValidateBookingTime(date string, scheduledTime stri
 		if !timeObj.Before(startTime) && timeObj.Before(endTime) {
 			if rule.IsBlocked {
 				inBlockedWindow = true
-				return fmt.Errorf("Zeit ist gesperrt: %s (%s-%s)", rule.RuleName, rule.StartTime, rule.EndTime)
+				return &models.ValidationError{
+					Field:   "scheduled_time",
+					Message: fmt.Sprintf("Zeit ist gesperrt: %s (%s-%s)", rule.RuleName, rule.StartTime, rule.EndTime),
+					Code:    "time_blocked",
+					Args:    []interface{}{rule.RuleName, rule.StartTime, rule.EndTime},
+				}
 			} else {
 				inAllowedWindow = true
 			}
@@ -73,11 +78,11 @@ func (s *BookingTimeService) ValidateBookingTime(date string, scheduledTime stri
 	}
 
 	if !inAllowedWindow {
-		return fmt.Errorf("Zeit ist außerhalb der erlaubten Buchungszeiten")
+		return &models.ValidationError{Field: "scheduled_time", Message: "Zeit ist außerhalb der erlaubten Buchungszeiten", Code: "time_outside_booking_hours"}
 	}
 
 	if inBlockedWindow {
-		return fmt.Errorf("Zeit fällt in eine Sperrzeit")
+		return &models.ValidationError{Field: "scheduled_time", Message: "Zeit fällt in eine Sperrzeit", Code: "time_in_blocked_period"}
 	}
 
 	return nil
@@ -88,7 +93,7 @@ func (s *BookingTimeService) GetAvailableTimeSlots(date string) ([]string, error
 	// Parse date
 	dateObj, err := time.Parse("2006-01-02", date)
 	if err != nil {
-		return nil, fmt.Errorf("invalid date format")
+		return nil, &models.ValidationError{Field: "date", Message: "invalid date format", Code: "invalid_date_format"}
 	}
 
 	// Determine day type
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// mimeMessage is an email that is encoded as multipart/alternative with
+// a plain-text part derived from the HTML body
+type mimeMessage struct {
+	From     string
+	To       string
+	Bcc      string // Optional
+	Subject  string
+	HTMLBody string
+	Headers  map[string]string // Additional headers (e.g. List-Unsubscribe)
+}
+
+// Bytes encodes the message in RFC 5322 / MIME format
+func (m *mimeMessage) Bytes() []byte {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	writePart(writer, "text/plain; charset=UTF-8", htmlToPlainText(m.HTMLBody))
+	writePart(writer, "text/html; charset=UTF-8", m.HTMLBody)
+	writer.Close()
+
+	var msg strings.Builder
+
+	// Headers in a fixed order
+	msg.WriteString(fmt.Sprintf("From: %s\r\n", formatAddress(m.From)))
+	msg.WriteString(fmt.Sprintf("To: %s\r\n", formatAddress(m.To)))
+	if m.Bcc != "" {
+		// For audit trail, recipient won't see it
+		msg.WriteString(fmt.Sprintf("Bcc: %s\r\n", formatAddress(m.Bcc)))
+	}
+	msg.WriteString(fmt.Sprintf("Subject: %s\r\n", encodeRFC2047(m.Subject)))
+	names := make([]string, 0, len(m.Headers))
+	for name := range m.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		// Strip line breaks to prevent header injection
+		value := strings.NewReplacer("\r", "", "\n", "").Replace(m.Headers[name])
+		msg.WriteString(fmt.Sprintf("%s: %s\r\n", textproto.CanonicalMIMEHeaderKey(name), value))
+	}
+	msg.WriteString(fmt.Sprintf("Date: %s\r\n", time.Now().Format(time.RFC1123Z)))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString(fmt.Sprintf("Content-Type: multipart/alternative; boundary=\"%s\"\r\n", writer.Boundary()))
+
+	// Blank line between headers and body
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+
+	return []byte(msg.String())
+}
+
+// writePart adds a quoted-printable encoded part to a multipart message
+// Writes to a bytes.Buffer cannot fail, so errors are ignored
+func writePart(writer *multipart.Writer, contentType, content string) {
+	part, _ := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	part.Write([]byte(encodeQuotedPrintable(content)))
+}
+
+// formatAddress formats an email address for a header, falling back to the raw value
+func formatAddress(address string) string {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return (&mail.Address{Address: address}).String()
+	}
+	return parsed.String()
+}
+
+var (
+	plainTextSpaces     = regexp.MustCompile(`[ \t]+`)
+	plainTextBlankLines = regexp.MustCompile(`\n{3,}`)
+)
+
+// htmlToPlainText derives a readable plain-text version of an HTML email
+// Styles and scripts are dropped, block elements become line breaks,
+// list items become "- " lines and links keep their target in brackets
+func htmlToPlainText(htmlBody string) string {
+	tokenizer := html.NewTokenizer(strings.NewReader(htmlBody))
+
+	var out strings.Builder
+	skipDepth := 0 // > 0 while inside <head>, <style>, <script> or <title>
+	var linkHref string
+	var linkText strings.Builder
+
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+
+		token := tokenizer.Token()
+		switch tokenType {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			switch token.Data {
+			case "head", "style", "script", "title":
+				if tokenType == html.StartTagToken {
+					skipDepth++
+				}
+			case "br", "p", "div", "h1", "h2", "h3", "h4", "h5", "h6", "ul", "ol", "table", "tr":
+				out.WriteString("\n")
+			case "li":
+				out.WriteString("\n- ")
+			case "hr":
+				out.WriteString("\n----------\n")
+			case "a":
+				linkHref = ""
+				for _, attr := range token.Attr {
+					if attr.Key == "href" {
+						linkHref = strings.TrimSpace(attr.Val)
+					}
+				}
+				linkText.Reset()
+			}
+
+		case html.EndTagToken:
+			switch token.Data {
+			case "head", "style", "script", "title":
+				if skipDepth > 0 {
+					skipDepth--
+				}
+			case "p", "h1", "h2", "h3", "h4", "h5", "h6", "ul", "ol", "table":
+				// Blank line after paragraphs; <div> rows stay on consecutive lines
+				out.WriteString("\n")
+			case "td", "th":
+				out.WriteString(" ")
+			case "a":
+				text := strings.TrimSpace(linkText.String())
+				if linkHref != "" && linkHref != text && !strings.HasPrefix(linkHref, "#") {
+					out.WriteString(" (" + linkHref + ")")
+				}
+				linkHref = ""
+			}
+
+		case html.TextToken:
+			if skipDepth > 0 {
+				continue
+			}
+			text := plainTextSpaces.ReplaceAllString(strings.ReplaceAll(token.Data, "\n", " "), " ")
+			out.WriteString(text)
+			if linkHref != "" {
+				linkText.WriteString(text)
+			}
+		}
+	}
+
+	// Trim every line and collapse runs of blank lines
+	lines := strings.Split(out.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	text := plainTextBlankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+
+	return strings.TrimSpace(text) + "\n"
+}
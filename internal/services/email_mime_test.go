@@ -0,0 +1,269 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+// parsedEmail holds the decoded parts of a multipart/alternative message
+type parsedEmail struct {
+	header mail.Header
+	parts  map[string]string // media type -> decoded content
+	order  []string
+}
+
+// parseMultipartEmail parses a raw message like a mail client would
+func parseMultipartEmail(t *testing.T, raw []byte) *parsedEmail {
+	t.Helper()
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("Failed to parse message: %v", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("Invalid Content-Type: %v", err)
+	}
+	if mediaType != "multipart/alternative" {
+		t.Fatalf("Expected multipart/alternative, got %s", mediaType)
+	}
+
+	parsed := &parsedEmail{header: msg.Header, parts: map[string]string{}}
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read part: %v", err)
+		}
+
+		partType, partParams, err := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		if err != nil {
+			t.Fatalf("Invalid part Content-Type: %v", err)
+		}
+		if partParams["charset"] != "UTF-8" {
+			t.Errorf("Part %s should be UTF-8, got %q", partType, partParams["charset"])
+		}
+		if encoding := part.Header.Get("Content-Transfer-Encoding"); encoding != "quoted-printable" {
+			t.Errorf("Part %s should be quoted-printable, got %q", partType, encoding)
+		}
+
+		content, err := io.ReadAll(quotedprintable.NewReader(part))
+		if err != nil {
+			t.Fatalf("Failed to decode part %s: %v", partType, err)
+		}
+
+		parsed.parts[partType] = string(content)
+		parsed.order = append(parsed.order, partType)
+	}
+
+	return parsed
+}
+
+// TestMimeMessage_Structure tests the multipart/alternative layout
+func TestMimeMessage_Structure(t *testing.T) {
+	htmlBody := "<html><body><h1>Schöne Grüße</h1><p>Hallo Anna,</p><p>Äpfel und Öl</p></body></html>"
+	message := &mimeMessage{
+		From:     "Gassigeher <sender@example.com>",
+		To:       "recipient@example.com",
+		Bcc:      "admin@example.com",
+		Subject:  "Schöne Grüße",
+		HTMLBody: htmlBody,
+	}
+
+	parsed := parseMultipartEmail(t, message.Bytes())
+
+	t.Run("headers", func(t *testing.T) {
+		decoder := new(mime.WordDecoder)
+		subject, err := decoder.DecodeHeader(parsed.header.Get("Subject"))
+		if err != nil || subject != "Schöne Grüße" {
+			t.Errorf("Expected decoded subject, got %q (%v)", subject, err)
+		}
+		if parsed.header.Get("MIME-Version") != "1.0" {
+			t.Error("Missing MIME-Version header")
+		}
+		if !strings.Contains(parsed.header.Get("From"), "sender@example.com") {
+			t.Errorf("Unexpected From header: %s", parsed.header.Get("From"))
+		}
+		if parsed.header.Get("Bcc") != "<admin@example.com>" {
+			t.Errorf("Unexpected Bcc header: %s", parsed.header.Get("Bcc"))
+		}
+		if _, err := parsed.header.Date(); err != nil {
+			t.Errorf("Invalid Date header: %v", err)
+		}
+	})
+
+	t.Run("plain text part comes first", func(t *testing.T) {
+		// Clients display the last part they support, so HTML must be last
+		if len(parsed.order) != 2 || parsed.order[0] != "text/plain" || parsed.order[1] != "text/html" {
+			t.Errorf("Expected [text/plain text/html], got %v", parsed.order)
+		}
+	})
+
+	t.Run("html part is unchanged", func(t *testing.T) {
+		if parsed.parts["text/html"] != htmlBody {
+			t.Errorf("HTML part was modified: %q", parsed.parts["text/html"])
+		}
+	})
+
+	t.Run("plain text part has no markup", func(t *testing.T) {
+		text := parsed.parts["text/plain"]
+		if strings.Contains(text, "<") {
+			t.Errorf("Plain text should not contain tags: %q", text)
+		}
+		for _, want := range []string{"Schöne Grüße", "Hallo Anna,", "Äpfel und Öl"} {
+			if !strings.Contains(text, want) {
+				t.Errorf("Plain text should contain %q, got %q", want, text)
+			}
+		}
+	})
+}
+
+// TestMimeMessage_WithoutBcc tests that no Bcc header is written when not configured
+func TestMimeMessage_WithoutBcc(t *testing.T) {
+	message := &mimeMessage{From: "sender@example.com", To: "recipient@example.com", Subject: "Test", HTMLBody: "<p>Test</p>"}
+
+	parsed := parseMultipartEmail(t, message.Bytes())
+	if _, ok := parsed.header["Bcc"]; ok {
+		t.Error("Bcc header should not be set")
+	}
+}
+
+// TestMimeMessage_RenderedTemplates tests all default templates produce readable plain text
+func TestMimeMessage_RenderedTemplates(t *testing.T) {
+	store := NewEmailTemplateStore(nil, "https://gassi.example.com")
+
+	for _, def := range store.Definitions() {
+		t.Run(def.Key, func(t *testing.T) {
+			subject, body, err := store.Render(def.Key, "de", def.SampleData)
+			if err != nil {
+				t.Fatalf("Render() failed: %v", err)
+			}
+
+			message := &mimeMessage{From: "sender@example.com", To: "recipient@example.com", Subject: subject, HTMLBody: body}
+			parsed := parseMultipartEmail(t, message.Bytes())
+			text := parsed.parts["text/plain"]
+
+			if strings.Contains(text, "<") || strings.Contains(text, "font-family") {
+				t.Errorf("Plain text contains markup or styles: %q", text)
+			}
+			// Invitations go to people without an account, they have no name yet
+			if name, ok := def.SampleData["Name"].(string); ok && !strings.Contains(text, name) {
+				t.Errorf("Plain text should contain the recipient name: %q", text)
+			}
+			if parsed.parts["text/html"] != body {
+				t.Error("HTML part should match the rendered body")
+			}
+		})
+	}
+}
+
+// TestHTMLToPlainText tests deriving plain text from HTML bodies
+func TestHTMLToPlainText(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "drops head and styles",
+			html:     "<html><head><title>Titel</title><style>body { color: red; }</style></head><body><p>Inhalt</p></body></html>",
+			contains: []string{"Inhalt"},
+			excludes: []string{"Titel", "color", "body {"},
+		},
+		{
+			name:     "keeps link targets",
+			html:     `<p>Bitte <a href="https://example.com/verify?token=abc">bestätigen</a>.</p>`,
+			contains: []string{"bestätigen (https://example.com/verify?token=abc)"},
+		},
+		{
+			name:     "does not repeat link text that is the URL",
+			html:     `<a href="https://example.com">https://example.com</a>`,
+			contains: []string{"https://example.com"},
+			excludes: []string{"(https://example.com)"},
+		},
+		{
+			name:     "list items",
+			html:     "<ul><li>Hunde buchen</li><li>Profil bearbeiten</li></ul>",
+			contains: []string{"- Hunde buchen\n- Profil bearbeiten"},
+		},
+		{
+			name:     "line breaks",
+			html:     "<p>Zeile 1<br>Zeile 2</p><p>Absatz</p>",
+			contains: []string{"Zeile 1\nZeile 2", "Zeile 2\n\nAbsatz"},
+		},
+		{
+			name:     "decodes entities",
+			html:     "<p>Tom &amp; Jerry &lt;3 &uuml;</p>",
+			contains: []string{"Tom & Jerry <3 ü"},
+		},
+		{
+			name:     "collapses whitespace",
+			html:     "<div>\n    <span>Hund:</span>     Bella\n</div>\n\n\n\n<div>Ende</div>",
+			contains: []string{"Hund: Bella\nEnde"},
+			excludes: []string{"\n\n\n", "  "},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := htmlToPlainText(tt.html)
+
+			for _, want := range tt.contains {
+				if !strings.Contains(result, want) {
+					t.Errorf("Expected %q in result %q", want, result)
+				}
+			}
+			for _, unwanted := range tt.excludes {
+				if strings.Contains(result, unwanted) {
+					t.Errorf("Did not expect %q in result %q", unwanted, result)
+				}
+			}
+		})
+	}
+}
+
+// TestGmailProvider_BuildRawMessage tests the Gmail provider sends the same multipart message
+func TestGmailProvider_BuildRawMessage(t *testing.T) {
+	provider := &GmailProvider{fromEmail: "sender@example.com", bccAdmin: "admin@example.com"}
+
+	raw, err := base64.URLEncoding.DecodeString(provider.buildRawMessage("recipient@example.com", "Test", "<p>Hallo <b>Anna</b></p>", nil))
+	if err != nil {
+		t.Fatalf("Raw message is not base64url encoded: %v", err)
+	}
+
+	parsed := parseMultipartEmail(t, raw)
+	if parsed.parts["text/html"] != "<p>Hallo <b>Anna</b></p>" {
+		t.Errorf("Unexpected HTML part: %q", parsed.parts["text/html"])
+	}
+	if strings.TrimSpace(parsed.parts["text/plain"]) != "Hallo Anna" {
+		t.Errorf("Unexpected plain text part: %q", parsed.parts["text/plain"])
+	}
+	if parsed.header.Get("Bcc") != "<admin@example.com>" {
+		t.Errorf("Expected Bcc header, got %q", parsed.header.Get("Bcc"))
+	}
+}
+
+// TestSMTPProvider_BuildMIMEMessage_Multipart tests the SMTP provider message parses as multipart/alternative
+func TestSMTPProvider_BuildMIMEMessage_Multipart(t *testing.T) {
+	provider := &SMTPProvider{fromEmail: "sender@example.com"}
+
+	parsed := parseMultipartEmail(t, provider.buildMIMEMessage("recipient@example.com", "Test", "<p>Hallo</p>", nil))
+	if strings.TrimSpace(parsed.parts["text/plain"]) != "Hallo" {
+		t.Errorf("Unexpected plain text part: %q", parsed.parts["text/plain"])
+	}
+	if parsed.parts["text/html"] != "<p>Hallo</p>" {
+		t.Errorf("Unexpected HTML part: %q", parsed.parts["text/html"])
+	}
+}
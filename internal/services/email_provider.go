@@ -7,6 +7,10 @@ type EmailProvider interface {
 	// Automatically includes BCC if configured in the provider
 	SendEmail(to, subject, body string) error
 
+	// SendEmailWithHeaders sends an email with HTML body and additional
+	// headers (e.g. List-Unsubscribe)
+	SendEmailWithHeaders(to, subject, body string, headers map[string]string) error
+
 	// ValidateConfig validates the provider configuration
 	ValidateConfig() error
 
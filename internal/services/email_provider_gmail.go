@@ -68,23 +68,12 @@ func NewGmailProvider(config *EmailConfig) (EmailProvider, error) {
 
 // SendEmail sends an email via Gmail API
 func (p *GmailProvider) SendEmail(to, subject, body string) error {
-	var message gmail.Message
-
-	// Build email content with optional BCC
-	emailContent := fmt.Sprintf("From: %s\r\n"+
-		"To: %s\r\n", p.fromEmail, to)
-
-	// Add BCC header if configured
-	if p.bccAdmin != "" {
-		emailContent += fmt.Sprintf("Bcc: %s\r\n", p.bccAdmin)
-	}
-
-	emailContent += fmt.Sprintf("Subject: %s\r\n"+
-		"Content-Type: text/html; charset=UTF-8\r\n\r\n"+
-		"%s", subject, body)
+	return p.SendEmailWithHeaders(to, subject, body, nil)
+}
 
-	// Encode message
-	message.Raw = base64.URLEncoding.EncodeToString([]byte(emailContent))
+// SendEmailWithHeaders sends an email with additional headers via Gmail API
+func (p *GmailProvider) SendEmailWithHeaders(to, subject, body string, headers map[string]string) error {
+	message := gmail.Message{Raw: p.buildRawMessage(to, subject, body, headers)}
 
 	// Send via Gmail API
 	_, err := p.service.Users.Messages.Send("me", &message).Do()
@@ -102,6 +91,20 @@ func (p *GmailProvider) SendEmail(to, subject, body string) error {
 	return nil
 }
 
+// buildRawMessage builds the multipart/alternative message (HTML + derived
+// plain text, optional BCC) base64url-encoded as expected by the Gmail API
+func (p *GmailProvider) buildRawMessage(to, subject, body string, headers map[string]string) string {
+	message := &mimeMessage{
+		From:     p.fromEmail,
+		To:       to,
+		Bcc:      p.bccAdmin,
+		Subject:  subject,
+		HTMLBody: body,
+		Headers:  headers,
+	}
+	return base64.URLEncoding.EncodeToString(message.Bytes())
+}
+
 // ValidateConfig validates the Gmail provider configuration
 func (p *GmailProvider) ValidateConfig() error {
 	if p.service == nil {
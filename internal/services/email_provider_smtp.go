@@ -49,6 +49,11 @@ func NewSMTPProvider(config *EmailConfig) (EmailProvider, error) {
 
 // SendEmail sends an email via SMTP
 func (p *SMTPProvider) SendEmail(to, subject, body string) error {
+	return p.SendEmailWithHeaders(to, subject, body, nil)
+}
+
+// SendEmailWithHeaders sends an email with additional headers via SMTP
+func (p *SMTPProvider) SendEmailWithHeaders(to, subject, body string, headers map[string]string) error {
 	// Validate recipient email
 	if _, err := mail.ParseAddress(to); err != nil {
 		return fmt.Errorf("invalid recipient email address: %v", err)
@@ -61,7 +66,7 @@ func (p *SMTPProvider) SendEmail(to, subject, body string) error {
 	}
 
 	// Create MIME message with proper headers
-	message := p.buildMIMEMessage(to, subject, body)
+	message := p.buildMIMEMessage(to, subject, body, headers)
 
 	// Send email based on SSL/TLS configuration
 	if p.useSSL {
@@ -228,55 +233,18 @@ func (p *SMTPProvider) sendWithSTARTTLS(addr string, auth smtp.Auth, recipients
 	return client.Quit()
 }
 
-// buildMIMEMessage creates a properly formatted MIME email message
-func (p *SMTPProvider) buildMIMEMessage(to, subject, htmlBody string) []byte {
-	// Parse from address to get proper format
-	fromAddr, err := mail.ParseAddress(p.fromEmail)
-	if err != nil {
-		// Fallback to simple format
-		fromAddr = &mail.Address{Address: p.fromEmail}
-	}
-
-	// Parse to address
-	toAddr, err := mail.ParseAddress(to)
-	if err != nil {
-		toAddr = &mail.Address{Address: to}
-	}
-
-	// Build headers
-	headers := make(map[string]string)
-	headers["From"] = fromAddr.String()
-	headers["To"] = toAddr.String()
-	headers["Subject"] = encodeRFC2047(subject)
-	headers["MIME-Version"] = "1.0"
-	headers["Content-Type"] = "text/html; charset=UTF-8"
-	headers["Content-Transfer-Encoding"] = "quoted-printable"
-	headers["Date"] = time.Now().Format(time.RFC1123Z)
-
-	// Add BCC header if configured (for audit trail, recipient won't see it)
-	if p.bccAdmin != "" {
-		bccAddr, err := mail.ParseAddress(p.bccAdmin)
-		if err != nil {
-			bccAddr = &mail.Address{Address: p.bccAdmin}
-		}
-		headers["Bcc"] = bccAddr.String()
-	}
-
-	// Build message
-	var msg strings.Builder
-
-	// Write headers
-	for key, value := range headers {
-		msg.WriteString(fmt.Sprintf("%s: %s\r\n", key, value))
-	}
-
-	// Blank line between headers and body
-	msg.WriteString("\r\n")
-
-	// Write body (quoted-printable encoded for UTF-8 support)
-	msg.WriteString(encodeQuotedPrintable(htmlBody))
-
-	return []byte(msg.String())
+// buildMIMEMessage creates a multipart/alternative MIME message with
+// a plain-text part derived from the HTML body
+func (p *SMTPProvider) buildMIMEMessage(to, subject, htmlBody string, headers map[string]string) []byte {
+	message := &mimeMessage{
+		From:     p.fromEmail,
+		To:       to,
+		Bcc:      p.bccAdmin,
+		Subject:  subject,
+		HTMLBody: htmlBody,
+		Headers:  headers,
+	}
+	return message.Bytes()
 }
 
 // encodeRFC2047 encodes a string using RFC 2047 for email headers (supports UTF-8)
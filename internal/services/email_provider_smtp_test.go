@@ -328,7 +328,7 @@ func TestBuildMIMEMessage(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			message := tt.provider.buildMIMEMessage(tt.to, tt.subject, tt.body)
+			message := tt.provider.buildMIMEMessage(tt.to, tt.subject, tt.body, nil)
 			messageStr := string(message)
 
 			// Check for required headers
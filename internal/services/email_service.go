@@ -1,16 +1,25 @@
 package services
 
 import (
-	"bytes"
+	"database/sql"
 	"fmt"
-	"html/template"
 	"log"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/i18n"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
 )
 
 // EmailService handles sending emails via any email provider
 type EmailService struct {
-	provider EmailProvider
-	baseURL  string // Base URL for email links
+	provider    EmailProvider
+	baseURL     string                                       // Base URL for email links
+	templates   *EmailTemplateStore                          // Subject/body templates (embedded defaults + admin overrides)
+	userRepo    *repository.UserRepository                   // Used to look up the recipient (nil = default language, no preferences)
+	preferences *repository.NotificationPreferenceRepository // Notification opt-outs (nil = send everything)
+	unsubscribe *UnsubscribeTokens                           // Signs unsubscribe links (nil = no unsubscribe links)
 }
 
 // NewEmailService creates a new email service with the specified provider
@@ -47,11 +56,31 @@ func NewEmailService(config *EmailConfig) (*EmailService, error) {
 	}
 
 	return &EmailService{
-		provider: provider,
-		baseURL:  baseURL,
+		provider:  provider,
+		baseURL:   baseURL,
+		templates: NewEmailTemplateStore(nil, baseURL),
 	}, nil
 }
 
+// NewEmailServiceFromConfig creates an email service from the application config,
+// enables admin template overrides stored in the database, sends emails
+// in the recipient's preferred language and respects notification preferences
+func NewEmailServiceFromConfig(db *sql.DB, cfg *config.Config) (*EmailService, error) {
+	service, err := NewEmailService(ConfigToEmailConfig(cfg))
+	if err != nil {
+		return nil, err
+	}
+
+	if db != nil {
+		service.SetTemplateStore(NewEmailTemplateStore(repository.NewEmailTemplateRepository(db), service.baseURL))
+		service.userRepo = repository.NewUserRepository(db)
+		service.preferences = repository.NewNotificationPreferenceRepository(db)
+		service.unsubscribe = NewUnsubscribeTokens(cfg.JWTSecret)
+	}
+
+	return service, nil
+}
+
 // NewEmailServiceLegacy creates email service using legacy Gmail API parameters (backward compatibility)
 // DEPRECATED: Use NewEmailService(config) instead
 func NewEmailServiceLegacy(clientID, clientSecret, refreshToken, fromEmail string) (*EmailService, error) {
@@ -65,555 +94,189 @@ func NewEmailServiceLegacy(clientID, clientSecret, refreshToken, fromEmail strin
 	return NewEmailService(config)
 }
 
+// SetTemplateStore replaces the template store used to render emails
+func (s *EmailService) SetTemplateStore(store *EmailTemplateStore) {
+	s.templates = store
+}
+
 // SendEmail sends an email using the configured provider
 func (s *EmailService) SendEmail(to, subject, body string) error {
 	return s.provider.SendEmail(to, subject, body)
 }
 
-// SendVerificationEmail sends an email verification link
-func (s *EmailService) SendVerificationEmail(to, name, token string) error {
-	subject := "Willkommen bei Gassigeher - E-Mail-Adresse bestätigen"
-
-	tmpl := `
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <style>
-        body { font-family: Titillium, Arial, sans-serif; line-height: 1.6; color: #26272b; }
-        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
-        .header { background-color: #82b965; color: white; padding: 20px; text-align: center; border-radius: 6px 6px 0 0; }
-        .content { background-color: #f9f9f9; padding: 30px; border-radius: 0 0 6px 6px; }
-        .button { display: inline-block; padding: 12px 30px; background-color: #82b965; color: white; text-decoration: none; border-radius: 6px; margin: 20px 0; }
-        .footer { text-align: center; margin-top: 20px; color: #666; font-size: 12px; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>🐕 Willkommen bei Gassigeher</h1>
-        </div>
-        <div class="content">
-            <p>Hallo {{.Name}},</p>
-            <p>vielen Dank für Ihre Registrierung bei Gassigeher! Bitte bestätigen Sie Ihre E-Mail-Adresse, um Ihr Konto zu aktivieren.</p>
-            <p style="text-align: center;">
-                <a href="{{.BaseURL}}/verify?token={{.Token}}" class="button">E-Mail-Adresse bestätigen</a>
-            </p>
-            <p>Oder kopieren Sie diesen Link in Ihren Browser:</p>
-            <p style="word-break: break-all; font-size: 12px; color: #666;">
-                {{.BaseURL}}/verify?token={{.Token}}
-            </p>
-            <p>Dieser Link ist 24 Stunden gültig.</p>
-            <p>Wenn Sie sich nicht bei Gassigeher registriert haben, können Sie diese E-Mail ignorieren.</p>
-        </div>
-        <div class="footer">
-            <p>© 2025 Gassigeher. Alle Rechte vorbehalten.</p>
-        </div>
-    </div>
-</body>
-</html>
-`
-
-	t := template.Must(template.New("verification").Parse(tmpl))
-	var body bytes.Buffer
-	if err := t.Execute(&body, map[string]string{
-		"Name":    name,
-		"Token":   token,
-		"BaseURL": s.baseURL,
-	}); err != nil {
-		return fmt.Errorf("failed to execute template: %w", err)
+// emailRecipient is the address an email is sent to and the matching user, if registered
+type emailRecipient struct {
+	email string
+	user  *models.User // nil for unknown addresses
+	lang  string
+}
+
+// lookupRecipient finds the user with the given email to determine
+// language and notification preferences
+func (s *EmailService) lookupRecipient(to string) *emailRecipient {
+	recipient := &emailRecipient{email: to, lang: i18n.DefaultLanguage}
+	if s.userRepo == nil {
+		return recipient
+	}
+
+	user, err := s.userRepo.FindByEmail(to)
+	if err != nil || user == nil {
+		return recipient
 	}
 
-	return s.SendEmail(to, subject, body.String())
+	recipient.user = user
+	recipient.lang = i18n.Normalize(user.PreferredLanguage)
+	return recipient
 }
 
-// SendWelcomeEmail sends a welcome email after verification
-func (s *EmailService) SendWelcomeEmail(to, name string) error {
-	subject := "Los geht's! Ihr Konto ist aktiviert"
-
-	tmpl := `
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <style>
-        body { font-family: Titillium, Arial, sans-serif; line-height: 1.6; color: #26272b; }
-        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
-        .header { background-color: #82b965; color: white; padding: 20px; text-align: center; border-radius: 6px 6px 0 0; }
-        .content { background-color: #f9f9f9; padding: 30px; border-radius: 0 0 6px 6px; }
-        .feature { margin: 15px 0; padding: 15px; background-color: white; border-left: 4px solid #82b965; }
-        .footer { text-align: center; margin-top: 20px; color: #666; font-size: 12px; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>🎉 Willkommen bei Gassigeher!</h1>
-        </div>
-        <div class="content">
-            <p>Hallo {{.Name}},</p>
-            <p>Ihr Konto ist jetzt aktiviert! Sie können sofort mit dem Buchen von Hunden beginnen.</p>
-
-            <h3>So funktioniert's:</h3>
-
-            <div class="feature">
-                <strong>🐶 Hunde durchsuchen</strong><br>
-                Sehen Sie sich alle verfügbaren Hunde an und filtern Sie nach Größe, Rasse und Erfahrungslevel.
-            </div>
-
-            <div class="feature">
-                <strong>📅 Termine buchen</strong><br>
-                Wählen Sie einen Hund und einen Zeitpunkt für Ihren Spaziergang. Sie können die vorgeschlagenen Zeiten anpassen.
-            </div>
-
-            <div class="feature">
-                <strong>⭐ Erfahrungslevel</strong><br>
-                Sie starten als "Grün" (Anfänger). Sie können höhere Levels beantragen, um Zugang zu anspruchsvolleren Hunden zu erhalten:
-                <ul>
-                    <li><strong>Grün:</strong> Alle Anfänger (Standard)</li>
-                    <li><strong>Blau:</strong> Erfahrene Gassigeher</li>
-                    <li><strong>Orange:</strong> Nur erfahrene Gassigeher</li>
-                </ul>
-            </div>
-
-            <p>Bei Fragen oder Problemen wenden Sie sich bitte an unseren Support.</p>
-
-            <p style="text-align: center; margin-top: 30px;">
-                <a href="{{.BaseURL}}" style="display: inline-block; padding: 12px 30px; background-color: #82b965; color: white; text-decoration: none; border-radius: 6px;">Zur Anwendung</a>
-            </p>
-        </div>
-        <div class="footer">
-            <p>© 2025 Gassigeher. Alle Rechte vorbehalten.</p>
-        </div>
-    </div>
-</body>
-</html>
-`
-
-	t := template.Must(template.New("welcome").Parse(tmpl))
-	var body bytes.Buffer
-	if err := t.Execute(&body, map[string]string{
-		"Name":    name,
-		"BaseURL": s.baseURL,
-	}); err != nil {
-		return fmt.Errorf("failed to execute template: %w", err)
+// sendTemplate renders the template for key with data in the recipient's language and sends it
+func (s *EmailService) sendTemplate(to, key string, data map[string]interface{}) error {
+	return s.sendToRecipient(s.lookupRecipient(to), key, data)
+}
+
+// sendToRecipient checks the recipient's notification preferences, renders
+// the template for key and sends it with unsubscribe headers for optional categories
+func (s *EmailService) sendToRecipient(recipient *emailRecipient, key string, data map[string]interface{}) error {
+	def := s.templates.Definition(key)
+	if def == nil {
+		return fmt.Errorf("failed to render email template %s: %w", key, ErrUnknownEmailTemplate)
+	}
+
+	var headers map[string]string
+	if recipient.user != nil && !models.IsMandatoryNotificationCategory(def.Category) {
+		if s.preferences != nil {
+			enabled, err := s.preferences.IsEnabled(recipient.user.ID, def.Category, models.NotificationChannelEmail)
+			if err != nil {
+				log.Printf("Failed to check notification preferences for user %d, sending anyway: %v", recipient.user.ID, err)
+			} else if !enabled {
+				log.Printf("Skipping %s email to user %d: %s notifications disabled", key, recipient.user.ID, def.Category)
+				return nil
+			}
+		}
+
+		if s.unsubscribe != nil {
+			token := s.unsubscribe.Generate(recipient.user.ID, def.Category)
+			data["UnsubscribeURL"] = s.baseURL + "/unsubscribe?token=" + token
+			// RFC 8058 one-click unsubscribe
+			headers = map[string]string{
+				"List-Unsubscribe":      "<" + s.baseURL + "/api/unsubscribe?token=" + token + ">",
+				"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+			}
+		}
+	}
+
+	subject, body, err := s.templates.Render(key, recipient.lang, data)
+	if err != nil {
+		return fmt.Errorf("failed to render email template %s: %w", key, err)
+	}
+
+	if headers != nil {
+		return s.provider.SendEmailWithHeaders(recipient.email, subject, body, headers)
 	}
+	return s.SendEmail(recipient.email, subject, body)
+}
+
+// optionalMessage returns the message text or an empty string
+func optionalMessage(message *string) string {
+	if message != nil {
+		return *message
+	}
+	return ""
+}
+
+// SendVerificationEmail sends an email verification link
+func (s *EmailService) SendVerificationEmail(to, name, token string) error {
+	return s.sendTemplate(to, "verification", map[string]interface{}{
+		"Name":  name,
+		"Token": token,
+	})
+}
 
-	return s.SendEmail(to, subject, body.String())
+// SendWelcomeEmail sends a welcome email after verification
+func (s *EmailService) SendWelcomeEmail(to, name string) error {
+	return s.sendTemplate(to, "welcome", map[string]interface{}{
+		"Name": name,
+	})
 }
 
 // SendPasswordResetEmail sends a password reset link
 func (s *EmailService) SendPasswordResetEmail(to, name, token string) error {
-	subject := "Passwort zurücksetzen - Gassigeher"
-
-	tmpl := `
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <style>
-        body { font-family: Titillium, Arial, sans-serif; line-height: 1.6; color: #26272b; }
-        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
-        .header { background-color: #82b965; color: white; padding: 20px; text-align: center; border-radius: 6px 6px 0 0; }
-        .content { background-color: #f9f9f9; padding: 30px; border-radius: 0 0 6px 6px; }
-        .button { display: inline-block; padding: 12px 30px; background-color: #82b965; color: white; text-decoration: none; border-radius: 6px; margin: 20px 0; }
-        .warning { background-color: #fff3cd; border-left: 4px solid #ffc107; padding: 15px; margin: 20px 0; }
-        .footer { text-align: center; margin-top: 20px; color: #666; font-size: 12px; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>🔑 Passwort zurücksetzen</h1>
-        </div>
-        <div class="content">
-            <p>Hallo {{.Name}},</p>
-            <p>Sie haben eine Anfrage zum Zurücksetzen Ihres Passworts gestellt. Klicken Sie auf den Button unten, um ein neues Passwort festzulegen.</p>
-            <p style="text-align: center;">
-                <a href="{{.BaseURL}}/reset-password?token={{.Token}}" class="button">Neues Passwort festlegen</a>
-            </p>
-            <p>Oder kopieren Sie diesen Link in Ihren Browser:</p>
-            <p style="word-break: break-all; font-size: 12px; color: #666;">
-                {{.BaseURL}}/reset-password?token={{.Token}}
-            </p>
-            <div class="warning">
-                <strong>⚠️ Wichtig:</strong> Dieser Link ist nur 1 Stunde gültig.
-            </div>
-            <p>Wenn Sie diese Anfrage nicht gestellt haben, können Sie diese E-Mail ignorieren. Ihr Passwort bleibt unverändert.</p>
-        </div>
-        <div class="footer">
-            <p>© 2025 Gassigeher. Alle Rechte vorbehalten.</p>
-        </div>
-    </div>
-</body>
-</html>
-`
-
-	t := template.Must(template.New("reset").Parse(tmpl))
-	var body bytes.Buffer
-	if err := t.Execute(&body, map[string]string{
-		"Name":    name,
-		"Token":   token,
-		"BaseURL": s.baseURL,
-	}); err != nil {
-		return fmt.Errorf("failed to execute template: %w", err)
-	}
+	return s.sendTemplate(to, "password_reset", map[string]interface{}{
+		"Name":  name,
+		"Token": token,
+	})
+}
 
-	return s.SendEmail(to, subject, body.String())
+// SendMagicLinkEmail sends a link to log in without password
+func (s *EmailService) SendMagicLinkEmail(to, name, token string) error {
+	return s.sendTemplate(to, "magic_link", map[string]interface{}{
+		"Name":  name,
+		"Token": token,
+	})
+}
+
+// SendInvitationEmail sends an invitation code with a link to the registration
+func (s *EmailService) SendInvitationEmail(to, code string, expiresAt time.Time) error {
+	return s.sendTemplate(to, "invitation", map[string]interface{}{
+		"Code":      code,
+		"ExpiresAt": expiresAt.Format("02.01.2006"),
+	})
+}
+
+// SendNewDeviceLoginEmail warns the user about a login from a device they did not use before
+func (s *EmailService) SendNewDeviceLoginEmail(to, name, device, ipAddress string, loginAt time.Time) error {
+	return s.sendTemplate(to, "new_device_login", map[string]interface{}{
+		"Name":      name,
+		"Device":    device,
+		"IPAddress": ipAddress,
+		"Time":      loginAt.Format("02.01.2006 15:04"),
+	})
 }
 
 // SendBookingConfirmation sends a booking confirmation email
 func (s *EmailService) SendBookingConfirmation(to, name, dogName, date, scheduledTime string) error {
-	subject := fmt.Sprintf("Buchungsbestätigung - %s", dogName)
-
-	tmpl := `
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <style>
-        body { font-family: Arial, sans-serif; line-height: 1.6; color: #26272b; }
-        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
-        .header { background-color: #82b965; color: white; padding: 20px; text-align: center; border-radius: 6px 6px 0 0; }
-        .content { background-color: #f9f9f9; padding: 30px; border-radius: 0 0 6px 6px; }
-        .booking-details { background-color: white; padding: 20px; margin: 20px 0; border-radius: 6px; border-left: 4px solid #82b965; }
-        .detail-row { margin: 10px 0; }
-        .label { font-weight: 600; color: #666; }
-        .footer { text-align: center; margin-top: 20px; color: #666; font-size: 12px; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>✅ Buchung bestätigt!</h1>
-        </div>
-        <div class="content">
-            <p>Hallo {{.Name}},</p>
-            <p>Ihre Buchung wurde erfolgreich bestätigt.</p>
-
-            <div class="booking-details">
-                <h3 style="margin-top: 0;">Buchungsdetails</h3>
-                <div class="detail-row">
-                    <span class="label">Hund:</span> {{.DogName}}
-                </div>
-                <div class="detail-row">
-                    <span class="label">Datum:</span> {{.Date}}
-                </div>
-                <div class="detail-row">
-                    <span class="label">Uhrzeit:</span> {{.ScheduledTime}} Uhr
-                </div>
-            </div>
-
-            <p>Sie erhalten eine Erinnerung 1 Stunde vor Ihrem Spaziergang.</p>
-            <p>Falls Sie den Termin stornieren möchten, tun Sie dies bitte mindestens 12 Stunden im Voraus über Ihr Dashboard.</p>
-        </div>
-        <div class="footer">
-            <p>© 2025 Gassigeher. Alle Rechte vorbehalten.</p>
-        </div>
-    </div>
-</body>
-</html>
-`
-
-	t := template.Must(template.New("booking").Parse(tmpl))
-	var body bytes.Buffer
-	data := map[string]string{
+	return s.sendTemplate(to, "booking_confirmation", map[string]interface{}{
 		"Name":          name,
 		"DogName":       dogName,
 		"Date":          date,
 		"ScheduledTime": scheduledTime,
-	}
-	if err := t.Execute(&body, data); err != nil {
-		return fmt.Errorf("failed to execute template: %w", err)
-	}
-
-	return s.SendEmail(to, subject, body.String())
+	})
 }
 
 // SendBookingCancellation sends a booking cancellation confirmation (user-initiated)
 func (s *EmailService) SendBookingCancellation(to, name, dogName, date, scheduledTime string) error {
-	subject := fmt.Sprintf("Buchung storniert - %s", dogName)
-
-	tmpl := `
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <style>
-        body { font-family: Arial, sans-serif; line-height: 1.6; color: #26272b; }
-        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
-        .header { background-color: #dc3545; color: white; padding: 20px; text-align: center; border-radius: 6px 6px 0 0; }
-        .content { background-color: #f9f9f9; padding: 30px; border-radius: 0 0 6px 6px; }
-        .booking-details { background-color: white; padding: 20px; margin: 20px 0; border-radius: 6px; border-left: 4px solid #dc3545; }
-        .detail-row { margin: 10px 0; }
-        .label { font-weight: 600; color: #666; }
-        .footer { text-align: center; margin-top: 20px; color: #666; font-size: 12px; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>Buchung storniert</h1>
-        </div>
-        <div class="content">
-            <p>Hallo {{.Name}},</p>
-            <p>Ihre Buchung wurde erfolgreich storniert.</p>
-
-            <div class="booking-details">
-                <h3 style="margin-top: 0;">Stornierte Buchung</h3>
-                <div class="detail-row">
-                    <span class="label">Hund:</span> {{.DogName}}
-                </div>
-                <div class="detail-row">
-                    <span class="label">Datum:</span> {{.Date}}
-                </div>
-                <div class="detail-row">
-                    <span class="label">Uhrzeit:</span> {{.ScheduledTime}} Uhr
-                </div>
-            </div>
-
-            <p>Sie können jederzeit eine neue Buchung vornehmen.</p>
-        </div>
-        <div class="footer">
-            <p>© 2025 Gassigeher. Alle Rechte vorbehalten.</p>
-        </div>
-    </div>
-</body>
-</html>
-`
-
-	t := template.Must(template.New("cancellation").Parse(tmpl))
-	var body bytes.Buffer
-	data := map[string]string{
+	return s.sendTemplate(to, "booking_cancellation", map[string]interface{}{
 		"Name":          name,
 		"DogName":       dogName,
 		"Date":          date,
 		"ScheduledTime": scheduledTime,
-	}
-	if err := t.Execute(&body, data); err != nil {
-		return fmt.Errorf("failed to execute template: %w", err)
-	}
-
-	return s.SendEmail(to, subject, body.String())
+	})
 }
 
 // SendAdminCancellation sends an admin cancellation notification
 func (s *EmailService) SendAdminCancellation(to, name, dogName, date, scheduledTime, reason string) error {
-	subject := fmt.Sprintf("Deine Buchung wurde storniert - %s", dogName)
-
-	tmpl := `
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <style>
-        body { font-family: Arial, sans-serif; line-height: 1.6; color: #26272b; }
-        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
-        .header { background-color: #dc3545; color: white; padding: 20px; text-align: center; border-radius: 6px 6px 0 0; }
-        .content { background-color: #f9f9f9; padding: 30px; border-radius: 0 0 6px 6px; }
-        .booking-details { background-color: white; padding: 20px; margin: 20px 0; border-radius: 6px; border-left: 4px solid #dc3545; }
-        .reason-box { background-color: #fff3cd; padding: 15px; margin: 20px 0; border-radius: 6px; border-left: 4px solid #ffc107; }
-        .detail-row { margin: 10px 0; }
-        .label { font-weight: 600; color: #666; }
-        .footer { text-align: center; margin-top: 20px; color: #666; font-size: 12px; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>Buchung storniert</h1>
-        </div>
-        <div class="content">
-            <p>Hallo {{.Name}},</p>
-            <p>Leider mussten wir Ihre folgende Buchung stornieren:</p>
-
-            <div class="booking-details">
-                <h3 style="margin-top: 0;">Stornierte Buchung</h3>
-                <div class="detail-row">
-                    <span class="label">Hund:</span> {{.DogName}}
-                </div>
-                <div class="detail-row">
-                    <span class="label">Datum:</span> {{.Date}}
-                </div>
-                <div class="detail-row">
-                    <span class="label">Uhrzeit:</span> {{.ScheduledTime}} Uhr
-                </div>
-            </div>
-
-            <div class="reason-box">
-                <strong>Grund der Stornierung:</strong><br>
-                {{.Reason}}
-            </div>
-
-            <p>Wir entschuldigen uns für die Unannehmlichkeiten. Sie können gerne einen anderen Termin buchen.</p>
-        </div>
-        <div class="footer">
-            <p>© 2025 Gassigeher. Alle Rechte vorbehalten.</p>
-        </div>
-    </div>
-</body>
-</html>
-`
-
-	t := template.Must(template.New("admin_cancel").Parse(tmpl))
-	var body bytes.Buffer
-	data := map[string]string{
+	return s.sendTemplate(to, "admin_cancellation", map[string]interface{}{
 		"Name":          name,
 		"DogName":       dogName,
 		"Date":          date,
 		"ScheduledTime": scheduledTime,
 		"Reason":        reason,
-	}
-	if err := t.Execute(&body, data); err != nil {
-		return fmt.Errorf("failed to execute template: %w", err)
-	}
-
-	return s.SendEmail(to, subject, body.String())
+	})
 }
 
-// SendBookingReminder sends a reminder 1 hour before the booking
+// SendBookingReminder sends a reminder of an upcoming booking, at the times of the reminder schedule
 func (s *EmailService) SendBookingReminder(to, name, dogName, date, scheduledTime string) error {
-	subject := fmt.Sprintf("Erinnerung: Gassirunde mit %s in 1 Stunde", dogName)
-
-	tmpl := `
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <style>
-        body { font-family: Arial, sans-serif; line-height: 1.6; color: #26272b; }
-        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
-        .header { background-color: #17a2b8; color: white; padding: 20px; text-align: center; border-radius: 6px 6px 0 0; }
-        .content { background-color: #f9f9f9; padding: 30px; border-radius: 0 0 6px 6px; }
-        .booking-details { background-color: white; padding: 20px; margin: 20px 0; border-radius: 6px; border-left: 4px solid #17a2b8; }
-        .detail-row { margin: 10px 0; }
-        .label { font-weight: 600; color: #666; }
-        .footer { text-align: center; margin-top: 20px; color: #666; font-size: 12px; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>🔔 Erinnerung</h1>
-        </div>
-        <div class="content">
-            <p>Hallo {{.Name}},</p>
-            <p>Dies ist eine Erinnerung an Ihren bevorstehenden Spaziergang:</p>
-
-            <div class="booking-details">
-                <h3 style="margin-top: 0;">Ihr Spaziergang</h3>
-                <div class="detail-row">
-                    <span class="label">Hund:</span> {{.DogName}}
-                </div>
-                <div class="detail-row">
-                    <span class="label">Datum:</span> {{.Date}}
-                </div>
-                <div class="detail-row">
-                    <span class="label">Uhrzeit:</span> {{.ScheduledTime}} Uhr
-                </div>
-            </div>
-
-            <p>Viel Spaß beim Spaziergang!</p>
-        </div>
-        <div class="footer">
-            <p>© 2025 Gassigeher. Alle Rechte vorbehalten.</p>
-        </div>
-    </div>
-</body>
-</html>
-`
-
-	t := template.Must(template.New("reminder").Parse(tmpl))
-	var body bytes.Buffer
-	data := map[string]string{
+	return s.sendTemplate(to, "booking_reminder", map[string]interface{}{
 		"Name":          name,
 		"DogName":       dogName,
 		"Date":          date,
 		"ScheduledTime": scheduledTime,
-	}
-	if err := t.Execute(&body, data); err != nil {
-		return fmt.Errorf("failed to execute template: %w", err)
-	}
-
-	return s.SendEmail(to, subject, body.String())
+	})
 }
 
 // SendBookingMoved sends an email when admin moves a booking
 func (s *EmailService) SendBookingMoved(to, name, dogName, oldDate, oldTime, newDate, newTime, reason string) error {
-	subject := fmt.Sprintf("Deine Buchung wurde verschoben - %s", dogName)
-
-	tmpl := `
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <style>
-        body { font-family: Arial, sans-serif; line-height: 1.6; color: #26272b; }
-        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
-        .header { background-color: #17a2b8; color: white; padding: 20px; text-align: center; border-radius: 6px 6px 0 0; }
-        .content { background-color: #f9f9f9; padding: 30px; border-radius: 0 0 6px 6px; }
-        .booking-details { background-color: white; padding: 20px; margin: 20px 0; border-radius: 6px; }
-        .old-details { border-left: 4px solid #dc3545; }
-        .new-details { border-left: 4px solid #28a745; margin-top: 20px; }
-        .reason-box { background-color: #fff3cd; padding: 15px; margin: 20px 0; border-radius: 6px; border-left: 4px solid #ffc107; }
-        .detail-row { margin: 10px 0; }
-        .label { font-weight: 600; color: #666; }
-        .footer { text-align: center; margin-top: 20px; color: #666; font-size: 12px; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>Buchung verschoben</h1>
-        </div>
-        <div class="content">
-            <p>Hallo {{.Name}},</p>
-            <p>Ihre Buchung wurde auf einen neuen Termin verschoben:</p>
-
-            <div class="booking-details old-details">
-                <h3 style="margin-top: 0; color: #dc3545;">Alter Termin</h3>
-                <div class="detail-row">
-                    <span class="label">Hund:</span> {{.DogName}}
-                </div>
-                <div class="detail-row">
-                    <span class="label">Datum:</span> {{.OldDate}}
-                </div>
-                <div class="detail-row">
-                    <span class="label">Uhrzeit:</span> {{.OldTime}} Uhr
-                </div>
-            </div>
-
-            <div class="booking-details new-details">
-                <h3 style="margin-top: 0; color: #28a745;">Neuer Termin</h3>
-                <div class="detail-row">
-                    <span class="label">Hund:</span> {{.DogName}}
-                </div>
-                <div class="detail-row">
-                    <span class="label">Datum:</span> {{.NewDate}}
-                </div>
-                <div class="detail-row">
-                    <span class="label">Uhrzeit:</span> {{.NewTime}} Uhr
-                </div>
-            </div>
-
-            <div class="reason-box">
-                <strong>Grund der Verschiebung:</strong><br>
-                {{.Reason}}
-            </div>
-
-            <p>Wir entschuldigen uns für die Unannehmlichkeiten. Bei Fragen oder Problemen wenden Sie sich bitte an uns.</p>
-        </div>
-        <div class="footer">
-            <p>© 2025 Gassigeher. Alle Rechte vorbehalten.</p>
-        </div>
-    </div>
-</body>
-</html>
-`
-
-	t := template.Must(template.New("moved").Parse(tmpl))
-	var body bytes.Buffer
-	data := map[string]string{
+	return s.sendTemplate(to, "booking_moved", map[string]interface{}{
 		"Name":    name,
 		"DogName": dogName,
 		"OldDate": oldDate,
@@ -621,315 +284,54 @@ func (s *EmailService) SendBookingMoved(to, name, dogName, oldDate, oldTime, new
 		"NewDate": newDate,
 		"NewTime": newTime,
 		"Reason":  reason,
-	}
-	if err := t.Execute(&body, data); err != nil {
-		return fmt.Errorf("failed to execute template: %w", err)
-	}
-
-	return s.SendEmail(to, subject, body.String())
+	})
 }
 
 // SendBookingApproved sends a notification when a pending booking is approved by admin
 func (s *EmailService) SendBookingApproved(to, name, dogName, date, scheduledTime string) error {
-	subject := fmt.Sprintf("Buchung genehmigt - %s am %s", dogName, date)
-
-	tmpl := `
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <style>
-        body { font-family: Arial, sans-serif; line-height: 1.6; color: #26272b; }
-        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
-        .header { background-color: #28a745; color: white; padding: 20px; text-align: center; border-radius: 6px 6px 0 0; }
-        .content { background-color: #f9f9f9; padding: 30px; border-radius: 0 0 6px 6px; }
-        .booking-details { background-color: white; padding: 20px; margin: 20px 0; border-radius: 6px; border-left: 4px solid #28a745; }
-        .detail-row { margin: 10px 0; }
-        .label { font-weight: 600; color: #666; }
-        .footer { text-align: center; margin-top: 20px; color: #666; font-size: 12px; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>✅ Buchung genehmigt!</h1>
-        </div>
-        <div class="content">
-            <p>Hallo {{.Name}},</p>
-            <p>Gute Nachrichten! Ihre Buchungsanfrage wurde genehmigt.</p>
-
-            <div class="booking-details">
-                <h3 style="margin-top: 0;">Buchungsdetails</h3>
-                <div class="detail-row">
-                    <span class="label">Hund:</span> {{.DogName}}
-                </div>
-                <div class="detail-row">
-                    <span class="label">Datum:</span> {{.Date}}
-                </div>
-                <div class="detail-row">
-                    <span class="label">Uhrzeit:</span> {{.ScheduledTime}} Uhr
-                </div>
-            </div>
-
-            <p>Sie können nun wie geplant mit {{.DogName}} spazieren gehen.</p>
-            <p>Falls Sie den Termin stornieren möchten, tun Sie dies bitte mindestens 12 Stunden im Voraus über Ihr Dashboard.</p>
-        </div>
-        <div class="footer">
-            <p>© 2025 Gassigeher. Alle Rechte vorbehalten.</p>
-        </div>
-    </div>
-</body>
-</html>
-`
-
-	t := template.Must(template.New("approval").Parse(tmpl))
-	var body bytes.Buffer
-	data := map[string]string{
+	return s.sendTemplate(to, "booking_approved", map[string]interface{}{
 		"Name":          name,
 		"DogName":       dogName,
 		"Date":          date,
 		"ScheduledTime": scheduledTime,
-	}
-	if err := t.Execute(&body, data); err != nil {
-		return fmt.Errorf("failed to execute template: %w", err)
-	}
-
-	return s.SendEmail(to, subject, body.String())
+	})
 }
 
 // SendBookingRejected sends a notification when a pending booking is rejected by admin
 func (s *EmailService) SendBookingRejected(to, name, dogName, date, scheduledTime, reason string) error {
-	subject := fmt.Sprintf("Buchung abgelehnt - %s am %s", dogName, date)
-
-	tmpl := `
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <style>
-        body { font-family: Arial, sans-serif; line-height: 1.6; color: #26272b; }
-        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
-        .header { background-color: #dc3545; color: white; padding: 20px; text-align: center; border-radius: 6px 6px 0 0; }
-        .content { background-color: #f9f9f9; padding: 30px; border-radius: 0 0 6px 6px; }
-        .booking-details { background-color: white; padding: 20px; margin: 20px 0; border-radius: 6px; border-left: 4px solid #dc3545; }
-        .reason-box { background-color: #fff3cd; padding: 15px; margin: 20px 0; border-radius: 6px; border-left: 4px solid #ffc107; }
-        .detail-row { margin: 10px 0; }
-        .label { font-weight: 600; color: #666; }
-        .footer { text-align: center; margin-top: 20px; color: #666; font-size: 12px; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>❌ Buchung abgelehnt</h1>
-        </div>
-        <div class="content">
-            <p>Hallo {{.Name}},</p>
-            <p>Leider mussten wir Ihre Buchungsanfrage ablehnen.</p>
-
-            <div class="booking-details">
-                <h3 style="margin-top: 0;">Buchungsdetails</h3>
-                <div class="detail-row">
-                    <span class="label">Hund:</span> {{.DogName}}
-                </div>
-                <div class="detail-row">
-                    <span class="label">Datum:</span> {{.Date}}
-                </div>
-                <div class="detail-row">
-                    <span class="label">Uhrzeit:</span> {{.ScheduledTime}} Uhr
-                </div>
-            </div>
-
-            <div class="reason-box">
-                <strong>Begründung:</strong>
-                <p style="margin-bottom: 0;">{{.Reason}}</p>
-            </div>
-
-            <p>Bitte versuchen Sie eine Buchung zu einem anderen Zeitpunkt oder kontaktieren Sie uns bei Fragen.</p>
-        </div>
-        <div class="footer">
-            <p>© 2025 Gassigeher. Alle Rechte vorbehalten.</p>
-        </div>
-    </div>
-</body>
-</html>
-`
-
-	t := template.Must(template.New("rejection").Parse(tmpl))
-	var body bytes.Buffer
-	data := map[string]string{
+	return s.sendTemplate(to, "booking_rejected", map[string]interface{}{
 		"Name":          name,
 		"DogName":       dogName,
 		"Date":          date,
 		"ScheduledTime": scheduledTime,
 		"Reason":        reason,
-	}
-	if err := t.Execute(&body, data); err != nil {
-		return fmt.Errorf("failed to execute template: %w", err)
-	}
-
-	return s.SendEmail(to, subject, body.String())
+	})
 }
 
 // SendExperienceLevelApproved sends an email when experience level request is approved
 func (s *EmailService) SendExperienceLevelApproved(to, name, level string, message *string) error {
-	levelLabel := "Blau"
-	if level == "orange" {
-		levelLabel = "Orange"
+	if level != "orange" {
+		level = "blue"
 	}
+	recipient := s.lookupRecipient(to)
 
-	subject := fmt.Sprintf("Ihr Antrag auf %s Level wurde genehmigt", levelLabel)
-
-	tmpl := `
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <style>
-        body { font-family: Arial, sans-serif; line-height: 1.6; color: #26272b; }
-        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
-        .header { background-color: #28a745; color: white; padding: 20px; text-align: center; border-radius: 6px 6px 0 0; }
-        .content { background-color: #f9f9f9; padding: 30px; border-radius: 0 0 6px 6px; }
-        .success-box { background-color: #d4edda; padding: 20px; margin: 20px 0; border-radius: 6px; border-left: 4px solid #28a745; }
-        .message-box { background-color: white; padding: 15px; margin: 20px 0; border-radius: 6px; border-left: 4px solid #17a2b8; }
-        .footer { text-align: center; margin-top: 20px; color: #666; font-size: 12px; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>✅ Glückwunsch!</h1>
-        </div>
-        <div class="content">
-            <p>Hallo {{.Name}},</p>
-            <p>Ihr Antrag auf <strong>{{.Level}} Level</strong> wurde genehmigt!</p>
-
-            <div class="success-box">
-                <h3 style="margin-top: 0;">Sie haben jetzt Zugang zu:</h3>
-                <p style="margin: 5px 0;">
-                    {{if eq .Level "Blau"}}
-                    ✓ Grüne Hunde (Anfänger)<br>
-                    ✓ Blaue Hunde (Erfahrene)
-                    {{else}}
-                    ✓ Grüne Hunde (Anfänger)<br>
-                    ✓ Blaue Hunde (Erfahrene)<br>
-                    ✓ Orange Hunde (Nur Erfahrene)
-                    {{end}}
-                </p>
-            </div>
-
-            {{if .Message}}
-            <div class="message-box">
-                <strong>Nachricht vom Administrator:</strong><br>
-                {{.Message}}
-            </div>
-            {{end}}
-
-            <p>Sie können jetzt sofort Hunde Ihres neuen Levels buchen!</p>
-
-            <p style="text-align: center; margin-top: 30px;">
-                <a href="{{.BaseURL}}/dogs.html" style="display: inline-block; padding: 12px 30px; background-color: #82b965; color: white; text-decoration: none; border-radius: 6px;">Hunde anzeigen</a>
-            </p>
-        </div>
-        <div class="footer">
-            <p>© 2025 Gassigeher. Alle Rechte vorbehalten.</p>
-        </div>
-    </div>
-</body>
-</html>
-`
-
-	t := template.Must(template.New("approved").Parse(tmpl))
-	var body bytes.Buffer
-	data := map[string]interface{}{
+	return s.sendToRecipient(recipient, "experience_approved", map[string]interface{}{
 		"Name":    name,
-		"Level":   levelLabel,
-		"BaseURL": s.baseURL,
-		"Message": func() string {
-			if message != nil {
-				return *message
-			}
-			return ""
-		}(),
-	}
-	if err := t.Execute(&body, data); err != nil {
-		return fmt.Errorf("failed to execute template: %w", err)
-	}
-
-	return s.SendEmail(to, subject, body.String())
+		"Level":   i18n.T(recipient.lang, "levels."+level),
+		"Message": optionalMessage(message),
+	})
 }
 
 // SendExperienceLevelDenied sends an email when experience level request is denied
 func (s *EmailService) SendExperienceLevelDenied(to, name, level string, message *string) error {
-	levelLabel := "Blau"
-	if level == "orange" {
-		levelLabel = "Orange"
-	}
-
-	subject := fmt.Sprintf("Ihr Antrag auf %s Level", levelLabel)
-
-	tmpl := `
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <style>
-        body { font-family: Arial, sans-serif; line-height: 1.6; color: #26272b; }
-        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
-        .header { background-color: #ffc107; color: #26272b; padding: 20px; text-align: center; border-radius: 6px 6px 0 0; }
-        .content { background-color: #f9f9f9; padding: 30px; border-radius: 0 0 6px 6px; }
-        .info-box { background-color: #fff3cd; padding: 20px; margin: 20px 0; border-radius: 6px; border-left: 4px solid #ffc107; }
-        .message-box { background-color: white; padding: 15px; margin: 20px 0; border-radius: 6px; border-left: 4px solid #17a2b8; }
-        .footer { text-align: center; margin-top: 20px; color: #666; font-size: 12px; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>Ihr Antrag auf {{.Level}} Level</h1>
-        </div>
-        <div class="content">
-            <p>Hallo {{.Name}},</p>
-            <p>Vielen Dank für Ihren Antrag auf <strong>{{.Level}} Level</strong>.</p>
-
-            <div class="info-box">
-                <p style="margin: 0;">
-                    Leider können wir Ihren Antrag derzeit nicht genehmigen. Sammeln Sie weiterhin Erfahrung und versuchen Sie es später erneut!
-                </p>
-            </div>
-
-            {{if .Message}}
-            <div class="message-box">
-                <strong>Nachricht vom Administrator:</strong><br>
-                {{.Message}}
-            </div>
-            {{end}}
-
-            <p>Sie können weiterhin Hunde Ihres aktuellen Levels buchen und jederzeit einen neuen Antrag stellen.</p>
-        </div>
-        <div class="footer">
-            <p>© 2025 Gassigeher. Alle Rechte vorbehalten.</p>
-        </div>
-    </div>
-</body>
-</html>
-`
-
-	t := template.Must(template.New("denied").Parse(tmpl))
-	var body bytes.Buffer
-	data := map[string]interface{}{
-		"Name":  name,
-		"Level": levelLabel,
-		"Message": func() string {
-			if message != nil {
-				return *message
-			}
-			return ""
-		}(),
-	}
-	if err := t.Execute(&body, data); err != nil {
-		return fmt.Errorf("failed to execute template: %w", err)
+	if level != "orange" {
+		level = "blue"
 	}
+	recipient := s.lookupRecipient(to)
 
-	return s.SendEmail(to, subject, body.String())
+	return s.sendToRecipient(recipient, "experience_denied", map[string]interface{}{
+		"Name":    name,
+		"Level":   i18n.T(recipient.lang, "levels."+level),
+		"Message": optionalMessage(message),
+	})
 }
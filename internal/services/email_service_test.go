@@ -3,6 +3,10 @@ package services
 import (
 	"strings"
 	"testing"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/testutil"
 )
 
 // DONE: TestEmailService_VerificationEmail tests verification email formatting
@@ -150,3 +154,126 @@ func TestEmailService_CancellationEmail(t *testing.T) {
 // Note: Full EmailService testing requires Gmail API credentials or mocking
 // These tests validate email formatting logic and required parameters
 // Integration/E2E tests should verify actual email delivery in staging environment
+
+// recordingEmailProvider captures sent emails instead of delivering them
+type recordingEmailProvider struct {
+	to, subject, body string
+	headers           map[string]string
+	sent              int
+}
+
+func (p *recordingEmailProvider) SendEmail(to, subject, body string) error {
+	return p.SendEmailWithHeaders(to, subject, body, nil)
+}
+
+func (p *recordingEmailProvider) SendEmailWithHeaders(to, subject, body string, headers map[string]string) error {
+	p.to, p.subject, p.body, p.headers = to, subject, body, headers
+	p.sent++
+	return nil
+}
+
+func (p *recordingEmailProvider) ValidateConfig() error { return nil }
+func (p *recordingEmailProvider) Close() error          { return nil }
+func (p *recordingEmailProvider) GetFromEmail() string  { return "noreply@example.com" }
+
+// TestEmailService_RecipientLanguage tests that emails use the recipient's preferred language
+func TestEmailService_RecipientLanguage(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	testutil.SeedTestUser(t, db, "de@example.com", "Anna", "green")
+	testutil.SeedTestUser(t, db, "en@example.com", "John", "green")
+	db.Exec("UPDATE users SET preferred_language = 'en' WHERE email = ?", "en@example.com")
+
+	provider := &recordingEmailProvider{}
+	service := &EmailService{
+		provider:  provider,
+		baseURL:   "https://example.com",
+		templates: NewEmailTemplateStore(repository.NewEmailTemplateRepository(db), "https://example.com"),
+		userRepo:  repository.NewUserRepository(db),
+	}
+
+	tests := []struct {
+		to      string
+		subject string
+		level   string
+	}{
+		{"de@example.com", "Ihr Antrag auf Orange Level wurde genehmigt", "Orange"},
+		{"en@example.com", "Your request for the Blue level was approved", "Blue"},
+		{"unknown@example.com", "Ihr Antrag auf Blau Level wurde genehmigt", "Blau"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.to, func(t *testing.T) {
+			level := "blue"
+			if tt.level == "Orange" {
+				level = "orange"
+			}
+
+			if err := service.SendExperienceLevelApproved(tt.to, "Test", level, nil); err != nil {
+				t.Fatalf("SendExperienceLevelApproved() failed: %v", err)
+			}
+
+			if provider.subject != tt.subject {
+				t.Errorf("Expected subject %q, got %q", tt.subject, provider.subject)
+			}
+			if !strings.Contains(provider.body, tt.level) {
+				t.Errorf("Body should contain the translated level %q", tt.level)
+			}
+		})
+	}
+}
+
+// TestEmailService_NotificationPreferences tests opt-outs and unsubscribe headers
+func TestEmailService_NotificationPreferences(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "Anna", "green")
+
+	preferences := repository.NewNotificationPreferenceRepository(db)
+	tokens := NewUnsubscribeTokens("test-secret")
+	provider := &recordingEmailProvider{}
+	service := &EmailService{
+		provider:    provider,
+		baseURL:     "https://example.com",
+		templates:   NewEmailTemplateStore(repository.NewEmailTemplateRepository(db), "https://example.com"),
+		userRepo:    repository.NewUserRepository(db),
+		preferences: preferences,
+		unsubscribe: tokens,
+	}
+
+	t.Run("optional category has unsubscribe link and headers", func(t *testing.T) {
+		if err := service.SendBookingConfirmation("user@example.com", "Anna", "Bella", "2025-12-01", "09:00"); err != nil {
+			t.Fatalf("SendBookingConfirmation() failed: %v", err)
+		}
+
+		token := tokens.Generate(userID, models.NotificationCategoryConfirmations)
+		if !strings.Contains(provider.body, "https://example.com/unsubscribe?token="+token) {
+			t.Error("Body should contain the unsubscribe link")
+		}
+		if provider.headers["List-Unsubscribe"] != "<https://example.com/api/unsubscribe?token="+token+">" {
+			t.Errorf("Unexpected List-Unsubscribe header: %q", provider.headers["List-Unsubscribe"])
+		}
+		if provider.headers["List-Unsubscribe-Post"] != "List-Unsubscribe=One-Click" {
+			t.Errorf("Unexpected List-Unsubscribe-Post header: %q", provider.headers["List-Unsubscribe-Post"])
+		}
+	})
+
+	t.Run("mandatory category has no unsubscribe headers", func(t *testing.T) {
+		if err := service.SendWelcomeEmail("user@example.com", "Anna"); err != nil {
+			t.Fatalf("SendWelcomeEmail() failed: %v", err)
+		}
+		if len(provider.headers) != 0 {
+			t.Errorf("Expected no extra headers, got %v", provider.headers)
+		}
+	})
+
+	t.Run("disabled category is skipped", func(t *testing.T) {
+		preferences.Set(userID, models.NotificationCategoryReminders, models.NotificationChannelEmail, false)
+		sent := provider.sent
+
+		if err := service.SendBookingReminder("user@example.com", "Anna", "Bella", "2025-12-01", "09:00"); err != nil {
+			t.Fatalf("SendBookingReminder() failed: %v", err)
+		}
+		if provider.sent != sent {
+			t.Error("Reminder should not be sent when disabled")
+		}
+	})
+}
@@ -0,0 +1,587 @@
+package services
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/i18n"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+)
+
+// Embedded default email templates (one directory per language,
+// one HTML body per template key). Default subjects live in the i18n
+// catalogs under "email.subjects.<key>".
+//
+//go:embed email_templates/*/*.html
+var defaultEmailTemplates embed.FS
+
+// ErrUnknownEmailTemplate is returned for template keys that are not registered
+var ErrUnknownEmailTemplate = errors.New("unknown email template")
+
+// commonTemplateVariables are available in every email template
+// UnsubscribeURL is empty for mandatory notification categories
+var commonTemplateVariables = []string{"BaseURL", "Year", "UnsubscribeURL"}
+
+// EmailTemplateDefinition describes a built-in email template
+type EmailTemplateDefinition struct {
+	Key         string
+	Category    string // Notification category, decides if the user can opt out
+	Description string
+	Variables   []string               // Template-specific variables
+	SampleData  map[string]interface{} // Used for validation and previews
+}
+
+// emailTemplateDefinitions lists all built-in email templates
+var emailTemplateDefinitions = []*EmailTemplateDefinition{
+	{
+		Key:         "verification",
+		Category:    models.NotificationCategoryAccount,
+		Description: "E-Mail-Adresse bestätigen nach der Registrierung",
+		Variables:   []string{"Name", "Token"},
+		SampleData:  map[string]interface{}{"Name": "Max Mustermann", "Token": "beispiel-token"},
+	},
+	{
+		Key:         "welcome",
+		Category:    models.NotificationCategoryAccount,
+		Description: "Willkommen nach erfolgreicher Verifizierung",
+		Variables:   []string{"Name"},
+		SampleData:  map[string]interface{}{"Name": "Max Mustermann"},
+	},
+	{
+		Key:         "password_reset",
+		Category:    models.NotificationCategoryAccount,
+		Description: "Link zum Zurücksetzen des Passworts",
+		Variables:   []string{"Name", "Token"},
+		SampleData:  map[string]interface{}{"Name": "Max Mustermann", "Token": "beispiel-token"},
+	},
+	{
+		Key:         "magic_link",
+		Category:    models.NotificationCategoryAccount,
+		Description: "Anmeldelink ohne Passwort",
+		Variables:   []string{"Name", "Token"},
+		SampleData:  map[string]interface{}{"Name": "Max Mustermann", "Token": "beispiel-token"},
+	},
+	{
+		Key:         "new_device_login",
+		Category:    models.NotificationCategoryAccount,
+		Description: "Hinweis auf eine Anmeldung von einem neuen Gerät",
+		Variables:   []string{"Name", "Device", "IPAddress", "Time"},
+		SampleData:  map[string]interface{}{"Name": "Max Mustermann", "Device": "Firefox (Windows)", "IPAddress": "203.0.113.42", "Time": "15.01.2025 18:30"},
+	},
+	{
+		Key:         "invitation",
+		Category:    models.NotificationCategoryAccount,
+		Description: "Einladung zur Registrierung mit Einladungscode",
+		Variables:   []string{"Code", "ExpiresAt"},
+		SampleData:  map[string]interface{}{"Code": "K7QM3XPA9TRW", "ExpiresAt": "31.01.2025"},
+	},
+	{
+		Key:         "booking_confirmation",
+		Category:    models.NotificationCategoryConfirmations,
+		Description: "Buchungsbestätigung",
+		Variables:   []string{"Name", "DogName", "Date", "ScheduledTime"},
+		SampleData:  sampleBookingData(),
+	},
+	{
+		Key:         "booking_cancellation",
+		Category:    models.NotificationCategoryConfirmations,
+		Description: "Stornierung durch den Benutzer",
+		Variables:   []string{"Name", "DogName", "Date", "ScheduledTime"},
+		SampleData:  sampleBookingData(),
+	},
+	{
+		Key:         "admin_cancellation",
+		Category:    models.NotificationCategoryBookingChanges,
+		Description: "Stornierung durch einen Administrator",
+		Variables:   []string{"Name", "DogName", "Date", "ScheduledTime", "Reason"},
+		SampleData:  withSampleReason(sampleBookingData()),
+	},
+	{
+		Key:         "booking_reminder",
+		Category:    models.NotificationCategoryReminders,
+		Description: "Erinnerung vor dem Spaziergang",
+		Variables:   []string{"Name", "DogName", "Date", "ScheduledTime"},
+		SampleData:  sampleBookingData(),
+	},
+	{
+		Key:         "booking_moved",
+		Category:    models.NotificationCategoryBookingChanges,
+		Description: "Buchung wurde von einem Administrator verschoben",
+		Variables:   []string{"Name", "DogName", "OldDate", "OldTime", "NewDate", "NewTime", "Reason"},
+		SampleData: map[string]interface{}{
+			"Name":    "Max Mustermann",
+			"DogName": "Bella",
+			"OldDate": "24.12.2025",
+			"OldTime": "10:00",
+			"NewDate": "26.12.2025",
+			"NewTime": "14:30",
+			"Reason":  "Tierarzttermin",
+		},
+	},
+	{
+		Key:         "booking_approved",
+		Category:    models.NotificationCategoryBookingChanges,
+		Description: "Genehmigung einer genehmigungspflichtigen Buchung",
+		Variables:   []string{"Name", "DogName", "Date", "ScheduledTime"},
+		SampleData:  sampleBookingData(),
+	},
+	{
+		Key:         "booking_rejected",
+		Category:    models.NotificationCategoryBookingChanges,
+		Description: "Ablehnung einer genehmigungspflichtigen Buchung",
+		Variables:   []string{"Name", "DogName", "Date", "ScheduledTime", "Reason"},
+		SampleData:  withSampleReason(sampleBookingData()),
+	},
+	{
+		Key:         "experience_approved",
+		Category:    models.NotificationCategoryBookingChanges,
+		Description: "Erfahrungslevel-Antrag genehmigt",
+		Variables:   []string{"Name", "Level", "Message"},
+		SampleData:  map[string]interface{}{"Name": "Max Mustermann", "Level": "blue", "Message": "Weiter so!"},
+	},
+	{
+		Key:         "experience_denied",
+		Category:    models.NotificationCategoryBookingChanges,
+		Description: "Erfahrungslevel-Antrag abgelehnt",
+		Variables:   []string{"Name", "Level", "Message"},
+		SampleData:  map[string]interface{}{"Name": "Max Mustermann", "Level": "orange", "Message": "Bitte sammeln Sie noch etwas Erfahrung."},
+	},
+	{
+		Key:         "account_deactivated",
+		Category:    models.NotificationCategoryAccount,
+		Description: "Konto wurde deaktiviert",
+		Variables:   []string{"Name", "Reason"},
+		SampleData:  map[string]interface{}{"Name": "Max Mustermann", "Reason": "Keine Aktivität seit 365 Tagen"},
+	},
+	{
+		Key:         "account_reactivated",
+		Category:    models.NotificationCategoryAccount,
+		Description: "Konto wurde wieder aktiviert",
+		Variables:   []string{"Name", "Message"},
+		SampleData:  map[string]interface{}{"Name": "Max Mustermann", "Message": "Willkommen zurück!"},
+	},
+	{
+		Key:         "reactivation_denied",
+		Category:    models.NotificationCategoryAccount,
+		Description: "Reaktivierungsanfrage abgelehnt",
+		Variables:   []string{"Name", "Message"},
+		SampleData:  map[string]interface{}{"Name": "Max Mustermann", "Message": "Bitte melden Sie sich im Tierheim."},
+	},
+	{
+		Key:         "registration_denied",
+		Category:    models.NotificationCategoryAccount,
+		Description: "Registrierung wurde nicht freigegeben",
+		Variables:   []string{"Name", "Message"},
+		SampleData:  map[string]interface{}{"Name": "Max Mustermann", "Message": "Bitte melden Sie sich zuerst im Tierheim."},
+	},
+	{
+		Key:         "account_deletion",
+		Category:    models.NotificationCategoryAccount,
+		Description: "Bestätigung der Kontolöschung",
+		Variables:   []string{"Name"},
+		SampleData:  map[string]interface{}{"Name": "Max Mustermann"},
+	},
+	{
+		Key:         "announcement",
+		Category:    models.NotificationCategoryAnnouncements,
+		Description: "Ankündigung der Administratoren an eine Benutzergruppe",
+		Variables:   []string{"Name", "Subject", "Message"},
+		SampleData: map[string]interface{}{
+			"Name":    "Max Mustermann",
+			"Subject": "Tierheim am Samstag geschlossen",
+			"Message": "Wegen des Sommerfests bleibt das Tierheim am Samstag geschlossen.\nAm Sonntag sind wieder alle Spaziergänge möglich.",
+		},
+	},
+}
+
+func sampleBookingData() map[string]interface{} {
+	return map[string]interface{}{
+		"Name":          "Max Mustermann",
+		"DogName":       "Bella",
+		"Date":          "24.12.2025",
+		"ScheduledTime": "10:00",
+	}
+}
+
+func withSampleReason(data map[string]interface{}) map[string]interface{} {
+	data["Reason"] = "Der Hund ist krank"
+	return data
+}
+
+// EmailTemplateStore provides email templates with embedded defaults
+// and optional admin overrides stored in the database
+type EmailTemplateStore struct {
+	repo    *repository.EmailTemplateRepository // nil = defaults only
+	baseURL string
+}
+
+// NewEmailTemplateStore creates a new template store
+// repo may be nil, in which case only the embedded defaults are used
+func NewEmailTemplateStore(repo *repository.EmailTemplateRepository, baseURL string) *EmailTemplateStore {
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+	return &EmailTemplateStore{
+		repo:    repo,
+		baseURL: baseURL,
+	}
+}
+
+// Definitions returns all built-in template definitions sorted by key
+func (s *EmailTemplateStore) Definitions() []*EmailTemplateDefinition {
+	defs := make([]*EmailTemplateDefinition, len(emailTemplateDefinitions))
+	copy(defs, emailTemplateDefinitions)
+	sort.Slice(defs, func(i, j int) bool {
+		return defs[i].Key < defs[j].Key
+	})
+	return defs
+}
+
+// Definition returns the definition for a template key, or nil if unknown
+func (s *EmailTemplateStore) Definition(key string) *EmailTemplateDefinition {
+	for _, def := range emailTemplateDefinitions {
+		if def.Key == key {
+			return def
+		}
+	}
+	return nil
+}
+
+// DefaultSubject returns the default subject for a template key from the
+// translation catalog of the given language
+func (s *EmailTemplateStore) DefaultSubject(key, lang string) (string, error) {
+	if s.Definition(key) == nil {
+		return "", ErrUnknownEmailTemplate
+	}
+	return i18n.T(lang, "email.subjects."+key), nil
+}
+
+// DefaultBody returns the embedded default body for a template key
+// Falls back to the default language if no translation exists
+func (s *EmailTemplateStore) DefaultBody(key, lang string) (string, error) {
+	if s.Definition(key) == nil {
+		return "", ErrUnknownEmailTemplate
+	}
+	content, err := defaultEmailTemplates.ReadFile("email_templates/" + i18n.Normalize(lang) + "/" + key + ".html")
+	if err != nil {
+		content, err = defaultEmailTemplates.ReadFile("email_templates/" + i18n.DefaultLanguage + "/" + key + ".html")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read default template %s: %w", key, err)
+	}
+	return string(content), nil
+}
+
+// Get returns the effective template (override or default) for the admin UI
+func (s *EmailTemplateStore) Get(key, lang string) (*models.EmailTemplateResponse, error) {
+	def := s.Definition(key)
+	if def == nil {
+		return nil, ErrUnknownEmailTemplate
+	}
+	lang = i18n.Normalize(lang)
+
+	var override *models.EmailTemplate
+	if s.repo != nil {
+		var err error
+		override, err = s.repo.Get(key, lang)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.buildResponse(def, lang, override)
+}
+
+// List returns the effective templates of a language for all keys
+func (s *EmailTemplateStore) List(lang string) ([]*models.EmailTemplateResponse, error) {
+	lang = i18n.Normalize(lang)
+
+	overrides := map[string]*models.EmailTemplate{}
+	if s.repo != nil {
+		var err error
+		overrides, err = s.repo.GetAll(lang)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	responses := []*models.EmailTemplateResponse{}
+	for _, def := range s.Definitions() {
+		resp, err := s.buildResponse(def, lang, overrides[def.Key])
+		if err != nil {
+			return nil, err
+		}
+		responses = append(responses, resp)
+	}
+	return responses, nil
+}
+
+func (s *EmailTemplateStore) buildResponse(def *EmailTemplateDefinition, lang string, override *models.EmailTemplate) (*models.EmailTemplateResponse, error) {
+	resp := &models.EmailTemplateResponse{
+		Key:         def.Key,
+		Language:    lang,
+		Description: def.Description,
+		Variables:   append(append([]string{}, def.Variables...), commonTemplateVariables...),
+	}
+
+	if override != nil {
+		resp.Subject = override.Subject
+		resp.Body = override.Body
+		resp.IsCustomized = true
+		resp.UpdatedBy = override.UpdatedBy
+		updatedAt := override.UpdatedAt
+		resp.UpdatedAt = &updatedAt
+		return resp, nil
+	}
+
+	body, err := s.DefaultBody(def.Key, lang)
+	if err != nil {
+		return nil, err
+	}
+	resp.Subject, _ = s.DefaultSubject(def.Key, lang)
+	resp.Body = body
+	return resp, nil
+}
+
+// Validate checks that subject and body parse, only reference known
+// variables and render with the template's sample data
+func (s *EmailTemplateStore) Validate(key, lang, subject, body string) error {
+	def := s.Definition(key)
+	if def == nil {
+		return ErrUnknownEmailTemplate
+	}
+
+	subjectTmpl, err := texttemplate.New(key + "_subject").Parse(subject)
+	if err != nil {
+		return &models.ValidationError{Field: "subject", Message: fmt.Sprintf("Invalid template syntax: %v", err), Code: "invalid_template_syntax", Args: []interface{}{err}}
+	}
+	bodyTmpl, err := htmltemplate.New(key).Parse(body)
+	if err != nil {
+		return &models.ValidationError{Field: "body", Message: fmt.Sprintf("Invalid template syntax: %v", err), Code: "invalid_template_syntax", Args: []interface{}{err}}
+	}
+
+	allowed := make(map[string]bool)
+	for _, v := range def.Variables {
+		allowed[v] = true
+	}
+	for _, v := range commonTemplateVariables {
+		allowed[v] = true
+	}
+
+	if unknown := unknownTemplateVariables(subjectTmpl.Tree, allowed); len(unknown) > 0 {
+		return &models.ValidationError{Field: "subject", Message: "Unknown variables: " + strings.Join(unknown, ", "), Code: "unknown_template_variables", Args: []interface{}{strings.Join(unknown, ", ")}}
+	}
+	if unknown := unknownTemplateVariables(bodyTmpl.Tree, allowed); len(unknown) > 0 {
+		return &models.ValidationError{Field: "body", Message: "Unknown variables: " + strings.Join(unknown, ", "), Code: "unknown_template_variables", Args: []interface{}{strings.Join(unknown, ", ")}}
+	}
+
+	if _, _, err := s.execute(key, subject, body, s.sampleData(def, lang)); err != nil {
+		return &models.ValidationError{Field: "body", Message: err.Error(), Code: "template_render_failed", Args: []interface{}{err}}
+	}
+
+	return nil
+}
+
+// Save validates and stores an override for a template key and language
+func (s *EmailTemplateStore) Save(key, lang, subject, body string, updatedBy int) error {
+	if s.repo == nil {
+		return fmt.Errorf("template overrides are not available")
+	}
+	lang = i18n.Normalize(lang)
+	if err := s.Validate(key, lang, subject, body); err != nil {
+		return err
+	}
+	return s.repo.Save(&models.EmailTemplate{
+		Key:       key,
+		Language:  lang,
+		Subject:   subject,
+		Body:      body,
+		UpdatedBy: &updatedBy,
+	})
+}
+
+// Reset removes the override for a template key and language so the default is used again
+func (s *EmailTemplateStore) Reset(key, lang string) error {
+	if s.Definition(key) == nil {
+		return ErrUnknownEmailTemplate
+	}
+	if s.repo == nil {
+		return nil
+	}
+	return s.repo.Delete(key, i18n.Normalize(lang))
+}
+
+// Preview renders a template with sample data
+// subject/body override the effective template when non-nil (unsaved edits)
+func (s *EmailTemplateStore) Preview(key, lang string, subject, body *string) (*models.EmailTemplatePreview, error) {
+	current, err := s.Get(key, lang)
+	if err != nil {
+		return nil, err
+	}
+
+	if subject != nil {
+		current.Subject = *subject
+	}
+	if body != nil {
+		current.Body = *body
+	}
+
+	if err := s.Validate(key, current.Language, current.Subject, current.Body); err != nil {
+		return nil, err
+	}
+
+	def := s.Definition(key)
+	renderedSubject, renderedBody, err := s.execute(key, current.Subject, current.Body, s.sampleData(def, current.Language))
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.EmailTemplatePreview{Subject: renderedSubject, Body: renderedBody}, nil
+}
+
+// Render renders the effective template for a key in the given language
+// Falls back to the embedded default if an override fails to render
+func (s *EmailTemplateStore) Render(key, lang string, data map[string]interface{}) (string, string, error) {
+	def := s.Definition(key)
+	if def == nil {
+		return "", "", ErrUnknownEmailTemplate
+	}
+
+	lang = i18n.Normalize(lang)
+	data = s.withCommonData(data)
+
+	if s.repo != nil {
+		override, err := s.repo.Get(key, lang)
+		if err != nil {
+			log.Printf("Failed to load email template override %s (%s), using default: %v", key, lang, err)
+		} else if override != nil {
+			subject, body, err := s.execute(key, override.Subject, override.Body, data)
+			if err == nil {
+				return subject, body, nil
+			}
+			log.Printf("Email template override %s (%s) failed to render, using default: %v", key, lang, err)
+		}
+	}
+
+	defaultSubject, _ := s.DefaultSubject(key, lang)
+	defaultBody, err := s.DefaultBody(key, lang)
+	if err != nil {
+		return "", "", err
+	}
+	return s.execute(key, defaultSubject, defaultBody, data)
+}
+
+// sampleData returns the definition's sample data with common variables,
+// translating the experience level the same way EmailService does
+func (s *EmailTemplateStore) sampleData(def *EmailTemplateDefinition, lang string) map[string]interface{} {
+	data := s.withCommonData(def.SampleData)
+	if !models.IsMandatoryNotificationCategory(def.Category) {
+		data["UnsubscribeURL"] = s.baseURL + "/unsubscribe?token=beispiel-token"
+	}
+	if level, ok := data["Level"].(string); ok {
+		data["Level"] = i18n.T(lang, "levels."+level)
+	}
+	return data
+}
+
+// execute renders subject (plain text) and body (HTML-escaped)
+func (s *EmailTemplateStore) execute(key, subject, body string, data map[string]interface{}) (string, string, error) {
+	subjectTmpl, err := texttemplate.New(key + "_subject").Parse(subject)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse subject template: %w", err)
+	}
+	bodyTmpl, err := htmltemplate.New(key).Parse(body)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse body template: %w", err)
+	}
+
+	var subjectBuf, bodyBuf bytes.Buffer
+	if err := subjectTmpl.Execute(&subjectBuf, data); err != nil {
+		return "", "", fmt.Errorf("failed to execute subject template: %w", err)
+	}
+	if err := bodyTmpl.Execute(&bodyBuf, data); err != nil {
+		return "", "", fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	return strings.TrimSpace(subjectBuf.String()), bodyBuf.String(), nil
+}
+
+// withCommonData returns a copy of data including the common variables
+func (s *EmailTemplateStore) withCommonData(data map[string]interface{}) map[string]interface{} {
+	merged := map[string]interface{}{
+		"BaseURL":        s.baseURL,
+		"Year":           time.Now().Year(),
+		"UnsubscribeURL": "",
+	}
+	for k, v := range data {
+		merged[k] = v
+	}
+	return merged
+}
+
+// unknownTemplateVariables returns top-level fields referenced by the
+// template that are not in the allowed set
+// Fields inside {{range}} and {{with}} blocks are relative to a different
+// dot and are therefore not checked
+func unknownTemplateVariables(tree *parse.Tree, allowed map[string]bool) []string {
+	if tree == nil || tree.Root == nil {
+		return nil
+	}
+
+	found := make(map[string]bool)
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				walk(cmd)
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		case *parse.FieldNode:
+			if len(n.Ident) > 0 && !allowed[n.Ident[0]] {
+				found[n.Ident[0]] = true
+			}
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.Pipe)
+		case *parse.WithNode:
+			walk(n.Pipe)
+		}
+	}
+	walk(tree.Root)
+
+	unknown := make([]string, 0, len(found))
+	for name := range found {
+		unknown = append(unknown, name)
+	}
+	sort.Strings(unknown)
+	return unknown
+}
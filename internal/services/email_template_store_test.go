@@ -0,0 +1,281 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tranmh/gassigeher/internal/i18n"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestEmailTemplateStore_DefaultsAreValid ensures every embedded default passes validation
+func TestEmailTemplateStore_DefaultsAreValid(t *testing.T) {
+	store := NewEmailTemplateStore(nil, "https://example.com")
+
+	for _, lang := range i18n.SupportedLanguages() {
+		for _, def := range store.Definitions() {
+			t.Run(lang+"/"+def.Key, func(t *testing.T) {
+				if _, err := defaultEmailTemplates.ReadFile("email_templates/" + lang + "/" + def.Key + ".html"); err != nil {
+					t.Fatalf("Missing embedded template: %v", err)
+				}
+
+				body, err := store.DefaultBody(def.Key, lang)
+				if err != nil {
+					t.Fatalf("DefaultBody() failed: %v", err)
+				}
+
+				if !i18n.Has(lang, "email.subjects."+def.Key) {
+					t.Fatalf("Missing subject translation")
+				}
+				subject, _ := store.DefaultSubject(def.Key, lang)
+
+				if err := store.Validate(def.Key, lang, subject, body); err != nil {
+					t.Errorf("Default template should be valid, got: %v", err)
+				}
+			})
+		}
+	}
+}
+
+// TestEmailTemplateStore_Render tests rendering the default templates
+func TestEmailTemplateStore_Render(t *testing.T) {
+	store := NewEmailTemplateStore(nil, "https://example.com")
+
+	t.Run("booking confirmation", func(t *testing.T) {
+		subject, body, err := store.Render("booking_confirmation", "de", map[string]interface{}{
+			"Name":          "Anna",
+			"DogName":       "Rex",
+			"Date":          "2025-12-25",
+			"ScheduledTime": "09:00",
+		})
+		if err != nil {
+			t.Fatalf("Render() failed: %v", err)
+		}
+
+		if subject != "Buchungsbestätigung - Rex" {
+			t.Errorf("Unexpected subject: %s", subject)
+		}
+
+		for _, want := range []string{"Anna", "Rex", "2025-12-25", "09:00"} {
+			if !strings.Contains(body, want) {
+				t.Errorf("Body should contain %q", want)
+			}
+		}
+	})
+
+	t.Run("common variables", func(t *testing.T) {
+		_, body, err := store.Render("welcome", "de", map[string]interface{}{"Name": "Anna"})
+		if err != nil {
+			t.Fatalf("Render() failed: %v", err)
+		}
+
+		if !strings.Contains(body, "https://example.com") {
+			t.Error("Body should contain the base URL")
+		}
+	})
+
+	t.Run("escapes user input", func(t *testing.T) {
+		_, body, err := store.Render("welcome", "de", map[string]interface{}{"Name": "<script>alert(1)</script>"})
+		if err != nil {
+			t.Fatalf("Render() failed: %v", err)
+		}
+
+		if strings.Contains(body, "<script>") {
+			t.Error("Body should HTML-escape template data")
+		}
+	})
+
+	t.Run("english", func(t *testing.T) {
+		subject, body, err := store.Render("booking_confirmation", "en", map[string]interface{}{
+			"Name":          "Anna",
+			"DogName":       "Rex",
+			"Date":          "2025-12-25",
+			"ScheduledTime": "09:00",
+		})
+		if err != nil {
+			t.Fatalf("Render() failed: %v", err)
+		}
+
+		if subject != "Booking confirmation - Rex" {
+			t.Errorf("Unexpected subject: %s", subject)
+		}
+		if !strings.Contains(body, "Booking details") {
+			t.Error("Body should use the English template")
+		}
+	})
+
+	t.Run("reminder subject names the walk", func(t *testing.T) {
+		subject, _, err := store.Render("booking_reminder", "de", map[string]interface{}{
+			"Name":          "Anna",
+			"DogName":       "Rex",
+			"Date":          "2025-12-25",
+			"ScheduledTime": "09:00",
+		})
+		if err != nil {
+			t.Fatalf("Render() failed: %v", err)
+		}
+
+		if subject != "Erinnerung: Gassirunde mit Rex am 2025-12-25 um 09:00" {
+			t.Errorf("Unexpected subject: %s", subject)
+		}
+	})
+
+	t.Run("unsupported language falls back to German", func(t *testing.T) {
+		subject, _, err := store.Render("booking_confirmation", "fr", map[string]interface{}{"DogName": "Rex"})
+		if err != nil {
+			t.Fatalf("Render() failed: %v", err)
+		}
+
+		if subject != "Buchungsbestätigung - Rex" {
+			t.Errorf("Unexpected subject: %s", subject)
+		}
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		_, _, err := store.Render("does_not_exist", "de", nil)
+		if !errors.Is(err, ErrUnknownEmailTemplate) {
+			t.Errorf("Expected ErrUnknownEmailTemplate, got %v", err)
+		}
+	})
+}
+
+// TestEmailTemplateStore_Validate tests variable and syntax validation
+func TestEmailTemplateStore_Validate(t *testing.T) {
+	store := NewEmailTemplateStore(nil, "")
+
+	tests := []struct {
+		name    string
+		subject string
+		body    string
+		field   string
+	}{
+		{"valid", "Hallo {{.Name}}", "<p>{{.DogName}} am {{.Date}} um {{.ScheduledTime}} - {{.BaseURL}} {{.Year}}</p>", ""},
+		{"unknown variable in subject", "Hallo {{.Nmae}}", "<p>ok</p>", "subject"},
+		{"unknown variable in body", "Hallo", "<p>{{.Password}}</p>", "body"},
+		{"unknown variable in if", "Hallo", "{{if .Secret}}x{{end}}", "body"},
+		{"syntax error in subject", "Hallo {{.Name", "<p>ok</p>", "subject"},
+		{"syntax error in body", "Hallo", "<p>{{if .Name}}</p>", "body"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := store.Validate("booking_confirmation", "de", tt.subject, tt.body)
+
+			if tt.field == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+
+			var validationErr *models.ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Expected ValidationError, got %v", err)
+			}
+			if validationErr.Field != tt.field {
+				t.Errorf("Expected field %s, got %s", tt.field, validationErr.Field)
+			}
+		})
+	}
+}
+
+// TestEmailTemplateStore_Overrides tests saving, previewing and resetting overrides
+func TestEmailTemplateStore_Overrides(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	adminID := testutil.SeedTestUser(t, db, "admin@example.com", "Admin", "orange")
+	store := NewEmailTemplateStore(repository.NewEmailTemplateRepository(db), "https://example.com")
+
+	t.Run("save and render override", func(t *testing.T) {
+		err := store.Save("welcome", "de", "Hallo {{.Name}}!", "<p>Servus {{.Name}}</p>", adminID)
+		if err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+
+		subject, body, err := store.Render("welcome", "de", map[string]interface{}{"Name": "Anna"})
+		if err != nil {
+			t.Fatalf("Render() failed: %v", err)
+		}
+		if subject != "Hallo Anna!" || body != "<p>Servus Anna</p>" {
+			t.Errorf("Override not used: %q / %q", subject, body)
+		}
+
+		tmpl, err := store.Get("welcome", "de")
+		if err != nil {
+			t.Fatalf("Get() failed: %v", err)
+		}
+		if !tmpl.IsCustomized {
+			t.Error("Expected template to be marked as customized")
+		}
+		if tmpl.UpdatedBy == nil || *tmpl.UpdatedBy != adminID {
+			t.Error("Expected updated_by to be the admin")
+		}
+	})
+
+	t.Run("invalid override is rejected", func(t *testing.T) {
+		err := store.Save("welcome", "de", "Hallo", "<p>{{.Token}}</p>", adminID)
+		var validationErr *models.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("Expected ValidationError, got %v", err)
+		}
+	})
+
+	t.Run("preview with unsaved body", func(t *testing.T) {
+		body := "<p>Vorschau für {{.Name}}</p>"
+		preview, err := store.Preview("welcome", "de", nil, &body)
+		if err != nil {
+			t.Fatalf("Preview() failed: %v", err)
+		}
+		if preview.Subject != "Hallo Max Mustermann!" {
+			t.Errorf("Preview should keep the saved subject, got %q", preview.Subject)
+		}
+		if preview.Body != "<p>Vorschau für Max Mustermann</p>" {
+			t.Errorf("Unexpected preview body: %q", preview.Body)
+		}
+	})
+
+	t.Run("override only applies to its language", func(t *testing.T) {
+		subject, _, err := store.Render("welcome", "en", map[string]interface{}{"Name": "Anna"})
+		if err != nil {
+			t.Fatalf("Render() failed: %v", err)
+		}
+		if subject != "Let's go! Your account is activated" {
+			t.Errorf("Expected English default subject, got %q", subject)
+		}
+	})
+
+	t.Run("reset restores default", func(t *testing.T) {
+		if err := store.Reset("welcome", "de"); err != nil {
+			t.Fatalf("Reset() failed: %v", err)
+		}
+
+		tmpl, err := store.Get("welcome", "de")
+		if err != nil {
+			t.Fatalf("Get() failed: %v", err)
+		}
+		if tmpl.IsCustomized {
+			t.Error("Expected template to use the default after reset")
+		}
+
+		defaultBody, _ := store.DefaultBody("welcome", "de")
+		if tmpl.Body != defaultBody {
+			t.Error("Expected default body after reset")
+		}
+	})
+
+	t.Run("broken override falls back to default", func(t *testing.T) {
+		// Bypass validation to simulate an override that fails at render time
+		db.Exec(`INSERT INTO email_templates (template_key, language, subject, body, updated_at) VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)`,
+			"booking_reminder", "de", "Erinnerung", "{{template \"missing\"}}")
+
+		subject, _, err := store.Render("booking_reminder", "de", map[string]interface{}{"Name": "Anna", "DogName": "Rex"})
+		if err != nil {
+			t.Fatalf("Render() should fall back to the default, got: %v", err)
+		}
+		if !strings.Contains(subject, "Rex") {
+			t.Errorf("Expected default subject, got %q", subject)
+		}
+	})
+}
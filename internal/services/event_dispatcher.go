@@ -0,0 +1,178 @@
+package services
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+)
+
+// Event dispatcher settings
+const (
+	eventBatchSize    = 100
+	eventPollInterval = 10 * time.Second // Fallback, committed events wake up the dispatcher immediately
+	eventClaimLease   = 5 * time.Minute  // Events of an instance that stopped while dispatching are taken over afterwards
+)
+
+// eventBackoff is the wait before each retry of an event with a failed subscriber, the event is given up after the last one
+var eventBackoff = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	30 * time.Minute,
+	2 * time.Hour,
+	12 * time.Hour,
+}
+
+// EventHandler handles a domain event
+type EventHandler func(event *models.DomainEvent) error
+
+type eventSubscriber struct {
+	name       string
+	eventTypes map[string]bool // nil = all events
+	handle     EventHandler
+}
+
+// EventDispatcher delivers the events of the outbox to in-process subscribers
+// Events are delivered at least once: every subscriber that handled an event is recorded, and the event is
+// marked as dispatched once all of them did. A failing subscriber does not block the others or later events,
+// the event is retried with backoff for the subscribers that failed only. Events are first delivered in the
+// order they were recorded, a retried event is delivered after later events, so subscribers must not rely on
+// the order (e.g. booking.cancelled may arrive before the retried booking.created of the same booking).
+// With several instances each event is claimed by one of them, events of a stopped instance are taken over
+// once its claim is stale.
+type EventDispatcher struct {
+	events      *repository.DomainEventRepository
+	subscribers []*eventSubscriber
+	instance    string           // Claims events for this dispatcher
+	now         func() time.Time // Replaced in tests
+	stopChan    chan bool
+}
+
+// NewEventDispatcher creates a dispatcher without subscribers
+func NewEventDispatcher(db *sql.DB) *EventDispatcher {
+	return &EventDispatcher{
+		events:   repository.NewDomainEventRepository(db),
+		instance: instanceName(),
+		now:      time.Now,
+		stopChan: make(chan bool),
+	}
+}
+
+// instanceName names this process in event and webhook claims, the random part tells restarts apart
+func instanceName() string {
+	hostname, _ := os.Hostname()
+	b := make([]byte, 4)
+	rand.Read(b)
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), hex.EncodeToString(b))
+}
+
+// Subscribe registers a handler for the given event types, or for all events if none are given
+func (d *EventDispatcher) Subscribe(name string, handle EventHandler, eventTypes ...string) {
+	subscriber := &eventSubscriber{name: name, handle: handle}
+	if len(eventTypes) > 0 {
+		subscriber.eventTypes = map[string]bool{}
+		for _, eventType := range eventTypes {
+			subscriber.eventTypes[eventType] = true
+		}
+	}
+	d.subscribers = append(d.subscribers, subscriber)
+}
+
+// DispatchPending delivers all events that are due and returns the number of events all subscribers received
+func (d *EventDispatcher) DispatchPending() (int, error) {
+	dispatched := 0
+	for {
+		now := d.now()
+		events, err := d.events.ClaimUndispatched(d.instance, now, now.Add(-eventClaimLease), eventBatchSize)
+		if err != nil {
+			return dispatched, err
+		}
+
+		for _, event := range events {
+			ok, err := d.dispatch(event)
+			if err != nil {
+				return dispatched, err
+			}
+
+			if !ok && event.Attempts < len(eventBackoff) {
+				if err := d.events.Retry(event.ID, event.Attempts+1, d.now().Add(eventBackoff[event.Attempts])); err != nil {
+					return dispatched, err
+				}
+				continue
+			}
+			if !ok {
+				log.Printf("Giving up %s event %d after %d attempts", event.EventType, event.ID, event.Attempts+1)
+			}
+			if err := d.events.MarkDispatched(event.ID, d.now()); err != nil {
+				return dispatched, err
+			}
+			if ok {
+				dispatched++
+			}
+		}
+
+		if len(events) < eventBatchSize {
+			return dispatched, nil
+		}
+	}
+}
+
+// dispatch runs the subscribers that did not handle the event yet, it returns false if one of them failed
+func (d *EventDispatcher) dispatch(event *models.DomainEvent) (bool, error) {
+	delivered, err := d.events.FindDeliveredSubscribers(event.ID)
+	if err != nil {
+		return false, err
+	}
+
+	ok := true
+	for _, subscriber := range d.subscribers {
+		if (subscriber.eventTypes != nil && !subscriber.eventTypes[event.EventType]) || delivered[subscriber.name] {
+			continue
+		}
+		if err := subscriber.handle(event); err != nil {
+			log.Printf("Event subscriber %s failed for %s event %d: %v", subscriber.name, event.EventType, event.ID, err)
+			ok = false
+			continue
+		}
+		if err := d.events.MarkDelivered(event.ID, subscriber.name, d.now()); err != nil {
+			return false, err
+		}
+	}
+	return ok, nil
+}
+
+// Start starts the dispatcher worker
+func (d *EventDispatcher) Start() {
+	log.Println("Starting event dispatcher...")
+	go d.run()
+}
+
+// Stop stops the dispatcher worker
+func (d *EventDispatcher) Stop() {
+	log.Println("Stopping event dispatcher...")
+	close(d.stopChan)
+}
+
+func (d *EventDispatcher) run() {
+	ticker := time.NewTicker(eventPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.DispatchPending(); err != nil {
+			log.Printf("Error dispatching domain events: %v", err)
+		}
+
+		select {
+		case <-eventsRecorded:
+		case <-ticker.C:
+		case <-d.stopChan:
+			return
+		}
+	}
+}
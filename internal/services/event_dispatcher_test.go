@@ -0,0 +1,295 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestOutboxService_Transact tests that events are only stored together with the state change
+func TestOutboxService_Transact(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	userID := testutil.SeedTestUser(t, db, "anna@example.com", "Anna", "green")
+	dogID := testutil.SeedTestDog(t, db, "Bella", "Labrador", "green")
+	bookingID := testutil.SeedTestBooking(t, db, userID, dogID, "2030-01-10", "09:00", "scheduled")
+	bookingRepo := repository.NewBookingRepository(db)
+	outbox := NewOutboxService(db)
+
+	cancel := func(fail error) error {
+		return outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+			if err := bookingRepo.WithTx(tx).Cancel(bookingID, nil); err != nil {
+				return nil, err
+			}
+			event, err := models.NewBookingEvent(models.EventBookingCancelled, &userID, &models.BookingEventData{BookingID: bookingID})
+			if fail != nil {
+				return nil, fail
+			}
+			return []*models.DomainEvent{event}, err
+		})
+	}
+
+	failure := errors.New("validation failed")
+	if err := cancel(failure); err != failure {
+		t.Fatalf("Expected the error of fn unchanged, got %v", err)
+	}
+	if booking, _ := bookingRepo.FindByID(bookingID); booking.Status != "scheduled" || testutil.CountRows(t, db, "domain_events") != 0 {
+		t.Fatalf("Expected neither change nor event after an error, got status %s", booking.Status)
+	}
+
+	if err := cancel(nil); err != nil {
+		t.Fatalf("Transact() failed: %v", err)
+	}
+	if booking, _ := bookingRepo.FindByID(bookingID); booking.Status != "cancelled" || testutil.CountRows(t, db, "domain_events") != 1 {
+		t.Errorf("Expected cancelled booking and one event, got status %s", booking.Status)
+	}
+}
+
+// TestEventDispatcher_DispatchPending tests routing events to subscribers and marking them as dispatched
+func TestEventDispatcher_DispatchPending(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	outbox := NewOutboxService(db)
+	for _, eventType := range []string{models.EventBookingCreated, models.EventUserActivated, models.EventBookingCancelled} {
+		outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+			event, err := models.NewDomainEvent(eventType, models.AggregateBooking, 1, nil, map[string]int{"booking_id": 1})
+			return []*models.DomainEvent{event}, err
+		})
+	}
+
+	dispatcher := NewEventDispatcher(db)
+	var bookings, all []string
+	dispatcher.Subscribe("bookings", func(event *models.DomainEvent) error {
+		bookings = append(bookings, event.EventType)
+		return nil
+	}, models.EventBookingCreated, models.EventBookingCancelled)
+	dispatcher.Subscribe("all", func(event *models.DomainEvent) error {
+		all = append(all, event.EventType)
+		return nil
+	})
+
+	count, err := dispatcher.DispatchPending()
+	if err != nil || count != 3 {
+		t.Fatalf("Expected 3 dispatched events, got %d (%v)", count, err)
+	}
+	if len(bookings) != 2 || bookings[0] != models.EventBookingCreated || bookings[1] != models.EventBookingCancelled {
+		t.Errorf("Expected booking events in order, got %v", bookings)
+	}
+	if len(all) != 3 {
+		t.Errorf("Expected all events, got %v", all)
+	}
+
+	if count, _ := dispatcher.DispatchPending(); count != 0 {
+		t.Errorf("Expected events to be dispatched only once, got %d", count)
+	}
+}
+
+// TestEventDispatcher_Retry tests that an event with a failed subscriber is retried for that subscriber only
+func TestEventDispatcher_Retry(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	outbox := NewOutboxService(db)
+	outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		event, err := models.NewDomainEvent(models.EventBookingCreated, models.AggregateBooking, 1, nil, map[string]int{"booking_id": 1})
+		return []*models.DomainEvent{event}, err
+	})
+
+	now := time.Now()
+	dispatcher := NewEventDispatcher(db)
+	dispatcher.now = func() time.Time { return now }
+	failures, flaky, steady := 1, 0, 0
+	dispatcher.Subscribe("flaky", func(event *models.DomainEvent) error {
+		flaky++
+		if failures > 0 {
+			failures--
+			return errors.New("smtp unavailable")
+		}
+		return nil
+	})
+	dispatcher.Subscribe("steady", func(event *models.DomainEvent) error {
+		steady++
+		return nil
+	})
+
+	if count, err := dispatcher.DispatchPending(); err != nil || count != 0 {
+		t.Fatalf("Expected the event to stay undispatched, got %d (%v)", count, err)
+	}
+	if count, _ := dispatcher.DispatchPending(); count != 0 || flaky != 1 {
+		t.Errorf("Expected no retry before the backoff, got %d dispatched and %d calls", count, flaky)
+	}
+
+	now = now.Add(eventBackoff[0])
+	if count, err := dispatcher.DispatchPending(); err != nil || count != 1 {
+		t.Fatalf("Expected the retry to dispatch the event, got %d (%v)", count, err)
+	}
+	if flaky != 2 || steady != 1 {
+		t.Errorf("Expected only the failed subscriber to be retried, got flaky %d and steady %d calls", flaky, steady)
+	}
+
+	t.Run("gives up after the last retry", func(t *testing.T) {
+		outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+			event, err := models.NewDomainEvent(models.EventBookingCreated, models.AggregateBooking, 2, nil, map[string]int{"booking_id": 2})
+			return []*models.DomainEvent{event}, err
+		})
+		failures = len(eventBackoff) + 1
+		for range eventBackoff {
+			dispatcher.DispatchPending()
+			now = now.Add(12 * time.Hour)
+		}
+		dispatcher.DispatchPending()
+
+		if pending, _ := dispatcher.events.FindUndispatched(10); len(pending) != 0 {
+			t.Errorf("Expected the event to be given up, got %d pending", len(pending))
+		}
+	})
+}
+
+// TestEventDispatcher_Claims tests that an event is dispatched by one instance only
+func TestEventDispatcher_Claims(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	outbox := NewOutboxService(db)
+	outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		event, err := models.NewDomainEvent(models.EventBookingCreated, models.AggregateBooking, 1, nil, map[string]int{"booking_id": 1})
+		return []*models.DomainEvent{event}, err
+	})
+
+	now := time.Now()
+	events := repository.NewDomainEventRepository(db)
+	if claimed, err := events.ClaimUndispatched("stopped-instance", now, now.Add(-eventClaimLease), 10); err != nil || len(claimed) != 1 {
+		t.Fatalf("Expected the event to be claimed, got %d (%v)", len(claimed), err)
+	}
+
+	calls := 0
+	dispatcher := NewEventDispatcher(db)
+	dispatcher.now = func() time.Time { return now }
+	dispatcher.Subscribe("count", func(event *models.DomainEvent) error {
+		calls++
+		return nil
+	})
+
+	if count, _ := dispatcher.DispatchPending(); count != 0 || calls != 0 {
+		t.Errorf("Expected an event claimed by another instance to be skipped, got %d", count)
+	}
+
+	now = now.Add(eventClaimLease + time.Minute)
+	if count, _ := dispatcher.DispatchPending(); count != 1 || calls != 1 {
+		t.Errorf("Expected a stale claim to be taken over, got %d", count)
+	}
+}
+
+// TestEventSubscribers tests the notification, activity and activity log subscribers of a created booking
+func TestEventSubscribers(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	userID := testutil.SeedTestUser(t, db, "anna@example.com", "Anna", "green")
+	adminID := testutil.SeedTestUser(t, db, "admin@example.com", "Admin", "orange")
+	db.Exec("UPDATE users SET is_admin = 1 WHERE id = ?", adminID)
+	db.Exec("UPDATE users SET last_activity_at = ? WHERE id = ?", time.Now().AddDate(0, -6, 0), userID)
+
+	provider := &recordingEmailProvider{}
+	email := &EmailService{provider: provider, templates: NewEmailTemplateStore(nil, "")}
+	userRepo := repository.NewUserRepository(db)
+	dispatcher := NewEventDispatcher(db)
+	RegisterEventSubscribers(dispatcher, userRepo, repository.NewActivityLogRepository(db), NewNotificationServiceFromConfig(db, &config.Config{}, email), NewWebhookService(db))
+
+	booking := &models.Booking{ID: 9, UserID: userID, DogID: 1, Date: "2030-01-10", ScheduledTime: "07:00", Status: "scheduled",
+		ApprovalStatus: "pending", RequiresApproval: true}
+	NewOutboxService(db).Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		event, err := models.NewBookingEvent(models.EventBookingCreated, &userID, models.NewBookingEventData(booking, "Bella", "Anna"))
+		return []*models.DomainEvent{event}, err
+	})
+
+	if _, err := dispatcher.DispatchPending(); err != nil {
+		t.Fatalf("DispatchPending() failed: %v", err)
+	}
+
+	if provider.sent != 1 || provider.to != "anna@example.com" {
+		t.Errorf("Expected confirmation email to the user, got %d (last to %s)", provider.sent, provider.to)
+	}
+
+	user, _ := userRepo.FindByID(userID)
+	if time.Since(user.LastActivityAt) > time.Minute {
+		t.Errorf("Expected last activity to be updated, got %v", user.LastActivityAt)
+	}
+
+	var adminNotifications int
+	db.QueryRow("SELECT COUNT(*) FROM notifications WHERE user_id = ? AND type = ?", adminID, models.NotificationTypeAdminBookingPending).Scan(&adminNotifications)
+	if adminNotifications != 1 {
+		t.Errorf("Expected admin notification about the pending booking, got %d", adminNotifications)
+	}
+
+	entries, total, err := repository.NewActivityLogRepository(db).Find(&models.ActivityFilter{Limit: 10})
+	if err != nil || total != 1 || entries[0].Type != models.EventBookingCreated || entries[0].UserName != "Anna" {
+		t.Errorf("Expected booking.created activity entry of Anna, got %+v (%v)", entries, err)
+	}
+}
+
+// TestEventSubscribers_DeactivationReason tests that the inactivity reason is in the language of the user
+func TestEventSubscribers_DeactivationReason(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	userID := testutil.SeedTestUser(t, db, "anna@example.com", "Anna", "green")
+	db.Exec("UPDATE users SET preferred_language = 'en' WHERE id = ?", userID)
+
+	provider := &recordingEmailProvider{}
+	userRepo := repository.NewUserRepository(db)
+	email := &EmailService{provider: provider, templates: NewEmailTemplateStore(nil, ""), userRepo: userRepo}
+	dispatcher := NewEventDispatcher(db)
+	RegisterEventSubscribers(dispatcher, userRepo, repository.NewActivityLogRepository(db), NewNotificationServiceFromConfig(db, &config.Config{}, email), NewWebhookService(db))
+
+	NewOutboxService(db).Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		event, err := models.NewDomainEvent(models.EventUserDeactivated, "user", userID, nil, models.UserDeactivatedEventData{
+			UserID: userID, Name: "Anna", Reason: models.DeactivationReasonInactivity, InactiveDays: 365,
+		})
+		return []*models.DomainEvent{event}, err
+	})
+
+	if _, err := dispatcher.DispatchPending(); err != nil {
+		t.Fatalf("DispatchPending() failed: %v", err)
+	}
+
+	if provider.sent != 1 || !strings.Contains(provider.body, "No activity for 365 days") {
+		t.Errorf("Expected the English reason in the email, got %d emails: %s", provider.sent, provider.body)
+	}
+}
+
+// TestEventSubscribers_RetryRecordsOnce tests that retrying a failed notification does not repeat the in-app notifications
+func TestEventSubscribers_RetryRecordsOnce(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	userID := testutil.SeedTestUser(t, db, "anna@example.com", "Anna", "green")
+	adminID := testutil.SeedTestUser(t, db, "admin@example.com", "Admin", "orange")
+	db.Exec("UPDATE users SET is_admin = 1 WHERE id = ?", adminID)
+
+	// Email is the only channel, so the notification fails until the mailbox is back
+	provider := &rejectingEmailProvider{reject: "anna@example.com"}
+	userRepo := repository.NewUserRepository(db)
+	email := &EmailService{provider: provider, templates: NewEmailTemplateStore(nil, "")}
+	now := time.Now()
+	dispatcher := NewEventDispatcher(db)
+	dispatcher.now = func() time.Time { return now }
+	RegisterEventSubscribers(dispatcher, userRepo, repository.NewActivityLogRepository(db), NewNotificationServiceFromConfig(db, &config.Config{}, email), NewWebhookService(db))
+
+	booking := &models.Booking{ID: 9, UserID: userID, DogID: 1, Date: "2030-01-10", ScheduledTime: "07:00", Status: "scheduled",
+		ApprovalStatus: "pending", RequiresApproval: true}
+	NewOutboxService(db).Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		event, err := models.NewBookingEvent(models.EventBookingCreated, &userID, models.NewBookingEventData(booking, "Bella", "Anna"))
+		return []*models.DomainEvent{event}, err
+	})
+
+	for _, backoff := range eventBackoff[:3] {
+		if count, _ := dispatcher.DispatchPending(); count != 0 {
+			t.Fatalf("Expected the event to be retried, got %d dispatched", count)
+		}
+		now = now.Add(backoff)
+	}
+
+	for _, id := range []int{userID, adminID} {
+		var count int
+		db.QueryRow("SELECT COUNT(*) FROM notifications WHERE user_id = ?", id).Scan(&count)
+		if count != 1 {
+			t.Errorf("Expected 1 notification for user %d, got %d", id, count)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"database/sql"
+	"log"
+
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/i18n"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+)
+
+// NewEventDispatcherFromConfig creates the dispatcher with all subscribers of the application
+func NewEventDispatcherFromConfig(db *sql.DB, cfg *config.Config) *EventDispatcher {
+	emailService, err := NewEmailServiceFromConfig(db, cfg)
+	if err != nil {
+		log.Printf("Warning: Email service not available for domain events: %v", err)
+	}
+
+	dispatcher := NewEventDispatcher(db)
+	RegisterEventSubscribers(dispatcher, repository.NewUserRepository(db), repository.NewActivityLogRepository(db),
+		NewNotificationServiceFromConfig(db, cfg, emailService), NewWebhookService(db))
+	return dispatcher
+}
+
+// RegisterEventSubscribers registers the activity tracking, activity log, notification and webhook subscribers
+func RegisterEventSubscribers(dispatcher *EventDispatcher, userRepo *repository.UserRepository, activityLog *repository.ActivityLogRepository,
+	notifier *NotificationService, webhooks *WebhookService) {
+	dispatcher.Subscribe("activity", func(event *models.DomainEvent) error {
+		if event.ActorID == nil {
+			return nil
+		}
+		return userRepo.UpdateLastActivity(*event.ActorID)
+	}, models.EventBookingCreated, models.EventBookingCancelled, models.EventBookingMoved)
+
+	// Every event is an entry of the admin activity feed
+	dispatcher.Subscribe("activity_log", func(event *models.DomainEvent) error {
+		entry, err := models.NewActivityEntry(event)
+		if err != nil {
+			return err
+		}
+		return activityLog.Create(entry)
+	})
+
+	notifications := &notificationSubscriber{notifier: notifier, userRepo: userRepo}
+	dispatcher.Subscribe("notifications", notifications.handle,
+		models.EventBookingCreated, models.EventBookingCancelled, models.EventBookingMoved, models.EventBookingApproved,
+		models.EventBookingRejected, models.EventUserDeactivated, models.EventUserActivated)
+
+	dispatcher.Subscribe("webhooks", webhooks.HandleDomainEvent,
+		models.EventBookingCreated, models.EventBookingCancelled, models.EventBookingRejected,
+		models.EventDogAvailabilityChanged, models.EventUserDeactivated)
+}
+
+// notificationSubscriber notifies users (and admins) about booking and account events
+type notificationSubscriber struct {
+	notifier *NotificationService
+	userRepo *repository.UserRepository
+}
+
+func (s *notificationSubscriber) handle(event *models.DomainEvent) error {
+	// A retried event must not repeat the in-app notifications
+	notifier := s.notifier.ForEvent(event.ID)
+
+	switch event.EventType {
+	case models.EventUserDeactivated:
+		var data models.UserDeactivatedEventData
+		if err := event.Decode(&data); err != nil {
+			return err
+		}
+		return s.notifyUser(data.UserID, func(email, lang string) error {
+			reason := data.Reason
+			if reason == models.DeactivationReasonInactivity {
+				reason = i18n.T(lang, "activity.values.inactive_days", data.InactiveDays)
+			}
+			return notifier.SendAccountDeactivated(email, data.Name, reason)
+		})
+
+	case models.EventUserActivated:
+		var data models.UserActivatedEventData
+		if err := event.Decode(&data); err != nil {
+			return err
+		}
+		return s.notifyUser(data.UserID, func(email, _ string) error {
+			return notifier.SendAccountReactivated(email, data.Name, data.Message)
+		})
+	}
+
+	var data models.BookingEventData
+	if err := event.Decode(&data); err != nil {
+		return err
+	}
+
+	if event.EventType == models.EventBookingCreated && data.RequiresApproval {
+		if err := notifier.NotifyAdmins(models.NotificationTypeAdminBookingPending, data.UserName, data.DogName, data.Date, data.ScheduledTime); err != nil {
+			log.Printf("Failed to notify admins about booking %d: %v", data.BookingID, err)
+		}
+	}
+
+	return s.notifyUser(data.UserID, func(email, _ string) error {
+		switch event.EventType {
+		case models.EventBookingCreated:
+			return notifier.SendBookingConfirmation(email, data.UserName, data.DogName, data.Date, data.ScheduledTime)
+		case models.EventBookingCancelled:
+			if data.CancelledBy != models.CancelledByUser && data.CancelledBy != models.CancelledByDogDeleted && data.Reason != nil {
+				return notifier.SendAdminCancellation(email, data.UserName, data.DogName, data.Date, data.ScheduledTime, *data.Reason)
+			}
+			return notifier.SendBookingCancellation(email, data.UserName, data.DogName, data.Date, data.ScheduledTime)
+		case models.EventBookingMoved:
+			return notifier.SendBookingMoved(email, data.UserName, data.DogName, data.PreviousDate, data.PreviousScheduledTime,
+				data.Date, data.ScheduledTime, stringValue(data.Reason))
+		case models.EventBookingApproved:
+			return notifier.SendBookingApproved(email, data.UserName, data.DogName, data.Date, data.ScheduledTime)
+		case models.EventBookingRejected:
+			return notifier.SendBookingRejected(email, data.UserName, data.DogName, data.Date, data.ScheduledTime, stringValue(data.Reason))
+		}
+		return nil
+	})
+}
+
+// notifyUser calls send with the current email address and the language of the user, users without email are skipped
+func (s *notificationSubscriber) notifyUser(userID int, send func(email, lang string) error) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if user == nil || user.Email == nil || *user.Email == "" {
+		return nil
+	}
+	return send(*user.Email, i18n.Normalize(user.PreferredLanguage))
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+)
+
+// invitationCodeLength is the number of characters of a code, about 59 bits of randomness
+const invitationCodeLength = 12
+
+// ErrInvalidInvitation is returned for unknown, revoked, used up and expired codes and codes of another email address
+var ErrInvalidInvitation = errors.New("invalid or expired invitation")
+
+// InvitationService handles the registration mode and the invitation codes admins create
+type InvitationService struct {
+	repo         *repository.InvitationRepository
+	settingsRepo *repository.SettingsRepository
+	now          func() time.Time
+}
+
+// NewInvitationService creates a new invitation service
+func NewInvitationService(db *sql.DB) *InvitationService {
+	return &InvitationService{
+		repo:         repository.NewInvitationRepository(db),
+		settingsRepo: repository.NewSettingsRepository(db),
+		now:          time.Now,
+	}
+}
+
+// RegistrationMode returns the registration mode set by admins, open if none is set
+func (s *InvitationService) RegistrationMode() string {
+	setting, err := s.settingsRepo.Get(models.SettingRegistrationMode)
+	if err != nil || setting == nil || !models.IsValidRegistrationMode(setting.Value) {
+		return models.RegistrationModeOpen
+	}
+	return setting.Value
+}
+
+// Create stores a new invitation with a random code
+func (s *InvitationService) Create(req *models.CreateInvitationRequest, createdBy int) (*models.Invitation, error) {
+	code, err := newInvitationCode()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	invitation := &models.Invitation{
+		Code:            code,
+		Email:           req.Email,
+		MaxUses:         req.MaxUses,
+		ExperienceLevel: req.ExperienceLevel,
+		Tag:             req.Tag,
+		ExpiresAt:       now.AddDate(0, 0, req.ExpiresInDays),
+		CreatedBy:       &createdBy,
+		CreatedAt:       now,
+	}
+	if err := s.repo.Create(invitation); err != nil {
+		return nil, err
+	}
+	invitation.Status = invitation.StatusAt(now)
+	return invitation, nil
+}
+
+// List returns all invitations with their current status, newest first
+func (s *InvitationService) List() ([]*models.Invitation, error) {
+	invitations, err := s.repo.List()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	for _, invitation := range invitations {
+		invitation.Status = invitation.StatusAt(now)
+	}
+	return invitations, nil
+}
+
+// Find returns the invitation, nil if there is none
+func (s *InvitationService) Find(id int) (*models.Invitation, error) {
+	return s.repo.FindByID(id)
+}
+
+// Revoke makes the invitation unusable, registrations already done with it are kept
+func (s *InvitationService) Revoke(id int) error {
+	return s.repo.Revoke(id, s.now())
+}
+
+// Check returns the invitation of the code if the email address can register with it
+// The invitation is redeemed with Redeem in the transaction that creates the user.
+func (s *InvitationService) Check(code, email string) (*models.Invitation, error) {
+	invitation, err := s.repo.FindByCode(models.NormalizeInvitationCode(code))
+	if err != nil {
+		return nil, err
+	}
+	if invitation == nil || invitation.StatusAt(s.now()) != models.InvitationStatusActive || !invitation.AcceptsEmail(email) {
+		return nil, ErrInvalidInvitation
+	}
+	return invitation, nil
+}
+
+// Redeem counts a registration with the invitation in the transaction
+// It returns ErrInvalidInvitation if another registration used it up in the meantime.
+func (s *InvitationService) Redeem(tx *sql.Tx, invitation *models.Invitation) error {
+	ok, err := s.repo.WithTx(tx).Redeem(invitation.ID, s.now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidInvitation
+	}
+	return nil
+}
+
+// newInvitationCode returns a random code of characters that are easy to read and type
+func newInvitationCode() (string, error) {
+	alphabet := models.InvitationCodeAlphabet
+	code := make([]byte, invitationCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate invitation code: %w", err)
+		}
+		code[i] = alphabet[n.Int64()]
+	}
+	return string(code), nil
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestInvitationService tests the registration mode and checking and redeeming invitation codes
+func TestInvitationService(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	service := NewInvitationService(db)
+	settingsRepo := repository.NewSettingsRepository(db)
+
+	now := time.Now()
+	service.now = func() time.Time { return now }
+
+	adminID := testutil.SeedTestUser(t, db, "admin@example.com", "Admin", "orange")
+
+	if mode := service.RegistrationMode(); mode != models.RegistrationModeOpen {
+		t.Errorf("Expected the open registration by default, got %s", mode)
+	}
+	settingsRepo.Update(models.SettingRegistrationMode, models.RegistrationModeInviteOnly)
+	if mode := service.RegistrationMode(); mode != models.RegistrationModeInviteOnly {
+		t.Errorf("Expected invite_only, got %s", mode)
+	}
+
+	create := func(req models.CreateInvitationRequest) *models.Invitation {
+		t.Helper()
+		if err := req.Validate(); err != nil {
+			t.Fatalf("Validate() failed: %v", err)
+		}
+		invitation, err := service.Create(&req, adminID)
+		if err != nil {
+			t.Fatalf("Create() failed: %v", err)
+		}
+		return invitation
+	}
+	redeem := func(invitation *models.Invitation) error {
+		tx, err := db.Begin()
+		if err != nil {
+			t.Fatalf("Begin() failed: %v", err)
+		}
+		defer tx.Commit()
+		return service.Redeem(tx, invitation)
+	}
+
+	t.Run("code", func(t *testing.T) {
+		invitation := create(models.CreateInvitationRequest{})
+		if len(invitation.Code) != invitationCodeLength || strings.Trim(invitation.Code, models.InvitationCodeAlphabet) != "" {
+			t.Errorf("Unexpected code %q", invitation.Code)
+		}
+		if invitation.Status != models.InvitationStatusActive || invitation.ExperienceLevel != "green" || invitation.MaxUses != 1 {
+			t.Errorf("Unexpected defaults %+v", invitation)
+		}
+		if !invitation.ExpiresAt.Equal(now.AddDate(0, 0, models.DefaultInvitationDays)) {
+			t.Errorf("Expected the invitation to expire in %d days, got %v", models.DefaultInvitationDays, invitation.ExpiresAt)
+		}
+
+		typed := strings.ToLower(invitation.Code[:4] + "-" + invitation.Code[4:8] + " " + invitation.Code[8:])
+		found, err := service.Check(typed, "anyone@example.com")
+		if err != nil || found.ID != invitation.ID {
+			t.Fatalf("Check(%q) = %+v, %v", typed, found, err)
+		}
+		if _, err := service.Check("UNKNOWNCODE1", "anyone@example.com"); !errors.Is(err, ErrInvalidInvitation) {
+			t.Errorf("Expected ErrInvalidInvitation for an unknown code, got %v", err)
+		}
+	})
+
+	t.Run("single use for an email address", func(t *testing.T) {
+		email := "Invited@Example.com"
+		invitation := create(models.CreateInvitationRequest{Email: &email, ExperienceLevel: "blue"})
+
+		if _, err := service.Check(invitation.Code, "other@example.com"); !errors.Is(err, ErrInvalidInvitation) {
+			t.Errorf("Expected another address to be rejected, got %v", err)
+		}
+		found, err := service.Check(invitation.Code, "invited@example.com")
+		if err != nil {
+			t.Fatalf("Check() failed: %v", err)
+		}
+		if err := redeem(found); err != nil {
+			t.Fatalf("Redeem() failed: %v", err)
+		}
+		if err := redeem(found); !errors.Is(err, ErrInvalidInvitation) {
+			t.Errorf("Expected the second redemption to fail, got %v", err)
+		}
+		if _, err := service.Check(invitation.Code, "invited@example.com"); !errors.Is(err, ErrInvalidInvitation) {
+			t.Errorf("Expected the used invitation to be rejected, got %v", err)
+		}
+	})
+
+	t.Run("expired and revoked", func(t *testing.T) {
+		expiring := create(models.CreateInvitationRequest{MaxUses: 5, ExpiresInDays: 1})
+		revoked := create(models.CreateInvitationRequest{MaxUses: 5})
+		if err := service.Revoke(revoked.ID); err != nil {
+			t.Fatalf("Revoke() failed: %v", err)
+		}
+
+		later := now.AddDate(0, 0, 1)
+		service.now = func() time.Time { return later }
+		defer func() { service.now = func() time.Time { return now } }()
+
+		for _, invitation := range []*models.Invitation{expiring, revoked} {
+			if _, err := service.Check(invitation.Code, "user@example.com"); !errors.Is(err, ErrInvalidInvitation) {
+				t.Errorf("Expected invitation %d to be rejected, got %v", invitation.ID, err)
+			}
+		}
+
+		invitations, err := service.List()
+		if err != nil {
+			t.Fatalf("List() failed: %v", err)
+		}
+		statuses := map[int]string{}
+		for _, invitation := range invitations {
+			statuses[invitation.ID] = invitation.Status
+		}
+		if statuses[expiring.ID] != models.InvitationStatusExpired || statuses[revoked.ID] != models.InvitationStatusRevoked {
+			t.Errorf("Unexpected statuses %v", statuses)
+		}
+	})
+}
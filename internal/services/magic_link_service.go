@@ -0,0 +1,133 @@
+package services
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+)
+
+const (
+	// MagicLinkTTL is how long a login link can be used
+	MagicLinkTTL = 15 * time.Minute
+	// magicLinkLimit is the number of links sent to an account within MagicLinkTTL
+	magicLinkLimit = 3
+)
+
+var (
+	// ErrInvalidMagicLink is returned for unknown, used and expired links
+	ErrInvalidMagicLink = errors.New("invalid or expired magic link")
+	// ErrMagicLinkOtherDevice is returned when the link is opened in another browser than it was requested in
+	ErrMagicLinkOtherDevice = errors.New("magic link requested on another device")
+)
+
+// MagicLinkService handles the passwordless login with a link sent by email
+// The tokens come from AuthService.GenerateToken and are stored as SHA-256 hashes.
+type MagicLinkService struct {
+	repo         *repository.MagicLinkRepository
+	settingsRepo *repository.SettingsRepository
+	authService  *AuthService
+	now          func() time.Time
+}
+
+// NewMagicLinkService creates a new magic link service
+func NewMagicLinkService(db *sql.DB, cfg *config.Config) *MagicLinkService {
+	return &MagicLinkService{
+		repo:         repository.NewMagicLinkRepository(db),
+		settingsRepo: repository.NewSettingsRepository(db),
+		authService:  NewAuthService(cfg.JWTSecret, cfg.GetAccessTokenMinutes()),
+		now:          time.Now,
+	}
+}
+
+// Enabled returns whether admins turned on the magic link login
+func (s *MagicLinkService) Enabled() bool {
+	setting, err := s.settingsRepo.Get(models.SettingMagicLinkLogin)
+	return err == nil && setting != nil && setting.Value == "true"
+}
+
+// sameDevice returns whether links only work in the browser that requested them, the default
+func (s *MagicLinkService) sameDevice() bool {
+	setting, err := s.settingsRepo.Get(models.SettingMagicLinkSameDevice)
+	return err != nil || setting == nil || setting.Value != "false"
+}
+
+// NewDeviceToken generates the token that binds a link to the requesting browser
+// It is returned for every request, also when no link is sent, so responses do not reveal accounts.
+func (s *MagicLinkService) NewDeviceToken() (string, error) {
+	return s.authService.GenerateToken()
+}
+
+// Create stores a new link for the user and returns its token
+// The token is empty if the user requested too many links, the request is then ignored.
+func (s *MagicLinkService) Create(userID int, deviceToken string) (string, error) {
+	now := s.now()
+	count, err := s.repo.CountSince(userID, now.Add(-MagicLinkTTL))
+	if err != nil {
+		return "", err
+	}
+	if count >= magicLinkLimit {
+		return "", nil
+	}
+
+	token, err := s.authService.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+	err = s.repo.Create(&models.MagicLink{
+		UserID:     userID,
+		TokenHash:  hashMagicLinkToken(token),
+		DeviceHash: hashMagicLinkToken(deviceToken),
+		ExpiresAt:  now.Add(MagicLinkTTL),
+		CreatedAt:  now,
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Use checks the link and marks it as used, it returns the ID of the user to log in
+// A link opened in another browser stays valid for the browser that requested it.
+func (s *MagicLinkService) Use(token, deviceToken string) (int, error) {
+	if token == "" {
+		return 0, ErrInvalidMagicLink
+	}
+	link, err := s.repo.FindByTokenHash(hashMagicLinkToken(token))
+	if err != nil {
+		return 0, err
+	}
+	now := s.now()
+	if link == nil || link.UsedAt != nil || now.After(link.ExpiresAt) {
+		return 0, ErrInvalidMagicLink
+	}
+
+	if s.sameDevice() && subtle.ConstantTimeCompare([]byte(hashMagicLinkToken(deviceToken)), []byte(link.DeviceHash)) != 1 {
+		return 0, ErrMagicLinkOtherDevice
+	}
+
+	used, err := s.repo.MarkUsed(link.ID, now)
+	if err != nil {
+		return 0, err
+	}
+	if !used {
+		return 0, ErrInvalidMagicLink
+	}
+	return link.UserID, nil
+}
+
+// DeleteExpired removes expired links
+func (s *MagicLinkService) DeleteExpired() (int64, error) {
+	return s.repo.DeleteExpired(s.now())
+}
+
+func hashMagicLinkToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
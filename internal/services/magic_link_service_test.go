@@ -0,0 +1,108 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestMagicLinkService tests single use, expiry, the device binding and the rate limit per account
+func TestMagicLinkService(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	service := NewMagicLinkService(db, &config.Config{JWTSecret: "test-secret"})
+	settingsRepo := repository.NewSettingsRepository(db)
+
+	now := time.Now()
+	service.now = func() time.Time { return now }
+
+	userID := testutil.SeedTestUser(t, db, "magic@example.com", "Magic User", "green")
+
+	if service.Enabled() {
+		t.Error("Expected the magic link login to be disabled by default")
+	}
+	settingsRepo.Update(models.SettingMagicLinkLogin, "true")
+	if !service.Enabled() {
+		t.Error("Expected the magic link login to be enabled")
+	}
+
+	deviceToken, _ := service.NewDeviceToken()
+	create := func() string {
+		token, err := service.Create(userID, deviceToken)
+		if err != nil {
+			t.Fatalf("Create() failed: %v", err)
+		}
+		return token
+	}
+
+	t.Run("single use", func(t *testing.T) {
+		token := create()
+		if len(token) != 64 {
+			t.Fatalf("Expected a token from GenerateToken, got %q", token)
+		}
+
+		got, err := service.Use(token, deviceToken)
+		if err != nil || got != userID {
+			t.Fatalf("Use() = %d, %v, want %d", got, err, userID)
+		}
+		if _, err := service.Use(token, deviceToken); !errors.Is(err, ErrInvalidMagicLink) {
+			t.Errorf("Expected ErrInvalidMagicLink for a used link, got %v", err)
+		}
+		if _, err := service.Use("unknown", deviceToken); !errors.Is(err, ErrInvalidMagicLink) {
+			t.Errorf("Expected ErrInvalidMagicLink for an unknown link, got %v", err)
+		}
+	})
+
+	t.Run("device binding", func(t *testing.T) {
+		now = now.Add(MagicLinkTTL)
+		token := create()
+
+		if _, err := service.Use(token, "other-device"); !errors.Is(err, ErrMagicLinkOtherDevice) {
+			t.Fatalf("Expected ErrMagicLinkOtherDevice, got %v", err)
+		}
+		// Still valid in the requesting browser
+		if _, err := service.Use(token, deviceToken); err != nil {
+			t.Errorf("Use() failed: %v", err)
+		}
+
+		settingsRepo.Update(models.SettingMagicLinkSameDevice, "false")
+		defer settingsRepo.Update(models.SettingMagicLinkSameDevice, "true")
+		if _, err := service.Use(create(), "other-device"); err != nil {
+			t.Errorf("Expected links to work on other devices, got %v", err)
+		}
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		now = now.Add(MagicLinkTTL)
+		token := create()
+
+		now = now.Add(MagicLinkTTL + time.Second)
+		if _, err := service.Use(token, deviceToken); !errors.Is(err, ErrInvalidMagicLink) {
+			t.Errorf("Expected ErrInvalidMagicLink for an expired link, got %v", err)
+		}
+		if deleted, err := service.DeleteExpired(); err != nil || deleted == 0 {
+			t.Errorf("Expected expired links to be deleted, got %d (%v)", deleted, err)
+		}
+	})
+
+	t.Run("rate limit", func(t *testing.T) {
+		now = now.Add(MagicLinkTTL)
+		for i := 0; i < magicLinkLimit; i++ {
+			if create() == "" {
+				t.Fatalf("Expected link %d to be created", i+1)
+			}
+		}
+		if token := create(); token != "" {
+			t.Error("Expected no link beyond the limit")
+		}
+
+		now = now.Add(MagicLinkTTL + time.Second)
+		if create() == "" {
+			t.Error("Expected a link after the limit window")
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// NotificationChannel defines the interface for text notification channels (SMS, Telegram, ...)
+// Email is handled by EmailService, which renders full HTML templates
+type NotificationChannel interface {
+	// Name returns the channel key used in notification preferences
+	Name() string
+
+	// Address returns where the recipient is reached on this channel
+	// Returns an empty string if the recipient cannot be reached
+	Address(recipient *ChannelRecipient) string
+
+	// Send delivers a plain-text message to an address
+	Send(address, message string) error
+}
+
+// ChannelRecipient holds the contact details of a user for text channels
+type ChannelRecipient struct {
+	UserID         int
+	Phone          string
+	TelegramChatID string
+}
+
+// channelHTTPTimeout limits how long a channel waits for its gateway
+const channelHTTPTimeout = 10 * time.Second
+
+// postJSON sends a JSON request and fails on non-2xx responses
+func postJSON(client *http.Client, url string, headers map[string]string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("gateway returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+)
+
+// PushChannel delivers notifications to all devices a user subscribed via Web Push
+type PushChannel struct {
+	sender *WebPushSender
+	repo   *repository.PushSubscriptionRepository
+	url    string // Page opened when the notification is clicked
+}
+
+// pushMessage is the payload the service worker turns into a notification
+type pushMessage struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	URL   string `json:"url"`
+}
+
+// NewPushChannel creates a push channel
+func NewPushChannel(sender *WebPushSender, repo *repository.PushSubscriptionRepository) *PushChannel {
+	return &PushChannel{sender: sender, repo: repo, url: "/dashboard.html"}
+}
+
+// Name returns the channel key
+func (c *PushChannel) Name() string {
+	return models.NotificationChannelPush
+}
+
+// Address returns the user ID if the user has subscribed devices
+func (c *PushChannel) Address(recipient *ChannelRecipient) string {
+	count, err := c.repo.CountByUser(recipient.UserID)
+	if err != nil {
+		log.Printf("Failed to count push subscriptions for user %d: %v", recipient.UserID, err)
+		return ""
+	}
+	if count == 0 {
+		return ""
+	}
+	return strconv.Itoa(recipient.UserID)
+}
+
+// Send delivers a message to every device of the user and removes expired subscriptions
+// Succeeds if at least one device received the message
+func (c *PushChannel) Send(address, message string) error {
+	userID, err := strconv.Atoi(address)
+	if err != nil {
+		return fmt.Errorf("invalid push address %q", address)
+	}
+
+	subscriptions, err := c.repo.FindByUser(userID)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(pushMessage{Title: "Gassigeher", Body: message, URL: c.url})
+	if err != nil {
+		return fmt.Errorf("failed to encode push message: %w", err)
+	}
+
+	delivered := 0
+	var lastErr error
+	for _, sub := range subscriptions {
+		err := c.sender.Send(sub, payload)
+		switch {
+		case errors.Is(err, ErrPushSubscriptionExpired):
+			log.Printf("Removing expired push subscription %d of user %d", sub.ID, userID)
+			if err := c.repo.Delete(sub.ID); err != nil {
+				log.Printf("Failed to remove push subscription %d: %v", sub.ID, err)
+			}
+		case err != nil:
+			lastErr = err
+		default:
+			delivered++
+			if err := c.repo.MarkUsed(sub.ID); err != nil {
+				log.Printf("Failed to update push subscription %d: %v", sub.ID, err)
+			}
+		}
+	}
+
+	if delivered > 0 {
+		return nil
+	}
+	if lastErr != nil {
+		return lastErr
+	}
+	return ErrPushSubscriptionExpired
+}
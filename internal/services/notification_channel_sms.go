@@ -0,0 +1,57 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/tranmh/gassigeher/internal/models"
+)
+
+// SMSChannel sends text messages via a generic HTTP gateway
+// The gateway receives POST {"to", "from", "text"} with an optional bearer token
+type SMSChannel struct {
+	gatewayURL string
+	token      string
+	sender     string
+	client     *http.Client
+}
+
+// smsRequest is the payload sent to the SMS gateway
+type smsRequest struct {
+	To   string `json:"to"`
+	From string `json:"from,omitempty"`
+	Text string `json:"text"`
+}
+
+// NewSMSChannel creates an SMS channel for the given gateway
+func NewSMSChannel(gatewayURL, token, sender string) *SMSChannel {
+	return &SMSChannel{
+		gatewayURL: gatewayURL,
+		token:      token,
+		sender:     sender,
+		client:     &http.Client{Timeout: channelHTTPTimeout},
+	}
+}
+
+// Name returns the channel key
+func (c *SMSChannel) Name() string {
+	return models.NotificationChannelSMS
+}
+
+// Address returns the recipient's phone number
+func (c *SMSChannel) Address(recipient *ChannelRecipient) string {
+	return recipient.Phone
+}
+
+// Send sends a text message to a phone number
+func (c *SMSChannel) Send(address, message string) error {
+	var headers map[string]string
+	if c.token != "" {
+		headers = map[string]string{"Authorization": "Bearer " + c.token}
+	}
+
+	if err := postJSON(c.client, c.gatewayURL, headers, smsRequest{To: address, From: c.sender, Text: message}); err != nil {
+		return fmt.Errorf("failed to send SMS: %w", err)
+	}
+	return nil
+}
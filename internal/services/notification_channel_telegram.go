@@ -0,0 +1,117 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+)
+
+// ErrInvalidTelegramLinkToken is returned for malformed, tampered or expired link tokens
+var ErrInvalidTelegramLinkToken = errors.New("invalid telegram link token")
+
+// telegramLinkValidity is how long a link created in the profile can be used
+const telegramLinkValidity = 30 * time.Minute
+
+// TelegramChannel sends messages via the Telegram Bot API
+type TelegramChannel struct {
+	apiURL      string
+	botToken    string
+	botUsername string
+	linkSecret  []byte
+	client      *http.Client
+}
+
+// telegramMessage is the payload of the sendMessage method
+type telegramMessage struct {
+	ChatID string `json:"chat_id"`
+	Text   string `json:"text"`
+}
+
+// NewTelegramChannel creates a Telegram channel
+// apiURL defaults to https://api.telegram.org, linkSecret signs the deep links used to connect chats
+func NewTelegramChannel(apiURL, botToken, botUsername, linkSecret string) *TelegramChannel {
+	if apiURL == "" {
+		apiURL = "https://api.telegram.org"
+	}
+
+	return &TelegramChannel{
+		apiURL:      strings.TrimRight(apiURL, "/"),
+		botToken:    botToken,
+		botUsername: botUsername,
+		linkSecret:  []byte(linkSecret),
+		client:      &http.Client{Timeout: channelHTTPTimeout},
+	}
+}
+
+// Name returns the channel key
+func (c *TelegramChannel) Name() string {
+	return models.NotificationChannelTelegram
+}
+
+// Address returns the recipient's linked chat
+func (c *TelegramChannel) Address(recipient *ChannelRecipient) string {
+	return recipient.TelegramChatID
+}
+
+// Send sends a message to a chat
+func (c *TelegramChannel) Send(address, message string) error {
+	endpoint := fmt.Sprintf("%s/bot%s/sendMessage", c.apiURL, c.botToken)
+	if err := postJSON(c.client, endpoint, nil, telegramMessage{ChatID: address, Text: message}); err != nil {
+		// Do not leak the bot token into logs
+		return fmt.Errorf("failed to send Telegram message: %s", strings.ReplaceAll(err.Error(), c.botToken, "***"))
+	}
+	return nil
+}
+
+// LinkURL returns the deep link that connects a chat to the user
+// Opening it in Telegram sends "/start <token>" to the bot
+func (c *TelegramChannel) LinkURL(userID int, now time.Time) string {
+	return "https://t.me/" + url.PathEscape(c.botUsername) + "?start=" + c.GenerateLinkToken(userID, now)
+}
+
+// GenerateLinkToken creates a short-lived token for linking a chat
+// Format: <userID>_<expiry unix>_<hex HMAC-SHA256> (Telegram allows [A-Za-z0-9_-], max 64 chars)
+func (c *TelegramChannel) GenerateLinkToken(userID int, now time.Time) string {
+	payload := fmt.Sprintf("%d_%d", userID, now.Add(telegramLinkValidity).Unix())
+	return payload + "_" + c.sign(payload)
+}
+
+// ParseLinkToken verifies a link token and returns the user ID
+func (c *TelegramChannel) ParseLinkToken(token string, now time.Time) (int, error) {
+	parts := strings.Split(token, "_")
+	if len(parts) != 3 {
+		return 0, ErrInvalidTelegramLinkToken
+	}
+
+	payload := parts[0] + "_" + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(c.sign(payload))) {
+		return 0, ErrInvalidTelegramLinkToken
+	}
+
+	userID, err := strconv.Atoi(parts[0])
+	if err != nil || userID <= 0 {
+		return 0, ErrInvalidTelegramLinkToken
+	}
+
+	expiry, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || now.Unix() > expiry {
+		return 0, ErrInvalidTelegramLinkToken
+	}
+
+	return userID, nil
+}
+
+func (c *TelegramChannel) sign(payload string) string {
+	mac := hmac.New(sha256.New, c.linkSecret)
+	mac.Write([]byte("telegram-link:" + payload))
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
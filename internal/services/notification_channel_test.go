@@ -0,0 +1,126 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSMSChannel_Send tests delivery to a local HTTP gateway stand-in
+func TestSMSChannel_Send(t *testing.T) {
+	var received smsRequest
+	var auth string
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer gateway.Close()
+
+	channel := NewSMSChannel(gateway.URL, "secret", "Gassigeher")
+
+	if err := channel.Send("+491701234567", "Hallo"); err != nil {
+		t.Fatalf("Send() failed: %v", err)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("Expected bearer token, got %q", auth)
+	}
+	if received.To != "+491701234567" || received.From != "Gassigeher" || received.Text != "Hallo" {
+		t.Errorf("Unexpected payload: %+v", received)
+	}
+	if channel.Address(&ChannelRecipient{Phone: "+491701234567"}) != "+491701234567" {
+		t.Error("Expected phone number as address")
+	}
+}
+
+// TestSMSChannel_GatewayError tests that gateway errors are reported
+func TestSMSChannel_GatewayError(t *testing.T) {
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "quota exceeded", http.StatusTooManyRequests)
+	}))
+	defer gateway.Close()
+
+	err := NewSMSChannel(gateway.URL, "", "").Send("+491701234567", "Hallo")
+	if err == nil || !strings.Contains(err.Error(), "429") {
+		t.Errorf("Expected status error, got %v", err)
+	}
+}
+
+// TestTelegramChannel_Send tests delivery to a local Bot API stand-in
+func TestTelegramChannel_Send(t *testing.T) {
+	var path string
+	var received telegramMessage
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer api.Close()
+
+	channel := NewTelegramChannel(api.URL, "123:abc", "gassi_bot", "test-secret")
+
+	if err := channel.Send("42", "Hallo"); err != nil {
+		t.Fatalf("Send() failed: %v", err)
+	}
+	if path != "/bot123:abc/sendMessage" {
+		t.Errorf("Unexpected path %q", path)
+	}
+	if received.ChatID != "42" || received.Text != "Hallo" {
+		t.Errorf("Unexpected payload: %+v", received)
+	}
+}
+
+// TestTelegramChannel_SendErrorHidesToken tests that errors do not contain the bot token
+func TestTelegramChannel_SendErrorHidesToken(t *testing.T) {
+	channel := NewTelegramChannel("http://127.0.0.1:1", "123:abc", "gassi_bot", "test-secret")
+
+	err := channel.Send("42", "Hallo")
+	if err == nil {
+		t.Fatal("Expected error for unreachable API")
+	}
+	if strings.Contains(err.Error(), "123:abc") {
+		t.Errorf("Error should not contain the bot token: %v", err)
+	}
+}
+
+// TestTelegramChannel_LinkToken tests signed, expiring link tokens
+func TestTelegramChannel_LinkToken(t *testing.T) {
+	channel := NewTelegramChannel("", "123:abc", "gassi_bot", "test-secret")
+	now := time.Now()
+
+	token := channel.GenerateLinkToken(7, now)
+	if len(token) > 64 {
+		t.Errorf("Token too long for Telegram deep links: %d", len(token))
+	}
+
+	userID, err := channel.ParseLinkToken(token, now)
+	if err != nil || userID != 7 {
+		t.Errorf("Expected user 7, got %d (%v)", userID, err)
+	}
+
+	if !strings.HasPrefix(channel.LinkURL(7, now), "https://t.me/gassi_bot?start=") {
+		t.Errorf("Unexpected link URL %q", channel.LinkURL(7, now))
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		now   time.Time
+	}{
+		{"expired", token, now.Add(time.Hour)},
+		{"tampered user", "8" + token[1:], now},
+		{"malformed", "abc", now},
+		{"other secret", NewTelegramChannel("", "123:abc", "gassi_bot", "other").GenerateLinkToken(7, now), now},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := channel.ParseLinkToken(tt.token, tt.now); err != ErrInvalidTelegramLinkToken {
+				t.Errorf("Expected ErrInvalidTelegramLinkToken, got %v", err)
+			}
+		})
+	}
+}
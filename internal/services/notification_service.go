@@ -0,0 +1,349 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/i18n"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+)
+
+// NotificationService delivers events to every channel a user has enabled
+// Email is sent through EmailService, other channels (push, SMS, Telegram) receive a short localized message.
+// Every event is also recorded in the user's in-app notification center.
+type NotificationService struct {
+	email       *EmailService // nil = email not configured
+	channels    []NotificationChannel
+	userRepo    *repository.UserRepository
+	preferences *repository.NotificationPreferenceRepository
+	inbox       *repository.NotificationRepository
+	eventID     *int // Set by ForEvent
+}
+
+// NewNotificationService creates a notification service with the given email service and text channels
+func NewNotificationService(email *EmailService, channels []NotificationChannel, userRepo *repository.UserRepository, preferences *repository.NotificationPreferenceRepository, inbox *repository.NotificationRepository) *NotificationService {
+	return &NotificationService{
+		email:       email,
+		channels:    channels,
+		userRepo:    userRepo,
+		preferences: preferences,
+		inbox:       inbox,
+	}
+}
+
+// NewNotificationServiceFromConfig creates a notification service with all channels enabled in the config
+// email may be nil if no email provider is configured
+func NewNotificationServiceFromConfig(db *sql.DB, cfg *config.Config, email *EmailService) *NotificationService {
+	return NewNotificationService(
+		email,
+		ConfiguredChannels(db, cfg),
+		repository.NewUserRepository(db),
+		repository.NewNotificationPreferenceRepository(db),
+		repository.NewNotificationRepository(db),
+	)
+}
+
+// ForEvent returns a copy that records in-app notifications once per domain event
+// The event dispatcher retries an event whose delivery failed, the copy keeps the retries from
+// repeating the notifications already in the notification center.
+func (s *NotificationService) ForEvent(eventID int) *NotificationService {
+	forEvent := *s
+	forEvent.eventID = &eventID
+	return &forEvent
+}
+
+// ConfiguredChannels returns the channels besides email enabled in the config
+func ConfiguredChannels(db *sql.DB, cfg *config.Config) []NotificationChannel {
+	channels := []NotificationChannel{}
+	if push := NewPushChannelFromConfig(db, cfg); push != nil {
+		channels = append(channels, push)
+	}
+	if cfg.SMSGatewayURL != "" {
+		channels = append(channels, NewSMSChannel(cfg.SMSGatewayURL, cfg.SMSGatewayToken, cfg.SMSSender))
+	}
+	if telegram := NewTelegramChannelFromConfig(cfg); telegram != nil {
+		channels = append(channels, telegram)
+	}
+	return channels
+}
+
+// NewPushChannelFromConfig creates the Web Push channel, or returns nil if push is disabled
+func NewPushChannelFromConfig(db *sql.DB, cfg *config.Config) *PushChannel {
+	if !cfg.WebPushEnabled || db == nil {
+		return nil
+	}
+
+	repo := repository.NewPushSubscriptionRepository(db)
+	keys, err := LoadVAPIDKeys(cfg, repo)
+	if err != nil {
+		log.Printf("Warning: Web Push disabled, failed to load VAPID keys: %v", err)
+		return nil
+	}
+
+	return NewPushChannel(NewWebPushSender(keys, VAPIDSubject(cfg)), repo)
+}
+
+// NewTelegramChannelFromConfig creates the Telegram channel, or returns nil if no bot is configured
+func NewTelegramChannelFromConfig(cfg *config.Config) *TelegramChannel {
+	if cfg.TelegramBotToken == "" {
+		return nil
+	}
+	return NewTelegramChannel(cfg.TelegramAPIURL, cfg.TelegramBotToken, cfg.TelegramBotUsername, cfg.JWTSecret)
+}
+
+// ChannelNames returns the keys of all available channels, email first
+func ChannelNames(channels []NotificationChannel) []string {
+	names := []string{models.NotificationChannelEmail}
+	for _, channel := range channels {
+		names = append(names, channel.Name())
+	}
+	return names
+}
+
+// SendBookingConfirmation notifies the user about a new booking
+func (s *NotificationService) SendBookingConfirmation(to, name, dogName, date, scheduledTime string) error {
+	return s.dispatch(to, "booking_confirmation", func(email *EmailService) error {
+		return email.SendBookingConfirmation(to, name, dogName, date, scheduledTime)
+	}, dogName, date, scheduledTime)
+}
+
+// SendBookingCancellation notifies the user about a booking they cancelled
+func (s *NotificationService) SendBookingCancellation(to, name, dogName, date, scheduledTime string) error {
+	return s.dispatch(to, "booking_cancellation", func(email *EmailService) error {
+		return email.SendBookingCancellation(to, name, dogName, date, scheduledTime)
+	}, dogName, date, scheduledTime)
+}
+
+// SendAdminCancellation notifies the user about a booking cancelled by an admin
+func (s *NotificationService) SendAdminCancellation(to, name, dogName, date, scheduledTime, reason string) error {
+	return s.dispatch(to, "admin_cancellation", func(email *EmailService) error {
+		return email.SendAdminCancellation(to, name, dogName, date, scheduledTime, reason)
+	}, dogName, date, scheduledTime, reason)
+}
+
+// SendBookingReminder reminds the user of an upcoming walk
+func (s *NotificationService) SendBookingReminder(to, name, dogName, date, scheduledTime string) error {
+	return s.dispatch(to, "booking_reminder", func(email *EmailService) error {
+		return email.SendBookingReminder(to, name, dogName, date, scheduledTime)
+	}, dogName, date, scheduledTime)
+}
+
+// SendBookingMoved notifies the user about a booking moved by an admin
+func (s *NotificationService) SendBookingMoved(to, name, dogName, oldDate, oldTime, newDate, newTime, reason string) error {
+	return s.dispatch(to, "booking_moved", func(email *EmailService) error {
+		return email.SendBookingMoved(to, name, dogName, oldDate, oldTime, newDate, newTime, reason)
+	}, dogName, oldDate, oldTime, newDate, newTime, reason)
+}
+
+// SendBookingApproved notifies the user that a pending booking was approved
+func (s *NotificationService) SendBookingApproved(to, name, dogName, date, scheduledTime string) error {
+	return s.dispatch(to, "booking_approved", func(email *EmailService) error {
+		return email.SendBookingApproved(to, name, dogName, date, scheduledTime)
+	}, dogName, date, scheduledTime)
+}
+
+// SendBookingRejected notifies the user that a pending booking was rejected
+func (s *NotificationService) SendBookingRejected(to, name, dogName, date, scheduledTime, reason string) error {
+	return s.dispatch(to, "booking_rejected", func(email *EmailService) error {
+		return email.SendBookingRejected(to, name, dogName, date, scheduledTime, reason)
+	}, dogName, date, scheduledTime, reason)
+}
+
+// SendWelcome welcomes a user after email verification
+func (s *NotificationService) SendWelcome(to, name string) error {
+	return s.dispatch(to, "welcome", func(email *EmailService) error {
+		return email.SendWelcomeEmail(to, name)
+	})
+}
+
+// SendExperienceLevelApproved notifies the user that an experience level request was approved
+func (s *NotificationService) SendExperienceLevelApproved(to, name, level string, message *string) error {
+	return s.dispatch(to, "experience_approved", func(email *EmailService) error {
+		return email.SendExperienceLevelApproved(to, name, level, message)
+	}, levelParam(level), optionalParam(message))
+}
+
+// SendExperienceLevelDenied notifies the user that an experience level request was denied
+func (s *NotificationService) SendExperienceLevelDenied(to, name, level string, message *string) error {
+	return s.dispatch(to, "experience_denied", func(email *EmailService) error {
+		return email.SendExperienceLevelDenied(to, name, level, message)
+	}, levelParam(level), optionalParam(message))
+}
+
+// SendAccountDeactivated notifies the user that their account was deactivated
+func (s *NotificationService) SendAccountDeactivated(to, name, reason string) error {
+	return s.dispatch(to, "account_deactivated", func(email *EmailService) error {
+		return email.SendAccountDeactivated(to, name, reason)
+	}, reason)
+}
+
+// SendAccountReactivated notifies the user that their account was reactivated
+func (s *NotificationService) SendAccountReactivated(to, name string, message *string) error {
+	return s.dispatch(to, "account_reactivated", func(email *EmailService) error {
+		return email.SendAccountReactivated(to, name, message)
+	}, optionalParam(message))
+}
+
+// SendReactivationDenied notifies the user that their reactivation request was denied
+func (s *NotificationService) SendReactivationDenied(to, name string, message *string) error {
+	return s.dispatch(to, "reactivation_denied", func(email *EmailService) error {
+		return email.SendReactivationDenied(to, name, message)
+	}, optionalParam(message))
+}
+
+// NotifyAdmins records an in-app notification for all active staff who work the queue of the notification type
+// Staff hold the permission of models.AdminNotificationPermissions through the admin flags or a role.
+func (s *NotificationService) NotifyAdmins(notificationType string, params ...string) error {
+	if s.inbox == nil {
+		return nil
+	}
+
+	permission, ok := models.AdminNotificationPermissions[notificationType]
+	if !ok {
+		return fmt.Errorf("no permission for admin notification %s", notificationType)
+	}
+	if _, err := s.inbox.CreateForStaff(permission, notificationType, params, models.NotificationLink(notificationType), s.eventID); err != nil {
+		log.Printf("Failed to create %s notifications for admins: %v", notificationType, err)
+		return err
+	}
+	return nil
+}
+
+// dispatch records an event (key) in the notification center and sends the email and the text message to all enabled channels
+// Fails only if every attempted delivery failed, so a retry does not repeat successful deliveries
+func (s *NotificationService) dispatch(to, key string, sendEmail func(*EmailService) error, args ...string) error {
+	category := notificationCategory(key)
+	user, err := s.userRepo.FindByEmail(to)
+	if err != nil {
+		log.Printf("Failed to look up notification recipient %s: %v", to, err)
+	}
+
+	if user != nil {
+		s.record(user.ID, key, args)
+	}
+
+	attempted, failed := 0, 0
+	var lastErr error
+
+	if s.email != nil && s.isEnabled(user, category, models.NotificationChannelEmail) {
+		attempted++
+		if err := sendEmail(s.email); err != nil {
+			log.Printf("Failed to send %s email to %s: %v", key, to, err)
+			failed++
+			lastErr = err
+		}
+	}
+
+	if user != nil && len(s.channels) > 0 {
+		sent, errs := s.sendText(user, key, category, args...)
+		attempted += sent + len(errs)
+		failed += len(errs)
+		for _, err := range errs {
+			log.Printf("Failed to send %s notification to user %d: %v", key, user.ID, err)
+			lastErr = err
+		}
+	}
+
+	if attempted > 0 && failed == attempted {
+		return lastErr
+	}
+	return nil
+}
+
+// record stores an event in the user's notification center
+// The notification center is the history of all events, so it does not depend on the channel preferences
+func (s *NotificationService) record(userID int, key string, params []string) {
+	if s.inbox == nil {
+		return
+	}
+
+	notification := &models.Notification{
+		UserID:  userID,
+		Type:    key,
+		Params:  params,
+		Link:    models.NotificationLink(key),
+		EventID: s.eventID,
+	}
+	if err := s.inbox.Create(notification); err != nil {
+		log.Printf("Failed to record %s notification for user %d: %v", key, userID, err)
+	}
+}
+
+// isEnabled checks the user's preference for a category on a channel
+// Unknown addresses only receive email, failed lookups fall back to sending
+func (s *NotificationService) isEnabled(user *models.User, category, channel string) bool {
+	if user == nil {
+		return channel == models.NotificationChannelEmail
+	}
+
+	enabled, err := s.preferences.IsEnabled(user.ID, category, channel)
+	if err != nil {
+		log.Printf("Failed to check notification preferences for user %d, sending anyway: %v", user.ID, err)
+		return true
+	}
+	return enabled
+}
+
+// sendText sends the localized text for key to the user's enabled text channels
+// Returns the number of successful deliveries and the errors of failed ones
+func (s *NotificationService) sendText(user *models.User, key, category string, args ...string) (int, []error) {
+	recipient := &ChannelRecipient{UserID: user.ID}
+	if user.Phone != nil {
+		recipient.Phone = *user.Phone
+	}
+	if chatID, err := s.userRepo.GetTelegramChatID(user.ID); err != nil {
+		log.Printf("Failed to get Telegram chat for user %d: %v", user.ID, err)
+	} else if chatID != nil {
+		recipient.TelegramChatID = *chatID
+	}
+
+	lang := i18n.Normalize(user.PreferredLanguage)
+	message := strings.TrimSpace(i18n.T(lang, "notifications."+key, models.LocalizeNotificationParams(lang, args)...))
+
+	sent := 0
+	errs := []error{}
+	for _, channel := range s.channels {
+		address := channel.Address(recipient)
+		if address == "" || !s.isEnabled(user, category, channel.Name()) {
+			continue
+		}
+
+		if err := channel.Send(address, message); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		sent++
+	}
+
+	return sent, errs
+}
+
+// notificationCategory returns the notification category of an event
+func notificationCategory(key string) string {
+	for _, def := range emailTemplateDefinitions {
+		if def.Key == key {
+			return def.Category
+		}
+	}
+	return models.NotificationCategoryAccount
+}
+
+// levelParam returns the experience level as a param translated on rendering
+func levelParam(level string) string {
+	if level != "orange" {
+		level = "blue"
+	}
+	return models.NotificationParamKeyPrefix + "levels." + level
+}
+
+// optionalParam returns the text of an optional admin message
+func optionalParam(message *string) string {
+	if message == nil {
+		return ""
+	}
+	return *message
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// channelStandIn is a local HTTP server standing in for an SMS gateway or the Telegram Bot API
+type channelStandIn struct {
+	server   *httptest.Server
+	mu       sync.Mutex
+	messages []map[string]interface{}
+	status   int
+}
+
+func newChannelStandIn(t *testing.T) *channelStandIn {
+	s := &channelStandIn{status: http.StatusOK}
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.messages = append(s.messages, payload)
+		w.WriteHeader(s.status)
+	}))
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+func (s *channelStandIn) received() []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]map[string]interface{}{}, s.messages...)
+}
+
+// TestNotificationService_Dispatch tests delivery of booking events to the enabled channels
+func TestNotificationService_Dispatch(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "Anna", "green")
+
+	sms := newChannelStandIn(t)
+	telegram := newChannelStandIn(t)
+
+	cfg := &config.Config{
+		JWTSecret:        "test-secret",
+		SMSGatewayURL:    sms.server.URL,
+		TelegramBotToken: "123:abc",
+		TelegramAPIURL:   telegram.server.URL,
+	}
+
+	userRepo := repository.NewUserRepository(db)
+	preferences := repository.NewNotificationPreferenceRepository(db)
+	provider := &recordingEmailProvider{}
+	email := &EmailService{
+		provider:    provider,
+		baseURL:     "https://example.com",
+		templates:   NewEmailTemplateStore(repository.NewEmailTemplateRepository(db), "https://example.com"),
+		userRepo:    userRepo,
+		preferences: preferences,
+	}
+	service := NewNotificationServiceFromConfig(db, cfg, email)
+
+	chatID := "4242"
+	userRepo.SetTelegramChatID(userID, &chatID)
+
+	t.Run("text channels are opt-in", func(t *testing.T) {
+		if err := service.SendBookingReminder("user@example.com", "Anna", "Bella", "01.12.2025", "09:00"); err != nil {
+			t.Fatalf("SendBookingReminder() failed: %v", err)
+		}
+		if provider.sent != 1 {
+			t.Errorf("Expected 1 email, got %d", provider.sent)
+		}
+		if len(sms.received()) != 0 || len(telegram.received()) != 0 {
+			t.Error("Expected no text messages without opt-in")
+		}
+	})
+
+	t.Run("delivers to enabled channels", func(t *testing.T) {
+		preferences.Set(userID, models.NotificationCategoryReminders, models.NotificationChannelSMS, true)
+		preferences.Set(userID, models.NotificationCategoryReminders, models.NotificationChannelTelegram, true)
+
+		if err := service.SendBookingReminder("user@example.com", "Anna", "Bella", "01.12.2025", "09:00"); err != nil {
+			t.Fatalf("SendBookingReminder() failed: %v", err)
+		}
+
+		// Seeded users have a phone number
+		messages := sms.received()
+		if len(messages) != 1 || !strings.Contains(messages[0]["text"].(string), "Bella") {
+			t.Errorf("Expected one SMS mentioning the dog, got %v", messages)
+		}
+
+		messages = telegram.received()
+		if len(messages) != 1 || messages[0]["chat_id"] != "4242" {
+			t.Errorf("Expected one Telegram message to chat 4242, got %v", messages)
+		}
+		if text, _ := messages[0]["text"].(string); !strings.Contains(text, "Erinnerung") {
+			t.Errorf("Expected German text, got %q", text)
+		}
+	})
+
+	t.Run("other categories stay disabled", func(t *testing.T) {
+		before := len(sms.received())
+		service.SendBookingCancellation("user@example.com", "Anna", "Bella", "01.12.2025", "09:00")
+		if len(sms.received()) != before {
+			t.Error("Cancellation should not be sent by SMS without opt-in")
+		}
+	})
+
+	t.Run("email disabled, text enabled", func(t *testing.T) {
+		preferences.Set(userID, models.NotificationCategoryReminders, models.NotificationChannelEmail, false)
+		emails, before := provider.sent, len(telegram.received())
+
+		service.SendBookingReminder("user@example.com", "Anna", "Bella", "01.12.2025", "09:00")
+
+		if provider.sent != emails {
+			t.Error("Expected no email")
+		}
+		if len(telegram.received()) != before+1 {
+			t.Error("Expected Telegram message")
+		}
+	})
+
+	t.Run("fails only if every channel failed", func(t *testing.T) {
+		sms.status = http.StatusInternalServerError
+		if err := service.SendBookingReminder("user@example.com", "Anna", "Bella", "01.12.2025", "09:00"); err != nil {
+			t.Errorf("Expected success while Telegram works, got %v", err)
+		}
+
+		telegram.status = http.StatusInternalServerError
+		if err := service.SendBookingReminder("user@example.com", "Anna", "Bella", "01.12.2025", "09:00"); err == nil {
+			t.Error("Expected error when all channels failed")
+		}
+	})
+}
+
+// TestNotificationService_WithoutEmail tests text delivery when no email provider is configured
+func TestNotificationService_WithoutEmail(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "Anna", "green")
+	repository.NewNotificationPreferenceRepository(db).Set(userID, models.NotificationCategoryBookingChanges, models.NotificationChannelSMS, true)
+
+	sms := newChannelStandIn(t)
+	service := NewNotificationServiceFromConfig(db, &config.Config{SMSGatewayURL: sms.server.URL}, nil)
+
+	if err := service.SendBookingMoved("user@example.com", "Anna", "Bella", "01.12.2025", "09:00", "02.12.2025", "10:00", "Tierarzt"); err != nil {
+		t.Fatalf("SendBookingMoved() failed: %v", err)
+	}
+	if len(sms.received()) != 1 {
+		t.Errorf("Expected one SMS, got %d", len(sms.received()))
+	}
+
+	// Unknown addresses are skipped without error
+	if err := service.SendBookingMoved("unknown@example.com", "X", "Bella", "01.12.2025", "09:00", "02.12.2025", "10:00", ""); err != nil {
+		t.Errorf("Expected no error for unknown address, got %v", err)
+	}
+}
+
+// TestNotificationService_NotificationCenter tests that events are recorded in the in-app notification center
+func TestNotificationService_NotificationCenter(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "Anna", "green")
+	adminID := testutil.SeedTestUser(t, db, "admin@example.com", "Admin", "blue")
+	db.Exec("UPDATE users SET is_admin = 1 WHERE id = ?", adminID)
+
+	inbox := repository.NewNotificationRepository(db)
+	service := NewNotificationServiceFromConfig(db, &config.Config{}, nil)
+
+	t.Run("records events without any channel", func(t *testing.T) {
+		service.SendBookingConfirmation("user@example.com", "Anna", "Bella", "01.12.2025", "09:00")
+
+		notifications, _ := inbox.FindByUser(userID, false, 50, 0)
+		if len(notifications) != 1 || notifications[0].Type != "booking_confirmation" {
+			t.Fatalf("Expected booking_confirmation notification, got %v", notifications)
+		}
+
+		notifications[0].Render("en")
+		if notifications[0].Message != "Your walk with Bella on 01.12.2025 at 09:00 is booked." {
+			t.Errorf("Unexpected message %q", notifications[0].Message)
+		}
+	})
+
+	t.Run("records events independent of preferences", func(t *testing.T) {
+		repository.NewNotificationPreferenceRepository(db).Set(userID, models.NotificationCategoryReminders, models.NotificationChannelEmail, false)
+		service.SendBookingReminder("user@example.com", "Anna", "Bella", "01.12.2025", "09:00")
+
+		if count, _ := inbox.CountUnread(userID); count != 2 {
+			t.Errorf("Expected 2 notifications, got %d", count)
+		}
+	})
+
+	t.Run("translates the experience level on rendering", func(t *testing.T) {
+		service.SendExperienceLevelApproved("user@example.com", "Anna", "orange", nil)
+
+		notifications, _ := inbox.FindByUser(userID, false, 1, 0)
+		notifications[0].Render("de")
+		if notifications[0].Message != "Deine Anfrage für die Stufe Orange wurde genehmigt." {
+			t.Errorf("Unexpected message %q", notifications[0].Message)
+		}
+	})
+
+	t.Run("notifies admins", func(t *testing.T) {
+		if err := service.NotifyAdmins(models.NotificationTypeAdminBookingPending, "Anna", "Bella", "01.12.2025", "20:00"); err != nil {
+			t.Fatalf("NotifyAdmins() failed: %v", err)
+		}
+
+		notifications, _ := inbox.FindByUser(adminID, false, 50, 0)
+		if len(notifications) != 1 || notifications[0].Link == nil || *notifications[0].Link != "/admin-booking-approvals.html" {
+			t.Errorf("Expected one admin notification linking to the approvals page, got %v", notifications)
+		}
+		if count, _ := inbox.CountUnread(userID); count != 3 {
+			t.Errorf("Expected no admin notification for the user, got %d notifications", count)
+		}
+	})
+}
@@ -0,0 +1,557 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+)
+
+const (
+	oidcLoginTTL           = 10 * time.Minute // Time to log in at the identity provider
+	oidcLoginCodeTTL       = time.Minute      // Time for the login page to exchange the login code
+	oidcKeyRefreshInterval = time.Minute      // Unknown key IDs refetch the provider keys at most this often
+)
+
+var (
+	// ErrInvalidOIDCLogin is returned for unknown, used and expired logins and responses of the provider that do not verify
+	ErrInvalidOIDCLogin = errors.New("invalid or expired OIDC login")
+	// ErrOIDCEmailNotVerified is returned when the provider does not confirm the email address of a new identity
+	ErrOIDCEmailNotVerified = errors.New("email address not verified by the identity provider")
+	// ErrOIDCAccountNotFound is returned when no active account has the email address of the identity
+	ErrOIDCAccountNotFound = errors.New("no account for the identity")
+)
+
+// OIDCService implements the single sign-on with an OpenID Connect provider (authorization code flow with PKCE)
+// Identities are linked to existing users by verified email address and found again by issuer and subject.
+// Groups of the ID token can grant roles, see config.Config.OIDCGroupRoles.
+type OIDCService struct {
+	repo         *repository.OIDCRepository
+	userRepo     *repository.UserRepository
+	roleRepo     *repository.RoleRepository
+	outbox       *OutboxService
+	authService  *AuthService
+	issuer       string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	scopes       []string
+	groupsClaim  string
+	groupRoles   map[string][]string
+	client       *http.Client
+	now          func() time.Time
+
+	mu            sync.Mutex
+	provider      *oidcProviderMetadata
+	keys          map[string]interface{}
+	keysFetchedAt time.Time
+}
+
+// oidcProviderMetadata is the part of the discovery document the login needs
+type oidcProviderMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcIdentity holds the verified claims of an ID token
+type oidcIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Groups        []string
+}
+
+// NewOIDCService creates a new OIDC service
+func NewOIDCService(db *sql.DB, cfg *config.Config) *OIDCService {
+	return &OIDCService{
+		repo:         repository.NewOIDCRepository(db),
+		userRepo:     repository.NewUserRepository(db),
+		roleRepo:     repository.NewRoleRepository(db),
+		outbox:       NewOutboxService(db),
+		authService:  NewAuthService(cfg.JWTSecret, cfg.GetAccessTokenMinutes()),
+		issuer:       strings.TrimRight(cfg.OIDCIssuerURL, "/"),
+		clientID:     cfg.OIDCClientID,
+		clientSecret: cfg.OIDCClientSecret,
+		redirectURL:  cfg.GetOIDCRedirectURL(),
+		scopes:       cfg.GetOIDCScopes(),
+		groupsClaim:  cfg.OIDCGroupsClaim,
+		groupRoles:   cfg.GetOIDCGroupRoles(),
+		client:       &http.Client{Timeout: 10 * time.Second},
+		now:          time.Now,
+	}
+}
+
+// Begin starts a login and returns the authorization URL of the provider and the state
+// The state identifies the login when the provider redirects back, the caller binds it to the browser.
+func (s *OIDCService) Begin(ctx context.Context) (string, string, error) {
+	provider, err := s.discover(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := s.authService.GenerateToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := s.authService.GenerateToken()
+	if err != nil {
+		return "", "", err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	now := s.now()
+	err = s.repo.CreateLogin(&models.OIDCLogin{
+		StateHash:    hashOIDCValue(state),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    now.Add(oidcLoginTTL),
+		CreatedAt:    now,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL := s.oauthConfig(provider).AuthCodeURL(state, oauth2.S256ChallengeOption(verifier), oauth2.SetAuthURLParam("nonce", nonce))
+	return authURL, state, nil
+}
+
+// Callback exchanges the authorization code, links the identity to its user and returns a single-use login code
+// The login page exchanges the login code for the tokens with Finish.
+func (s *OIDCService) Callback(ctx context.Context, state, code string) (string, error) {
+	if state == "" || code == "" {
+		return "", ErrInvalidOIDCLogin
+	}
+	login, err := s.repo.FindLoginByState(hashOIDCValue(state))
+	if err != nil {
+		return "", err
+	}
+	if login == nil || login.LoginCodeHash != nil || s.now().After(login.ExpiresAt) {
+		return "", ErrInvalidOIDCLogin
+	}
+
+	provider, err := s.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	token, err := s.oauthConfig(provider).Exchange(context.WithValue(ctx, oauth2.HTTPClient, s.client), code,
+		oauth2.VerifierOption(login.CodeVerifier))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidOIDCLogin, err)
+	}
+	rawIDToken, _ := token.Extra("id_token").(string)
+	identity, err := s.verifyIDToken(ctx, provider, rawIDToken, login.Nonce)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidOIDCLogin, err)
+	}
+
+	user, err := s.linkUser(provider.Issuer, identity)
+	if err != nil {
+		return "", err
+	}
+
+	loginCode, err := s.authService.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+	completed, err := s.repo.CompleteLogin(login.ID, user.ID, hashOIDCValue(loginCode), s.now().Add(oidcLoginCodeTTL))
+	if err != nil {
+		return "", err
+	}
+	if !completed {
+		return "", ErrInvalidOIDCLogin
+	}
+	return loginCode, nil
+}
+
+// Finish takes the login code and returns the ID of the user to log in
+func (s *OIDCService) Finish(loginCode string) (int, error) {
+	if loginCode == "" {
+		return 0, ErrInvalidOIDCLogin
+	}
+	login, err := s.repo.TakeLoginByCode(hashOIDCValue(loginCode))
+	if err != nil {
+		return 0, err
+	}
+	if login == nil || login.UserID == nil || s.now().After(login.ExpiresAt) {
+		return 0, ErrInvalidOIDCLogin
+	}
+	return *login.UserID, nil
+}
+
+// DeleteExpiredLogins removes logins that were not completed
+func (s *OIDCService) DeleteExpiredLogins() (int64, error) {
+	return s.repo.DeleteExpiredLogins(s.now())
+}
+
+// linkUser finds the user of the identity, a new identity is linked to the active account with its verified email address
+// The roles mapped from groups are updated on every login.
+func (s *OIDCService) linkUser(issuer string, identity *oidcIdentity) (*models.User, error) {
+	linked, err := s.repo.FindIdentity(issuer, identity.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	var user *models.User
+	if linked != nil {
+		user, err = s.userRepo.FindByID(linked.UserID)
+	} else {
+		if identity.Email == "" || !identity.EmailVerified {
+			return nil, ErrOIDCEmailNotVerified
+		}
+		user, err = s.userRepo.FindByEmail(identity.Email)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.IsVerified || !user.IsActive || user.IsDeleted {
+		return nil, ErrOIDCAccountNotFound
+	}
+
+	now := s.now()
+	err = s.outbox.Transact(func(tx *sql.Tx) ([]*models.DomainEvent, error) {
+		repo := s.repo.WithTx(tx)
+		if linked != nil {
+			if err := repo.UpdateIdentityLogin(linked.ID, identity.Email, now); err != nil {
+				return nil, err
+			}
+		} else {
+			err := repo.CreateIdentity(&models.UserIdentity{
+				UserID:      user.ID,
+				Issuer:      issuer,
+				Subject:     identity.Subject,
+				Email:       identity.Email,
+				CreatedAt:   now,
+				LastLoginAt: &now,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		return s.syncRoles(tx, user, identity.Groups)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// syncRoles grants the roles mapped from the groups of the user and removes mapped roles of groups the user left
+// Roles that are not mapped are left unchanged, super admins keep their flag.
+func (s *OIDCService) syncRoles(tx *sql.Tx, user *models.User, groups []string) ([]*models.DomainEvent, error) {
+	if len(s.groupRoles) == 0 {
+		return nil, nil
+	}
+
+	member := map[string]bool{}
+	for _, group := range groups {
+		member[group] = true
+	}
+	managed := map[string]bool{}
+	granted := map[string]bool{}
+	for group, roles := range s.groupRoles {
+		for _, role := range roles {
+			managed[role] = true
+			if member[group] {
+				granted[role] = true
+			}
+		}
+	}
+
+	events := []*models.DomainEvent{}
+	if managed[models.RoleAdmin] && !user.IsSuperAdmin && granted[models.RoleAdmin] != user.IsAdmin {
+		userRepo := s.userRepo.WithTx(tx)
+		eventType := models.EventUserPromoted
+		var err error
+		if granted[models.RoleAdmin] {
+			err = userRepo.PromoteToAdmin(user.ID)
+		} else {
+			eventType = models.EventUserDemoted
+			err = userRepo.DemoteAdmin(user.ID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		user.IsAdmin = granted[models.RoleAdmin]
+
+		event, err := models.NewDomainEvent(eventType, models.AggregateUser, user.ID, nil,
+			&models.UserEventData{UserID: user.ID, Name: user.Name})
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	roleRepo := s.roleRepo.WithTx(tx)
+	assigned, err := roleRepo.FindByUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	changed := false
+	has := map[string]bool{}
+	roleIDs := []int{}
+	for _, role := range assigned {
+		if managed[role.Name] && !granted[role.Name] {
+			changed = true
+			continue
+		}
+		has[role.Name] = true
+		roleIDs = append(roleIDs, role.ID)
+	}
+
+	names := []string{}
+	for name := range granted {
+		if !models.IsFlagRole(name) && !has[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		role, err := roleRepo.FindByName(name)
+		if err != nil {
+			return nil, err
+		}
+		if role == nil {
+			log.Printf("OIDC group mapping: role %q does not exist", name)
+			continue
+		}
+		roleIDs = append(roleIDs, role.ID)
+		changed = true
+	}
+
+	if changed {
+		if err := roleRepo.SetUserRoles(user.ID, roleIDs); err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
+
+// verifyIDToken checks signature, issuer, audience, expiry and nonce of the ID token and returns its claims
+func (s *OIDCService) verifyIDToken(ctx context.Context, provider *oidcProviderMetadata, rawIDToken, nonce string) (*oidcIdentity, error) {
+	if rawIDToken == "" {
+		return nil, errors.New("no ID token in the token response")
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(rawIDToken, claims,
+		func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+			return s.signingKey(ctx, provider, kid)
+		},
+		jwt.WithValidMethods([]string{"RS256", "ES256"}),
+		jwt.WithIssuer(provider.Issuer),
+		jwt.WithAudience(s.clientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(s.now),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if value, _ := claims["nonce"].(string); value != nonce {
+		return nil, errors.New("nonce does not match")
+	}
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return nil, errors.New("ID token without subject")
+	}
+
+	identity := &oidcIdentity{Subject: subject}
+	identity.Email, _ = claims["email"].(string)
+	// Some providers send the flag as string
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = verified
+	case string:
+		identity.EmailVerified = verified == "true"
+	}
+	switch groups := claims[s.groupsClaim].(type) {
+	case []interface{}:
+		for _, group := range groups {
+			if name, ok := group.(string); ok {
+				identity.Groups = append(identity.Groups, name)
+			}
+		}
+	case string:
+		identity.Groups = []string{groups}
+	}
+	return identity, nil
+}
+
+// signingKey returns the provider key with the ID, the keys are fetched again when the provider rotated them
+func (s *OIDCService) signingKey(ctx context.Context, provider *oidcProviderMetadata, kid string) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key := findOIDCKey(s.keys, kid); key != nil {
+		return key, nil
+	}
+	if s.keys != nil && s.now().Sub(s.keysFetchedAt) < oidcKeyRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	var jwks struct {
+		Keys []oidcJSONWebKey `json:"keys"`
+	}
+	if err := s.getJSON(ctx, provider.JWKSURI, &jwks); err != nil {
+		return nil, fmt.Errorf("failed to fetch signing keys: %w", err)
+	}
+	s.keys = map[string]interface{}{}
+	for _, jwk := range jwks.Keys {
+		key, err := jwk.publicKey()
+		if err != nil {
+			log.Printf("OIDC: skipping signing key %q: %v", jwk.Kid, err)
+			continue
+		}
+		s.keys[jwk.Kid] = key
+	}
+	s.keysFetchedAt = s.now()
+
+	if key := findOIDCKey(s.keys, kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// findOIDCKey returns the key with the ID, tokens without key ID use the only key of the provider
+func findOIDCKey(keys map[string]interface{}, kid string) interface{} {
+	if key, ok := keys[kid]; ok {
+		return key
+	}
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key
+		}
+	}
+	return nil
+}
+
+// discover fetches the discovery document of the issuer once
+func (s *OIDCService) discover(ctx context.Context) (*oidcProviderMetadata, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.provider != nil {
+		return s.provider, nil
+	}
+
+	provider := &oidcProviderMetadata{}
+	if err := s.getJSON(ctx, s.issuer+"/.well-known/openid-configuration", provider); err != nil {
+		return nil, fmt.Errorf("failed to discover OIDC provider: %w", err)
+	}
+	if strings.TrimRight(provider.Issuer, "/") != s.issuer {
+		return nil, fmt.Errorf("OIDC provider issuer %q does not match %q", provider.Issuer, s.issuer)
+	}
+	if provider.AuthorizationEndpoint == "" || provider.TokenEndpoint == "" || provider.JWKSURI == "" {
+		return nil, errors.New("OIDC discovery document is incomplete")
+	}
+	s.provider = provider
+	return provider, nil
+}
+
+func (s *OIDCService) oauthConfig(provider *oidcProviderMetadata) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     s.clientID,
+		ClientSecret: s.clientSecret,
+		RedirectURL:  s.redirectURL,
+		Scopes:       s.scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  provider.AuthorizationEndpoint,
+			TokenURL: provider.TokenEndpoint,
+		},
+	}
+}
+
+func (s *OIDCService) getJSON(ctx context.Context, url string, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+	return json.NewDecoder(resp.Body).Decode(target)
+}
+
+// oidcJSONWebKey is a public key of the provider (RFC 7517), RSA and P-256 keys are supported
+type oidcJSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k *oidcJSONWebKey) publicKey() (interface{}, error) {
+	if k.Use != "" && k.Use != "sig" {
+		return nil, fmt.Errorf("key use %q", k.Use)
+	}
+
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil || len(n) < 256 {
+			return nil, errors.New("invalid RSA modulus")
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+		if errX != nil || errY != nil || len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("invalid P-256 coordinates")
+		}
+		// Rejects points that are not on the curve
+		point := append(append([]byte{0x04}, x...), y...)
+		if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+			return nil, fmt.Errorf("invalid P-256 key: %w", err)
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func hashOIDCValue(value string) string {
+	hash := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestOIDCService tests the login against the mock provider, linking by verified email and the checks of the ID token
+func TestOIDCService(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	provider := testutil.NewMockOIDCProvider(t)
+	service := NewOIDCService(db, &config.Config{
+		JWTSecret:        "test-secret",
+		BaseURL:          "http://localhost:8080",
+		OIDCIssuerURL:    provider.Issuer(),
+		OIDCClientID:     provider.ClientID,
+		OIDCClientSecret: provider.ClientSecret,
+		OIDCGroupsClaim:  "groups",
+	})
+	ctx := context.Background()
+
+	userID := testutil.SeedTestUser(t, db, "staff@example.com", "Staff", "green")
+	testutil.SeedTestUser(t, db, "other@example.com", "Other", "green")
+
+	login := func(t *testing.T) (string, string, string, error) {
+		t.Helper()
+		authURL, state, err := service.Begin(ctx)
+		if err != nil {
+			t.Fatalf("Begin() failed: %v", err)
+		}
+		query := provider.Login(t, authURL)
+		if query.Get("state") != state {
+			t.Fatalf("Expected the state back, got %q", query.Get("state"))
+		}
+		loginCode, err := service.Callback(ctx, query.Get("state"), query.Get("code"))
+		return loginCode, query.Get("state"), query.Get("code"), err
+	}
+
+	t.Run("links the account by verified email", func(t *testing.T) {
+		provider.Email = "staff@example.com"
+		loginCode, state, code, err := login(t)
+		if err != nil {
+			t.Fatalf("Callback() failed: %v", err)
+		}
+
+		if got, err := service.Finish(loginCode); err != nil || got != userID {
+			t.Fatalf("Finish() = %d, %v, want %d", got, err, userID)
+		}
+		if _, err := service.Finish(loginCode); !errors.Is(err, ErrInvalidOIDCLogin) {
+			t.Errorf("Expected the login code to be used once, got %v", err)
+		}
+		if _, err := service.Callback(ctx, state, code); !errors.Is(err, ErrInvalidOIDCLogin) {
+			t.Errorf("Expected the state to be used once, got %v", err)
+		}
+
+		identity, _ := repository.NewOIDCRepository(db).FindIdentity(provider.Issuer(), provider.Subject)
+		if identity == nil || identity.UserID != userID {
+			t.Errorf("Expected the identity to be linked, got %+v", identity)
+		}
+	})
+
+	t.Run("finds linked identities by subject", func(t *testing.T) {
+		provider.Email = "renamed@example.com"
+		provider.EmailVerified = false
+		defer func() { provider.EmailVerified = true }()
+
+		loginCode, _, _, err := login(t)
+		if err != nil {
+			t.Fatalf("Callback() failed: %v", err)
+		}
+		if got, _ := service.Finish(loginCode); got != userID {
+			t.Errorf("Expected user %d, got %d", userID, got)
+		}
+	})
+
+	t.Run("rejects unverified and unknown email addresses", func(t *testing.T) {
+		provider.Subject = "staff-2"
+		defer func() { provider.Subject = "staff-1" }()
+
+		provider.Email = "other@example.com"
+		provider.EmailVerified = false
+		if _, _, _, err := login(t); !errors.Is(err, ErrOIDCEmailNotVerified) {
+			t.Errorf("Expected ErrOIDCEmailNotVerified, got %v", err)
+		}
+
+		provider.EmailVerified = true
+		provider.Email = "unknown@example.com"
+		if _, _, _, err := login(t); !errors.Is(err, ErrOIDCAccountNotFound) {
+			t.Errorf("Expected ErrOIDCAccountNotFound, got %v", err)
+		}
+	})
+
+	t.Run("rejects invalid ID tokens", func(t *testing.T) {
+		defer func() { provider.ModifyClaims = nil }()
+
+		for name, modify := range map[string]func(jwt.MapClaims){
+			"nonce":    func(claims jwt.MapClaims) { claims["nonce"] = "other" },
+			"audience": func(claims jwt.MapClaims) { claims["aud"] = "other-client" },
+			"issuer":   func(claims jwt.MapClaims) { claims["iss"] = "https://evil.example.com" },
+			"expired":  func(claims jwt.MapClaims) { claims["exp"] = claims["iat"].(int64) - 60 },
+		} {
+			provider.ModifyClaims = modify
+			if _, _, _, err := login(t); !errors.Is(err, ErrInvalidOIDCLogin) {
+				t.Errorf("%s: expected ErrInvalidOIDCLogin, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("rejects unknown states", func(t *testing.T) {
+		if _, err := service.Callback(ctx, "unknown", "code"); !errors.Is(err, ErrInvalidOIDCLogin) {
+			t.Errorf("Expected ErrInvalidOIDCLogin, got %v", err)
+		}
+	})
+}
+
+// TestOIDCService_GroupRoles tests granting and removing the roles mapped from groups
+func TestOIDCService_GroupRoles(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	provider := testutil.NewMockOIDCProvider(t)
+	service := NewOIDCService(db, &config.Config{
+		JWTSecret:        "test-secret",
+		BaseURL:          "http://localhost:8080",
+		OIDCIssuerURL:    provider.Issuer(),
+		OIDCClientID:     provider.ClientID,
+		OIDCClientSecret: provider.ClientSecret,
+		OIDCGroupsClaim:  "groups",
+		OIDCGroupRoles:   "shelter-admins=admin, kennel=kennel_staff",
+	})
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+
+	userID := testutil.SeedTestUser(t, db, "staff@example.com", "Staff", "green")
+	provider.Email = "staff@example.com"
+
+	// Not mapped, kept on every login
+	scheduler, _ := roleRepo.FindByName(models.RoleScheduler)
+	roleRepo.SetUserRoles(userID, []int{scheduler.ID})
+
+	login := func(groups ...string) {
+		t.Helper()
+		provider.Groups = groups
+		authURL, _, err := service.Begin(ctx)
+		if err != nil {
+			t.Fatalf("Begin() failed: %v", err)
+		}
+		query := provider.Login(t, authURL)
+		if _, err := service.Callback(ctx, query.Get("state"), query.Get("code")); err != nil {
+			t.Fatalf("Callback() failed: %v", err)
+		}
+	}
+	roleNames := func() []string {
+		roles, _ := roleRepo.FindByUser(userID)
+		names := []string{}
+		for _, role := range roles {
+			names = append(names, role.Name)
+		}
+		return names
+	}
+
+	login("shelter-admins", "kennel", "volunteers")
+	user, _ := userRepo.FindByID(userID)
+	if !user.IsAdmin {
+		t.Error("Expected the user to be promoted to admin")
+	}
+	if names := roleNames(); len(names) != 2 || names[0] != models.RoleKennelStaff || names[1] != models.RoleScheduler {
+		t.Errorf("Expected kennel_staff and scheduler, got %v", names)
+	}
+
+	login("volunteers")
+	user, _ = userRepo.FindByID(userID)
+	if user.IsAdmin {
+		t.Error("Expected the admin flag to be removed with the group")
+	}
+	if names := roleNames(); len(names) != 1 || names[0] != models.RoleScheduler {
+		t.Errorf("Expected only scheduler, got %v", names)
+	}
+
+	var events int
+	db.QueryRow(`SELECT COUNT(*) FROM domain_events WHERE event_type IN (?, ?)`, models.EventUserPromoted, models.EventUserDemoted).Scan(&events)
+	if events != 2 {
+		t.Errorf("Expected promotion and demotion events, got %d", events)
+	}
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+)
+
+// eventsRecorded wakes up the event dispatcher after events were committed
+var eventsRecorded = make(chan struct{}, 1)
+
+// OutboxService records domain events in the same transaction as the state change they describe
+// Either both the change and its events are stored or neither, the EventDispatcher delivers them afterwards.
+type OutboxService struct {
+	db     *sql.DB
+	events *repository.DomainEventRepository
+}
+
+// NewOutboxService creates a new outbox service
+func NewOutboxService(db *sql.DB) *OutboxService {
+	return &OutboxService{
+		db:     db,
+		events: repository.NewDomainEventRepository(db),
+	}
+}
+
+// Transact runs fn in a transaction and appends the events it returns to the outbox before committing
+// fn must only use repositories bound to tx (WithTx). Errors of fn are returned unchanged and roll back the transaction.
+func (s *OutboxService) Transact(fn func(tx *sql.Tx) ([]*models.DomainEvent, error)) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	events, err := fn(tx)
+	if err != nil {
+		return err
+	}
+
+	outbox := s.events.WithTx(tx)
+	for _, event := range events {
+		if err := outbox.Create(event); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if len(events) > 0 {
+		select {
+		case eventsRecorded <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
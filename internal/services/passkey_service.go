@@ -0,0 +1,325 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+)
+
+const (
+	passkeyRPName       = "Gassigeher"
+	passkeyChallengeTTL = 5 * time.Minute
+)
+
+var (
+	// ErrInvalidPasskey is returned when a registration or login response does not verify
+	ErrInvalidPasskey = errors.New("invalid passkey response")
+	// ErrPasskeyExists is returned when a passkey is registered a second time
+	ErrPasskeyExists = errors.New("passkey already registered")
+)
+
+// PasskeyService implements the WebAuthn registration and login ceremonies (WebAuthn Level 2, §7)
+// Passkeys are discoverable credentials with user verification, so they replace password and second factor.
+// The relying party is the host of BASE_URL.
+type PasskeyService struct {
+	repo   *repository.PasskeyRepository
+	rpID   string
+	origin string
+	now    func() time.Time
+}
+
+// NewPasskeyService creates a new passkey service
+func NewPasskeyService(db *sql.DB, cfg *config.Config) *PasskeyService {
+	return &PasskeyService{
+		repo:   repository.NewPasskeyRepository(db),
+		rpID:   cfg.GetWebAuthnRPID(),
+		origin: cfg.GetWebAuthnOrigin(),
+		now:    time.Now,
+	}
+}
+
+// BeginRegistration returns the options for navigator.credentials.create
+func (s *PasskeyService) BeginRegistration(user *models.User) (*models.PasskeyOptions, error) {
+	challenge, err := s.createChallenge(&user.ID, models.CeremonyRegistration)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.FindByUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	// The authenticator refuses to create a second passkey for the account
+	exclude := []models.PasskeyCredentialDescriptor{}
+	for _, passkey := range existing {
+		exclude = append(exclude, models.PasskeyCredentialDescriptor{Type: "public-key", ID: passkey.CredentialID, Transports: passkey.Transports})
+	}
+
+	name := user.Name
+	if user.Email != nil {
+		name = *user.Email
+	}
+
+	return &models.PasskeyOptions{PublicKey: &models.PasskeyCreationOptions{
+		Challenge: challenge,
+		RP:        models.PasskeyRelyingParty{ID: s.rpID, Name: passkeyRPName},
+		User:      models.PasskeyUserEntity{ID: userHandle(user.ID), Name: name, DisplayName: user.Name},
+		PubKeyCredParams: []models.PasskeyCredentialParameter{
+			{Type: "public-key", Alg: coseAlgES256},
+			{Type: "public-key", Alg: coseAlgEdDSA},
+			{Type: "public-key", Alg: coseAlgRS256},
+		},
+		Timeout:     int(passkeyChallengeTTL.Milliseconds()),
+		Attestation: "none",
+		AuthenticatorSelection: models.PasskeyAuthenticatorSelection{
+			ResidentKey:        "required",
+			RequireResidentKey: true,
+			UserVerification:   "required",
+		},
+		ExcludeCredentials: exclude,
+	}}, nil
+}
+
+// FinishRegistration verifies the response of navigator.credentials.create and stores the passkey
+func (s *PasskeyService) FinishRegistration(userID int, req *models.PasskeyRegistrationRequest) (*models.Passkey, error) {
+	credential := &req.Credential
+	_, challenge, err := s.verifyClientData(credential.Response.ClientDataJSON, "webauthn.create", models.CeremonyRegistration)
+	if err != nil {
+		return nil, err
+	}
+	if challenge.UserID == nil || *challenge.UserID != userID {
+		return nil, ErrInvalidPasskey
+	}
+
+	attestationObject, err := decodeBase64URL(credential.Response.AttestationObject)
+	if err != nil {
+		return nil, ErrInvalidPasskey
+	}
+	rawAuthData, err := parseAttestationObject(attestationObject)
+	if err != nil {
+		return nil, ErrInvalidPasskey
+	}
+	authData, err := s.verifyAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if authData.CredentialID == nil {
+		return nil, ErrInvalidPasskey
+	}
+	if rawID, err := decodeBase64URL(credential.RawID); err != nil || !bytes.Equal(rawID, authData.CredentialID) {
+		return nil, ErrInvalidPasskey
+	}
+	if _, err := coseAlgorithm(authData.PublicKey); err != nil {
+		return nil, ErrInvalidPasskey
+	}
+
+	credentialID := base64.RawURLEncoding.EncodeToString(authData.CredentialID)
+	existing, err := s.repo.FindByCredentialID(credentialID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrPasskeyExists
+	}
+
+	passkey := &models.Passkey{
+		UserID:       userID,
+		CredentialID: credentialID,
+		PublicKey:    base64.RawURLEncoding.EncodeToString(authData.PublicKey),
+		SignCount:    authData.SignCount,
+		Name:         req.Name,
+		Transports:   sanitizeTransports(credential.Response.Transports),
+		CreatedAt:    s.now(),
+	}
+	if err := s.repo.Create(passkey); err != nil {
+		return nil, err
+	}
+	return passkey, nil
+}
+
+// BeginLogin returns the options for navigator.credentials.get
+func (s *PasskeyService) BeginLogin() (*models.PasskeyOptions, error) {
+	challenge, err := s.createChallenge(nil, models.CeremonyLogin)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PasskeyOptions{PublicKey: &models.PasskeyRequestOptions{
+		Challenge:        challenge,
+		RPID:             s.rpID,
+		Timeout:          int(passkeyChallengeTTL.Milliseconds()),
+		UserVerification: "required",
+		AllowCredentials: []models.PasskeyCredentialDescriptor{},
+	}}, nil
+}
+
+// FinishLogin verifies the response of navigator.credentials.get and returns the ID of the user
+func (s *PasskeyService) FinishLogin(credential *models.PasskeyCredential) (int, error) {
+	rawID, err := decodeBase64URL(credential.RawID)
+	if err != nil {
+		return 0, ErrInvalidPasskey
+	}
+	passkey, err := s.repo.FindByCredentialID(base64.RawURLEncoding.EncodeToString(rawID))
+	if err != nil {
+		return 0, err
+	}
+	if passkey == nil {
+		return 0, ErrInvalidPasskey
+	}
+
+	clientDataJSON, challenge, err := s.verifyClientData(credential.Response.ClientDataJSON, "webauthn.get", models.CeremonyLogin)
+	if err != nil {
+		return 0, err
+	}
+	if challenge.UserID != nil {
+		return 0, ErrInvalidPasskey
+	}
+
+	rawAuthData, err := decodeBase64URL(credential.Response.AuthenticatorData)
+	if err != nil {
+		return 0, ErrInvalidPasskey
+	}
+	authData, err := s.verifyAuthenticatorData(rawAuthData)
+	if err != nil {
+		return 0, err
+	}
+
+	// The user handle is optional, if sent it must belong to the passkey's user
+	if credential.Response.UserHandle != "" {
+		handle, err := decodeBase64URL(credential.Response.UserHandle)
+		if err != nil || base64.RawURLEncoding.EncodeToString(handle) != userHandle(passkey.UserID) {
+			return 0, ErrInvalidPasskey
+		}
+	}
+
+	publicKey, err := decodeBase64URL(passkey.PublicKey)
+	if err != nil {
+		return 0, fmt.Errorf("invalid stored passkey: %w", err)
+	}
+	signature, err := decodeBase64URL(credential.Response.Signature)
+	if err != nil {
+		return 0, ErrInvalidPasskey
+	}
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	if err := verifyCOSESignature(publicKey, append(bytes.Clone(rawAuthData), clientDataHash[:]...), signature); err != nil {
+		return 0, ErrInvalidPasskey
+	}
+
+	// A counter that does not increase means the passkey was probably cloned, authenticators without counter send 0
+	if (authData.SignCount != 0 || passkey.SignCount != 0) && authData.SignCount <= passkey.SignCount {
+		return 0, ErrInvalidPasskey
+	}
+	updated, err := s.repo.UpdateSignCount(passkey.ID, passkey.SignCount, authData.SignCount, s.now())
+	if err != nil {
+		return 0, err
+	}
+	if !updated {
+		return 0, ErrInvalidPasskey
+	}
+	return passkey.UserID, nil
+}
+
+// DeleteExpiredChallenges removes challenges of ceremonies that were not completed
+func (s *PasskeyService) DeleteExpiredChallenges() (int64, error) {
+	return s.repo.DeleteExpiredChallenges(s.now())
+}
+
+func (s *PasskeyService) createChallenge(userID *int, ceremony string) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate challenge: %w", err)
+	}
+	challenge := base64.RawURLEncoding.EncodeToString(raw)
+
+	now := s.now()
+	err := s.repo.CreateChallenge(&models.WebAuthnChallenge{
+		UserID:    userID,
+		Challenge: challenge,
+		Ceremony:  ceremony,
+		ExpiresAt: now.Add(passkeyChallengeTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return "", err
+	}
+	return challenge, nil
+}
+
+// verifyClientData checks type and origin of the client data and takes its challenge, which is used only once
+func (s *PasskeyService) verifyClientData(encoded, ceremonyType, ceremony string) ([]byte, *models.WebAuthnChallenge, error) {
+	raw, err := decodeBase64URL(encoded)
+	if err != nil {
+		return nil, nil, ErrInvalidPasskey
+	}
+	var clientData struct {
+		Type        string `json:"type"`
+		Challenge   string `json:"challenge"`
+		Origin      string `json:"origin"`
+		CrossOrigin bool   `json:"crossOrigin"`
+	}
+	if err := json.Unmarshal(raw, &clientData); err != nil {
+		return nil, nil, ErrInvalidPasskey
+	}
+	if clientData.Type != ceremonyType || clientData.Origin != s.origin || clientData.CrossOrigin || clientData.Challenge == "" {
+		return nil, nil, ErrInvalidPasskey
+	}
+
+	challenge, err := s.repo.TakeChallenge(strings.TrimRight(clientData.Challenge, "="))
+	if err != nil {
+		return nil, nil, err
+	}
+	if challenge == nil || challenge.Ceremony != ceremony || s.now().After(challenge.ExpiresAt) {
+		return nil, nil, ErrInvalidPasskey
+	}
+	return raw, challenge, nil
+}
+
+// verifyAuthenticatorData checks that the passkey is bound to this site and the user was verified
+func (s *PasskeyService) verifyAuthenticatorData(raw []byte) (*authenticatorData, error) {
+	authData, err := parseAuthenticatorData(raw)
+	if err != nil {
+		return nil, ErrInvalidPasskey
+	}
+	rpIDHash := sha256.Sum256([]byte(s.rpID))
+	if !bytes.Equal(authData.RPIDHash, rpIDHash[:]) {
+		return nil, ErrInvalidPasskey
+	}
+	if authData.Flags&authDataUserPresent == 0 || authData.Flags&authDataUserVerified == 0 {
+		return nil, ErrInvalidPasskey
+	}
+	return authData, nil
+}
+
+// userHandle is the WebAuthn user ID of a user, base64url encoded
+func userHandle(userID int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(userID)))
+}
+
+// decodeBase64URL accepts base64url with and without padding, as sent by browsers and libraries
+func decodeBase64URL(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
+
+// sanitizeTransports keeps the known transport hints, they are passed back to the browser
+func sanitizeTransports(transports []string) []string {
+	known := map[string]bool{"usb": true, "nfc": true, "ble": true, "internal": true, "hybrid": true, "smart-card": true}
+	result := []string{}
+	for _, transport := range transports {
+		if known[transport] {
+			result = append(result, transport)
+		}
+	}
+	return result
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestPasskeyService tests registration and login with a software authenticator
+func TestPasskeyService(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	service := NewPasskeyService(db, &config.Config{BaseURL: "https://gassi.example.com"})
+	userRepo := repository.NewUserRepository(db)
+	passkeyRepo := repository.NewPasskeyRepository(db)
+
+	userID := testutil.SeedTestUser(t, db, "passkey@example.com", "Passkey User", "green")
+	user, _ := userRepo.FindByID(userID)
+	authenticator := testutil.NewPasskeyAuthenticator(t, "https://gassi.example.com", "gassi.example.com")
+
+	beginRegistration := func() *models.PasskeyCreationOptions {
+		options, err := service.BeginRegistration(user)
+		if err != nil {
+			t.Fatalf("BeginRegistration() failed: %v", err)
+		}
+		return options.PublicKey.(*models.PasskeyCreationOptions)
+	}
+	beginLogin := func() *models.PasskeyRequestOptions {
+		options, err := service.BeginLogin()
+		if err != nil {
+			t.Fatalf("BeginLogin() failed: %v", err)
+		}
+		return options.PublicKey.(*models.PasskeyRequestOptions)
+	}
+
+	t.Run("registration", func(t *testing.T) {
+		options := beginRegistration()
+		if options.RP.ID != "gassi.example.com" || options.User.ID != userHandle(userID) || len(options.ExcludeCredentials) != 0 {
+			t.Fatalf("Unexpected options: %+v", options)
+		}
+
+		credential := authenticator.Register(t, options)
+		passkey, err := service.FinishRegistration(userID, &models.PasskeyRegistrationRequest{Name: "Phone", Credential: credential})
+		if err != nil {
+			t.Fatalf("FinishRegistration() failed: %v", err)
+		}
+		if passkey.Name != "Phone" || passkey.CredentialID != credential.RawID || len(passkey.Transports) != 2 {
+			t.Errorf("Unexpected passkey: %+v", passkey)
+		}
+
+		// The challenge is used up
+		_, err = service.FinishRegistration(userID, &models.PasskeyRegistrationRequest{Name: "Phone", Credential: credential})
+		if !errors.Is(err, ErrInvalidPasskey) {
+			t.Errorf("Expected ErrInvalidPasskey for a replayed registration, got %v", err)
+		}
+
+		// Existing passkeys are excluded and cannot be registered again
+		options = beginRegistration()
+		if len(options.ExcludeCredentials) != 1 || options.ExcludeCredentials[0].ID != credential.RawID {
+			t.Errorf("Expected the passkey to be excluded, got %+v", options.ExcludeCredentials)
+		}
+		_, err = service.FinishRegistration(userID, &models.PasskeyRegistrationRequest{Name: "Phone", Credential: authenticator.Register(t, options)})
+		if !errors.Is(err, ErrPasskeyExists) {
+			t.Errorf("Expected ErrPasskeyExists, got %v", err)
+		}
+	})
+
+	t.Run("registration is rejected", func(t *testing.T) {
+		otherID := testutil.SeedTestUser(t, db, "other@example.com", "Other", "green")
+
+		tests := []struct {
+			name   string
+			userID int
+			modify func(a *testutil.PasskeyAuthenticator)
+		}{
+			{"other user", otherID, func(a *testutil.PasskeyAuthenticator) {}},
+			{"wrong origin", userID, func(a *testutil.PasskeyAuthenticator) { a.Origin = "https://evil.example.com" }},
+			{"wrong relying party", userID, func(a *testutil.PasskeyAuthenticator) { a.RPID = "evil.example.com" }},
+			{"without user verification", userID, func(a *testutil.PasskeyAuthenticator) { a.Flags = testutil.PasskeyUserPresent }},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				other := testutil.NewPasskeyAuthenticator(t, "https://gassi.example.com", "gassi.example.com")
+				tt.modify(other)
+				_, err := service.FinishRegistration(tt.userID, &models.PasskeyRegistrationRequest{Name: "Key", Credential: other.Register(t, beginRegistration())})
+				if !errors.Is(err, ErrInvalidPasskey) {
+					t.Errorf("Expected ErrInvalidPasskey, got %v", err)
+				}
+			})
+		}
+	})
+
+	t.Run("login", func(t *testing.T) {
+		credential := authenticator.Login(t, beginLogin())
+		got, err := service.FinishLogin(&credential)
+		if err != nil {
+			t.Fatalf("FinishLogin() failed: %v", err)
+		}
+		if got != userID {
+			t.Errorf("Expected user %d, got %d", userID, got)
+		}
+
+		passkey, _ := passkeyRepo.FindByCredentialID(credential.RawID)
+		if passkey.SignCount != authenticator.SignCount || passkey.LastUsedAt == nil {
+			t.Errorf("Expected the login to be recorded, got %+v", passkey)
+		}
+
+		// The challenge is used up
+		if _, err := service.FinishLogin(&credential); !errors.Is(err, ErrInvalidPasskey) {
+			t.Errorf("Expected ErrInvalidPasskey for a replayed login, got %v", err)
+		}
+	})
+
+	t.Run("login is rejected", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			modify func(c *models.PasskeyCredential)
+		}{
+			{"unknown passkey", func(c *models.PasskeyCredential) { c.RawID = "AAAA" }},
+			{"wrong signature", func(c *models.PasskeyCredential) {
+				c.Response.Signature = c.Response.Signature[:len(c.Response.Signature)-4] + "AAAA"
+			}},
+			{"other user handle", func(c *models.PasskeyCredential) { c.Response.UserHandle = userHandle(userID + 1) }},
+			{"registration challenge", func(c *models.PasskeyCredential) {
+				other := authenticator.Login(t, &models.PasskeyRequestOptions{Challenge: beginRegistration().Challenge})
+				*c = other
+			}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				credential := authenticator.Login(t, beginLogin())
+				tt.modify(&credential)
+				if _, err := service.FinishLogin(&credential); !errors.Is(err, ErrInvalidPasskey) {
+					t.Errorf("Expected ErrInvalidPasskey, got %v", err)
+				}
+			})
+		}
+	})
+
+	t.Run("cloned passkey", func(t *testing.T) {
+		// A counter that does not increase is rejected, the first login stored 1
+		authenticator.SignCount = 0
+		credential := authenticator.Login(t, beginLogin())
+		if _, err := service.FinishLogin(&credential); !errors.Is(err, ErrInvalidPasskey) {
+			t.Errorf("Expected ErrInvalidPasskey for a lower counter, got %v", err)
+		}
+
+		authenticator.SignCount = 100
+		credential = authenticator.Login(t, beginLogin())
+		if _, err := service.FinishLogin(&credential); err != nil {
+			t.Errorf("FinishLogin() failed: %v", err)
+		}
+	})
+
+	t.Run("expired challenge", func(t *testing.T) {
+		options := beginLogin()
+		service.now = func() time.Time { return time.Now().Add(passkeyChallengeTTL + time.Minute) }
+		defer func() { service.now = time.Now }()
+
+		credential := authenticator.Login(t, options)
+		if _, err := service.FinishLogin(&credential); !errors.Is(err, ErrInvalidPasskey) {
+			t.Errorf("Expected ErrInvalidPasskey, got %v", err)
+		}
+
+		beginLogin()
+		if deleted, err := service.DeleteExpiredChallenges(); err != nil || deleted == 0 {
+			t.Errorf("Expected expired challenges to be deleted, got %d (%v)", deleted, err)
+		}
+	})
+}
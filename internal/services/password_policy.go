@@ -0,0 +1,144 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+)
+
+//go:embed password_lists/breached_passwords.txt
+var bundledBreachedPasswords string
+
+// breachedPasswords holds the sorted SHA-1 hashes of breached passwords, see LoadBreachedPasswords
+var (
+	breachedPasswordsMu sync.RWMutex
+	breachedPasswords   [][sha1.Size]byte
+)
+
+// PasswordPolicy checks new passwords at registration, reset and change
+type PasswordPolicy struct {
+	minLength   int
+	minScore    int // Strength score 0-4 of estimatePasswordStrength, 0 accepts any
+	breachCheck bool
+}
+
+// NewPasswordPolicy creates the password policy of the configuration
+func NewPasswordPolicy(cfg *config.Config) *PasswordPolicy {
+	return &PasswordPolicy{
+		minLength:   cfg.GetPasswordMinLength(),
+		minScore:    cfg.PasswordMinScore,
+		breachCheck: cfg.PasswordBreachCheck,
+	}
+}
+
+// Validate checks length, character classes, breaches and strength of a password, in this order
+// userInputs are name and email of the user, passwords made of them are easy to guess.
+// The error is a ValidationError whose code tells the user what to change.
+func (p *PasswordPolicy) Validate(password string, userInputs ...string) error {
+	if err := validatePasswordRules(password, p.minLength); err != nil {
+		return err
+	}
+
+	if p.breachCheck && IsBreachedPassword(password) {
+		return &models.ValidationError{Field: "password", Message: "password appeared in a data breach", Code: "password_breached"}
+	}
+
+	if score, hint := estimatePasswordStrength(password, userInputs); score < p.minScore {
+		return &models.ValidationError{Field: "password", Message: passwordHintMessages[hint], Code: hint}
+	}
+	return nil
+}
+
+// IsBreachedPassword reports whether the password is in the list of breached passwords
+func IsBreachedPassword(password string) bool {
+	breachedPasswordsMu.RLock()
+	hashes := breachedPasswords
+	breachedPasswordsMu.RUnlock()
+
+	if hashes == nil {
+		if err := LoadBreachedPasswords(""); err != nil {
+			return false
+		}
+		breachedPasswordsMu.RLock()
+		hashes = breachedPasswords
+		breachedPasswordsMu.RUnlock()
+	}
+
+	sum := sha1.Sum([]byte(password))
+	i := sort.Search(len(hashes), func(i int) bool { return bytes.Compare(hashes[i][:], sum[:]) >= 0 })
+	return i < len(hashes) && hashes[i] == sum
+}
+
+// LoadBreachedPasswords loads the bundled list of breached passwords and the hashes of the file, if any
+// The file has one SHA-1 hash per line, optionally followed by ":count" like the Pwned Passwords downloads.
+// Lines starting with # are comments. It is read once at startup, replacing it takes effect on restart.
+func LoadBreachedPasswords(path string) error {
+	hashes, err := readPasswordHashes(strings.NewReader(bundledBreachedPasswords))
+	if err != nil {
+		return fmt.Errorf("failed to read bundled breached passwords: %w", err)
+	}
+
+	if path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open breached passwords: %w", err)
+		}
+		defer file.Close()
+
+		extra, err := readPasswordHashes(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		hashes = append(hashes, extra...)
+	}
+
+	sort.Slice(hashes, func(i, j int) bool { return bytes.Compare(hashes[i][:], hashes[j][:]) < 0 })
+	unique := hashes[:0]
+	for i, hash := range hashes {
+		if i == 0 || hash != hashes[i-1] {
+			unique = append(unique, hash)
+		}
+	}
+
+	breachedPasswordsMu.Lock()
+	breachedPasswords = unique
+	breachedPasswordsMu.Unlock()
+	return nil
+}
+
+func readPasswordHashes(r io.Reader) ([][sha1.Size]byte, error) {
+	var hashes [][sha1.Size]byte
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text, _, _ = strings.Cut(text, ":")
+
+		// Checked before decoding, hex.Decode panics for lines longer than the hash
+		var hash [sha1.Size]byte
+		if len(text) != 2*sha1.Size {
+			return nil, fmt.Errorf("line %d: invalid SHA-1 hash", line)
+		}
+		if _, err := hex.Decode(hash[:], []byte(text)); err != nil {
+			return nil, fmt.Errorf("line %d: invalid SHA-1 hash", line)
+		}
+		hashes = append(hashes, hash)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
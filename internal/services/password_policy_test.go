@@ -0,0 +1,86 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+)
+
+// TestPasswordPolicy tests the order of the checks and their error codes
+func TestPasswordPolicy(t *testing.T) {
+	policy := NewPasswordPolicy(&config.Config{PasswordMinLength: 10, PasswordMinScore: 3, PasswordBreachCheck: true})
+
+	tests := []struct {
+		name     string
+		password string
+		code     string
+	}{
+		{"too short", "Ab1cdefg", "password_too_short"},
+		{"missing number", "Leine-Kompass-Regen", "password_missing_number"},
+		{"breached", "Passwort123!", "password_breached"},
+		{"personal", "Mustermann2!x", "password_contains_personal_info"},
+		{"too weak", "Hundeleine7", "password_common_word"},
+		{"strong", "Leine7 Kompass Regentonne", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.password, "Max Mustermann", "max@example.com")
+			var validationErr *models.ValidationError
+			switch {
+			case tt.code == "" && err != nil:
+				t.Errorf("Expected the password to be accepted, got %v", err)
+			case tt.code != "" && (!errors.As(err, &validationErr) || validationErr.Code != tt.code):
+				t.Errorf("Expected code %s, got %v", tt.code, err)
+			}
+		})
+	}
+
+	t.Run("minimum length is at least 8", func(t *testing.T) {
+		lenient := NewPasswordPolicy(&config.Config{PasswordMinLength: 4})
+		if err := lenient.Validate("Ab1cd"); err == nil {
+			t.Error("Expected passwords shorter than 8 characters to be rejected")
+		}
+		if err := lenient.Validate("Passwort123!"); err != nil {
+			t.Errorf("Expected breach and strength checks to be off, got %v", err)
+		}
+	})
+}
+
+// TestLoadBreachedPasswords tests the bundled list and an additional file in Pwned Passwords format
+func TestLoadBreachedPasswords(t *testing.T) {
+	t.Cleanup(func() { LoadBreachedPasswords("") })
+
+	if !IsBreachedPassword("Passwort1") || IsBreachedPassword("Leine7 Kompass Regentonne") {
+		t.Fatal("Expected only the common password to be in the bundled list")
+	}
+
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	// SHA-1 of "Leine7 Kompass Regentonne" in lower case, count as in the downloads
+	content := "# extra hashes\n929ad77d892332e9e3db1425dcb02e1b31ef33d0:3\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadBreachedPasswords(path); err != nil {
+		t.Fatalf("Failed to load breached passwords: %v", err)
+	}
+	if !IsBreachedPassword("Leine7 Kompass Regentonne") || !IsBreachedPassword("Passwort1") {
+		t.Error("Expected the hashes of the file in addition to the bundled list")
+	}
+
+	for _, invalid := range []string{
+		"not-a-hash\n",
+		// SHA-256 of "password", longer than a SHA-1 hash
+		"5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8\n",
+		"929ad77d892332e9e3db1425dcb02e1b31ef33\n",
+	} {
+		os.WriteFile(path, []byte(invalid), 0o600)
+		if err := LoadBreachedPasswords(path); err == nil {
+			t.Errorf("Expected an error for the invalid line %q", invalid)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+package services
+
+import (
+	_ "embed"
+	"math"
+	"strings"
+	"unicode"
+)
+
+//go:embed password_lists/common_words.txt
+var commonWordsList string
+
+// Password strength scores, like zxcvbn: 0 too guessable ... 4 very unguessable
+// Thresholds are log2 of the guesses needed (10^3, 10^6, 10^8 and 10^10 guesses).
+var passwordScoreBits = [4]float64{10, 19.93, 26.58, 33.22}
+
+// maxEstimatedPasswordLength limits the work per password, longer passwords are strong anyway
+const maxEstimatedPasswordLength = 100
+
+// Hints name the weakest part of a password, they are error codes of PasswordPolicy.Validate
+const (
+	passwordHintTooWeak      = "password_too_weak"
+	passwordHintCommonWord   = "password_common_word"
+	passwordHintPersonalInfo = "password_contains_personal_info"
+	passwordHintSequence     = "password_contains_sequence"
+	passwordHintRepeated     = "password_contains_repetition"
+	passwordHintKeyboard     = "password_keyboard_pattern"
+	passwordHintDate         = "password_contains_date"
+)
+
+// passwordHintMessages are the English messages of the hints, translated via the i18n catalogs
+var passwordHintMessages = map[string]string{
+	passwordHintTooWeak:      "password is too easy to guess, use a longer password or several uncommon words",
+	passwordHintCommonWord:   "password is based on a common password or word",
+	passwordHintPersonalInfo: "password must not contain your name or email address",
+	passwordHintSequence:     "password contains a sequence like abc or 1234",
+	passwordHintRepeated:     "password contains repeated characters or words",
+	passwordHintKeyboard:     "password contains a keyboard pattern like qwertz",
+	passwordHintDate:         "password contains a year or date",
+}
+
+// Keyboard rows and columns of German and English layouts, patterns are parts of them
+var keyboardPatterns = []string{
+	"qwertzuiopü", "asdfghjklöä", "yxcvbnm",
+	"qwertyuiop", "zxcvbnm",
+	"1qaz2wsx3edc4rfv5tgb6zhn7ujm8ik9ol0p",
+	"1qaz2wsx3edc4rfv5tgb6yhn",
+}
+
+// Leetspeak substitutions undone before looking up words
+var leetLetters = map[rune]rune{
+	'4': 'a', '@': 'a', '8': 'b', '(': 'c', '3': 'e', '6': 'g',
+	'1': 'i', '!': 'i', '|': 'i', '0': 'o', '$': 's', '5': 's', '7': 't', '+': 't',
+}
+
+// commonWords maps the bundled words to their rank, 1 is the most common
+var commonWords = func() map[string]int {
+	words := map[string]int{}
+	for _, line := range strings.Split(commonWordsList, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, ok := words[line]; !ok {
+			words[line] = len(words) + 1
+		}
+	}
+	return words
+}()
+
+// passwordMatch is a guessable part of a password
+type passwordMatch struct {
+	start, end int     // Rune positions, end exclusive
+	bits       float64 // log2 of the guesses needed for the part
+	hint       string
+}
+
+// estimatePasswordStrength returns the strength score (0-4) of a password and a hint for its weakest part
+// Like zxcvbn, the password is split into the parts that need the fewest guesses: words from the
+// bundled list or the user inputs (name, email), sequences, repetitions, keyboard patterns and dates.
+// Other characters are guessed one by one.
+func estimatePasswordStrength(password string, userInputs []string) (int, string) {
+	runes := []rune(password)
+	if len(runes) > maxEstimatedPasswordLength {
+		runes = runes[:maxEstimatedPasswordLength]
+	}
+
+	matches, bits := splitPassword(runes, personalWords(userInputs))
+
+	score := 0
+	for score < len(passwordScoreBits) && bits >= passwordScoreBits[score] {
+		score++
+	}
+
+	// The longest guessable part is the most helpful hint
+	hint, longest := passwordHintTooWeak, 0
+	for _, match := range matches {
+		if match.end-match.start > longest {
+			hint, longest = match.hint, match.end-match.start
+		}
+	}
+	return score, hint
+}
+
+// splitPassword returns the parts of the password that need the fewest guesses in total and their log2
+func splitPassword(runes []rune, personal map[string]int) ([]passwordMatch, float64) {
+	candidates := findPasswordMatches(runes, personal)
+
+	// best[i] is the cheapest guess of the first i runes, every part costs one extra bit
+	best := make([]float64, len(runes)+1)
+	last := make([]*passwordMatch, len(runes)+1)
+	for i := 1; i <= len(runes); i++ {
+		best[i] = best[i-1] + bruteforceBits(runes[i-1])
+		for j := range candidates {
+			match := &candidates[j]
+			if match.end == i && best[match.start]+match.bits+1 < best[i] {
+				best[i] = best[match.start] + match.bits + 1
+				last[i] = match
+			}
+		}
+	}
+
+	var matches []passwordMatch
+	for i := len(runes); i > 0; {
+		if last[i] == nil {
+			i--
+			continue
+		}
+		matches = append(matches, *last[i])
+		i = last[i].start
+	}
+	return matches, best[len(runes)]
+}
+
+func findPasswordMatches(runes []rune, personal map[string]int) []passwordMatch {
+	lower := []rune(strings.ToLower(string(runes)))
+	if len(lower) != len(runes) {
+		lower = runes // Lower-casing changed the length, match case-sensitive
+	}
+
+	var matches []passwordMatch
+	matches = append(matches, wordMatches(runes, lower, personal)...)
+	matches = append(matches, sequenceMatches(lower)...)
+	matches = append(matches, repeatMatches(runes)...)
+	matches = append(matches, keyboardMatches(lower)...)
+	matches = append(matches, dateMatches(lower)...)
+	return matches
+}
+
+// wordMatches finds common and personal words, also capitalized and in leetspeak
+func wordMatches(runes, lower []rune, personal map[string]int) []passwordMatch {
+	var matches []passwordMatch
+	for start := 0; start < len(lower); start++ {
+		for end := start + 3; end <= len(lower); end++ {
+			word := lower[start:end]
+			unleeted := make([]rune, len(word))
+			substitutions := 0
+			for i, r := range word {
+				unleeted[i] = r
+				if letter, ok := leetLetters[r]; ok {
+					unleeted[i] = letter
+					substitutions++
+				}
+			}
+
+			for _, candidate := range []struct {
+				word  string
+				extra int
+			}{{string(word), 0}, {string(unleeted), substitutions}} {
+				if candidate.extra > 0 && candidate.word == string(word) {
+					continue
+				}
+				hint := passwordHintPersonalInfo
+				rank, ok := personal[candidate.word]
+				if !ok {
+					hint = passwordHintCommonWord
+					rank, ok = commonWords[candidate.word]
+				}
+				if !ok {
+					continue
+				}
+				bits := math.Log2(float64(rank)) + uppercaseBits(runes[start:end]) + float64(candidate.extra)
+				matches = append(matches, passwordMatch{start: start, end: end, bits: bits, hint: hint})
+			}
+		}
+	}
+	return matches
+}
+
+// uppercaseBits returns the guesses for capital letters of a word: none, first or all are common
+func uppercaseBits(word []rune) float64 {
+	upper := 0
+	for _, r := range word {
+		if unicode.IsUpper(r) {
+			upper++
+		}
+	}
+	switch {
+	case upper == 0:
+		return 0
+	case upper == len(word) || (upper == 1 && unicode.IsUpper(word[0])):
+		return 1
+	default:
+		return float64(upper) + 1
+	}
+}
+
+// sequenceMatches finds runs like abc, 9876 or xyz of at least three characters
+func sequenceMatches(lower []rune) []passwordMatch {
+	var matches []passwordMatch
+	for start := 0; start+2 < len(lower); start++ {
+		delta := lower[start+1] - lower[start]
+		if (delta != 1 && delta != -1) || !sameClass(lower[start], lower[start+1]) {
+			continue
+		}
+		end := start + 2
+		for end < len(lower) && lower[end]-lower[end-1] == delta && sameClass(lower[end], lower[start]) {
+			end++
+		}
+		for e := start + 3; e <= end; e++ {
+			bits := math.Log2(float64(e - start))
+			if strings.ContainsRune("az019", lower[start]) {
+				bits += 2
+			} else {
+				bits += bruteforceBits(lower[start])
+			}
+			if delta < 0 {
+				bits++
+			}
+			matches = append(matches, passwordMatch{start: start, end: e, bits: bits, hint: passwordHintSequence})
+		}
+	}
+	return matches
+}
+
+// repeatMatches finds repeated characters (aaa) and repeated blocks (hund1hund1)
+func repeatMatches(runes []rune) []passwordMatch {
+	var matches []passwordMatch
+	for start := 0; start < len(runes); start++ {
+		for size := 1; start+2*size <= len(runes); size++ {
+			block := string(runes[start : start+size])
+			end := start + size
+			for end+size <= len(runes) && string(runes[end:end+size]) == block {
+				end += size
+			}
+			count := (end - start) / size
+			if count < 2 || (size == 1 && count < 3) {
+				continue
+			}
+			_, blockBits := splitPassword(runes[start:start+size], nil)
+			matches = append(matches, passwordMatch{start: start, end: end, bits: blockBits + math.Log2(float64(count)), hint: passwordHintRepeated})
+		}
+	}
+	return matches
+}
+
+// keyboardMatches finds at least four neighboring keys, e.g. qwertz or lkjh
+func keyboardMatches(lower []rune) []passwordMatch {
+	var matches []passwordMatch
+	for start := 0; start+4 <= len(lower); start++ {
+		for end := start + 4; end <= len(lower); end++ {
+			part := string(lower[start:end])
+			if !onKeyboard(part) {
+				break
+			}
+			bits := math.Log2(50) + math.Log2(float64(end-start))
+			matches = append(matches, passwordMatch{start: start, end: end, bits: bits, hint: passwordHintKeyboard})
+		}
+	}
+	return matches
+}
+
+func onKeyboard(part string) bool {
+	reversed := []rune(part)
+	for i, j := 0, len(reversed)-1; i < j; i, j = i+1, j-1 {
+		reversed[i], reversed[j] = reversed[j], reversed[i]
+	}
+	for _, pattern := range keyboardPatterns {
+		if strings.Contains(pattern, part) || strings.Contains(pattern, string(reversed)) {
+			return true
+		}
+	}
+	return false
+}
+
+// dateMatches finds years from 1900 to 2099 and dates like 24121990 or 241290
+func dateMatches(lower []rune) []passwordMatch {
+	var matches []passwordMatch
+	for start := 0; start < len(lower); start++ {
+		for _, length := range []int{4, 6, 8} {
+			end := start + length
+			if end > len(lower) || !allDigits(lower[start:end]) {
+				continue
+			}
+			digits := string(lower[start:end])
+			switch {
+			case length == 4 && (strings.HasPrefix(digits, "19") || strings.HasPrefix(digits, "20")):
+				matches = append(matches, passwordMatch{start: start, end: end, bits: math.Log2(200), hint: passwordHintDate})
+			case length > 4 && validDayMonth(digits):
+				matches = append(matches, passwordMatch{start: start, end: end, bits: math.Log2(366 * 100), hint: passwordHintDate})
+			}
+		}
+	}
+	return matches
+}
+
+func validDayMonth(digits string) bool {
+	day := int(digits[0]-'0')*10 + int(digits[1]-'0')
+	month := int(digits[2]-'0')*10 + int(digits[3]-'0')
+	return day >= 1 && day <= 31 && month >= 1 && month <= 12
+}
+
+func allDigits(runes []rune) bool {
+	for _, r := range runes {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func sameClass(a, b rune) bool {
+	return unicode.IsDigit(a) == unicode.IsDigit(b) && unicode.IsLetter(a) == unicode.IsLetter(b)
+}
+
+// bruteforceBits returns log2 of the guesses for a single character of its class
+func bruteforceBits(r rune) float64 {
+	switch {
+	case r >= '0' && r <= '9':
+		return math.Log2(10)
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		return math.Log2(26)
+	case r < unicode.MaxASCII:
+		return math.Log2(33)
+	default:
+		return math.Log2(100)
+	}
+}
+
+// personalWords splits name and email of the user into the words guessed first
+func personalWords(userInputs []string) map[string]int {
+	words := map[string]int{}
+	add := func(word string) {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if len([]rune(word)) >= 3 {
+			if _, ok := words[word]; !ok {
+				words[word] = len(words) + 1
+			}
+		}
+	}
+
+	for _, input := range userInputs {
+		add(input)
+		for _, part := range strings.FieldsFunc(input, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			add(part)
+		}
+	}
+	return words
+}
@@ -0,0 +1,37 @@
+package services
+
+import "testing"
+
+// TestEstimatePasswordStrength tests scores and hints of guessable and strong passwords
+func TestEstimatePasswordStrength(t *testing.T) {
+	userInputs := []string{"Max Mustermann", "max.mustermann@example.com"}
+
+	tests := []struct {
+		password string
+		maxScore int
+		minScore int
+		hint     string
+	}{
+		{"Passwort1", 1, 0, passwordHintCommonWord},
+		{"P@ssw0rd", 1, 0, passwordHintCommonWord},
+		{"Mustermann7!", 2, 0, passwordHintPersonalInfo},
+		{"abcdefgh12", 2, 0, passwordHintSequence},
+		{"aaaaaaaaA1", 2, 0, passwordHintRepeated},
+		{"Qwertzuiop1", 2, 0, passwordHintKeyboard},
+		{"Lx24121990", 2, 0, passwordHintDate},
+		{"xK9#mQ2pWv", 4, 4, ""},
+		{"Leine Kompass Regentonne", 4, 4, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			score, hint := estimatePasswordStrength(tt.password, userInputs)
+			if score < tt.minScore || score > tt.maxScore {
+				t.Errorf("Expected a score from %d to %d, got %d", tt.minScore, tt.maxScore, score)
+			}
+			if tt.hint != "" && hint != tt.hint {
+				t.Errorf("Expected hint %s, got %s", tt.hint, hint)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+)
+
+const (
+	// loginLockoutThreshold is the number of failed password logins before an account is locked
+	loginLockoutThreshold = 5
+	// loginLockoutBase is the first lock, it doubles with every further failure
+	loginLockoutBase = time.Minute
+	// loginLockoutMax is the longest lock
+	loginLockoutMax = time.Hour
+	// loginFailureReset is how long failed logins are counted
+	loginFailureReset = 24 * time.Hour
+)
+
+// RateLimitService counts requests per policy and client in the database, so limits survive restarts
+// and are shared by all instances of the server.
+type RateLimitService struct {
+	repo *repository.RateLimitRepository
+	now  func() time.Time
+}
+
+// NewRateLimitService creates a new rate limit service
+func NewRateLimitService(db *sql.DB) *RateLimitService {
+	return &RateLimitService{
+		repo: repository.NewRateLimitRepository(db),
+		now:  time.Now,
+	}
+}
+
+// Allow counts the request of the client and returns whether it is within the limit of the policy
+// For rejected requests it returns the time until the window ends.
+func (s *RateLimitService) Allow(policy models.RateLimitPolicy, client string) (bool, time.Duration, error) {
+	now := s.now()
+	hits, expiresAt, err := s.repo.Hit(policy.Name+":"+client, policy.Window, now)
+	if err != nil {
+		return false, 0, err
+	}
+	if hits > policy.Limit {
+		return false, expiresAt.Sub(now), nil
+	}
+	return true, 0, nil
+}
+
+// DeleteExpired deletes expired request counters and failed logins that are no longer counted
+func (s *RateLimitService) DeleteExpired() (int64, error) {
+	now := s.now()
+	return s.repo.DeleteExpired(now, now.Add(-loginFailureReset))
+}
+
+// LoginLockoutService locks accounts after repeated failed password logins
+// From the fifth failure within a day the account is locked for a minute, doubling with every
+// further failure up to an hour. The lock applies to the email address, whether or not it exists.
+type LoginLockoutService struct {
+	repo *repository.RateLimitRepository
+	now  func() time.Time
+}
+
+// NewLoginLockoutService creates a new login lockout service
+func NewLoginLockoutService(db *sql.DB) *LoginLockoutService {
+	return &LoginLockoutService{
+		repo: repository.NewRateLimitRepository(db),
+		now:  time.Now,
+	}
+}
+
+// LockedFor returns how long password logins of the email address are locked, zero if they are not
+func (s *LoginLockoutService) LockedFor(email string) (time.Duration, error) {
+	lockout, err := s.repo.FindLockout(loginAccountKey(email))
+	if err != nil || lockout == nil || lockout.LockedUntil == nil {
+		return 0, err
+	}
+	if remaining := lockout.LockedUntil.Sub(s.now()); remaining > 0 {
+		return remaining, nil
+	}
+	return 0, nil
+}
+
+// RecordFailure counts a failed password login and returns the lock it caused, zero below the threshold
+func (s *LoginLockoutService) RecordFailure(email string) (time.Duration, error) {
+	now := s.now()
+	key := loginAccountKey(email)
+	failures, err := s.repo.RecordFailure(key, now, now.Add(-loginFailureReset))
+	if err != nil || failures < loginLockoutThreshold {
+		return 0, err
+	}
+
+	lock := loginLockoutBase
+	for i := loginLockoutThreshold; i < failures && lock < loginLockoutMax; i++ {
+		lock *= 2
+	}
+	if lock > loginLockoutMax {
+		lock = loginLockoutMax
+	}
+	if err := s.repo.SetLockedUntil(key, now.Add(lock)); err != nil {
+		return 0, err
+	}
+	return lock, nil
+}
+
+// Reset forgets the failed logins after a successful login
+func (s *LoginLockoutService) Reset(email string) error {
+	return s.repo.DeleteLockout(loginAccountKey(email))
+}
+
+func loginAccountKey(email string) string {
+	hash := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(hash[:])
+}
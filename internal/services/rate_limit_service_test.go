@@ -0,0 +1,81 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestRateLimitService tests the limit and the retry time of a policy
+func TestRateLimitService(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	service := NewRateLimitService(db)
+	now := time.Now()
+	service.now = func() time.Time { return now }
+	policy := models.RateLimitPolicy{Name: "test", Limit: 2, Window: time.Minute}
+
+	for i := 0; i < 2; i++ {
+		if allowed, _, err := service.Allow(policy, "203.0.113.1"); err != nil || !allowed {
+			t.Fatalf("Request %d: expected to be allowed (%v)", i+1, err)
+		}
+	}
+
+	now = now.Add(20 * time.Second)
+	allowed, retryAfter, _ := service.Allow(policy, "203.0.113.1")
+	if allowed || retryAfter != 40*time.Second {
+		t.Errorf("Expected rejection with 40s left, got %v, %v", allowed, retryAfter)
+	}
+
+	other := models.RateLimitPolicy{Name: "other", Limit: 2, Window: time.Minute}
+	if allowed, _, _ := service.Allow(other, "203.0.113.1"); !allowed {
+		t.Error("Expected policies to be counted separately")
+	}
+
+	now = now.Add(time.Minute)
+	if allowed, _, _ := service.Allow(policy, "203.0.113.1"); !allowed {
+		t.Error("Expected the request to be allowed in the next window")
+	}
+}
+
+// TestLoginLockoutService tests the lock after five failures and the exponential backoff
+func TestLoginLockoutService(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	service := NewLoginLockoutService(db)
+	now := time.Now()
+	service.now = func() time.Time { return now }
+
+	for i := 0; i < 4; i++ {
+		if lock, err := service.RecordFailure("User@Example.com"); err != nil || lock != 0 {
+			t.Fatalf("Failure %d: expected no lock, got %v (%v)", i+1, lock, err)
+		}
+	}
+
+	for _, want := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute} {
+		lock, _ := service.RecordFailure(" user@example.com ")
+		if lock != want {
+			t.Errorf("Expected a lock of %v, got %v", want, lock)
+		}
+		if locked, _ := service.LockedFor("user@example.com"); locked != want {
+			t.Errorf("Expected LockedFor() = %v, got %v", want, locked)
+		}
+	}
+
+	for i := 0; i < 10; i++ {
+		service.RecordFailure("user@example.com")
+	}
+	if locked, _ := service.LockedFor("user@example.com"); locked != time.Hour {
+		t.Errorf("Expected the lock to be capped at an hour, got %v", locked)
+	}
+
+	now = now.Add(time.Hour)
+	if locked, _ := service.LockedFor("user@example.com"); locked != 0 {
+		t.Errorf("Expected the lock to expire, got %v", locked)
+	}
+
+	service.Reset("user@example.com")
+	if lock, _ := service.RecordFailure("user@example.com"); lock != 0 {
+		t.Errorf("Expected the failures to be reset, got a lock of %v", lock)
+	}
+}
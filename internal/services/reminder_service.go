@@ -0,0 +1,206 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+)
+
+// legacyReminderKey marks reminders sent before per-reminder tracking existed
+const legacyReminderKey = "legacy"
+
+// ReminderService decides which booking reminders are due
+type ReminderService struct {
+	bookingRepo  *repository.BookingRepository
+	reminderRepo *repository.BookingReminderRepository
+	userRepo     *repository.UserRepository
+	settingsRepo *repository.SettingsRepository
+}
+
+// DueReminder is a booking with reminders that are due and not sent yet
+// Several due reminders (e.g. after downtime) are delivered as one email
+type DueReminder struct {
+	Booking *models.Booking
+	Keys    []string
+}
+
+// NewReminderService creates a new reminder service
+func NewReminderService(bookingRepo *repository.BookingRepository, reminderRepo *repository.BookingReminderRepository, userRepo *repository.UserRepository, settingsRepo *repository.SettingsRepository) *ReminderService {
+	return &ReminderService{
+		bookingRepo:  bookingRepo,
+		reminderRepo: reminderRepo,
+		userRepo:     userRepo,
+		settingsRepo: settingsRepo,
+	}
+}
+
+// DefaultSchedule returns the admin-configured reminder schedule
+func (s *ReminderService) DefaultSchedule() models.ReminderSchedule {
+	if setting, err := s.settingsRepo.Get(models.SettingBookingReminders); err == nil && setting != nil {
+		if schedule, err := models.ParseReminderSchedule(setting.Value); err == nil {
+			return schedule
+		}
+		log.Printf("Warning: invalid %s setting %q, using default", models.SettingBookingReminders, setting.Value)
+	}
+
+	schedule, _ := models.ParseReminderSchedule(models.DefaultBookingReminders)
+	return schedule
+}
+
+// Limits returns the limits for personal reminder schedules
+func (s *ReminderService) Limits() models.ReminderLimits {
+	return models.ReminderLimits{
+		MaxCount:         s.intSetting(models.SettingReminderMaxCount, models.DefaultReminderMaxCount),
+		MaxDaysBefore:    s.intSetting(models.SettingReminderMaxDaysBefore, models.DefaultReminderMaxDaysBefore),
+		MinMinutesBefore: models.MinReminderMinutesBefore,
+	}
+}
+
+// ScheduleForUser returns the schedule a user receives and whether it is the default
+// Personal schedules outside the current limits fall back to the default
+func (s *ReminderService) ScheduleForUser(userID int) (models.ReminderSchedule, bool, error) {
+	stored, err := s.userRepo.GetReminderSchedule(userID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if stored != nil {
+		schedule, err := models.ParseReminderSchedule(*stored)
+		if err == nil && schedule.Validate(s.Limits()) == nil {
+			return schedule, false, nil
+		}
+	}
+
+	return s.DefaultSchedule(), true, nil
+}
+
+// SetUserSchedule validates and stores a personal schedule
+func (s *ReminderService) SetUserSchedule(userID int, schedule models.ReminderSchedule) error {
+	if err := schedule.Validate(s.Limits()); err != nil {
+		return err
+	}
+
+	value := schedule.String()
+	return s.userRepo.SetReminderSchedule(userID, &value)
+}
+
+// ResetUserSchedule makes a user use the default schedule again
+func (s *ReminderService) ResetUserSchedule(userID int) error {
+	return s.userRepo.SetReminderSchedule(userID, nil)
+}
+
+// DueReminders returns bookings with reminders due at the given time
+// A reminder is due once its time has passed, as long as the walk has not started.
+// Reminders that were already due when the booking was created are skipped.
+func (s *ReminderService) DueReminders(now time.Time) ([]*DueReminder, error) {
+	defaultSchedule := s.DefaultSchedule()
+
+	// Look ahead far enough for the earliest possible reminder
+	horizon := s.Limits().MaxDaysBefore
+	for _, offset := range defaultSchedule {
+		if days := offset.DaysBefore + int(offset.Before/(24*time.Hour)); days > horizon {
+			horizon = days
+		}
+	}
+
+	bookings, err := s.bookingRepo.GetUpcomingForReminders(
+		now.Format("2006-01-02"),
+		now.AddDate(0, 0, horizon+1).Format("2006-01-02"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	schedules := make(map[int]models.ReminderSchedule)
+	due := []*DueReminder{}
+
+	for _, booking := range bookings {
+		start, err := time.ParseInLocation("2006-01-02 15:04", booking.Date+" "+booking.ScheduledTime, now.Location())
+		if err != nil || !start.After(now) {
+			continue
+		}
+
+		schedule, ok := schedules[booking.UserID]
+		if !ok {
+			schedule, _, err = s.ScheduleForUser(booking.UserID)
+			if err != nil {
+				return nil, err
+			}
+			schedules[booking.UserID] = schedule
+		}
+
+		sentKeys, err := s.reminderRepo.FindSentKeys(booking.ID)
+		if err != nil {
+			return nil, err
+		}
+		sent := make(map[string]bool)
+		for _, key := range sentKeys {
+			sent[key] = true
+		}
+		if sent[legacyReminderKey] {
+			continue
+		}
+
+		keys := []string{}
+		for _, offset := range schedule {
+			dueAt := offset.DueAt(start)
+			if sent[offset.Key()] || dueAt.After(now) || dueAt.Before(booking.CreatedAt) {
+				continue
+			}
+			keys = append(keys, offset.Key())
+		}
+
+		if len(keys) > 0 {
+			due = append(due, &DueReminder{Booking: booking, Keys: keys})
+		}
+	}
+
+	return due, nil
+}
+
+// Claim records the reminders as sent before delivery
+// Returns false if another run already claimed them
+func (s *ReminderService) Claim(reminder *DueReminder) (bool, error) {
+	claimed := []string{}
+	for _, key := range reminder.Keys {
+		ok, err := s.reminderRepo.Claim(reminder.Booking.ID, key)
+		if err != nil {
+			s.release(reminder.Booking.ID, claimed)
+			return false, err
+		}
+		if ok {
+			claimed = append(claimed, key)
+		}
+	}
+
+	// Only keys claimed by this run are released on failure
+	reminder.Keys = claimed
+	return len(claimed) > 0, nil
+}
+
+// Release removes the claims of a reminder that could not be delivered
+func (s *ReminderService) Release(reminder *DueReminder) error {
+	return s.release(reminder.Booking.ID, reminder.Keys)
+}
+
+func (s *ReminderService) release(bookingID int, keys []string) error {
+	for _, key := range keys {
+		if err := s.reminderRepo.Release(bookingID, key); err != nil {
+			return fmt.Errorf("failed to release reminder %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func (s *ReminderService) intSetting(key string, fallback int) int {
+	if setting, err := s.settingsRepo.Get(key); err == nil && setting != nil {
+		if value, err := strconv.Atoi(setting.Value); err == nil && value > 0 {
+			return value
+		}
+	}
+	return fallback
+}
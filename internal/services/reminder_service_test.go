@@ -0,0 +1,196 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestReminderService_DueReminders tests the default schedule "1d@18:00,1h"
+func TestReminderService_DueReminders(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	reminderRepo := repository.NewBookingReminderRepository(db)
+	service := NewReminderService(
+		repository.NewBookingRepository(db),
+		reminderRepo,
+		repository.NewUserRepository(db),
+		repository.NewSettingsRepository(db),
+	)
+
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "User", "green")
+	dogID := testutil.SeedTestDog(t, db, "Bella", "Labrador", "green")
+
+	// Walk in 10 days at 09:00, booked now
+	day := time.Now().AddDate(0, 0, 10)
+	start := time.Date(day.Year(), day.Month(), day.Day(), 9, 0, 0, 0, time.Local)
+	bookingID := testutil.SeedTestBooking(t, db, userID, dogID, start.Format("2006-01-02"), "09:00", "scheduled")
+
+	dueKeys := func(now time.Time) []string {
+		reminders, err := service.DueReminders(now)
+		if err != nil {
+			t.Fatalf("DueReminders() failed: %v", err)
+		}
+		for _, r := range reminders {
+			if r.Booking.ID == bookingID {
+				return r.Keys
+			}
+		}
+		return nil
+	}
+
+	t.Run("nothing due before the evening", func(t *testing.T) {
+		if keys := dueKeys(start.Add(-21 * time.Hour)); len(keys) != 0 {
+			t.Errorf("Expected no reminders, got %v", keys)
+		}
+	})
+
+	t.Run("evening reminder is sent once", func(t *testing.T) {
+		now := start.Add(-15*time.Hour + 5*time.Minute) // 18:05 the day before
+		keys := dueKeys(now)
+		if len(keys) != 1 || keys[0] != "1d@18:00" {
+			t.Fatalf("Expected [1d@18:00], got %v", keys)
+		}
+
+		reminders, _ := service.DueReminders(now)
+		claimed, err := service.Claim(reminders[0])
+		if err != nil || !claimed {
+			t.Fatalf("Expected first claim to succeed, got %v, %v", claimed, err)
+		}
+
+		// Overlapping run with the same due list
+		again, err := service.Claim(&DueReminder{Booking: reminders[0].Booking, Keys: []string{"1d@18:00"}})
+		if err != nil || again {
+			t.Errorf("Expected second claim to fail, got %v, %v", again, err)
+		}
+
+		if keys := dueKeys(now); len(keys) != 0 {
+			t.Errorf("Expected no reminders after claim, got %v", keys)
+		}
+	})
+
+	t.Run("failed send is retried", func(t *testing.T) {
+		now := start.Add(-50 * time.Minute)
+		reminders, _ := service.DueReminders(now)
+		if len(reminders) != 1 || len(reminders[0].Keys) != 1 || reminders[0].Keys[0] != "1h" {
+			t.Fatalf("Expected the 1h reminder, got %v", reminders)
+		}
+
+		service.Claim(reminders[0])
+		if err := service.Release(reminders[0]); err != nil {
+			t.Fatalf("Release() failed: %v", err)
+		}
+
+		if keys := dueKeys(now); len(keys) != 1 {
+			t.Errorf("Expected released reminder to be due again, got %v", keys)
+		}
+	})
+
+	t.Run("nothing after the walk started", func(t *testing.T) {
+		if keys := dueKeys(start.Add(time.Minute)); len(keys) != 0 {
+			t.Errorf("Expected no reminders, got %v", keys)
+		}
+	})
+
+	t.Run("missed reminders are combined", func(t *testing.T) {
+		otherID := testutil.SeedTestBooking(t, db, userID, dogID, start.Format("2006-01-02"), "11:00", "scheduled")
+		reminders, _ := service.DueReminders(start.Add(90 * time.Minute)) // 10:30, after downtime
+
+		for _, r := range reminders {
+			if r.Booking.ID == otherID {
+				if len(r.Keys) != 2 {
+					t.Errorf("Expected both reminders in one email, got %v", r.Keys)
+				}
+				return
+			}
+		}
+		t.Error("Expected reminders for the second booking")
+	})
+
+	t.Run("legacy reminder is not repeated", func(t *testing.T) {
+		legacyID := testutil.SeedTestBooking(t, db, userID, dogID, start.Format("2006-01-02"), "15:00", "scheduled")
+		db.Exec("INSERT INTO booking_reminders (booking_id, reminder_key) VALUES (?, 'legacy')", legacyID)
+
+		reminders, _ := service.DueReminders(start.Add(5*time.Hour + 30*time.Minute))
+		for _, r := range reminders {
+			if r.Booking.ID == legacyID {
+				t.Errorf("Expected no reminders for booking with legacy reminder, got %v", r.Keys)
+			}
+		}
+	})
+
+	t.Run("reminders due before booking are skipped", func(t *testing.T) {
+		soon := time.Now().Add(30 * time.Minute)
+		soonID := testutil.SeedTestBooking(t, db, userID, dogID, soon.Format("2006-01-02"), soon.Format("15:04"), "scheduled")
+
+		reminders, _ := service.DueReminders(time.Now())
+		for _, r := range reminders {
+			if r.Booking.ID == soonID {
+				t.Errorf("Expected no reminders for late booking, got %v", r.Keys)
+			}
+		}
+	})
+}
+
+// TestReminderService_UserSchedule tests personal schedules and their limits
+func TestReminderService_UserSchedule(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	settingsRepo := repository.NewSettingsRepository(db)
+	service := NewReminderService(
+		repository.NewBookingRepository(db),
+		repository.NewBookingReminderRepository(db),
+		repository.NewUserRepository(db),
+		settingsRepo,
+	)
+
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "User", "green")
+
+	t.Run("default schedule", func(t *testing.T) {
+		schedule, isDefault, err := service.ScheduleForUser(userID)
+		if err != nil {
+			t.Fatalf("ScheduleForUser() failed: %v", err)
+		}
+		if !isDefault || schedule.String() != "1d@18:00,1h" {
+			t.Errorf("Expected default schedule, got %s (default=%v)", schedule, isDefault)
+		}
+	})
+
+	t.Run("personal schedule", func(t *testing.T) {
+		schedule, _ := models.ParseReminderSchedule("2d@19:00,30m")
+		if err := service.SetUserSchedule(userID, schedule); err != nil {
+			t.Fatalf("SetUserSchedule() failed: %v", err)
+		}
+
+		got, isDefault, _ := service.ScheduleForUser(userID)
+		if isDefault || got.String() != "2d@19:00,30m" {
+			t.Errorf("Expected personal schedule, got %s (default=%v)", got, isDefault)
+		}
+	})
+
+	t.Run("schedule outside limits is rejected", func(t *testing.T) {
+		schedule, _ := models.ParseReminderSchedule("5d@19:00")
+		if err := service.SetUserSchedule(userID, schedule); err == nil {
+			t.Error("Expected error for reminder too early")
+		}
+	})
+
+	t.Run("stricter limits fall back to default", func(t *testing.T) {
+		settingsRepo.Update("reminder_max_days_before", "1")
+
+		got, isDefault, _ := service.ScheduleForUser(userID)
+		if !isDefault || got.String() != "1d@18:00,1h" {
+			t.Errorf("Expected default schedule, got %s (default=%v)", got, isDefault)
+		}
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		if err := service.ResetUserSchedule(userID); err != nil {
+			t.Fatalf("ResetUserSchedule() failed: %v", err)
+		}
+		if _, isDefault, _ := service.ScheduleForUser(userID); !isDefault {
+			t.Error("Expected default schedule after reset")
+		}
+	})
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"bytes"
+	"database/sql"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"log"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/i18n"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+)
+
+//go:embed report_templates/run_sheet.html
+var runSheetTemplate string
+
+// RunSheetService builds the daily run sheet for staff and sends it as a morning digest
+type RunSheetService struct {
+	repo         *repository.RunSheetRepository
+	settingsRepo *repository.SettingsRepository
+	email        *EmailService // nil = digest disabled
+}
+
+// NewRunSheetService creates a new run sheet service
+// email may be nil if no email provider is configured
+func NewRunSheetService(db *sql.DB, email *EmailService) *RunSheetService {
+	return &RunSheetService{
+		repo:         repository.NewRunSheetRepository(db),
+		settingsRepo: repository.NewSettingsRepository(db),
+		email:        email,
+	}
+}
+
+// Build collects the walks, pending approvals and unwalked dogs of a date (YYYY-MM-DD)
+func (s *RunSheetService) Build(date string) (*models.RunSheet, error) {
+	walks, err := s.repo.FindWalks(date)
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := s.repo.FindPendingApprovals(date)
+	if err != nil {
+		return nil, err
+	}
+
+	dogs, err := s.repo.FindUnwalkedDogs(date)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.RunSheet{
+		Date:             date,
+		Walks:            walks,
+		PendingApprovals: pending,
+		UnwalkedDogs:     dogs,
+		GeneratedAt:      time.Now(),
+	}, nil
+}
+
+// RenderHTML renders the run sheet as a printable HTML page in the given language
+func (s *RunSheetService) RenderHTML(sheet *models.RunSheet, lang string) (string, error) {
+	lang = i18n.Normalize(lang)
+	tmpl, err := template.New("run_sheet").Funcs(template.FuncMap{
+		"t": func(key string, args ...interface{}) string {
+			return i18n.T(lang, key, args...)
+		},
+		"level": func(level string) string {
+			return i18n.T(lang, "levels."+level)
+		},
+		"date":     formatRunSheetDate,
+		"datetime": func(t time.Time) string { return t.Format("02.01.2006 15:04") },
+	}).Parse(runSheetTemplate)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse run sheet template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, map[string]interface{}{"Lang": lang, "Sheet": sheet}); err != nil {
+		return "", fmt.Errorf("failed to render run sheet: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// SendDigest sends today's run sheet to the configured recipients once the send time has passed
+// Returns true if the digest was sent. The digest is claimed before sending, so restarts never send it twice.
+func (s *RunSheetService) SendDigest(now time.Time) (bool, error) {
+	if s.email == nil {
+		return false, nil
+	}
+
+	sendTime := s.setting(models.SettingRunSheetSendTime, models.DefaultRunSheetSendTime)
+	if _, err := models.ParseRunSheetSendTime(sendTime); err != nil {
+		log.Printf("Warning: invalid %s setting %q, using default", models.SettingRunSheetSendTime, sendTime)
+		sendTime = models.DefaultRunSheetSendTime
+	}
+	if sendTime == models.RunSheetSendTimeOff || now.Format("15:04") < sendTime {
+		return false, nil
+	}
+
+	recipients, err := models.ParseRunSheetRecipients(s.setting(models.SettingRunSheetRecipients, ""))
+	if err != nil {
+		return false, fmt.Errorf("invalid %s setting: %w", models.SettingRunSheetRecipients, err)
+	}
+	if len(recipients) == 0 {
+		return false, nil
+	}
+
+	date := now.Format("2006-01-02")
+	claimed, err := s.repo.ClaimDigest(date)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	sheet, err := s.Build(date)
+	if err != nil {
+		s.release(date)
+		return false, err
+	}
+
+	body, err := s.RenderHTML(sheet, i18n.DefaultLanguage)
+	if err != nil {
+		s.release(date)
+		return false, err
+	}
+	subject := i18n.T(i18n.DefaultLanguage, "run_sheet.subject", formatRunSheetDate(date))
+
+	sent := 0
+	var lastErr error
+	for _, to := range recipients {
+		if err := s.email.SendEmail(to, subject, body); err != nil {
+			log.Printf("Failed to send run sheet to %s: %v", to, err)
+			lastErr = err
+			continue
+		}
+		sent++
+	}
+
+	// Retry with the next run only if nobody received the digest
+	if sent == 0 {
+		s.release(date)
+		return false, lastErr
+	}
+	return true, nil
+}
+
+func (s *RunSheetService) setting(key, fallback string) string {
+	setting, err := s.settingsRepo.Get(key)
+	if err != nil || setting == nil {
+		return fallback
+	}
+	return setting.Value
+}
+
+func (s *RunSheetService) release(date string) {
+	if err := s.repo.ReleaseDigest(date); err != nil {
+		log.Printf("Failed to release run sheet digest of %s: %v", date, err)
+	}
+}
+
+// formatRunSheetDate formats YYYY-MM-DD as DD.MM.YYYY
+func formatRunSheetDate(date string) string {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return date
+	}
+	return t.Format("02.01.2006")
+}
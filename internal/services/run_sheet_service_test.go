@@ -0,0 +1,82 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestRunSheetService_RenderHTML tests rendering the printable run sheet
+func TestRunSheetService_RenderHTML(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	walker := testutil.SeedTestUser(t, db, "walker@example.com", "Walker", "blue")
+	bella := testutil.SeedTestDog(t, db, "Bella", "Labrador", "green")
+	luna := testutil.SeedTestDog(t, db, "Luna", "Mischling", "green")
+	testutil.SeedTestBooking(t, db, walker, bella, "2025-12-10", "09:00", "scheduled")
+	testutil.SeedTestBooking(t, db, walker, luna, "2025-12-01", "09:00", "completed")
+
+	service := NewRunSheetService(db, nil)
+	sheet, err := service.Build("2025-12-10")
+	if err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+	if len(sheet.Walks) != 1 || len(sheet.UnwalkedDogs) != 1 || len(sheet.PendingApprovals) != 0 {
+		t.Fatalf("Unexpected run sheet: %d walks, %d unwalked, %d pending", len(sheet.Walks), len(sheet.UnwalkedDogs), len(sheet.PendingApprovals))
+	}
+
+	page, err := service.RenderHTML(sheet, "en")
+	if err != nil {
+		t.Fatalf("RenderHTML() failed: %v", err)
+	}
+	for _, want := range []string{"Run sheet 10.12.2025", "Bella", "Walker", "123 456789", "Blue", "Luna", "01.12.2025", "No bookings are waiting for approval."} {
+		if !strings.Contains(page, want) {
+			t.Errorf("Expected run sheet to contain %q", want)
+		}
+	}
+
+	if page, _ := service.RenderHTML(sheet, "de"); !strings.Contains(page, "Laufzettel 10.12.2025") {
+		t.Error("Expected German run sheet")
+	}
+}
+
+// TestRunSheetService_SendDigest tests sending the morning digest once per day after the send time
+func TestRunSheetService_SendDigest(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	settingsRepo := repository.NewSettingsRepository(db)
+	provider := &recordingEmailProvider{}
+	service := NewRunSheetService(db, &EmailService{provider: provider, templates: NewEmailTemplateStore(nil, "")})
+
+	morning := time.Date(2025, 12, 10, 6, 0, 0, 0, time.Local)
+	later := time.Date(2025, 12, 10, 7, 0, 0, 0, time.Local)
+
+	if sent, err := service.SendDigest(later); err != nil || sent {
+		t.Fatalf("Expected no digest without recipients, got %v (%v)", sent, err)
+	}
+
+	settingsRepo.Update(models.SettingRunSheetRecipients, "staff@example.com, office@example.com")
+
+	if sent, _ := service.SendDigest(morning); sent {
+		t.Error("Expected no digest before the send time")
+	}
+
+	sent, err := service.SendDigest(later)
+	if err != nil || !sent {
+		t.Fatalf("Expected digest to be sent, got %v (%v)", sent, err)
+	}
+	if provider.sent != 2 || provider.to != "office@example.com" || provider.subject != "Laufzettel für 10.12.2025" {
+		t.Errorf("Unexpected emails: %d (last %q to %s)", provider.sent, provider.subject, provider.to)
+	}
+
+	if sent, _ := service.SendDigest(later.Add(time.Hour)); sent || provider.sent != 2 {
+		t.Error("Expected digest to be sent only once per day")
+	}
+
+	settingsRepo.Update(models.SettingRunSheetSendTime, models.RunSheetSendTimeOff)
+	if sent, _ := service.SendDigest(later.AddDate(0, 0, 1)); sent {
+		t.Error("Expected no digest when switched off")
+	}
+}
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+)
+
+// ErrInvalidRefreshToken is returned for unknown, expired and revoked refresh tokens
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+// ErrSessionNotFound is returned when revoking a session that does not exist or already ended
+var ErrSessionNotFound = errors.New("session not found")
+
+// ErrRefreshTokenReused is returned when a refresh token is exchanged a second time
+// The login it belongs to is revoked, as the token was probably stolen.
+var ErrRefreshTokenReused = errors.New("refresh token reused")
+
+// TokenService issues short-lived access tokens with rotating refresh tokens
+// Refresh tokens are stored hashed. Access tokens carry the user's token version, see middleware.AuthMiddleware.
+// Every login is recorded as a session with the device it was made on, the session ends with its refresh tokens.
+type TokenService struct {
+	db              *sql.DB
+	auth            *AuthService
+	tokenRepo       *repository.RefreshTokenRepository
+	sessionRepo     *repository.SessionRepository
+	userRepo        *repository.UserRepository
+	roleRepo        *repository.RoleRepository
+	twoFactorRepo   *repository.TwoFactorRepository
+	refreshLifetime time.Duration
+}
+
+// NewTokenService creates a new token service
+func NewTokenService(db *sql.DB, cfg *config.Config) *TokenService {
+	return &TokenService{
+		db:              db,
+		auth:            NewAuthService(cfg.JWTSecret, cfg.GetAccessTokenMinutes()),
+		tokenRepo:       repository.NewRefreshTokenRepository(db),
+		sessionRepo:     repository.NewSessionRepository(db),
+		userRepo:        repository.NewUserRepository(db),
+		roleRepo:        repository.NewRoleRepository(db),
+		twoFactorRepo:   repository.NewTwoFactorRepository(db),
+		refreshLifetime: cfg.GetRefreshTokenLifetime(),
+	}
+}
+
+// Issue starts a new login for the user on the client
+// user.Permissions must be loaded, they become part of the access token. NewDevice of the result is set
+// if the user logged in before, but never with the browser and operating system of the client.
+func (s *TokenService) Issue(user *models.User, client models.SessionClient) (*models.TokenPair, error) {
+	state, err := s.userRepo.FindAuthState(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	familyID, err := s.auth.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+	device := client.Device()
+	sessions, sameDevice, err := s.sessionRepo.CountDevice(user.ID, device)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	session := &models.UserSession{
+		UserID:     user.ID,
+		FamilyID:   familyID,
+		Device:     device,
+		UserAgent:  client.UserAgent,
+		IPAddress:  client.IPAddress,
+		CreatedAt:  now,
+		LastUsedAt: now,
+	}
+	if err := s.sessionRepo.WithTx(tx).Create(session); err != nil {
+		return nil, err
+	}
+	pair, err := s.issue(s.tokenRepo.WithTx(tx), user, state.TokenVersion, session)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	pair.NewDevice = sessions > 0 && sameDevice == 0
+	return pair, nil
+}
+
+// Refresh exchanges a refresh token for a new access token and a new refresh token
+// The old refresh token can not be used again, reusing it revokes the whole login.
+// The session of the login is updated with the client.
+func (s *TokenService) Refresh(refreshToken string, client models.SessionClient) (*models.TokenPair, error) {
+	stored, err := s.tokenRepo.FindByHash(models.HashRefreshToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil || stored.RevokedAt != nil || time.Now().After(stored.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if stored.UsedAt != nil {
+		return nil, s.revokeReused(stored)
+	}
+
+	// Deactivated and deleted users lose their logins
+	user, err := s.userRepo.FindByID(stored.UserID)
+	if err != nil {
+		return nil, err
+	}
+	state, err := s.userRepo.FindAuthState(stored.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || state == nil || !user.IsActive || user.IsDeleted || user.Email == nil {
+		if err := s.tokenRepo.RevokeAllForUser(stored.UserID); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// Roles may have changed since the last refresh
+	roles, err := s.roleRepo.ForUser(user)
+	if err != nil {
+		return nil, err
+	}
+	user.Permissions = models.PermissionsOf(roles)
+
+	// Staff log in again to set up 2FA, e.g. after it was reset or they were given a role
+	if len(user.Permissions) > 0 {
+		enabled, err := s.twoFactorRepo.IsEnabled(user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !enabled {
+			return nil, ErrInvalidRefreshToken
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	tokenRepo := s.tokenRepo.WithTx(tx)
+	marked, err := tokenRepo.MarkUsed(stored.ID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !marked {
+		// Exchanged concurrently
+		tx.Rollback()
+		return nil, s.revokeReused(stored)
+	}
+
+	session, err := s.touchSession(s.sessionRepo.WithTx(tx), stored, client)
+	if err != nil {
+		return nil, err
+	}
+	pair, err := s.issue(tokenRepo, user, state.TokenVersion, session)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return pair, nil
+}
+
+// RevokeSession ends a session of the user
+// Its refresh tokens are revoked and the token version increased, so the access token of the session
+// is rejected at once. The user's other sessions get a new access token with their refresh tokens.
+func (s *TokenService) RevokeSession(userID, sessionID int) error {
+	session, err := s.sessionRepo.FindActive(userID, sessionID, time.Now())
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		return ErrSessionNotFound
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.tokenRepo.WithTx(tx).RevokeFamily(session.FamilyID); err != nil {
+		return err
+	}
+	if err := s.userRepo.WithTx(tx).IncrementTokenVersion(userID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Revoke ends the login the refresh token belongs to, unknown tokens are ignored
+func (s *TokenService) Revoke(refreshToken string) error {
+	stored, err := s.tokenRepo.FindByHash(models.HashRefreshToken(refreshToken))
+	if err != nil || stored == nil {
+		return err
+	}
+	return s.tokenRepo.RevokeFamily(stored.FamilyID)
+}
+
+// RevokeAll ends all logins of the user, their access tokens are rejected from the next request on
+func (s *TokenService) RevokeAll(userID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.tokenRepo.WithTx(tx).RevokeAllForUser(userID); err != nil {
+		return err
+	}
+	if err := s.userRepo.WithTx(tx).IncrementTokenVersion(userID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (s *TokenService) issue(tokenRepo *repository.RefreshTokenRepository, user *models.User, tokenVersion int, session *models.UserSession) (*models.TokenPair, error) {
+	email := ""
+	if user.Email != nil {
+		email = *user.Email
+	}
+	accessToken, err := s.auth.GenerateSessionJWT(user.ID, email, user.IsAdmin, user.IsSuperAdmin, user.Permissions, tokenVersion, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := s.auth.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	err = tokenRepo.Create(&models.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: models.HashRefreshToken(refreshToken),
+		FamilyID:  session.FamilyID,
+		ExpiresAt: now.Add(s.refreshLifetime),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenPair{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(s.auth.AccessTokenLifetime().Seconds()),
+	}, nil
+}
+
+// touchSession records the refresh in the session of the token
+// Logins from before sessions were recorded get a session on their first refresh.
+func (s *TokenService) touchSession(sessionRepo *repository.SessionRepository, stored *models.RefreshToken, client models.SessionClient) (*models.UserSession, error) {
+	now := time.Now()
+	session, err := sessionRepo.FindByFamily(stored.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		session = &models.UserSession{
+			UserID:     stored.UserID,
+			FamilyID:   stored.FamilyID,
+			Device:     client.Device(),
+			UserAgent:  client.UserAgent,
+			IPAddress:  client.IPAddress,
+			CreatedAt:  stored.CreatedAt,
+			LastUsedAt: now,
+		}
+		return session, sessionRepo.Create(session)
+	}
+	return session, sessionRepo.Touch(session.ID, client, now)
+}
+
+func (s *TokenService) revokeReused(stored *models.RefreshToken) error {
+	if err := s.tokenRepo.RevokeFamily(stored.FamilyID); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
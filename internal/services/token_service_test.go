@@ -0,0 +1,205 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestTokenService tests refresh token rotation, reuse detection and revocation
+func TestTokenService(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	cfg := &config.Config{JWTSecret: "test-secret", AccessTokenMinutes: 15, JWTExpirationHours: 24}
+	tokens := NewTokenService(db, cfg)
+	auth := NewAuthService(cfg.JWTSecret, cfg.AccessTokenMinutes)
+	userRepo := repository.NewUserRepository(db)
+
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "User", "green")
+	user, _ := userRepo.FindByID(userID)
+
+	login, err := tokens.Issue(user, models.SessionClient{})
+	if err != nil {
+		t.Fatalf("Issue() failed: %v", err)
+	}
+	if login.RefreshToken == "" || login.ExpiresIn != 15*60 {
+		t.Fatalf("Unexpected token pair %+v", login)
+	}
+	claims, err := auth.ValidateJWT(login.Token)
+	if err != nil || int((*claims)["user_id"].(float64)) != userID {
+		t.Fatalf("Expected a valid access token for the user, got %v (%v)", claims, err)
+	}
+	stored, _ := repository.NewRefreshTokenRepository(db).FindByHash(models.HashRefreshToken(login.RefreshToken))
+	if stored == nil || stored.ExpiresAt.Before(time.Now().Add(23*time.Hour)) {
+		t.Fatalf("Expected the refresh token to be stored hashed for the login lifetime, got %+v", stored)
+	}
+
+	t.Run("rotates the refresh token", func(t *testing.T) {
+		refreshed, err := tokens.Refresh(login.RefreshToken, models.SessionClient{})
+		if err != nil {
+			t.Fatalf("Refresh() failed: %v", err)
+		}
+		if refreshed.RefreshToken == login.RefreshToken {
+			t.Error("Expected a new refresh token")
+		}
+
+		// The new token keeps working
+		if _, err := tokens.Refresh(refreshed.RefreshToken, models.SessionClient{}); err != nil {
+			t.Errorf("Expected the rotated token to be valid, got %v", err)
+		}
+	})
+
+	t.Run("reuse revokes the login", func(t *testing.T) {
+		session, _ := tokens.Issue(user, models.SessionClient{})
+		rotated, err := tokens.Refresh(session.RefreshToken, models.SessionClient{})
+		if err != nil {
+			t.Fatalf("Refresh() failed: %v", err)
+		}
+
+		if _, err := tokens.Refresh(session.RefreshToken, models.SessionClient{}); !errors.Is(err, ErrRefreshTokenReused) {
+			t.Fatalf("Expected ErrRefreshTokenReused, got %v", err)
+		}
+		if _, err := tokens.Refresh(rotated.RefreshToken, models.SessionClient{}); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("Expected the rotated token to be revoked, got %v", err)
+		}
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		if _, err := tokens.Refresh("unknown", models.SessionClient{}); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("Expected ErrInvalidRefreshToken, got %v", err)
+		}
+	})
+
+	t.Run("logout", func(t *testing.T) {
+		phone, _ := tokens.Issue(user, models.SessionClient{})
+		laptop, _ := tokens.Issue(user, models.SessionClient{})
+
+		if err := tokens.Revoke(phone.RefreshToken); err != nil {
+			t.Fatalf("Revoke() failed: %v", err)
+		}
+		if _, err := tokens.Refresh(phone.RefreshToken, models.SessionClient{}); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("Expected the revoked token to be invalid, got %v", err)
+		}
+		if _, err := tokens.Refresh(laptop.RefreshToken, models.SessionClient{}); err != nil {
+			t.Errorf("Expected other logins to stay valid, got %v", err)
+		}
+		if err := tokens.Revoke("unknown"); err != nil {
+			t.Errorf("Expected unknown tokens to be ignored, got %v", err)
+		}
+	})
+
+	t.Run("deactivated user", func(t *testing.T) {
+		session, _ := tokens.Issue(user, models.SessionClient{})
+		db.Exec(`UPDATE users SET is_active = 0 WHERE id = ?`, userID)
+		defer db.Exec(`UPDATE users SET is_active = 1 WHERE id = ?`, userID)
+
+		if _, err := tokens.Refresh(session.RefreshToken, models.SessionClient{}); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("Expected ErrInvalidRefreshToken, got %v", err)
+		}
+	})
+
+	t.Run("logout everywhere", func(t *testing.T) {
+		phone, _ := tokens.Issue(user, models.SessionClient{})
+		laptop, _ := tokens.Issue(user, models.SessionClient{})
+
+		if err := tokens.RevokeAll(userID); err != nil {
+			t.Fatalf("RevokeAll() failed: %v", err)
+		}
+		for _, pair := range []*models.TokenPair{phone, laptop} {
+			if _, err := tokens.Refresh(pair.RefreshToken, models.SessionClient{}); !errors.Is(err, ErrInvalidRefreshToken) {
+				t.Errorf("Expected all logins to be revoked, got %v", err)
+			}
+		}
+
+		// Access tokens issued from now on carry the increased token version
+		state, _ := userRepo.FindAuthState(userID)
+		next, _ := tokens.Issue(user, models.SessionClient{})
+		claims, _ := auth.ValidateJWT(next.Token)
+		if state.TokenVersion == 0 || int((*claims)["token_version"].(float64)) != state.TokenVersion {
+			t.Errorf("Expected the token to carry version %d, got %v", state.TokenVersion, (*claims)["token_version"])
+		}
+	})
+}
+
+// TestTokenService_Sessions tests recording sessions, recognizing new devices and ending single sessions
+func TestTokenService_Sessions(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	cfg := &config.Config{JWTSecret: "test-secret", AccessTokenMinutes: 15, JWTExpirationHours: 24}
+	tokens := NewTokenService(db, cfg)
+	auth := NewAuthService(cfg.JWTSecret, cfg.AccessTokenMinutes)
+	userRepo := repository.NewUserRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "User", "green")
+	user, _ := userRepo.FindByID(userID)
+
+	firefox := models.NewSessionClient("Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0", "203.0.113.1")
+	chrome := models.NewSessionClient("Mozilla/5.0 (Linux; Android 14) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Mobile Safari/537.36", "198.51.100.2")
+
+	first, err := tokens.Issue(user, firefox)
+	if err != nil {
+		t.Fatalf("Issue() failed: %v", err)
+	}
+	if first.NewDevice {
+		t.Error("Expected the first login not to be reported as new device")
+	}
+	if again, _ := tokens.Issue(user, firefox); again.NewDevice {
+		t.Error("Expected a known device not to be reported")
+	}
+	second, _ := tokens.Issue(user, chrome)
+	if !second.NewDevice {
+		t.Error("Expected a login with another browser to be reported as new device")
+	}
+
+	claims, _ := auth.ValidateJWT(second.Token)
+	sessionID := int((*claims)["session_id"].(float64))
+	sessions, _ := sessionRepo.ListActive(userID, time.Now())
+	if len(sessions) != 3 || sessions[0].ID != sessionID || sessions[0].Device != "Chrome (Android)" {
+		t.Fatalf("Expected the Chrome session first, got %+v", sessions)
+	}
+
+	t.Run("refresh updates the session", func(t *testing.T) {
+		refreshed, err := tokens.Refresh(second.RefreshToken, models.NewSessionClient(chrome.UserAgent, "198.51.100.3"))
+		if err != nil {
+			t.Fatalf("Refresh() failed: %v", err)
+		}
+		claims, _ := auth.ValidateJWT(refreshed.Token)
+		if int((*claims)["session_id"].(float64)) != sessionID {
+			t.Errorf("Expected the refreshed token to keep the session")
+		}
+		session, _ := sessionRepo.FindActive(userID, sessionID, time.Now())
+		if session == nil || session.IPAddress != "198.51.100.3" {
+			t.Errorf("Expected the new IP address, got %+v", session)
+		}
+		second = refreshed
+	})
+
+	t.Run("revoking ends one session", func(t *testing.T) {
+		if err := tokens.RevokeSession(userID, sessionID); err != nil {
+			t.Fatalf("RevokeSession() failed: %v", err)
+		}
+		if _, err := tokens.Refresh(second.RefreshToken, chrome); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("Expected the revoked session to be rejected, got %v", err)
+		}
+		if _, err := tokens.Refresh(first.RefreshToken, firefox); err != nil {
+			t.Errorf("Expected other sessions to continue, got %v", err)
+		}
+		if state, _ := userRepo.FindAuthState(userID); state.TokenVersion != 1 {
+			t.Errorf("Expected the token version to be increased, got %d", state.TokenVersion)
+		}
+		if err := tokens.RevokeSession(userID, sessionID); !errors.Is(err, ErrSessionNotFound) {
+			t.Errorf("Expected ErrSessionNotFound for an ended session, got %v", err)
+		}
+	})
+
+	t.Run("logout everywhere ends all sessions", func(t *testing.T) {
+		tokens.RevokeAll(userID)
+		if sessions, _ := sessionRepo.ListActive(userID, time.Now()); len(sessions) != 0 {
+			t.Errorf("Expected no active sessions, got %d", len(sessions))
+		}
+	})
+}
@@ -0,0 +1,382 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/qrcode"
+	"github.com/tranmh/gassigeher/internal/repository"
+)
+
+const (
+	totpIssuer             = "Gassigeher"
+	totpPeriod             = 30 // Seconds
+	totpDigits             = 6
+	totpSkew               = 1 // Codes of the previous and next time step are accepted as well
+	recoveryCodeCount      = 10
+	twoFactorChallengeTTL  = 5 * time.Minute
+	twoFactorMaxAttempts   = 5
+	twoFactorSecretBytes   = 20
+	recoveryCodeRandomSize = 5 // Bytes, 10 hex characters
+)
+
+var (
+	// ErrTwoFactorAlreadyEnabled is returned when 2FA is set up a second time
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication already enabled")
+	// ErrTwoFactorNotSetUp is returned when a code is checked for a user without a secret
+	ErrTwoFactorNotSetUp = errors.New("two-factor authentication not set up")
+	// ErrInvalidTwoFactorCode is returned for wrong, replayed and used codes
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	// ErrInvalidChallenge is returned for unknown and expired login challenges and after too many wrong codes
+	ErrInvalidChallenge = errors.New("invalid two-factor challenge")
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TwoFactorService implements TOTP two-factor authentication (RFC 6238) with recovery codes
+// Staff must use it, for everyone else it is optional.
+type TwoFactorService struct {
+	db    *sql.DB
+	repo  *repository.TwoFactorRepository
+	roles *repository.RoleRepository
+	now   func() time.Time
+}
+
+// NewTwoFactorService creates a new two-factor service
+func NewTwoFactorService(db *sql.DB) *TwoFactorService {
+	return &TwoFactorService{
+		db:    db,
+		repo:  repository.NewTwoFactorRepository(db),
+		roles: repository.NewRoleRepository(db),
+		now:   time.Now,
+	}
+}
+
+// RequiredFor reports whether the user must use 2FA
+// Everyone who holds a staff permission must, whether through the admin flags or a role.
+func (s *TwoFactorService) RequiredFor(user *models.User) (bool, error) {
+	roles, err := s.roles.ForUser(user)
+	if err != nil {
+		return false, err
+	}
+	return len(models.PermissionsOf(roles)) > 0, nil
+}
+
+// IsEnabled reports whether the user has confirmed 2FA
+func (s *TwoFactorService) IsEnabled(userID int) (bool, error) {
+	return s.repo.IsEnabled(userID)
+}
+
+// Status returns the 2FA state of the user
+func (s *TwoFactorService) Status(user *models.User) (*models.TwoFactorStatus, error) {
+	enabled, err := s.repo.IsEnabled(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	remaining := 0
+	if enabled {
+		if remaining, err = s.repo.CountRecoveryCodes(user.ID); err != nil {
+			return nil, err
+		}
+	}
+	required, err := s.RequiredFor(user)
+	if err != nil {
+		return nil, err
+	}
+	return &models.TwoFactorStatus{
+		Enabled:                enabled,
+		Required:               required,
+		RecoveryCodesRemaining: remaining,
+	}, nil
+}
+
+// Setup creates a new secret, 2FA is enabled once a code of it was confirmed with Enable
+func (s *TwoFactorService) Setup(user *models.User) (*models.TwoFactorSetup, error) {
+	enabled, err := s.repo.IsEnabled(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	raw := make([]byte, twoFactorSecretBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate secret: %w", err)
+	}
+	secret := totpEncoding.EncodeToString(raw)
+	if err := s.repo.SavePending(&models.TwoFactor{UserID: user.ID, Secret: secret, CreatedAt: s.now()}); err != nil {
+		return nil, err
+	}
+
+	account := ""
+	if user.Email != nil {
+		account = *user.Email
+	}
+	uri := provisioningURI(secret, account)
+
+	// Without a QR code the secret is entered manually
+	qr := ""
+	if code, err := qrcode.Encode([]byte(uri)); err == nil {
+		qr = "data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString([]byte(code.SVG()))
+	}
+
+	return &models.TwoFactorSetup{Secret: secret, ProvisioningURI: uri, QRCode: qr}, nil
+}
+
+// Enable confirms the secret from Setup with a code of the authenticator app
+// It returns the recovery codes, they are shown only once.
+func (s *TwoFactorService) Enable(userID int, code string) ([]string, error) {
+	totp, err := s.repo.Find(userID)
+	if err != nil {
+		return nil, err
+	}
+	if totp == nil {
+		return nil, ErrTwoFactorNotSetUp
+	}
+	if totp.EnabledAt != nil {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	step, ok := s.matchStep(totp, code)
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	repo := s.repo.WithTx(tx)
+	if err := repo.Enable(userID, step, s.now()); err != nil {
+		return nil, err
+	}
+	if err := repo.ReplaceRecoveryCodes(userID, hashes, s.now()); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return codes, nil
+}
+
+// Verify checks a code of the authenticator app or, if given, a recovery code
+// Both can be used only once.
+func (s *TwoFactorService) Verify(userID int, code, recoveryCode string) error {
+	totp, err := s.repo.Find(userID)
+	if err != nil {
+		return err
+	}
+	if totp == nil || totp.EnabledAt == nil {
+		return ErrTwoFactorNotSetUp
+	}
+
+	if strings.TrimSpace(recoveryCode) != "" {
+		used, err := s.repo.UseRecoveryCode(userID, hashRecoveryCode(recoveryCode), s.now())
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	step, ok := s.matchStep(totp, code)
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+	// Rejects a code that was accepted before, also when two requests race
+	used, err := s.repo.UseStep(userID, step)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// Disable removes the secret and the recovery codes of the user
+// Also used by super admins to reset the 2FA of a user who lost their device.
+func (s *TwoFactorService) Disable(userID int) error {
+	return s.repo.Delete(userID)
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes of the user
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID int) ([]string, error) {
+	enabled, err := s.repo.IsEnabled(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, ErrTwoFactorNotSetUp
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.ReplaceRecoveryCodes(userID, hashes, s.now()); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// StartChallenge starts the second login step for a user whose password was checked
+// It returns the challenge token and its lifetime in seconds.
+func (s *TwoFactorService) StartChallenge(userID int) (string, int, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", 0, fmt.Errorf("failed to generate challenge token: %w", err)
+	}
+	token := hex.EncodeToString(raw)
+
+	now := s.now()
+	err := s.repo.CreateChallenge(&models.TwoFactorChallenge{
+		UserID:    userID,
+		TokenHash: hashChallengeToken(token),
+		ExpiresAt: now.Add(twoFactorChallengeTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	return token, int(twoFactorChallengeTTL.Seconds()), nil
+}
+
+// Challenge returns the open login challenge of the token
+func (s *TwoFactorService) Challenge(token string) (*models.TwoFactorChallenge, error) {
+	if token == "" {
+		return nil, ErrInvalidChallenge
+	}
+	challenge, err := s.repo.FindChallenge(hashChallengeToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if challenge == nil || s.now().After(challenge.ExpiresAt) || challenge.Attempts >= twoFactorMaxAttempts {
+		return nil, ErrInvalidChallenge
+	}
+	return challenge, nil
+}
+
+// FailChallenge counts a wrong code, the challenge ends after too many
+func (s *TwoFactorService) FailChallenge(challenge *models.TwoFactorChallenge) error {
+	return s.repo.IncrementChallengeAttempts(challenge.ID)
+}
+
+// EndChallenge deletes a completed challenge, its token can not be used again
+func (s *TwoFactorService) EndChallenge(challenge *models.TwoFactorChallenge) error {
+	return s.repo.DeleteChallenge(challenge.ID)
+}
+
+// DeleteExpiredChallenges removes expired login challenges
+func (s *TwoFactorService) DeleteExpiredChallenges() (int64, error) {
+	return s.repo.DeleteExpiredChallenges(s.now())
+}
+
+// matchStep returns the time step of the code, codes of steps already used are rejected
+func (s *TwoFactorService) matchStep(totp *models.TwoFactor, code string) (int64, bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := s.now().Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= totp.LastUsedStep {
+			continue
+		}
+		expected, err := totpCode(totp.Secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// TOTPCode returns the code an authenticator app shows for the secret at the given time
+func TOTPCode(secret string, at time.Time) (string, error) {
+	return totpCode(secret, at.Unix()/totpPeriod)
+}
+
+// totpCode computes the code of a time step (RFC 4226 dynamic truncation)
+func totpCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", fmt.Errorf("invalid TOTP secret: %w", err)
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", value%1000000), nil
+}
+
+// provisioningURI is the otpauth:// URI read by authenticator apps
+func provisioningURI(secret, account string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", totpIssuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(totpDigits))
+	params.Set("period", fmt.Sprint(totpPeriod))
+	return "otpauth://totp/" + url.PathEscape(totpIssuer+":"+account) + "?" + params.Encode()
+}
+
+// newRecoveryCodes returns new recovery codes and their hashes
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw := make([]byte, recoveryCodeRandomSize)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		code := hex.EncodeToString(raw)
+		codes[i] = code[:5] + "-" + code[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode ignores case, spaces and dashes, codes are typed in by hand
+func hashRecoveryCode(code string) string {
+	normalized := strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(code)))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+func hashChallengeToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
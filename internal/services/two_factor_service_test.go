@@ -0,0 +1,270 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestTotpCode tests the code calculation against the RFC 6238 SHA-1 test vectors
+func TestTotpCode(t *testing.T) {
+	// "12345678901234567890" in base32
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		got, err := totpCode(secret, tt.unix/totpPeriod)
+		if err != nil {
+			t.Fatalf("totpCode() failed: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("totpCode(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+// TestTwoFactorService tests enrollment, code checks, replay protection and recovery codes
+func TestTwoFactorService(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	service := NewTwoFactorService(db)
+	userRepo := repository.NewUserRepository(db)
+
+	now := time.Unix(1700000000, 0)
+	service.now = func() time.Time { return now }
+
+	userID := testutil.SeedTestUser(t, db, "totp@example.com", "TOTP User", "green")
+	user, _ := userRepo.FindByID(userID)
+
+	codeAt := func(secret string, offset int64) string {
+		code, err := totpCode(secret, now.Unix()/totpPeriod+offset)
+		if err != nil {
+			t.Fatalf("totpCode() failed: %v", err)
+		}
+		return code
+	}
+
+	if err := service.Verify(userID, "123456", ""); !errors.Is(err, ErrTwoFactorNotSetUp) {
+		t.Fatalf("Expected ErrTwoFactorNotSetUp before setup, got %v", err)
+	}
+
+	setup, err := service.Setup(user)
+	if err != nil {
+		t.Fatalf("Setup() failed: %v", err)
+	}
+	if len(setup.Secret) != 32 {
+		t.Errorf("Expected a 160 bit base32 secret, got %q", setup.Secret)
+	}
+	if !strings.HasPrefix(setup.ProvisioningURI, "otpauth://totp/Gassigeher:totp@example.com?") ||
+		!strings.Contains(setup.ProvisioningURI, "secret="+setup.Secret) {
+		t.Errorf("Unexpected provisioning URI %q", setup.ProvisioningURI)
+	}
+	if !strings.HasPrefix(setup.QRCode, "data:image/svg+xml;base64,") {
+		t.Errorf("Expected an SVG QR code, got %q", setup.QRCode)
+	}
+
+	// A second setup before enabling replaces the secret
+	setup, err = service.Setup(user)
+	if err != nil {
+		t.Fatalf("Setup() failed: %v", err)
+	}
+
+	if _, err := service.Enable(userID, "000000"); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("Expected ErrInvalidTwoFactorCode for a wrong code, got %v", err)
+	}
+	codes, err := service.Enable(userID, codeAt(setup.Secret, 0))
+	if err != nil {
+		t.Fatalf("Enable() failed: %v", err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("Expected %d recovery codes, got %d", recoveryCodeCount, len(codes))
+	}
+	if _, err := service.Setup(user); !errors.Is(err, ErrTwoFactorAlreadyEnabled) {
+		t.Errorf("Expected ErrTwoFactorAlreadyEnabled, got %v", err)
+	}
+
+	t.Run("code is accepted once", func(t *testing.T) {
+		// The code used to enable 2FA cannot be used to log in
+		if err := service.Verify(userID, codeAt(setup.Secret, 0), ""); !errors.Is(err, ErrInvalidTwoFactorCode) {
+			t.Errorf("Expected a replayed code to be rejected, got %v", err)
+		}
+
+		// Clock drift of one step is accepted
+		if err := service.Verify(userID, codeAt(setup.Secret, 1), ""); err != nil {
+			t.Errorf("Expected the next code to be accepted, got %v", err)
+		}
+		if err := service.Verify(userID, codeAt(setup.Secret, 1), ""); !errors.Is(err, ErrInvalidTwoFactorCode) {
+			t.Errorf("Expected a replayed code to be rejected, got %v", err)
+		}
+
+		// Older steps are rejected once a later one was used
+		if err := service.Verify(userID, codeAt(setup.Secret, -1), ""); !errors.Is(err, ErrInvalidTwoFactorCode) {
+			t.Errorf("Expected an older code to be rejected, got %v", err)
+		}
+
+		now = now.Add(10 * time.Minute)
+		if err := service.Verify(userID, codeAt(setup.Secret, 2), ""); !errors.Is(err, ErrInvalidTwoFactorCode) {
+			t.Errorf("Expected a code two steps ahead to be rejected, got %v", err)
+		}
+		if err := service.Verify(userID, codeAt(setup.Secret, 0), ""); err != nil {
+			t.Errorf("Expected the current code to be accepted, got %v", err)
+		}
+	})
+
+	t.Run("recovery codes are single-use", func(t *testing.T) {
+		// Typed in without dash and in upper case
+		typed := strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))
+		if err := service.Verify(userID, "", typed); err != nil {
+			t.Fatalf("Expected the recovery code to be accepted, got %v", err)
+		}
+		if err := service.Verify(userID, "", codes[0]); !errors.Is(err, ErrInvalidTwoFactorCode) {
+			t.Errorf("Expected a used recovery code to be rejected, got %v", err)
+		}
+		if err := service.Verify(userID, "", "aaaaa-bbbbb"); !errors.Is(err, ErrInvalidTwoFactorCode) {
+			t.Errorf("Expected an unknown recovery code to be rejected, got %v", err)
+		}
+
+		status, err := service.Status(user)
+		if err != nil {
+			t.Fatalf("Status() failed: %v", err)
+		}
+		if !status.Enabled || status.Required || status.RecoveryCodesRemaining != recoveryCodeCount-1 {
+			t.Errorf("Unexpected status %+v", status)
+		}
+
+		regenerated, err := service.RegenerateRecoveryCodes(userID)
+		if err != nil {
+			t.Fatalf("RegenerateRecoveryCodes() failed: %v", err)
+		}
+		if err := service.Verify(userID, "", codes[1]); !errors.Is(err, ErrInvalidTwoFactorCode) {
+			t.Errorf("Expected old recovery codes to be replaced, got %v", err)
+		}
+		if err := service.Verify(userID, "", regenerated[1]); err != nil {
+			t.Errorf("Expected a new recovery code to be accepted, got %v", err)
+		}
+	})
+
+	t.Run("disable removes the secret", func(t *testing.T) {
+		if err := service.Disable(userID); err != nil {
+			t.Fatalf("Disable() failed: %v", err)
+		}
+		if enabled, _ := service.IsEnabled(userID); enabled {
+			t.Error("Expected 2FA to be disabled")
+		}
+	})
+}
+
+// TestTwoFactorService_Challenge tests expiry and the attempt limit of login challenges
+func TestTwoFactorService_Challenge(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	service := NewTwoFactorService(db)
+	userID := testutil.SeedTestUser(t, db, "challenge@example.com", "Challenge", "green")
+
+	token, expiresIn, err := service.StartChallenge(userID)
+	if err != nil {
+		t.Fatalf("StartChallenge() failed: %v", err)
+	}
+	if expiresIn != 300 {
+		t.Errorf("Expected a 5 minute challenge, got %d seconds", expiresIn)
+	}
+
+	challenge, err := service.Challenge(token)
+	if err != nil || challenge.UserID != userID {
+		t.Fatalf("Expected the challenge of the user, got %+v (%v)", challenge, err)
+	}
+	if _, err := service.Challenge("unknown"); !errors.Is(err, ErrInvalidChallenge) {
+		t.Errorf("Expected ErrInvalidChallenge for an unknown token, got %v", err)
+	}
+
+	for i := 0; i < twoFactorMaxAttempts; i++ {
+		if err := service.FailChallenge(challenge); err != nil {
+			t.Fatalf("FailChallenge() failed: %v", err)
+		}
+	}
+	if _, err := service.Challenge(token); !errors.Is(err, ErrInvalidChallenge) {
+		t.Errorf("Expected the challenge to end after %d wrong codes, got %v", twoFactorMaxAttempts, err)
+	}
+
+	token, _, _ = service.StartChallenge(userID)
+	service.now = func() time.Time { return time.Now().Add(6 * time.Minute) }
+	if _, err := service.Challenge(token); !errors.Is(err, ErrInvalidChallenge) {
+		t.Errorf("Expected an expired challenge to be rejected, got %v", err)
+	}
+	if deleted, err := service.DeleteExpiredChallenges(); err != nil || deleted != 2 {
+		t.Errorf("Expected 2 expired challenges to be deleted, got %d (%v)", deleted, err)
+	}
+}
+
+// TestTokenService_AdminWithoutTwoFactor tests that staff without 2FA cannot renew their login
+func TestTokenService_AdminWithoutTwoFactor(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	cfg := &config.Config{JWTSecret: "test-secret", AccessTokenMinutes: 15, JWTExpirationHours: 24}
+	tokens := NewTokenService(db, cfg)
+	twoFactor := NewTwoFactorService(db)
+
+	adminID := testutil.SeedTestUser(t, db, "admin@example.com", "Admin", "blue")
+	db.Exec("UPDATE users SET is_admin = 1 WHERE id = ?", adminID)
+	admin, _ := repository.NewUserRepository(db).FindByID(adminID)
+
+	login, err := tokens.Issue(admin, models.SessionClient{})
+	if err != nil {
+		t.Fatalf("Issue() failed: %v", err)
+	}
+	if _, err := tokens.Refresh(login.RefreshToken, models.SessionClient{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("Expected ErrInvalidRefreshToken for an admin without 2FA, got %v", err)
+	}
+
+	setup, _ := twoFactor.Setup(admin)
+	code, _ := totpCode(setup.Secret, time.Now().Unix()/totpPeriod)
+	if _, err := twoFactor.Enable(adminID, code); err != nil {
+		t.Fatalf("Enable() failed: %v", err)
+	}
+	login, _ = tokens.Issue(admin, models.SessionClient{})
+	if _, err := tokens.Refresh(login.RefreshToken, models.SessionClient{}); err != nil {
+		t.Errorf("Expected refresh to work with 2FA, got %v", err)
+	}
+
+	t.Run("staff with a role", func(t *testing.T) {
+		roles := repository.NewRoleRepository(db)
+		coordinator := &models.Role{Name: "volunteer_coordinator", Permissions: []string{models.PermissionManageUsers}}
+		if err := roles.Create(coordinator); err != nil {
+			t.Fatalf("Create() failed: %v", err)
+		}
+		staffID := testutil.SeedTestUser(t, db, "staff@example.com", "Staff", "blue")
+		userID := testutil.SeedTestUser(t, db, "user@example.com", "User", "green")
+		roles.SetUserRoles(staffID, []int{coordinator.ID})
+		staff, _ := repository.NewUserRepository(db).FindByID(staffID)
+		user, _ := repository.NewUserRepository(db).FindByID(userID)
+
+		if required, err := twoFactor.RequiredFor(staff); err != nil || !required {
+			t.Errorf("Expected 2FA to be required for staff with a role, got %v (%v)", required, err)
+		}
+		if required, err := twoFactor.RequiredFor(user); err != nil || required {
+			t.Errorf("Expected 2FA to be optional for users, got %v (%v)", required, err)
+		}
+
+		login, _ := tokens.Issue(staff, models.SessionClient{})
+		if _, err := tokens.Refresh(login.RefreshToken, models.SessionClient{}); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("Expected ErrInvalidRefreshToken for staff without 2FA, got %v", err)
+		}
+		login, _ = tokens.Issue(user, models.SessionClient{})
+		if _, err := tokens.Refresh(login.RefreshToken, models.SessionClient{}); err != nil {
+			t.Errorf("Expected refresh to work for users without 2FA, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tranmh/gassigeher/internal/models"
+)
+
+// ErrInvalidUnsubscribeToken is returned for malformed or tampered unsubscribe tokens
+var ErrInvalidUnsubscribeToken = errors.New("invalid unsubscribe token")
+
+// UnsubscribeTokens creates and verifies signed one-click unsubscribe tokens
+// Tokens do not expire so links in old emails keep working
+type UnsubscribeTokens struct {
+	secret []byte
+}
+
+// NewUnsubscribeTokens creates a token signer with the given secret
+func NewUnsubscribeTokens(secret string) *UnsubscribeTokens {
+	return &UnsubscribeTokens{secret: []byte(secret)}
+}
+
+// Generate creates a token for unsubscribing a user from a category
+// Format: <userID>.<category>.<base64url HMAC-SHA256>
+func (u *UnsubscribeTokens) Generate(userID int, category string) string {
+	payload := fmt.Sprintf("%d.%s", userID, category)
+	return payload + "." + u.sign(payload)
+}
+
+// Parse verifies a token and returns the user ID and category
+func (u *UnsubscribeTokens) Parse(token string) (int, string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, "", ErrInvalidUnsubscribeToken
+	}
+
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(u.sign(payload))) {
+		return 0, "", ErrInvalidUnsubscribeToken
+	}
+
+	userID, err := strconv.Atoi(parts[0])
+	if err != nil || userID <= 0 {
+		return 0, "", ErrInvalidUnsubscribeToken
+	}
+
+	category := parts[1]
+	if !models.IsValidNotificationCategory(category) || models.IsMandatoryNotificationCategory(category) {
+		return 0, "", ErrInvalidUnsubscribeToken
+	}
+
+	return userID, category, nil
+}
+
+func (u *UnsubscribeTokens) sign(payload string) string {
+	mac := hmac.New(sha256.New, u.secret)
+	mac.Write([]byte("unsubscribe:" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
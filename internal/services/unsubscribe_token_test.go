@@ -0,0 +1,41 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/tranmh/gassigeher/internal/models"
+)
+
+// TestUnsubscribeTokens tests generating and verifying unsubscribe tokens
+func TestUnsubscribeTokens(t *testing.T) {
+	tokens := NewUnsubscribeTokens("test-secret")
+
+	t.Run("round trip", func(t *testing.T) {
+		token := tokens.Generate(42, models.NotificationCategoryReminders)
+
+		userID, category, err := tokens.Parse(token)
+		if err != nil {
+			t.Fatalf("Parse() failed: %v", err)
+		}
+		if userID != 42 || category != models.NotificationCategoryReminders {
+			t.Errorf("Expected 42/reminders, got %d/%s", userID, category)
+		}
+	})
+
+	invalid := map[string]string{
+		"empty":              "",
+		"malformed":          "not-a-token",
+		"other user":         "43.reminders." + tokens.sign("42.reminders"),
+		"other secret":       NewUnsubscribeTokens("other-secret").Generate(42, models.NotificationCategoryReminders),
+		"mandatory category": tokens.Generate(42, models.NotificationCategoryAccount),
+		"unknown category":   tokens.Generate(42, "unknown"),
+	}
+
+	for name, token := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := tokens.Parse(token); err != ErrInvalidUnsubscribeToken {
+				t.Errorf("Expected ErrInvalidUnsubscribeToken, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package services
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// COSE algorithm identifiers of the accepted passkey signatures
+const (
+	coseAlgES256 = -7
+	coseAlgEdDSA = -8
+	coseAlgRS256 = -257
+)
+
+// Flags of the authenticator data (WebAuthn §6.1)
+const (
+	authDataUserPresent  = 0x01
+	authDataUserVerified = 0x04
+	authDataAttested     = 0x40
+)
+
+var errInvalidCBOR = errors.New("invalid CBOR")
+
+// authenticatorData is the parsed authenticator data of a registration or login
+type authenticatorData struct {
+	RPIDHash     []byte
+	Flags        byte
+	SignCount    uint32
+	CredentialID []byte // Registration only
+	PublicKey    []byte // COSE key, registration only
+}
+
+// parseAuthenticatorData parses the authenticator data, with the attested credential data of a registration
+func parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < 37 {
+		return nil, errors.New("authenticator data too short")
+	}
+	auth := &authenticatorData{
+		RPIDHash:  data[:32],
+		Flags:     data[32],
+		SignCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	if auth.Flags&authDataAttested == 0 {
+		return auth, nil
+	}
+
+	// AAGUID (16 bytes), credential ID length (2 bytes), credential ID, COSE public key
+	rest := data[37:]
+	if len(rest) < 18 {
+		return nil, errors.New("attested credential data too short")
+	}
+	idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if len(rest) < idLength {
+		return nil, errors.New("credential ID too short")
+	}
+	auth.CredentialID = rest[:idLength]
+	rest = rest[idLength:]
+
+	// Extensions may follow the key, only its own bytes are kept
+	_, remaining, err := decodeCBOR(rest)
+	if err != nil {
+		return nil, fmt.Errorf("invalid credential public key: %w", err)
+	}
+	auth.PublicKey = rest[:len(rest)-len(remaining)]
+	return auth, nil
+}
+
+// parseAttestationObject returns the authenticator data of a registration
+// The attestation statement is not checked: passkeys are requested without attestation ("none"),
+// any authenticator the user owns is accepted.
+func parseAttestationObject(data []byte) ([]byte, error) {
+	value, _, err := decodeCBOR(data)
+	if err != nil {
+		return nil, err
+	}
+	object, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.New("attestation object is not a map")
+	}
+	authData, ok := object["authData"].([]byte)
+	if !ok {
+		return nil, errors.New("attestation object without authenticator data")
+	}
+	return authData, nil
+}
+
+// coseAlgorithm returns the signature algorithm of a COSE key and checks that it is supported
+func coseAlgorithm(coseKey []byte) (int, error) {
+	_, alg, err := parseCOSEKey(coseKey)
+	return alg, err
+}
+
+// verifyCOSESignature checks the signature of a login over authenticatorData || SHA-256(clientDataJSON)
+func verifyCOSESignature(coseKey, signed, signature []byte) error {
+	key, alg, err := parseCOSEKey(coseKey)
+	if err != nil {
+		return err
+	}
+
+	switch alg {
+	case coseAlgES256:
+		digest := sha256.Sum256(signed)
+		if !ecdsa.VerifyASN1(key.(*ecdsa.PublicKey), digest[:], signature) {
+			return errors.New("invalid ES256 signature")
+		}
+	case coseAlgRS256:
+		digest := sha256.Sum256(signed)
+		if err := rsa.VerifyPKCS1v15(key.(*rsa.PublicKey), crypto.SHA256, digest[:], signature); err != nil {
+			return errors.New("invalid RS256 signature")
+		}
+	case coseAlgEdDSA:
+		if !ed25519.Verify(key.(ed25519.PublicKey), signed, signature) {
+			return errors.New("invalid EdDSA signature")
+		}
+	}
+	return nil
+}
+
+// parseCOSEKey decodes an ES256 (P-256), RS256 or EdDSA (Ed25519) public key (RFC 9053)
+func parseCOSEKey(coseKey []byte) (crypto.PublicKey, int, error) {
+	value, _, err := decodeCBOR(coseKey)
+	if err != nil {
+		return nil, 0, err
+	}
+	key, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, 0, errors.New("COSE key is not a map")
+	}
+	kty, _ := key[int64(1)].(int64)
+	alg, _ := key[int64(3)].(int64)
+	crv, _ := key[int64(-1)].(int64)
+
+	switch {
+	case kty == 2 && alg == coseAlgES256 && crv == 1:
+		x, _ := key[int64(-2)].([]byte)
+		y, _ := key[int64(-3)].([]byte)
+		if len(x) != 32 || len(y) != 32 {
+			return nil, 0, errors.New("invalid P-256 coordinates")
+		}
+		// Rejects points that are not on the curve
+		point := append(append([]byte{0x04}, x...), y...)
+		if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+			return nil, 0, fmt.Errorf("invalid P-256 key: %w", err)
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, coseAlgES256, nil
+
+	case kty == 3 && alg == coseAlgRS256:
+		n, _ := key[int64(-1)].([]byte)
+		e, _ := key[int64(-2)].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, 0, errors.New("invalid RSA key")
+		}
+		exponent := int(new(big.Int).SetBytes(e).Int64())
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exponent}, coseAlgRS256, nil
+
+	case kty == 1 && alg == coseAlgEdDSA && crv == 6:
+		x, _ := key[int64(-2)].([]byte)
+		if len(x) != ed25519.PublicKeySize {
+			return nil, 0, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), coseAlgEdDSA, nil
+	}
+	return nil, 0, fmt.Errorf("unsupported COSE key (kty %d, alg %d)", kty, alg)
+}
+
+// decodeCBOR decodes one CBOR data item (RFC 8949) and returns the bytes after it
+// Only what WebAuthn uses is supported: integers, byte and text strings, arrays, maps, booleans and null.
+// Integers are returned as int64, maps as map[interface{}]interface{}.
+func decodeCBOR(data []byte) (interface{}, []byte, error) {
+	return decodeCBORItem(data, 0)
+}
+
+func decodeCBORItem(data []byte, depth int) (interface{}, []byte, error) {
+	if len(data) == 0 || depth > 16 {
+		return nil, nil, errInvalidCBOR
+	}
+	major := data[0] >> 5
+	info := data[0] & 0x1f
+	data = data[1:]
+
+	if major == 7 {
+		switch info {
+		case 20:
+			return false, data, nil
+		case 21:
+			return true, data, nil
+		case 22:
+			return nil, data, nil
+		}
+		return nil, nil, errInvalidCBOR
+	}
+
+	// Argument: the value of integers, the length of strings, arrays and maps
+	var argument uint64
+	switch {
+	case info < 24:
+		argument = uint64(info)
+	case info == 24 && len(data) >= 1:
+		argument, data = uint64(data[0]), data[1:]
+	case info == 25 && len(data) >= 2:
+		argument, data = uint64(binary.BigEndian.Uint16(data)), data[2:]
+	case info == 26 && len(data) >= 4:
+		argument, data = uint64(binary.BigEndian.Uint32(data)), data[4:]
+	case info == 27 && len(data) >= 8:
+		argument, data = binary.BigEndian.Uint64(data), data[8:]
+	default:
+		// Indefinite lengths are not used by authenticators
+		return nil, nil, errInvalidCBOR
+	}
+
+	switch major {
+	case 0, 1:
+		if argument > 1<<63-1 {
+			return nil, nil, errInvalidCBOR
+		}
+		if major == 1 {
+			return -1 - int64(argument), data, nil
+		}
+		return int64(argument), data, nil
+
+	case 2, 3:
+		if argument > uint64(len(data)) {
+			return nil, nil, errInvalidCBOR
+		}
+		value := bytes.Clone(data[:argument])
+		if major == 3 {
+			return string(value), data[argument:], nil
+		}
+		return value, data[argument:], nil
+
+	case 4:
+		if argument > uint64(len(data)) {
+			return nil, nil, errInvalidCBOR
+		}
+		items := make([]interface{}, 0, argument)
+		for i := uint64(0); i < argument; i++ {
+			var item interface{}
+			var err error
+			if item, data, err = decodeCBORItem(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item)
+		}
+		return items, data, nil
+
+	case 5:
+		if argument > uint64(len(data)) {
+			return nil, nil, errInvalidCBOR
+		}
+		entries := make(map[interface{}]interface{}, argument)
+		for i := uint64(0); i < argument; i++ {
+			var key, value interface{}
+			var err error
+			if key, data, err = decodeCBORItem(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			if value, data, err = decodeCBORItem(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+				entries[key] = value
+			default:
+				return nil, nil, errInvalidCBOR
+			}
+		}
+		return entries, data, nil
+	}
+	return nil, nil, errInvalidCBOR
+}
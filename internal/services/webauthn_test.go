@@ -0,0 +1,65 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+)
+
+// TestDecodeCBOR tests the decoding of the CBOR subset authenticators use
+func TestDecodeCBOR(t *testing.T) {
+	value, rest, err := decodeCBOR([]byte{0xa2, 0x01, 0x02, 0x63, 'f', 'm', 't', 0x38, 0x18, 0xff})
+	if err != nil {
+		t.Fatalf("decodeCBOR() failed: %v", err)
+	}
+	entries := value.(map[interface{}]interface{})
+	if entries[int64(1)] != int64(2) || entries["fmt"] != int64(-25) {
+		t.Errorf("Unexpected map: %v", entries)
+	}
+	if len(rest) != 1 || rest[0] != 0xff {
+		t.Errorf("Expected the remaining byte, got %x", rest)
+	}
+
+	invalid := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", []byte{}},
+		{"truncated string", []byte{0x44, 0x01, 0x02}},
+		{"indefinite length", []byte{0x5f, 0x41, 0x01, 0xff}},
+		{"array key", []byte{0xa1, 0x80, 0x01}},
+		{"float", []byte{0xfa, 0x00, 0x00, 0x00, 0x00}},
+		{"too deep", []byte{0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x00}},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := decodeCBOR(tt.data); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+// TestVerifyCOSESignature tests an EdDSA key and the rejection of unsupported keys
+func TestVerifyCOSESignature(t *testing.T) {
+	publicKey, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	// {1: 1 (OKP), 3: -8 (EdDSA), -1: 6 (Ed25519), -2: x}
+	coseKey := append([]byte{0xa4, 0x01, 0x01, 0x03, 0x27, 0x20, 0x06, 0x21, 0x58, 0x20}, publicKey...)
+
+	if alg, err := coseAlgorithm(coseKey); err != nil || alg != coseAlgEdDSA {
+		t.Fatalf("Expected EdDSA, got %d (%v)", alg, err)
+	}
+
+	signed := []byte("authenticator data and client data hash")
+	if err := verifyCOSESignature(coseKey, signed, ed25519.Sign(privateKey, signed)); err != nil {
+		t.Errorf("Expected a valid signature, got %v", err)
+	}
+	if err := verifyCOSESignature(coseKey, []byte("other data"), ed25519.Sign(privateKey, signed)); err == nil {
+		t.Error("Expected an invalid signature")
+	}
+
+	// EC2 key with an unsupported algorithm (ES384)
+	if _, err := coseAlgorithm([]byte{0xa3, 0x01, 0x02, 0x03, 0x38, 0x22, 0x20, 0x02}); err == nil {
+		t.Error("Expected an unsupported key")
+	}
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+)
+
+// Webhook delivery settings
+const (
+	webhookTimeout        = 10 * time.Second
+	webhookBatchSize      = 100
+	webhookClaimLease     = 30 * time.Minute    // Longer than a batch takes with every request timing out
+	webhookLogRetention   = 30 * 24 * time.Hour // Delivered and failed deliveries are kept for 30 days
+	webhookMaxErrorLength = 500
+)
+
+// webhookBackoff is the wait before each retry, the delivery fails after the last one
+var webhookBackoff = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	30 * time.Minute,
+	2 * time.Hour,
+	12 * time.Hour,
+}
+
+// WebhookService queues events for subscribed webhooks and delivers them with signed requests
+// Domain events are queued by the event dispatcher and delivered by the cron job, failed deliveries are retried with backoff.
+type WebhookService struct {
+	repo     *repository.WebhookRepository
+	client   *http.Client
+	instance string // Claims due deliveries, so with several instances each attempt is sent once
+}
+
+// NewWebhookService creates a new webhook service
+func NewWebhookService(db *sql.DB) *WebhookService {
+	return &WebhookService{
+		repo:     repository.NewWebhookRepository(db),
+		client:   &http.Client{Timeout: webhookTimeout},
+		instance: instanceName(),
+	}
+}
+
+// GenerateWebhookSecret creates a random signing secret
+func GenerateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// SignWebhookPayload returns the signature header value of a payload
+// The signature is the HMAC-SHA256 of "<timestamp>.<payload>" with the webhook secret.
+func SignWebhookPayload(secret, timestamp, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + payload))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Publish queues an event for all active webhooks subscribed to it
+func (s *WebhookService) Publish(event string, data interface{}) error {
+	return s.publish(event, data, time.Now())
+}
+
+// HandleDomainEvent queues the webhook deliveries of a domain event
+// It is registered as subscriber of the event dispatcher, rejected bookings are sent as booking.cancelled.
+func (s *WebhookService) HandleDomainEvent(event *models.DomainEvent) error {
+	eventType := event.EventType
+	if eventType == models.EventBookingRejected {
+		eventType = models.WebhookEventBookingCancelled
+	}
+	return s.publish(eventType, json.RawMessage(event.Payload), event.CreatedAt)
+}
+
+func (s *WebhookService) publish(event string, data interface{}, createdAt time.Time) error {
+	webhooks, err := s.repo.FindActiveByEvent(event)
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+
+	payload, err := json.Marshal(models.WebhookPayload{Event: event, CreatedAt: createdAt, Data: data})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	now := time.Now()
+	for _, webhook := range webhooks {
+		delivery := &models.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			Event:         event,
+			Payload:       string(payload),
+			NextAttemptAt: now,
+		}
+		if err := s.repo.CreateDelivery(delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ProcessDue delivers all pending deliveries that are due and removes old log entries
+// Returns the number of successful deliveries.
+func (s *WebhookService) ProcessDue(now time.Time) (int, error) {
+	if _, err := s.repo.DeleteFinishedBefore(now.Add(-webhookLogRetention)); err != nil {
+		log.Printf("Failed to clean up webhook deliveries: %v", err)
+	}
+
+	deliveries, err := s.repo.ClaimDue(s.instance, now, now.Add(-webhookClaimLease), webhookBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	webhooks := map[int]*models.Webhook{}
+	delivered := 0
+	for _, delivery := range deliveries {
+		webhook, ok := webhooks[delivery.WebhookID]
+		if !ok {
+			webhook, err = s.repo.FindByID(delivery.WebhookID)
+			if err != nil {
+				return delivered, err
+			}
+			webhooks[delivery.WebhookID] = webhook
+		}
+
+		if s.deliver(webhook, delivery, now) {
+			delivered++
+		}
+		if err := s.repo.UpdateDelivery(delivery); err != nil {
+			return delivered, err
+		}
+	}
+	return delivered, nil
+}
+
+// deliver sends one attempt and updates the delivery, returns true on success
+func (s *WebhookService) deliver(webhook *models.Webhook, delivery *models.WebhookDelivery, now time.Time) bool {
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = nil
+
+	if webhook == nil || !webhook.IsActive {
+		// Queued before the webhook was disabled
+		s.fail(delivery, "webhook disabled", now, true)
+		return false
+	}
+
+	status, err := s.send(webhook, delivery, now)
+	if status != 0 {
+		delivery.ResponseStatus = &status
+	}
+	if err != nil {
+		s.fail(delivery, err.Error(), now, false)
+		return false
+	}
+
+	delivery.Status = models.WebhookDeliveryDelivered
+	delivery.Error = nil
+	return true
+}
+
+// fail records an error and schedules the next retry, or gives up after the last one
+func (s *WebhookService) fail(delivery *models.WebhookDelivery, message string, now time.Time, final bool) {
+	if len(message) > webhookMaxErrorLength {
+		message = message[:webhookMaxErrorLength]
+	}
+	delivery.Error = &message
+
+	if final || delivery.Attempts > len(webhookBackoff) {
+		delivery.Status = models.WebhookDeliveryFailed
+		return
+	}
+	delivery.NextAttemptAt = now.Add(webhookBackoff[delivery.Attempts-1])
+}
+
+// send posts the payload with signature headers and returns the response status
+func (s *WebhookService) send(webhook *models.Webhook, delivery *models.WebhookDelivery, now time.Time) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewBufferString(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("invalid request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Gassigeher-Webhooks/1.0")
+	req.Header.Set("X-Gassigeher-Event", delivery.Event)
+	req.Header.Set("X-Gassigeher-Delivery", strconv.Itoa(delivery.ID))
+	req.Header.Set("X-Gassigeher-Timestamp", timestamp)
+	req.Header.Set("X-Gassigeher-Signature", SignWebhookPayload(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
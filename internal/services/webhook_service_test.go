@@ -0,0 +1,125 @@
+package services
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// TestWebhookService_Deliver tests publishing an event and delivering it with a valid signature
+func TestWebhookService_Deliver(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := repository.NewWebhookRepository(db)
+	service := NewWebhookService(db)
+
+	var received *http.Request
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		received, body = r, string(b)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	webhook := &models.Webhook{Name: "Bot", URL: server.URL, Secret: "secret", Events: []string{models.WebhookEventBookingCreated}, IsActive: true}
+	other := &models.Webhook{Name: "Dogs", URL: server.URL, Secret: "other", Events: []string{models.WebhookEventDogAvailabilityChanged}, IsActive: true}
+	repo.Create(webhook)
+	repo.Create(other)
+
+	booking := &models.Booking{ID: 7, UserID: 2, DogID: 3, Date: "2025-12-10", ScheduledTime: "09:00", Status: "scheduled", ApprovalStatus: "approved"}
+	event, _ := models.NewBookingEvent(models.EventBookingCreated, nil, models.NewBookingEventData(booking, "Bella", "Anna"))
+	if err := service.HandleDomainEvent(event); err != nil {
+		t.Fatalf("HandleDomainEvent() failed: %v", err)
+	}
+
+	if count := testutil.CountRows(t, db, "webhook_deliveries"); count != 1 {
+		t.Fatalf("Expected 1 queued delivery, got %d", count)
+	}
+
+	now := time.Now()
+	delivered, err := service.ProcessDue(now)
+	if err != nil || delivered != 1 {
+		t.Fatalf("Expected 1 delivery, got %d (%v)", delivered, err)
+	}
+
+	if received == nil {
+		t.Fatal("Expected webhook request")
+	}
+	timestamp := received.Header.Get("X-Gassigeher-Timestamp")
+	if received.Header.Get("X-Gassigeher-Signature") != SignWebhookPayload("secret", timestamp, body) {
+		t.Error("Expected valid signature")
+	}
+	if received.Header.Get("X-Gassigeher-Event") != models.WebhookEventBookingCreated || received.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Unexpected headers: %v", received.Header)
+	}
+
+	var payload struct {
+		Event string                  `json:"event"`
+		Data  models.BookingEventData `json:"data"`
+	}
+	json.Unmarshal([]byte(body), &payload)
+	if payload.Event != models.WebhookEventBookingCreated || payload.Data.BookingID != 7 || payload.Data.DogName != "Bella" {
+		t.Errorf("Unexpected payload: %s", body)
+	}
+
+	log, _ := repo.FindDeliveries(webhook.ID, 10)
+	if len(log) != 1 || log[0].Status != models.WebhookDeliveryDelivered || *log[0].ResponseStatus != http.StatusNoContent {
+		t.Errorf("Expected delivered entry in the log, got %+v", log[0])
+	}
+}
+
+// TestWebhookService_Retry tests the backoff of failed deliveries and giving up after the last retry
+func TestWebhookService_Retry(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := repository.NewWebhookRepository(db)
+	service := NewWebhookService(db)
+
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	webhook := &models.Webhook{Name: "Bot", URL: server.URL, Secret: "secret", Events: []string{models.WebhookEventUserDeactivated}, IsActive: true}
+	repo.Create(webhook)
+	service.Publish(models.WebhookEventUserDeactivated, &models.UserDeactivatedEventData{UserID: 1, Name: "Anna", Reason: models.DeactivationReasonInactivity})
+
+	now := time.Now()
+	if delivered, _ := service.ProcessDue(now); delivered != 0 {
+		t.Fatalf("Expected failed delivery, got %d", delivered)
+	}
+
+	log, _ := repo.FindDeliveries(webhook.ID, 10)
+	delivery := log[0]
+	if delivery.Status != models.WebhookDeliveryPending || delivery.Attempts != 1 || *delivery.ResponseStatus != http.StatusInternalServerError {
+		t.Fatalf("Expected pending retry after the first attempt, got %+v", delivery)
+	}
+	if wait := delivery.NextAttemptAt.Sub(now); wait < 59*time.Second || wait > 61*time.Second {
+		t.Errorf("Expected first retry after 1 minute, got %v", wait)
+	}
+
+	// Not due before the backoff has passed
+	service.ProcessDue(now.Add(30 * time.Second))
+	if attempts != 1 {
+		t.Errorf("Expected no attempt before the retry is due, got %d", attempts)
+	}
+
+	// Run until the delivery gives up
+	for i := 0; i < len(webhookBackoff); i++ {
+		log, _ = repo.FindDeliveries(webhook.ID, 10)
+		service.ProcessDue(log[0].NextAttemptAt.Add(time.Second))
+	}
+
+	log, _ = repo.FindDeliveries(webhook.ID, 10)
+	if log[0].Status != models.WebhookDeliveryFailed || log[0].Attempts != len(webhookBackoff)+1 || attempts != len(webhookBackoff)+1 {
+		t.Errorf("Expected failure after %d attempts, got %s after %d", len(webhookBackoff)+1, log[0].Status, log[0].Attempts)
+	}
+}
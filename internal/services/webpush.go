@@ -0,0 +1,282 @@
+package services
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+)
+
+// ErrPushSubscriptionExpired is returned when the push service no longer knows the subscription (404/410)
+var ErrPushSubscriptionExpired = errors.New("push subscription expired")
+
+// webPushTTL is how long the push service keeps a message for an offline device
+const webPushTTL = 12 * time.Hour
+
+// webPushRecordSize is the aes128gcm record size, payloads must fit into a single record
+const webPushRecordSize = 4096
+
+// VAPIDKeys is the application server key pair used to identify the server to push services (RFC 8292)
+type VAPIDKeys struct {
+	PublicKey  string // Uncompressed P-256 point (base64url), passed to PushManager.subscribe()
+	privateKey *ecdsa.PrivateKey
+}
+
+// GenerateVAPIDKeys creates a new key pair and returns it base64url encoded
+func GenerateVAPIDKeys() (string, string, error) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate VAPID keys: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()),
+		base64.RawURLEncoding.EncodeToString(key.Bytes()), nil
+}
+
+// ParseVAPIDKeys parses a base64url key pair and checks that both keys belong together
+func ParseVAPIDKeys(publicKey, privateKey string) (*VAPIDKeys, error) {
+	raw, err := models.DecodePushKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+
+	key, err := ecdh.P256().NewPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+
+	point := key.PublicKey().Bytes()
+	if base64.RawURLEncoding.EncodeToString(point) != publicKey {
+		return nil, fmt.Errorf("VAPID public key does not match private key")
+	}
+
+	return &VAPIDKeys{
+		PublicKey: publicKey,
+		privateKey: &ecdsa.PrivateKey{
+			PublicKey: ecdsa.PublicKey{
+				Curve: elliptic.P256(),
+				X:     new(big.Int).SetBytes(point[1:33]),
+				Y:     new(big.Int).SetBytes(point[33:]),
+			},
+			D: new(big.Int).SetBytes(raw),
+		},
+	}, nil
+}
+
+// LoadVAPIDKeys returns the configured key pair or the one stored in the database
+// A key pair is generated on first use, so push works without manual setup.
+// Configured keys take precedence so several instances can share one pair.
+func LoadVAPIDKeys(cfg *config.Config, repo *repository.PushSubscriptionRepository) (*VAPIDKeys, error) {
+	if cfg.VAPIDPublicKey != "" || cfg.VAPIDPrivateKey != "" {
+		return ParseVAPIDKeys(cfg.VAPIDPublicKey, cfg.VAPIDPrivateKey)
+	}
+
+	publicKey, privateKey, err := repo.GetVAPIDKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	if publicKey == "" {
+		publicKey, privateKey, err = GenerateVAPIDKeys()
+		if err != nil {
+			return nil, err
+		}
+		if publicKey, privateKey, err = repo.SaveVAPIDKeys(publicKey, privateKey); err != nil {
+			return nil, err
+		}
+		log.Println("Generated VAPID key pair for Web Push")
+	}
+
+	return ParseVAPIDKeys(publicKey, privateKey)
+}
+
+// VAPIDSubject returns the contact for push services (mailto: or https: URL)
+func VAPIDSubject(cfg *config.Config) string {
+	switch {
+	case cfg.VAPIDSubject != "":
+		return cfg.VAPIDSubject
+	case cfg.SuperAdminEmail != "":
+		return "mailto:" + cfg.SuperAdminEmail
+	default:
+		return cfg.BaseURL
+	}
+}
+
+// WebPushSender delivers encrypted messages to push services (RFC 8030, 8291, 8292)
+type WebPushSender struct {
+	keys    *VAPIDKeys
+	subject string
+	client  *http.Client
+}
+
+// NewWebPushSender creates a sender with the given VAPID keys and contact
+func NewWebPushSender(keys *VAPIDKeys, subject string) *WebPushSender {
+	return &WebPushSender{
+		keys:    keys,
+		subject: subject,
+		client:  &http.Client{Timeout: channelHTTPTimeout},
+	}
+}
+
+// Send encrypts the payload for the subscription and posts it to the push service
+// Returns ErrPushSubscriptionExpired if the subscription should be removed
+func (s *WebPushSender) Send(sub *models.PushSubscription, payload []byte) error {
+	clientKey, err := models.DecodePushKey(sub.P256dh)
+	if err != nil {
+		return fmt.Errorf("invalid subscription key: %w", err)
+	}
+	authSecret, err := models.DecodePushKey(sub.Auth)
+	if err != nil {
+		return fmt.Errorf("invalid subscription auth secret: %w", err)
+	}
+
+	body, err := encryptPushPayload(clientKey, authSecret, payload)
+	if err != nil {
+		return err
+	}
+
+	authorization, err := s.authorization(sub.Endpoint, time.Now())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create push request: %w", err)
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(int(webPushTTL.Seconds())))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send push message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrPushSubscriptionExpired
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("push service returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
+
+// authorization creates the VAPID header for an endpoint (RFC 8292)
+func (s *WebPushSender) authorization(endpoint string, now time.Time) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid push endpoint: %w", err)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": now.Add(12 * time.Hour).Unix(),
+		"sub": s.subject,
+	})
+
+	signed, err := token.SignedString(s.keys.privateKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign VAPID token: %w", err)
+	}
+
+	return "vapid t=" + signed + ", k=" + s.keys.PublicKey, nil
+}
+
+// encryptPushPayload encrypts a payload for a subscription with a fresh key and salt (RFC 8291)
+func encryptPushPayload(clientKey, authSecret, payload []byte) ([]byte, error) {
+	serverKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate push key: %w", err)
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate push salt: %w", err)
+	}
+
+	return encryptPushPayloadWith(serverKey, salt, clientKey, authSecret, payload)
+}
+
+// encryptPushPayloadWith encrypts a payload with the given server key and salt
+// Output: salt (16) | record size (4) | key length (1) | server public key (65) | ciphertext
+func encryptPushPayloadWith(serverKey *ecdh.PrivateKey, salt, clientKey, authSecret, payload []byte) ([]byte, error) {
+	// Payload + delimiter + GCM tag must fit into one record
+	if len(payload)+1+16 > webPushRecordSize {
+		return nil, fmt.Errorf("push payload too large: %d bytes", len(payload))
+	}
+
+	clientPublic, err := ecdh.P256().NewPublicKey(clientKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subscription key: %w", err)
+	}
+
+	sharedSecret, err := serverKey.ECDH(clientPublic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive push secret: %w", err)
+	}
+
+	serverPublic := serverKey.PublicKey().Bytes()
+
+	// IKM = HKDF(auth_secret, ecdh_secret, "WebPush: info" || 0x00 || ua_public || as_public, 32)
+	keyInfo := append([]byte("WebPush: info\x00"), clientKey...)
+	keyInfo = append(keyInfo, serverPublic...)
+	ikm, err := hkdf.Key(sha256.New, sharedSecret, authSecret, string(keyInfo), 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive push key: %w", err)
+	}
+
+	contentKey, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive push key: %w", err)
+	}
+	nonce, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive push nonce: %w", err)
+	}
+
+	block, err := aes.NewCipher(contentKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create push cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create push cipher: %w", err)
+	}
+
+	// 0x02 marks the last (and only) record
+	plaintext := append(append([]byte{}, payload...), 0x02)
+
+	header := make([]byte, 0, 16+4+1+len(serverPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, webPushRecordSize)
+	header = append(header, byte(len(serverPublic)))
+	header = append(header, serverPublic...)
+
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/tranmh/gassigeher/internal/config"
+	"github.com/tranmh/gassigeher/internal/models"
+	"github.com/tranmh/gassigeher/internal/repository"
+	"github.com/tranmh/gassigeher/internal/testutil"
+)
+
+// decryptPushPayload decrypts an aes128gcm push message like a browser would (RFC 8291)
+func decryptPushPayload(t *testing.T, clientKey *ecdh.PrivateKey, authSecret, body []byte) []byte {
+	t.Helper()
+
+	salt := body[:16]
+	recordSize := binary.BigEndian.Uint32(body[16:20])
+	keyLength := int(body[20])
+	serverPublic := body[21 : 21+keyLength]
+	ciphertext := body[21+keyLength:]
+
+	if recordSize != webPushRecordSize {
+		t.Errorf("Expected record size %d, got %d", webPushRecordSize, recordSize)
+	}
+
+	serverKey, err := ecdh.P256().NewPublicKey(serverPublic)
+	if err != nil {
+		t.Fatalf("Invalid server key: %v", err)
+	}
+	sharedSecret, _ := clientKey.ECDH(serverKey)
+
+	keyInfo := append([]byte("WebPush: info\x00"), clientKey.PublicKey().Bytes()...)
+	keyInfo = append(keyInfo, serverPublic...)
+	ikm, _ := hkdf.Key(sha256.New, sharedSecret, authSecret, string(keyInfo), 32)
+	contentKey, _ := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	nonce, _ := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+
+	block, _ := aes.NewCipher(contentKey)
+	gcm, _ := cipher.NewGCM(block)
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		t.Fatalf("Failed to decrypt push payload: %v", err)
+	}
+
+	if plaintext[len(plaintext)-1] != 0x02 {
+		t.Errorf("Expected last record delimiter, got %x", plaintext[len(plaintext)-1])
+	}
+	return plaintext[:len(plaintext)-1]
+}
+
+// newTestSubscription creates a browser-side key pair and the matching subscription
+func newTestSubscription(t *testing.T, userID int, endpoint string) (*models.PushSubscription, *ecdh.PrivateKey, []byte) {
+	t.Helper()
+
+	clientKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate client key: %v", err)
+	}
+	authSecret := make([]byte, 16)
+	rand.Read(authSecret)
+
+	return &models.PushSubscription{
+		UserID:   userID,
+		Endpoint: endpoint,
+		P256dh:   base64.RawURLEncoding.EncodeToString(clientKey.PublicKey().Bytes()),
+		Auth:     base64.RawURLEncoding.EncodeToString(authSecret),
+	}, clientKey, authSecret
+}
+
+// TestEncryptPushPayload tests that payloads can be decrypted with the subscription keys
+func TestEncryptPushPayload(t *testing.T) {
+	sub, clientKey, authSecret := newTestSubscription(t, 1, "https://push.example.com/1")
+	clientPublic, _ := models.DecodePushKey(sub.P256dh)
+
+	payload := []byte(`{"title":"Gassigeher","body":"Erinnerung"}`)
+	body, err := encryptPushPayload(clientPublic, authSecret, payload)
+	if err != nil {
+		t.Fatalf("encryptPushPayload() failed: %v", err)
+	}
+
+	if decrypted := decryptPushPayload(t, clientKey, authSecret, body); !bytes.Equal(decrypted, payload) {
+		t.Errorf("Expected %q, got %q", payload, decrypted)
+	}
+
+	// Fresh salt and key for every message
+	other, _ := encryptPushPayload(clientPublic, authSecret, payload)
+	if bytes.Equal(body[:16], other[:16]) {
+		t.Error("Expected a new salt per message")
+	}
+
+	if _, err := encryptPushPayload(clientPublic, authSecret, make([]byte, webPushRecordSize)); err == nil {
+		t.Error("Expected error for payload larger than one record")
+	}
+}
+
+// TestLoadVAPIDKeys tests generating, storing and configuring VAPID keys
+func TestLoadVAPIDKeys(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := repository.NewPushSubscriptionRepository(db)
+	cfg := &config.Config{}
+
+	keys, err := LoadVAPIDKeys(cfg, repo)
+	if err != nil {
+		t.Fatalf("LoadVAPIDKeys() failed: %v", err)
+	}
+	if raw, _ := models.DecodePushKey(keys.PublicKey); len(raw) != 65 {
+		t.Errorf("Expected uncompressed P-256 public key, got %d bytes", len(raw))
+	}
+
+	again, _ := LoadVAPIDKeys(cfg, repo)
+	if again.PublicKey != keys.PublicKey {
+		t.Error("Expected the stored key pair to be reused")
+	}
+
+	publicKey, privateKey, _ := GenerateVAPIDKeys()
+	configured, err := LoadVAPIDKeys(&config.Config{VAPIDPublicKey: publicKey, VAPIDPrivateKey: privateKey}, repo)
+	if err != nil || configured.PublicKey != publicKey {
+		t.Errorf("Expected configured key pair, got %v", err)
+	}
+
+	if _, err := LoadVAPIDKeys(&config.Config{VAPIDPublicKey: keys.PublicKey, VAPIDPrivateKey: privateKey}, repo); err == nil {
+		t.Error("Expected error for mismatching key pair")
+	}
+}
+
+// TestWebPushSender_Send tests the request sent to the push service
+func TestWebPushSender_Send(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	pushService := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer pushService.Close()
+
+	publicKey, privateKey, _ := GenerateVAPIDKeys()
+	keys, _ := ParseVAPIDKeys(publicKey, privateKey)
+	sender := NewWebPushSender(keys, "mailto:admin@example.com")
+
+	sub, clientKey, authSecret := newTestSubscription(t, 1, pushService.URL+"/push/abc")
+	if err := sender.Send(sub, []byte("hello")); err != nil {
+		t.Fatalf("Send() failed: %v", err)
+	}
+
+	if received.Header.Get("Content-Encoding") != "aes128gcm" || received.Header.Get("TTL") == "" {
+		t.Errorf("Missing Web Push headers: %v", received.Header)
+	}
+	if string(decryptPushPayload(t, clientKey, authSecret, body)) != "hello" {
+		t.Error("Unexpected payload")
+	}
+
+	// Authorization: vapid t=<JWT>, k=<public key>
+	authorization := received.Header.Get("Authorization")
+	parts := strings.SplitN(strings.TrimPrefix(authorization, "vapid t="), ", k=", 2)
+	if len(parts) != 2 || parts[1] != publicKey {
+		t.Fatalf("Unexpected Authorization header %q", authorization)
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(parts[0], claims, func(token *jwt.Token) (interface{}, error) {
+		return &keys.privateKey.PublicKey, nil
+	}, jwt.WithValidMethods([]string{"ES256"}))
+	if err != nil {
+		t.Fatalf("Invalid VAPID token: %v", err)
+	}
+	if claims["aud"] != pushService.URL || claims["sub"] != "mailto:admin@example.com" {
+		t.Errorf("Unexpected claims: %v", claims)
+	}
+	if exp, _ := claims.GetExpirationTime(); exp == nil || exp.After(time.Now().Add(24*time.Hour)) {
+		t.Errorf("Expiration must be within 24 hours, got %v", exp)
+	}
+}
+
+// TestPushChannel_Send tests delivery to all devices and removal of expired subscriptions
+func TestPushChannel_Send(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	userID := testutil.SeedTestUser(t, db, "user@example.com", "Anna", "green")
+	repo := repository.NewPushSubscriptionRepository(db)
+
+	var payloads [][]byte
+	pushService := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/expired") {
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		payloads = append(payloads, body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer pushService.Close()
+
+	active, clientKey, authSecret := newTestSubscription(t, userID, pushService.URL+"/active")
+	expired, _, _ := newTestSubscription(t, userID, pushService.URL+"/expired")
+	repo.Save(active)
+	repo.Save(expired)
+
+	keys, _ := LoadVAPIDKeys(&config.Config{}, repo)
+	channel := NewPushChannel(NewWebPushSender(keys, "mailto:admin@example.com"), repo)
+
+	address := channel.Address(&ChannelRecipient{UserID: userID})
+	if address == "" {
+		t.Fatal("Expected user with devices to be reachable")
+	}
+
+	if err := channel.Send(address, "Erinnerung an Bella"); err != nil {
+		t.Fatalf("Send() failed: %v", err)
+	}
+
+	if len(payloads) != 1 {
+		t.Fatalf("Expected one delivered message, got %d", len(payloads))
+	}
+	var message pushMessage
+	json.Unmarshal(decryptPushPayload(t, clientKey, authSecret, payloads[0]), &message)
+	if message.Body != "Erinnerung an Bella" || message.Title == "" {
+		t.Errorf("Unexpected message %+v", message)
+	}
+
+	subscriptions, _ := repo.FindByUser(userID)
+	if len(subscriptions) != 1 || subscriptions[0].ID != active.ID {
+		t.Errorf("Expected only the active subscription to remain, got %d", len(subscriptions))
+	}
+	if subscriptions[0].LastUsedAt == nil {
+		t.Error("Expected last_used_at to be set")
+	}
+
+	// Only expired devices left
+	repo.Delete(active.ID)
+	repo.Save(expired)
+	if err := channel.Send(address, "x"); err == nil {
+		t.Error("Expected error when no device received the message")
+	}
+	if channel.Address(&ChannelRecipient{UserID: userID}) != "" {
+		t.Error("Expected user without devices to be unreachable")
+	}
+}
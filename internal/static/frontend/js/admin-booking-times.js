@@ -1,5 +1,5 @@
 (async function() {
-    // Check authentication and admin status
+    // Check authentication and permission
     if (!api.isAuthenticated()) {
         window.location.href = '/login.html';
         return;
@@ -7,11 +7,12 @@
 
     try {
         const userData = await api.getMe();
-        if (!userData.is_admin) {
-            alert('Zugriff verweigert: Diese Seite ist nur für Administratoren zugänglich.');
-            window.location.href = '/dashboard.html';
+        if (!api.hasPermission(userData, 'calendar.manage')) {
+            alert('Zugriff verweigert: Ihnen fehlt die Berechtigung für diese Seite.');
+            window.location.href = api.adminHomePage(userData) || '/dashboard.html';
             return;
         }
+        applyAdminPermissions(userData);
     } catch (error) {
         console.error('Failed to verify admin status:', error);
         window.location.href = '/dashboard.html';
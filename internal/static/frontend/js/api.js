@@ -1,20 +1,86 @@
 // API Client for backend communication
+
+// Admin pages and the permission they require, the first one the user may open is their admin home page
+const ADMIN_PAGE_PERMISSIONS = {
+    '/admin-dashboard.html': 'dashboard.view',
+    '/admin-dogs.html': 'dogs.manage',
+    '/admin-bookings.html': 'bookings.manage',
+    '/admin-booking-approvals.html': 'bookings.manage',
+    '/admin-booking-times.html': 'calendar.manage',
+    '/admin-blocked-dates.html': 'calendar.manage',
+    '/admin-users.html': 'users.manage',
+    '/admin-experience-requests.html': 'users.manage',
+    '/admin-reactivation-requests.html': 'users.manage',
+    '/admin-invitations.html': 'users.manage',
+    '/admin-announcements.html': 'announcements.manage',
+    '/admin-settings.html': 'settings.manage',
+    '/admin-webhooks.html': 'settings.manage',
+    '/admin-activity.html': 'dashboard.view',
+    '/admin-roles.html': 'roles.manage',
+    '/admin-audit-log.html': 'audit.view'
+};
+
+// Passkey options and credentials carry binary fields, the server sends and expects them base64url encoded
+function base64urlToBuffer(value) {
+    const padding = '='.repeat((4 - value.length % 4) % 4);
+    const raw = atob((value + padding).replace(/-/g, '+').replace(/_/g, '/'));
+    return Uint8Array.from([...raw].map(char => char.charCodeAt(0))).buffer;
+}
+
+function bufferToBase64url(buffer) {
+    const raw = String.fromCharCode(...new Uint8Array(buffer));
+    return btoa(raw).replace(/\+/g, '-').replace(/\//g, '_').replace(/=+$/, '');
+}
+
+// PublicKeyCredential as JSON, for registration (attestationObject) and login (signature)
+function passkeyCredentialToJSON(credential) {
+    const response = credential.response;
+    const result = {
+        id: credential.id,
+        rawId: bufferToBase64url(credential.rawId),
+        type: credential.type,
+        response: { clientDataJSON: bufferToBase64url(response.clientDataJSON) }
+    };
+    if (response.attestationObject) {
+        result.response.attestationObject = bufferToBase64url(response.attestationObject);
+        result.response.transports = response.getTransports ? response.getTransports() : [];
+    } else {
+        result.response.authenticatorData = bufferToBase64url(response.authenticatorData);
+        result.response.signature = bufferToBase64url(response.signature);
+        result.response.userHandle = response.userHandle ? bufferToBase64url(response.userHandle) : '';
+    }
+    return result;
+}
+
 class API {
     constructor() {
         this.baseURL = '/api';
         this.token = localStorage.getItem('gassigeher_token');
+        this.refreshing = null;
     }
 
-    // Set authentication token
+    // Set authentication token, null logs out (also removes the refresh token)
     setToken(token) {
         this.token = token;
         if (token) {
             localStorage.setItem('gassigeher_token', token);
         } else {
             localStorage.removeItem('gassigeher_token');
+            localStorage.removeItem('gassigeher_refresh_token');
         }
     }
 
+    // Store the access and refresh token of a login, refresh or password change response
+    setTokens(response) {
+        this.setToken(response.token);
+        localStorage.setItem('gassigeher_refresh_token', response.refresh_token);
+    }
+
+    // Refresh tokens are shared by all tabs and rotate on use, so they are always read from localStorage
+    getRefreshToken() {
+        return localStorage.getItem('gassigeher_refresh_token');
+    }
+
     // Get authentication token
     getToken() {
         return this.token;
@@ -25,8 +91,78 @@ class API {
         return !!this.token;
     }
 
+    // Whether the access token expires within the next minute (or cannot be read)
+    tokenExpiresSoon(token) {
+        try {
+            const payload = JSON.parse(atob(token.split('.')[1].replace(/-/g, '+').replace(/_/g, '/')));
+            return payload.exp * 1000 - Date.now() < 60 * 1000;
+        } catch (e) {
+            return true;
+        }
+    }
+
+    // Refresh the access token before it expires
+    async ensureFreshToken() {
+        if (this.token && this.getRefreshToken() && this.tokenExpiresSoon(this.token)) {
+            await this.refreshAccessToken();
+        }
+    }
+
+    // Exchange the refresh token for new tokens, returns false if the login has ended
+    // Only one refresh runs at a time, across tabs where the browser supports locks: a refresh token
+    // used twice ends the login on all devices.
+    async refreshAccessToken() {
+        if (!this.refreshing) {
+            const refresh = () => this.exchangeRefreshToken();
+            const run = navigator.locks ? navigator.locks.request('gassigeher_refresh', refresh) : refresh();
+            this.refreshing = run.finally(() => {
+                this.refreshing = null;
+            });
+        }
+        return this.refreshing;
+    }
+
+    async exchangeRefreshToken() {
+        // Another tab may have refreshed already
+        const stored = localStorage.getItem('gassigeher_token');
+        if (stored && stored !== this.token && !this.tokenExpiresSoon(stored)) {
+            this.token = stored;
+            return true;
+        }
+
+        const refreshToken = this.getRefreshToken();
+        if (!refreshToken) {
+            return false;
+        }
+
+        try {
+            const response = await fetch(`${this.baseURL}/auth/refresh`, {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ refresh_token: refreshToken }),
+            });
+            if (response.status === 401) {
+                this.setToken(null);
+                return false;
+            }
+            if (!response.ok) {
+                return false;
+            }
+            this.setTokens(await response.json());
+            return true;
+        } catch (error) {
+            return false;
+        }
+    }
+
     // Make HTTP request
-    async request(method, endpoint, data = null) {
+    async request(method, endpoint, data = null, retry = true) {
+        // Auth endpoints work without a valid access token
+        const authenticated = !endpoint.startsWith('/auth/') || endpoint === '/auth/change-password' || endpoint === '/auth/logout-all';
+        if (authenticated) {
+            await this.ensureFreshToken();
+        }
+
         const headers = {
             'Content-Type': 'application/json',
         };
@@ -35,6 +171,11 @@ class API {
             headers['Authorization'] = `Bearer ${this.token}`;
         }
 
+        // Let the server localize error messages
+        if (window.i18n && window.i18n.locale) {
+            headers['Accept-Language'] = window.i18n.locale;
+        }
+
         const options = {
             method,
             headers,
@@ -46,12 +187,21 @@ class API {
 
         try {
             const response = await fetch(`${this.baseURL}${endpoint}`, options);
+
+            // The access token expired or was revoked, retry once with a new one
+            if (response.status === 401 && authenticated && retry && this.getRefreshToken()) {
+                if (await this.refreshAccessToken()) {
+                    return this.request(method, endpoint, data, false);
+                }
+            }
+
             const responseData = await response.json();
 
             if (!response.ok) {
                 // Create error with response data attached
                 const error = new Error(responseData.error || 'Request failed');
                 error.status = response.status;
+                error.code = responseData.code;
                 error.data = responseData;
                 throw error;
             }
@@ -64,12 +214,17 @@ class API {
 
     // Upload file
     async uploadFile(endpoint, formData) {
+        await this.ensureFreshToken();
         const headers = {};
 
         if (this.token) {
             headers['Authorization'] = `Bearer ${this.token}`;
         }
 
+        if (window.i18n && window.i18n.locale) {
+            headers['Accept-Language'] = window.i18n.locale;
+        }
+
         try {
             const response = await fetch(`${this.baseURL}${endpoint}`, {
                 method: 'POST',
@@ -95,6 +250,10 @@ class API {
         return this.request('POST', '/auth/register', data);
     }
 
+    async getRegistrationStatus() {
+        return this.request('GET', '/auth/registration');
+    }
+
     async verifyEmail(token) {
         return this.request('POST', '/auth/verify-email', { token });
     }
@@ -102,12 +261,113 @@ class API {
     async login(email, password) {
         const response = await this.request('POST', '/auth/login', { email, password });
         if (response.token) {
-            this.setToken(response.token);
+            this.setTokens(response);
+        }
+        return response;
+    }
+
+    // Second login step, the challenge token comes from login
+    async verifyTwoFactor(challengeToken, code, recoveryCode = '') {
+        const response = await this.request('POST', '/auth/2fa/verify', {
+            challenge_token: challengeToken,
+            code,
+            recovery_code: recoveryCode,
+        });
+        this.setTokens(response);
+        return response;
+    }
+
+    // Admins without 2FA set it up during login
+    async setupTwoFactorLogin(challengeToken) {
+        return this.request('POST', '/auth/2fa/setup', { challenge_token: challengeToken });
+    }
+
+    async confirmTwoFactorLogin(challengeToken, code) {
+        const response = await this.request('POST', '/auth/2fa/setup/confirm', {
+            challenge_token: challengeToken,
+            code,
+        });
+        this.setTokens(response);
+        return response;
+    }
+
+    passkeysSupported() {
+        return !!window.PublicKeyCredential && !!navigator.credentials;
+    }
+
+    // Login with a passkey, the response is handled like the one of login (tokens or 2FA challenge)
+    async loginWithPasskey() {
+        const options = await this.request('POST', '/auth/passkey/begin');
+        const publicKey = { ...options.publicKey, challenge: base64urlToBuffer(options.publicKey.challenge) };
+        const credential = await navigator.credentials.get({ publicKey });
+
+        const response = await this.request('POST', '/auth/passkey/finish', {
+            credential: passkeyCredentialToJSON(credential),
+        });
+        if (response.token) {
+            this.setTokens(response);
+        }
+        return response;
+    }
+
+    async getMagicLinkStatus() {
+        return this.request('GET', '/auth/magic-link');
+    }
+
+    // The device token binds the link to this browser, it is kept until the link is used
+    async requestMagicLink(email) {
+        const response = await this.request('POST', '/auth/magic-link', { email });
+        localStorage.setItem('gassigeher_magic_link_device', response.device_token);
+        return response;
+    }
+
+    // Login with the token of an email link, the response is handled like the one of login
+    async loginWithMagicLink(token) {
+        const response = await this.request('POST', '/auth/magic-link/verify', {
+            token,
+            device_token: localStorage.getItem('gassigeher_magic_link_device') || '',
+        });
+        localStorage.removeItem('gassigeher_magic_link_device');
+        if (response.token) {
+            this.setTokens(response);
+        }
+        return response;
+    }
+
+    async getOIDCStatus() {
+        return this.request('GET', '/auth/oidc');
+    }
+
+    // The login at the identity provider is a browser redirect, it returns with a login code
+    startOIDCLogin() {
+        window.location.href = '/api/auth/oidc/login';
+    }
+
+    // Exchange the login code of the redirect back, the response is handled like the one of login
+    async loginWithOIDC(code) {
+        const response = await this.request('POST', '/auth/oidc/finish', { code });
+        if (response.token) {
+            this.setTokens(response);
         }
         return response;
     }
 
     async logout() {
+        const refreshToken = this.getRefreshToken();
+        if (refreshToken) {
+            try {
+                await this.request('POST', '/auth/logout', { refresh_token: refreshToken });
+            } catch (error) {
+                // Logged out locally anyway
+            }
+        }
+        this.setToken(null);
+        window.location.href = '/';
+    }
+
+    // End all logins of the user, on every device
+    async logoutAll() {
+        await this.request('POST', '/auth/logout-all');
         this.setToken(null);
         window.location.href = '/';
     }
@@ -124,12 +384,17 @@ class API {
         });
     }
 
+    // Other devices are logged out, this one continues with the returned tokens
     async changePassword(oldPassword, newPassword, confirmPassword) {
-        return this.request('PUT', '/auth/change-password', {
+        const response = await this.request('PUT', '/auth/change-password', {
             old_password: oldPassword,
             new_password: newPassword,
             confirm_password: confirmPassword,
         });
+        if (response.token) {
+            this.setTokens(response);
+        }
+        return response;
     }
 
     // USER ENDPOINTS
@@ -138,6 +403,16 @@ class API {
         return this.request('GET', '/users/me');
     }
 
+    // Whether the user (from getMe) holds the permission through one of their roles
+    hasPermission(user, permission) {
+        return !!user && (user.permissions || []).includes(permission);
+    }
+
+    // First admin page the user may open, null for users without staff permissions
+    adminHomePage(user) {
+        return Object.keys(ADMIN_PAGE_PERMISSIONS).find(page => this.hasPermission(user, ADMIN_PAGE_PERMISSIONS[page])) || null;
+    }
+
     async updateMe(data) {
         return this.request('PUT', '/users/me', data);
     }
@@ -148,6 +423,145 @@ class API {
         return this.uploadFile('/users/me/photo', formData);
     }
 
+    async getTwoFactorStatus() {
+        return this.request('GET', '/users/me/2fa');
+    }
+
+    async setupTwoFactor() {
+        return this.request('POST', '/users/me/2fa/setup');
+    }
+
+    async enableTwoFactor(code) {
+        return this.request('POST', '/users/me/2fa/enable', { code });
+    }
+
+    async disableTwoFactor(password, code, recoveryCode = '') {
+        return this.request('POST', '/users/me/2fa/disable', { password, code, recovery_code: recoveryCode });
+    }
+
+    async regenerateRecoveryCodes(code) {
+        return this.request('POST', '/users/me/2fa/recovery-codes', { code });
+    }
+
+    async getPasskeys() {
+        return this.request('GET', '/users/me/passkeys');
+    }
+
+    // Creates a passkey on this device (or a phone nearby) and stores it
+    async registerPasskey(name) {
+        const options = await this.request('POST', '/users/me/passkeys/register/begin');
+        const publicKey = {
+            ...options.publicKey,
+            challenge: base64urlToBuffer(options.publicKey.challenge),
+            user: { ...options.publicKey.user, id: base64urlToBuffer(options.publicKey.user.id) },
+            excludeCredentials: options.publicKey.excludeCredentials.map(credential => ({
+                ...credential,
+                id: base64urlToBuffer(credential.id)
+            }))
+        };
+        const credential = await navigator.credentials.create({ publicKey });
+
+        return this.request('POST', '/users/me/passkeys/register/finish', {
+            name,
+            credential: passkeyCredentialToJSON(credential),
+        });
+    }
+
+    async renamePasskey(id, name) {
+        return this.request('PUT', `/users/me/passkeys/${id}`, { name });
+    }
+
+    async deletePasskey(id) {
+        return this.request('DELETE', `/users/me/passkeys/${id}`);
+    }
+
+    // Logins of the user, the one of this browser has current set
+    async getSessions() {
+        return this.request('GET', '/users/me/sessions');
+    }
+
+    async revokeSession(id) {
+        return this.request('DELETE', `/users/me/sessions/${id}`);
+    }
+
+    async getNotificationPreferences() {
+        return this.request('GET', '/users/me/notification-preferences');
+    }
+
+    async updateNotificationPreferences(preferences) {
+        return this.request('PUT', '/users/me/notification-preferences', { preferences });
+    }
+
+    async getReminderSchedule() {
+        return this.request('GET', '/users/me/reminder-schedule');
+    }
+
+    async updateReminderSchedule(reminders) {
+        return this.request('PUT', '/users/me/reminder-schedule', { reminders });
+    }
+
+    async resetReminderSchedule() {
+        return this.request('DELETE', '/users/me/reminder-schedule');
+    }
+
+    async getNotificationChannels() {
+        return this.request('GET', '/users/me/notification-channels');
+    }
+
+    async createTelegramLink() {
+        return this.request('POST', '/users/me/telegram-link');
+    }
+
+    async deleteTelegramLink() {
+        return this.request('DELETE', '/users/me/telegram-link');
+    }
+
+    // PUSH ENDPOINTS
+
+    async getVAPIDPublicKey() {
+        return this.request('GET', '/push/vapid-public-key');
+    }
+
+    async getPushSubscriptions() {
+        return this.request('GET', '/users/me/push-subscriptions');
+    }
+
+    async createPushSubscription(subscription) {
+        return this.request('POST', '/users/me/push-subscriptions', subscription);
+    }
+
+    async deletePushSubscription(id) {
+        return this.request('DELETE', `/users/me/push-subscriptions/${id}`);
+    }
+
+    // NOTIFICATION CENTER ENDPOINTS
+
+    async getNotifications(unreadOnly = false) {
+        return this.request('GET', unreadOnly ? '/notifications?unread=true' : '/notifications');
+    }
+
+    async getUnreadNotificationCount() {
+        return this.request('GET', '/notifications/unread-count');
+    }
+
+    async markNotificationRead(id) {
+        return this.request('PUT', `/notifications/${id}/read`);
+    }
+
+    async markAllNotificationsRead() {
+        return this.request('PUT', '/notifications/read-all');
+    }
+
+    // UNSUBSCRIBE ENDPOINTS
+
+    async getUnsubscribeInfo(token) {
+        return this.request('GET', `/unsubscribe?token=${encodeURIComponent(token)}`);
+    }
+
+    async unsubscribe(token) {
+        return this.request('POST', '/unsubscribe', { token });
+    }
+
     // DOG ENDPOINTS
 
     async getDogs(filters = {}) {
@@ -307,6 +721,14 @@ class API {
         return this.request('PUT', `/users/${id}/activate`, { message });
     }
 
+    async getUserSessions(id) {
+        return this.request('GET', `/users/${id}/sessions`);
+    }
+
+    async revokeUserSession(id, sessionId) {
+        return this.request('DELETE', `/users/${id}/sessions/${sessionId}`);
+    }
+
     async promoteToAdmin(userId) {
         return this.request('POST', `/admin/users/${userId}/promote`);
     }
@@ -315,6 +737,104 @@ class API {
         return this.request('POST', `/admin/users/${userId}/demote`);
     }
 
+    // Super admin only, for users who lost their authenticator app
+    async resetUserTwoFactor(userId) {
+        return this.request('DELETE', `/admin/users/${userId}/2fa`);
+    }
+
+    async getUserTags() {
+        return this.request('GET', '/users/tags');
+    }
+
+    async updateUserTags(id, tags) {
+        return this.request('PUT', `/users/${id}/tags`, { tags });
+    }
+
+    // ANNOUNCEMENT ENDPOINTS
+
+    async getAnnouncements() {
+        return this.request('GET', '/admin/announcements');
+    }
+
+    async getAnnouncement(id) {
+        return this.request('GET', `/admin/announcements/${id}`);
+    }
+
+    async createAnnouncement(announcement) {
+        return this.request('POST', '/admin/announcements', announcement);
+    }
+
+    async previewAnnouncement(announcement) {
+        return this.request('POST', '/admin/announcements/preview', announcement);
+    }
+
+    async cancelAnnouncement(id) {
+        return this.request('DELETE', `/admin/announcements/${id}`);
+    }
+
+    // WEBHOOK ENDPOINTS
+
+    async getWebhooks() {
+        return this.request('GET', '/admin/webhooks');
+    }
+
+    async createWebhook(webhook) {
+        return this.request('POST', '/admin/webhooks', webhook);
+    }
+
+    async updateWebhook(id, webhook) {
+        return this.request('PUT', `/admin/webhooks/${id}`, webhook);
+    }
+
+    async deleteWebhook(id) {
+        return this.request('DELETE', `/admin/webhooks/${id}`);
+    }
+
+    async rotateWebhookSecret(id) {
+        return this.request('POST', `/admin/webhooks/${id}/rotate-secret`);
+    }
+
+    async getWebhookDeliveries(id) {
+        return this.request('GET', `/admin/webhooks/${id}/deliveries`);
+    }
+
+    // RUN SHEET ENDPOINTS
+
+    async getRunSheet(date = null) {
+        const query = date ? `?date=${encodeURIComponent(date)}` : '';
+        return this.request('GET', `/admin/run-sheet${query}`);
+    }
+
+    // Printable run sheet as HTML (not JSON, so request() cannot be used)
+    async getRunSheetHTML(date = null) {
+        await this.ensureFreshToken();
+        const headers = {};
+
+        if (this.token) {
+            headers['Authorization'] = `Bearer ${this.token}`;
+        }
+
+        if (window.i18n && window.i18n.locale) {
+            headers['Accept-Language'] = window.i18n.locale;
+        }
+
+        const params = new URLSearchParams({ format: 'html' });
+        if (date) {
+            params.set('date', date);
+        }
+
+        const response = await fetch(`${this.baseURL}/admin/run-sheet?${params}`, { headers });
+        if (!response.ok) {
+            const responseData = await response.json();
+            const error = new Error(responseData.error || 'Request failed');
+            error.status = response.status;
+            error.code = responseData.code;
+            throw error;
+        }
+
+        return response.text();
+    }
+
     // REACTIVATION REQUEST ENDPOINTS
 
     async createReactivationRequest(email) {
@@ -333,14 +853,107 @@ class API {
         return this.request('PUT', `/reactivation-requests/${id}/deny`, { message });
     }
 
+    // INVITATION AND REGISTRATION REQUEST ENDPOINTS
+
+    async getInvitations() {
+        return this.request('GET', '/invitations');
+    }
+
+    async createInvitation(data) {
+        return this.request('POST', '/invitations', data);
+    }
+
+    async revokeInvitation(id) {
+        return this.request('DELETE', `/invitations/${id}`);
+    }
+
+    async getRegistrationRequests() {
+        return this.request('GET', '/registration-requests');
+    }
+
+    async approveRegistrationRequest(id, message = null) {
+        return this.request('PUT', `/registration-requests/${id}/approve`, { message });
+    }
+
+    async denyRegistrationRequest(id, message = null) {
+        return this.request('PUT', `/registration-requests/${id}/deny`, { message });
+    }
+
     // ADMIN DASHBOARD ENDPOINTS
 
     async getAdminStats() {
         return this.request('GET', '/admin/stats');
     }
 
-    async getRecentActivity() {
-        return this.request('GET', '/admin/activity');
+    // filters: type, user_id, dog_id, date_from, date_to, limit, offset
+    async getRecentActivity(filters = {}) {
+        const params = new URLSearchParams(filters);
+        const endpoint = `/admin/activity${params.toString() ? '?' + params.toString() : ''}`;
+        return this.request('GET', endpoint);
+    }
+
+    // AUDIT LOG ENDPOINTS (super admin only)
+
+    async getAuditLog(filters = {}) {
+        const params = new URLSearchParams(filters);
+        const endpoint = `/admin/audit-log${params.toString() ? '?' + params.toString() : ''}`;
+        return this.request('GET', endpoint);
+    }
+
+    async verifyAuditLog() {
+        return this.request('GET', '/admin/audit-log/verify');
+    }
+
+    // Export as a file download (not JSON for csv, so request() cannot be used)
+    async exportAuditLog(format = 'csv', filters = {}) {
+        await this.ensureFreshToken();
+        const headers = {};
+
+        if (this.token) {
+            headers['Authorization'] = `Bearer ${this.token}`;
+        }
+
+        if (window.i18n && window.i18n.locale) {
+            headers['Accept-Language'] = window.i18n.locale;
+        }
+
+        const params = new URLSearchParams({ ...filters, format });
+        const response = await fetch(`${this.baseURL}/admin/audit-log/export?${params}`, { headers });
+        if (!response.ok) {
+            const responseData = await response.json();
+            const error = new Error(responseData.error || 'Request failed');
+            error.status = response.status;
+            error.code = responseData.code;
+            throw error;
+        }
+
+        return response.blob();
+    }
+
+    // ROLE ENDPOINTS (super admin only)
+
+    async getRoles() {
+        return this.request('GET', '/admin/roles');
+    }
+
+    async createRole(role) {
+        return this.request('POST', '/admin/roles', role);
+    }
+
+    async updateRole(id, role) {
+        return this.request('PUT', `/admin/roles/${id}`, role);
+    }
+
+    async deleteRole(id) {
+        return this.request('DELETE', `/admin/roles/${id}`);
+    }
+
+    async getUserRoles(userId) {
+        return this.request('GET', `/admin/users/${userId}/roles`);
+    }
+
+    async updateUserRoles(userId, roleIds) {
+        return this.request('PUT', `/admin/users/${userId}/roles`, { role_ids: roleIds });
     }
 
     // BOOKING TIME ENDPOINTS
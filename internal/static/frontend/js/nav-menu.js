@@ -29,11 +29,13 @@ document.addEventListener('click', function(e) {
     }
 });
 
-// Show admin area link if user is admin (for user pages)
+// Show admin area link if the user's roles grant access to an admin page (for user pages)
 function showAdminLinkIfAdmin(user) {
-    if (user && user.is_admin) {
+    const home = api.adminHomePage(user);
+    if (home) {
         const adminLink = document.getElementById('admin-area-link');
         if (adminLink) {
+            adminLink.querySelector('a').href = home;
             adminLink.style.display = 'list-item';
             // Update translations for the admin link after making it visible
             if (window.i18n && window.i18n.updateElement) {
@@ -43,8 +45,57 @@ function showAdminLinkIfAdmin(user) {
     }
 }
 
+// Hide admin navigation links the user's roles do not grant (for admin pages)
+function applyAdminPermissions(user) {
+    document.querySelectorAll('#main-nav a[href^="/admin-"]').forEach(link => {
+        const permission = ADMIN_PAGE_PERMISSIONS[link.getAttribute('href')];
+        if (permission && !api.hasPermission(user, permission)) {
+            link.style.display = 'none';
+        }
+    });
+
+    // Dropdowns without any remaining link
+    document.querySelectorAll('#main-nav .nav-dropdown').forEach(dropdown => {
+        const links = Array.from(dropdown.querySelectorAll('.nav-dropdown-menu a'));
+        if (links.length > 0 && links.every(link => link.style.display === 'none')) {
+            dropdown.style.display = 'none';
+        }
+    });
+}
+
 // User area link is always visible on admin pages (no special logic needed)
 
+// Add the notification center link with the unread count for logged-in users
+async function loadNotificationBadge() {
+    const list = document.querySelector('#main-nav ul');
+    if (!list || typeof api === 'undefined' || !api.isAuthenticated()) {
+        return;
+    }
+
+    let item = document.getElementById('notifications-link');
+    if (!item) {
+        item = document.createElement('li');
+        item.id = 'notifications-link';
+        item.innerHTML = '<a href="/notifications.html" style="position: relative;">🔔 <span data-i18n="nav.notifications">Benachrichtigungen</span> <span id="notifications-badge" style="display: none; background: #dc3545; color: white; border-radius: 10px; padding: 1px 7px; font-size: 0.75rem;"></span></a>';
+        const logout = list.querySelector('a[onclick*="logout"]');
+        // Inserted before i18n.load() finishes, so the link is translated with the page
+        list.insertBefore(item, logout ? logout.parentElement : null);
+    }
+
+    try {
+        const result = await api.getUnreadNotificationCount();
+        const badge = document.getElementById('notifications-badge');
+        badge.textContent = result.count > 99 ? '99+' : result.count;
+        badge.style.display = result.count > 0 ? 'inline-block' : 'none';
+    } catch (error) {
+        console.error('Failed to load unread notifications:', error);
+    }
+}
+
+document.addEventListener('DOMContentLoaded', () => {
+    loadNotificationBadge();
+});
+
 // Debug logging
 console.log('nav-menu.js loaded successfully');
 console.log('toggleMenu function:', typeof toggleMenu);
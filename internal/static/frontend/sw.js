@@ -0,0 +1,36 @@
+// Service worker for Web Push notifications
+// Served from the root so it controls all pages
+
+self.addEventListener('push', (event) => {
+    let message = { title: 'Gassigeher', body: '', url: '/dashboard.html' };
+    if (event.data) {
+        try {
+            message = Object.assign(message, event.data.json());
+        } catch (error) {
+            message.body = event.data.text();
+        }
+    }
+
+    event.waitUntil(
+        self.registration.showNotification(message.title, {
+            body: message.body,
+            data: { url: message.url }
+        })
+    );
+});
+
+self.addEventListener('notificationclick', (event) => {
+    event.notification.close();
+    const url = (event.notification.data && event.notification.data.url) || '/';
+
+    event.waitUntil(
+        self.clients.matchAll({ type: 'window', includeUncontrolled: true }).then((windows) => {
+            for (const client of windows) {
+                if (client.url.endsWith(url) && 'focus' in client) {
+                    return client.focus();
+                }
+            }
+            return self.clients.openWindow(url);
+        })
+    );
+});
@@ -68,6 +68,11 @@ func TestFrontendFS_ContainsAdminPages(t *testing.T) {
 		"admin-blocked-dates.html",
 		"admin-experience-requests.html",
 		"admin-reactivation-requests.html",
+		"admin-announcements.html",
+		"admin-webhooks.html",
+		"admin-activity.html",
+		"admin-roles.html",
+		"admin-audit-log.html",
 	}
 
 	for _, file := range adminPages {
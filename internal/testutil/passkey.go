@@ -0,0 +1,160 @@
+package testutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/tranmh/gassigeher/internal/models"
+)
+
+// Flags of the authenticator data
+const (
+	PasskeyUserPresent  = 0x01
+	PasskeyUserVerified = 0x04
+	passkeyAttested     = 0x40
+)
+
+// PasskeyAuthenticator is a software authenticator with an ES256 key for passkey tests
+// Change Origin, Flags or SignCount to test rejected responses.
+type PasskeyAuthenticator struct {
+	Origin       string
+	RPID         string
+	Flags        byte
+	SignCount    uint32
+	CredentialID []byte
+	UserHandle   string // base64url, set by Register
+	Key          *ecdsa.PrivateKey
+}
+
+// NewPasskeyAuthenticator creates an authenticator that verifies the user
+func NewPasskeyAuthenticator(t *testing.T, origin, rpID string) *PasskeyAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	credentialID := make([]byte, 16)
+	rand.Read(credentialID)
+
+	return &PasskeyAuthenticator{
+		Origin:       origin,
+		RPID:         rpID,
+		Flags:        PasskeyUserPresent | PasskeyUserVerified,
+		CredentialID: credentialID,
+		Key:          key,
+	}
+}
+
+// Register answers the options of navigator.credentials.create
+func (a *PasskeyAuthenticator) Register(t *testing.T, options *models.PasskeyCreationOptions) models.PasskeyCredential {
+	t.Helper()
+	a.UserHandle = options.User.ID
+
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	a.Key.X.FillBytes(x)
+	a.Key.Y.FillBytes(y)
+	coseKey := encodeCBOR(cborMap{{1, 2}, {3, -7}, {-1, 1}, {-2, x}, {-3, y}})
+
+	authData := a.authenticatorData(a.Flags | passkeyAttested)
+	authData = append(authData, make([]byte, 16)...) // AAGUID
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(a.CredentialID)))
+	authData = append(authData, a.CredentialID...)
+	authData = append(authData, coseKey...)
+
+	attestationObject := encodeCBOR(cborMap{{"fmt", "none"}, {"attStmt", cborMap{}}, {"authData", authData}})
+
+	credential := a.credential()
+	credential.Response.ClientDataJSON = a.clientData(t, "webauthn.create", options.Challenge)
+	credential.Response.AttestationObject = base64.RawURLEncoding.EncodeToString(attestationObject)
+	credential.Response.Transports = []string{"internal", "hybrid"}
+	return credential
+}
+
+// Login answers the options of navigator.credentials.get and increments the signature counter
+func (a *PasskeyAuthenticator) Login(t *testing.T, options *models.PasskeyRequestOptions) models.PasskeyCredential {
+	t.Helper()
+	a.SignCount++
+	authData := a.authenticatorData(a.Flags)
+	clientData := a.clientData(t, "webauthn.get", options.Challenge)
+
+	rawClientData, _ := base64.RawURLEncoding.DecodeString(clientData)
+	clientDataHash := sha256.Sum256(rawClientData)
+	digest := sha256.Sum256(append(authData, clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.Key, digest[:])
+	if err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+
+	credential := a.credential()
+	credential.Response.ClientDataJSON = clientData
+	credential.Response.AuthenticatorData = base64.RawURLEncoding.EncodeToString(authData)
+	credential.Response.Signature = base64.RawURLEncoding.EncodeToString(signature)
+	credential.Response.UserHandle = a.UserHandle
+	return credential
+}
+
+func (a *PasskeyAuthenticator) credential() models.PasskeyCredential {
+	id := base64.RawURLEncoding.EncodeToString(a.CredentialID)
+	return models.PasskeyCredential{ID: id, RawID: id, Type: "public-key"}
+}
+
+func (a *PasskeyAuthenticator) authenticatorData(flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.RPID))
+	data := append(rpIDHash[:], flags)
+	return binary.BigEndian.AppendUint32(data, a.SignCount)
+}
+
+func (a *PasskeyAuthenticator) clientData(t *testing.T, ceremonyType, challenge string) string {
+	t.Helper()
+	data, err := json.Marshal(map[string]interface{}{"type": ceremonyType, "challenge": challenge, "origin": a.Origin, "crossOrigin": false})
+	if err != nil {
+		t.Fatalf("Failed to encode client data: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// cborMap is a CBOR map with its entries in encoding order
+type cborMap [][2]interface{}
+
+// encodeCBOR encodes the integers, strings and maps authenticators send
+func encodeCBOR(value interface{}) []byte {
+	head := func(major byte, argument uint64) []byte {
+		switch {
+		case argument < 24:
+			return []byte{major<<5 | byte(argument)}
+		case argument < 1<<8:
+			return []byte{major<<5 | 24, byte(argument)}
+		case argument < 1<<16:
+			return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(argument))
+		default:
+			return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(argument))
+		}
+	}
+
+	switch v := value.(type) {
+	case int:
+		if v < 0 {
+			return head(1, uint64(-1-v))
+		}
+		return head(0, uint64(v))
+	case []byte:
+		return append(head(2, uint64(len(v))), v...)
+	case string:
+		return append(head(3, uint64(len(v))), v...)
+	case cborMap:
+		data := head(5, uint64(len(v)))
+		for _, entry := range v {
+			data = append(data, encodeCBOR(entry[0])...)
+			data = append(data, encodeCBOR(entry[1])...)
+		}
+		return data
+	}
+	panic("unsupported CBOR value")
+}